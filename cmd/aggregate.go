@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"oracle-n-plus-1-demo/internal/aggregate"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// runAggregate - aggregateコマンド（複数回分の結果ファイルから推移と回帰を集計）
+func runAggregate(args []string) error {
+	fs := flag.NewFlagSet("aggregate", flag.ContinueOnError)
+	window := fs.Int("window", aggregate.DefaultWindow, "移動平均と回帰判定に使う直前の実行回数")
+	threshold := fs.Float64("threshold", aggregate.DefaultThreshold, "回帰とみなす移動平均からの悪化率（%）")
+	jsonPath := fs.String("json", "", "集計結果をJSONファイルに出力する")
+	failOnRegression := fs.Bool("fail-on-regression", false, "回帰を検出した場合に終了コード2で終了する")
+	tableOptions := addTableFlags(fs, "method, runs, mean, min, max, stddev, moving_average, latest, change, verdict")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	opts := tableOptions()
+	if err := newTrendTable().Check(opts); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("結果ファイルのディレクトリを1つ指定してください")
+	}
+
+	runs, skipped, err := aggregate.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return fmt.Errorf("%s に計測結果のJSONがありません", fs.Arg(0))
+	}
+
+	summary := aggregate.Analyze(runs, aggregate.Options{Window: *window, Threshold: *threshold})
+	summary.Skipped = skipped
+	if err := displayAggregateReport(summary, opts); err != nil {
+		return err
+	}
+
+	if *jsonPath != "" {
+		jsonData, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return fmt.Errorf("JSON変換エラー: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, jsonData, 0o644); err != nil {
+			return fmt.Errorf("集計結果の書き込みに失敗: %w", err)
+		}
+		fmt.Printf("\n集計結果を出力しました: %s\n", *jsonPath)
+	}
+
+	if regressions := summary.Regressions(); *failOnRegression && len(regressions) > 0 {
+		return fmt.Errorf("%w: %d件の手法で回帰を検出しました", errRegression, len(regressions))
+	}
+
+	return nil
+}
+
+// displayAggregateReport - シナリオごとに手法の推移を表示
+func displayAggregateReport(summary *aggregate.Report, opts report.TableOptions) error {
+	w := report.Stdout()
+	w.Line("=== 計測結果の推移 ===")
+	w.Linef("対象: %d件（%s 〜 %s）", len(summary.Files),
+		summary.From.Format("2006-01-02 15:04"), summary.To.Format("2006-01-02 15:04"))
+	if len(summary.Skipped) > 0 {
+		w.Linef("計測結果を含まないため除外: %d件", len(summary.Skipped))
+	}
+	w.Linef("移動平均: 直近%d回, 回帰判定: 最新が直前%d回の平均より%.0f%%以上遅い",
+		summary.Window, summary.Window, summary.Threshold)
+
+	var table *report.Table
+	flush := func() error {
+		if table == nil {
+			return nil
+		}
+		if err := table.Apply(opts); err != nil {
+			return err
+		}
+		w.Table(table)
+		return nil
+	}
+
+	scenario := ""
+	for _, t := range summary.Trends {
+		if table == nil || t.Scenario != scenario {
+			if err := flush(); err != nil {
+				return err
+			}
+			scenario = t.Scenario
+			w.Blank()
+			w.Linef("[%s]", scenario)
+			table = newTrendTable()
+		}
+
+		change := report.Text("-")
+		if t.Baseline > 0 {
+			change = report.Float("%+.1f%%", t.ChangePercent)
+		}
+		verdict := ""
+		if t.Regression {
+			verdict = "⚠️ 回帰"
+		}
+		table.AddRow(
+			report.Text(t.Method),
+			report.Int(int64(t.Runs)),
+			report.Duration(t.Mean),
+			report.Duration(t.Min),
+			report.Duration(t.Max),
+			report.Duration(t.StdDev),
+			report.Duration(t.Points[len(t.Points)-1].MovingAverage),
+			report.Duration(t.Latest),
+			change,
+			report.Text(verdict))
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	regressions := summary.Regressions()
+	w.Blank()
+	if len(regressions) == 0 {
+		w.Line("回帰は検出されませんでした")
+		return nil
+	}
+	w.Linef("⚠️ %d件の手法で回帰を検出しました:", len(regressions))
+	for _, t := range regressions {
+		w.Indentf(1, "- %s / %s: %v → %v（%+.1f%%）", t.Scenario, t.Method, t.Baseline, t.Latest, t.ChangePercent)
+	}
+	return nil
+}
+
+// newTrendTable - 手法ごとの推移の表（列定義のみ）
+func newTrendTable() *report.Table {
+	return report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "runs", Header: "回数", Align: report.AlignRight},
+		report.Column{Key: "mean", Header: "平均", Align: report.AlignRight},
+		report.Column{Key: "min", Header: "最小", Align: report.AlignRight},
+		report.Column{Key: "max", Header: "最大", Align: report.AlignRight},
+		report.Column{Key: "stddev", Header: "標準偏差", Align: report.AlignRight},
+		report.Column{Key: "moving_average", Header: "移動平均", Align: report.AlignRight},
+		report.Column{Key: "latest", Header: "最新", Align: report.AlignRight},
+		report.Column{Key: "change", Header: "変化率", Align: report.AlignRight},
+		report.Column{Key: "verdict", Header: "判定"},
+	)
+}
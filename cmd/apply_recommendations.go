@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"oracle-n-plus-1-demo/internal/cache"
+	"oracle-n-plus-1-demo/internal/presenter"
+)
+
+// runApplyRecommendations - apply-recommendationsコマンド（推奨事項の修正スクリプトを出力・適用）
+func runApplyRecommendations(args []string) error {
+	fs := flag.NewFlagSet("apply-recommendations", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", true, "修正スクリプトを出力するだけで実行しない（-dry-run=false で自動適用可能な文のみ実行）")
+	from := fs.String("from", "", "キャッシュ分析結果のJSON（省略時はキャッシュ分析を実行する）")
+	runs := fs.Int("runs", 3, "キャッシュ分析を実行する場合の実行回数")
+	fkAllTables := fs.Bool("fk-all-tables", false, "キャッシュ分析を実行する場合に、外部キーの索引をスキーマのすべてのテーブルについて確認する")
+	save := fs.String("save", "", "実行したキャッシュ分析の結果をJSONファイルに保存する（次回は -from で再利用）")
+	output := fs.String("o", "", "修正スクリプトの出力先（省略時は標準出力）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	recs, appUser, err := loadRecommendations(*from, *runs, *save, *fkAllTables)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		script := cache.RemediationScript(recs, appUser)
+		if *output == "" {
+			fmt.Print(script)
+			return nil
+		}
+		if err := os.WriteFile(*output, []byte(script), 0o644); err != nil {
+			return fmt.Errorf("修正スクリプトの書き込みに失敗: %w", err)
+		}
+		fmt.Printf("修正スクリプトを出力しました: %s\n", *output)
+		return nil
+	}
+
+	return applyAutoFixes(recs)
+}
+
+// loadRecommendations - 分析結果ファイルまたはキャッシュ分析の実行から推奨事項を取得
+func loadRecommendations(from string, runs int, save string, fkAllTables bool) ([]cache.Recommendation, string, error) {
+	if from != "" {
+		data, err := os.ReadFile(from)
+		if err != nil {
+			return nil, "", fmt.Errorf("分析結果の読み込みに失敗: %w", err)
+		}
+		var results cache.AnalysisResults
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, "", fmt.Errorf("分析結果の解析に失敗: %w", err)
+		}
+		return results.Recommendations, "", nil
+	}
+
+	cfg, db, err := openDatabase()
+	if err != nil {
+		return nil, "", err
+	}
+	defer closeDatabase(db)
+
+	analyzer := cache.NewPerformanceAnalyzer(db)
+	analyzer.SetForeignKeyScope(fkAllTables)
+	results, err := analyzer.PerformComprehensiveAnalysis(runs)
+	if err != nil {
+		return nil, "", fmt.Errorf("キャッシュ分析に失敗しました: %w", err)
+	}
+
+	// 分析結果はスクリプトと混ざらないよう標準エラーに出す
+	p, err := presenter.New(presenter.FormatText, os.Stderr, presenter.Options{})
+	if err != nil {
+		return nil, "", err
+	}
+	if err := p.Analysis(results); err != nil {
+		return nil, "", err
+	}
+
+	if save != "" {
+		jsonData, err := analyzer.ExportAnalysisResults()
+		if err != nil {
+			return nil, "", err
+		}
+		if err := os.WriteFile(save, []byte(jsonData), 0o644); err != nil {
+			return nil, "", fmt.Errorf("分析結果の書き込みに失敗: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "分析結果を保存しました: %s\n", save)
+	}
+
+	return results.Recommendations, cfg.DBUsername, nil
+}
+
+// applyAutoFixes - 自動適用できる推奨事項のSQLを実行
+func applyAutoFixes(recs []cache.Recommendation) error {
+	stmts := cache.AutoFixStatements(recs)
+	if len(stmts) == 0 {
+		fmt.Println("自動適用できる推奨事項はありません（-dry-run で修正スクリプトを確認してください）")
+		return nil
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	for _, stmt := range stmts {
+		fmt.Printf("実行: %s\n", stmt)
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to apply %q: %w", stmt, err)
+		}
+	}
+	fmt.Printf("%d件の文を適用しました\n", len(stmts))
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package main
+
+// -cache-backends で指定できる独自のキャッシュ実装
+//
+// pkg/cache.Register を init で呼び出すパッケージをここに blank import すると、登録名で指定できるようになる。
+// timesten を使う場合は、TimesTen に接続できる database/sql ドライバー（ODBCドライバーなど）もここに blank import する。
+import (
+	_ "oracle-n-plus-1-demo/pkg/cache/coherence"
+	_ "oracle-n-plus-1-demo/pkg/cache/groupcache"
+	_ "oracle-n-plus-1-demo/pkg/cache/memory"
+	_ "oracle-n-plus-1-demo/pkg/cache/timesten"
+)
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/bundle"
+	"oracle-n-plus-1-demo/internal/runmeta"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// redactedFlags - 実行の記録に値を残さないフラグ（送信先のURLは署名付きURLや認証情報を含み得る）
+var redactedFlags = map[string]bool{"sink": true}
+
+// bundleConfig - アーカイブに含める実行時の設定（パスワードや送信先のURLは含めない）
+type bundleConfig struct {
+	Args       []string              `json:"args"`
+	Parameters service.RunParameters `json:"parameters"`
+	Metadata   *runmeta.Metadata     `json:"metadata,omitempty"`
+}
+
+// runBundle - -bundle 指定時の実行の記録（コンソール出力と実行計画の起点）
+type runBundle struct {
+	path       string
+	transcript *bundle.Transcript
+	since      time.Time
+	sinceErr   error
+}
+
+// startBundle - コンソール出力の記録を開始（ログも同じ記録に加える）
+func startBundle(path string) (*runBundle, error) {
+	if err := bundle.ValidatePath(path); err != nil {
+		return nil, err
+	}
+	transcript, err := bundle.StartTranscript()
+	if err != nil {
+		return nil, err
+	}
+	log.SetOutput(io.MultiWriter(os.Stderr, transcript))
+	return &runBundle{path: path, transcript: transcript}, nil
+}
+
+// markStart - 実行計画を集める起点としてデータベースの現在時刻を記録
+func (b *runBundle) markStart(db *sql.DB) {
+	if b == nil {
+		return
+	}
+	b.since, b.sinceErr = bundle.DBTime(db)
+}
+
+// stop - 記録を終えて標準出力とログの出力先を戻す（記録した内容を返す）
+func (b *runBundle) stop() []byte {
+	data := b.transcript.Stop()
+	log.SetOutput(os.Stderr)
+	return data
+}
+
+// write - コンソール出力・計測結果・実行計画・設定をアーカイブにまとめる（中断時と終了時の両方から呼ばれる）
+func (b *runBundle) write(demoService *service.DemoService, db *sql.DB, meta *runmeta.Metadata, params service.RunParameters, sign bool) {
+	if b == nil {
+		return
+	}
+
+	results, err := demoService.MarshalResults(meta, params)
+	if err != nil {
+		log.Printf("計測結果の変換中にエラー: %v", err)
+	}
+	var plans []bundle.Plan
+	planErr := b.sinceErr
+	if planErr == nil {
+		plans, planErr = bundle.CollectPlans(db, b.since, bundle.DefaultMaxPlans)
+	}
+	config, err := json.MarshalIndent(bundleConfig{Args: redactArgs(os.Args[1:]), Parameters: params, Metadata: meta}, "", "  ")
+	if err != nil {
+		log.Printf("設定の変換中にエラー: %v", err)
+	}
+
+	files := []bundle.File{{Name: bundle.TranscriptFile, Data: b.stop()}}
+	if results != nil {
+		files = append(files, bundle.File{Name: bundle.ResultsFile, Data: results})
+	}
+	files = append(files, bundle.File{Name: bundle.PlansFile, Data: bundle.FormatPlans(plans, planErr)})
+	if config != nil {
+		files = append(files, bundle.File{Name: bundle.ConfigFile, Data: config})
+	}
+
+	if err := bundle.Write(b.path, files, time.Now()); err != nil {
+		log.Printf("実行の記録の出力中にエラー: %v", err)
+		return
+	}
+	fmt.Printf("実行の記録をまとめました: %s\n", b.path)
+	signExport(sign, b.path)
+}
+
+// redactArgs - 記録に残さないフラグの値を伏せたコマンドライン引数
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 0; i < len(redacted); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(redacted[i], "-"), "=")
+		if !strings.HasPrefix(redacted[i], "-") || !redactedFlags[name] {
+			continue
+		}
+		if hasValue {
+			redacted[i] = redacted[i][:strings.Index(redacted[i], "=")+1] + "REDACTED"
+		} else if i+1 < len(redacted) {
+			i++
+			redacted[i] = "REDACTED"
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/convcheck"
+	"oracle-n-plus-1-demo/internal/schema"
+)
+
+// runCheckConversions - check-conversionsコマンド（バインド変数と列の型の不一致による暗黙の型変換を検出）
+func runCheckConversions(args []string) error {
+	fs := flag.NewFlagSet("check-conversions", flag.ContinueOnError)
+	workload := fs.String("workload", "", "検査するクエリとバインドの型を記述したJSONファイル（省略時はデモのクエリ）")
+	live := fs.Bool("live", false, "列の型と索引を実スキーマ（USER_TAB_COLUMNS・USER_IND_COLUMNS）から取得する（省略時は期待スキーマ）")
+	showInfo := fs.Bool("info", false, "INFOレベル（バインド側の変換など）と型を判定できなかった列も表示する")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	queries := convcheck.DemoWorkload()
+	if *workload != "" {
+		loaded, err := convcheck.LoadWorkload(*workload)
+		if err != nil {
+			return err
+		}
+		queries = loaded
+	}
+
+	catalog := convcheck.ExpectedCatalog()
+	source := "期待スキーマ（scripts/ddl/create_tables.sql）"
+	if *live {
+		_, db, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer closeDatabase(db)
+
+		catalog, err = convcheck.LoadCatalog(db, convcheck.Tables(queries))
+		if err != nil {
+			return fmt.Errorf("列の型の取得に失敗しました: %w", err)
+		}
+		source = "実スキーマ"
+	}
+
+	report := convcheck.Analyze(queries, catalog)
+	displayConversionReport(report, source, *showInfo)
+
+	if report.HasProblems() {
+		return errors.New("索引が使えなくなる、または実行時エラーになる型の組み合わせがあります")
+	}
+	return nil
+}
+
+// displayConversionReport - 暗黙の型変換の検査結果を表示
+func displayConversionReport(report *convcheck.Report, source string, showInfo bool) {
+	fmt.Println("=== 暗黙の型変換レポート ===")
+	fmt.Printf("クエリ数: %d, 判定したバインド変数: %d（列の型: %s）\n\n", report.Queries, report.Checked, source)
+
+	for _, f := range report.Findings {
+		if f.Severity == schema.SeverityInfo && !showInfo {
+			continue
+		}
+		target := f.Column
+		if target == "" {
+			target = "-"
+		}
+		fmt.Printf("[%-5s] %s %s（位置%d, %s）→ %s: %s\n", f.Severity, f.Query, f.Bind, f.Position, f.BindType, target, f.Message)
+		if f.Conversion != "" && f.Conversion != "-" {
+			fmt.Printf("        実行計画の述語: %s\n", f.Conversion)
+		}
+	}
+	if showInfo && len(report.Unresolved) > 0 {
+		fmt.Println("\n型を判定できなかった列（カタログにない表・別名・副問合せの列）:")
+		for _, u := range report.Unresolved {
+			fmt.Printf("  %s\n", u)
+		}
+	}
+
+	fmt.Printf("\nERROR: %d件, WARN: %d件, INFO: %d件\n",
+		report.Count(schema.SeverityError), report.Count(schema.SeverityWarning), report.Count(schema.SeverityInfo))
+	if !report.HasProblems() {
+		fmt.Println("索引が使えなくなる暗黙の型変換はありません。")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/redis/go-redis/v9"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/service"
+	"oracle-n-plus-1-demo/internal/teardown"
+)
+
+// runCleanup - cleanupコマンド（デモが作成したオブジェクトとRedisキーを削除）
+func runCleanup(args []string) error {
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", true, "削除対象を表示するだけで削除しない（-dry-run=false で削除）")
+	skipRedis := fs.Bool("skip-redis", false, "Redisのデモ用キーを削除しない")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	var redisClient *redis.Client
+	if !*skipRedis {
+		redisClient, err = config.ConnectRedis(cfg)
+		if err != nil {
+			fmt.Printf("Redis接続に失敗しました（Redisのキーは削除しません）: %v\n", err)
+		}
+		if redisClient != nil {
+			defer func() {
+				if err := redisClient.Close(); err != nil {
+					fmt.Printf("redis client Close() failed: %v\n", err)
+				}
+			}()
+		}
+	}
+
+	ctx := context.Background()
+	planner := teardown.NewPlanner(db, redisClient, service.PLSQLFunctionMarker, service.RedisDemoKeyPatterns)
+	plan, err := planner.Plan(ctx)
+	if err != nil {
+		return fmt.Errorf("削除対象の確認に失敗しました: %w", err)
+	}
+
+	displayCleanupPlan(plan, redisClient != nil)
+	if plan.Empty() {
+		fmt.Println("\n削除するものはありません。")
+		return nil
+	}
+	if *dryRun {
+		fmt.Println("\n-dry-run=false を指定すると上記を削除します（表のデータも削除され、元に戻せません）。")
+		return nil
+	}
+
+	fmt.Println("\n=== 削除 ===")
+	result, err := teardown.Execute(ctx, db, redisClient, plan, func(stmt string) {
+		fmt.Printf("実行: %s\n", stmt)
+	})
+	fmt.Printf("\nオブジェクト%d件、Redisキー%d件を削除しました\n", len(result.Dropped), result.RedisKeys)
+	for _, failure := range result.Failures {
+		fmt.Printf("  失敗: %s\n", failure)
+	}
+	if err != nil {
+		return fmt.Errorf("一部を削除できませんでした（再実行すると残りを削除します）: %w", err)
+	}
+	return nil
+}
+
+// displayCleanupPlan - 削除対象を表示
+func displayCleanupPlan(plan *teardown.Plan, redisConnected bool) {
+	fmt.Println("=== 削除対象 ===")
+	fmt.Printf("Oracle（%d件）:\n", len(plan.Objects))
+	for _, o := range plan.Objects {
+		fmt.Printf("  %-18s %s\n", o.Type, o.Name)
+	}
+
+	if !redisConnected {
+		fmt.Println("Redis: 対象外")
+		return
+	}
+	fmt.Printf("Redis（%d件）:\n", plan.RedisKeyCount())
+	patterns := make([]string, 0, len(plan.RedisKeys))
+	for pattern := range plan.RedisKeys {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		fmt.Printf("  %-32s %d件\n", pattern, len(plan.RedisKeys[pattern]))
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/coldread"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+// runColdRead - cold-readコマンド（バッファキャッシュにない状態からの読み取りと2回目以降の読み取りを比較）
+func runColdRead(args []string) error {
+	defaults := coldread.DefaultConfig()
+	fs := flag.NewFlagSet("cold-read", flag.ContinueOnError)
+	orders := fs.Int("orders", defaults.Orders, "読む受注の件数（受注IDの大きい順。明細はその受注のものすべて）")
+	runs := fs.Int("runs", defaults.Runs, "2回目以降（ウォーム）の実行回数")
+	mode := fs.String("mode", defaults.Mode, "コールドな状態の作り方（"+strings.Join(coldread.Modes, ", ")+"）")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg := coldread.Config{Orders: *orders, Runs: *runs, Mode: *mode}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	result, err := coldread.Run(context.Background(), db, cfg)
+	if err != nil {
+		return fmt.Errorf("計測に失敗しました: %w", err)
+	}
+	displayColdReadReport(result)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal cold read report: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// displayColdReadReport - コールドとウォームの読み取りの比較と、ファイルシステムキャッシュについての注意を表示
+func displayColdReadReport(r *coldread.Report) {
+	w := report.Stdout()
+	w.Heading(fmt.Sprintf("コールドとウォームの読み取り（受注%d件とその明細、ウォーム%d回）", r.Orders, r.Runs))
+	switch r.Mode {
+	case coldread.ModeFlush:
+		w.Line("コールドな状態: ALTER SYSTEM FLUSH BUFFER_CACHE でバッファキャッシュを空にしてから元の表を読みました")
+	case coldread.ModeFreshSegment:
+		w.Line("コールドな状態: 受注と明細をCREATE TABLE AS SELECTで複製し、一度も読まれていない表を読みました（複製は削除済み）")
+		if r.FlushError != "" {
+			w.Linef("バッファキャッシュをフラッシュできなかったため切り替えました: %s", r.FlushError)
+		}
+	}
+
+	table := report.NewTable(
+		report.Column{Key: "phase", Header: "読み取り"},
+		report.Column{Key: "elapsed", Header: "実行時間", Align: report.AlignRight},
+		report.Column{Key: "rows", Header: "行数", Align: report.AlignRight},
+		report.Column{Key: "logical", Header: "論理読み取り", Align: report.AlignRight},
+		report.Column{Key: "physical", Header: "物理読み取り", Align: report.AlignRight},
+		report.Column{Key: "direct", Header: "うちダイレクト", Align: report.AlignRight},
+		report.Column{Key: "hit_ratio", Header: "ヒット率", Align: report.AlignRight},
+		report.Column{Key: "read_wait", Header: "読み取り待機/回", Align: report.AlignRight},
+	)
+	for _, phase := range []struct {
+		label string
+		phase coldread.Phase
+	}{
+		{"コールド（1回目）", r.Cold},
+		{"ウォーム（中央値）", r.Warm},
+	} {
+		p := phase.phase
+		logical, physical, direct, hitRatio := report.Text("-"), report.Text("-"), report.Text("-"), report.Text("-")
+		if p.Stats != nil {
+			logical = report.Int(p.Stats[sessionstats.LogicalReads])
+			physical = report.Int(p.Stats[sessionstats.PhysicalReads])
+			direct = report.Int(p.Stats[sessionstats.PhysicalReadsDirect])
+			if ratio, ok := p.HitRatio(); ok {
+				hitRatio = report.Number(fmt.Sprintf("%.1f%%", ratio), ratio)
+			}
+		}
+		readWait := report.Text("-")
+		if avg, ok := p.AvgReadWait(); ok {
+			readWait = report.Duration(avg.Round(time.Microsecond))
+		}
+		table.AddRow(
+			report.Text(phase.label),
+			report.Duration(p.Elapsed.Round(time.Microsecond)),
+			report.Int(int64(p.Rows)),
+			logical, physical, direct, hitRatio, readWait)
+	}
+	w.Table(table)
+	if r.StatsUnavailable {
+		w.Line("V$MYSTATを参照できないため、読み取りブロック数とヒット率は表示しません（SELECT権限が必要です）")
+	}
+	if r.WaitsUnavailable {
+		w.Line("V$SESSION_EVENTを参照できないため、読み取り待機は表示しません（SELECT権限が必要です）")
+	}
+
+	w.Blank()
+	w.Linef("ウォームの読み取りはコールドの %.1f 倍速く実行されました。", r.Speedup())
+	w.Line("キャッシュのヒット率や高速化は、この差を基準に評価してください（すでに温まった状態同士の比較では、キャッシュがない場合のコストは見えません）。")
+	if r.Warm.Stats[sessionstats.PhysicalReadsDirect] > 0 {
+		w.Line("ウォームでもダイレクト・パス読み取りが発生しています。大きな表の全表スキャンはバッファキャッシュを経由しないため、繰り返しても速くなりません。")
+	}
+
+	w.Blank()
+	w.Line("物理読み取りはバッファキャッシュになかったブロックの数で、OSのファイルシステムキャッシュから返った読み取りも含みます。")
+	if r.FilesystemCacheLikely() {
+		avg, _ := r.Cold.AvgReadWait()
+		w.Linef("コールドの読み取り待機が1回あたり %v と短く（目安: %v 未満）、ディスクではなくファイルシステムキャッシュやストレージのキャッシュから返った可能性があります。",
+			avg.Round(time.Microsecond), coldread.FastReadThreshold)
+		w.Line("ディスクからの読み取りを計測するには、ダイレクトI/O（filesystemio_options=SETALL）やASMを使うか、OSのページキャッシュを空にしてから実行してください。")
+	}
+	if r.FilesystemIO != "" {
+		w.Linef("filesystemio_options: %s", r.FilesystemIO)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// command - サブコマンド定義
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+// commands - 利用可能なサブコマンド一覧
+var commands = []command{
+	{name: "verify-schema", description: "実スキーマと期待スキーマの差分（ドリフト）を検出する", run: runVerifySchema},
+	{name: "serve", description: "顧客サマリーAPI（GET /customers/{id}/summary）を起動する", run: runServe},
+	{name: "loadtest", description: "顧客サマリーAPIに負荷をかけてN+1・集計SQL・Redisキャッシュを比較する", run: runLoadTest},
+	{name: "verify", description: "エクスポートした結果ファイルの署名（HMAC-SHA256）を検証する", run: runVerify},
+	{name: "aggregate", description: "複数回分の結果ファイルから推移・移動平均・回帰を集計する", run: runAggregate},
+	{name: "apply-recommendations", description: "キャッシュ分析の推奨事項から修正SQLスクリプトを出力する（-dry-run=false で自動適用可能な文を実行）", run: runApplyRecommendations},
+	{name: "matrix", description: "環境（-env）ごとの結果を手法別に並べて比較する", run: runMatrix},
+	{name: "multi-pdb", description: "複数のPDB/サービスで同じ計測を順に実行し、サービス間の比較表を表示する", run: runMultiPDB},
+	{name: "consumer-groups", description: "リソース・マネージャのコンシューマ・グループ（サービスで割り当て）ごとに計測し、CPUの上限によるN+1と一括取得の差の変化を比較する", run: runConsumerGroups},
+	{name: "failover", description: "計測中にセッションを切断し、再実行の有無による手法ごとの回復（やり直す処理と回復時間）を比較する（ALTER SYSTEM権限が必要）", run: runFailover},
+	{name: "inlist-bench", description: "IN句のバインド数（10/100/1000）・一時表の結合・配列バインドのパースと実行のコストを比較する", run: runInListBench},
+	{name: "invalidation-bench", description: "Redisのキャッシュのまとめての無効化を、バージョン番号の更新とキーの走査（SCAN + DEL）で比較する", run: runInvalidationBench},
+	{name: "pubsub-invalidation", description: "受注の更新をRedis Pub/Subで通知してローカルキャッシュを無効化し、伝播遅延と古い値を返す期間をResult Cacheと比較する", run: runPubSubInvalidation},
+	{name: "cqn-invalidation", description: "受注と明細のクエリをContinuous Query Notificationに登録し、Oracleの通知でローカルキャッシュを無効化するまでの伝播遅延と古い値を返す期間を計測する", run: runCQNInvalidation},
+	{name: "aq-enrichment", description: "受注への明細の付加を同期的なN+1とAdvanced Queuingによる非同期処理で行い、呼び出し側の応答時間・エンドツーエンドの時間・スループットを比較する", run: runAQEnrichment},
+	{name: "batch-consistency", description: "受注の明細と金額を同時に更新しながら各手法で読み、受注と明細を別々の時点から組み合わせていないか（Batch_OptimizedとAS OF SCNで固定したバッチ取得・JOIN）を検証する", run: runBatchConsistency},
+	{name: "clustering-factor", description: "直近の受注の明細を受注IDの順・無作為な順のヒープ表とIOTに複製し、クラスタリング・ファクターとJOIN・バッチ取得の実行時間と論理読み取りを比較する", run: runClusteringFactor},
+	{name: "storage-options", description: "直近の受注の明細を無作為な順に非圧縮・BASIC圧縮・OLTP圧縮・属性クラスタリングの表へ読み込み、サイズとJOIN・バッチ取得の実行時間と論理読み取りを比較する", run: runStorageOptions},
+	{name: "profile", description: "profile sql: 指定した問い合わせ（ファイル・標準入力・-sql、バインド変数付き）を繰り返し実行し、実行時間の統計・セッション統計・実行計画・キャッシュの効果を表示する", run: runProfile},
+	{name: "detect-nplus1", description: "カーソルキャッシュ（V$SQLAREA）からキー1つで少数の行を繰り返し読む文を検出し、生成元（GORM・ent・sqlc・手書き）に合わせた直し方（Preload・With・dataloaderなど）を示す", run: runDetectNPlus1},
+	{name: "compare-clients", description: "同じ受注明細のN+1とJOINをGo・JDBC・python-oracledbの参照実装で計測し、実行時間と実行した文の数を並べて言語・ドライバーによらないことを示す", run: runCompareClients},
+	{name: "config", description: "config validate: 設定値ごとの読み込み元（環境変数・.env・既定値）を表示し、値の誤り・名前解決・リスナー・サービス・Redisへの接続を確認して直し方を示す", run: runConfig},
+	{name: "cold-read", description: "バッファキャッシュにない状態（フラッシュまたは新しいセグメント）からの読み取りと2回目以降を比べ、物理読み取りとキャッシュの効果の基準を示す", run: runColdRead},
+	{name: "groupcache-peer", description: "-cache-backends=groupcache が起動するピアプロセス（内部用。起動したプロセスが終了すると終了する）", run: runGroupcachePeer},
+	{name: "setup", description: "不足しているデモの表・索引・シーケンス・マテリアライズドビューを作成し、既存のものを定義のチェックサムで検証する", run: runSetup},
+	{name: "seed", description: "不足しているデモの表・索引を作成し、指定した件数の部署・社員・受注・明細などの合成データを配列バインドで一括投入する", run: runSeed},
+	{name: "cleanup", description: "デモが作成した表・索引・PL/SQL関数・マテリアライズドビュー・Redisキーを削除する（-dry-run=false で実行）", run: runCleanup},
+	{name: "export-sql", description: "各シナリオのクエリを計測・実行計画付きでSQL*Plus/SQLclから再実行できる.sqlスクリプトとして出力する", run: runExportSQL},
+	{name: "check-conversions", description: "クエリのバインド変数と列の型を突き合わせ、索引が使えなくなる暗黙の型変換を検出する", run: runCheckConversions},
+}
+
+// isCommand - 第1引数がサブコマンド指定かどうか
+func isCommand(args []string) bool {
+	return len(args) > 0 && !strings.HasPrefix(args[0], "-")
+}
+
+// runCommand - サブコマンドを実行して終了コードを返す
+func runCommand(name string, args []string) int {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(args); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				return exitCodeFor(err)
+			}
+			return exitOK
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "不明なコマンドです: %s\n\n", name)
+	showCommands()
+	return exitError
+}
+
+// showCommands - サブコマンド一覧を表示
+func showCommands() {
+	fmt.Println("コマンド:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-22s %s\n", cmd.name, cmd.description)
+	}
+}
+
+// openDatabase - 設定を読み込んでデータベースに接続する
+func openDatabase() (*config.Config, *sql.DB, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("設定の読み込みに失敗しました（config validate で読み込み元と直し方を確認できます）: %w", err)
+	}
+
+	db, err := config.ConnectDatabase(cfg)
+	if err != nil {
+		return nil, nil, &connectivityError{fmt.Errorf("データベース接続に失敗しました: %w", err)}
+	}
+
+	if err := db.Ping(); err != nil {
+		closeDatabase(db)
+		return nil, nil, &connectivityError{fmt.Errorf("データベース接続テストに失敗しました: %w", err)}
+	}
+
+	return cfg, db, nil
+}
+
+// addTableFlags - 一覧表の並べ替え（-sort）と表示する列（-columns）のフラグを登録
+func addTableFlags(fs *flag.FlagSet, keys string) func() report.TableOptions {
+	sortKey := fs.String("sort", "", "並べ替える列（"+keys+"。先頭に - を付けると降順）")
+	columns := fs.String("columns", "", "表示する列（カンマ区切り）")
+	return func() report.TableOptions {
+		return report.ParseTableOptions(*sortKey, *columns)
+	}
+}
+
+// closeDatabase - データベース接続をクローズする
+func closeDatabase(db *sql.DB) {
+	if err := db.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "データベースクローズエラー: %v\n", err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/polyglot"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// runCompareClients - compare-clientsコマンド（同じN+1とJOINをGo・JDBC・python-oracledbで計測して比べる）
+func runCompareClients(args []string) error {
+	defaults := polyglot.DefaultConfig()
+	fs := flag.NewFlagSet("compare-clients", flag.ContinueOnError)
+	clients := fs.String("clients", strings.Join(defaults.Clients, ","), "計測するクライアント（カンマ区切り: go, java, python）")
+	days := fs.Int("days", defaults.Days, "取得する受注データの日数（過去何日間）")
+	maxOrders := fs.Int("max-orders", defaults.MaxOrders, "扱う受注の上限（新しい順、0: 上限なし）")
+	runs := fs.Int("runs", defaults.Runs, "手法ごとの計測回数（中央値で比べる）")
+	warmup := fs.Int("warmup", defaults.Warmup, "計測前に捨てる実行の回数（JVMのJITなどを温める）")
+	javaCmd := fs.String("java", defaults.Java, "javaコマンド（単一ファイルのソースを実行できるJDK 17以降）")
+	ojdbc := fs.String("ojdbc", os.Getenv("OJDBC_JAR"), "ojdbcのJAR（省略時はOJDBC_JAR。指定がなければJDBCは計測しない）")
+	pythonCmd := fs.String("python", defaults.Python, "python-oracledbを導入したPythonのコマンド")
+	timeout := fs.Duration("timeout", defaults.Timeout, "Java・Pythonの参照実装1つあたりの実行時間の上限")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	selected, err := polyglot.ParseClients(*clients)
+	if err != nil {
+		return err
+	}
+
+	appCfg, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	cfg := polyglot.Config{
+		Clients: selected, Days: *days, MaxOrders: *maxOrders, Runs: *runs, Warmup: *warmup,
+		Java: *javaCmd, OJDBC: *ojdbc, Python: *pythonCmd, Timeout: *timeout, Env: clientEnv(appCfg),
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := polyglot.Run(ctx, db, cfg)
+	if err != nil {
+		return err
+	}
+	displayClientComparison(result)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal client comparison: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// clientEnv - 参照実装に渡す接続先（.envから読み込んだ設定を環境変数で渡す）
+func clientEnv(cfg *config.Config) []string {
+	return []string{
+		"DB_HOST=" + cfg.DBHost,
+		"DB_PORT=" + strconv.Itoa(cfg.DBPort),
+		"DB_SERVICE_NAME=" + cfg.DBServiceName,
+		"DB_USERNAME=" + cfg.DBUsername,
+		"DB_PASSWORD=" + cfg.DBPassword,
+	}
+}
+
+// displayClientComparison - クライアントごとのN+1とJOINの中央値・実行した文の数・倍率を表示
+func displayClientComparison(r *polyglot.Report) {
+	w := report.Stdout()
+	limit := "上限なし"
+	if r.MaxOrders > 0 {
+		limit = fmt.Sprintf("新しい順に%d件", r.MaxOrders)
+	}
+	w.Heading(fmt.Sprintf("クライアントごとのN+1とJOIN（過去%d日間の受注・%s、%d回の中央値）", r.Days, limit, r.Runs))
+
+	table := report.NewTable(
+		report.Column{Key: "client", Header: "クライアント"},
+		report.Column{Key: "driver", Header: "ドライバー"},
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "median", Header: "中央値", Align: report.AlignRight},
+		report.Column{Key: "statements", Header: "実行した文", Align: report.AlignRight},
+		report.Column{Key: "records", Header: "明細", Align: report.AlignRight},
+		report.Column{Key: "ratio", Header: "N+1/JOIN", Align: report.AlignRight},
+	)
+	var skipped []polyglot.ClientReport
+	for _, c := range r.Clients {
+		if c.Skipped != "" {
+			skipped = append(skipped, c)
+			continue
+		}
+		for _, m := range c.Results {
+			ratio := report.Text("")
+			if m.Method == polyglot.MethodNPlus1 {
+				ratio = report.Float("%.1f倍", c.Ratio())
+			}
+			table.AddRow(
+				report.Text(c.Name),
+				report.Text(c.Driver),
+				report.Text(m.Method),
+				report.Duration(m.Median.Round(time.Microsecond)),
+				report.Int(m.Statements),
+				report.Int(m.Records),
+				ratio)
+		}
+	}
+	if len(skipped) < len(r.Clients) {
+		w.Table(table)
+	}
+	for _, c := range skipped {
+		w.Linef("%s: 計測できませんでした（%s）", c.Name, c.Skipped)
+	}
+
+	w.Blank()
+	if lo, hi, ok := r.RatioRange(); ok {
+		w.Linef("N+1はJOINの%.1f〜%.1f倍の時間がかかりました。実行した文の数（1 + 受注の数）はどの言語・ドライバーでも同じで、差はラウンドトリップの数から生じます。", lo, hi)
+	}
+	if mismatched := r.RecordMismatch(); len(mismatched) > 0 {
+		w.Linef("注意: %s で読んだ明細の件数がクライアントによって異なります（計測中にデータが変わった可能性があります）。", strings.Join(mismatched, ", "))
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// configValidation - config validate -json の出力
+type configValidation struct {
+	Resolution *config.Resolution   `json:"resolution"`
+	Probes     []config.ProbeResult `json:"probes,omitempty"`
+	Problems   []config.Problem     `json:"problems"`
+}
+
+// runConfig - configコマンド
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return errors.New("使い方: config validate [-offline] [-timeout DURATION] [-json FILE]")
+	}
+	return runConfigValidate(args[1:])
+}
+
+// runConfigValidate - 設定値の読み込み元を表示し、値と接続先を検証して直し方を示す
+func runConfigValidate(args []string) error {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	offline := fs.Bool("offline", false, "名前解決・リスナー・サービス・Redisへの接続を確認しない")
+	timeout := fs.Duration("timeout", 5*time.Second, "接続の確認1件あたりの待ち時間の上限")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	res, err := config.Resolve(config.DotEnvFile, os.LookupEnv)
+	if err != nil {
+		return fmt.Errorf(".envの読み込みに失敗しました: %w", err)
+	}
+	displayResolution(res)
+
+	result := configValidation{Resolution: res, Problems: res.Check()}
+	unreachable := false
+	switch {
+	case *offline:
+	case config.HasErrors(result.Problems):
+		fmt.Println("\n設定値に誤りがあるため、接続の確認を省略しました。")
+	default:
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("設定の読み込みに失敗しました: %w", err)
+		}
+		probes, problems := config.Probe(context.Background(), cfg, *timeout)
+		result.Probes = probes
+		result.Problems = append(result.Problems, problems...)
+		unreachable = config.HasErrors(problems)
+		displayProbes(probes)
+	}
+	failed := displayProblems(result.Problems)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal config validation: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+
+	switch {
+	case unreachable:
+		return &connectivityError{errors.New("接続先に接続できません")}
+	case failed:
+		return errors.New("設定に誤りがあります")
+	}
+	return nil
+}
+
+// displayResolution - 設定値と読み込み元を表示
+func displayResolution(res *config.Resolution) {
+	w := report.Stdout()
+	source := "なし（環境変数と既定値のみ）"
+	if res.DotEnvPath != "" {
+		source = res.DotEnvPath
+	}
+	w.Heading("設定値と読み込み元（環境変数 > .env > 既定値）")
+	w.Linef(".env: %s", source)
+
+	table := report.NewTable(
+		report.Column{Key: "key", Header: "項目"},
+		report.Column{Key: "value", Header: "値"},
+		report.Column{Key: "source", Header: "読み込み元"},
+		report.Column{Key: "note", Header: "備考"},
+	)
+	for _, s := range res.Settings {
+		if s.Source == config.SourceUnset && !s.Required {
+			continue
+		}
+		note := ""
+		switch {
+		case s.Shadowed != "":
+			note = ".envの値を上書き"
+		case s.EmptyIgnored:
+			note = "空のため既定値"
+		}
+		table.AddRow(report.Text(s.Name), report.Text(s.Display()), report.Text(string(s.Source)), report.Text(note))
+	}
+	w.Table(table)
+}
+
+// displayProbes - 接続の確認結果を表示
+func displayProbes(probes []config.ProbeResult) {
+	w := report.Stdout()
+	w.Blank()
+	w.Heading("接続の確認")
+	for _, p := range probes {
+		status := "OK"
+		if !p.OK {
+			status = "NG"
+		}
+		w.Linef("[%-2s] %s: %s", status, p.Name, p.Detail)
+	}
+}
+
+// displayProblems - 問題と直し方を表示して、計測を実行できない問題があるかを返す
+func displayProblems(problems []config.Problem) bool {
+	fmt.Println()
+	errCount, warnCount := 0, 0
+	for _, p := range problems {
+		label := "WARN"
+		if p.Severity == config.SeverityError {
+			label = "ERROR"
+			errCount++
+		} else {
+			warnCount++
+		}
+		fmt.Printf("[%-5s] %s: %s\n", label, p.Key, p.Message)
+		if p.Fix != "" {
+			fmt.Printf("        → %s\n", p.Fix)
+		}
+	}
+	fmt.Printf("\nERROR: %d件, WARN: %d件\n", errCount, warnCount)
+	if errCount == 0 && warnCount == 0 {
+		fmt.Println("設定は期待どおりです。")
+	}
+	return errCount > 0
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/aggregate"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/runmeta"
+)
+
+// runConsumerGroups - consumer-groupsコマンド（リソース・マネージャのコンシューマ・グループごとに計測し、N+1と一括取得の差を比較）
+func runConsumerGroups(args []string) error {
+	fs := flag.NewFlagSet("consumer-groups", flag.ContinueOnError)
+	groups := fs.String("groups", "", "計測するコンシューマ・グループとサービスの対応（GROUP=SERVICE のカンマ区切り、省略時はRESOURCE_CONSUMER_GROUPS）")
+	dir := fs.String("dir", "rsrc-results", "結果ファイルを出力するディレクトリ（実行ごとにサブディレクトリを作成）")
+	runs := fs.Int("runs", 1, "グループごとの計測回数（比較表は中央値）")
+	baseline := fs.String("baseline", "", "倍率の基準にするグループ（省略時は最初に指定したグループ）")
+	tableOptions := addTableFlags(fs, "method またはグループ名")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *runs < 1 {
+		return errors.New("-runs には1以上を指定してください")
+	}
+
+	spec := *groups
+	if spec == "" {
+		spec = config.LoadConsumerGroups()
+	}
+	if spec == "" {
+		return errors.New("-groups またはRESOURCE_CONSUMER_GROUPSで GROUP=SERVICE を指定してください")
+	}
+	targets, err := config.ParseConsumerGroups(spec)
+	if err != nil {
+		return fmt.Errorf("コンシューマ・グループの指定が正しくありません: %w", err)
+	}
+
+	// `--` 以降は各グループでの計測にそのまま渡す
+	benchArgs := fs.Args()
+	if err := checkBenchArgs(benchArgs); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("設定の読み込みに失敗しました（config validate で読み込み元と直し方を確認できます）: %w", err)
+	}
+	benchTargets, failed := checkConsumerGroups(cfg, targets)
+	if len(benchTargets) == 0 {
+		return &connectivityError{fmt.Errorf("計測できるコンシューマ・グループがありません: %s", strings.Join(failed, ", "))}
+	}
+
+	outDir, err := newResultsDir(*dir)
+	if err != nil {
+		return err
+	}
+	runFailed, err := runBenchmarks(benchTargets, outDir, *runs, benchArgs)
+	if err != nil {
+		return err
+	}
+	failed = append(failed, runFailed...)
+
+	base := strings.ToUpper(*baseline)
+	if base == "" {
+		base = benchTargets[0].env
+	}
+	matrix, err := loadResultsMatrix(outDir, base, failed)
+	if err != nil {
+		return err
+	}
+	if err := displayMatrix(matrix, tableOptions()); err != nil {
+		return err
+	}
+	displaySetBasedRatios(matrix)
+	fmt.Printf("\n結果ファイル: %s（matrix コマンドで再集計できます）\n", outDir)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("計測できなかったコンシューマ・グループがあります: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// checkConsumerGroups - 各サービスで接続し、セッションが指定したグループに割り当てられるか確認
+//
+// マッピングが設定されていないグループは計測しない。V$SESSIONを参照できない場合は確認せずに計測する。
+func checkConsumerGroups(cfg *config.Config, targets []config.ConsumerGroupTarget) ([]benchTarget, []string) {
+	var benchTargets []benchTarget
+	var failed []string
+	for _, target := range targets {
+		group, err := sessionConsumerGroup(*cfg, target.Service)
+		switch {
+		case errors.Is(err, errConsumerGroupUnknown):
+			fmt.Printf("%s: セッションのコンシューマ・グループを確認できません（V$SESSIONの参照権限がありません）。割り当てを確認せずに計測します\n", target.Group)
+		case err != nil:
+			fmt.Printf("%s: サービス %s に接続できません: %v\n", target.Group, target.Service, err)
+			failed = append(failed, target.Group)
+			continue
+		case !strings.EqualFold(group, target.Group):
+			fmt.Printf("%s: サービス %s のセッションは %s に割り当てられています。SERVICE_NAME のマッピングとリソース・プランを確認してください\n", target.Group, target.Service, group)
+			failed = append(failed, target.Group)
+			continue
+		default:
+			fmt.Printf("%s: サービス %s のセッションが割り当てられることを確認しました\n", target.Group, target.Service)
+		}
+		benchTargets = append(benchTargets, benchTarget{env: target.Group, service: target.Service})
+	}
+	return benchTargets, failed
+}
+
+// errConsumerGroupUnknown - 接続はできたが、セッションのコンシューマ・グループを取得できない
+var errConsumerGroupUnknown = errors.New("consumer group unknown")
+
+// sessionConsumerGroup - 指定したサービスに接続したセッションのコンシューマ・グループ
+func sessionConsumerGroup(cfg config.Config, service string) (string, error) {
+	cfg.DBServiceName = service
+	db, err := config.ConnectDatabase(&cfg)
+	if err != nil {
+		return "", err
+	}
+	defer closeDatabase(db)
+
+	if err := db.Ping(); err != nil {
+		return "", err
+	}
+	group, err := runmeta.ConsumerGroup(db)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errConsumerGroupUnknown, err)
+	}
+	return group, nil
+}
+
+// displaySetBasedRatios - グループごとに、N+1の手法が最も速い一括取得の手法の何倍かかったかを表示
+func displaySetBasedRatios(m *aggregate.Matrix) {
+	ratios := m.SetBasedRatios()
+	if len(ratios) == 0 {
+		return
+	}
+
+	w := report.Stdout()
+	w.Blank()
+	w.Line("=== N+1と一括取得の差（N+1_Problem ÷ 最も速い一括取得の手法） ===")
+	columns := []report.Column{{Key: "scenario", Header: "シナリオ"}}
+	for _, env := range m.Environments {
+		columns = append(columns, report.Column{Key: env, Header: env, Align: report.AlignRight})
+	}
+	table := report.NewTable(columns...)
+	for _, r := range ratios {
+		cells := []report.Cell{report.Text(r.Scenario)}
+		for _, env := range m.Environments {
+			ratio, ok := r.Ratios[env]
+			if !ok {
+				cells = append(cells, report.Text("-"))
+				continue
+			}
+			cells = append(cells, report.Number(fmt.Sprintf("x%.1f (%s)", ratio, r.Fastest[env]), ratio))
+		}
+		table.AddRow(cells...)
+	}
+	w.Table(table)
+	w.Line("CPUの上限が厳しいグループほど、SQLごとにCPUの割り当てを待つ（resmgr:cpu quantum）ためN+1の倍率が大きくなります。")
+}
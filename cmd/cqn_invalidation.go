@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/invalidation"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// runCQNInvalidation - cqn-invalidationコマンド（Continuous Query Notificationによるローカルキャッシュの無効化）
+func runCQNInvalidation(args []string) error {
+	defaults := invalidation.DefaultConfig()
+	fs := flag.NewFlagSet("cqn-invalidation", flag.ContinueOnError)
+	writes := fs.Int("writes", defaults.Writes, "書き込み（更新・コミット）の回数")
+	interval := fs.Duration("interval", defaults.Interval, "書き込みの間隔")
+	poll := fs.Duration("poll", defaults.PollInterval, "読み取り側がローカルキャッシュを読む間隔")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg := invalidation.Config{Writes: *writes, Interval: *interval, PollInterval: *poll}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	result, err := invalidation.RunCQN(context.Background(), db, cfg)
+	if err != nil {
+		return fmt.Errorf("計測に失敗しました（CHANGE NOTIFICATION権限、DBMS_CQ_NOTIFICATION・DBMS_ALERTのEXECUTE権限と、JOB_QUEUE_PROCESSES > 0 が必要です）: %w", err)
+	}
+	displayCQNInvalidation(result)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal cqn report: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// displayCQNInvalidation - CQNの通知による伝播遅延・古い値を返した期間・古い値の読み取りを表示
+func displayCQNInvalidation(r *invalidation.CQNReport) {
+	w := report.Stdout()
+	w.Heading(fmt.Sprintf("CQNによる無効化（顧客ID %d、登録ID %d、書き込み%d回・間隔 %v、読み取り間隔 %v）",
+		r.CustomerID, r.RegistrationID, r.Writes, r.Interval, r.PollInterval))
+
+	m := r.CQN
+	table := report.NewTable(
+		report.Column{Key: "metric", Header: "項目"},
+		report.Column{Key: "value", Header: "値", Align: report.AlignRight},
+	)
+	if m.Propagation != nil {
+		table.AddRow(report.Text("伝播遅延（中央値）"), report.Duration(m.Propagation.Median.Round(time.Microsecond)))
+		table.AddRow(report.Text("伝播遅延（p95）"), report.Duration(m.Propagation.P95.Round(time.Microsecond)))
+		table.AddRow(report.Text("伝播遅延（最大）"), report.Duration(m.Propagation.Max.Round(time.Microsecond)))
+	} else {
+		table.AddRow(report.Text("伝播遅延"), report.Text("-"))
+	}
+	table.AddRow(report.Text("古い値の期間（中央値）"), report.Duration(m.StaleWindow.Median.Round(time.Microsecond)))
+	table.AddRow(report.Text("古い値の期間（最大）"), report.Duration(m.StaleWindow.Max.Round(time.Microsecond)))
+	table.AddRow(report.Text("古い値/読み取り"), report.Text(fmt.Sprintf("%d/%d", m.StaleReads, m.Reads)))
+	w.Table(table)
+
+	if m.Missed > 0 {
+		w.Linef("%d回の書き込みは、計測の終わりまで新しい値を返しませんでした（通知が届かなかったか、無効化の後に古い値を保存し直した可能性があります）。", m.Missed)
+	}
+	w.Blank()
+	w.Line("CQNはアプリケーションが通知を送らなくても、登録したクエリの結果を変えるコミットをOracleが検出して通知します（別のアプリケーションやSQL*Plusからの更新も届きます）。")
+	w.Line("通知はジョブとして実行されるコールバックから届くため、Pub/Subより遅れることがあります。数秒以上かかる場合はJOB_QUEUE_PROCESSESを確認してください。")
+}
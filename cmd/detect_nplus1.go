@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/nplusone"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// runDetectNPlus1 - detect-nplus1コマンド（カーソルキャッシュからN+1の疑いのある文を検出し、ORMごとの直し方を示す）
+func runDetectNPlus1(args []string) error {
+	defaults := nplusone.DefaultConfig()
+	fs := flag.NewFlagSet("detect-nplus1", flag.ContinueOnError)
+	schemaName := fs.String("schema", "", "文を解析したスキーマ（アプリケーションのスキーマ。省略時は接続したユーザー）")
+	minExecutions := fs.Int64("min-executions", defaults.MinExecutions, "N+1とみなす実行回数の下限")
+	maxRows := fs.Float64("max-rows-per-exec", defaults.MaxRowsPerExecution, "N+1とみなす1回あたりの行数の上限")
+	limit := fs.Int("limit", defaults.Limit, "調べるカーソルの数（実行回数の多い順）")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg := nplusone.Config{Schema: strings.ToUpper(*schemaName), MinExecutions: *minExecutions, MaxRowsPerExecution: *maxRows, Limit: *limit}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	if cfg.Schema == "" {
+		if err := db.QueryRow("SELECT USER FROM dual").Scan(&cfg.Schema); err != nil {
+			return fmt.Errorf("failed to query current user: %w", err)
+		}
+	}
+	statements, err := nplusone.LoadStatements(db, cfg.Schema, cfg.MinExecutions, cfg.Limit)
+	if err != nil {
+		return fmt.Errorf("カーソルキャッシュの取得に失敗しました（V$SQLAREAの参照権限が必要です）: %w", err)
+	}
+	result := nplusone.Detect(statements, cfg)
+	displayNPlus1Findings(result)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal n+1 report: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// displayNPlus1Findings - N+1の疑いのある文と、フレームワークごとの直し方を表示
+func displayNPlus1Findings(r *nplusone.Report) {
+	w := report.Stdout()
+	w.Heading(fmt.Sprintf("N+1の疑いのある文（スキーマ %s、実行%d回以上・1回あたり%g行以下、%d件のカーソルを調査）",
+		r.Schema, r.MinExecutions, r.MaxRowsPerExecution, r.Scanned))
+	if len(r.Findings) == 0 {
+		w.Line("キー1つで少数の行を繰り返し読む文は見つかりませんでした。")
+		return
+	}
+
+	table := report.NewTable(
+		report.Column{Key: "sql_id", Header: "SQL_ID"},
+		report.Column{Key: "executions", Header: "実行回数", Align: report.AlignRight},
+		report.Column{Key: "rows_per_exec", Header: "行/回", Align: report.AlignRight},
+		report.Column{Key: "elapsed", Header: "累計時間", Align: report.AlignRight},
+		report.Column{Key: "key", Header: "表.列"},
+		report.Column{Key: "framework", Header: "生成元"},
+	)
+	for _, f := range r.Findings {
+		table.AddRow(
+			report.Text(f.SQLID),
+			report.Int(f.Executions),
+			report.Float("%.1f", f.RowsPerExecution()),
+			report.Duration(f.Elapsed.Round(time.Millisecond)),
+			report.Text(f.Table+"."+f.Column),
+			report.Text(frameworkLabel(f)))
+	}
+	w.Table(table)
+
+	for _, f := range r.Findings {
+		w.Blank()
+		w.Linef("[%s] %s（%s）", f.SQLID, frameworkLabel(f), f.RuleID)
+		w.Linef("  %s", strings.Join(strings.Fields(f.Text), " "))
+		w.Linef("  → %s", f.Hint)
+	}
+	w.Blank()
+	w.Line("実行回数はインスタンスの起動（または共有プールからの追い出し）以降の累計です。同じ文を少ない行数で何度も実行していれば、呼び出し元のループを疑ってください。")
+}
+
+// frameworkLabel - 生成元の表示（sqlcはクエリ名も付ける。ORMのルールに合わない場合は手書き）
+func frameworkLabel(f nplusone.Finding) string {
+	switch {
+	case f.Framework == "":
+		return "手書き/不明"
+	case f.Name != "":
+		return fmt.Sprintf("%s（%s）", f.Framework, f.Name)
+	default:
+		return f.Framework
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+)
+
+// 終了コード（シェルスクリプトやCIが判定結果で分岐できるよう固定している）
+const (
+	exitOK              = 0
+	exitError           = 1
+	exitRegression      = 2
+	exitCacheEfficiency = 3
+	exitConnectivity    = 4
+	exitLocked          = 5
+	exitInterrupted     = 130
+)
+
+// errRegression - 回帰を検出した（終了コード2）
+var errRegression = errors.New("regression detected")
+
+// connectivityError - データベースに接続できない（終了コード4）
+type connectivityError struct {
+	err error
+}
+
+func (e *connectivityError) Error() string { return e.err.Error() }
+
+func (e *connectivityError) Unwrap() error { return e.err }
+
+// exitCodeFor - サブコマンドのエラーに対応する終了コード
+func exitCodeFor(err error) int {
+	var connErr *connectivityError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &connErr):
+		return exitConnectivity
+	case errors.Is(err, errRegression):
+		return exitRegression
+	default:
+		return exitError
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/sqlscript"
+)
+
+// runExportSQL - export-sqlコマンド（各シナリオのクエリをSQL*Plus/SQLclで再実行できるスクリプトとして出力）
+func runExportSQL(args []string) error {
+	defaults := sqlscript.DefaultParams()
+	fs := flag.NewFlagSet("export-sql", flag.ContinueOnError)
+	dir := fs.String("dir", "sql", "スクリプトの出力先ディレクトリ")
+	scenarios := fs.String("scenario", "", fmt.Sprintf("出力するシナリオ（カンマ区切り、省略時はすべて: %s）", strings.Join(sqlscript.IDs(), ",")))
+	days := fs.Int("days", defaults.Days, "スクリプトの既定の受注日数（DEFINE days）")
+	months := fs.Int("months", defaults.Months, "スクリプトの既定の月数（DEFINE months）")
+	topCustomers := fs.Int("top-customers", defaults.TopCustomers, "スクリプトの既定の上位顧客数（DEFINE top_customers）")
+	recentOrders := fs.Int("recent-orders", defaults.RecentOrders, "スクリプトの既定の直近受注数（DEFINE recent_orders）")
+	sqlcl := fs.Bool("sqlcl", false, "SQLcl用のバンドル（経過時間のCSVと棒グラフを書き出す run_benchmark.sql）を出力する")
+	runs := fs.Int("runs", 3, "-sqlcl でシナリオを繰り返し実行する回数")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	selected, err := sqlscript.Select(*scenarios)
+	if err != nil {
+		return err
+	}
+	params := sqlscript.Params{Days: *days, Months: *months, TopCustomers: *topCustomers, RecentOrders: *recentOrders}
+	if *sqlcl {
+		written, err := sqlscript.ExportSQLcl(*dir, selected, params, *runs)
+		if err != nil {
+			return fmt.Errorf("スクリプトの出力に失敗しました: %w", err)
+		}
+		displayWrittenScripts("SQLcl用のバンドル", written)
+		fmt.Printf("出力先のディレクトリで sql user/password@host:1521/service @%s を実行すると、各シナリオを%d回ずつ実行し、\n", sqlscript.BenchmarkFile, *runs)
+		fmt.Printf("実行ごとの経過時間を %s に、手法ごとの平均と棒グラフを %s に書き出します。\n", sqlscript.TimingsFile, sqlscript.ChartFile)
+		return nil
+	}
+
+	written, err := sqlscript.Export(*dir, selected, params)
+	if err != nil {
+		return fmt.Errorf("スクリプトの出力に失敗しました: %w", err)
+	}
+
+	displayWrittenScripts("SQL*Plus/SQLcl用のスクリプト", written)
+	fmt.Printf("出力先のディレクトリで sqlplus user/password@host:1521/service @%s を実行すると全シナリオを比較できます。\n", sqlscript.RunAllFile)
+	fmt.Println("N+1のループ用スクリプト（*_loop.sql）は実行時にカレントディレクトリへ生成されます。")
+	return nil
+}
+
+// displayWrittenScripts - 出力したスクリプトの一覧を表示
+func displayWrittenScripts(kind string, written []string) {
+	fmt.Printf("%sを %d 件出力しました:\n", kind, len(written))
+	for _, path := range written {
+		fmt.Printf("  %s\n", path)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/service"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// runFailover - failoverコマンド（計測中にセッションを切断し、手法ごとの回復を再実行の有無で比較）
+func runFailover(args []string) error {
+	fs := flag.NewFlagSet("failover", flag.ContinueOnError)
+	days := fs.Int("days", 30, "受注データの取得期間（日数）")
+	maxOrders := fs.Int("max-orders", 0, "取得する受注の上限（0は無制限）")
+	killAt := fs.Float64("kill-at", 0.5, "障害なしの実行時間のどの時点で切断するか（0〜1）")
+	killAfter := fs.Duration("kill-after", 0, "開始から切断までの時間（指定時は -kill-at より優先）")
+	modes := fs.String("modes", "none,replay", "比較する切断後の扱い（none: 再実行なし, replay: 接続を取り直して再実行）")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *days < 1 {
+		return errors.New("-days には1以上を指定してください")
+	}
+	if *killAt <= 0 || *killAt >= 1 {
+		return errors.New("-kill-at には0より大きく1より小さい値を指定してください")
+	}
+	if *killAfter < 0 {
+		return errors.New("-kill-after には0以上を指定してください")
+	}
+	failoverModes, err := service.ParseFailoverModes(*modes)
+	if err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	demo := service.NewDemoService(db)
+	defer func() {
+		if cerr := demo.Close(); cerr != nil {
+			fmt.Printf("サービスのクローズエラー: %v\n", cerr)
+		}
+	}()
+	demo.SetLimits(repository.Limits{MaxOrders: *maxOrders})
+
+	results, err := demo.CompareFailover(service.FailoverOptions{
+		Days:         *days,
+		KillFraction: *killAt,
+		KillAfter:    *killAfter,
+		Modes:        failoverModes,
+	})
+	if errors.Is(err, service.ErrKillNotAllowed) {
+		return fmt.Errorf("セッションを切断するにはALTER SYSTEM権限が必要です（GRANT ALTER SYSTEM TO <ユーザー>）: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+	displayFailoverResults(results)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal failover results: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// displayFailoverResults - 手法・扱いごとの回復結果を一覧表示
+func displayFailoverResults(results []service.FailoverResult) {
+	w := report.Stdout()
+	w.Heading("セッション切断からの回復")
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "mode", Header: "扱い"},
+		report.Column{Key: "clean", Header: "障害なし", Align: report.AlignRight},
+		report.Column{Key: "outcome", Header: "結果"},
+		report.Column{Key: "elapsed", Header: "合計時間", Align: report.AlignRight},
+		report.Column{Key: "lost", Header: "やり直した処理", Align: report.AlignRight},
+		report.Column{Key: "recovery", Header: "回復時間", Align: report.AlignRight},
+	)
+	for _, r := range results {
+		outcome := "切断前に完了"
+		switch {
+		case r.Disrupted && r.Completed:
+			outcome = fmt.Sprintf("再実行で完了（%d回）", r.Replays)
+		case r.Disrupted:
+			outcome = "失敗"
+		}
+		lost, recovery := report.Text("-"), report.Text("-")
+		if r.Disrupted {
+			lost = report.Duration(r.LostWork.Round(time.Microsecond))
+		}
+		if r.Recovery > 0 {
+			recovery = report.Duration(r.Recovery.Round(time.Microsecond))
+		}
+		table.AddRow(
+			report.Text(r.Method),
+			report.Text(string(r.Mode)),
+			report.Duration(r.Clean.Round(time.Microsecond)),
+			report.Text(outcome),
+			report.Duration(r.Elapsed.Round(time.Microsecond)),
+			lost,
+			recovery)
+	}
+	w.Table(table)
+	w.Line("go-oraはTAF/Application Continuityに対応していないため、replayはアプリケーション側で接続を取り直して手法を最初から再実行します。")
+	w.Line("N+1のループは切断までに実行したSQLをすべてやり直すため、実行時間の短い一括取得より回復に時間がかかります。")
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/flashback"
+)
+
+// openFlashback - -as-of の時点をSCNに解決し、すべての接続をそのSCNに固定した接続プールを開く
+//
+// 解決したSCNはcfg.Session.FlashbackSCNに設定する（実行メタデータに記録され、-as-of=SCN で同じデータを再計測できる）。
+// 元の接続プールは実行ロックの保持に使い続けるため閉じない。
+func openFlashback(ctx context.Context, db *sql.DB, cfg *config.Config, asOf flashback.AsOf) (*sql.DB, error) {
+	scn, err := flashback.Resolve(ctx, db, asOf)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Session.FlashbackSCN = scn
+
+	pinned, err := config.ConnectDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := pinned.PingContext(ctx); err != nil {
+		closeDatabase(pinned)
+		return nil, fmt.Errorf("SCN %d に固定できません（DBMS_FLASHBACKのEXECUTE権限と、UNDO_RETENTION内のSCNが必要です）: %w", scn, err)
+	}
+	fmt.Printf("フラッシュバック問合せ: %s（SCN %d）時点のデータで計測します\n", asOf, scn)
+	return pinned, nil
+}
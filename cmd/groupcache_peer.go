@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"oracle-n-plus-1-demo/pkg/cache/groupcache"
+)
+
+// runGroupcachePeer - groupcache-peerコマンド（-cache-backends=groupcache が起動するピアプロセス）
+//
+// 起動したプロセスが標準入力を閉じる（終了する）か、SIGINT/SIGTERMを受けると終了する。
+func runGroupcachePeer(args []string) error {
+	fs := flag.NewFlagSet(groupcache.PeerCommand, flag.ContinueOnError)
+	self := fs.String("self", "", "このピアのURL（例: http://127.0.0.1:8001）")
+	peers := fs.String("peers", "", "自身を含むすべてのピアのURL（カンマ区切り）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *self == "" || *peers == "" {
+		return errors.New("-self と -peers を指定してください")
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		stop()
+	}()
+
+	return groupcache.ServePeer(ctx, db, *self, strings.Split(*peers, ","))
+}
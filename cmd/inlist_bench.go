@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/inlist"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+	"oracle-n-plus-1-demo/internal/sqlutil"
+)
+
+// runInListBench - inlist-benchコマンド（IN句のバインド数・一時表の結合・配列バインドのパースと実行のコストを比較）
+func runInListBench(args []string) error {
+	defaults := inlist.DefaultConfig()
+	fs := flag.NewFlagSet("inlist-bench", flag.ContinueOnError)
+	ids := fs.Int("ids", defaults.IDs, "1回の取得で渡す受注IDの件数")
+	chunkSizes := fs.String("chunk-sizes", joinInts(defaults.ChunkSizes), "比較するIN句のバインド数（カンマ区切り、最大1000）")
+	runs := fs.Int("runs", defaults.Runs, "取得方法ごとの実行回数（1回目はハードパースを含むため中央値は2回目以降で求める）")
+	composite := fs.Bool("composite", false, "受注ID・商品IDの複合キーで、組ごとの取得・tuple IN・等価条件のOR・一時表の結合を比較する")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	sizes, err := inlist.ParseChunkSizes(*chunkSizes)
+	if err != nil {
+		return err
+	}
+	cfg := inlist.Config{IDs: *ids, ChunkSizes: sizes, Runs: *runs, Composite: *composite}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	report, err := inlist.Run(context.Background(), db, cfg)
+	if err != nil {
+		return fmt.Errorf("ベンチマークに失敗しました: %w", err)
+	}
+	displayInListReport(report)
+	return nil
+}
+
+// displayInListReport - 取得方法ごとの計測結果とチャンクサイズの推奨を表示
+func displayInListReport(report *inlist.Report) {
+	keys := "受注ID"
+	if report.Composite {
+		keys = "受注ID・商品IDの組"
+	}
+	fmt.Printf("=== IDの一括取得方法の比較（%s %d件、各%d回） ===\n", keys, report.IDs, report.Runs)
+	fmt.Printf("%-16s %6s %8s %12s %12s %10s %8s %10s\n",
+		"手法", "SQL数", "行数", "初回", "中央値", "1IDあたり", "ハード", "パース時間")
+	for _, r := range report.Results {
+		if r.Skipped != "" {
+			fmt.Printf("%-16s スキップ: %s\n", r.Label(), r.Skipped)
+			continue
+		}
+		hardParses, parseTime := "-", "-"
+		if r.Stats != nil {
+			hardParses = fmt.Sprintf("%d", r.Stats[sessionstats.ParseCountHard])
+			parseTime = r.ParseTime().String()
+		}
+		fmt.Printf("%-16s %6d %8d %12v %12v %10v %8s %10s\n",
+			r.Label(), r.Executions, r.Rows, r.First, r.Median, r.PerID(report.IDs), hardParses, parseTime)
+	}
+	if report.StatsUnavailable {
+		fmt.Println("V$MYSTATを参照できないため、パース回数・時間は表示しません（SELECT権限が必要です）")
+	}
+
+	fmt.Println()
+	fmt.Println("初回はSQL文ごとのハードパースを含みます。IN句はバインド数ごとに別のSQL文になるため、件数が変わるたびに共有プールにカーソルが増えます。")
+	if report.Composite {
+		fmt.Println("tuple IN（(order_id, product_id) IN ((:1,:2),...)）は複合索引のINLIST ITERATORになりやすく、等価条件のOR（OR_Chain）は組ごとの索引アクセスの連結（CONCATENATION）か全表走査になることがあります。")
+		fmt.Println("複合キーはコレクション型1つでは渡せないため、配列バインドは比較しません。")
+	}
+	if best, ok := report.BestChunkSize(); ok {
+		fmt.Printf("2回目以降が最も速いIN句のバインド数: %d（sqlutil.QueryInの既定: %d）\n", best, sqlutil.DefaultInChunkSize)
+	}
+}
+
+// joinInts - 整数をカンマ区切りにする
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%d", v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/cachekey"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// runInvalidationBench - invalidation-benchコマンド（Redisのキーのまとめての無効化をバージョンの更新とキーの走査で比較）
+func runInvalidationBench(args []string) error {
+	defaults := cachekey.DefaultConfig()
+	fs := flag.NewFlagSet("invalidation-bench", flag.ContinueOnError)
+	keys := fs.Int("keys", defaults.Keys, "1回の無効化で対象になるキーの数")
+	runs := fs.Int("runs", defaults.Runs, "実行回数")
+	scanCount := fs.Int("scan-count", defaults.ScanCount, "SCANの1回あたりのCOUNT")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg := cachekey.Config{Keys: *keys, Runs: *runs, ScanCount: *scanCount}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	appConfig, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("設定の読み込みに失敗しました（config validate で読み込み元と直し方を確認できます）: %w", err)
+	}
+	client, err := config.ConnectRedis(appConfig)
+	if err != nil {
+		return &connectivityError{fmt.Errorf("Redis接続に失敗しました: %w", err)}
+	}
+	if client == nil {
+		return errors.New("REDIS_HOST が設定されていません")
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "redis client Close() failed: %v\n", err)
+		}
+	}()
+
+	result, err := cachekey.Run(context.Background(), client, cfg)
+	if err != nil {
+		return fmt.Errorf("計測に失敗しました: %w", err)
+	}
+	displayInvalidationReport(result)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal invalidation report: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// displayInvalidationReport - 無効化の方法ごとの時間・ラウンドトリップと、バージョンによる読み取りのオーバーヘッドを表示
+func displayInvalidationReport(r *cachekey.Report) {
+	w := report.Stdout()
+	w.Heading(fmt.Sprintf("キャッシュのまとめての無効化（キー%d件、%d回の中央値）", r.Keys, r.Runs))
+
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "無効化の方法"},
+		report.Column{Key: "median", Header: "時間", Align: report.AlignRight},
+		report.Column{Key: "round_trips", Header: "コマンド数", Align: report.AlignRight},
+		report.Column{Key: "deleted", Header: "削除したキー", Align: report.AlignRight},
+	)
+	table.AddRow(report.Text(fmt.Sprintf("SCAN（COUNT %d）+ DEL", r.ScanCount)), report.Duration(r.Scan.Median.Round(time.Microsecond)),
+		report.Int(int64(r.Scan.RoundTrips)), report.Int(int64(r.Scan.Deleted)))
+	table.AddRow(report.Text("バージョンの更新（INCR）"), report.Duration(r.Version.Median.Round(time.Microsecond)),
+		report.Int(int64(r.Version.RoundTrips)), report.Int(int64(r.Version.Deleted)))
+	w.Table(table)
+
+	if speedup, ok := r.Speedup(); ok {
+		w.Linef("バージョンの更新はキーの走査より %.1f 倍速く無効化しました（キーの数によらず1コマンドです）。", speedup)
+	}
+	w.Blank()
+	w.Linef("読み取り1回あたり（%d件の中央値）: 組み立て済みのキー %v, バージョン番号を取得してから %v（+%v）",
+		r.Reads, r.DirectRead.Round(time.Microsecond), r.VersionedRead.Round(time.Microsecond), r.ReadOverhead().Round(time.Microsecond))
+	w.Line("バージョンによる無効化は、読み取りのたびにバージョン番号を取得するラウンドトリップが増えます（MGETでまとめるか、短時間だけ手元に保持して減らせます）。")
+	w.Linef("古いバージョンのキーは削除されず、有効期限（%v）まで %d 件がメモリに残ります（ベンチマークの終了時には削除しました）。", r.StaleTTL, r.StaleKeys)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// 実行結果の状態
+const (
+	runCompleted   = "completed"
+	runInterrupted = "interrupted"
+	runFailed      = "failed"
+)
+
+// runDocument - -json 指定時に標準出力へ出す実行結果
+type runDocument struct {
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+	*service.ResultsReport
+	Verdict *verdict `json:"verdict,omitempty"`
+}
+
+// jsonReporter - 装飾的な表示を抑止し、標準出力にJSONを1つだけ出す
+//
+// 各処理の表示を個別に切り替えずに済むよう、実行中は os.Stdout を /dev/null に差し替える。
+// エラーや警告のログは標準エラーに出るため、そのまま残る。
+type jsonReporter struct {
+	stdout  *os.File
+	devNull *os.File
+	once    sync.Once
+}
+
+// newJSONReporter - -json 指定時のみ標準出力を差し替える（未指定時はnilを返し、各メソッドは何もしない）
+func newJSONReporter(enabled bool) (*jsonReporter, error) {
+	if !enabled {
+		return nil, nil
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+
+	r := &jsonReporter{stdout: os.Stdout, devNull: devNull}
+	os.Stdout = devNull
+	return r, nil
+}
+
+// emit - 標準出力を戻してJSONを出力（中断時と終了時の両方から呼ばれても1回だけ出す）
+func (r *jsonReporter) emit(doc runDocument) {
+	if r == nil {
+		return
+	}
+
+	r.once.Do(func() {
+		os.Stdout = r.stdout
+		if err := r.devNull.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "devNull.Close() failed: %v\n", err)
+		}
+
+		encoder := json.NewEncoder(r.stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			fmt.Fprintf(os.Stderr, "JSON出力エラー: %v\n", err)
+		}
+	})
+}
+
+// fail - 計測前に終了する場合のJSONを出力
+func (r *jsonReporter) fail(code int, err error) {
+	r.emit(runDocument{Status: runFailed, ExitCode: code, Error: err.Error()})
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/api"
+	"oracle-n-plus-1-demo/internal/loadtest"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// runLoadTest - loadtestコマンド（顧客サマリーAPIに負荷をかけて手法を比較）
+func runLoadTest(args []string) error {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	url := fs.String("url", "", "対象APIのベースURL（省略時はプロセス内でAPIを起動）")
+	requests := fs.Int("requests", 500, "手法ごとのリクエスト数")
+	concurrency := fs.Int("concurrency", 8, "同時実行数")
+	customers := fs.String("customers", "1001-1050", "対象顧客IDの範囲（例: 1001-1050）またはカンマ区切り")
+	strategies := fs.String("strategies", "n1,sql,cached", "比較する手法（カンマ区切り）")
+	mixFile := fs.String("mix", "", "リクエスト構成ファイル（JSON、指定時は-customersの代わりに顧客ごとの割合と偏りに従う）")
+	tableOptions := addTableFlags(fs, "strategy, rps, mean, p50, p95, p99, errors, hits")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// 負荷をかけ終えてから列指定の誤りに気付かないよう、先に確認する
+	opts := tableOptions()
+	if err := newLoadTestTable().Check(opts); err != nil {
+		return err
+	}
+
+	customerIDs, err := loadtest.ParseCustomerIDs(*customers)
+	if err != nil {
+		return err
+	}
+	var mix *loadtest.Mix
+	if *mixFile != "" {
+		if mix, err = loadtest.LoadMix(*mixFile); err != nil {
+			return err
+		}
+	}
+	strategyList := strings.Split(*strategies, ",")
+
+	baseURL := *url
+	if baseURL == "" {
+		cfg, db, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		defer closeDatabase(db)
+
+		summaries := newSummaryService(cfg, db, service.DefaultSummaryTTL)
+		if !summaries.RedisAvailable() {
+			strategyList = withoutStrategy(strategyList, string(service.SummaryCached))
+		}
+
+		server := httptest.NewServer(api.NewServer(summaries))
+		defer server.Close()
+		baseURL = server.URL
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("=== 顧客サマリーAPI 負荷テスト ===\n")
+	fmt.Printf("対象: %s/customers/{id}/summary\n", baseURL)
+	if mix != nil {
+		fmt.Printf("リクエスト数: %d/手法, 同時実行数: %d, リクエスト構成: %s（シード: %d）\n", *requests, *concurrency, *mixFile, mix.Seed)
+		displayMixSegments(mix)
+	} else {
+		fmt.Printf("リクエスト数: %d/手法, 同時実行数: %d, 顧客数: %d\n\n", *requests, *concurrency, len(customerIDs))
+	}
+
+	results, err := loadtest.Run(ctx, loadtest.Config{
+		BaseURL:     baseURL,
+		Strategies:  strategyList,
+		Requests:    *requests,
+		Concurrency: *concurrency,
+		CustomerIDs: customerIDs,
+		Mix:         mix,
+	})
+
+	table := newLoadTestTable()
+	for _, r := range results {
+		table.AddRow(
+			report.Text(r.Strategy),
+			report.Float("%.1f", r.Throughput),
+			report.Duration(r.Mean),
+			report.Duration(r.P50),
+			report.Duration(r.P95),
+			report.Duration(r.P99),
+			report.Int(r.Errors),
+			report.Int(r.CacheHits))
+	}
+	if terr := table.Apply(opts); terr != nil {
+		return terr
+	}
+	report.Stdout().Table(table)
+	displaySegmentResults(results)
+
+	if err != nil {
+		return fmt.Errorf("負荷テストが中断されました: %w", err)
+	}
+
+	return nil
+}
+
+// newLoadTestTable - 負荷テスト結果の表（列定義のみ）
+func newLoadTestTable() *report.Table {
+	return report.NewTable(
+		report.Column{Key: "strategy", Header: "手法"},
+		report.Column{Key: "rps", Header: "req/s", Align: report.AlignRight},
+		report.Column{Key: "mean", Header: "平均", Align: report.AlignRight},
+		report.Column{Key: "p50", Header: "p50", Align: report.AlignRight},
+		report.Column{Key: "p95", Header: "p95", Align: report.AlignRight},
+		report.Column{Key: "p99", Header: "p99", Align: report.AlignRight},
+		report.Column{Key: "errors", Header: "エラー", Align: report.AlignRight},
+		report.Column{Key: "hits", Header: "HIT", Align: report.AlignRight},
+	)
+}
+
+// displayMixSegments - リクエスト構成のセグメント一覧を表示
+func displayMixSegments(mix *loadtest.Mix) {
+	var total float64
+	for _, seg := range mix.Segments {
+		total += seg.Weight
+	}
+
+	table := report.NewTable(
+		report.Column{Key: "segment", Header: "セグメント"},
+		report.Column{Key: "customers", Header: "顧客"},
+		report.Column{Key: "share", Header: "割合", Align: report.AlignRight},
+		report.Column{Key: "distribution", Header: "分布"},
+	)
+	for _, seg := range mix.Segments {
+		distribution := seg.Distribution
+		if distribution == loadtest.DistributionZipf {
+			distribution = fmt.Sprintf("zipf (s=%.2f)", seg.Skew)
+		}
+		table.AddRow(
+			report.Text(seg.Name),
+			report.Text(seg.Customers),
+			report.Float("%.1f%%", seg.Weight/total*100),
+			report.Text(distribution))
+	}
+	report.Stdout().IndentTable(1, table)
+	fmt.Println()
+}
+
+// displaySegmentResults - リクエスト構成のセグメント別の内訳を表示
+func displaySegmentResults(results []loadtest.Result) {
+	w := report.Stdout()
+	for _, r := range results {
+		if len(r.Segments) == 0 {
+			continue
+		}
+		table := report.NewTable(
+			report.Column{Key: "segment", Header: "セグメント"},
+			report.Column{Key: "requests", Header: "件数", Align: report.AlignRight},
+			report.Column{Key: "mean", Header: "平均", Align: report.AlignRight},
+			report.Column{Key: "p95", Header: "p95", Align: report.AlignRight},
+			report.Column{Key: "hit_rate", Header: "HIT率", Align: report.AlignRight},
+			report.Column{Key: "not_found", Header: "404", Align: report.AlignRight},
+			report.Column{Key: "errors", Header: "エラー", Align: report.AlignRight},
+		)
+		for _, seg := range r.Segments {
+			table.AddRow(
+				report.Text(seg.Name),
+				report.Int(int64(seg.Requests)),
+				report.Duration(seg.Mean),
+				report.Duration(seg.P95),
+				report.Float("%.1f%%", seg.HitRate()),
+				report.Int(seg.NotFound),
+				report.Int(seg.Errors))
+		}
+		w.Heading(fmt.Sprintf("セグメント別の内訳（%s）", r.Strategy))
+		w.IndentTable(1, table)
+	}
+}
+
+// withoutStrategy - 指定した手法を除いたリストを返す
+func withoutStrategy(strategies []string, name string) []string {
+	result := make([]string, 0, len(strategies))
+	for _, st := range strategies {
+		if st == name {
+			fmt.Printf("Redisが利用できないため %s 手法をスキップします\n", name)
+			continue
+		}
+		result = append(result, st)
+	}
+	return result
+}
@@ -1,74 +1,732 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/aggregate"
+	"oracle-n-plus-1-demo/internal/cache"
+	"oracle-n-plus-1-demo/internal/chrometrace"
+	"oracle-n-plus-1-demo/internal/costmodel"
+	"oracle-n-plus-1-demo/internal/explain"
+	"oracle-n-plus-1-demo/internal/export"
+	"oracle-n-plus-1-demo/internal/flashback"
+	"oracle-n-plus-1-demo/internal/ingest"
+	"oracle-n-plus-1-demo/internal/lesson"
+	"oracle-n-plus-1-demo/internal/presenter"
+	"oracle-n-plus-1-demo/internal/querylog"
+	"oracle-n-plus-1-demo/internal/rac"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/resultcache"
+	"oracle-n-plus-1-demo/internal/runlock"
+	"oracle-n-plus-1-demo/internal/runmeta"
 	"oracle-n-plus-1-demo/internal/service"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+	"oracle-n-plus-1-demo/internal/signing"
+	"oracle-n-plus-1-demo/internal/sink"
+	"oracle-n-plus-1-demo/internal/telemetry"
+	backend "oracle-n-plus-1-demo/pkg/cache"
+	"oracle-n-plus-1-demo/pkg/cache/groupcache"
+	"oracle-n-plus-1-demo/repository"
 )
 
 func main() {
+	// サブコマンドの実行
+	if isCommand(os.Args[1:]) {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+	os.Exit(run())
+}
+
+// run - デモを実行して終了コードを返す（deferでの後片付けを終了前に済ませるためmainから分離）
+func run() int {
+
 	// コマンドラインフラグの定義
 	var (
-		days          = flag.Int("days", 30, "取得する受注データの日数（過去何日間）")
-		showSample    = flag.Bool("sample", false, "サンプルデータを表示する")
-		showStats     = flag.Bool("stats", false, "データベース統計情報を表示する")
-		orderOnly     = flag.Bool("order-only", false, "受注データのみテストする")
-		employeeOnly  = flag.Bool("employee-only", false, "社員データのみテストする")
-		cacheTest     = flag.Bool("cache-test", false, "キャッシュ性能比較テストを実行する")
-		cacheOnly     = flag.Bool("cache-only", false, "キャッシュテストのみ実行する")
-		benchmarkRuns = flag.Int("benchmark-runs", 10, "ベンチマーク実行回数")
-		help          = flag.Bool("help", false, "ヘルプを表示する")
+		days           = flag.Int("days", 30, "取得する受注データの日数（過去何日間）")
+		maxOrders      = flag.Int("max-orders", 0, "1回の取得で扱う受注の上限（新しい順、0: 上限なし）。すべての手法に同じ条件で適用する")
+		maxEmployees   = flag.Int("max-employees", 0, "1回の取得で扱う社員の上限（社員ID順、0: 上限なし）。すべての手法に同じ条件で適用する")
+		asOf           = flag.String("as-of", "", "すべての手法を同じ時点のデータで計測する（start: 実行開始時のSCN、SCNの数値、または YYYY-MM-DD HH24:MI:SS）")
+		showSample     = flag.Bool("sample", false, "サンプルデータを表示する")
+		showStats      = flag.Bool("stats", false, "データベース統計情報を表示する")
+		statsJSON      = flag.String("stats-json", "", "データベース統計情報をJSONファイルに出力する")
+		sign           = flag.Bool("sign", false, "出力した結果ファイル（-results-json・-stats-json・-results-bench・-output など）にRESULT_SIGNING_KEYでHMAC署名（<ファイル>.sig）を付ける")
+		resultsJSON    = flag.String("results-json", "", "計測結果を実行メタデータ付きでJSONファイルに出力する")
+		resultsBench   = flag.String("results-bench", "", "計測結果をGoのベンチマーク形式（benchstatで比較できる形式）でファイルに出力する")
+		output         = flag.String("output", "", "シナリオとキャッシュ比較の計測結果を改善率付きで出力する形式（json, csv, markdown）")
+		outputFile     = flag.String("output-file", "", "-output の出力先（省略時は benchmark_results.<拡張子>）")
+		envName        = flag.String("env", "", "結果に記録する実行環境名（例: dev, staging, prod-replica。省略時はBENCH_ENVIRONMENT）")
+		sinkSpecs      = flag.String("sink", "", "計測結果の送信先（カンマ区切り: stdout, file:DIR, https://..., s3://BUCKET/PREFIX, oci-par:URL）")
+		orderOnly      = flag.Bool("order-only", false, "受注データのみテストする")
+		employeeOnly   = flag.Bool("employee-only", false, "社員データのみテストする")
+		projectOnly    = flag.Bool("project-only", false, "社員・プロジェクト（多対多）のみテストする")
+		salesOnly      = flag.Bool("sales-only", false, "月次売上レポートの集計方法比較のみ実行する")
+		months         = flag.Int("months", 12, "月次売上レポートの対象月数")
+		topOnly        = flag.Bool("top-only", false, "売上上位顧客と直近受注（Top-N）のみテストする")
+		topCustomers   = flag.Int("top-customers", 10, "Top-Nテストの上位顧客数")
+		recentOrders   = flag.Int("recent-orders", 5, "Top-Nテストで顧客ごとに取得する直近受注数")
+		windowOnly     = flag.Bool("window-only", false, "分析関数（累計・順位・LAG/LEAD）の比較のみ実行する")
+		lobOnly        = flag.Bool("lob-only", false, "LOB列（受注備考）を含む取得方法の比較のみ実行する")
+		compositeOnly  = flag.Bool("composite-only", false, "受注・明細・商品（3階層）の取得方法比較のみ実行する")
+		sharedPool     = flag.Bool("sharedpool-only", false, "リテラル埋め込みN+1の共有プール負荷比較のみ実行する")
+		workers        = flag.Int("workers", 8, "共有プール負荷比較の並列数")
+		pruningOnly    = flag.Bool("pruning-only", false, "SELECT * と必要な列のみのSELECTの比較のみ実行する")
+		paginationOnly = flag.Bool("pagination-only", false, "ページングでの件数の取得方法（件数クエリ・COUNT(*) OVER・推定）の比較のみ実行する")
+		pageSize       = flag.Int("page-size", 20, "ページングの比較で1ページに表示する受注の数")
+		pages          = flag.Int("pages", 10, "ページングの比較で先頭から順にめくるページ数")
+		softDeleteOnly = flag.Bool("soft-delete-only", false, "論理削除された受注の除外と部分索引（関数索引）の比較のみ実行する")
+		payload        = flag.Bool("payload", false, "取得結果をJSONレスポンスへエンコードするまでを含めて計測する")
+		target         = flag.Duration("target", 0, "シナリオあたりの目標実行時間（例: 10s）。指定時はサンプル計測で-days/-monthsを自動調整する")
+		sessionStats   = flag.Bool("session-stats", false, "手法ごとにセッション統計（転送量・ラウンドトリップ等）を表示する")
+		racOn          = flag.Bool("rac", false, "RAC環境で手法ごとに接続先インスタンスとgc待機イベント（Clusterクラス）を記録する")
+		racPin         = flag.String("rac-pin", "", "シナリオを実行するインスタンスを固定する（例: orders=ORCL1,employees=ORCL2。-rac を含む）")
+		noRunLock      = flag.Bool("no-run-lock", false, "実行ロック（同じスキーマでの計測の同時実行の防止）を取得しない")
+		lockWait       = flag.Duration("lock-wait", 0, "別の計測が実行ロックを保持している場合に待つ時間（0: 待たずに終了）")
+		lockStaleAfter = flag.Duration("lock-stale-after", runlock.DefaultStaleAfter, "実行ロックの保持者がこの時間以上応答していなければ古いロックとみなす")
+		breakStaleLock = flag.Bool("break-stale-lock", false, "古い実行ロックの保持者のセッションを切断して取得し直す（ALTER SYSTEM権限が必要）")
+		parallel       = flag.Int("parallel", 1, "全体実行のシナリオを並列に実行する数（1: 順に実行）")
+		concurrency    = flag.Int("concurrency", 1, "各手法の計測の後に、手法を同時に実行するゴルーチンの数（1: 同時実行を計測しない）")
+		concRounds     = flag.Int("concurrency-rounds", service.DefaultConcurrencyRounds, "-concurrency で1つのゴルーチンが手法を実行する回数")
+		resetKinds     = flag.String("reset", "", "手法の計測前に行うリセット（カンマ区切り: result-cache, buffer-cache, reconnect）")
+		resetScope     = flag.String("reset-scope", string(service.ResetPerMethod), "リセットを行う単位（method: 手法ごと, scenario: シナリオごと）")
+		resetSleep     = flag.Duration("reset-sleep", 0, "リセット後に待機する時間（例: 2s）")
+		resetSession   = flag.String("reset-session", "", "手法ごとに計測する接続で実行するALTER SESSION文（セミコロン区切り）")
+		warmup         = flag.String("warmup", "", "シナリオの計測前に行うウォームアップ（none, scan, iteration。シナリオごとは orders=scan のようにカンマ区切り）")
+		iterations     = flag.Int("iterations", 1, "手法ごとの計測回数（2以上で中央値と回ごとのばらつきを表示）")
+		interleave     = flag.Bool("interleave", false, "-iterations の計測を手法ごとに連続せず、手法を1回ずつ交互に実行して回ごとの差を取る")
+		repeat         = flag.Int("repeat", 1, "全体実行を繰り返す回数")
+		shuffle        = flag.Bool("shuffle", false, "繰り返しごとにシナリオと手法の実行順序を無作為に並べ替え、実行順による影響を分析する")
+		seed           = flag.Uint64("seed", 0, "-shuffle・-read-write-mix・-quiz の乱数シード（0: 実行時刻から決める）")
+		isolationName  = flag.String("isolation", string(service.IsolationSession), "並列実行時の接続の分離（session: シナリオごとに専用の接続, pool: 接続プールを共有）")
+		cacheTest      = flag.Bool("cache-test", false, "キャッシュ性能比較テストを実行する")
+		cacheOnly      = flag.Bool("cache-only", false, "キャッシュテストのみ実行する")
+		benchmarkRuns  = flag.Int("benchmark-runs", 10, "ベンチマーク実行回数")
+		cacheSort      = flag.String("cache-sort", "", "キャッシュ比較表を並べ替える列（method, time, hit_rate, description。先頭に - で降順）")
+		cacheColumns   = flag.String("cache-columns", "", "キャッシュ比較表に表示する列（カンマ区切り）")
+		cacheFormat    = flag.String("cache-format", presenter.FormatText, "キャッシュテスト結果の表示形式（text, json）")
+		cacheBackends  = flag.String("cache-backends", "", "キャッシュテストに加える独自のキャッシュ実装の登録名（カンマ区切り。組み込み: memory, coherence, timesten, groupcache）")
+		peerWorkers    = flag.Int("groupcache-workers", groupcache.DefaultWorkers, "-cache-backends=groupcache で起動するピアプロセスの数（計測するプロセスと合わせてキーを分担する）")
+		plsqlPrefix    = flag.String("plsql-prefix", service.DefaultPLSQLFunctionPrefix, "PL/SQL Function Result Cacheテストで作成する関数名の接頭辞")
+		plsqlSuffix    = flag.String("plsql-suffix", "", "PL/SQL Function Result Cacheテストで作成する関数名の接尾辞（利用者ごとに分ける場合など）")
+		noPLSQL        = flag.Bool("no-plsql-function", false, "PL/SQL関数を作成せず、PL/SQL Function Result Cacheテストをスキップする（DDLを実行できない共有スキーマ向け）")
+		keepPLSQL      = flag.Bool("keep-plsql-function", false, "PL/SQL Function Result Cacheテストで作成した関数をテスト後に削除しない")
+		fkAllTables    = flag.Bool("fk-all-tables", false, "キャッシュテストの推奨事項で、外部キーの索引をデモのテーブルだけでなくスキーマのすべてのテーブルについて確認する")
+		readWriteMix   = flag.String("read-write-mix", "", "キャッシュテストに読み書き混在ワークロードを追加する読み取り/書き込みの比率（例: 90/10）")
+		readWriteOps   = flag.Int("read-write-ops", cache.DefaultReadWriteOperations, "読み書き混在ワークロードの手法ごとの操作数")
+		costModelPath  = flag.String("cost-model", "", "手法ごとの月額コストを見積もる単価ファイル（JSON。DB CPU秒・Redisインスタンス時間・転送量の単価と想定リクエスト数）")
+		capacityRPS    = flag.Float64("capacity-rps", 0, "計測結果を外挿して必要なDB CPU・ラウンドトリップを見積もる想定リクエスト数（req/s、0: 見積もらない）")
+		ingestDir      = flag.String("ingest-dir", "", "指定ディレクトリのCSV（<テーブル名>.csv）をデモスキーマへ取り込む")
+		ingestBatch    = flag.Int("ingest-batch", ingest.DefaultBatchSize, "取り込み時の配列バインド行数")
+		jsonMode       = flag.Bool("json", false, "装飾的な表示を抑止し、実行結果を1つのJSONとして標準出力に出す")
+		failOn         = flag.String("fail-on", "", "判定結果を終了コードに反映する条件（カンマ区切り: regression, cache）")
+		compare        = flag.String("compare", "", "回帰判定の基準にする以前の計測結果JSON（-fail-on=regression で使用）")
+		regressionPct  = flag.Float64("regression-threshold", aggregate.DefaultThreshold, "回帰とみなす基準からの悪化率（%）")
+		minCacheEff    = flag.Float64("min-cache-efficiency", 70, "-fail-on=cache で許容する総合キャッシュ効率の下限（%）")
+		walkthrough    = flag.Bool("walkthrough", false, "研修向けに教材のステップごとに説明・SQL・予想を表示し、Enterを待って実行・観測結果を示す")
+		lessonID       = flag.String("lesson", "", "-walkthrough で進める教材のID（省略時はすべての教材）")
+		bundlePath     = flag.String("bundle", "", "コンソール出力・計測結果・実行計画・設定を1つのアーカイブ（.tar.gz）にまとめて出力する")
+		telemetryOn    = flag.Bool("telemetry", false, "匿名化した改善率と環境の区分（エディション・データ量）を送信する（オプトイン）")
+		telemetryURL   = flag.String("telemetry-endpoint", "", "-telemetry の送信先URL（省略時はTELEMETRY_ENDPOINT）")
+		quiz           = flag.Bool("quiz", false, "研修向けに手法名を伏せて計測結果を示し、N+1問題のある手法を当てるクイズを出す")
+		quizScenario   = flag.String("quiz-scenario", "", "-quiz で出題するシナリオ（orders, employees, employee_projects。省略時はすべて）")
+		queryLog       = flag.String("query-log", "", "実行したSQL・バインド変数・行数・時間を1文ずつ書き出す先（stderr, stdout, またはファイル）")
+		queryLogRedact = flag.String("query-log-redact", string(querylog.RedactNone), "-query-log / -trace でバインド変数の値を伏せる範囲（none, strings: 文字列とバイト列, all: すべて）")
+		queryLogAll    = flag.Bool("query-log-all", false, "-query-log / -trace にV$ビューへの問い合わせ（セッション統計の取得など計測のための文）も含める")
+		queryCount     = flag.Bool("query-count", true, "手法ごとに発行したSQL・バインド実行の数を数える（接続をラップするため、ドライバー固有の接続が必要な処理では無効にする）")
+		explainPlans   = flag.Bool("explain", false, "手法ごとに実行した問い合わせの実行計画（EXPLAIN PLAN・DBMS_XPLAN.DISPLAY）を結果に添えて表示する")
+		planStats      = flag.Bool("plan-stats", false, "問い合わせにGATHER_PLAN_STATISTICSヒントを加えて実行し、DISPLAY_CURSOR（ALLSTATS LAST）の推定と実際の行数を表示する")
+		goResultTTL    = flag.Duration("go-result-cache-ttl", 30*time.Second, "月次売上の比較に加えるプロセス内の結果キャッシュ（Go_Result_Cache）の有効期限（0で比較しない）")
+		goResultMax    = flag.Int("go-result-cache-entries", resultcache.DefaultMaxEntries, "プロセス内の結果キャッシュに置くエントリ数の上限（超えたら最も古く使われたものから追い出す）")
+		tracePath      = flag.String("trace", "", "手法の実行区間と各SQLの区間をChrome trace形式（chrome://tracing、Perfetto）で書き出すファイル")
+		help           = flag.Bool("help", false, "ヘルプを表示する")
 	)
 
-	flag.Parse()
+	// 不正なフラグの終了コード（flagパッケージ既定の2）が回帰の判定結果と重ならないよう自前で扱う
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			showHelp()
+			return exitOK
+		}
+		return exitError
+	}
 
 	// ヘルプ表示
 	if *help {
 		showHelp()
-		return
+		return exitOK
+	}
+
+	// -json 指定時は以降の表示を抑止し、最後にJSONだけを出す
+	reporter, err := newJSONReporter(*jsonMode)
+	if err != nil {
+		log.Printf("%v", err)
+		return exitError
+	}
+	fatal := func(code int, format string, args ...interface{}) int {
+		err := fmt.Errorf(format, args...)
+		log.Print(err)
+		reporter.fail(code, err)
+		return code
+	}
+
+	// -bundle 指定時はコンソール出力を記録する（-json は標準出力を差し替えるため組み合わせない）
+	var runRecord *runBundle
+	if *bundlePath != "" {
+		if *jsonMode {
+			return fatal(exitError, "-bundle は -json と同時に指定できません")
+		}
+		if runRecord, err = startBundle(*bundlePath); err != nil {
+			return fatal(exitError, "-bundle の指定が正しくありません: %v", err)
+		}
+		defer runRecord.stop()
+	}
+
+	// 判定条件の指定誤りは計測前に検出する
+	conds, err := parseFailOn(*failOn)
+	if err != nil {
+		return fatal(exitError, "-fail-on の指定が正しくありません: %v", err)
+	}
+	var baseline *aggregate.Run
+	if conds.regression {
+		if *compare == "" {
+			return fatal(exitError, "-fail-on=regression には -compare で基準の計測結果を指定してください")
+		}
+		loaded, err := aggregate.LoadFile(*compare)
+		if err != nil {
+			return fatal(exitError, "基準の計測結果を読み込めません: %v", err)
+		}
+		baseline = &loaded
+	}
+	if conds.cache && !*cacheTest && !*cacheOnly {
+		return fatal(exitError, "-fail-on=cache には -cache-test または -cache-only を指定してください")
+	}
+
+	// キャッシュテスト結果の表示形式と比較表の列指定
+	cachePresenter, err := presenter.New(*cacheFormat, os.Stdout, presenter.Options{
+		Table: report.ParseTableOptions(*cacheSort, *cacheColumns),
+	})
+	if err != nil {
+		return fatal(exitError, "キャッシュテストの表示指定が正しくありません: %v", err)
+	}
+	if *output != "" {
+		if err := export.CheckFormat(*output); err != nil {
+			return fatal(exitError, "-output の形式が正しくありません: %v", err)
+		}
+		if *outputFile == "" {
+			*outputFile = export.DefaultPath(*output)
+		}
+	} else if *outputFile != "" {
+		return fatal(exitError, "-output-file には -output を指定してください")
+	}
+
+	// 並列実行の指定（同時に使う接続数が接続プールの上限を超えないようにする）
+	if *parallel < 1 || *parallel > config.MaxOpenConns {
+		return fatal(exitError, "-parallel は1〜%dの範囲で指定してください: %d", config.MaxOpenConns, *parallel)
+	}
+	if *concurrency < 1 || *concRounds < 1 {
+		return fatal(exitError, "-concurrency と -concurrency-rounds は1以上を指定してください: %d, %d", *concurrency, *concRounds)
+	}
+	if *concurrency > 1 && *parallel > 1 {
+		return fatal(exitError, "-concurrency は -parallel と同時に指定できません（並列に実行中のシナリオの負荷がレイテンシに混ざるため）")
+	}
+	isolation, err := service.ParseIsolation(*isolationName)
+	if err != nil {
+		return fatal(exitError, "-isolation の指定が正しくありません: %v", err)
+	}
+
+	if *iterations < 1 {
+		return fatal(exitError, "-iterations は1以上を指定してください: %d", *iterations)
+	}
+	if *interleave && *iterations < 2 {
+		return fatal(exitError, "-interleave には -iterations=2 以上を指定してください")
+	}
+	if *repeat < 1 {
+		return fatal(exitError, "-repeat は1以上を指定してください: %d", *repeat)
+	}
+	if *pageSize < 1 || *pages < 1 {
+		return fatal(exitError, "-page-size と -pages は1以上を指定してください: %d, %d", *pageSize, *pages)
+	}
+	limits := repository.Limits{MaxOrders: *maxOrders, MaxEmployees: *maxEmployees}
+	if err := limits.Validate(); err != nil {
+		return fatal(exitError, "-max-orders / -max-employees は0以上を指定してください: %v", err)
+	}
+	// ウォークスルーの教材（入力を待ちながら進めるため並列実行やJSON出力とは組み合わせない）
+	var lessons []lesson.Lesson
+	if *walkthrough {
+		if *jsonMode || *parallel > 1 {
+			return fatal(exitError, "-walkthrough は -json / -parallel と同時に指定できません")
+		}
+		catalog, err := lesson.Load()
+		if err != nil {
+			return fatal(exitError, "教材カタログを読み込めません: %v", err)
+		}
+		if lessons, err = selectLessons(catalog, *lessonID); err != nil {
+			return fatal(exitError, "-lesson の指定が正しくありません: %v", err)
+		}
+	} else if *lessonID != "" {
+		return fatal(exitError, "-lesson には -walkthrough を指定してください")
+	}
+
+	// クイズのシナリオ（ウォークスルーと同じく入力を待つ）
+	var quizScenarios []service.QuizScenario
+	if *quiz {
+		if *walkthrough || *jsonMode || *parallel > 1 {
+			return fatal(exitError, "-quiz は -walkthrough / -json / -parallel と同時に指定できません")
+		}
+		if quizScenarios, err = selectQuizScenarios(*quizScenario); err != nil {
+			return fatal(exitError, "-quiz-scenario の指定が正しくありません: %v", err)
+		}
+	} else if *quizScenario != "" {
+		return fatal(exitError, "-quiz-scenario には -quiz を指定してください")
+	}
+	if (*shuffle || *readWriteMix != "" || *quiz) && *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
+	}
+	var shuffler *service.Shuffler
+	if *shuffle {
+		shuffler = service.NewShuffler(*seed)
+	}
+
+	// 独自のキャッシュ実装（キャッシュテストに追加する）
+	backendNames, err := backend.ParseNames(*cacheBackends)
+	if err != nil {
+		return fatal(exitError, "-cache-backends の指定が正しくありません: %v", err)
+	}
+	if len(backendNames) > 0 && !*cacheTest && !*cacheOnly {
+		return fatal(exitError, "-cache-backends には -cache-test または -cache-only を指定してください")
+	}
+	if *peerWorkers < 0 {
+		return fatal(exitError, "-groupcache-workers には0以上を指定してください")
+	}
+	groupcache.SetWorkers(*peerWorkers)
+	if *fkAllTables && !*cacheTest && !*cacheOnly {
+		return fatal(exitError, "-fk-all-tables には -cache-test または -cache-only を指定してください")
+	}
+
+	// PL/SQL Function Result Cacheテストで作成する関数
+	plsqlFunction := service.PLSQLFunctionOptions{Prefix: *plsqlPrefix, Suffix: *plsqlSuffix, Disabled: *noPLSQL, Keep: *keepPLSQL}
+	if err := plsqlFunction.Validate(); err != nil {
+		return fatal(exitError, "-plsql-prefix / -plsql-suffix の指定が正しくありません: %v", err)
+	}
+
+	// 読み書き混在ワークロード（キャッシュテストに追加する）
+	var mixConfig *cache.ReadWriteMixConfig
+	if *readWriteMix != "" {
+		if !*cacheTest && !*cacheOnly {
+			return fatal(exitError, "-read-write-mix には -cache-test または -cache-only を指定してください")
+		}
+		writeRatio, err := cache.ParseReadWriteRatio(*readWriteMix)
+		if err != nil {
+			return fatal(exitError, "-read-write-mix の指定が正しくありません: %v", err)
+		}
+		if *readWriteOps < 1 {
+			return fatal(exitError, "-read-write-ops は1以上を指定してください: %d", *readWriteOps)
+		}
+		mixConfig = &cache.ReadWriteMixConfig{Operations: *readWriteOps, WriteRatio: writeRatio, Seed: *seed}
+	}
+
+	// 実行した文の記録（書き出し先はデータベースに接続するときに開く）
+	queryLogRedactMode, err := querylog.ParseRedact(*queryLogRedact)
+	if err != nil {
+		return fatal(exitError, "-query-log-redact の指定が正しくありません: %v", err)
+	}
+	if *queryLog == "stdout" && *jsonMode {
+		return fatal(exitError, "-query-log=stdout は -json と同時に指定できません（-query-log=stderr を使ってください）")
+	}
+
+	// 問い合わせを固定する時点（フラッシュバック・モードの接続では更新やDDLを実行できない）
+	asOfSpec, err := flashback.Parse(*asOf)
+	if err != nil {
+		return fatal(exitError, "-as-of の指定が正しくありません: %v", err)
+	}
+	if asOfSpec != nil && (*cacheTest || *cacheOnly || *ingestDir != "") {
+		return fatal(exitError, "-as-of は -cache-test / -cache-only / -ingest-dir と同時に指定できません（キャッシュテストの更新・PL/SQL関数の作成と取り込みができないため）")
+	}
+
+	// 月額コストの単価モデル
+	var costModel *costmodel.Model
+	if *costModelPath != "" {
+		model, err := costmodel.Load(*costModelPath)
+		if err != nil {
+			return fatal(exitError, "-cost-model の指定が正しくありません: %v", err)
+		}
+		costModel = &model
+	}
+
+	if *capacityRPS < 0 {
+		return fatal(exitError, "-capacity-rps は0以上を指定してください: %g", *capacityRPS)
+	}
+
+	// 計測前のリセット（インスタンス全体に効くため並列実行とは組み合わせない）
+	resetPolicy, err := service.ParseResetPolicy(*resetKinds, *resetScope, *resetSleep, *resetSession)
+	if err != nil {
+		return fatal(exitError, "リセットの指定が正しくありません: %v", err)
+	}
+	if resetPolicy.Enabled() && *parallel > 1 {
+		return fatal(exitError, "-reset / -reset-sleep / -reset-session は -parallel と同時に指定できません（他のシナリオの計測中にキャッシュをフラッシュしてしまうため）")
+	}
+
+	// 計測前のウォームアップ（手法ごとにキャッシュをフラッシュすると温めた状態が最初の手法にしか残らない）
+	warmupPolicy, err := service.ParseWarmupPolicy(*warmup)
+	if err != nil {
+		return fatal(exitError, "-warmup の指定が正しくありません: %v", err)
+	}
+	if warmupPolicy.Enabled() && resetPolicy.Scope == service.ResetPerMethod && (resetPolicy.FlushBufferCache || resetPolicy.FlushResultCache) {
+		return fatal(exitError, "-warmup は手法ごとのキャッシュのフラッシュ（-reset-scope=method）と同時に指定できません。-reset-scope=scenario を指定してください")
+	}
+
+	// RACのインスタンス固定（シナリオごとに別の接続プールを使うため並列実行とは組み合わせない）
+	var racPins map[string]string
+	if *racPin != "" {
+		racPins, err = rac.ParsePins(*racPin)
+		if err == nil {
+			err = service.ValidateRACPins(racPins)
+		}
+		if err != nil {
+			return fatal(exitError, "-rac-pin の指定が正しくありません: %v", err)
+		}
+		if *parallel > 1 {
+			return fatal(exitError, "-rac-pin は -parallel と同時に指定できません")
+		}
+		*racOn = true
+	}
+
+	// 同じスキーマでの計測の同時実行を防ぐ実行ロック
+	lockOpts := runlock.Options{Wait: *lockWait, StaleAfter: *lockStaleAfter}
+	if err := lockOpts.Validate(); err != nil {
+		return fatal(exitError, "実行ロックの指定が正しくありません: %v", err)
+	}
+
+	// プロセス内の結果キャッシュ（-go-result-cache-ttl が0なら比較しない）
+	resultCacheOpts := resultcache.Options{TTL: *goResultTTL, MaxEntries: *goResultMax}
+	if *goResultTTL != 0 {
+		if err := resultCacheOpts.Validate(); err != nil {
+			return fatal(exitError, "プロセス内の結果キャッシュの指定が正しくありません: %v", err)
+		}
+	}
+
+	// 署名鍵がないまま長時間の計測を始めないよう先に確認する
+	if *sign && len(config.LoadSigningKey()) == 0 {
+		return fatal(exitError, "-sign には RESULT_SIGNING_KEY の設定が必要です")
+	}
+
+	// 送信先の指定誤りや認証情報の不足も計測前に検出する
+	sinks, err := sink.ParseList(*sinkSpecs)
+	if err != nil {
+		return fatal(exitError, "-sink の指定が正しくありません: %v", err)
+	}
+	for _, sk := range sinks {
+		if _, ok := sk.(*sink.StdoutSink); ok && *jsonMode {
+			return fatal(exitError, "-sink=stdout は -json と同時に指定できません（-sink=file:DIR を使ってください）")
+		}
+	}
+	if *envName == "" {
+		*envName = config.LoadEnvironmentName()
+	}
+
+	// 匿名化した改善率の送信（明示的に指定した場合のみ）
+	if *telemetryOn {
+		if *telemetryURL == "" {
+			*telemetryURL = config.LoadTelemetryEndpoint()
+		}
+		if err := telemetry.ValidateEndpoint(*telemetryURL); err != nil {
+			return fatal(exitError, "-telemetry には -telemetry-endpoint またはTELEMETRY_ENDPOINTで送信先を指定してください: %v", err)
+		}
+	} else if *telemetryURL != "" {
+		return fatal(exitError, "-telemetry-endpoint には -telemetry を指定してください")
+	}
+	startedAt := time.Now().Format("20060102-150405")
+	resultsName := fmt.Sprintf("results-%s.json", startedAt)
+	if *envName != "" {
+		resultsName = fmt.Sprintf("results-%s-%s.json", *envName, startedAt)
 	}
 
 	// アプリケーション開始
 	fmt.Println("Oracle N+1問題 & キャッシュ性能デモンストレーション")
 	fmt.Println("===============================================")
 
+	// Ctrl-C（SIGINT/SIGTERM）では新しい計測を止め、結果を書き出して接続とデモのオブジェクトを片付けてから終了する
+	sd := newShutdown()
+	defer sd.finish()
+
 	// 設定読み込み
 	fmt.Println("設定を読み込み中...")
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("設定の読み込みに失敗しました: %v", err)
+		return fatal(exitError, "設定の読み込みに失敗しました（config validate で読み込み元と直し方を確認できます）: %v", err)
+	}
+
+	queryLogOpts := querylog.Options{Redact: queryLogRedactMode, IncludeDictionary: *queryLogAll}
+	var recorders []querylog.Recorder
+	if *queryLog != "" {
+		logger, closeLog, err := openQueryLog(*queryLog, queryLogOpts)
+		if err != nil {
+			return fatal(exitError, "-query-log の書き出し先を開けません: %v", err)
+		}
+		sd.onClose("クエリログ", closeLog)
+		recorders = append(recorders, logger)
+	}
+	var tracer *chrometrace.Recorder
+	if *tracePath != "" {
+		// 接続プールを閉じた後（登録と逆の順）に書き出すため、中断時も実行した文までを残せる
+		tracer = chrometrace.New(queryLogOpts)
+		sd.onClose("トレース", func() error { return writeTrace(tracer, *tracePath) })
+		recorders = append(recorders, tracer)
+	}
+	var queryCounter *querylog.Counter
+	if *queryCount {
+		queryCounter = querylog.NewCounter()
+		recorders = append(recorders, queryCounter)
+	}
+	var planCapture *explain.Capture
+	if *explainPlans || *planStats {
+		planCapture = explain.NewCapture()
+		recorders = append(recorders, planCapture)
+	}
+	cfg.PlanStatistics = *planStats
+	if len(recorders) > 0 {
+		cfg.QueryLog = querylog.Tee(recorders...)
 	}
 
 	// データベース接続
 	fmt.Println("データベースに接続中...")
 	db, err := config.ConnectDatabase(cfg)
 	if err != nil {
-		log.Fatalf("データベース接続に失敗しました: %v", err)
+		return fatal(exitConnectivity, "データベース接続に失敗しました: %w", err)
 	}
-	defer func() {
-		if err := db.Close(); err != nil {
-			log.Printf("データベースクローズエラー: %v", err)
-		}
-	}()
+	sd.onClose("データベース", db.Close)
 
 	// 接続テスト
 	if err := db.Ping(); err != nil {
-		log.Fatalf("データベース接続テストに失敗しました: %v", err)
+		return fatal(exitConnectivity, "データベース接続テストに失敗しました: %w", err)
 	}
 	fmt.Println("データベース接続成功！")
+	if !*noRunLock {
+		lock, err := acquireRunLock(sd.ctx, db, lockOpts, *breakStaleLock)
+		if err != nil {
+			return fatal(exitLocked, "実行ロックを取得できません: %w", err)
+		}
+		if lock != nil {
+			sd.onClose("実行ロック", lock.Release)
+		}
+	}
+	if asOfSpec != nil {
+		// 実行ロックは元の接続プールで保持したまま、計測はSCNに固定した接続プールで行う
+		if db, err = openFlashback(sd.ctx, db, cfg, *asOfSpec); err != nil {
+			return fatal(exitError, "フラッシュバック問合せの準備に失敗しました: %w", err)
+		}
+		sd.onClose("フラッシュバック問合せの接続プール", db.Close)
+	}
+	if settings := cfg.Session.String(); settings != "" {
+		fmt.Printf("セッション設定: %s\n", settings)
+	}
+	runRecord.markStart(db)
+
+	// ユーザー提供データの取り込み
+	if *ingestDir != "" {
+		if err := runIngest(db, *ingestDir, *ingestBatch); err != nil {
+			return fatal(exitError, "データの取り込みに失敗しました: %w", err)
+		}
+		fmt.Println()
+	}
 
 	// サービスの初期化
 	demoService := service.NewDemoService(db)
+	demoService.SetContext(sd.ctx)
+	sd.onClose("ステートメントキャッシュ", demoService.Close)
+	demoService.EnableSessionStats(*sessionStats)
+	if queryCounter != nil {
+		demoService.EnableQueryCount(queryCounter)
+	}
+	if *explainPlans {
+		demoService.EnableExplain(planCapture)
+	}
+	if *planStats {
+		demoService.EnablePlanStatistics(planCapture)
+	}
+	if *goResultTTL > 0 {
+		// 計測に使う接続プールとは別に、結果キャッシュで包んだ接続プールを作る（ほかの手法はキャッシュしない）
+		cachedCfg := *cfg
+		cachedCfg.ResultCache = resultcache.New(resultCacheOpts)
+		cachedDB, err := config.ConnectDatabase(&cachedCfg)
+		if err != nil {
+			return fatal(exitConnectivity, "プロセス内の結果キャッシュの接続プールを作成できません: %w", err)
+		}
+		sd.onClose("プロセス内の結果キャッシュの接続プール", cachedDB.Close)
+		demoService.EnableGoResultCache(cachedDB, cachedCfg.ResultCache)
+	}
+	if *racOn {
+		pinnedDBs, closePinned, err := openRACPins(db, cfg, racPins)
+		if err != nil {
+			return fatal(exitConnectivity, "RACのインスタンス固定に失敗しました: %w", err)
+		}
+		sd.onClose("インスタンス固定の接続プール", func() error { closePinned(); return nil })
+		demoService.EnableRAC(pinnedDBs)
+	}
+	demoService.EnablePayloadTiming(*payload)
+	demoService.SetResetPolicy(resetPolicy)
+	demoService.SetWarmupPolicy(warmupPolicy)
+	demoService.SetIterations(*iterations, *interleave)
+	demoService.SetConcurrency(*concurrency, *concRounds)
+	demoService.SetCostModel(costModel)
+	demoService.SetCapacityTarget(*capacityRPS)
+	demoService.SetLimits(limits)
+	demoService.SetFlashbackSCN(cfg.Session.FlashbackSCN)
+	demoService.SetTrace(tracer)
+	if limits.MaxOrders > 0 || limits.MaxEmployees > 0 {
+		fmt.Printf("件数の上限: 受注 %s、社員 %s（すべての手法に同じ条件で適用）\n",
+			formatLimit(limits.MaxOrders, "件"), formatLimit(limits.MaxEmployees, "人"))
+	}
 	cacheService := service.NewCacheService(db, cfg)
+	cacheService.SetContext(sd.ctx)
+	sd.onClose("キャッシュテストのPL/SQL関数・Redis接続・独自のキャッシュ実装", cacheService.Close)
+	cacheService.SetCostModel(costModel)
+	cacheService.SetPLSQLFunctionOptions(plsqlFunction)
+	cacheService.SetForeignKeyScope(*fkAllTables)
+	if err := cacheService.SetCacheBackends(backendNames); err != nil {
+		return fatal(exitError, "独自のキャッシュ実装を作成できません: %v", err)
+	}
+	if err := cacheService.RedisError(); err != nil {
+		fmt.Printf("Redis接続に失敗しました（キャッシュ比較はスキップされます）: %v\n", err)
+	}
+
+	// 目標実行時間に合わせたワークロードの自動調整（明示した-days/-monthsは優先する）
+	if *target > 0 {
+		size, err := demoService.CalibrateWorkload(*target)
+		if err != nil {
+			log.Printf("ワークロードの自動調整に失敗したため指定値で実行します: %v", err)
+		} else {
+			explicit := make(map[string]bool)
+			flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+			if !explicit["days"] {
+				*days = size.Days
+			}
+			if !explicit["months"] {
+				*months = size.Months
+			}
+		}
+		fmt.Println()
+	}
+
+	// 実行メタデータ（エクスポートする結果に添付する）
+	var meta *runmeta.Metadata
+	if *statsJSON != "" || *resultsJSON != "" || *resultsBench != "" || *output != "" || len(sinks) > 0 || *jsonMode || *bundlePath != "" || *telemetryOn {
+		meta = runmeta.Collect(db, cfg)
+		meta.Environment = *envName
+	}
+	runParams := func() service.RunParameters {
+		params := service.RunParameters{
+			Days: *days, Months: *months, SessionStats: *sessionStats, Payload: *payload,
+			MaxOrders: limits.MaxOrders, MaxEmployees: limits.MaxEmployees, RACPins: racPins,
+		}
+		if resetPolicy.Enabled() {
+			params.Reset = &resetPolicy
+		}
+		if warmupPolicy.Enabled() {
+			params.Warmup = &warmupPolicy
+		}
+		if *iterations > 1 {
+			params.Iterations = *iterations
+			params.Interleave = *interleave
+		}
+		if *repeat > 1 {
+			params.Repeat = *repeat
+		}
+		if shuffler != nil {
+			params.Seed = shuffler.Seed()
+		}
+		return params
+	}
+	suite := func() suiteOptions {
+		return suiteOptions{
+			days:         *days,
+			months:       *months,
+			topCustomers: *topCustomers,
+			recentOrders: *recentOrders,
+			pageSize:     *pageSize,
+			pages:        *pages,
+			parallel:     *parallel,
+			isolation:    isolation,
+			repeat:       *repeat,
+			shuffler:     shuffler,
+		}
+	}
+	writeExports := func() {
+		if *resultsJSON == "" && *resultsBench == "" && *output == "" && len(sinks) == 0 {
+			return
+		}
+		params := runParams()
+		if *resultsJSON != "" {
+			if err := demoService.ExportResults(*resultsJSON, meta, params); err != nil {
+				log.Printf("計測結果の出力中にエラー: %v", err)
+			} else {
+				fmt.Printf("計測結果を出力しました: %s\n", *resultsJSON)
+				signExport(*sign, *resultsJSON)
+			}
+		}
+		if *resultsBench != "" {
+			if err := demoService.ExportBenchmarkResults(*resultsBench, meta, params); err != nil {
+				log.Printf("計測結果の出力中にエラー: %v", err)
+			} else {
+				fmt.Printf("計測結果をベンチマーク形式で出力しました: %s\n", *resultsBench)
+				signExport(*sign, *resultsBench)
+			}
+		}
+		if *output != "" {
+			results := export.Build(meta, params, demoService.ResultsSince(0), cacheService.Results())
+			if err := export.WriteFile(*outputFile, *output, results); err != nil {
+				log.Printf("計測結果の出力中にエラー: %v", err)
+			} else {
+				fmt.Printf("計測結果を%s形式で出力しました: %s\n", *output, *outputFile)
+				signExport(*sign, *outputFile)
+			}
+		}
+		if len(sinks) > 0 {
+			data, err := demoService.MarshalResults(meta, params)
+			if err != nil {
+				log.Printf("計測結果の変換中にエラー: %v", err)
+				return
+			}
+			publishExport(sinks, *sign, resultsName, data)
+		}
+	}
+	// 中断時と正常終了時の両方から呼ばれ得るため、書き出しは1回だけ行う
+	var exportOnce sync.Once
+	exportResults := func() { exportOnce.Do(writeExports) }
+	sd.setFlush(func() {
+		exportResults()
+		runRecord.write(demoService, db, meta, runParams(), *sign)
+		reporter.emit(runDocument{
+			Status:        runInterrupted,
+			ExitCode:      exitInterrupted,
+			ResultsReport: demoService.BuildResultsReport(meta, runParams()),
+		})
+	})
 
 	// データベース統計情報の表示
-	if *showStats {
-		if err := demoService.GetDatabaseStats(); err != nil {
+	if *showStats || *statsJSON != "" {
+		stats, err := demoService.GetDatabaseStats()
+		if err != nil {
 			log.Printf("データベース統計の取得中にエラー: %v", err)
+		} else if *statsJSON != "" {
+			stats.Metadata = meta
+			if err := demoService.ExportDatabaseStats(stats, *statsJSON); err != nil {
+				log.Printf("データベース統計の出力中にエラー: %v", err)
+			} else {
+				fmt.Printf("統計情報を出力しました: %s\n", *statsJSON)
+				signExport(*sign, *statsJSON)
+			}
 		}
 		fmt.Println()
 	}
@@ -83,31 +741,167 @@ func main() {
 
 	// 実行モードに応じた処理
 	switch {
+	case *walkthrough:
+		// 研修向けのウォークスルー
+		runWalkthrough(demoService, lessons, *days, os.Stdin)
+	case *quiz:
+		// 研修向けのクイズ（-seed を指定すると同じ手法の並びを再現する）
+		demoService.SetShuffler(service.NewShuffler(*seed))
+		runQuiz(demoService, quizScenarios, *days, os.Stdin)
 	case *cacheOnly:
 		// キャッシュテストのみ
-		runCacheTests(cacheService, *benchmarkRuns)
-	case *cacheTest && !*orderOnly && !*employeeOnly:
+		runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+	case *cacheTest && !*orderOnly && !*employeeOnly && !*projectOnly && !*salesOnly && !*topOnly && !*windowOnly && !*lobOnly && !*compositeOnly && !*pruningOnly && !*paginationOnly && !*softDeleteOnly && !*sharedPool:
 		// 全テスト + キャッシュテスト
-		runAllTests(demoService, *days)
-		runCacheTests(cacheService, *benchmarkRuns)
+		runAllTests(demoService, suite(), sd)
+		runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
 	case *orderOnly:
 		// 受注データのみ
 		runOrderTests(demoService, *days)
 		if *cacheTest {
-			runCacheTests(cacheService, *benchmarkRuns)
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
 		}
 	case *employeeOnly:
 		// 社員データのみ
 		runEmployeeTests(demoService)
 		if *cacheTest {
-			runCacheTests(cacheService, *benchmarkRuns)
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *projectOnly:
+		// 社員・プロジェクト（多対多）のみ
+		runProjectTests(demoService)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *topOnly:
+		// 売上上位顧客（Top-N）のみ
+		runTopCustomersTests(demoService, *topCustomers, *recentOrders)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *windowOnly:
+		// 分析関数のみ
+		runWindowFunctionTests(demoService, *days)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *lobOnly:
+		// LOB列のみ
+		runLOBTests(demoService, *days)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *compositeOnly:
+		// 3階層の取得のみ
+		runCompositeFetchTests(demoService, *days)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *sharedPool:
+		// 共有プール負荷のみ（共有プールを汚すため全体実行には含めない）
+		runSharedPoolTests(demoService, *days, *workers)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *pruningOnly:
+		// SELECT列の絞り込みのみ
+		runColumnPruningTests(demoService, *days)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *paginationOnly:
+		// ページングと件数のみ
+		runPaginationTests(demoService, *days, *pageSize, *pages)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *softDeleteOnly:
+		// 論理削除と部分索引のみ
+		runSoftDeleteTests(demoService, *days)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
+		}
+	case *salesOnly:
+		// 月次売上レポートのみ
+		runSalesReportTests(demoService, *months)
+		if *cacheTest {
+			runCacheTests(cacheService, cachePresenter, *benchmarkRuns, mixConfig)
 		}
 	default:
 		// デフォルト：N+1問題のテストのみ
-		runAllTests(demoService, *days)
+		runAllTests(demoService, suite(), sd)
 	}
 
+	if sd.interrupted() {
+		// 結果の書き出しと後片付けはシグナルを受け取った側が行う
+		return exitInterrupted
+	}
+	displayDemoProjections(demoService)
+	exportResults()
+	if *telemetryOn {
+		sendTelemetry(db, demoService, meta, *telemetryURL)
+	}
 	fmt.Println("\nデモンストレーション完了！")
+
+	v := judgeRun(conds, baseline, demoService.ResultsSince(0), cacheService.OverallEfficiency, *regressionPct, *minCacheEff)
+	v.display()
+	runRecord.write(demoService, db, meta, runParams(), *sign)
+
+	doc := runDocument{
+		Status:        runCompleted,
+		ExitCode:      v.ExitCode,
+		ResultsReport: demoService.BuildResultsReport(meta, runParams()),
+	}
+	if conds.regression || conds.cache {
+		doc.Verdict = v
+	}
+	reporter.emit(doc)
+
+	return v.ExitCode
+}
+
+// signExport - 出力したファイルに署名を付ける（-sign 指定時のみ）
+func signExport(enabled bool, path string) {
+	if !enabled {
+		return
+	}
+	sigPath, err := signing.SignFile(path, config.LoadSigningKey())
+	if err != nil {
+		log.Printf("署名の作成に失敗しました（%s）: %v", path, err)
+		return
+	}
+	fmt.Printf("署名を出力しました: %s\n", sigPath)
+}
+
+// publishExport - 送信先に結果を書き出す（-sign 指定時は <名前>.sig も送る）
+func publishExport(sinks []sink.Sink, sign bool, name string, data []byte) {
+	ctx := context.Background()
+	if err := sink.WriteAll(ctx, sinks, name, data); err != nil {
+		log.Printf("計測結果の送信に失敗しました: %v", err)
+	} else {
+		fmt.Printf("計測結果を送信しました: %s → %s\n", name, sinkNames(sinks))
+	}
+
+	if !sign {
+		return
+	}
+	sigData, err := signing.Sign(name, data, config.LoadSigningKey())
+	if err != nil {
+		log.Printf("署名の作成に失敗しました（%s）: %v", name, err)
+		return
+	}
+	if err := sink.WriteAll(ctx, sinks, name+signing.SignatureSuffix, sigData); err != nil {
+		log.Printf("署名の送信に失敗しました: %v", err)
+	}
+}
+
+// sinkNames - 送信先の表示用文字列
+func sinkNames(sinks []sink.Sink) string {
+	names := make([]string, len(sinks))
+	for i, s := range sinks {
+		names[i] = s.String()
+	}
+	return strings.Join(names, ", ")
 }
 
 // showHelp - ヘルプメッセージを表示
@@ -117,23 +911,110 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("使用方法:")
 	fmt.Printf("  %s [オプション]\n", os.Args[0])
+	fmt.Printf("  %s <コマンド> [オプション]\n", os.Args[0])
+	fmt.Println()
+	showCommands()
 	fmt.Println()
 	fmt.Println("オプション:")
 	fmt.Println("  -days=30          取得する受注データの日数（デフォルト: 30日）")
+	fmt.Println("  -max-orders=500   扱う受注を新しい順に500件までに絞る（すべての手法に同じ条件を適用し、大きなデータでも短時間で終わらせる）")
+	fmt.Println("  -max-employees=200 扱う社員を社員ID順に200人までに絞る（すべての手法に同じ条件を適用）")
+	fmt.Println("  -as-of=start      すべての手法を実行開始時のSCNのデータで計測する（SCNの数値や YYYY-MM-DD HH24:MI:SS も指定可。SCNは実行メタデータに記録）")
 	fmt.Println("  -sample           サンプルデータを表示する")
 	fmt.Println("  -stats            データベース統計情報を表示する")
+	fmt.Println("  -stats-json=FILE  データベース統計情報をJSONファイルに出力する")
+	fmt.Println("  -sign             出力した結果ファイル（-results-json・-stats-json・-results-bench・-output など）にHMAC署名（<ファイル>.sig）を付ける（RESULT_SIGNING_KEYが必要）")
+	fmt.Println("  -results-json=FILE 計測結果を実行メタデータ（バージョン・環境・接続設定）付きでJSONファイルに出力する")
+	fmt.Println("  -results-bench=FILE 計測結果をGoのベンチマーク形式で出力する（benchstatで実行どうしを比較）")
+	fmt.Println("  -output=csv       シナリオとキャッシュ比較の計測結果を改善率付きで出力する（json, csv, markdown）")
+	fmt.Println("  -output-file=FILE -output の出力先（省略時は benchmark_results.csv など）")
+	fmt.Println("  -env=NAME         結果に記録する実行環境名（省略時はBENCH_ENVIRONMENT、matrixコマンドで環境間を比較）")
+	fmt.Println("  -sink=SPEC,...    計測結果の送信先（stdout, file:DIR, https://URL へPOST, s3://BUCKET/PREFIX, oci-par:PAR_URL）")
 	fmt.Println("  -order-only       受注データのパフォーマンステストのみ実行")
 	fmt.Println("  -employee-only    社員データのパフォーマンステストのみ実行")
+	fmt.Println("  -project-only     社員・プロジェクト（多対多）のパフォーマンステストのみ実行")
+	fmt.Println("  -sales-only       月次売上レポートの集計方法比較のみ実行")
+	fmt.Println("  -months=12        月次売上レポートの対象月数（デフォルト: 12か月）")
+	fmt.Println("  -top-only         売上上位顧客と直近受注（Top-N）のテストのみ実行")
+	fmt.Println("  -top-customers=10 Top-Nテストの上位顧客数")
+	fmt.Println("  -recent-orders=5  Top-Nテストで顧客ごとに取得する直近受注数")
+	fmt.Println("  -window-only      分析関数（累計・順位・LAG/LEAD）とアプリ側ループの比較のみ実行")
+	fmt.Println("  -lob-only         LOB列（受注備考CLOB）を含むJOIN・バッチ・遅延取得の比較のみ実行")
+	fmt.Println("  -composite-only   受注・明細・商品（3階層）の入れ子N+1・3クエリ・多段JOINの比較のみ実行")
+	fmt.Println("  -sharedpool-only  リテラル埋め込みとバインド変数のN+1を並行実行して共有プール負荷を比較（全体実行には含まない）")
+	fmt.Println("  -workers=8        共有プール負荷比較の並列数")
+	fmt.Println("  -pruning-only     SELECT * による過剰取得と必要な列のみのSELECTの比較のみ実行")
+	fmt.Println("  -pagination-only  ページごとの件数クエリ・COUNT(*) OVER ()・推定件数の比較のみ実行")
+	fmt.Println("  -page-size=20 -pages=10 ページングの比較の1ページの件数と、先頭からめくるページ数")
+	fmt.Println("  -soft-delete-only 論理削除された受注の除外と部分索引（関数索引）の比較のみ実行（全体実行には含まない）")
+	fmt.Println("  -payload          JSONレスポンス生成（Go側のエンコード）までを含めたエンドツーエンドの時間を計測")
+	fmt.Println("  -target=10s       シナリオあたりの目標実行時間。少量のサンプルで計測して-days/-monthsを自動調整")
+	fmt.Println("  -session-stats    手法ごとにV$MYSTATの転送量・ラウンドトリップ・論理読み取りを表示")
+	fmt.Println("  -rac              RAC環境で手法ごとの接続先インスタンスとgc待機（gc cr/current block ...）を表示")
+	fmt.Println("  -rac-pin=orders=ORCL1 シナリオを指定したインスタンスに固定して計測（-rac を含む）")
+	fmt.Println("  -no-run-lock      実行ロック（同じスキーマでの計測の同時実行の防止）を取得しない")
+	fmt.Println("  -lock-wait=5m     別の計測が実行ロックを保持している場合に最大5分待つ（デフォルト: 待たずに終了コード5で終了）")
+	fmt.Println("  -lock-stale-after=5m 実行ロックの保持者が5分以上応答していなければ古いロックとみなす")
+	fmt.Println("  -break-stale-lock 古い実行ロックの保持者のセッションを切断して取得し直す（ALTER SYSTEM権限が必要）")
+	fmt.Println("  -reset=result-cache,buffer-cache,reconnect 手法の計測前にキャッシュをフラッシュ・接続を張り直す")
+	fmt.Println("  -reset-scope=method リセットを行う単位（method: 手法ごと, scenario: シナリオの最初だけ）")
+	fmt.Println("  -reset-sleep=2s   リセット後に待機する時間")
+	fmt.Println("  -reset-session='ALTER SESSION SET ...' 手法ごとに計測する接続で実行するALTER SESSION文（セミコロン区切り）")
+	fmt.Println("  -warmup=scan      シナリオの計測前に対象の表を全表スキャンして温める（iteration: 各手法を計測せずに1回実行、orders=none のようにシナリオごとに指定）")
+	fmt.Println("  -iterations=5     手法ごとに5回計測し、実行時間は中央値を使う（N+1との有意差検定と効果量も表示）")
+	fmt.Println("  -interleave       -iterations の計測を手法ごとに連続せず A,B,A,B... と交互に実行し、基準（N+1）との回ごとの差と95%信頼区間を表示")
+	fmt.Println("  -repeat=3         全体実行を3回繰り返す")
+	fmt.Println("  -shuffle          繰り返しごとにシナリオと手法の実行順序を並べ替え、実行順による影響（キャッシュの温まり）を分析")
+	fmt.Println("  -seed=N           -shuffle・-read-write-mix・-quiz の乱数シード（表示されたシードを指定すると同じ順序を再現）")
+	fmt.Println("  -parallel=4       全体実行のシナリオを4並列で実行（詳細表示は省略し、完了したシナリオから結果を表示）")
+	fmt.Println("  -isolation=session 並列実行時の接続の分離（session: シナリオごとに専用の接続, pool: 接続プールを共有）")
 	fmt.Println("  -cache-test       キャッシュ性能比較テストを追加実行")
 	fmt.Println("  -cache-only       キャッシュテストのみ実行")
 	fmt.Println("  -benchmark-runs=10 ベンチマーク実行回数（デフォルト: 10回）")
+	fmt.Println("  -cache-sort=-time キャッシュ比較表を並べ替える列（method, time, hit_rate, description。- で降順）")
+	fmt.Println("  -cache-columns=method,time キャッシュ比較表に表示する列")
+	fmt.Println("  -cache-format=text キャッシュテスト結果の表示形式（text, json: 結果ごとに1行のJSON）")
+	fmt.Println("  -cache-backends=memory キャッシュテストに独自のキャッシュ実装（pkg/cache.Register で登録したもの）を加える")
+	fmt.Println("  -cache-backends=coherence,timesten キャッシュテストにOracle Coherence・TimesTen In-Memory Cacheを加える")
+	fmt.Println("  -cache-backends=groupcache -groupcache-workers=3 キャッシュテストに3つのピアプロセスとキーを分担するgroupcacheを加える")
+	fmt.Println("  -plsql-prefix=nplus1_ PL/SQL Function Result Cacheテストで作成する関数名の接頭辞（-plsql-suffix で接尾辞）")
+	fmt.Println("  -no-plsql-function PL/SQL関数を作成せず、PL/SQL Function Result Cacheテストをスキップする")
+	fmt.Println("  -keep-plsql-function PL/SQL Function Result Cacheテストで作成した関数をテスト後に削除しない")
+	fmt.Println("  -fk-all-tables    キャッシュテストの推奨事項で、外部キーの索引をスキーマのすべてのテーブルについて確認する（既定はデモのテーブルのみ）")
+	fmt.Println("  -read-write-mix=90/10 キャッシュテストに読み書き混在ワークロードを追加（書き込みによる無効化込みの実効ヒット率と整合性）")
+	fmt.Println("  -read-write-ops=500 読み書き混在ワークロードの手法ごとの操作数")
+	fmt.Println("  -cost-model=FILE  DB CPU秒・Redisインスタンス時間・転送量の単価から手法ごとの月額コストを見積もる（例: scripts/cost/cost_model.json）")
+	fmt.Println("  -capacity-rps=500 計測結果を500 req/sに外挿し、手法ごとに必要なDB CPUコア数・ラウンドトリップ数を見積もる")
+	fmt.Println("  -ingest-dir=DIR   DIR内のCSVをデモスキーマへ取り込んでから実行")
+	fmt.Println("  -ingest-batch=1000 取り込み時の配列バインド行数")
+	fmt.Println("  -json             装飾的な表示を抑止し、実行結果を1つのJSONとして標準出力に出す（ログは標準エラー）")
+	fmt.Println("  -fail-on=COND,... 判定結果を終了コードに反映（regression: -compareの結果より遅い, cache: キャッシュ効率が下限未満）")
+	fmt.Println("  -compare=FILE     回帰判定の基準にする以前の計測結果JSON")
+	fmt.Println("  -regression-threshold=20 回帰とみなす基準からの悪化率（%）")
+	fmt.Println("  -min-cache-efficiency=70 -fail-on=cache で許容する総合キャッシュ効率の下限（%）")
+	fmt.Println("  -walkthrough      研修向けに教材のステップごとに説明・実行するSQL・予想される動きを表示し、Enterを待って実行・観測結果を示す")
+	fmt.Println("  -lesson=orders    -walkthrough で進める教材のID（orders, employees。省略時はすべて）")
+	fmt.Println("  -query-log=stderr 実行したSQL・バインド変数・行数・時間を1文ずつ表示する（N+1では同じ文が受注の数だけ流れる。ファイル名も指定可）")
+	fmt.Println("  -query-log-redact=strings -query-log / -trace でバインド変数の文字列の値を伏せる（all: すべての値を伏せる）")
+	fmt.Println("  -query-log-all    -query-log / -trace にセッション統計の取得など計測のためのV$ビューへの問い合わせも含める")
+	fmt.Println("  -trace=trace.json 手法の実行区間と各SQLの区間をChrome trace形式で書き出す（chrome://tracing やPerfettoでN+1のループとJOINを時間軸で比較）")
+	fmt.Println("  -bundle=run.tar.gz コンソール出力・計測結果・実行計画（DBMS_XPLAN）・設定を1つのアーカイブにまとめる（チケットへの添付・共有用）")
+	fmt.Println("  -telemetry        匿名化した改善率（N+1比）と環境の区分（XE/EE等・データ量の区分）を送信する（オプトイン、送信内容は実行時に表示）")
+	fmt.Println("  -telemetry-endpoint=URL -telemetry の送信先（省略時はTELEMETRY_ENDPOINT）")
+	fmt.Println("  -quiz             研修向けに手法名を伏せて計測結果を示し、N+1問題のある手法を当てるクイズを出す（回答を判定して解説を表示）")
+	fmt.Println("  -quiz-scenario=orders -quiz で出題するシナリオ（orders, employees, employee_projects。省略時はすべて）")
 	fmt.Println("  -help             このヘルプを表示する")
 	fmt.Println()
+	fmt.Println("終了コード:")
+	fmt.Println("  0 正常終了 / 1 エラー / 2 回帰を検出 / 3 キャッシュ効率が下限未満 / 4 データベースに接続できない / 130 中断")
+	fmt.Println()
 	fmt.Println("使用例:")
 	fmt.Printf("  %s -days=7 -sample              # 過去7日間の受注データでテスト、サンプル表示\n", os.Args[0])
 	fmt.Printf("  %s -order-only -stats           # 受注データのみテスト、統計表示\n", os.Args[0])
 	fmt.Printf("  %s -cache-test                  # N+1テスト + キャッシュ性能比較\n", os.Args[0])
 	fmt.Printf("  %s -cache-only -benchmark-runs=20 # キャッシュテストのみ20回実行\n", os.Args[0])
+	fmt.Printf("  %s -ingest-dir=./data -stats    # 独自データを取り込んでテスト\n", os.Args[0])
+	fmt.Printf("  %s verify-schema                # スキーマのドリフトを検出\n", os.Args[0])
 	fmt.Println()
 	fmt.Println("環境設定:")
 	fmt.Println("  .envファイルまたは環境変数でOracle接続情報を設定してください。")
@@ -141,49 +1022,160 @@ func showHelp() {
 	fmt.Println("    - DB_HOST: Oracleサーバーのホスト名")
 	fmt.Println("    - DB_PORT: ポート番号（デフォルト: 1521）")
 	fmt.Println("    - DB_SERVICE_NAME: サービス名")
+	fmt.Println("    - DB_INSTANCE_NAME: RACで接続先を固定するインスタンス名（オプション）")
 	fmt.Println("    - DB_USERNAME: ユーザー名")
 	fmt.Println("    - DB_PASSWORD: パスワード")
+	fmt.Println("    - NLS_DATE_FORMAT: 接続ごとに設定する日付書式（デフォルト: YYYY-MM-DD HH24:MI:SS）")
+	fmt.Println("    - NLS_TERRITORY / DB_TIME_ZONE: 接続ごとに設定する地域とタイムゾーン（オプション、未設定ならサーバーの既定）")
 	fmt.Println("    - REDIS_HOST: Redisサーバーのホスト名（オプション）")
 	fmt.Println("    - REDIS_PORT: Redisポート番号（オプション）")
+	fmt.Println("    - COHERENCE_URL / COHERENCE_CACHE: Coherence RESTのベースURLとキャッシュ名（-cache-backends=coherence）")
+	fmt.Println("    - TELEMETRY_ENDPOINT: -telemetry の送信先URL（オプション）")
+	fmt.Println("    - TIMESTEN_DRIVER / TIMESTEN_DSN: TimesTenのdatabase/sqlドライバー名と接続文字列（-cache-backends=timesten）")
+}
+
+// runIngest - CSVファイルをデモスキーマへ取り込む
+func runIngest(db *sql.DB, dir string, batchSize int) error {
+	fmt.Printf("\n=== データ取り込み（%s）===\n", dir)
+
+	loader := ingest.NewLoader(db, batchSize)
+	results, err := loader.LoadDirectory(dir)
+	for _, result := range results {
+		fmt.Printf("%s: %d件 (%dバッチ, %v) ← %s\n",
+			result.Table, result.Rows, result.Batches, result.Duration, result.File)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("オプティマイザ統計を更新中...")
+	if err := loader.GatherStats(results); err != nil {
+		log.Printf("統計情報の更新に失敗しました: %v", err)
+	}
+
+	return nil
 }
 
 // runCacheTests - キャッシュ性能比較テストを実行
-func runCacheTests(cacheService *service.CacheService, benchmarkRuns int) {
+func runCacheTests(cacheService *service.CacheService, p presenter.Presenter, benchmarkRuns int, mixConfig *cache.ReadWriteMixConfig) {
 	fmt.Printf("\n=== キャッシュ性能比較テスト（%d回実行）===\n", benchmarkRuns)
 	fmt.Println("Oracle内蔵キャッシュ vs 外部キャッシュ(Redis) の性能を比較します")
 	fmt.Println()
 
 	// Oracle内蔵キャッシュのテスト
-	if err := cacheService.TestOracleInternalCache(benchmarkRuns); err != nil {
+	if internal, err := cacheService.TestOracleInternalCache(benchmarkRuns); err != nil {
 		log.Printf("Oracle内蔵キャッシュテストでエラー: %v", err)
+	} else if err := p.InternalCacheTest(internal); err != nil {
+		log.Printf("Oracle内蔵キャッシュテスト結果の表示でエラー: %v", err)
 	}
 
 	// 外部キャッシュ（Redis）のテスト
-	if err := cacheService.TestExternalCache(benchmarkRuns); err != nil {
+	if external, err := cacheService.TestExternalCache(benchmarkRuns); err != nil {
 		log.Printf("外部キャッシュテストでエラー: %v", err)
+	} else if err := p.ExternalCacheTest(external); err != nil {
+		log.Printf("外部キャッシュテスト結果の表示でエラー: %v", err)
+	}
+
+	// 読み書き混在ワークロード（書き込みによる無効化を含めた実効ヒット率と整合性）
+	if mixConfig != nil {
+		if mix, err := cacheService.TestReadWriteMix(*mixConfig); err != nil {
+			log.Printf("読み書き混在ワークロードでエラー: %v", err)
+		} else if err := p.ReadWriteMix(mix); err != nil {
+			log.Printf("読み書き混在ワークロード結果の表示でエラー: %v", err)
+		}
 	}
 
 	// 比較結果の表示
-	if err := cacheService.DisplayCacheComparison(); err != nil {
+	if comparison, err := cacheService.CompareCaches(); err != nil {
+		log.Printf("キャッシュ比較結果の表示でエラー: %v", err)
+	} else if err := p.CacheComparison(comparison); err != nil {
 		log.Printf("キャッシュ比較結果の表示でエラー: %v", err)
 	}
 
 	// メモリ使用量の比較
-	if err := cacheService.DisplayMemoryUsageComparison(); err != nil {
+	if err := p.MemoryUsage(cacheService.MemoryUsage()); err != nil {
 		log.Printf("メモリ使用量比較でエラー: %v", err)
 	}
+
+	// 月額コストの見積もり（-cost-model 指定時のみ）
+	if cost := cacheService.CostReport(); cost != nil {
+		if err := p.Cost(cost); err != nil {
+			log.Printf("月額コストの表示でエラー: %v", err)
+		}
+	}
+}
+
+// suiteOptions - 全体実行の設定
+type suiteOptions struct {
+	days   int
+	months int
+	// topCustomers / recentOrders - Top-Nテストの上位顧客数と顧客ごとの直近受注数
+	topCustomers int
+	recentOrders int
+	// pageSize / pages - ページングの比較の1ページの件数とめくるページ数
+	pageSize int
+	pages    int
+	// parallel - 2以上の場合は、シナリオごとにForkしたサービスで並列に実行する
+	parallel  int
+	isolation service.Isolation
+	repeat    int
+	// shuffler - 指定時は繰り返しごとにシナリオと手法の実行順序を並べ替える
+	shuffler *service.Shuffler
 }
 
 // runAllTests - 全てのパフォーマンステストを実行
-func runAllTests(demoService *service.DemoService, days int) {
+//
+// Ctrl-Cで中断した場合は途中経過を表示し、残りのシナリオを始めない（結果の出力と終了はsdが行う）。
+func runAllTests(demoService *service.DemoService, opts suiteOptions, sd *shutdown) {
+	days, months := opts.days, opts.months
 	fmt.Printf("\n全てのパフォーマンステストを実行します（受注: 過去%d日間）\n", days)
 	fmt.Println("==================================================")
+	fmt.Println("Ctrl-Cで中断すると、完了したシナリオまでの結果を表示して終了します")
 
-	// 受注データのテスト
-	runOrderTests(demoService, days)
+	scenarios := []scenario{
+		{name: "受注データ", run: func(s *service.DemoService) { runOrderTests(s, days) }},
+		{name: "社員データ", run: func(s *service.DemoService) { runEmployeeTests(s) }},
+		{name: "社員・プロジェクト（多対多）", run: func(s *service.DemoService) { runProjectTests(s) }},
+		{name: "売上上位顧客（Top-N）", run: func(s *service.DemoService) { runTopCustomersTests(s, opts.topCustomers, opts.recentOrders) }},
+		{name: "分析関数", run: func(s *service.DemoService) { runWindowFunctionTests(s, days) }},
+		{name: "LOB列", run: func(s *service.DemoService) { runLOBTests(s, days) }},
+		{name: "3階層の取得", run: func(s *service.DemoService) { runCompositeFetchTests(s, days) }},
+		{name: "SELECT列の絞り込み", run: func(s *service.DemoService) { runColumnPruningTests(s, days) }},
+		{name: "ページングと件数", run: func(s *service.DemoService) { runPaginationTests(s, days, opts.pageSize, opts.pages) }},
+		{name: "月次売上レポート", run: func(s *service.DemoService) { runSalesReportTests(s, months) }},
+	}
+	if opts.parallel > 1 {
+		fmt.Println("並列実行中はシナリオ同士がCPU・I/Oを奪い合うため、実行時間は単独実行より長めに出ます（メモリ割り当て量はプロセス全体の値）")
+	}
+	if opts.shuffler != nil {
+		fmt.Printf("繰り返しごとにシナリオと手法の実行順序を並べ替えます（-seed=%d で同じ順序を再現できます）\n", opts.shuffler.Seed())
+		demoService.SetShuffler(opts.shuffler)
+	}
 
-	// 社員データのテスト
-	runEmployeeTests(demoService)
+	for repetition := 1; repetition <= opts.repeat && !sd.interrupted(); repetition++ {
+		if opts.repeat > 1 {
+			fmt.Printf("\n##### 繰り返し %d/%d #####\n", repetition, opts.repeat)
+			demoService.SetRepetition(repetition)
+		}
+
+		ordered := make([]scenario, len(scenarios))
+		for i, j := range opts.shuffler.Perm(len(scenarios)) {
+			ordered[i] = scenarios[j]
+		}
+		if opts.parallel > 1 {
+			runScenariosParallel(demoService, sd, ordered, opts.parallel, opts.isolation)
+		} else {
+			runScenarios(demoService, sd, ordered)
+		}
+	}
+
+	if opts.shuffler != nil {
+		if opts.repeat > 1 {
+			displayOrderEffects(demoService.ResultsSince(0))
+		} else {
+			fmt.Println("\n実行順による影響の分析には -repeat=2 以上を指定してください")
+		}
+	}
 
 	// 総合結果の表示
 	fmt.Println("\n=== 総合結果 ===")
@@ -218,20 +1210,7 @@ func runOrderTests(demoService *service.DemoService, days int) {
 	}
 
 	// N+1問題の影響を具体的に説明
-	if len(results) >= 2 {
-		baseDuration := results[0].ExecutionTime
-		optimizedDuration := results[1].ExecutionTime
-
-		fmt.Printf("N+1問題による影響:\n")
-		fmt.Printf("- 実行時間: %v → %v\n", baseDuration, optimizedDuration)
-
-		if baseDuration > optimizedDuration {
-			saved := baseDuration - optimizedDuration
-			fmt.Printf("- 時間短縮: %v (%.1f%%削減)\n",
-				saved,
-				float64(saved.Nanoseconds())/float64(baseDuration.Nanoseconds())*100)
-		}
-	}
+	displayNPlusOneImpact(results)
 }
 
 // runEmployeeTests - 社員データのパフォーマンステストを実行
@@ -254,18 +1233,253 @@ func runEmployeeTests(demoService *service.DemoService) {
 	}
 
 	// N+1問題の影響を具体的に説明
-	if len(results) >= 2 {
-		baseDuration := results[0].ExecutionTime
-		optimizedDuration := results[1].ExecutionTime
+	displayNPlusOneImpact(results)
+}
+
+// runProjectTests - 社員・プロジェクト（多対多）のパフォーマンステストを実行
+func runProjectTests(demoService *service.DemoService) {
+	fmt.Printf("\n社員・プロジェクト（多対多）のパフォーマンステストを実行中...\n")
+
+	results, err := demoService.CompareEmployeeProjectPerformance()
+	if err != nil {
+		log.Printf("社員・プロジェクトテスト中にエラー: %v", err)
+		return
+	}
+
+	// 結果の詳細表示
+	fmt.Println("\n--- 社員・プロジェクトテスト結果詳細 ---")
+	for _, result := range results {
+		fmt.Printf("手法: %s\n", result.Description)
+		fmt.Printf("実行時間: %v\n", result.ExecutionTime)
+		fmt.Printf("取得件数: %d件\n", result.RecordCount)
+		fmt.Println()
+	}
+
+	// N+1問題の影響を具体的に説明
+	displayNPlusOneImpact(results)
+}
+
+// runTopCustomersTests - 売上上位顧客と直近受注（Top-N）のパフォーマンステストを実行
+func runTopCustomersTests(demoService *service.DemoService, topN, recentOrders int) {
+	fmt.Printf("\n売上上位顧客（Top-N）のパフォーマンステストを実行中...\n")
+
+	results, err := demoService.CompareTopCustomersPerformance(topN, recentOrders)
+	if err != nil {
+		log.Printf("Top-Nテスト中にエラー: %v", err)
+		return
+	}
+
+	// 結果の詳細表示
+	fmt.Println("\n--- Top-Nテスト結果詳細 ---")
+	for _, result := range results {
+		fmt.Printf("手法: %s\n", result.Description)
+		fmt.Printf("実行時間: %v\n", result.ExecutionTime)
+		fmt.Printf("取得顧客数: %d件\n", result.RecordCount)
+		fmt.Println()
+	}
+
+	// N+1問題の影響を具体的に説明
+	displayNPlusOneImpact(results)
+}
+
+// runWindowFunctionTests - 分析関数とアプリ側ループの比較を実行
+func runWindowFunctionTests(demoService *service.DemoService, days int) {
+	fmt.Printf("\n分析関数のパフォーマンステストを実行中...\n")
+
+	if _, err := demoService.CompareWindowFunctionPerformance(days); err != nil {
+		log.Printf("分析関数テスト中にエラー: %v", err)
+	}
+}
+
+// runLOBTests - LOB列を含む取得方法の比較を実行
+func runLOBTests(demoService *service.DemoService, days int) {
+	fmt.Printf("\nLOB列を含む取得方法の比較を実行中...\n")
+
+	results, err := demoService.CompareLOBPerformance(days)
+	if err != nil {
+		log.Printf("LOBテスト中にエラー: %v", err)
+		return
+	}
+
+	// 結果の詳細表示
+	fmt.Println("\n--- LOBテスト結果詳細 ---")
+	for _, result := range results {
+		fmt.Printf("手法: %s\n", result.Description)
+		fmt.Printf("実行時間: %v\n", result.ExecutionTime)
+		fmt.Printf("取得件数: %d件\n", result.RecordCount)
+		fmt.Printf("メモリ割り当て: %d bytes (%d回)\n", result.AllocBytes, result.Allocs)
+		fmt.Println()
+	}
+}
+
+// runCompositeFetchTests - 受注・明細・商品（3階層）の取得方法比較を実行
+func runCompositeFetchTests(demoService *service.DemoService, days int) {
+	fmt.Printf("\n受注・明細・商品（3階層）の取得方法比較を実行中...\n")
+
+	results, err := demoService.CompareCompositeFetchPerformance(days)
+	if err != nil {
+		log.Printf("3階層取得テスト中にエラー: %v", err)
+		return
+	}
+
+	// 結果の詳細表示
+	fmt.Println("\n--- 3階層取得テスト結果詳細 ---")
+	for _, result := range results {
+		fmt.Printf("手法: %s\n", result.Description)
+		fmt.Printf("実行時間: %v\n", result.ExecutionTime)
+		fmt.Printf("取得件数: %d件\n", result.RecordCount)
+		fmt.Printf("メモリ割り当て: %d bytes (%d回)\n", result.AllocBytes, result.Allocs)
+		if result.SessionStats != nil {
+			fmt.Printf("転送量: %d bytes, ラウンドトリップ: %d回\n",
+				result.SessionStats[sessionstats.BytesSent], result.SessionStats[sessionstats.RoundTrips])
+		}
+		fmt.Println()
+	}
+
+	displayNPlusOneImpact(results)
+}
+
+// runSharedPoolTests - リテラル埋め込みN+1の共有プール負荷比較を実行
+func runSharedPoolTests(demoService *service.DemoService, days, workers int) {
+	fmt.Printf("\n共有プール負荷の比較を実行中...\n")
+
+	results, err := demoService.CompareSharedPoolPressure(days, workers)
+	if err != nil {
+		log.Printf("共有プール負荷テスト中にエラー: %v", err)
+		return
+	}
+
+	// 結果の詳細表示
+	fmt.Println("\n--- 共有プール負荷テスト結果詳細 ---")
+	for _, result := range results {
+		fmt.Printf("手法: %s\n", result.Description)
+		fmt.Printf("実行時間: %v\n", result.ExecutionTime)
+		fmt.Printf("取得件数: %d件\n", result.RecordCount)
+		if result.SharedPool != nil && result.SharedPool.Available("V$SYSSTAT") {
+			fmt.Printf("ハードパース（インスタンス全体）: %d回\n", result.SharedPool.HardParses)
+		}
+		fmt.Println()
+	}
+}
+
+// runPaginationTests - ページングでの件数の取得方法の比較を実行
+func runPaginationTests(demoService *service.DemoService, days, pageSize, pages int) {
+	fmt.Printf("\nページングと件数の取得方法の比較を実行中...\n")
+
+	if _, err := demoService.ComparePaginationPerformance(days, pageSize, pages); err != nil {
+		log.Printf("ページングテスト中にエラー: %v", err)
+	}
+}
+
+// runSoftDeleteTests - 論理削除された受注の除外と部分索引の比較を実行
+func runSoftDeleteTests(demoService *service.DemoService, days int) {
+	fmt.Printf("\n論理削除と部分索引の比較を実行中...\n")
+
+	if _, err := demoService.CompareSoftDeletePerformance(days); err != nil {
+		log.Printf("論理削除テスト中にエラー: %v", err)
+	}
+}
+
+// runColumnPruningTests - SELECT * と必要な列のみのSELECTの比較を実行
+func runColumnPruningTests(demoService *service.DemoService, days int) {
+	fmt.Printf("\nSELECT列の絞り込み（過剰取得）の比較を実行中...\n")
 
-		fmt.Printf("N+1問題による影響:\n")
-		fmt.Printf("- 実行時間: %v → %v\n", baseDuration, optimizedDuration)
+	results, err := demoService.CompareColumnPruningPerformance(days)
+	if err != nil {
+		log.Printf("SELECT列の絞り込みテスト中にエラー: %v", err)
+		return
+	}
 
-		if baseDuration > optimizedDuration {
-			saved := baseDuration - optimizedDuration
-			fmt.Printf("- 時間短縮: %v (%.1f%%削減)\n",
-				saved,
-				float64(saved.Nanoseconds())/float64(baseDuration.Nanoseconds())*100)
+	// 結果の詳細表示
+	fmt.Println("\n--- SELECT列の絞り込みテスト結果詳細 ---")
+	for _, result := range results {
+		fmt.Printf("手法: %s\n", result.Description)
+		fmt.Printf("実行時間: %v\n", result.ExecutionTime)
+		fmt.Printf("取得件数: %d件\n", result.RecordCount)
+		fmt.Printf("メモリ割り当て: %d bytes (%d回)\n", result.AllocBytes, result.Allocs)
+		if result.SessionStats != nil {
+			fmt.Printf("転送量: %d bytes\n", result.SessionStats[sessionstats.BytesSent])
 		}
+		fmt.Println()
+	}
+}
+
+// runSalesReportTests - 月次売上レポートの集計方法比較を実行
+func runSalesReportTests(demoService *service.DemoService, months int) {
+	fmt.Printf("\n月次売上レポートの集計方法比較を実行中...\n")
+
+	results, err := demoService.CompareMonthlySalesPerformance(months)
+	if err != nil {
+		log.Printf("月次売上レポートテスト中にエラー: %v", err)
+		return
+	}
+
+	// 結果の詳細表示
+	fmt.Println("\n--- 月次売上レポートテスト結果詳細 ---")
+	for _, result := range results {
+		fmt.Printf("手法: %s\n", result.Description)
+		fmt.Printf("実行時間: %v\n", result.ExecutionTime)
+		fmt.Printf("集計行数: %d件\n", result.RecordCount)
+		fmt.Printf("メモリ割り当て: %d bytes (%d回)\n", result.AllocBytes, result.Allocs)
+		fmt.Println()
+	}
+}
+
+// displayNPlusOneImpact - N+1（先頭の結果）と最速の手法を比較して影響を表示
+func displayNPlusOneImpact(results []service.PerformanceResult) {
+	if len(results) < 2 {
+		return
+	}
+
+	baseline := results[0]
+	fastest := results[1]
+	for _, result := range results[2:] {
+		if result.ExecutionTime < fastest.ExecutionTime {
+			fastest = result
+		}
+	}
+
+	fmt.Printf("N+1問題による影響（%s → %s）:\n", baseline.Method, fastest.Method)
+	fmt.Printf("- 実行時間: %v → %v\n", baseline.ExecutionTime, fastest.ExecutionTime)
+
+	if baseline.ExecutionTime > fastest.ExecutionTime {
+		saved := baseline.ExecutionTime - fastest.ExecutionTime
+		fmt.Printf("- 時間短縮: %v (%.1f%%削減)\n",
+			saved,
+			float64(saved.Nanoseconds())/float64(baseline.ExecutionTime.Nanoseconds())*100)
+	}
+	if fastest.Significance != nil {
+		fmt.Printf("- 信頼度: %s\n", fastest.Significance.Statement())
+	}
+}
+
+// formatLimit - 件数の上限の表示（0は上限なし）
+func formatLimit(limit int, unit string) string {
+	if limit <= 0 {
+		return "上限なし"
+	}
+	return fmt.Sprintf("%d%s", limit, unit)
+}
+
+// sendTelemetry - 匿名化した改善率を表示してから送信する（失敗しても実行結果には影響しない）
+func sendTelemetry(db *sql.DB, demoService *service.DemoService, meta *runmeta.Metadata, endpoint string) {
+	report := telemetry.Build(meta.ToolVersion, meta.DBBanner, telemetry.CountOrders(db), demoService.ResultsSince(0))
+	if len(report.Improvements) == 0 {
+		fmt.Println("\n改善率を求められる結果がないため、テレメトリーは送信しません")
+		return
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Printf("テレメトリーの変換中にエラー: %v", err)
+		return
+	}
+	fmt.Println("\n=== 送信するテレメトリー（匿名化済み） ===")
+	fmt.Println(string(data))
+
+	if err := telemetry.Send(context.Background(), endpoint, report); err != nil {
+		log.Printf("テレメトリーの送信に失敗しました: %v", err)
+		return
 	}
+	fmt.Println("テレメトリーを送信しました。ご協力ありがとうございます。")
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"oracle-n-plus-1-demo/internal/aggregate"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// runMatrix - matrixコマンド（環境ごとの結果を手法別に並べて比較）
+func runMatrix(args []string) error {
+	fs := flag.NewFlagSet("matrix", flag.ContinueOnError)
+	baseline := fs.String("baseline", "", "倍率の基準にする環境名（省略時は名前順で最初の環境）")
+	jsonPath := fs.String("json", "", "比較表をJSONファイルに出力する")
+	tableOptions := addTableFlags(fs, "method または環境名")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("結果ファイルのディレクトリを指定してください")
+	}
+
+	// 環境ごとにディレクトリを分けて収集している場合もあるため、複数指定を受け付ける
+	var runs []aggregate.Run
+	for _, dir := range fs.Args() {
+		loaded, _, err := aggregate.Load(dir)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, loaded...)
+	}
+	if len(runs) == 0 {
+		return errors.New("計測結果のJSONがありません")
+	}
+
+	matrix := aggregate.BuildMatrix(runs, *baseline)
+	if *baseline != "" && matrix.Baseline != *baseline {
+		fmt.Printf("環境 %q の結果がないため、%q を基準にします\n\n", *baseline, matrix.Baseline)
+	}
+	if err := displayMatrix(matrix, tableOptions()); err != nil {
+		return err
+	}
+
+	if *jsonPath != "" {
+		jsonData, err := json.MarshalIndent(matrix, "", "  ")
+		if err != nil {
+			return fmt.Errorf("JSON変換エラー: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, jsonData, 0o644); err != nil {
+			return fmt.Errorf("比較表の書き込みに失敗: %w", err)
+		}
+		fmt.Printf("\n比較表を出力しました: %s\n", *jsonPath)
+	}
+
+	return nil
+}
+
+// displayMatrix - シナリオごとに 手法 × 環境 の中央値と基準環境に対する倍率を表示
+func displayMatrix(m *aggregate.Matrix, opts report.TableOptions) error {
+	// 表の途中で列指定の誤りに気付かないよう、先に確認する
+	if err := newMatrixTable(m).Check(opts); err != nil {
+		return err
+	}
+
+	w := report.Stdout()
+	w.Line("=== 環境間の比較（実行時間の中央値） ===")
+	w.Linef("基準環境: %s（倍率は基準環境の中央値に対する値）", m.Baseline)
+
+	var table *report.Table
+	flush := func() error {
+		if table == nil {
+			return nil
+		}
+		if err := table.Apply(opts); err != nil {
+			return err
+		}
+		w.Table(table)
+		return nil
+	}
+
+	scenario := ""
+	for _, row := range m.Rows {
+		if table == nil || row.Scenario != scenario {
+			if err := flush(); err != nil {
+				return err
+			}
+			scenario = row.Scenario
+			w.Blank()
+			w.Linef("[%s]", scenario)
+			table = newMatrixTable(m)
+		}
+
+		cells := []report.Cell{report.Text(row.Method)}
+		for _, env := range m.Environments {
+			cell, ok := row.Cells[env]
+			switch {
+			case !ok:
+				cells = append(cells, report.Text("-"))
+			case cell.Ratio > 0 && env != m.Baseline:
+				cells = append(cells, report.Number(fmt.Sprintf("%v (x%.2f)", cell.Median, cell.Ratio), float64(cell.Median)))
+			default:
+				cells = append(cells, report.Duration(cell.Median))
+			}
+		}
+		table.AddRow(cells...)
+	}
+	return flush()
+}
+
+// newMatrixTable - 手法 × 環境 の表（列のKeyは method と環境名）
+func newMatrixTable(m *aggregate.Matrix) *report.Table {
+	columns := []report.Column{{Key: "method", Header: "手法"}}
+	for _, env := range m.Environments {
+		columns = append(columns, report.Column{Key: env, Header: env, Align: report.AlignRight})
+	}
+	return report.NewTable(columns...)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/aggregate"
+)
+
+// reservedBenchFlags - 接続先ごとに multi-pdb / consumer-groups が指定するため、計測のオプションとして渡せないフラグ
+var reservedBenchFlags = []string{"env", "results-json"}
+
+// runMultiPDB - multi-pdbコマンド（複数のPDB/サービスで同じ計測を順に実行し、環境間の比較表を表示）
+func runMultiPDB(args []string) error {
+	fs := flag.NewFlagSet("multi-pdb", flag.ContinueOnError)
+	services := fs.String("services", "", "計測するサービス名のカンマ区切り（省略時はDB_SERVICE_NAMES）")
+	dir := fs.String("dir", "pdb-results", "結果ファイルを出力するディレクトリ（実行ごとにサブディレクトリを作成）")
+	runs := fs.Int("runs", 1, "サービスごとの計測回数（比較表は中央値）")
+	baseline := fs.String("baseline", "", "倍率の基準にするサービス名（省略時は最初に指定したサービス）")
+	tableOptions := addTableFlags(fs, "method またはサービス名")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *runs < 1 {
+		return errors.New("-runs には1以上を指定してください")
+	}
+
+	spec := *services
+	if spec == "" {
+		spec = config.LoadServiceNames()
+	}
+	if spec == "" {
+		return errors.New("-services またはDB_SERVICE_NAMESで計測するサービス名を指定してください")
+	}
+	names, err := config.ParseServiceNames(spec)
+	if err != nil {
+		return fmt.Errorf("サービス名の指定が正しくありません: %w", err)
+	}
+
+	// `--` 以降は各サービスでの計測にそのまま渡す
+	benchArgs := fs.Args()
+	if err := checkBenchArgs(benchArgs); err != nil {
+		return err
+	}
+	outDir, err := newResultsDir(*dir)
+	if err != nil {
+		return err
+	}
+
+	targets := make([]benchTarget, len(names))
+	for i, name := range names {
+		targets[i] = benchTarget{env: name, service: name}
+	}
+	failed, err := runBenchmarks(targets, outDir, *runs, benchArgs)
+	if err != nil {
+		return err
+	}
+
+	base := *baseline
+	if base == "" {
+		base = names[0]
+	}
+	matrix, err := loadResultsMatrix(outDir, base, failed)
+	if err != nil {
+		return err
+	}
+	if err := displayMatrix(matrix, tableOptions()); err != nil {
+		return err
+	}
+	fmt.Printf("\n結果ファイル: %s（matrix コマンドで再集計できます）\n", outDir)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("計測に失敗したサービスがあります: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// benchTarget - 別プロセスで計測する接続先（envは結果の環境名で、比較表の列になる）
+type benchTarget struct {
+	env     string
+	service string
+}
+
+// checkBenchArgs - 計測に渡すオプションに自動で指定するフラグが含まれていないか確認
+func checkBenchArgs(args []string) error {
+	for _, arg := range args {
+		for _, name := range reservedBenchFlags {
+			if arg == "-"+name || strings.HasPrefix(arg, "-"+name+"=") || arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") {
+				return fmt.Errorf("-%s は接続先ごとに自動で指定するため、計測のオプションには指定できません", name)
+			}
+		}
+	}
+	return nil
+}
+
+// newResultsDir - 実行日時のサブディレクトリを作成（前回の結果と混ざらないようにする）
+func newResultsDir(dir string) (string, error) {
+	outDir := filepath.Join(dir, time.Now().Format("20060102-150405"))
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", fmt.Errorf("結果ディレクトリの作成に失敗: %w", err)
+	}
+	return outDir, nil
+}
+
+// runBenchmarks - 接続先ごとにこのプログラム自身を起動して計測し、失敗した接続先の環境名を返す
+//
+// 前の接続先の計測が次の接続先のキャッシュや接続プールに影響しないよう、1つずつ別プロセスで実行する。
+// 接続できない接続先があっても残りは続け、Ctrl+Cで中断された場合はエラーを返す。
+func runBenchmarks(targets []benchTarget, outDir string, runs int, benchArgs []string) ([]string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	var failed []string
+	for _, target := range targets {
+		for i := 1; i <= runs; i++ {
+			fmt.Printf("\n##### %s（サービス %s、%d/%d回目） #####\n", target.env, target.service, i, runs)
+			resultPath := filepath.Join(outDir, fmt.Sprintf("results-%s-%d.json", target.env, i))
+			cmd := exec.Command(executable, append([]string{"-env=" + target.env, "-results-json=" + resultPath}, benchArgs...)...)
+			cmd.Env = append(os.Environ(), "DB_SERVICE_NAME="+target.service)
+			cmd.Stdin = os.Stdin
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+
+			if err := cmd.Run(); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) && exitErr.ExitCode() == exitInterrupted {
+					return nil, fmt.Errorf("%s の計測が中断されました", target.env)
+				}
+				fmt.Printf("%s の計測に失敗しました: %v\n", target.env, err)
+				failed = append(failed, target.env)
+				break
+			}
+		}
+	}
+	return failed, nil
+}
+
+// loadResultsMatrix - 計測結果のディレクトリから環境間の比較表を作成
+func loadResultsMatrix(outDir, baseline string, failed []string) (*aggregate.Matrix, error) {
+	loaded, _, err := aggregate.Load(outDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("すべての接続先で計測に失敗しました: %s", strings.Join(failed, ", "))
+	}
+
+	matrix := aggregate.BuildMatrix(loaded, baseline)
+	fmt.Println()
+	if matrix.Baseline != baseline {
+		fmt.Printf("%q の結果がないため、%q を基準にします\n\n", baseline, matrix.Baseline)
+	}
+	return matrix, nil
+}
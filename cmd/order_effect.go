@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/aggregate"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/service"
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+// displayOrderEffects - 実行順序を並べ替えて繰り返した結果から、実行順による影響を表示
+func displayOrderEffects(results []service.PerformanceResult) {
+	effects := aggregate.AnalyzeOrderEffects(results, aggregate.DefaultOrderThreshold)
+	if len(effects) == 0 {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading("実行順による影響")
+	table := report.NewTable(
+		report.Column{Key: "scenario", Header: "シナリオ"},
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "runs", Header: "回数", Align: report.AlignRight},
+		report.Column{Key: "first", Header: "最初に実行", Align: report.AlignRight},
+		report.Column{Key: "later", Header: "2番目以降", Align: report.AlignRight},
+		report.Column{Key: "change", Header: "差", Align: report.AlignRight},
+		report.Column{Key: "suite_change", Header: "先頭シナリオ時の差", Align: report.AlignRight},
+		report.Column{Key: "verdict", Header: "判定"},
+	)
+
+	sensitive := 0
+	for _, e := range effects {
+		verdict := "影響なし"
+		switch {
+		case e.Sensitive:
+			verdict = "順序の影響あり"
+			sensitive++
+		case e.Inconclusive:
+			verdict = "判定不可"
+		}
+		table.AddRow(
+			report.Text(e.Scenario),
+			report.Text(e.Method),
+			report.Int(int64(e.Runs)),
+			positionMean(e.InScenario.FirstRuns, e.InScenario.FirstMean),
+			positionMean(e.InScenario.LaterRuns, e.InScenario.LaterMean),
+			positionChange(e.InScenario),
+			positionChange(e.InSuite),
+			report.Text(verdict))
+	}
+	w.Table(table)
+
+	w.Linef("「差」は手法をシナリオ内で最初に実行した回の平均と、他の手法の後に実行した回の平均の差（正の値は最初の方が遅い）")
+	w.Linef("「先頭シナリオ時の差」は繰り返しの最初のシナリオとして実行した回とそれ以外の回の差")
+	w.Linef("どちらかの差が%.0f%%を超え、Welchのt検定で有意（p < %.2f）なら順序の影響ありと判定します", aggregate.DefaultOrderThreshold, stats.SignificanceLevel)
+	w.Linef("各実行順で%d回以上計測できなかった差は検定せず、判定不可とします（-repeat を増やしてください）", aggregate.MinOrderRuns)
+	if sensitive > 0 {
+		w.Linef("%d件の手法はキャッシュの温まり方で結果が変わります。-reset でキャッシュをフラッシュするか、-repeat を増やして平均で比較してください", sensitive)
+	}
+}
+
+// positionMean - 実行順ごとの平均（該当する回がなければ「-」）
+func positionMean(runs int, mean time.Duration) report.Cell {
+	if runs == 0 {
+		return report.Text("-")
+	}
+	return report.Number(fmt.Sprintf("%v (%d回)", mean, runs), float64(mean))
+}
+
+// positionChange - 実行順による差と、検定できた場合はp値（比較できなければ「-」）
+func positionChange(e aggregate.PositionEffect) report.Cell {
+	if !e.Comparable {
+		return report.Text("-")
+	}
+	if !e.Tested {
+		return report.Number(fmt.Sprintf("%+.1f%% (回数不足)", e.ChangePercent), e.ChangePercent)
+	}
+	return report.Number(fmt.Sprintf("%+.1f%% (p=%.3f)", e.ChangePercent, e.P), e.ChangePercent)
+}
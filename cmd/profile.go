@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/profile"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+// runProfile - profileコマンド（profile sql: 利用者が指定した問い合わせの計測）
+func runProfile(args []string) error {
+	if len(args) == 0 || args[0] != "sql" {
+		return errors.New("使い方: profile sql (-file FILE | -sql TEXT) [-bind VALUE ...] [-runs N] [-result-cache] [-json FILE]")
+	}
+	return runProfileSQL(args[1:])
+}
+
+// runProfileSQL - 利用者の問い合わせを繰り返し実行し、実行時間・セッション統計・実行計画・キャッシュの効果を表示
+func runProfileSQL(args []string) error {
+	defaults := profile.DefaultConfig()
+	fs := flag.NewFlagSet("profile sql", flag.ContinueOnError)
+	file := fs.String("file", "", "計測する問い合わせのファイル（- で標準入力から貼り付け）")
+	text := fs.String("sql", "", "計測する問い合わせ（-file の代わりに直接指定）")
+	var binds []profile.Bind
+	fs.Func("bind", "バインド変数（出現順に :1, :2, ... または 名前=値。str:/date:YYYY-MM-DD/null で型を指定。繰り返し指定可）", func(s string) error {
+		b, err := profile.ParseBind(s)
+		if err != nil {
+			return err
+		}
+		binds = append(binds, b)
+		return nil
+	})
+	runs := fs.Int("runs", defaults.Runs, "計測回数")
+	warmup := fs.Int("warmup", defaults.Warmup, "計測前に実行して捨てる回数")
+	plan := fs.Bool("plan", defaults.Plan, "カーソルキャッシュ上の実行計画を表示する（V$SQLとDBMS_XPLANの参照権限が必要）")
+	resultCache := fs.Bool("result-cache", false, "RESULT_CACHEヒントを付けた場合も計測して比べる")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	query, err := readProfileSQL(*file, *text)
+	if err != nil {
+		return err
+	}
+	cfg := profile.Config{SQL: query, Binds: binds, Runs: *runs, Warmup: *warmup, Plan: *plan, ResultCache: *resultCache}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	result, err := profile.Run(context.Background(), db, cfg)
+	if err != nil {
+		return fmt.Errorf("計測に失敗しました: %w", err)
+	}
+	displayProfile(result)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal profile report: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// readProfileSQL - -file（- は標準入力）または -sql から問い合わせを読む
+func readProfileSQL(file, text string) (string, error) {
+	switch {
+	case file != "" && text != "":
+		return "", errors.New("-file と -sql はどちらか一方を指定してください")
+	case file == "-":
+		fmt.Fprintln(os.Stderr, "計測する問い合わせを貼り付け、入力の終わり（Ctrl-D）で確定してください:")
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("標準入力の読み込みに失敗しました: %w", err)
+		}
+		return string(data), nil
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("問い合わせのファイルの読み込みに失敗しました: %w", err)
+		}
+		return string(data), nil
+	case text != "":
+		return text, nil
+	default:
+		return "", errors.New("-file または -sql で計測する問い合わせを指定してください")
+	}
+}
+
+// displayProfile - 実行方法ごとの実行時間とセッション統計、初回の実行、実行計画を表示
+func displayProfile(r *profile.Report) {
+	w := report.Stdout()
+	w.Heading(fmt.Sprintf("問い合わせの計測（%d回、ウォームアップ%d回）", r.Runs, r.Warmup))
+	w.Line(r.SQL)
+	for i, b := range r.Binds {
+		if b.Name != "" {
+			w.Linef("  :%s = %v", b.Name, b.Value)
+		} else {
+			w.Linef("  :%d = %v", i+1, b.Value)
+		}
+	}
+	w.Blank()
+
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "実行方法"},
+		report.Column{Key: "median", Header: "中央値", Align: report.AlignRight},
+		report.Column{Key: "min", Header: "最小", Align: report.AlignRight},
+		report.Column{Key: "max", Header: "最大", Align: report.AlignRight},
+		report.Column{Key: "stddev", Header: "標準偏差", Align: report.AlignRight},
+		report.Column{Key: "rows", Header: "行数", Align: report.AlignRight},
+		report.Column{Key: "logical_reads", Header: "論理読み取り", Align: report.AlignRight},
+		report.Column{Key: "physical_reads", Header: "物理読み取り", Align: report.AlignRight},
+		report.Column{Key: "roundtrips", Header: "往復", Align: report.AlignRight},
+		report.Column{Key: "bytes_sent", Header: "転送量", Align: report.AlignRight},
+	)
+	for _, v := range r.Variants {
+		table.AddRow(
+			report.Text(v.Name),
+			report.Duration(v.Elapsed.Median.Round(time.Microsecond)),
+			report.Duration(v.Elapsed.Min.Round(time.Microsecond)),
+			report.Duration(v.Elapsed.Max.Round(time.Microsecond)),
+			report.Duration(v.Elapsed.StdDev.Round(time.Microsecond)),
+			report.Int(v.Rows),
+			statCell(r, v.Stats, sessionstats.LogicalReads),
+			statCell(r, v.Stats, sessionstats.PhysicalReads),
+			statCell(r, v.Stats, sessionstats.RoundTrips),
+			statBytesCell(r, v.Stats, sessionstats.BytesSent))
+	}
+	w.Table(table)
+	w.Line("セッション統計は実行ごとの差分の中央値です。")
+
+	if r.StatsUnavailable {
+		w.Line("V$MYSTATを参照できないため、セッション統計とキャッシュのヒット率は表示しません（V$MYSTAT・V$STATNAMEのSELECT権限が必要です）。")
+	} else {
+		first := r.First
+		w.Blank()
+		w.Linef("最初の実行: %v（論理読み取り %d、物理読み取り %d、ハードパース %d）",
+			first.Elapsed.Round(time.Microsecond), first.Stats[sessionstats.LogicalReads],
+			first.Stats[sessionstats.PhysicalReads], first.Stats[sessionstats.ParseCountHard])
+		if len(r.Variants) > 0 {
+			executions := r.Variants[0].Executions
+			last := executions[len(executions)-1]
+			if hit, lastHit := first.HitRatio(), last.HitRatio(); hit >= 0 && lastHit >= 0 {
+				w.Linef("バッファキャッシュのヒット率: 最初の実行 %.1f%% → 最後の実行 %.1f%%", hit*100, lastHit*100)
+			}
+		}
+	}
+
+	if len(r.Variants) > 1 {
+		base, cached := r.Variants[0].Elapsed.Median, r.Variants[1].Elapsed.Median
+		if cached > 0 {
+			w.Linef("RESULT_CACHEヒントにより中央値は %.1f倍 速くなりました。", float64(base)/float64(cached))
+		}
+		w.Line("論理読み取りが0に近ければ、結果はResult Cacheから返されています。減らない場合は RESULT_CACHE_MODE や RESULT_CACHE_MAX_SIZE、問い合わせが結果をキャッシュできる条件（SYSDATEなどを含まないこと）を確認してください。")
+	}
+
+	if r.Plan != nil || r.PlanError != "" {
+		w.Blank()
+		if r.PlanError != "" {
+			w.Linef("実行計画を取得できませんでした: %s", r.PlanError)
+			return
+		}
+		w.Linef("実行計画（SQL_ID %s、子カーソル %d）:", r.SQLID, r.ChildNumber)
+		for _, line := range r.Plan {
+			w.Line(line)
+		}
+	}
+}
+
+// statCell - セッション統計の値（取得できなかった場合は -）
+func statCell(r *profile.Report, s sessionstats.Stats, name string) report.Cell {
+	if r.StatsUnavailable {
+		return report.Text("-")
+	}
+	return report.Int(s[name])
+}
+
+// statBytesCell - バイト数のセッション統計の値（取得できなかった場合は -）
+func statBytesCell(r *profile.Report, s sessionstats.Stats, name string) report.Cell {
+	if r.StatsUnavailable {
+		return report.Text("-")
+	}
+	return report.Bytes(s[name])
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// scenario - 全体実行の1シナリオ
+//
+// runには実行するサービスが渡される（並列実行時はシナリオごとにForkしたサービス）。
+type scenario struct {
+	name string
+	run  func(demoService *service.DemoService)
+}
+
+// completedScenario - 完了したシナリオとその結果
+type completedScenario struct {
+	name    string
+	elapsed time.Duration
+	results []service.PerformanceResult
+}
+
+// scenarioRunner - シナリオを実行して進捗と残り時間を表示する
+//
+// 実行中にCtrl-C（SIGINT/SIGTERM）を受け取った場合は、完了したシナリオの結果と
+// 実行中のシナリオで完了した手法の結果を表示する（終了はshutdownが行う）。
+type scenarioRunner struct {
+	demoService *service.DemoService
+	out         *report.Writer
+
+	mu        sync.Mutex
+	started   time.Time
+	total     int
+	current   string
+	fromIndex int
+	completed []completedScenario
+
+	// 並列実行時のみ使う（添字はシナリオの定義順）
+	names    []string
+	forks    []*service.DemoService
+	finished []bool
+	adopted  bool
+}
+
+// newScenarioRunner - シナリオランナーを作成（表示は作成時点の標準出力へ書く）
+func newScenarioRunner(demoService *service.DemoService, scenarios []scenario) *scenarioRunner {
+	names := make([]string, len(scenarios))
+	for i, sc := range scenarios {
+		names[i] = sc.name
+	}
+	return &scenarioRunner{
+		demoService: demoService,
+		out:         report.New(os.Stdout),
+		started:     time.Now(),
+		total:       len(scenarios),
+		names:       names,
+	}
+}
+
+// watchInterrupt - 中断時に途中経過を表示し、並列実行の結果を取り込むよう設定（戻り値で解除する）
+//
+// 実行中のSQLの完了は待たない（sdが接続を取り消し、接続プールを閉じる）。
+func (r *scenarioRunner) watchInterrupt(sd *shutdown) func() {
+	return sd.setProgress(func(sig os.Signal) {
+		r.displayPartialResults(sig)
+		r.adoptForks()
+	})
+}
+
+// runScenarios - シナリオを順に実行（中断時は途中経過を表示し、新しいシナリオを始めない）
+func runScenarios(demoService *service.DemoService, sd *shutdown, scenarios []scenario) {
+	r := newScenarioRunner(demoService, scenarios)
+	stop := r.watchInterrupt(sd)
+	defer stop()
+
+	defer demoService.SetScenarioPosition(0)
+	for i, sc := range scenarios {
+		if sd.interrupted() {
+			return
+		}
+		r.begin(i, sc.name)
+		demoService.SetScenarioPosition(i + 1)
+		start := time.Now()
+		sc.run(demoService)
+		r.finish(sc.name, time.Since(start))
+	}
+}
+
+// runScenariosParallel - シナリオを最大workers個ずつ並列に実行
+//
+// シナリオごとにサービスをForkし、isolationに応じて接続プールの共有または専用の接続で実行する。
+// 各シナリオの詳細表示は並列に書き込むと混ざるため抑止し、完了したシナリオから結果を1つずつまとめて表示する。
+// 結果は完了順ではなくシナリオの定義順に元のサービスへ取り込む。
+func runScenariosParallel(demoService *service.DemoService, sd *shutdown, scenarios []scenario, workers int, isolation service.Isolation) {
+	r := newScenarioRunner(demoService, scenarios)
+	r.forks = make([]*service.DemoService, len(scenarios))
+	r.finished = make([]bool, len(scenarios))
+	stop := r.watchInterrupt(sd)
+	defer stop()
+
+	r.out.Linef("\n%d件のシナリオを最大%d並列で実行します（接続の分離: %s）", r.total, workers, isolation)
+	r.out.Line("並列実行中は各シナリオの詳細表示を省略し、完了したシナリオから結果を表示します")
+
+	restore, err := silenceStdout()
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r.runForked(i, scenarios[i], isolation)
+			}
+		}()
+	}
+	for i := range scenarios {
+		if sd.interrupted() {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	restore()
+	r.adoptForks()
+	fmt.Printf("\n全シナリオ完了（総経過時間: %v）\n", time.Since(r.started).Round(time.Second))
+}
+
+// silenceStdout - os.Stdout を /dev/null に差し替える（戻り値で元に戻す）
+func silenceStdout() (func(), error) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = devNull
+	return func() {
+		os.Stdout = stdout
+		if err := devNull.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "devNull.Close() failed: %v\n", err)
+		}
+	}, nil
+}
+
+// runForked - Forkしたサービスでシナリオを1つ実行し、完了したら結果を表示
+func (r *scenarioRunner) runForked(index int, sc scenario, isolation service.Isolation) {
+	fork, err := r.demoService.Fork(isolation)
+	if err != nil {
+		log.Printf("%sを実行できません: %v", sc.name, err)
+		return
+	}
+	defer func() {
+		if err := fork.Close(); err != nil {
+			log.Printf("%sの接続のクローズエラー: %v", sc.name, err)
+		}
+	}()
+
+	fork.SetScenarioPosition(index + 1)
+	r.mu.Lock()
+	r.forks[index] = fork
+	r.mu.Unlock()
+
+	start := time.Now()
+	sc.run(fork)
+	elapsed := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.finished[index] = true
+	c := completedScenario{name: sc.name, elapsed: elapsed, results: fork.ResultsSince(0)}
+	r.completed = append(r.completed, c)
+
+	r.out.Linef("\n[%d/%d] %s 完了（%v、経過 %v）", len(r.completed), r.total, sc.name,
+		elapsed.Round(time.Millisecond), time.Since(r.started).Round(time.Second))
+	displayResultLines(r.out, c.results)
+}
+
+// adoptForks - 並列実行したシナリオの結果を定義順に元のサービスへ取り込む（1回だけ）
+func (r *scenarioRunner) adoptForks() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.adopted {
+		return
+	}
+	r.adopted = true
+	for _, fork := range r.forks {
+		if fork != nil {
+			r.demoService.Adopt(fork)
+		}
+	}
+}
+
+// begin - シナリオ開始時に進捗と残り時間の見込みを表示
+func (r *scenarioRunner) begin(index int, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.current = name
+	r.fromIndex = r.demoService.ResultCount()
+
+	elapsed := time.Since(r.started).Round(time.Second)
+	eta := "計測中"
+	if len(r.completed) > 0 {
+		// 完了したシナリオの平均時間 × 残りのシナリオ数（実行するシナリオを含む）
+		average := elapsed / time.Duration(len(r.completed))
+		eta = "約" + (average * time.Duration(r.total-index)).Round(time.Second).String()
+	}
+
+	r.out.Linef("\n[%d/%d] %s（経過 %v、残り %s）", index+1, r.total, name, elapsed, eta)
+}
+
+// finish - シナリオ完了時に結果を記録
+func (r *scenarioRunner) finish(name string, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.completed = append(r.completed, completedScenario{
+		name:    name,
+		elapsed: elapsed,
+		results: r.demoService.ResultsSince(r.fromIndex),
+	})
+	r.current = ""
+}
+
+// displayPartialResults - 中断時に完了分の結果を表示
+func (r *scenarioRunner) displayPartialResults(sig os.Signal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.out.Linef("\n\n=== 中断されました（%v）: 完了したシナリオ %d/%d ===", sig, len(r.completed), r.total)
+	for _, c := range r.completed {
+		r.out.Linef("\n%s（%v）", c.name, c.elapsed.Round(time.Millisecond))
+		displayResultLines(r.out, c.results)
+	}
+
+	if r.current != "" {
+		if partial := r.demoService.ResultsSince(r.fromIndex); len(partial) > 0 {
+			r.out.Linef("\n%s（実行中・完了した手法のみ）", r.current)
+			displayResultLines(r.out, partial)
+		}
+	}
+	for i, fork := range r.forks {
+		if fork == nil || r.finished[i] {
+			continue
+		}
+		if partial := fork.ResultsSince(0); len(partial) > 0 {
+			r.out.Linef("\n%s（実行中・完了した手法のみ）", r.names[i])
+			displayResultLines(r.out, partial)
+		}
+	}
+	r.out.Linef("\n総経過時間: %v", time.Since(r.started).Round(time.Second))
+}
+
+// displayResultLines - 手法ごとの結果を1行ずつ表示
+func displayResultLines(w *report.Writer, results []service.PerformanceResult) {
+	if len(results) == 0 {
+		w.Indentf(1, "（結果なし）")
+		return
+	}
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "time", Header: "実行時間", Align: report.AlignRight},
+		report.Column{Key: "records", Header: "件数", Align: report.AlignRight},
+	)
+	for _, result := range results {
+		table.AddRow(
+			report.Text(result.Method),
+			report.Duration(result.ExecutionTime.Round(time.Microsecond)),
+			report.Int(int64(result.RecordCount)))
+	}
+	w.IndentTable(1, table)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"oracle-n-plus-1-demo/internal/presenter"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// displayDemoProjections - N+1デモの手法ごとの月額コストと容量見積もりを表示（-cost-model / -capacity-rps 指定時のみ）
+func displayDemoProjections(demoService *service.DemoService) {
+	cost := demoService.CostReport()
+	capacity := demoService.CapacityReport()
+	hasCost := cost != nil && len(cost.Estimates) > 0
+	hasCapacity := capacity != nil && len(capacity.Projections) > 0
+	if !hasCost && !hasCapacity {
+		return
+	}
+
+	p, err := presenter.New(presenter.FormatText, os.Stdout, presenter.Options{})
+	if err != nil {
+		log.Printf("見積もりの表示でエラー: %v", err)
+		return
+	}
+	if hasCost {
+		if err := p.Cost(cost); err != nil {
+			log.Printf("月額コストの表示でエラー: %v", err)
+		}
+	}
+	if hasCapacity {
+		if err := p.Capacity(capacity); err != nil {
+			log.Printf("容量見積もりの表示でエラー: %v", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/invalidation"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// runPubSubInvalidation - pubsub-invalidationコマンド（Redis Pub/Subによるローカルキャッシュの無効化とResult Cacheの比較）
+func runPubSubInvalidation(args []string) error {
+	defaults := invalidation.DefaultConfig()
+	fs := flag.NewFlagSet("pubsub-invalidation", flag.ContinueOnError)
+	writes := fs.Int("writes", defaults.Writes, "書き込み（更新・コミット・通知）の回数")
+	interval := fs.Duration("interval", defaults.Interval, "書き込みの間隔")
+	poll := fs.Duration("poll", defaults.PollInterval, "読み取り側がローカルキャッシュを読む間隔")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg := invalidation.Config{Writes: *writes, Interval: *interval, PollInterval: *poll}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	appConfig, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+	client, err := config.ConnectRedis(appConfig)
+	if err != nil {
+		return &connectivityError{fmt.Errorf("Redis接続に失敗しました: %w", err)}
+	}
+	if client == nil {
+		return errors.New("REDIS_HOST が設定されていません")
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "redis client Close() failed: %v\n", err)
+		}
+	}()
+
+	result, err := invalidation.Run(context.Background(), db, client, cfg)
+	if err != nil {
+		return fmt.Errorf("計測に失敗しました: %w", err)
+	}
+	displayInvalidationPropagation(result)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal invalidation report: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// displayInvalidationPropagation - 無効化の方法ごとの伝播遅延・古い値を返した期間・古い値の読み取りを表示
+func displayInvalidationPropagation(r *invalidation.Report) {
+	w := report.Stdout()
+	w.Heading(fmt.Sprintf("更新の伝播と古い値を返す期間（顧客ID %d、書き込み%d回・間隔 %v、読み取り間隔 %v）",
+		r.CustomerID, r.Writes, r.Interval, r.PollInterval))
+
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "無効化の方法"},
+		report.Column{Key: "propagation", Header: "伝播遅延（中央値）", Align: report.AlignRight},
+		report.Column{Key: "propagation_p95", Header: "伝播遅延（p95）", Align: report.AlignRight},
+		report.Column{Key: "stale_window", Header: "古い値の期間（中央値）", Align: report.AlignRight},
+		report.Column{Key: "stale_window_max", Header: "古い値の期間（最大）", Align: report.AlignRight},
+		report.Column{Key: "stale_reads", Header: "古い値/読み取り", Align: report.AlignRight},
+	)
+	for _, m := range []invalidation.Method{r.PubSub, r.ResultCache} {
+		propagation, p95 := report.Text("-"), report.Text("-")
+		if m.Propagation != nil {
+			propagation = report.Duration(m.Propagation.Median.Round(time.Microsecond))
+			p95 = report.Duration(m.Propagation.P95.Round(time.Microsecond))
+		}
+		table.AddRow(
+			report.Text(m.Name),
+			propagation, p95,
+			report.Duration(m.StaleWindow.Median.Round(time.Microsecond)),
+			report.Duration(m.StaleWindow.Max.Round(time.Microsecond)),
+			report.Text(fmt.Sprintf("%d/%d", m.StaleReads, m.Reads)))
+	}
+	w.Table(table)
+
+	for _, m := range []invalidation.Method{r.PubSub, r.ResultCache} {
+		if m.Missed > 0 {
+			w.Linef("%s: %d回の書き込みは、計測の終わりまで新しい値を返しませんでした（無効化の後に古い値を保存し直した可能性があります）。", m.Name, m.Missed)
+		}
+	}
+	w.Blank()
+	w.Line("Pub/Subでは、コミットから通知が届いてローカルキャッシュを削除するまでの間、読み取りは古い値を返します。通知は保存されないため、購読していない間の更新は届きません。")
+	w.Line("Result Cacheはコミットと同時に依存する結果を無効化するため、コミット後の読み取りは新しい値を返します（古い値の期間は、コミット直後の読み取りの実行時間です）。")
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"oracle-n-plus-1-demo/internal/chrometrace"
+	"oracle-n-plus-1-demo/internal/querylog"
+)
+
+// openQueryLog - -query-log の書き出し先を開く（stderr・stdout以外はファイルを作成し、終了時に閉じる）
+func openQueryLog(target string, opts querylog.Options) (*querylog.Logger, func() error, error) {
+	switch target {
+	case "stderr":
+		return querylog.New(os.Stderr, opts), func() error { return nil }, nil
+	case "stdout":
+		return querylog.New(os.Stdout, opts), func() error { return nil }, nil
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create query log: %w", err)
+	}
+	return querylog.New(f, opts), f.Close, nil
+}
+
+// writeTrace - -trace のファイルを書き出す（-json の標準出力を汚さないよう、書き出し先は標準エラーに表示する）
+func writeTrace(r *chrometrace.Recorder, path string) error {
+	if err := r.WriteFile(path); err != nil {
+		return err
+	}
+	log.Printf("トレースファイル: %s（%d区間。chrome://tracing または https://ui.perfetto.dev で開けます）", path, r.Len())
+	return nil
+}
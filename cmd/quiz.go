@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"oracle-n-plus-1-demo/internal/prompt"
+	"oracle-n-plus-1-demo/internal/service"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+// selectQuizScenarios - -quiz-scenario で指定したシナリオ（省略時は出題できるすべてのシナリオ）
+func selectQuizScenarios(id string) ([]service.QuizScenario, error) {
+	if id == "" {
+		return service.QuizScenarios(), nil
+	}
+	sc, err := service.FindQuizScenario(id)
+	if err != nil {
+		return nil, err
+	}
+	return []service.QuizScenario{sc}, nil
+}
+
+// runQuiz - 手法名を伏せて計測結果を示し、N+1の手法を当ててもらう
+func runQuiz(demoService *service.DemoService, scenarios []service.QuizScenario, days int, in io.Reader) {
+	p := prompt.New(in, os.Stdout)
+	answered, correct := 0, 0
+
+	for i, sc := range scenarios {
+		fmt.Printf("\n=== クイズ %d/%d: %s ===\n", i+1, len(scenarios), sc.Title)
+		fmt.Println("手法の名前を伏せて計測します。計測結果から、N+1問題のある手法を当ててください。")
+		fmt.Println()
+
+		quiz, err := demoService.RunQuiz(sc.ID, days)
+		if err != nil {
+			fmt.Printf("\nクイズの計測中にエラー: %v\n", err)
+			return
+		}
+		displayQuizMetrics(quiz)
+		fmt.Printf("\nヒント: %s\n", sc.Hint)
+
+		answer, err := p.Choose("N+1問題のある手法はどれですか？", quiz.Labels())
+		switch {
+		case errors.Is(err, prompt.ErrQuit):
+			fmt.Println("\nクイズを終了します")
+			displayQuizScore(answered, correct)
+			return
+		case errors.Is(err, prompt.ErrNoInput):
+			fmt.Println("回答の入力がないため、正解を表示します")
+		case err != nil:
+			fmt.Printf("\n回答を読み取れません: %v\n", err)
+			return
+		default:
+			ok, err := quiz.Check(answer)
+			if err != nil {
+				fmt.Printf("\n回答を判定できません: %v\n", err)
+				return
+			}
+			answered++
+			if ok {
+				correct++
+				fmt.Printf("\n正解です！ 手法 %s がN+1問題のある手法でした。\n", answer)
+			} else {
+				fmt.Printf("\n不正解です。正解は手法 %s でした。\n", quiz.Answer().Label)
+			}
+		}
+		displayQuizAnswer(quiz)
+	}
+
+	displayQuizScore(answered, correct)
+}
+
+// displayQuizMetrics - 呼び名ごとの計測結果を表示（手法名は表示しない）
+func displayQuizMetrics(quiz *service.Quiz) {
+	fmt.Println("\n--- 計測結果 ---")
+	fmt.Printf("%-6s %14s %8s %10s %14s\n", "手法", "実行時間", "件数", "SQL実行", "ラウンドトリップ")
+	for _, run := range quiz.Runs {
+		executions, roundTrips := "-", "-"
+		if run.Result.SessionStats != nil {
+			executions = fmt.Sprintf("%d", run.Result.SessionStats[sessionstats.ExecuteCount])
+			roundTrips = fmt.Sprintf("%d", run.Result.SessionStats[sessionstats.RoundTrips])
+		}
+		fmt.Printf("%-6s %14v %8d %10s %14s\n", run.Label, run.Result.ExecutionTime, run.Result.RecordCount, executions, roundTrips)
+	}
+}
+
+// displayQuizAnswer - 呼び名と手法の対応と解説を表示
+func displayQuizAnswer(quiz *service.Quiz) {
+	fmt.Println("\n--- 答え合わせ ---")
+	for _, run := range quiz.Runs {
+		mark := ""
+		if run.NPlusOne {
+			mark = " ← N+1"
+		}
+		fmt.Printf("  手法 %s: %s%s\n", run.Label, run.MethodLabel, mark)
+	}
+	fmt.Printf("\n解説: %s\n", quiz.Scenario.Explanation)
+}
+
+// displayQuizScore - 正解数を表示（回答がなかった場合は表示しない）
+func displayQuizScore(answered, correct int) {
+	if answered == 0 {
+		return
+	}
+	fmt.Printf("\n=== 結果: %d問中%d問正解 ===\n", answered, correct)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/rac"
+)
+
+// openRACPins - RACの構成を表示し、-rac-pin で指定したインスタンスごとに接続プールを作る
+//
+// 戻り値はシナリオ → そのシナリオを固定するインスタンスの接続プールと、作った接続プールを閉じる関数。
+func openRACPins(db *sql.DB, cfg *config.Config, pins map[string]string) (map[string]*sql.DB, func(), error) {
+	instances, instancesErr := rac.Instances(db)
+	switch {
+	case instancesErr != nil:
+		fmt.Printf("GV$INSTANCEを参照できないため、クラスタ構成を確認せずに記録します: %v\n", instancesErr)
+	case len(instances) == 1:
+		fmt.Println("単一インスタンスのデータベースです（インスタンス間のブロック転送によるgc待機は発生しません）")
+	default:
+		names := make([]string, len(instances))
+		for i, inst := range instances {
+			names[i] = inst.String()
+		}
+		fmt.Printf("RACインスタンス: %s\n", strings.Join(names, ", "))
+	}
+	if current, err := rac.Current(db); err == nil {
+		fmt.Printf("既定の接続先インスタンス: %s\n", current)
+	}
+
+	// 同じインスタンスに固定するシナリオは接続プールを共有する
+	byInstance := make(map[string][]string)
+	for scenario, instance := range pins {
+		key := strings.ToUpper(instance)
+		byInstance[key] = append(byInstance[key], scenario)
+	}
+	names := make([]string, 0, len(byInstance))
+	for name := range byInstance {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var opened []*sql.DB
+	closeAll := func() {
+		for _, pinned := range opened {
+			closeDatabase(pinned)
+		}
+	}
+	pinned := make(map[string]*sql.DB)
+	for _, name := range names {
+		if instancesErr == nil && !hasInstance(instances, name) {
+			closeAll()
+			return nil, nil, fmt.Errorf("インスタンス %s はクラスタにありません", name)
+		}
+		instanceDB, err := connectInstance(cfg, name)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		opened = append(opened, instanceDB)
+
+		scenarios := byInstance[name]
+		sort.Strings(scenarios)
+		for _, scenario := range scenarios {
+			pinned[scenario] = instanceDB
+		}
+		fmt.Printf("インスタンス %s に固定: %s\n", name, strings.Join(scenarios, ", "))
+	}
+	return pinned, closeAll, nil
+}
+
+// hasInstance - インスタンス名がクラスタの一覧にあるか
+func hasInstance(instances []rac.Instance, name string) bool {
+	for _, inst := range instances {
+		if strings.EqualFold(inst.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// connectInstance - インスタンスを指定して接続し、実際の接続先を確認する
+func connectInstance(cfg *config.Config, name string) (*sql.DB, error) {
+	instanceCfg := *cfg
+	instanceCfg.DBInstanceName = name
+	db, err := config.ConnectDatabase(&instanceCfg)
+	if err != nil {
+		return nil, fmt.Errorf("インスタンス %s への接続に失敗しました: %w", name, err)
+	}
+	if err := db.Ping(); err != nil {
+		closeDatabase(db)
+		return nil, fmt.Errorf("インスタンス %s への接続テストに失敗しました: %w", name, err)
+	}
+
+	// サービスが指定したインスタンスで提供されていない場合、リスナーが別のインスタンスへ振り分けることがある
+	current, err := rac.Current(db)
+	if err != nil {
+		closeDatabase(db)
+		return nil, err
+	}
+	if !strings.EqualFold(current.Name, name) {
+		closeDatabase(db)
+		return nil, fmt.Errorf("インスタンス %s を指定しましたが %s に接続されました（サービスがそのインスタンスで起動しているか確認してください）", name, current.Name)
+	}
+	return db, nil
+}
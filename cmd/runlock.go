@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/runlock"
+)
+
+// staleBreakWait - 古いロックの保持者を切断した後、Oracleがロックを解放するまで待つ時間の下限
+const staleBreakWait = 30 * time.Second
+
+// acquireRunLock - 同じスキーマで計測が同時に実行されないよう実行ロックを取得する
+//
+// DBMS_LOCKを実行できない場合は警告を表示してnilを返す（ロックなしで計測する）。
+// 古いロックが残っている場合、breakStaleがtrueなら保持者のセッションを切断して取得し直す。
+func acquireRunLock(ctx context.Context, db *sql.DB, opts runlock.Options, breakStale bool) (*runlock.Lock, error) {
+	lock, err := runlock.Acquire(ctx, db, opts)
+	var lockedErr *runlock.LockedError
+	switch {
+	case err == nil:
+		fmt.Printf("実行ロックを取得しました（スキーマ %s）\n", lock.Schema)
+		return lock, nil
+	case errors.Is(err, runlock.ErrUnavailable):
+		fmt.Printf("DBMS_LOCKのEXECUTE権限がないため、同時実行を防止せずに計測します: %v\n", err)
+		return nil, nil
+	case !errors.As(err, &lockedErr):
+		return nil, err
+	}
+
+	if lockedErr.Holder == nil {
+		fmt.Printf("スキーマ %s では別の計測が実行中です（GV$LOCK・GV$SESSIONを参照できないため保持者は表示できません）\n", lockedErr.Schema)
+		return nil, err
+	}
+	fmt.Printf("スキーマ %s では別の計測が実行中です: %s\n", lockedErr.Schema, lockedErr.Holder)
+	if !lockedErr.Stale() {
+		fmt.Println("終了を待つ場合は -lock-wait を指定してください")
+		return nil, err
+	}
+	if !breakStale {
+		fmt.Printf("保持者のセッションは %v 以上応答していないため、クライアントが異常終了した古いロックの可能性があります。-break-stale-lock で保持者のセッションを切断できます\n", lockedErr.StaleAfter)
+		return nil, err
+	}
+
+	fmt.Println("古いロックの保持者のセッションを切断します")
+	if err := runlock.BreakStale(ctx, db, lockedErr); err != nil {
+		return nil, fmt.Errorf("古いロックを解除できません: %w", err)
+	}
+	opts.Wait = max(opts.Wait, staleBreakWait)
+	lock, err = runlock.Acquire(ctx, db, opts)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("実行ロックを取得しました（スキーマ %s）\n", lock.Schema)
+	return lock, nil
+}
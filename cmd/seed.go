@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/provision"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/seed"
+)
+
+// runSeed - seedコマンド（不足しているデモのオブジェクトを作成し、指定した件数の合成データを一括投入）
+func runSeed(args []string) error {
+	defaults := seed.DefaultConfig()
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	orders := fs.Int("orders", defaults.Orders, "投入する受注の件数")
+	detailsPerOrder := fs.Int("details-per-order", defaults.DetailsPerOrder, "受注あたりの明細数")
+	// 投入モードの指定として案内されている名前の別名
+	fs.IntVar(orders, "seed-orders", defaults.Orders, "-orders の別名")
+	fs.IntVar(detailsPerOrder, "seed-details-per-order", defaults.DetailsPerOrder, "-details-per-order の別名")
+	departments := fs.Int("departments", defaults.Departments, "投入する部署の数")
+	employeesPerDept := fs.Int("employees-per-department", defaults.EmployeesPerDepartment, "部署あたりの社員数")
+	projects := fs.Int("projects", defaults.Projects, "投入するプロジェクトの数")
+	projectsPerEmployee := fs.Int("projects-per-employee", defaults.ProjectsPerEmployee, "社員あたりのプロジェクトの割り当て数の上限（1〜上限件を割り当てる）")
+	products := fs.Int("products", defaults.Products, "投入する商品の数")
+	customers := fs.Int("customers", defaults.Customers, "受注の顧客の数（顧客IDは1001から）")
+	days := fs.Int("days", defaults.Days, "受注日を散らす期間（過去何日間）")
+	deletedPercent := fs.Int("deleted-percent", defaults.DeletedPercent, "論理削除済みにする受注の割合（%）")
+	notesMaxChars := fs.Int("notes-max-chars", defaults.NotesMaxChars, "受注備考（CLOB）の最大文字数（0: 備考を付けない）")
+	batchSize := fs.Int("batch", defaults.BatchSize, "配列バインド1回（1コミット）あたりの行数")
+	randomSeed := fs.Uint64("seed", defaults.Seed, "乱数シード（同じ件数とシードなら同じデータになる）")
+	jsonPath := fs.String("json", "", "結果をJSONで出力するファイル")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg := seed.Config{
+		Departments:            *departments,
+		EmployeesPerDepartment: *employeesPerDept,
+		Projects:               *projects,
+		ProjectsPerEmployee:    *projectsPerEmployee,
+		Products:               *products,
+		Customers:              *customers,
+		Orders:                 *orders,
+		DetailsPerOrder:        *detailsPerOrder,
+		Days:                   *days,
+		DeletedPercent:         *deletedPercent,
+		NotesMaxChars:          *notesMaxChars,
+		BatchSize:              *batchSize,
+		Seed:                   *randomSeed,
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	ctx := context.Background()
+	schemaReport, err := seed.EnsureSchema(ctx, db, func(stmt string) {
+		fmt.Printf("作成: %s\n", strings.SplitN(stmt, "\n", 2)[0])
+	})
+	if err != nil {
+		if schemaReport != nil {
+			displaySetupReport(schemaReport)
+		}
+		return fmt.Errorf("スキーマの作成に失敗しました: %w", err)
+	}
+	if mismatched := schemaReport.Count(provision.StatusMismatch); mismatched > 0 {
+		fmt.Printf("定義がスクリプトと異なるオブジェクトが%d件あります（setup -dry-run で確認できます）。投入は続けます。\n", mismatched)
+	}
+
+	fmt.Printf("\n受注%d件（明細%d件/受注）・社員%d人を投入します（シード %d、配列バインド %d行ずつ）\n",
+		cfg.Orders, cfg.DetailsPerOrder, cfg.Departments*cfg.EmployeesPerDepartment, cfg.Seed, cfg.BatchSize)
+	result, err := seed.Run(ctx, db, cfg, func(t seed.TableResult) {
+		fmt.Printf("%s: %d件 (%dバッチ, %v)\n", t.Table, t.Rows, t.Batches, t.Duration.Round(time.Millisecond))
+	})
+	if err != nil {
+		return fmt.Errorf("データの投入に失敗しました（投入済みのバッチはコミットされています）: %w", err)
+	}
+	displaySeedReport(result)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal seed report: %w", err)
+		}
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("結果ファイルの書き込みに失敗しました: %w", err)
+		}
+		fmt.Printf("\n結果ファイル: %s\n", *jsonPath)
+	}
+	return nil
+}
+
+// displaySeedReport - 投入した行数・スループットと後処理の警告を表示
+func displaySeedReport(r *seed.Report) {
+	w := report.Stdout()
+	w.Heading("合成データの投入結果")
+	w.Linef("合計 %d件を %v で投入しました（シーケンスの調整・統計の収集・月次売上のマテリアライズドビューの更新を含む）",
+		r.Rows(), r.Elapsed.Round(time.Millisecond))
+	if seconds := r.Elapsed.Seconds(); seconds > 0 {
+		w.Linef("スループット: %.0f 行/秒", float64(r.Rows())/seconds)
+	}
+	for _, warning := range r.Warnings {
+		w.Linef("警告: %s", warning)
+	}
+	w.Blank()
+	w.Line("既存のデータは残したまま、各表の最大IDの次から投入しています。投入し直す場合は cleanup -dry-run=false で表を削除してから seed を実行してください。")
+}
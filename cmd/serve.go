@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/api"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// runServe - serveコマンド（顧客サマリーAPIを起動）
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "待ち受けアドレス")
+	ttl := fs.Duration("cache-ttl", service.DefaultSummaryTTL, "cached手法のキャッシュ有効期限")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	summaries := newSummaryService(cfg, db, *ttl)
+	defer func() {
+		if err := summaries.Close(); err != nil {
+			log.Printf("Redisクローズエラー: %v", err)
+		}
+	}()
+
+	// シャットダウンが時間内に終わらない場合に、処理中のリクエストのコンテキストを取り消す
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           api.NewServer(summaries),
+		ReadHeaderTimeout: 5 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Printf("顧客サマリーAPIを起動しました: http://localhost%s/customers/{id}/summary?strategy=n1|sql|cached\n", *addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("サーバーの起動に失敗しました: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	// 新しい接続の受け付けを止め、処理中のリクエストの完了を待つ
+	fmt.Println("\nシャットダウン中...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		cancelRequests()
+		if cerr := server.Close(); cerr != nil {
+			log.Printf("サーバーのクローズエラー: %v", cerr)
+		}
+		return fmt.Errorf("処理中のリクエストが時間内に終わらなかったため打ち切りました: %w", err)
+	}
+	return nil
+}
+
+// newSummaryService - Redis接続を試行して顧客サマリーサービスを作成（Redisなしでも動作する）
+func newSummaryService(cfg *config.Config, db *sql.DB, ttl time.Duration) *service.CustomerSummaryService {
+	redisClient, err := config.ConnectRedis(cfg)
+	if err != nil {
+		fmt.Printf("Redis接続に失敗しました（cached手法は利用できません）: %v\n", err)
+	}
+	return service.NewCustomerSummaryService(db, redisClient, ttl)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/provision"
+	"oracle-n-plus-1-demo/scripts/ddl"
+)
+
+// runSetup - setupコマンド（不足しているデモのオブジェクトを作成し、既存のものを定義のチェックサムで検証）
+func runSetup(args []string) error {
+	fs := flag.NewFlagSet("setup", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "作成せずに不足と定義の不一致を表示するだけにする")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	p, err := provision.New(db, ddl.CreateTables)
+	if err != nil {
+		return err
+	}
+
+	report, err := p.Run(context.Background(), !*dryRun, func(stmt string) {
+		fmt.Printf("作成: %s\n", strings.SplitN(stmt, "\n", 2)[0])
+	})
+	if err != nil {
+		return fmt.Errorf("セットアップに失敗しました: %w", err)
+	}
+
+	displaySetupReport(report)
+
+	if report.Count(provision.StatusMismatch) > 0 {
+		fmt.Println("\n定義が異なるオブジェクトは変更していません。cleanup で削除してから setup を再実行すると作り直せます（データも削除されます）。")
+	}
+	if report.Count(provision.StatusMissing) > 0 {
+		fmt.Println("\n-dry-run を外すと不足しているオブジェクトを作成します。")
+	}
+	if report.Count(provision.StatusCreated) > 0 {
+		fmt.Println("\nデータは scripts/dml/insert_initial_data.sql・scripts/load_test_data.sh・-ingest-dir で投入してください。")
+	}
+	return report.Err()
+}
+
+// displaySetupReport - オブジェクトごとの確認結果を表示
+func displaySetupReport(report *provision.Report) {
+	fmt.Println("\n=== デモスキーマの確認 ===")
+	for _, c := range report.Checks {
+		checksum := c.Expected
+		if c.Actual != "" && c.Actual != c.Expected {
+			checksum = fmt.Sprintf("期待 %s / 実際 %s", c.Expected, c.Actual)
+		}
+		fmt.Printf("[%-8s] %-18s %-30s %s\n", c.Status, c.Type, c.Name, checksum)
+		for _, line := range c.Missing {
+			fmt.Printf("    - %s（スクリプトにあり、実際にない）\n", line)
+		}
+		for _, line := range c.Unexpected {
+			fmt.Printf("    + %s（実際にあり、スクリプトにない）\n", line)
+		}
+		if c.Error != "" {
+			fmt.Printf("    エラー: %s\n", c.Error)
+		}
+	}
+
+	fmt.Printf("\nOK: %d件, 作成: %d件, 不足: %d件, 定義の不一致: %d件, 失敗: %d件\n",
+		report.Count(provision.StatusOK), report.Count(provision.StatusCreated), report.Count(provision.StatusMissing),
+		report.Count(provision.StatusMismatch), report.Count(provision.StatusFailed))
+}
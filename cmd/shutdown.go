@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// shutdown - 計測中にSIGINT/SIGTERMを受け取ったときの中断と後片付け
+//
+// 最初のシグナルでctxを取り消し（新しい計測を始めず、実行中のSQLを中断する）、途中経過の表示、
+// 結果の書き出し、登録した後片付け（PL/SQL関数の削除・Redis/Oracleの接続プールのクローズ）を順に行って
+// 終了コード130で終了する。後片付けの途中で再びシグナルを受け取った場合は待たずに終了する。
+type shutdown struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	signals chan os.Signal
+	done    chan struct{}
+
+	mu sync.Mutex
+	// progress - 実行中のシナリオの途中経過を表示する（シナリオの実行中のみ）
+	progress func(sig os.Signal)
+	// flush - 完了した結果を書き出す
+	flush   func()
+	closers []closer
+
+	closeOnce sync.Once
+}
+
+// closer - 後片付けの処理と、失敗時の表示名
+type closer struct {
+	name string
+	fn   func() error
+}
+
+// newShutdown - シグナルの監視を始める
+func newShutdown() *shutdown {
+	ctx, cancel := context.WithCancel(context.Background())
+	sd := &shutdown{
+		ctx:     ctx,
+		cancel:  cancel,
+		signals: make(chan os.Signal, 2),
+		done:    make(chan struct{}),
+	}
+	signal.Notify(sd.signals, os.Interrupt, syscall.SIGTERM)
+	go sd.watch()
+	return sd
+}
+
+// watch - シグナルを受け取ったら中断して終了する
+func (sd *shutdown) watch() {
+	sig := <-sd.signals
+	sd.cancel()
+	fmt.Fprintf(os.Stderr, "\n%vを受け取りました。新しい計測を止めて後片付けをします（もう一度押すと待たずに終了します）\n", sig)
+	go func() {
+		<-sd.signals
+		fmt.Fprintln(os.Stderr, "後片付けを待たずに終了します")
+		os.Exit(exitInterrupted)
+	}()
+
+	sd.mu.Lock()
+	progress, flush := sd.progress, sd.flush
+	sd.mu.Unlock()
+	if progress != nil {
+		progress(sig)
+	}
+	if flush != nil {
+		flush()
+	}
+	sd.close()
+	close(sd.done)
+	os.Exit(exitInterrupted)
+}
+
+// interrupted - シグナルを受け取って中断したか
+func (sd *shutdown) interrupted() bool {
+	return sd.ctx.Err() != nil
+}
+
+// setProgress - 中断時に途中経過を表示する処理を設定（戻り値で解除する）
+func (sd *shutdown) setProgress(fn func(sig os.Signal)) func() {
+	sd.mu.Lock()
+	sd.progress = fn
+	sd.mu.Unlock()
+	return func() {
+		sd.mu.Lock()
+		sd.progress = nil
+		sd.mu.Unlock()
+	}
+}
+
+// setFlush - 中断時に完了した結果を書き出す処理を設定
+func (sd *shutdown) setFlush(fn func()) {
+	sd.mu.Lock()
+	sd.flush = fn
+	sd.mu.Unlock()
+}
+
+// onClose - 終了時の後片付けを登録（登録と逆の順に実行する）
+func (sd *shutdown) onClose(name string, fn func() error) {
+	sd.mu.Lock()
+	sd.closers = append(sd.closers, closer{name: name, fn: fn})
+	sd.mu.Unlock()
+}
+
+// close - 登録した後片付けを1回だけ実行
+func (sd *shutdown) close() {
+	sd.closeOnce.Do(func() {
+		sd.mu.Lock()
+		closers := sd.closers
+		sd.mu.Unlock()
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i].fn(); err != nil {
+				log.Printf("%sのクローズエラー: %v", closers[i].name, err)
+			}
+		}
+	})
+}
+
+// finish - 正常終了時は後片付けを実行してシグナルの監視を止める（中断時は監視側の後片付けと終了を待つ）
+func (sd *shutdown) finish() {
+	if sd.interrupted() {
+		<-sd.done
+		return
+	}
+	signal.Stop(sd.signals)
+	sd.close()
+}
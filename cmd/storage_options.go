@@ -0,0 +1,8 @@
+package main
+
+import "oracle-n-plus-1-demo/internal/clustering"
+
+// runStorageOptions - storage-optionsコマンド（明細の表の圧縮・属性クラスタリングとJOIN・バッチ取得の比較）
+func runStorageOptions(args []string) error {
+	return runClusteringSet("storage-options", clustering.SetStorage, args)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/aggregate"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// failConditions - -fail-on で指定された判定条件
+type failConditions struct {
+	regression bool
+	cache      bool
+}
+
+// parseFailOn - -fail-on（カンマ区切り: regression, cache）を解釈する
+func parseFailOn(spec string) (failConditions, error) {
+	var conds failConditions
+	for _, name := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "regression":
+			conds.regression = true
+		case "cache":
+			conds.cache = true
+		default:
+			return conds, fmt.Errorf("unknown -fail-on condition: %q", name)
+		}
+	}
+	return conds, nil
+}
+
+// verdict - -fail-on の条件による判定結果
+type verdict struct {
+	Baseline            string             `json:"baseline,omitempty"`
+	RegressionThreshold float64            `json:"regression_threshold,omitempty"`
+	Regressions         []aggregate.Change `json:"regressions,omitempty"`
+	// CacheEfficiency - 総合キャッシュ効率（%）（包括分析の結果がない場合はnil）
+	CacheEfficiency    *float64 `json:"cache_efficiency,omitempty"`
+	MinCacheEfficiency float64  `json:"min_cache_efficiency,omitempty"`
+	ExitCode           int      `json:"exit_code"`
+
+	conds failConditions
+}
+
+// judgeRun - -fail-on の条件で計測結果を判定する（複数該当時は小さい終了コード）
+//
+// cacheEfficiencyは総合キャッシュ効率（CacheService.OverallEfficiency）を返す。
+func judgeRun(conds failConditions, baseline *aggregate.Run, current []service.PerformanceResult,
+	cacheEfficiency func() (float64, bool), regressionPct, minCacheEff float64) *verdict {
+	v := &verdict{ExitCode: exitOK, conds: conds}
+
+	if conds.regression {
+		v.Baseline = baseline.Path
+		v.RegressionThreshold = regressionPct
+		v.Regressions = aggregate.CompareResults(baseline.Results, current, regressionPct)
+		if len(v.Regressions) > 0 {
+			v.ExitCode = exitRegression
+		}
+	}
+
+	if conds.cache {
+		v.MinCacheEfficiency = minCacheEff
+		if efficiency, ok := cacheEfficiency(); ok {
+			v.CacheEfficiency = &efficiency
+			if efficiency < minCacheEff && v.ExitCode == exitOK {
+				v.ExitCode = exitCacheEfficiency
+			}
+		}
+	}
+
+	return v
+}
+
+// display - 判定結果を表示
+func (v *verdict) display() {
+	if !v.conds.regression && !v.conds.cache {
+		return
+	}
+
+	fmt.Println("\n=== 判定 ===")
+
+	if v.conds.regression {
+		if len(v.Regressions) == 0 {
+			fmt.Printf("回帰: なし（基準: %s, しきい値: %.0f%%）\n", v.Baseline, v.RegressionThreshold)
+		} else {
+			fmt.Printf("回帰: %d件（基準: %s, しきい値: %.0f%%）\n", len(v.Regressions), v.Baseline, v.RegressionThreshold)
+			for _, r := range v.Regressions {
+				fmt.Printf("  - %s / %s: %v → %v（%+.1f%%）\n", r.Scenario, r.Method, r.Baseline, r.Current, r.ChangePercent)
+			}
+		}
+	}
+
+	if v.conds.cache {
+		switch {
+		case v.CacheEfficiency == nil:
+			fmt.Println("キャッシュ効率: 包括分析の結果がないため判定できません")
+		case *v.CacheEfficiency < v.MinCacheEfficiency:
+			fmt.Printf("キャッシュ効率: %.1f%%（下限 %.0f%% 未満）\n", *v.CacheEfficiency, v.MinCacheEfficiency)
+		default:
+			fmt.Printf("キャッシュ効率: %.1f%%（下限 %.0f%% 以上）\n", *v.CacheEfficiency, v.MinCacheEfficiency)
+		}
+	}
+
+	fmt.Printf("終了コード: %d\n", v.ExitCode)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/aggregate"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+func TestParseFailOn(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    failConditions
+		wantErr bool
+	}{
+		{spec: "", want: failConditions{}},
+		{spec: "regression", want: failConditions{regression: true}},
+		{spec: "regression,cache", want: failConditions{regression: true, cache: true}},
+		{spec: " cache , ,regression ", want: failConditions{regression: true, cache: true}},
+		{spec: "regression,latency", wantErr: true},
+		{spec: "Regression", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFailOn(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFailOn(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseFailOn(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestJudgeRun(t *testing.T) {
+	baseline := &aggregate.Run{
+		Path:    "baseline.json",
+		Results: []service.PerformanceResult{{Scenario: "orders", Method: "N+1_Problem", ExecutionTime: 100 * time.Millisecond}},
+	}
+	slower := []service.PerformanceResult{{Scenario: "orders", Method: "N+1_Problem", ExecutionTime: 150 * time.Millisecond}}
+	same := []service.PerformanceResult{{Scenario: "orders", Method: "N+1_Problem", ExecutionTime: 100 * time.Millisecond}}
+	efficiency := func(v float64, ok bool) func() (float64, bool) {
+		return func() (float64, bool) { return v, ok }
+	}
+
+	tests := []struct {
+		name            string
+		conds           failConditions
+		current         []service.PerformanceResult
+		efficiency      func() (float64, bool)
+		wantCode        int
+		wantRegressions int
+	}{
+		{name: "no conditions", current: slower, efficiency: efficiency(10, true), wantCode: exitOK},
+		{name: "regression", conds: failConditions{regression: true}, current: slower, efficiency: efficiency(10, true), wantCode: exitRegression, wantRegressions: 1},
+		{name: "no regression", conds: failConditions{regression: true}, current: same, efficiency: efficiency(10, true), wantCode: exitOK},
+		{name: "cache below minimum", conds: failConditions{cache: true}, current: slower, efficiency: efficiency(69.9, true), wantCode: exitCacheEfficiency},
+		{name: "cache at minimum", conds: failConditions{cache: true}, current: same, efficiency: efficiency(70, true), wantCode: exitOK},
+		// 包括分析の結果がなければキャッシュ効率では失敗させない
+		{name: "cache unavailable", conds: failConditions{cache: true}, current: same, efficiency: efficiency(0, false), wantCode: exitOK},
+		// 両方に該当したら小さい終了コード（回帰）
+		{name: "both", conds: failConditions{regression: true, cache: true}, current: slower, efficiency: efficiency(10, true), wantCode: exitRegression, wantRegressions: 1},
+	}
+	for _, tt := range tests {
+		v := judgeRun(tt.conds, baseline, tt.current, tt.efficiency, 20, 70)
+		if v.ExitCode != tt.wantCode || len(v.Regressions) != tt.wantRegressions {
+			t.Errorf("%s: judgeRun() = exit %d, %d regressions, want exit %d, %d regressions",
+				tt.name, v.ExitCode, len(v.Regressions), tt.wantCode, tt.wantRegressions)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"oracle-n-plus-1-demo/config"
+	"oracle-n-plus-1-demo/internal/signing"
+)
+
+// runVerify - verifyコマンド（エクスポートした結果ファイルの署名を検証）
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	sigPath := fs.String("sig", "", "署名ファイル（省略時は <ファイル>.sig、複数ファイル指定時は使用不可）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		return errors.New("検証するファイルを指定してください")
+	}
+	if *sigPath != "" && len(files) > 1 {
+		return errors.New("-sig は1ファイルのみ指定できます")
+	}
+
+	key := config.LoadSigningKey()
+	if len(key) == 0 {
+		return fmt.Errorf("RESULT_SIGNING_KEY を設定してください: %w", signing.ErrNoKey)
+	}
+
+	failed := 0
+	for _, file := range files {
+		sig, err := signing.VerifyFile(file, *sigPath, key)
+		if err != nil {
+			fmt.Printf("NG  %s: %v\n", file, err)
+			failed++
+			continue
+		}
+		fmt.Printf("OK  %s（署名日時: %s, SHA-256: %s）\n", file, sig.SignedAt.Format("2006-01-02 15:04:05"), sig.SHA256)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d件のファイルの署名を検証できませんでした", failed)
+	}
+
+	return nil
+}
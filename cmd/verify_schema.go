@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/schema"
+)
+
+// runVerifySchema - verify-schemaコマンド
+func runVerifySchema(args []string) error {
+	fs := flag.NewFlagSet("verify-schema", flag.ContinueOnError)
+	showInfo := fs.Bool("info", false, "INFOレベル（追加列など）の差分も表示する")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	report, err := schema.NewVerifier(db).Verify()
+	if err != nil {
+		return fmt.Errorf("スキーマ検証に失敗しました: %w", err)
+	}
+
+	fmt.Println("=== スキーマドリフトレポート ===")
+	fmt.Printf("検証テーブル数: %d\n\n", report.CheckedTables)
+
+	for _, d := range report.Drifts {
+		if d.Severity == schema.SeverityInfo && !*showInfo {
+			continue
+		}
+		fmt.Printf("[%-5s] %s.%s: %s\n", d.Severity, d.Table, d.Object, d.Message)
+	}
+
+	errCount := report.Count(schema.SeverityError)
+	warnCount := report.Count(schema.SeverityWarning)
+	fmt.Printf("\nERROR: %d件, WARN: %d件, INFO: %d件\n", errCount, warnCount, report.Count(schema.SeverityInfo))
+
+	if report.HasErrors() {
+		fmt.Println("scripts/ddl/create_tables.sql を適用して不足しているオブジェクトを作成してください。")
+		return errors.New("スキーマに不足があります")
+	}
+	if warnCount == 0 {
+		fmt.Println("スキーマは期待どおりです。")
+	}
+
+	return nil
+}
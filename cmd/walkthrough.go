@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"oracle-n-plus-1-demo/internal/lesson"
+	"oracle-n-plus-1-demo/internal/prompt"
+	"oracle-n-plus-1-demo/internal/service"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+// selectLessons - -lesson で指定した教材（省略時はカタログのすべての教材）
+func selectLessons(catalog lesson.Catalog, id string) ([]lesson.Lesson, error) {
+	if id == "" {
+		return catalog.Lessons, nil
+	}
+	l, err := catalog.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	return []lesson.Lesson{l}, nil
+}
+
+// runWalkthrough - 教材のステップごとに説明・SQL・予想を表示し、入力を待ってから実行して観測結果を示す
+func runWalkthrough(demoService *service.DemoService, lessons []lesson.Lesson, days int, in io.Reader) {
+	p := prompt.New(in, os.Stdout)
+	for i, l := range lessons {
+		if err := walkLesson(demoService, l, i+1, len(lessons), days, p); err != nil {
+			if errors.Is(err, prompt.ErrQuit) {
+				fmt.Println("\nウォークスルーを終了します")
+				return
+			}
+			fmt.Printf("\nウォークスルー中にエラー: %v\n", err)
+			return
+		}
+	}
+}
+
+// walkLesson - 1つの教材を最初のステップから順に進める
+func walkLesson(demoService *service.DemoService, l lesson.Lesson, index, total, days int, p *prompt.Prompter) error {
+	fmt.Printf("\n=== 教材 %d/%d: %s ===\n", index, total, l.Title)
+	if l.Summary != "" {
+		fmt.Println(l.Summary)
+	}
+
+	results := make([]service.PerformanceResult, 0, len(l.Steps))
+	for i, st := range l.Steps {
+		fmt.Printf("\n--- ステップ %d/%d: %s ---\n", i+1, len(l.Steps), st.Title)
+		fmt.Println(st.Narration)
+		fmt.Println("\n実行するSQL:")
+		for _, line := range st.SQL {
+			fmt.Printf("    %s\n", line)
+		}
+		fmt.Println("\n予想される動き:")
+		fmt.Printf("  %s\n", st.Expected)
+
+		if err := p.Pause("SQLを実行します"); err != nil {
+			return err
+		}
+		fmt.Println()
+		result, err := demoService.RunLessonAction(st.Action, days)
+		if err != nil {
+			return fmt.Errorf("%s: %w", st.ID, err)
+		}
+		results = append(results, result)
+
+		fmt.Println("\n観測結果:")
+		fmt.Printf("  %s\n", describeObservation(result))
+		if st.Takeaway != "" {
+			fmt.Printf("  → %s\n", st.Takeaway)
+		}
+
+		if i < len(l.Steps)-1 {
+			if err := p.Pause("次のステップへ進みます"); err != nil {
+				return err
+			}
+		}
+	}
+
+	displayLessonSummary(l, results)
+	return nil
+}
+
+// describeObservation - 観測結果を1行で表す（セッション統計を取得できない場合は時間と件数のみ）
+func describeObservation(r service.PerformanceResult) string {
+	observed := fmt.Sprintf("%d件を%vで取得", r.RecordCount, r.ExecutionTime)
+	if r.SessionStats == nil {
+		return observed + "（V$MYSTATを参照できないため実行回数は表示しません）"
+	}
+	return fmt.Sprintf("%s、SQLの実行 %d回、ラウンドトリップ %d回", observed,
+		r.SessionStats[sessionstats.ExecuteCount], r.SessionStats[sessionstats.RoundTrips])
+}
+
+// displayLessonSummary - 教材のステップごとの観測結果を一覧表示
+func displayLessonSummary(l lesson.Lesson, results []service.PerformanceResult) {
+	fmt.Printf("\n=== まとめ: %s ===\n", l.Title)
+	fmt.Printf("%-40s %14s %8s %10s %14s\n", "ステップ", "実行時間", "件数", "SQL実行", "ラウンドトリップ")
+	for i, r := range results {
+		executions, roundTrips := "-", "-"
+		if r.SessionStats != nil {
+			executions = fmt.Sprintf("%d", r.SessionStats[sessionstats.ExecuteCount])
+			roundTrips = fmt.Sprintf("%d", r.SessionStats[sessionstats.RoundTrips])
+		}
+		fmt.Printf("%-40s %14v %8d %10s %14s\n", l.Steps[i].Title, r.ExecutionTime, r.RecordCount, executions, roundTrips)
+	}
+}
@@ -1,22 +1,52 @@
 package config
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
-	_ "github.com/sijms/go-ora/v2"
+	"github.com/redis/go-redis/v9"
+	go_ora "github.com/sijms/go-ora/v2"
+
+	"oracle-n-plus-1-demo/internal/explain"
+	"oracle-n-plus-1-demo/internal/querylog"
+	"oracle-n-plus-1-demo/internal/resultcache"
+)
+
+// 接続プールの設定（実行メタデータにも記録する）
+const (
+	MaxOpenConns = 10
+	MaxIdleConns = 5
 )
 
+// DefaultNLSDateFormat - NLS_DATE_FORMAT の既定値（文字列で受け取る日付をサーバーの設定に依存させない）
+const DefaultNLSDateFormat = "YYYY-MM-DD HH24:MI:SS"
+
 // Config - アプリケーション設定
 type Config struct {
 	DBHost        string
 	DBPort        int
 	DBServiceName string
-	DBUsername    string
-	DBPassword    string
+	// DBInstanceName - RACで接続先を固定するインスタンス名（空ならサービスの負荷分散に任せる）
+	DBInstanceName string
+	DBUsername     string
+	DBPassword     string
+
+	// 接続ごとに設定するセッションパラメータ
+	Session SessionSettings
+
+	// QueryLog - nil以外なら、実行したSQL・バインド変数・行数・時間を渡す（-query-log、-trace）
+	QueryLog querylog.Recorder
+	// PlanStatistics - 問い合わせにGATHER_PLAN_STATISTICSヒントを加えて実行する（-plan-stats）
+	PlanStatistics bool
+	// ResultCache - nil以外なら、問い合わせの結果をプロセス内にキャッシュする（Go_Result_Cacheの手法の接続プール専用）
+	ResultCache *resultcache.Cache
 
 	// Redis設定（オプション）
 	RedisHost     string
@@ -31,15 +61,20 @@ func LoadConfig() (*Config, error) {
 	_ = godotenv.Load()
 
 	config := &Config{
-		DBHost:        getEnv("DB_HOST", "localhost"),
-		DBServiceName: getEnv("DB_SERVICE_NAME", "ORCLPDB1"),
-		DBUsername:    getEnv("DB_USERNAME", ""),
-		DBPassword:    getEnv("DB_PASSWORD", ""),
+		DBHost:         getEnv("DB_HOST", "localhost"),
+		DBServiceName:  getEnv("DB_SERVICE_NAME", "ORCLPDB1"),
+		DBInstanceName: os.Getenv("DB_INSTANCE_NAME"),
+		DBUsername:     getEnv("DB_USERNAME", ""),
+		DBPassword:     getEnv("DB_PASSWORD", ""),
+		Session: SessionSettings{
+			Territory:  os.Getenv("NLS_TERRITORY"),
+			DateFormat: getEnv("NLS_DATE_FORMAT", DefaultNLSDateFormat),
+			TimeZone:   os.Getenv("DB_TIME_ZONE"),
+		},
 
 		// Redis設定（オプション）
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       0,
 	}
 
 	// DBポート番号の解析
@@ -58,6 +93,13 @@ func LoadConfig() (*Config, error) {
 	}
 	config.RedisPort = redisPort
 
+	// Redisのデータベース番号の解析
+	redisDB, err := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	if err != nil || redisDB < 0 {
+		return nil, fmt.Errorf("invalid REDIS_DB: %q", os.Getenv("REDIS_DB"))
+	}
+	config.RedisDB = redisDB
+
 	// 必須項目のチェック
 	if config.DBUsername == "" {
 		return nil, fmt.Errorf("DB_USERNAME is required")
@@ -65,6 +107,12 @@ func LoadConfig() (*Config, error) {
 	if config.DBPassword == "" {
 		return nil, fmt.Errorf("DB_PASSWORD is required")
 	}
+	if config.DBInstanceName != "" && !validName(config.DBInstanceName) {
+		return nil, fmt.Errorf("invalid DB_INSTANCE_NAME: %q", config.DBInstanceName)
+	}
+	if err := config.Session.Validate(); err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
@@ -79,19 +127,268 @@ func ConnectDatabase(config *Config) (*sql.DB, error) {
 		config.DBPort,
 		config.DBServiceName,
 	)
+	if config.DBInstanceName != "" {
+		// 接続記述子の INSTANCE_NAME に相当し、サービスを提供するインスタンスのうち指定したものに接続する
+		dsn += "?" + url.Values{"instance name": {config.DBInstanceName}}.Encode()
+	}
 
-	db, err := sql.Open("oracle", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+	// 接続プールが新しい接続を作るたびにセッションパラメータを設定する
+	connector := NewSessionConnector(go_ora.NewConnector(dsn), config.Session)
+	// ALTER SESSION文は記録せず、アプリケーションが実行した文だけを記録する
+	if config.QueryLog != nil {
+		connector = querylog.Connector(connector, config.QueryLog)
+	}
+	// ヒントを加えた後の文（DBに送った文）を記録するよう、記録より外側で包む
+	if config.PlanStatistics {
+		connector = explain.StatisticsConnector(connector)
 	}
+	// キャッシュにヒットした問い合わせはDBへ送らないため、記録・計数もしない
+	if config.ResultCache != nil {
+		connector = resultcache.Connector(connector, config.ResultCache)
+	}
+	db := sql.OpenDB(connector)
 
 	// 接続プールの設定
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
+	db.SetMaxOpenConns(MaxOpenConns)
+	db.SetMaxIdleConns(MaxIdleConns)
 
 	return db, nil
 }
 
+// SessionSettings - 接続ごとに ALTER SESSION で設定するNLS・タイムゾーン（空の項目はサーバーの既定のまま）
+type SessionSettings struct {
+	Territory  string // NLS_TERRITORY（例: JAPAN）
+	DateFormat string // NLS_DATE_FORMAT（例: YYYY-MM-DD HH24:MI:SS）
+	TimeZone   string // TIME_ZONE（例: Asia/Tokyo、+09:00）
+	// FlashbackSCN - 0以外なら、DBMS_FLASHBACKでセッションの問い合わせをこのSCN時点のデータに固定する（DML・DDLは実行できなくなる）
+	FlashbackSCN uint64
+}
+
+// Validate - 値にALTER SESSION文を壊す文字が含まれていないか検証
+func (s SessionSettings) Validate() error {
+	for _, v := range []struct{ name, value string }{
+		{"NLS_TERRITORY", s.Territory}, {"NLS_DATE_FORMAT", s.DateFormat}, {"DB_TIME_ZONE", s.TimeZone},
+	} {
+		if strings.ContainsAny(v.value, "';") {
+			return fmt.Errorf("invalid %s: must not contain quotes or semicolons: %q", v.name, v.value)
+		}
+	}
+	return nil
+}
+
+// Statements - 接続ごとに実行するALTER SESSION文
+//
+// NLS_TERRITORYを変更するとNLS_DATE_FORMATがその地域の既定に戻るため、地域を先に設定する。
+func (s SessionSettings) Statements() []string {
+	var statements []string
+	if s.Territory != "" {
+		statements = append(statements, fmt.Sprintf("ALTER SESSION SET NLS_TERRITORY = '%s'", s.Territory))
+	}
+	if s.DateFormat != "" {
+		statements = append(statements, fmt.Sprintf("ALTER SESSION SET NLS_DATE_FORMAT = '%s'", s.DateFormat))
+	}
+	if s.TimeZone != "" {
+		statements = append(statements, fmt.Sprintf("ALTER SESSION SET TIME_ZONE = '%s'", s.TimeZone))
+	}
+	// フラッシュバック・モードでもALTER SESSIONは実行できるが、NLSの設定を済ませてから固定する
+	if s.FlashbackSCN != 0 {
+		statements = append(statements, fmt.Sprintf("BEGIN DBMS_FLASHBACK.ENABLE_AT_SYSTEM_CHANGE_NUMBER(%d); END;", s.FlashbackSCN))
+	}
+	return statements
+}
+
+// String - 設定するパラメータの一覧（表示用）
+func (s SessionSettings) String() string {
+	var parts []string
+	if s.Territory != "" {
+		parts = append(parts, "NLS_TERRITORY="+s.Territory)
+	}
+	if s.DateFormat != "" {
+		parts = append(parts, "NLS_DATE_FORMAT="+s.DateFormat)
+	}
+	if s.TimeZone != "" {
+		parts = append(parts, "TIME_ZONE="+s.TimeZone)
+	}
+	if s.FlashbackSCN != 0 {
+		parts = append(parts, fmt.Sprintf("AS OF SCN %d", s.FlashbackSCN))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NewSessionConnector - 接続を作成するたびにセッションパラメータを設定するコネクター
+//
+// 独自の接続文字列やドライバーの設定で接続プールを作るアプリケーションが、sql.OpenDBに渡して使う。
+// 日付を文字列で受け取る列があるため、settings.DateFormat は DefaultNLSDateFormat にしておく。
+func NewSessionConnector(base driver.Connector, settings SessionSettings) driver.Connector {
+	return &sessionConnector{Connector: base, statements: settings.Statements()}
+}
+
+// sessionConnector - 接続を作成した直後にALTER SESSION文を実行するコネクター
+type sessionConnector struct {
+	driver.Connector
+	statements []string
+}
+
+// Connect - 接続を作成してセッションパラメータを設定
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil || len(c.statements) == 0 {
+		return conn, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		closeConn(conn)
+		return nil, fmt.Errorf("driver connection does not support ExecContext")
+	}
+	for _, statement := range c.statements {
+		if _, err := execer.ExecContext(ctx, statement, nil); err != nil {
+			closeConn(conn)
+			return nil, fmt.Errorf("failed to apply session setting (%s): %w", statement, err)
+		}
+	}
+	return conn, nil
+}
+
+// closeConn - セッションパラメータを設定できなかった接続を閉じる
+func closeConn(conn driver.Conn) {
+	if cerr := conn.Close(); cerr != nil {
+		fmt.Printf("connection Close() failed: %v\n", cerr)
+	}
+}
+
+// ConnectRedis - Redisに接続する（REDIS_HOSTが空の場合はnilを返す）
+func ConnectRedis(config *Config) (*redis.Client, error) {
+	if config.RedisHost == "" {
+		return nil, nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", config.RedisHost, config.RedisPort),
+		Password: config.RedisPassword,
+		DB:       config.RedisDB,
+	})
+
+	// 接続テスト
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		if cerr := client.Close(); cerr != nil {
+			fmt.Printf("redis client Close() failed: %v\n", cerr)
+		}
+		return nil, fmt.Errorf("failed to connect redis: %w", err)
+	}
+
+	return client, nil
+}
+
+// LoadSigningKey - 結果ファイルの署名鍵（RESULT_SIGNING_KEY）を読み込む（未設定の場合は空）
+//
+// verifyコマンドなどDB接続を伴わない処理からも使えるよう、LoadConfigとは独立して読み込む。
+func LoadSigningKey() []byte {
+	_ = godotenv.Load()
+	return []byte(os.Getenv("RESULT_SIGNING_KEY"))
+}
+
+// LoadEnvironmentName - 結果に付ける実行環境名（BENCH_ENVIRONMENT）を読み込む（未設定の場合は空）
+func LoadEnvironmentName() string {
+	_ = godotenv.Load()
+	return os.Getenv("BENCH_ENVIRONMENT")
+}
+
+// LoadTelemetryEndpoint - 匿名化した改善率の送信先（TELEMETRY_ENDPOINT）を読み込む（未設定の場合は空）
+func LoadTelemetryEndpoint() string {
+	_ = godotenv.Load()
+	return os.Getenv("TELEMETRY_ENDPOINT")
+}
+
+// LoadServiceNames - 順に計測するPDBのサービス名の一覧（DB_SERVICE_NAMES、カンマ区切り）を読み込む（未設定の場合は空）
+func LoadServiceNames() string {
+	_ = godotenv.Load()
+	return os.Getenv("DB_SERVICE_NAMES")
+}
+
+// ParseServiceNames - カンマ区切りのサービス名を分割して検証する
+//
+// サービス名は結果の環境名とファイル名にも使うため、英数字と「_ . - $ #」に限る。
+func ParseServiceNames(spec string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.TrimSpace(part)
+		if name == "" {
+			continue
+		}
+		if !validName(name) {
+			return nil, fmt.Errorf("invalid service name %q", name)
+		}
+		if seen[strings.ToUpper(name)] {
+			return nil, fmt.Errorf("duplicate service name %q", name)
+		}
+		seen[strings.ToUpper(name)] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no service names in %q", spec)
+	}
+	return names, nil
+}
+
+// ConsumerGroupTarget - 計測するコンシューマ・グループと、そのグループに対応付けたサービス
+type ConsumerGroupTarget struct {
+	Group   string
+	Service string
+}
+
+// LoadConsumerGroups - 計測するコンシューマ・グループとサービスの対応（RESOURCE_CONSUMER_GROUPS）を読み込む（未設定の場合は空）
+func LoadConsumerGroups() string {
+	_ = godotenv.Load()
+	return os.Getenv("RESOURCE_CONSUMER_GROUPS")
+}
+
+// ParseConsumerGroups - 「グループ=サービス」のカンマ区切りを分割して検証する
+//
+// グループはリソース・マネージャのサービス名のマッピング（SERVICE_NAME属性）で割り当てるため、
+// グループごとに異なるサービスを指定する。
+func ParseConsumerGroups(spec string) ([]ConsumerGroupTarget, error) {
+	var targets []ConsumerGroupTarget
+	groups := make(map[string]bool)
+	services := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		group, service, ok := strings.Cut(part, "=")
+		group, service = strings.TrimSpace(group), strings.TrimSpace(service)
+		if !ok || group == "" || service == "" {
+			return nil, fmt.Errorf("invalid consumer group %q (want GROUP=SERVICE)", part)
+		}
+		if !validName(group) || !validName(service) {
+			return nil, fmt.Errorf("invalid consumer group %q", part)
+		}
+		group = strings.ToUpper(group)
+		if groups[group] {
+			return nil, fmt.Errorf("duplicate consumer group %q", group)
+		}
+		if services[strings.ToUpper(service)] {
+			return nil, fmt.Errorf("service %q is mapped to more than one consumer group", service)
+		}
+		groups[group] = true
+		services[strings.ToUpper(service)] = true
+		targets = append(targets, ConsumerGroupTarget{Group: group, Service: service})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no consumer groups in %q", spec)
+	}
+	return targets, nil
+}
+
+// validName - サービス名・グループ名に使える文字か（結果の環境名とファイル名にも使う）
+func validName(name string) bool {
+	return strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_.-$#", r))
+	}) < 0
+}
+
 // getEnv - 環境変数を取得（デフォルト値付き）
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSessionSettingsStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings SessionSettings
+		want     []string
+	}{
+		{name: "none", settings: SessionSettings{}},
+		{
+			name:     "date format only",
+			settings: SessionSettings{DateFormat: DefaultNLSDateFormat},
+			want:     []string{"ALTER SESSION SET NLS_DATE_FORMAT = 'YYYY-MM-DD HH24:MI:SS'"},
+		},
+		{
+			name:     "territory before date format",
+			settings: SessionSettings{Territory: "JAPAN", DateFormat: "YYYY/MM/DD", TimeZone: "Asia/Tokyo"},
+			want: []string{
+				"ALTER SESSION SET NLS_TERRITORY = 'JAPAN'",
+				"ALTER SESSION SET NLS_DATE_FORMAT = 'YYYY/MM/DD'",
+				"ALTER SESSION SET TIME_ZONE = 'Asia/Tokyo'",
+			},
+		},
+		{
+			name:     "flashback after nls",
+			settings: SessionSettings{DateFormat: "YYYY/MM/DD", FlashbackSCN: 1234567},
+			want: []string{
+				"ALTER SESSION SET NLS_DATE_FORMAT = 'YYYY/MM/DD'",
+				"BEGIN DBMS_FLASHBACK.ENABLE_AT_SYSTEM_CHANGE_NUMBER(1234567); END;",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.settings.Statements()
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Statements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings SessionSettings
+		wantErr  bool
+	}{
+		{name: "valid", settings: SessionSettings{Territory: "UNITED KINGDOM", DateFormat: `YYYY"年"MM"月"DD"日"`, TimeZone: "+09:00"}},
+		{name: "quote", settings: SessionSettings{DateFormat: "YYYY' OR '1"}, wantErr: true},
+		{name: "semicolon", settings: SessionSettings{TimeZone: "UTC; DROP TABLE orders"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.settings.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseServiceNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr string
+	}{
+		{name: "single", spec: "ORCLPDB1", want: []string{"ORCLPDB1"}},
+		{name: "trimmed", spec: " pdb1.example.com , PDB_2,", want: []string{"pdb1.example.com", "PDB_2"}},
+		{name: "empty", spec: " , ", wantErr: "no service names"},
+		{name: "duplicate ignores case", spec: "pdb1,PDB1", wantErr: "duplicate service name"},
+		{name: "path separator", spec: "pdb1/../x", wantErr: "invalid service name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseServiceNames(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseServiceNames() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseServiceNames() error = %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ParseServiceNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConsumerGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []ConsumerGroupTarget
+		wantErr string
+	}{
+		{
+			name: "two groups",
+			spec: "oltp_high=svc_high, OLTP_LOW = svc_low",
+			want: []ConsumerGroupTarget{{Group: "OLTP_HIGH", Service: "svc_high"}, {Group: "OLTP_LOW", Service: "svc_low"}},
+		},
+		{name: "empty", spec: "", wantErr: "no consumer groups"},
+		{name: "missing service", spec: "OLTP_HIGH=", wantErr: "want GROUP=SERVICE"},
+		{name: "missing separator", spec: "OLTP_HIGH", wantErr: "want GROUP=SERVICE"},
+		{name: "duplicate group", spec: "a=svc1,A=svc2", wantErr: "duplicate consumer group"},
+		{name: "shared service", spec: "a=svc1,b=SVC1", wantErr: "more than one consumer group"},
+		{name: "invalid characters", spec: "a=svc 1", wantErr: "invalid consumer group"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConsumerGroups(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseConsumerGroups() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseConsumerGroups() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseConsumerGroups() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseConsumerGroups()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
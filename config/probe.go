@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProbeResult - 接続の確認1件の結果
+type ProbeResult struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// Probe - 名前解決・リスナー・サービスへの接続とRedisへの接続を順に確認する（Redisはオプションのため失敗しても警告）
+//
+// 前の段階で失敗した場合、同じ接続先の後の段階は確認しない。
+func Probe(ctx context.Context, cfg *Config, timeout time.Duration) ([]ProbeResult, []Problem) {
+	var results []ProbeResult
+	var problems []Problem
+	pass := func(name, detail string) {
+		results = append(results, ProbeResult{Name: name, OK: true, Detail: detail})
+	}
+	fail := func(name string, p Problem) {
+		results = append(results, ProbeResult{Name: name, Detail: p.Message})
+		problems = append(problems, p)
+	}
+
+	if addrs, err := lookupHost(ctx, cfg.DBHost, timeout); err != nil {
+		fail("Oracleの名前解決", Problem{Key: "DB_HOST", Severity: SeverityError,
+			Message: fmt.Sprintf("DB_HOST（%s）を名前解決できません: %v", cfg.DBHost, err),
+			Fix:     "ホスト名の綴りと、DNSまたは /etc/hosts を確認してください（IPアドレスも指定できます）"})
+	} else {
+		pass("Oracleの名前解決", fmt.Sprintf("%s → %s", cfg.DBHost, strings.Join(addrs, ", ")))
+		address := net.JoinHostPort(cfg.DBHost, strconv.Itoa(cfg.DBPort))
+		if err := dial(ctx, address, timeout); err != nil {
+			fail("Oracleのリスナー", Problem{Key: "DB_PORT", Severity: SeverityError,
+				Message: fmt.Sprintf("%s のリスナーに接続できません: %v", address, err),
+				Fix:     "lsnrctl status でリスナーのポートを確認し、ファイアウォールやコンテナのポート公開（-p 1521:1521）を確認してください"})
+		} else {
+			pass("Oracleのリスナー", address)
+			if detail, err := pingDatabase(ctx, cfg, timeout); err != nil {
+				fail("Oracleのサービス", DiagnoseConnectError(cfg, err))
+			} else {
+				pass("Oracleのサービス", detail)
+			}
+		}
+	}
+
+	if cfg.RedisHost != "" {
+		address := net.JoinHostPort(cfg.RedisHost, strconv.Itoa(cfg.RedisPort))
+		client, err := ConnectRedis(cfg)
+		if err != nil {
+			fail("Redis", Problem{Key: "REDIS_HOST", Severity: SeverityWarning,
+				Message: fmt.Sprintf("Redis（%s）に接続できません: %v", address, err),
+				Fix:     "Redisはオプションです（キャッシュ比較のRedisを使う部分をスキップします）。使う場合はRedisを起動し、REDIS_HOST / REDIS_PORT / REDIS_PASSWORD を確認してください"})
+		} else {
+			_ = client.Close()
+			pass("Redis", fmt.Sprintf("%s（DB %d）", address, cfg.RedisDB))
+		}
+	}
+	return results, problems
+}
+
+// lookupHost - ホスト名を解決する（IPアドレスはそのまま返す）
+func lookupHost(ctx context.Context, host string, timeout time.Duration) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return net.DefaultResolver.LookupHost(ctx, host)
+}
+
+// dial - TCPで接続できるか確認する
+func dial(ctx context.Context, address string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// pingDatabase - サービスに接続して、接続先のコンテナ（PDB）とユーザーを返す
+func pingDatabase(ctx context.Context, cfg *Config, timeout time.Duration) (string, error) {
+	db, err := ConnectDatabase(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = db.Close() }()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var container, user string
+	if err := db.QueryRowContext(ctx, "SELECT SYS_CONTEXT('USERENV', 'CON_NAME'), USER FROM dual").Scan(&container, &user); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("サービス %s（コンテナ %s）にユーザー %s で接続しました", cfg.DBServiceName, container, user), nil
+}
+
+// DiagnoseConnectError - サービスへの接続のエラーを、原因の設定項目と修正方法に対応付ける
+func DiagnoseConnectError(cfg *Config, err error) Problem {
+	msg := err.Error()
+	p := Problem{Key: "DB_SERVICE_NAME", Severity: SeverityError, Message: "データベースに接続できません: " + msg}
+	switch {
+	case strings.Contains(msg, "ORA-12514"):
+		p.Message = fmt.Sprintf("リスナーがサービス %s を認識していません（ORA-12514）", cfg.DBServiceName)
+		p.Fix = "lsnrctl services で登録済みのサービス名を確認してください（PDBは ORCLPDB1 などPDB名のサービス。SIDではなくサービス名を指定します）"
+	case strings.Contains(msg, "ORA-12505"), strings.Contains(msg, "ORA-12516"), strings.Contains(msg, "ORA-12521"):
+		p.Key = "DB_INSTANCE_NAME"
+		p.Message = fmt.Sprintf("指定したインスタンス %q がサービス %s を提供していません: %s", cfg.DBInstanceName, cfg.DBServiceName, msg)
+		p.Fix = "SELECT inst_id, instance_name FROM gv$instance でインスタンス名を確認するか、DB_INSTANCE_NAME を空にしてください"
+	case strings.Contains(msg, "ORA-01017"):
+		p.Key = "DB_PASSWORD"
+		p.Message = "ユーザー名またはパスワードが正しくありません（ORA-01017）"
+		p.Fix = "DB_USERNAME と DB_PASSWORD を確認してください（パスワードは大文字・小文字を区別します。環境変数が.envより優先される点にも注意してください）"
+	case strings.Contains(msg, "ORA-28000"):
+		p.Key = "DB_USERNAME"
+		p.Message = fmt.Sprintf("ユーザー %s はロックされています（ORA-28000）", cfg.DBUsername)
+		p.Fix = fmt.Sprintf("管理者に ALTER USER %s ACCOUNT UNLOCK を依頼してください", cfg.DBUsername)
+	case strings.Contains(msg, "ORA-28001"):
+		p.Key = "DB_PASSWORD"
+		p.Message = fmt.Sprintf("ユーザー %s のパスワードの有効期限が切れています（ORA-28001）", cfg.DBUsername)
+		p.Fix = fmt.Sprintf("SQL*Plusで接続してパスワードを変更するか、管理者に ALTER USER %s IDENTIFIED BY ... を依頼してください", cfg.DBUsername)
+	case strings.Contains(msg, "ORA-01033"), strings.Contains(msg, "ORA-01034"), strings.Contains(msg, "ORA-01109"):
+		p.Message = "データベース（またはPDB）が起動していないか、オープンしていません: " + msg
+		p.Fix = "起動直後であれば数分待ってください。PDBは ALTER PLUGGABLE DATABASE <PDB名> OPEN でオープンできます"
+	case strings.Contains(msg, "failed to apply session setting"):
+		p.Key = "NLS_*"
+		p.Fix = "NLS_TERRITORY / NLS_DATE_FORMAT / DB_TIME_ZONE の値を確認してください（空にするとサーバーの既定を使います）"
+	default:
+		p.Fix = "DB_HOST / DB_PORT / DB_SERVICE_NAME を確認し、SQL*Plusなどで同じ接続先に接続できるか試してください"
+	}
+	return p
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// DotEnvFile - 設定を読み込む .env ファイル（カレントディレクトリからの相対パス）
+const DotEnvFile = ".env"
+
+// Source - 設定値の読み込み元
+type Source string
+
+const (
+	// SourceEnv - 環境変数（.envより優先される）
+	SourceEnv Source = "環境変数"
+	// SourceDotEnv - .envファイル
+	SourceDotEnv Source = ".env"
+	// SourceDefault - 未設定または空のため既定値を使う
+	SourceDefault Source = "既定値"
+	// SourceUnset - 未設定（既定値もない）
+	SourceUnset Source = "未設定"
+)
+
+// Key - 設定項目の定義
+type Key struct {
+	Name string `json:"name"`
+	// Default - 未設定または空の場合に使う値（空なら既定値なし）
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+	// Secret - 表示する値を伏せる
+	Secret bool `json:"secret,omitempty"`
+}
+
+// Keys - 読み込む設定項目（env.exampleと同じ順）
+var Keys = []Key{
+	{Name: "DB_HOST", Default: "localhost", Description: "Oracleのホスト名"},
+	{Name: "DB_PORT", Default: "1521", Description: "リスナーのポート番号"},
+	{Name: "DB_SERVICE_NAME", Default: "ORCLPDB1", Description: "接続するサービス名（PDB）"},
+	{Name: "DB_INSTANCE_NAME", Description: "RACで接続先を固定するインスタンス名"},
+	{Name: "DB_USERNAME", Description: "デモのスキーマのユーザー名", Required: true},
+	{Name: "DB_PASSWORD", Description: "デモのスキーマのパスワード", Required: true, Secret: true},
+	{Name: "NLS_DATE_FORMAT", Default: DefaultNLSDateFormat, Description: "接続ごとに設定するNLS_DATE_FORMAT"},
+	{Name: "NLS_TERRITORY", Description: "接続ごとに設定するNLS_TERRITORY"},
+	{Name: "DB_TIME_ZONE", Description: "接続ごとに設定するTIME_ZONE"},
+	{Name: "REDIS_HOST", Default: "localhost", Description: "Redisのホスト名（キャッシュ比較用、オプション）"},
+	{Name: "REDIS_PORT", Default: "6379", Description: "Redisのポート番号"},
+	{Name: "REDIS_PASSWORD", Description: "Redisのパスワード", Secret: true},
+	{Name: "REDIS_DB", Default: "0", Description: "Redisのデータベース番号"},
+	{Name: "COHERENCE_URL", Description: "Coherence RESTのベースURL（-cache-backends=coherence）"},
+	{Name: "COHERENCE_CACHE", Description: "Coherenceのキャッシュ名"},
+	{Name: "TIMESTEN_DRIVER", Description: "TimesTenのdatabase/sqlドライバー名（-cache-backends=timesten）"},
+	{Name: "TIMESTEN_DSN", Description: "TimesTenの接続文字列", Secret: true},
+	{Name: "RESULT_SIGNING_KEY", Description: "結果ファイルの署名鍵（-sign、verify）", Secret: true},
+	{Name: "BENCH_ENVIRONMENT", Description: "結果に記録する実行環境名（matrix）"},
+	{Name: "DB_SERVICE_NAMES", Description: "順に計測するPDBのサービス名（multi-pdb）"},
+	{Name: "RESOURCE_CONSUMER_GROUPS", Description: "計測するコンシューマ・グループとサービス（consumer-groups）"},
+	{Name: "TELEMETRY_ENDPOINT", Description: "匿名化した改善率の送信先（-telemetry）"},
+	{Name: "OJDBC_JAR", Description: "ojdbcのJAR（compare-clients）"},
+}
+
+// Setting - 解決した設定値と読み込み元
+type Setting struct {
+	Key
+	Value  string `json:"value"`
+	Source Source `json:"source"`
+	// Shadowed - 環境変数に上書きされて使われない.envの値（同じ値の場合は空）
+	Shadowed string `json:"shadowed,omitempty"`
+	// EmptyIgnored - 空の値が設定されているが、既定値で置き換えられる
+	EmptyIgnored bool `json:"empty_ignored,omitempty"`
+}
+
+// Display - 表示する値（秘密の値は伏せる）
+func (s Setting) Display() string {
+	if s.Secret && s.Value != "" {
+		return "********"
+	}
+	return s.Value
+}
+
+// Resolution - 設定値の解決結果
+type Resolution struct {
+	// DotEnvPath - 読み込んだ.envのパス（見つからなかった場合は空）
+	DotEnvPath string    `json:"dotenv_path,omitempty"`
+	Settings   []Setting `json:"settings"`
+	// UnknownKeys - .envにあるが読み込まない項目（綴りの誤りなど）
+	UnknownKeys []string `json:"unknown_keys,omitempty"`
+}
+
+// Setting - 名前で設定値を探す
+func (r *Resolution) Setting(name string) Setting {
+	for _, s := range r.Settings {
+		if s.Name == name {
+			return s
+		}
+	}
+	return Setting{Key: Key{Name: name}, Source: SourceUnset}
+}
+
+// Resolve - 環境変数と.envから設定値と読み込み元を求める（LoadConfigと同じく環境変数を.envより優先する）
+//
+// LoadConfigは.envを環境変数に読み込むため、読み込み元を正しく判定するにはLoadConfigより前に呼び出す。
+func Resolve(dotEnvPath string, lookup func(string) (string, bool)) (*Resolution, error) {
+	dotEnv, err := godotenv.Read(dotEnvPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		dotEnv = nil
+		dotEnvPath = ""
+	case err != nil:
+		return nil, fmt.Errorf("failed to parse %s: %w", dotEnvPath, err)
+	}
+
+	r := &Resolution{DotEnvPath: dotEnvPath}
+	known := make(map[string]bool, len(Keys))
+	for _, key := range Keys {
+		known[key.Name] = true
+		s := Setting{Key: key, Source: SourceUnset}
+		fileValue, inFile := dotEnv[key.Name]
+		if value, ok := lookup(key.Name); ok {
+			s.Value, s.Source = value, SourceEnv
+			if inFile && fileValue != value {
+				s.Shadowed = fileValue
+			}
+		} else if inFile {
+			s.Value, s.Source = fileValue, SourceDotEnv
+		}
+		if s.Value == "" && key.Default != "" {
+			s.EmptyIgnored = s.Source != SourceUnset
+			s.Value, s.Source = key.Default, SourceDefault
+		}
+		r.Settings = append(r.Settings, s)
+	}
+	for name := range dotEnv {
+		if !known[name] {
+			r.UnknownKeys = append(r.UnknownKeys, name)
+		}
+	}
+	sort.Strings(r.UnknownKeys)
+	return r, nil
+}
+
+// Severity - 問題の重さ
+type Severity string
+
+const (
+	// SeverityError - 計測を実行できない
+	SeverityError Severity = "error"
+	// SeverityWarning - 実行できるが、意図と異なる値が使われている可能性がある
+	SeverityWarning Severity = "warning"
+)
+
+// Problem - 設定の問題と修正方法
+type Problem struct {
+	Key      string   `json:"key"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Fix      string   `json:"fix"`
+}
+
+// Check - 解決した設定値を検証する（ネットワークには接続しない）
+func (r *Resolution) Check() []Problem {
+	var problems []Problem
+	add := func(key string, severity Severity, message, fix string) {
+		problems = append(problems, Problem{Key: key, Severity: severity, Message: message, Fix: fix})
+	}
+
+	if r.DotEnvPath == "" {
+		add(DotEnvFile, SeverityWarning, "カレントディレクトリに.envがありません（環境変数と既定値だけを使います）",
+			"cp env.example .env で作成し、DB_USERNAME と DB_PASSWORD を設定してください（.envはコマンドを実行したディレクトリから読み込みます）")
+	}
+	for _, s := range r.Settings {
+		switch {
+		case s.Required && s.Value == "":
+			add(s.Name, SeverityError, s.Name+" が設定されていません（"+s.Description+"）",
+				fmt.Sprintf(".env に %s=... を追加するか、export %s=... で設定してください", s.Name, s.Name))
+		case s.Shadowed != "":
+			add(s.Name, SeverityWarning, fmt.Sprintf("環境変数の %s が.envの値（%s）より優先されます", s.Name, maskIf(s.Secret, s.Shadowed)),
+				fmt.Sprintf(".envの値を使う場合は unset %s を実行してください", s.Name))
+		case s.EmptyIgnored && s.Name == "REDIS_HOST":
+			add(s.Name, SeverityWarning, "REDIS_HOST が空のため既定の localhost に接続します（空にしてもRedisは無効になりません）",
+				"Redisがない場合は設定を残したままで構いません（接続できなければキャッシュ比較の該当部分をスキップします）")
+		case s.EmptyIgnored:
+			add(s.Name, SeverityWarning, fmt.Sprintf("%s が空のため既定値 %s を使います", s.Name, s.Value),
+				fmt.Sprintf("既定値でよければ %s の行を削除してください", s.Name))
+		}
+	}
+
+	for _, name := range []string{"DB_PORT", "REDIS_PORT"} {
+		s := r.Setting(name)
+		if port, err := strconv.Atoi(s.Value); err != nil || port < 1 || port > 65535 {
+			add(name, SeverityError, fmt.Sprintf("%s が1〜65535の数値ではありません: %q", name, s.Value),
+				fmt.Sprintf("%s=%s のように数値で指定してください", name, s.Default))
+		}
+	}
+	if s := r.Setting("REDIS_DB"); !validRedisDB(s.Value) {
+		add(s.Name, SeverityError, fmt.Sprintf("REDIS_DB が0以上の数値ではありません: %q", s.Value), "REDIS_DB=0 のように指定してください")
+	}
+	for _, name := range []string{"DB_HOST", "REDIS_HOST"} {
+		s := r.Setting(name)
+		if strings.Contains(s.Value, ":") && net.ParseIP(s.Value) == nil {
+			add(name, SeverityError, fmt.Sprintf("%s にポート番号やURLが含まれています: %q", name, s.Value),
+				fmt.Sprintf("ホスト名だけを指定し、ポート番号は %s に分けてください", strings.Replace(name, "HOST", "PORT", 1)))
+		}
+	}
+	if s := r.Setting("DB_SERVICE_NAME"); strings.ContainsAny(s.Value, "/:@") {
+		add(s.Name, SeverityError, fmt.Sprintf("DB_SERVICE_NAME に接続文字列が含まれています: %q", s.Value),
+			"サービス名（lsnrctl services で表示される名前、例: ORCLPDB1）だけを指定してください")
+	}
+	if s := r.Setting("DB_INSTANCE_NAME"); s.Value != "" && !validName(s.Value) {
+		add(s.Name, SeverityError, fmt.Sprintf("DB_INSTANCE_NAME に使えない文字が含まれています: %q", s.Value),
+			"SELECT instance_name FROM gv$instance で表示される名前を指定してください")
+	}
+	session := SessionSettings{
+		Territory: r.Setting("NLS_TERRITORY").Value, DateFormat: r.Setting("NLS_DATE_FORMAT").Value, TimeZone: r.Setting("DB_TIME_ZONE").Value,
+	}
+	if err := session.Validate(); err != nil {
+		add("NLS_*", SeverityError, err.Error(), "値から引用符とセミコロンを取り除いてください（.envでは値全体を二重引用符で囲めます）")
+	}
+	for _, name := range []string{"DB_SERVICE_NAMES", "RESOURCE_CONSUMER_GROUPS"} {
+		s := r.Setting(name)
+		if s.Value == "" {
+			continue
+		}
+		var err error
+		if name == "DB_SERVICE_NAMES" {
+			_, err = ParseServiceNames(s.Value)
+		} else {
+			_, err = ParseConsumerGroups(s.Value)
+		}
+		if err != nil {
+			add(name, SeverityError, err.Error(), "env.example の説明に従って指定してください")
+		}
+	}
+
+	for _, name := range r.UnknownKeys {
+		fix := "使われない項目です。不要なら削除してください"
+		if similar := closestKey(name); similar != "" {
+			fix = fmt.Sprintf("%s の誤りではありませんか", similar)
+		}
+		add(name, SeverityWarning, ".envの "+name+" は読み込まれません", fix)
+	}
+	return problems
+}
+
+// HasErrors - 計測を実行できない問題があるか
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// validRedisDB - REDIS_DBが0以上の数値か
+func validRedisDB(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0
+}
+
+// maskIf - 秘密の値を伏せる
+func maskIf(secret bool, value string) string {
+	if secret {
+		return "********"
+	}
+	return value
+}
+
+// closestKey - 綴りの近い設定項目（編集距離2以内、なければ空）
+func closestKey(name string) string {
+	best, bestDistance := "", 3
+	for _, key := range Keys {
+		if d := editDistance(strings.ToUpper(name), key.Name); d < bestDistance {
+			best, bestDistance = key.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance - レーベンシュタイン距離
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
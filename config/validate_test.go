@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	dotEnv := "DB_HOST=db.example.com\nDB_PORT=1521\nDB_USERNAME=demo\nDB_PASSWORD=secret\nREDIS_HOST=\nDB_SERVCE_NAME=ORCLPDB1\n"
+	if err := os.WriteFile(path, []byte(dotEnv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"DB_PORT": "1522", "DB_PASSWORD": "secret"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	res, err := Resolve(path, lookup)
+	if err != nil {
+		t.Fatalf("Resolve() failed: %v", err)
+	}
+	tests := []struct {
+		key          string
+		value        string
+		source       Source
+		shadowed     string
+		emptyIgnored bool
+	}{
+		{key: "DB_HOST", value: "db.example.com", source: SourceDotEnv},
+		{key: "DB_PORT", value: "1522", source: SourceEnv, shadowed: "1521"},
+		{key: "DB_PASSWORD", value: "secret", source: SourceEnv},
+		{key: "DB_SERVICE_NAME", value: "ORCLPDB1", source: SourceDefault},
+		{key: "REDIS_HOST", value: "localhost", source: SourceDefault, emptyIgnored: true},
+		{key: "NLS_TERRITORY", source: SourceUnset},
+	}
+	for _, tt := range tests {
+		s := res.Setting(tt.key)
+		if s.Value != tt.value || s.Source != tt.source || s.Shadowed != tt.shadowed || s.EmptyIgnored != tt.emptyIgnored {
+			t.Errorf("%s: got %q from %s (shadowed %q, empty %v), want %q from %s (shadowed %q, empty %v)",
+				tt.key, s.Value, s.Source, s.Shadowed, s.EmptyIgnored, tt.value, tt.source, tt.shadowed, tt.emptyIgnored)
+		}
+	}
+	if !reflect.DeepEqual(res.UnknownKeys, []string{"DB_SERVCE_NAME"}) {
+		t.Errorf("UnknownKeys = %v, want [DB_SERVCE_NAME]", res.UnknownKeys)
+	}
+	if got := res.Setting("DB_PASSWORD").Display(); got != "********" {
+		t.Errorf("DB_PASSWORD displayed as %q", got)
+	}
+
+	missing, err := Resolve(filepath.Join(dir, "missing.env"), lookup)
+	if err != nil || missing.DotEnvPath != "" {
+		t.Errorf("Resolve(missing) = %+v, %v, want no .env", missing, err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		// want - 期待する問題の項目と重さ
+		want []Problem
+	}{
+		{
+			name: "valid",
+			env:  map[string]string{"DB_USERNAME": "demo", "DB_PASSWORD": "secret"},
+		},
+		{
+			name: "missing credentials",
+			env:  map[string]string{},
+			want: []Problem{{Key: "DB_USERNAME", Severity: SeverityError}, {Key: "DB_PASSWORD", Severity: SeverityError}},
+		},
+		{
+			name: "invalid values",
+			env: map[string]string{
+				"DB_USERNAME": "demo", "DB_PASSWORD": "secret",
+				"DB_HOST": "db.example.com:1521", "DB_PORT": "70000", "REDIS_DB": "-1", "DB_SERVICE_NAME": "db:1521/ORCLPDB1",
+			},
+			want: []Problem{
+				{Key: "DB_PORT", Severity: SeverityError},
+				{Key: "REDIS_DB", Severity: SeverityError},
+				{Key: "DB_HOST", Severity: SeverityError},
+				{Key: "DB_SERVICE_NAME", Severity: SeverityError},
+			},
+		},
+		{
+			name: "ipv6 host",
+			env:  map[string]string{"DB_USERNAME": "demo", "DB_PASSWORD": "secret", "DB_HOST": "::1"},
+		},
+		{
+			name: "empty redis host",
+			env:  map[string]string{"DB_USERNAME": "demo", "DB_PASSWORD": "secret", "REDIS_HOST": ""},
+			want: []Problem{{Key: "REDIS_HOST", Severity: SeverityWarning}},
+		},
+	}
+
+	for _, tt := range tests {
+		lookup := func(name string) (string, bool) {
+			v, ok := tt.env[name]
+			return v, ok
+		}
+		res, err := Resolve(filepath.Join(t.TempDir(), ".env"), lookup)
+		if err != nil {
+			t.Fatalf("%s: Resolve() failed: %v", tt.name, err)
+		}
+		var got []Problem
+		for _, p := range res.Check() {
+			if p.Key != DotEnvFile {
+				got = append(got, Problem{Key: p.Key, Severity: p.Severity})
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Check() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClosestKey(t *testing.T) {
+	tests := map[string]string{
+		"DB_SERVCE_NAME": "DB_SERVICE_NAME",
+		"db_username":    "DB_USERNAME",
+		"REDIS_PASS":     "",
+		"FOO":            "",
+	}
+	for name, want := range tests {
+		if got := closestKey(name); got != want {
+			t.Errorf("closestKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestDiagnoseConnectError(t *testing.T) {
+	cfg := &Config{DBServiceName: "ORCLPDB1", DBUsername: "demo"}
+	tests := []struct {
+		err  string
+		want string
+	}{
+		{err: "ORA-12514: TNS:listener does not currently know of service requested", want: "DB_SERVICE_NAME"},
+		{err: "ORA-01017: invalid username/password; logon denied", want: "DB_PASSWORD"},
+		{err: "ORA-28000: the account is locked", want: "DB_USERNAME"},
+		{err: "ORA-12521: TNS:listener does not currently know of instance", want: "DB_INSTANCE_NAME"},
+		{err: "failed to apply session setting: ORA-12705", want: "NLS_*"},
+		{err: "i/o timeout", want: "DB_SERVICE_NAME"},
+	}
+	for _, tt := range tests {
+		p := DiagnoseConnectError(cfg, errors.New(tt.err))
+		if p.Key != tt.want || p.Severity != SeverityError || p.Fix == "" {
+			t.Errorf("DiagnoseConnectError(%q) = %+v, want key %s", tt.err, p, tt.want)
+		}
+	}
+}
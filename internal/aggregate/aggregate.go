@@ -0,0 +1,241 @@
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// DefaultWindow - 移動平均と回帰判定に使う直前の実行回数
+const DefaultWindow = 5
+
+// DefaultThreshold - 回帰とみなす移動平均からの悪化率（%）
+const DefaultThreshold = 20.0
+
+// minRunsForRegression - 回帰判定に必要な最小実行回数（最新 + 比較対象）
+const minRunsForRegression = 3
+
+// Run - 1回分の計測結果ファイル
+type Run struct {
+	Path        string
+	Environment string
+	CollectedAt time.Time
+	Results     []service.PerformanceResult
+}
+
+// Point - ある手法の1回分の計測値
+type Point struct {
+	At            time.Time     `json:"at"`
+	File          string        `json:"file"`
+	ExecutionTime time.Duration `json:"execution_time"`
+	// MovingAverage - この実行までの直近Window回の移動平均
+	MovingAverage time.Duration `json:"moving_average"`
+}
+
+// Trend - 手法ごとの時系列統計
+type Trend struct {
+	Scenario string        `json:"scenario"`
+	Method   string        `json:"method"`
+	Runs     int           `json:"runs"`
+	Mean     time.Duration `json:"mean"`
+	Min      time.Duration `json:"min"`
+	Max      time.Duration `json:"max"`
+	StdDev   time.Duration `json:"stddev"`
+	Latest   time.Duration `json:"latest"`
+	// Baseline - 最新を除く直近Window回の平均（回帰判定の基準）
+	Baseline time.Duration `json:"baseline"`
+	// ChangePercent - Baselineに対する最新の変化率（正の値は悪化）
+	ChangePercent float64 `json:"change_percent"`
+	Regression    bool    `json:"regression"`
+	Points        []Point `json:"points"`
+}
+
+// Options - 集計の設定
+type Options struct {
+	Window    int
+	Threshold float64
+}
+
+// Report - 集計結果
+type Report struct {
+	Files     []string  `json:"files"`
+	Skipped   []string  `json:"skipped,omitempty"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Window    int       `json:"window"`
+	Threshold float64   `json:"threshold"`
+	Trends    []Trend   `json:"trends"`
+}
+
+// Regressions - 回帰と判定された手法
+func (r *Report) Regressions() []Trend {
+	var regressions []Trend
+	for _, t := range r.Trends {
+		if t.Regression {
+			regressions = append(regressions, t)
+		}
+	}
+	return regressions
+}
+
+// Load - ディレクトリ内の結果JSON（-results-json / -sink の出力）を時系列順に読み込む
+//
+// 結果を含まないJSON（-stats-json の出力や署名ファイルなど）は skipped として返す。
+func Load(dir string) ([]Run, []string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list result files: %w", err)
+	}
+
+	var runs []Run
+	var skipped []string
+	for _, path := range paths {
+		run, ok, err := loadRun(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			skipped = append(skipped, path)
+			continue
+		}
+		runs = append(runs, run)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].CollectedAt.Before(runs[j].CollectedAt)
+	})
+
+	return runs, skipped, nil
+}
+
+// loadRun - 結果ファイルを1件読み込む（メタデータがない場合はファイルの更新日時を使う）
+func loadRun(path string) (Run, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Run{}, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	report, _, err := service.DecodeResultsReport(data)
+	if errors.Is(err, service.ErrUnsupportedSchemaVersion) {
+		return Run{}, false, fmt.Errorf("%s: %w", path, err)
+	}
+	if err != nil || len(report.Results) == 0 {
+		return Run{}, false, nil
+	}
+
+	run := Run{Path: path, Results: report.Results}
+	if report.Metadata != nil {
+		run.Environment = report.Metadata.Environment
+	}
+	if report.Metadata != nil && !report.Metadata.CollectedAt.IsZero() {
+		run.CollectedAt = report.Metadata.CollectedAt
+	} else if info, err := os.Stat(path); err == nil {
+		run.CollectedAt = info.ModTime()
+	}
+
+	return run, true, nil
+}
+
+// Analyze - 時系列順の実行結果から手法ごとの統計と回帰判定を作成
+func Analyze(runs []Run, opts Options) *Report {
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+
+	report := &Report{Window: opts.Window, Threshold: opts.Threshold}
+	if len(runs) > 0 {
+		report.From = runs[0].CollectedAt
+		report.To = runs[len(runs)-1].CollectedAt
+	}
+
+	type seriesKey struct{ scenario, method string }
+	var order []seriesKey
+	series := make(map[seriesKey][]Point)
+
+	for _, run := range runs {
+		report.Files = append(report.Files, run.Path)
+		for _, result := range run.Results {
+			key := seriesKey{result.Scenario, result.Method}
+			if _, ok := series[key]; !ok {
+				order = append(order, key)
+			}
+			series[key] = append(series[key], Point{
+				At:            run.CollectedAt,
+				File:          filepath.Base(run.Path),
+				ExecutionTime: result.ExecutionTime,
+			})
+		}
+	}
+
+	for _, key := range order {
+		report.Trends = append(report.Trends, buildTrend(key.scenario, key.method, series[key], opts))
+	}
+
+	return report
+}
+
+// buildTrend - 1手法分の時系列から統計を計算
+func buildTrend(scenario, method string, points []Point, opts Options) Trend {
+	t := Trend{
+		Scenario: scenario,
+		Method:   method,
+		Runs:     len(points),
+		Min:      points[0].ExecutionTime,
+		Max:      points[0].ExecutionTime,
+		Latest:   points[len(points)-1].ExecutionTime,
+	}
+
+	var sum float64
+	for i := range points {
+		d := points[i].ExecutionTime
+		sum += float64(d)
+		if d < t.Min {
+			t.Min = d
+		}
+		if d > t.Max {
+			t.Max = d
+		}
+		points[i].MovingAverage = mean(points[max(0, i-opts.Window+1) : i+1])
+	}
+	t.Mean = time.Duration(sum / float64(len(points))).Round(time.Microsecond)
+
+	var variance float64
+	for _, p := range points {
+		diff := float64(p.ExecutionTime - t.Mean)
+		variance += diff * diff
+	}
+	t.StdDev = time.Duration(math.Sqrt(variance / float64(len(points)))).Round(time.Microsecond)
+
+	if len(points) >= minRunsForRegression {
+		previous := points[max(0, len(points)-1-opts.Window) : len(points)-1]
+		t.Baseline = mean(previous)
+		if t.Baseline > 0 {
+			t.ChangePercent = float64(t.Latest-t.Baseline) * 100 / float64(t.Baseline)
+			t.Regression = t.ChangePercent > opts.Threshold
+		}
+	}
+
+	t.Points = points
+	return t
+}
+
+// mean - 計測値の平均
+func mean(points []Point) time.Duration {
+	if len(points) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, p := range points {
+		sum += p.ExecutionTime
+	}
+	return (sum / time.Duration(len(points))).Round(time.Microsecond)
+}
@@ -0,0 +1,132 @@
+package aggregate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// seriesRuns - 1手法の実行時間（ミリ秒）を1日ずつずらした実行結果にする
+func seriesRuns(ms ...float64) []Run {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	runs := make([]Run, len(ms))
+	for i, v := range ms {
+		runs[i] = Run{
+			Path:        fmt.Sprintf("/results/run%d.json", i+1),
+			CollectedAt: start.AddDate(0, 0, i),
+			Results: []service.PerformanceResult{{
+				Scenario:      "orders",
+				Method:        "N+1_Problem",
+				ExecutionTime: time.Duration(v * float64(time.Millisecond)),
+			}},
+		}
+	}
+	return runs
+}
+
+func TestAnalyzeTrend(t *testing.T) {
+	report := Analyze(seriesRuns(100, 110, 90, 100, 121), Options{Window: 3, Threshold: 20})
+	if len(report.Trends) != 1 {
+		t.Fatalf("Analyze() returned %d trends, want 1", len(report.Trends))
+	}
+	tr := report.Trends[0]
+
+	if tr.Runs != 5 || tr.Min != 90*time.Millisecond || tr.Max != 121*time.Millisecond || tr.Latest != 121*time.Millisecond {
+		t.Errorf("Analyze() = %+v, want 5 runs, min 90ms, max 121ms", tr)
+	}
+	// 平均104.2ms、母標準偏差は √110.56 ≈ 10.515ms
+	if tr.Mean != 104200*time.Microsecond || tr.StdDev != 10515*time.Microsecond {
+		t.Errorf("Analyze() mean/stddev = %v/%v, want 104.2ms/10.515ms", tr.Mean, tr.StdDev)
+	}
+
+	// 移動平均は先頭では取れた分だけで平均する
+	wantMA := []time.Duration{100 * time.Millisecond, 105 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond, 103667 * time.Microsecond}
+	for i, p := range tr.Points {
+		if p.MovingAverage != wantMA[i] {
+			t.Errorf("Points[%d].MovingAverage = %v, want %v", i, p.MovingAverage, wantMA[i])
+		}
+	}
+	if tr.Points[4].File != "run5.json" {
+		t.Errorf("Points[4].File = %q, want run5.json", tr.Points[4].File)
+	}
+
+	// 基準は最新を除く直近3回（110, 90, 100）の平均
+	if tr.Baseline != 100*time.Millisecond || tr.ChangePercent != 21 || !tr.Regression {
+		t.Errorf("Analyze() baseline = %v, change %v%%, regression %v, want 100ms, 21%%, true", tr.Baseline, tr.ChangePercent, tr.Regression)
+	}
+	if !report.From.Equal(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)) || !report.To.Equal(time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Analyze() range = %v - %v", report.From, report.To)
+	}
+	if got := report.Regressions(); len(got) != 1 {
+		t.Errorf("Regressions() = %d trends, want 1", len(got))
+	}
+}
+
+func TestAnalyzeRegression(t *testing.T) {
+	tests := []struct {
+		name           string
+		ms             []float64
+		opts           Options
+		wantBaseline   time.Duration
+		wantChange     float64
+		wantRegression bool
+	}{
+		{name: "at threshold", ms: []float64{100, 110, 90, 100, 120}, opts: Options{Window: 3, Threshold: 20},
+			wantBaseline: 100 * time.Millisecond, wantChange: 20},
+		{name: "over threshold", ms: []float64{100, 110, 90, 100, 120.1}, opts: Options{Window: 3, Threshold: 20},
+			wantBaseline: 100 * time.Millisecond, wantChange: 20.1, wantRegression: true},
+		// 直前3回より前（500ms）は基準に含めない
+		{name: "window edge", ms: []float64{500, 100, 100, 100, 110}, opts: Options{Window: 3, Threshold: 5},
+			wantBaseline: 100 * time.Millisecond, wantChange: 10, wantRegression: true},
+		// 実行回数がWindowより少なければある分だけで基準を求める
+		{name: "window larger than series", ms: []float64{100, 100, 150}, opts: Options{Window: 10, Threshold: 20},
+			wantBaseline: 100 * time.Millisecond, wantChange: 50, wantRegression: true},
+		{name: "below minimum runs", ms: []float64{100, 500}, opts: Options{Window: 3, Threshold: 20}},
+		{name: "single run", ms: []float64{100}, opts: Options{Window: 3, Threshold: 20}},
+		{name: "zero baseline", ms: []float64{0, 0, 100}, opts: Options{Window: 3, Threshold: 20}},
+		{name: "faster", ms: []float64{100, 100, 50}, opts: Options{Window: 3, Threshold: 20},
+			wantBaseline: 100 * time.Millisecond, wantChange: -50},
+		// 0以下の指定は既定値（Window 5、しきい値20%）
+		{name: "defaults", ms: []float64{1000, 100, 100, 100, 100, 100, 125}, opts: Options{},
+			wantBaseline: 100 * time.Millisecond, wantChange: 25, wantRegression: true},
+	}
+	for _, tt := range tests {
+		tr := Analyze(seriesRuns(tt.ms...), tt.opts).Trends[0]
+		if tr.Baseline != tt.wantBaseline || tr.ChangePercent != tt.wantChange || tr.Regression != tt.wantRegression {
+			t.Errorf("%s: Analyze() baseline = %v, change %v%%, regression %v, want %v, %v%%, %v",
+				tt.name, tr.Baseline, tr.ChangePercent, tr.Regression, tt.wantBaseline, tt.wantChange, tt.wantRegression)
+		}
+	}
+}
+
+func TestAnalyzeSeries(t *testing.T) {
+	runs := seriesRuns(100, 200)
+	runs[1].Results = append([]service.PerformanceResult{{Scenario: "orders", Method: "JOIN_Optimized", ExecutionTime: 10 * time.Millisecond}}, runs[1].Results...)
+
+	report := Analyze(runs, Options{})
+	if report.Window != DefaultWindow || report.Threshold != DefaultThreshold {
+		t.Errorf("Analyze() window/threshold = %d/%v, want defaults", report.Window, report.Threshold)
+	}
+	if want := []string{"/results/run1.json", "/results/run2.json"}; !reflect.DeepEqual(report.Files, want) {
+		t.Errorf("Analyze() files = %q, want %q", report.Files, want)
+	}
+
+	// 手法は最初に現れた順、途中から現れた手法はその回からの系列
+	var got []string
+	for _, tr := range report.Trends {
+		got = append(got, tr.Method)
+	}
+	if want := []string{"N+1_Problem", "JOIN_Optimized"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Analyze() methods = %q, want %q", got, want)
+	}
+	if report.Trends[1].Runs != 1 || report.Trends[1].StdDev != 0 {
+		t.Errorf("Analyze() JOIN_Optimized = %+v, want 1 run", report.Trends[1])
+	}
+
+	if empty := Analyze(nil, Options{}); len(empty.Trends) != 0 || !empty.From.IsZero() {
+		t.Errorf("Analyze(nil) = %+v, want an empty report", empty)
+	}
+}
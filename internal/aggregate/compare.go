@@ -0,0 +1,85 @@
+package aggregate
+
+import (
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// Change - 基準の計測結果に対する手法ごとの変化
+type Change struct {
+	Scenario string `json:"scenario"`
+	Method   string `json:"method"`
+	// Baseline / Current - 基準と今回の実行時間の中央値
+	Baseline      time.Duration `json:"baseline"`
+	Current       time.Duration `json:"current"`
+	ChangePercent float64       `json:"change_percent"`
+	// BaselineRuns / CurrentRuns - 中央値を求めた計測の数
+	BaselineRuns int `json:"baseline_runs"`
+	CurrentRuns  int `json:"current_runs"`
+}
+
+// LoadFile - 結果ファイルを1件読み込む
+func LoadFile(path string) (Run, error) {
+	run, ok, err := loadRun(path)
+	if err != nil {
+		return Run{}, err
+	}
+	if !ok {
+		return Run{}, fmt.Errorf("%s does not contain results", path)
+	}
+	return run, nil
+}
+
+// CompareResults - 基準と同じシナリオ・手法の結果を比べ、threshold%を超えて遅くなったものを返す
+//
+// -repeat・-iterations で同じシナリオ・手法を複数回計測した結果は、基準・今回それぞれの中央値で比べる。
+// 結果は今回の結果に現れた順に並べる。
+func CompareResults(baseline, current []service.PerformanceResult, threshold float64) []Change {
+	base, _ := groupDurations(baseline)
+	runs, order := groupDurations(current)
+
+	var regressions []Change
+	for _, k := range order {
+		b := base[k]
+		if len(b) == 0 {
+			continue
+		}
+		baseMedian, currentMedian := median(b), median(runs[k])
+		if baseMedian <= 0 {
+			continue
+		}
+		change := float64(currentMedian-baseMedian) * 100 / float64(baseMedian)
+		if change > threshold {
+			regressions = append(regressions, Change{
+				Scenario:      k.scenario,
+				Method:        k.method,
+				Baseline:      baseMedian,
+				Current:       currentMedian,
+				ChangePercent: change,
+				BaselineRuns:  len(b),
+				CurrentRuns:   len(runs[k]),
+			})
+		}
+	}
+
+	return regressions
+}
+
+// resultKey - シナリオと手法の組
+type resultKey struct{ scenario, method string }
+
+// groupDurations - シナリオ・手法ごとの実行時間と、最初に現れた順のキー
+func groupDurations(results []service.PerformanceResult) (map[resultKey][]time.Duration, []resultKey) {
+	groups := make(map[resultKey][]time.Duration)
+	var order []resultKey
+	for _, r := range results {
+		k := resultKey{r.Scenario, r.Method}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], r.ExecutionTime)
+	}
+	return groups, order
+}
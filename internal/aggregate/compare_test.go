@@ -0,0 +1,106 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// result - シナリオ・手法・実行時間（ミリ秒）だけを持つ計測結果
+func result(scenario, method string, ms int) service.PerformanceResult {
+	return service.PerformanceResult{Scenario: scenario, Method: method, ExecutionTime: time.Duration(ms) * time.Millisecond}
+}
+
+func TestCompareResults(t *testing.T) {
+	baseline := []service.PerformanceResult{
+		result("orders", "N+1_Problem", 100),
+		result("orders", "JOIN_Optimized", 10),
+		result("employees", "N+1_Problem", 0),
+	}
+
+	tests := []struct {
+		name      string
+		current   []service.PerformanceResult
+		threshold float64
+		want      []Change
+	}{
+		{
+			name:      "at threshold",
+			current:   []service.PerformanceResult{result("orders", "N+1_Problem", 120)},
+			threshold: 20,
+		},
+		{
+			name:      "over threshold",
+			current:   []service.PerformanceResult{result("orders", "N+1_Problem", 121)},
+			threshold: 20,
+			want: []Change{{Scenario: "orders", Method: "N+1_Problem", Baseline: 100 * time.Millisecond, Current: 121 * time.Millisecond,
+				ChangePercent: 21, BaselineRuns: 1, CurrentRuns: 1}},
+		},
+		{
+			name:      "faster",
+			current:   []service.PerformanceResult{result("orders", "JOIN_Optimized", 5)},
+			threshold: 0,
+		},
+		{
+			// 基準にない手法と、基準が0の手法は比べない
+			name: "missing and zero baseline",
+			current: []service.PerformanceResult{
+				result("orders", "Batch_IN", 500),
+				result("customers", "N+1_Problem", 500),
+				result("employees", "N+1_Problem", 500),
+			},
+			threshold: 20,
+		},
+		{
+			name: "current order",
+			current: []service.PerformanceResult{
+				result("orders", "JOIN_Optimized", 20),
+				result("orders", "N+1_Problem", 200),
+			},
+			threshold: 20,
+			want: []Change{
+				{Scenario: "orders", Method: "JOIN_Optimized", Baseline: 10 * time.Millisecond, Current: 20 * time.Millisecond,
+					ChangePercent: 100, BaselineRuns: 1, CurrentRuns: 1},
+				{Scenario: "orders", Method: "N+1_Problem", Baseline: 100 * time.Millisecond, Current: 200 * time.Millisecond,
+					ChangePercent: 100, BaselineRuns: 1, CurrentRuns: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := CompareResults(baseline, tt.current, tt.threshold); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: CompareResults() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompareResultsMedians(t *testing.T) {
+	// 繰り返し計測した基準は中央値（100ms）で比べ、最後の1件（300ms）に左右されない
+	baseline := []service.PerformanceResult{
+		result("orders", "N+1_Problem", 90),
+		result("orders", "N+1_Problem", 100),
+		result("orders", "N+1_Problem", 300),
+	}
+	current := []service.PerformanceResult{
+		result("orders", "N+1_Problem", 130),
+		result("orders", "N+1_Problem", 500),
+		result("orders", "N+1_Problem", 110),
+		result("orders", "N+1_Problem", 150),
+	}
+	want := []Change{{Scenario: "orders", Method: "N+1_Problem", Baseline: 100 * time.Millisecond, Current: 140 * time.Millisecond,
+		ChangePercent: 40, BaselineRuns: 3, CurrentRuns: 4}}
+	if got := CompareResults(baseline, current, 20); !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareResults() = %+v, want %+v", got, want)
+	}
+
+	// 今回の外れ値1件だけでは回帰にならない
+	current = []service.PerformanceResult{
+		result("orders", "N+1_Problem", 95),
+		result("orders", "N+1_Problem", 400),
+		result("orders", "N+1_Problem", 105),
+	}
+	if got := CompareResults(baseline, current, 20); len(got) != 0 {
+		t.Errorf("CompareResults(one outlier) = %+v, want none", got)
+	}
+}
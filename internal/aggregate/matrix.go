@@ -0,0 +1,174 @@
+package aggregate
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// UntaggedEnvironment - -env を指定せずに実行した結果の環境名
+const UntaggedEnvironment = "untagged"
+
+// MatrixCell - ある環境での手法の計測値
+type MatrixCell struct {
+	Runs int `json:"runs"`
+	// Median - 環境内の実行結果の中央値（外れ値の影響を抑える）
+	Median time.Duration `json:"median"`
+	// Ratio - 基準環境の中央値に対する倍率（基準環境に結果がない場合は0）
+	Ratio float64 `json:"ratio,omitempty"`
+}
+
+// MatrixRow - 手法ごとの環境別の計測値
+type MatrixRow struct {
+	Scenario string                `json:"scenario"`
+	Method   string                `json:"method"`
+	Cells    map[string]MatrixCell `json:"cells"`
+}
+
+// Matrix - 環境 × 手法の比較表
+type Matrix struct {
+	Environments []string    `json:"environments"`
+	Baseline     string      `json:"baseline"`
+	Rows         []MatrixRow `json:"rows"`
+}
+
+// BuildMatrix - 環境ごとに手法の中央値を求め、基準環境との倍率を計算
+//
+// baselineが空の場合や結果にない場合は、最初の環境（名前順）を基準にする。
+func BuildMatrix(runs []Run, baseline string) *Matrix {
+	type rowKey struct{ scenario, method string }
+	var order []rowKey
+	samples := make(map[rowKey]map[string][]time.Duration)
+	envSet := make(map[string]bool)
+
+	for _, run := range runs {
+		env := run.Environment
+		if env == "" {
+			env = UntaggedEnvironment
+		}
+		envSet[env] = true
+
+		for _, result := range run.Results {
+			key := rowKey{result.Scenario, result.Method}
+			if _, ok := samples[key]; !ok {
+				order = append(order, key)
+				samples[key] = make(map[string][]time.Duration)
+			}
+			samples[key][env] = append(samples[key][env], result.ExecutionTime)
+		}
+	}
+
+	m := &Matrix{}
+	for env := range envSet {
+		m.Environments = append(m.Environments, env)
+	}
+	sort.Strings(m.Environments)
+	if len(m.Environments) == 0 {
+		return m
+	}
+
+	if !envSet[baseline] {
+		baseline = m.Environments[0]
+	}
+	m.Baseline = baseline
+	// 基準環境を先頭の列にする
+	envs := []string{baseline}
+	for _, env := range m.Environments {
+		if env != baseline {
+			envs = append(envs, env)
+		}
+	}
+	m.Environments = envs
+
+	for _, key := range order {
+		row := MatrixRow{Scenario: key.scenario, Method: key.method, Cells: make(map[string]MatrixCell)}
+		for env, durations := range samples[key] {
+			row.Cells[env] = MatrixCell{Runs: len(durations), Median: median(durations)}
+		}
+		if base, ok := row.Cells[baseline]; ok && base.Median > 0 {
+			for env, cell := range row.Cells {
+				cell.Ratio = float64(cell.Median) / float64(base.Median)
+				row.Cells[env] = cell
+			}
+		}
+		m.Rows = append(m.Rows, row)
+	}
+
+	return m
+}
+
+// median - 計測値の中央値
+func median(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// NPlusOneMethod - 一括取得の手法と比べるN+1の手法
+const NPlusOneMethod = "N+1_Problem"
+
+// SetBasedRatio - シナリオごとのN+1の手法と最も速い一括取得の手法の比
+type SetBasedRatio struct {
+	Scenario string `json:"scenario"`
+	// Ratios - 環境ごとの「N+1の中央値 ÷ 最も速い一括取得の手法の中央値」
+	Ratios map[string]float64 `json:"ratios"`
+	// Fastest - 環境ごとの最も速い一括取得の手法
+	Fastest map[string]string `json:"fastest"`
+}
+
+// SetBasedRatios - 環境ごとに、N+1の手法が最も速い一括取得の手法の何倍かかったかを求める
+//
+// 一括取得の手法は名前が「N+1」で始まらない手法とする。N+1_Problemのないシナリオは含めない。
+func (m *Matrix) SetBasedRatios() []SetBasedRatio {
+	var ratios []SetBasedRatio
+	index := make(map[string]int)
+	nPlusOne := make(map[string]MatrixRow)
+	for _, row := range m.Rows {
+		if _, ok := index[row.Scenario]; !ok {
+			index[row.Scenario] = len(ratios)
+			ratios = append(ratios, SetBasedRatio{Scenario: row.Scenario, Ratios: make(map[string]float64), Fastest: make(map[string]string)})
+		}
+		if row.Method == NPlusOneMethod {
+			nPlusOne[row.Scenario] = row
+		}
+	}
+
+	fastest := make(map[string]map[string]MatrixCell)
+	for _, row := range m.Rows {
+		if strings.HasPrefix(row.Method, "N+1") {
+			continue
+		}
+		r := &ratios[index[row.Scenario]]
+		if fastest[row.Scenario] == nil {
+			fastest[row.Scenario] = make(map[string]MatrixCell)
+		}
+		for env, cell := range row.Cells {
+			if best, ok := fastest[row.Scenario][env]; cell.Median > 0 && (!ok || cell.Median < best.Median) {
+				fastest[row.Scenario][env] = cell
+				r.Fastest[env] = row.Method
+			}
+		}
+	}
+
+	var result []SetBasedRatio
+	for _, r := range ratios {
+		row, ok := nPlusOne[r.Scenario]
+		if !ok {
+			continue
+		}
+		for env, best := range fastest[r.Scenario] {
+			if cell, ok := row.Cells[env]; ok {
+				r.Ratios[env] = float64(cell.Median) / float64(best.Median)
+			}
+		}
+		if len(r.Ratios) > 0 {
+			result = append(result, r)
+		}
+	}
+	return result
+}
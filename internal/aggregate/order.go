@@ -0,0 +1,125 @@
+package aggregate
+
+import (
+	"math"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/service"
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+// DefaultOrderThreshold - 実行順による差がこの割合（%）を超えたら順序の影響ありとみなす
+const DefaultOrderThreshold = 10.0
+
+// MinOrderRuns - 実行順ごとに必要な計測回数（これより少ない場合は検定せず判定不可とする）
+const MinOrderRuns = 3
+
+// PositionEffect - 最初に実行した場合とそれ以降に実行した場合の実行時間の比較
+type PositionEffect struct {
+	FirstRuns int           `json:"first_runs"`
+	LaterRuns int           `json:"later_runs"`
+	FirstMean time.Duration `json:"first_mean"`
+	LaterMean time.Duration `json:"later_mean"`
+	// ChangePercent - 以降に実行した場合に対する最初に実行した場合の差（正の値は最初の方が遅い）
+	ChangePercent float64 `json:"change_percent"`
+	// Comparable - 両方の実行順で計測できたか
+	Comparable bool `json:"comparable"`
+	// Tested - 両方の実行順でMinOrderRuns回以上計測でき、Welchのt検定を行えたか
+	Tested bool `json:"tested"`
+	// P - Welchのt検定の両側p値（検定していない場合は1）
+	P float64 `json:"p_value"`
+}
+
+// OrderEffect - 手法ごとの実行順による影響
+type OrderEffect struct {
+	Scenario string `json:"scenario"`
+	Method   string `json:"method"`
+	Runs     int    `json:"runs"`
+	// InScenario - シナリオ内で最初に実行したか（前の手法でキャッシュが温まっていないか）
+	InScenario PositionEffect `json:"in_scenario"`
+	// InSuite - シナリオを繰り返しの最初に実行したか（前のシナリオでキャッシュが温まっていないか）
+	InSuite PositionEffect `json:"in_suite"`
+	// Sensitive - いずれかの実行順で、有意（p < 0.05）かつthreshold%を超える差があった
+	Sensitive bool `json:"sensitive"`
+	// Inconclusive - 順序の影響ありとはいえず、検定できなかった実行順がある（計測回数の不足など）
+	Inconclusive bool `json:"inconclusive"`
+}
+
+// AnalyzeOrderEffects - 実行順を並べ替えて繰り返した結果から、手法ごとに実行順による影響を求める
+//
+// キャッシュの温まり方で結果が変わる手法は、最初に実行した場合とそれ以降に実行した場合で
+// 実行時間に差が出る。いずれかの実行順で、差がthreshold%を超え、かつWelchのt検定で有意な手法を順序の影響ありとする。
+// 少ない回数の平均の差はノイズと区別できないため、実行順ごとにMinOrderRuns回以上の計測がなければ判定不可とする。
+func AnalyzeOrderEffects(results []service.PerformanceResult, threshold float64) []OrderEffect {
+	if threshold <= 0 {
+		threshold = DefaultOrderThreshold
+	}
+
+	type key struct{ scenario, method string }
+	var order []key
+	grouped := make(map[key][]service.PerformanceResult)
+	for _, r := range results {
+		k := key{r.Scenario, r.Method}
+		if _, ok := grouped[k]; !ok {
+			order = append(order, k)
+		}
+		grouped[k] = append(grouped[k], r)
+	}
+
+	effects := make([]OrderEffect, 0, len(order))
+	for _, k := range order {
+		runs := grouped[k]
+		effect := OrderEffect{
+			Scenario:   k.scenario,
+			Method:     k.method,
+			Runs:       len(runs),
+			InScenario: comparePosition(runs, func(r service.PerformanceResult) bool { return r.Position == 1 }),
+			InSuite:    comparePosition(runs, func(r service.PerformanceResult) bool { return r.ScenarioPosition == 1 }),
+		}
+		effect.Sensitive = effect.InScenario.exceeds(threshold) || effect.InSuite.exceeds(threshold)
+		effect.Inconclusive = !effect.Sensitive && (!effect.InScenario.Tested || !effect.InSuite.Tested)
+		effects = append(effects, effect)
+	}
+
+	return effects
+}
+
+// comparePosition - 最初に実行した回とそれ以降の回の平均を比べる
+func comparePosition(runs []service.PerformanceResult, first func(service.PerformanceResult) bool) PositionEffect {
+	e := PositionEffect{P: 1}
+	var firstSum, laterSum time.Duration
+	var firstTimes, laterTimes []float64
+	for _, r := range runs {
+		if first(r) {
+			e.FirstRuns++
+			firstSum += r.ExecutionTime
+			firstTimes = append(firstTimes, float64(r.ExecutionTime))
+		} else {
+			e.LaterRuns++
+			laterSum += r.ExecutionTime
+			laterTimes = append(laterTimes, float64(r.ExecutionTime))
+		}
+	}
+
+	if e.FirstRuns > 0 {
+		e.FirstMean = (firstSum / time.Duration(e.FirstRuns)).Round(time.Microsecond)
+	}
+	if e.LaterRuns > 0 {
+		e.LaterMean = (laterSum / time.Duration(e.LaterRuns)).Round(time.Microsecond)
+	}
+	e.Comparable = e.FirstRuns > 0 && e.LaterRuns > 0 && e.LaterMean > 0
+	if e.Comparable {
+		e.ChangePercent = float64(e.FirstMean-e.LaterMean) * 100 / float64(e.LaterMean)
+	}
+	if e.Comparable && e.FirstRuns >= MinOrderRuns && e.LaterRuns >= MinOrderRuns {
+		if t, ok := stats.WelchTTest(firstTimes, laterTimes); ok {
+			e.Tested, e.P = true, t.P
+		}
+	}
+	return e
+}
+
+// exceeds - 有意な差があり、その差がthreshold%を超えたか
+func (e PositionEffect) exceeds(threshold float64) bool {
+	return e.Tested && e.P < stats.SignificanceLevel && math.Abs(e.ChangePercent) > threshold
+}
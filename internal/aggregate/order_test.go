@@ -0,0 +1,132 @@
+package aggregate
+
+import (
+	"testing"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// positioned - 実行順と実行時間（ミリ秒）だけを持つ計測結果（シナリオ内・繰り返し内の位置を同じにする）
+func positioned(position, ms int) service.PerformanceResult {
+	r := result("orders", "N+1_Problem", ms)
+	r.Position, r.ScenarioPosition = position, position
+	return r
+}
+
+// runsAt - 最初に実行した回とそれ以降の回の実行時間から計測結果を作成
+func runsAt(first, later []int) []service.PerformanceResult {
+	var results []service.PerformanceResult
+	for _, ms := range first {
+		results = append(results, positioned(1, ms))
+	}
+	for _, ms := range later {
+		results = append(results, positioned(2, ms))
+	}
+	return results
+}
+
+func TestAnalyzeOrderEffects(t *testing.T) {
+	tests := []struct {
+		name         string
+		results      []service.PerformanceResult
+		tested       bool
+		sensitive    bool
+		inconclusive bool
+	}{
+		{
+			// 平均は2倍違うが、最初に実行した回が1回だけでは検定できない
+			name:         "one first run",
+			results:      runsAt([]int{200}, []int{100, 101, 99, 100}),
+			inconclusive: true,
+		},
+		{
+			name:         "two runs per side",
+			results:      runsAt([]int{200, 210}, []int{100, 101}),
+			inconclusive: true,
+		},
+		{
+			name:      "significant and over threshold",
+			results:   runsAt([]int{150, 152, 148, 151}, []int{100, 101, 99, 100}),
+			tested:    true,
+			sensitive: true,
+		},
+		{
+			// 平均の差は10%を超えるが、ばらつきに埋もれて有意ではない
+			name:    "noisy difference",
+			results: runsAt([]int{60, 180, 90, 170}, []int{100, 60, 140, 90}),
+			tested:  true,
+		},
+		{
+			// 有意だが差は10%以下
+			name:    "significant but small",
+			results: runsAt([]int{105, 106, 105, 106}, []int{100, 101, 100, 101}),
+			tested:  true,
+		},
+		{
+			// 分散が両方0では検定できない
+			name:         "constant times",
+			results:      runsAt([]int{150, 150, 150}, []int{100, 100, 100}),
+			inconclusive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			effects := AnalyzeOrderEffects(tt.results, 0)
+			if len(effects) != 1 {
+				t.Fatalf("AnalyzeOrderEffects() returned %d effects, want 1", len(effects))
+			}
+			e := effects[0]
+			if e.Runs != len(tt.results) {
+				t.Errorf("Runs = %d, want %d", e.Runs, len(tt.results))
+			}
+			if !e.InScenario.Comparable {
+				t.Errorf("InScenario.Comparable = false, want true")
+			}
+			if e.InScenario.Tested != tt.tested || e.InSuite.Tested != tt.tested {
+				t.Errorf("Tested = %v/%v, want %v", e.InScenario.Tested, e.InSuite.Tested, tt.tested)
+			}
+			if !tt.tested && e.InScenario.P != 1 {
+				t.Errorf("InScenario.P = %v, want 1 when not tested", e.InScenario.P)
+			}
+			if e.Sensitive != tt.sensitive {
+				t.Errorf("Sensitive = %v, want %v (change %.1f%%, p=%.3f)", e.Sensitive, tt.sensitive, e.InScenario.ChangePercent, e.InScenario.P)
+			}
+			if e.Inconclusive != tt.inconclusive {
+				t.Errorf("Inconclusive = %v, want %v", e.Inconclusive, tt.inconclusive)
+			}
+		})
+	}
+}
+
+func TestAnalyzeOrderEffectsOnePositionTested(t *testing.T) {
+	// シナリオ内では十分に計測できたが、シナリオを繰り返しの最初に実行した回は1回だけ
+	var results []service.PerformanceResult
+	for i, ms := range []int{100, 101, 99, 100, 102, 98} {
+		r := result("orders", "N+1_Problem", ms)
+		r.Position = i%2 + 1
+		r.ScenarioPosition = 2
+		if i == 0 {
+			r.ScenarioPosition = 1
+		}
+		results = append(results, r)
+	}
+
+	e := AnalyzeOrderEffects(results, 0)[0]
+	if !e.InScenario.Tested {
+		t.Fatalf("InScenario.Tested = false, want true")
+	}
+	if e.InSuite.Tested {
+		t.Errorf("InSuite.Tested = true, want false with one first run")
+	}
+	if e.Sensitive || !e.Inconclusive {
+		t.Errorf("Sensitive, Inconclusive = %v, %v, want false, true", e.Sensitive, e.Inconclusive)
+	}
+}
+
+func TestAnalyzeOrderEffectsThreshold(t *testing.T) {
+	results := runsAt([]int{150, 152, 148, 151}, []int{100, 101, 99, 100})
+	if e := AnalyzeOrderEffects(results, 60)[0]; e.Sensitive {
+		t.Errorf("Sensitive = true with threshold 60%% and change %.1f%%, want false", e.InScenario.ChangePercent)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// StrategyHeader - 使用した取得手法を返すレスポンスヘッダー
+const StrategyHeader = "X-Summary-Strategy"
+
+// CacheHeader - cached手法でのキャッシュヒット有無を返すレスポンスヘッダー（HIT/MISS）
+const CacheHeader = "X-Cache"
+
+// errorResponse - エラー時のレスポンス
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server - 顧客サマリーAPIのHTTPハンドラー
+type Server struct {
+	summaries *service.CustomerSummaryService
+	mux       *http.ServeMux
+}
+
+// NewServer - APIサーバーのコンストラクタ
+//
+// GET /customers/{id}/summary?strategy=n1|sql|cached（省略時はsql）
+func NewServer(summaries *service.CustomerSummaryService) *Server {
+	s := &Server{
+		summaries: summaries,
+		mux:       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /customers/{id}/summary", s.handleCustomerSummary)
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	return s
+}
+
+// ServeHTTP - http.Handlerの実装
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleCustomerSummary - 顧客サマリーを返す
+func (s *Server) handleCustomerSummary(w http.ResponseWriter, r *http.Request) {
+	customerID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || customerID <= 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid customer id"})
+		return
+	}
+
+	strategy, err := service.ParseSummaryStrategy(r.URL.Query().Get("strategy"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	start := time.Now()
+	lookup, err := s.summaries.GetSummary(r.Context(), customerID, strategy)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrRedisUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, errorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set(StrategyHeader, string(strategy))
+	w.Header().Set("Server-Timing", fmt.Sprintf("db;dur=%.3f", float64(time.Since(start).Microseconds())/1000))
+	if strategy == service.SummaryCached {
+		if lookup.CacheHit {
+			w.Header().Set(CacheHeader, "HIT")
+		} else {
+			w.Header().Set(CacheHeader, "MISS")
+		}
+	}
+
+	if lookup.Summary == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "customer not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lookup.Summary)
+}
+
+// handleHealth - ヘルスチェック
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]bool{
+		"ok":    true,
+		"redis": s.summaries.RedisAvailable(),
+	})
+}
+
+// writeJSON - JSONレスポンスを書き込む
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		fmt.Printf("レスポンスの書き込みに失敗しました: %v\n", err)
+	}
+}
@@ -0,0 +1,357 @@
+// Package aqenrich - 受注への明細の付加（エンリッチメント）を、同期的なN+1の問い合わせとOracle Advanced Queuing（AQ）による非同期処理で比較する
+//
+// 同期では呼び出し側が受注ごとに明細を問い合わせ、すべて終わるまで待つ。非同期では呼び出し側は受注IDをキューに入れるだけで戻り、
+// ワーカーがキューから取り出して明細を付加する。呼び出し側の応答時間と、受注を受け付けてから付加が終わるまでの時間（エンドツーエンド）、
+// スループットを計測する。
+package aqenrich
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+const (
+	// QueueTable / Queue - 計測中だけ作成するキュー表とキュー（ペイロードは受注IDを変換したRAW）
+	QueueTable = "NPLUS1_ENRICH_QT"
+	Queue      = "NPLUS1_ENRICH_Q"
+	// DefaultOrders - 既定で付加する受注の件数（受注IDの大きい順）
+	DefaultOrders = 200
+	// DefaultWorkers - 既定のワーカー（キューから取り出す接続）の数
+	DefaultWorkers = 4
+	// DefaultDrainTimeout - 既定の、すべての受注をキューに入れてから付加の完了を待つ時間
+	DefaultDrainTimeout = 30 * time.Second
+	// dequeueWait - DBMS_AQ.DEQUEUEの1回の待機時間（秒。完了と停止の確認の間隔）
+	dequeueWait = 1
+)
+
+// enqueueSQL - 受注IDを1件キューに入れる（visibility IMMEDIATEで、呼び出し側のトランザクションを待たずに見える）
+const enqueueSQL = `DECLARE
+  eo    DBMS_AQ.ENQUEUE_OPTIONS_T;
+  mp    DBMS_AQ.MESSAGE_PROPERTIES_T;
+  msgid RAW(16);
+BEGIN
+  eo.visibility := DBMS_AQ.IMMEDIATE;
+  DBMS_AQ.ENQUEUE(queue_name => '` + Queue + `', enqueue_options => eo, message_properties => mp,
+    payload => UTL_RAW.CAST_FROM_NUMBER(:1), msgid => msgid);
+END;`
+
+// dequeueSQL - 受注IDを1件取り出す（待機時間内に届かなければ0を返す）
+const dequeueSQL = `DECLARE
+  dq      DBMS_AQ.DEQUEUE_OPTIONS_T;
+  mp      DBMS_AQ.MESSAGE_PROPERTIES_T;
+  msgid   RAW(16);
+  payload RAW(32);
+  no_messages EXCEPTION;
+  PRAGMA EXCEPTION_INIT(no_messages, -25228);
+BEGIN
+  dq.wait := :1;
+  dq.visibility := DBMS_AQ.IMMEDIATE;
+  dq.navigation := DBMS_AQ.FIRST_MESSAGE;
+  DBMS_AQ.DEQUEUE(queue_name => '` + Queue + `', dequeue_options => dq, message_properties => mp,
+    payload => payload, msgid => msgid);
+  :2 := UTL_RAW.CAST_TO_NUMBER(payload);
+EXCEPTION
+  WHEN no_messages THEN :2 := 0;
+END;`
+
+// createQueueSQL / dropQueueSQL - キュー表とキューの作成・開始と、キュー表ごとの削除
+const (
+	createQueueSQL = `BEGIN
+  DBMS_AQADM.CREATE_QUEUE_TABLE(queue_table => '` + QueueTable + `', queue_payload_type => 'RAW');
+  DBMS_AQADM.CREATE_QUEUE(queue_name => '` + Queue + `', queue_table => '` + QueueTable + `');
+  DBMS_AQADM.START_QUEUE(queue_name => '` + Queue + `');
+END;`
+	dropQueueSQL = `BEGIN DBMS_AQADM.DROP_QUEUE_TABLE(queue_table => '` + QueueTable + `', force => TRUE); END;`
+)
+
+// Config - 計測の設定
+type Config struct {
+	// Orders - 付加する受注の件数
+	Orders int
+	// Workers - ワーカー（キューから取り出す接続）の数
+	Workers int
+	// DrainTimeout - すべての受注をキューに入れてから、付加の完了を待つ時間
+	DrainTimeout time.Duration
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{Orders: DefaultOrders, Workers: DefaultWorkers, DrainTimeout: DefaultDrainTimeout}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if c.Orders <= 0 {
+		return fmt.Errorf("orders must be positive: %d", c.Orders)
+	}
+	if c.Workers <= 0 {
+		return fmt.Errorf("workers must be positive: %d", c.Workers)
+	}
+	if c.DrainTimeout <= 0 {
+		return fmt.Errorf("drain timeout must be positive: %v", c.DrainTimeout)
+	}
+	return nil
+}
+
+// Latency - 受注ごとのエンドツーエンドの時間の要約
+type Latency struct {
+	Median time.Duration `json:"median"`
+	P95    time.Duration `json:"p95"`
+	Max    time.Duration `json:"max"`
+}
+
+// summarize - 時間の中央値・95パーセンタイル・最大
+func summarize(values []time.Duration) Latency {
+	if len(values) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return Latency{
+		Median: stats.MedianDuration(sorted),
+		P95:    sorted[(len(sorted)*95+99)/100-1],
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// Phase - 同期または非同期での付加の計測結果
+type Phase struct {
+	Name string `json:"name"`
+	// Response - 呼び出し側が戻るまでの時間（同期は全件の付加、非同期はキューに入れ終わるまで）
+	Response time.Duration `json:"response"`
+	// Elapsed - 最初の受注を受け付けてから、最後の付加が終わるまでの時間
+	Elapsed time.Duration `json:"elapsed"`
+	// Latency - 受注を受け付けてから、その受注の付加が終わるまでの時間
+	Latency  Latency `json:"latency"`
+	Enriched int     `json:"enriched"`
+	// Details - 付加した明細の件数の合計（同期と非同期で一致する）
+	Details int64 `json:"details"`
+}
+
+// Throughput - 1秒あたりに付加した受注の件数（時間がなければ0）
+func (p Phase) Throughput() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Enriched) / p.Elapsed.Seconds()
+}
+
+// Report - 同期的なN+1と、AQによる非同期の付加の比較
+type Report struct {
+	Orders  int   `json:"orders"`
+	Workers int   `json:"workers"`
+	Sync    Phase `json:"sync"`
+	Async   Phase `json:"async"`
+	// Pending - 待つ時間内に付加が終わらなかった受注の件数
+	Pending int `json:"pending,omitempty"`
+}
+
+// Run - 受注cfg.Orders件を同期的なN+1で付加し、続けてAQのキューに入れてcfg.Workers個のワーカーで付加する
+//
+// DBMS_AQ・DBMS_AQADMのEXECUTE権限（またはAQ_ADMINISTRATOR_ROLE）が必要。キュー表とキューは終了時に削除する。
+func Run(ctx context.Context, db *sql.DB, cfg Config) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	orderIDs, err := latestOrders(ctx, db, cfg.Orders)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Orders: len(orderIDs), Workers: cfg.Workers}
+
+	if report.Sync, err = runSync(ctx, db, orderIDs); err != nil {
+		return nil, err
+	}
+
+	// 前回の計測で残ったキュー表は作り直す
+	_, _ = db.ExecContext(ctx, dropQueueSQL)
+	if _, err := db.ExecContext(ctx, createQueueSQL); err != nil {
+		return nil, fmt.Errorf("failed to create queue %s: %w", Queue, err)
+	}
+	defer func() {
+		if _, err := db.Exec(dropQueueSQL); err != nil {
+			fmt.Printf("failed to drop queue table %s: %v\n", QueueTable, err)
+		}
+	}()
+
+	if report.Async, report.Pending, err = runAsync(ctx, db, orderIDs, cfg); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// latestOrders - 受注IDの大きい順にn件
+func latestOrders(ctx context.Context, db *sql.DB, n int) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT order_id FROM (SELECT order_id FROM orders ORDER BY order_id DESC)
+		WHERE ROWNUM <= :1`, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan order row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no orders found")
+	}
+	return ids, nil
+}
+
+// rowQueryer - *sql.DBと*sql.Conn（ワーカーの接続）の共通部分
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// enrich - 受注1件の明細を問い合わせ、件数を返す（付加の処理の本体）
+func enrich(ctx context.Context, q rowQueryer, orderID int64) (int64, error) {
+	var details int64
+	var amount float64
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*), NVL(SUM(quantity * unit_price), 0)
+		FROM order_details WHERE order_id = :1`, orderID).Scan(&details, &amount); err != nil {
+		return 0, fmt.Errorf("failed to enrich order %d: %w", orderID, err)
+	}
+	return details, nil
+}
+
+// runSync - すべての受注を同時に受け付け、呼び出し側が1件ずつ明細を問い合わせる（N+1）
+func runSync(ctx context.Context, db *sql.DB, orderIDs []int64) (Phase, error) {
+	phase := Phase{Name: "Sync_N_Plus_1"}
+	latencies := make([]time.Duration, 0, len(orderIDs))
+	start := time.Now()
+	for _, id := range orderIDs {
+		details, err := enrich(ctx, db, id)
+		if err != nil {
+			return phase, err
+		}
+		phase.Details += details
+		phase.Enriched++
+		latencies = append(latencies, time.Since(start))
+	}
+	phase.Response = time.Since(start)
+	phase.Elapsed = phase.Response
+	phase.Latency = summarize(latencies)
+	return phase, nil
+}
+
+// runAsync - 呼び出し側が受注IDをキューに入れ、ワーカーが取り出して付加する（付加が終わらなかった件数を返す）
+func runAsync(ctx context.Context, db *sql.DB, orderIDs []int64, cfg Config) (Phase, int, error) {
+	phase := Phase{Name: "AQ_Async_Enrichment"}
+	var mu sync.Mutex
+	enqueued := make(map[int64]time.Time, len(orderIDs))
+	var latencies []time.Duration
+	var last time.Time
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	allDone := make(chan struct{})
+	errs := make(chan error, cfg.Workers)
+	var wg sync.WaitGroup
+	for range cfg.Workers {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			cancel()
+			wg.Wait()
+			return phase, 0, fmt.Errorf("failed to open worker connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if cerr := conn.Close(); cerr != nil {
+					fmt.Printf("conn.Close() failed: %v\n", cerr)
+				}
+			}()
+			for workCtx.Err() == nil {
+				// 待機中に取り消すと接続が使えなくなるため、取り消しはdequeueWaitごとに確認する
+				var orderID int64
+				if _, err := conn.ExecContext(context.Background(), dequeueSQL, dequeueWait, sql.Out{Dest: &orderID}); err != nil {
+					errs <- fmt.Errorf("failed to dequeue: %w", err)
+					return
+				}
+				if orderID == 0 {
+					continue
+				}
+				details, err := enrich(context.Background(), conn, orderID)
+				if err != nil {
+					errs <- err
+					return
+				}
+				now := time.Now()
+				mu.Lock()
+				if at, ok := enqueued[orderID]; ok {
+					latencies = append(latencies, now.Sub(at))
+				}
+				phase.Details += details
+				phase.Enriched++
+				last = now
+				if phase.Enriched == len(orderIDs) {
+					close(allDone)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	start := time.Now()
+	var enqueueErr error
+	for _, id := range orderIDs {
+		// ワーカーが先に取り出しても時刻が見つかるよう、キューに入れる前に記録する
+		mu.Lock()
+		enqueued[id] = time.Now()
+		mu.Unlock()
+		if _, err := db.ExecContext(ctx, enqueueSQL, id); err != nil {
+			enqueueErr = fmt.Errorf("failed to enqueue order %d: %w", id, err)
+			break
+		}
+	}
+	phase.Response = time.Since(start)
+
+	var workerErr error
+	if enqueueErr == nil {
+		select {
+		case <-allDone:
+		case workerErr = <-errs:
+		case <-time.After(cfg.DrainTimeout):
+		case <-ctx.Done():
+			workerErr = ctx.Err()
+		}
+	}
+	cancel()
+	wg.Wait()
+	if enqueueErr != nil {
+		return phase, 0, enqueueErr
+	}
+	if workerErr != nil {
+		return phase, 0, workerErr
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if phase.Enriched > 0 {
+		phase.Elapsed = last.Sub(start)
+	}
+	phase.Latency = summarize(latencies)
+	return phase, len(orderIDs) - phase.Enriched, nil
+}
@@ -0,0 +1,61 @@
+package aqenrich
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "default", cfg: DefaultConfig()},
+		{name: "no orders", cfg: Config{Orders: 0, Workers: 1, DrainTimeout: time.Second}, wantErr: true},
+		{name: "no workers", cfg: Config{Orders: 10, Workers: 0, DrainTimeout: time.Second}, wantErr: true},
+		{name: "no drain timeout", cfg: Config{Orders: 10, Workers: 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var values []time.Duration
+	for i := 20; i >= 1; i-- {
+		values = append(values, time.Duration(i)*time.Millisecond)
+	}
+	got := summarize(values)
+	want := Latency{Median: 10500 * time.Microsecond, P95: 19 * time.Millisecond, Max: 20 * time.Millisecond}
+	if got != want {
+		t.Errorf("summarize() = %+v, want %+v", got, want)
+	}
+	if got := summarize(nil); got != (Latency{}) {
+		t.Errorf("summarize(nil) = %+v, want zero", got)
+	}
+}
+
+func TestPhaseThroughput(t *testing.T) {
+	if got := (Phase{Enriched: 200, Elapsed: 2 * time.Second}).Throughput(); got != 100 {
+		t.Errorf("Throughput() = %v, want 100", got)
+	}
+	if got := (Phase{Enriched: 200}).Throughput(); got != 0 {
+		t.Errorf("Throughput() without elapsed = %v, want 0", got)
+	}
+}
+
+func TestQueueSQL(t *testing.T) {
+	// キューへの出し入れは作成したキューを、削除はキュー表ごと対象にする
+	for name, statement := range map[string]string{"enqueue": enqueueSQL, "dequeue": dequeueSQL, "create": createQueueSQL} {
+		if !strings.Contains(statement, "'"+Queue+"'") {
+			t.Errorf("%s SQL does not use queue %s", name, Queue)
+		}
+	}
+	if !strings.Contains(dropQueueSQL, "'"+QueueTable+"'") {
+		t.Errorf("drop SQL does not use queue table %s", QueueTable)
+	}
+}
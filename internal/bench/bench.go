@@ -0,0 +1,67 @@
+// Package bench - go test -bench で比較の手法を計測するためのアダプター
+//
+// 接続先はcmdと同じ環境変数（と、カレントディレクトリから上にたどって最初に見つかった.env）で指定する。
+// 接続先が設定されていなければベンチマークをスキップする。
+package bench
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+
+	"oracle-n-plus-1-demo/config"
+)
+
+// ErrNotConfigured - 接続先（DB_USERNAME）が設定されていない
+var ErrNotConfigured = errors.New("DB_USERNAME is not set")
+
+// Open - 環境変数と.envから接続プールを作成して疎通を確認する
+func Open() (*sql.DB, error) {
+	if path := findDotEnv(); path != "" {
+		// 環境変数を優先する（godotenv.Loadは設定済みの変数を上書きしない）
+		if err := godotenv.Load(path); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+	}
+	if os.Getenv("DB_USERNAME") == "" {
+		return nil, ErrNotConfigured
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := config.ConnectDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// findDotEnv - カレントディレクトリから上にたどって最初に見つかった.env（なければ空）
+//
+// go test はパッケージのディレクトリで実行されるため、リポジトリ直下の.envを探す。
+func findDotEnv() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, config.DotEnvFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
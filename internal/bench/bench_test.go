@@ -0,0 +1,143 @@
+package bench
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"os"
+	"sync"
+	"testing"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// days - 受注データの日数（go test -bench . ./internal/bench -args -days=7）
+var days = flag.Int("days", 30, "取得する受注データの日数（過去何日間）")
+
+var (
+	openOnce sync.Once
+	db       *sql.DB
+	openErr  error
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+	if db != nil {
+		_ = db.Close()
+	}
+	os.Exit(code)
+}
+
+// openDB - 接続プールを1回だけ作成する（接続先が設定されていなければスキップ）
+func openDB(b *testing.B) *sql.DB {
+	b.Helper()
+	openOnce.Do(func() { db, openErr = Open() })
+	if errors.Is(openErr, ErrNotConfigured) {
+		b.Skip("DB_USERNAME が設定されていないためスキップします")
+	}
+	if openErr != nil {
+		b.Skipf("データベースに接続できないためスキップします: %v", openErr)
+	}
+	return db
+}
+
+// benchmarkMethod - 手法を b.N 回実行する（1回あたりの件数を rows/op として報告）
+func benchmarkMethod(b *testing.B, scenario, method string) {
+	demo := service.NewDemoService(openDB(b))
+	defer func() { _ = demo.Close() }()
+	run, err := demo.Runner(scenario, method, *days)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	rows := 0
+	for i := 0; i < b.N; i++ {
+		if rows, err = run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(rows), "rows/op")
+}
+
+func Benchmark_OrderNPlus1(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioOrders, "N+1_Problem")
+}
+
+func Benchmark_OrderJoin(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioOrders, "JOIN_Optimized")
+}
+
+func Benchmark_OrderBatch(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioOrders, "Batch_Optimized")
+}
+
+func Benchmark_OrderBatchSCN(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioOrders, "Batch_SCN_Consistent")
+}
+
+func Benchmark_OrderPrepareInLoop(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioOrders, "N+1_PrepareInLoop")
+}
+
+func Benchmark_OrderStmtCache(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioOrders, "N+1_StmtCache")
+}
+
+func Benchmark_EmployeeNPlus1(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioEmployees, "N+1_Problem")
+}
+
+func Benchmark_EmployeeMemoized(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioEmployees, "Memoized_Partial")
+}
+
+func Benchmark_EmployeeBatch(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioEmployees, "Batch_Optimized")
+}
+
+func Benchmark_EmployeeJoin(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioEmployees, "JOIN_Optimized")
+}
+
+func Benchmark_EmployeeProjectNPlus1(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioEmployeeProjects, "N+1_Problem")
+}
+
+func Benchmark_EmployeeProjectBatch(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioEmployeeProjects, "Batch_Optimized")
+}
+
+func Benchmark_EmployeeProjectJoin(b *testing.B) {
+	benchmarkMethod(b, service.ScenarioEmployeeProjects, "JOIN_Optimized")
+}
+
+// TestRunnerMethods - ベンチマークが参照する手法がすべて存在する
+func TestRunnerMethods(t *testing.T) {
+	demo := service.NewDemoService(nil)
+	for _, m := range []struct{ scenario, method string }{
+		{service.ScenarioOrders, "N+1_Problem"},
+		{service.ScenarioOrders, "JOIN_Optimized"},
+		{service.ScenarioOrders, "Batch_Optimized"},
+		{service.ScenarioOrders, "Batch_SCN_Consistent"},
+		{service.ScenarioOrders, "N+1_PrepareInLoop"},
+		{service.ScenarioOrders, "N+1_StmtCache"},
+		{service.ScenarioEmployees, "N+1_Problem"},
+		{service.ScenarioEmployees, "Memoized_Partial"},
+		{service.ScenarioEmployees, "Batch_Optimized"},
+		{service.ScenarioEmployees, "JOIN_Optimized"},
+		{service.ScenarioEmployeeProjects, "N+1_Problem"},
+		{service.ScenarioEmployeeProjects, "Batch_Optimized"},
+		{service.ScenarioEmployeeProjects, "JOIN_Optimized"},
+	} {
+		if _, err := demo.Runner(m.scenario, m.method, 30); err != nil {
+			t.Errorf("Runner(%s, %s) failed: %v", m.scenario, m.method, err)
+		}
+	}
+	if _, err := demo.Runner(service.ScenarioOrders, "Unknown", 30); err == nil {
+		t.Errorf("Runner(orders, Unknown) succeeded")
+	}
+}
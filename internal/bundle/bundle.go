@@ -0,0 +1,87 @@
+// Package bundle - 実行の記録（コンソール出力・計測結果・実行計画・設定）を1つのアーカイブにまとめる（-bundle）
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// アーカイブ内のファイル名
+const (
+	TranscriptFile = "transcript.txt"
+	ResultsFile    = "results.json"
+	PlansFile      = "plans.txt"
+	ConfigFile     = "config.json"
+)
+
+// File - アーカイブに含めるファイル
+type File struct {
+	Name string
+	Data []byte
+}
+
+// ValidatePath - 出力先が .tar.gz / .tgz で終わるか確認
+func ValidatePath(path string) error {
+	if path == "" {
+		return errors.New("bundle path is empty")
+	}
+	if !strings.HasSuffix(path, ".tar.gz") && !strings.HasSuffix(path, ".tgz") {
+		return fmt.Errorf("bundle path must end with .tar.gz or .tgz: %s", path)
+	}
+	return nil
+}
+
+// Prefix - アーカイブ内のディレクトリ名（展開したときにファイルが散らばらないよう、出力先のファイル名から拡張子を除いたもの）
+func Prefix(path string) string {
+	base := filepath.Base(path)
+	for _, ext := range []string{".tar.gz", ".tgz"} {
+		if strings.HasSuffix(base, ext) {
+			return strings.TrimSuffix(base, ext)
+		}
+	}
+	return base
+}
+
+// Write - ファイルをgzip圧縮したtarアーカイブとして出力
+func Write(path string, files []File, modTime time.Time) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close bundle: %w", cerr)
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	prefix := Prefix(path)
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    prefix + "/" + file.Name,
+			Mode:    0o644,
+			Size:    int64(len(file.Data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s header: %w", file.Name, err)
+		}
+		if _, err := tw.Write(file.Data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish tar archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish gzip stream: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "run.tar.gz"},
+		{path: "out/run.tgz"},
+		{path: "", wantErr: true},
+		{path: "run.zip", wantErr: true},
+		{path: "run.tar", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePath(tt.path); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := map[string]string{
+		"run.tar.gz":              "run",
+		"/tmp/ticket-123.tgz":     "ticket-123",
+		"dir/run.2026.tar.gz":     "run.2026",
+		"results-dev-x.tar.gz.gz": "results-dev-x.tar.gz.gz",
+	}
+	for path, want := range tests {
+		if got := Prefix(path); got != want {
+			t.Errorf("Prefix(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.tar.gz")
+	files := []File{
+		{Name: TranscriptFile, Data: []byte("hello\n")},
+		{Name: ResultsFile, Data: []byte(`{"results":[]}`)},
+	}
+	if err := Write(path, files, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var got []string
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, header.Name+"="+string(data))
+	}
+	want := []string{"run/transcript.txt=hello\n", `run/results.json={"results":[]}`}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("archive entries = %q, want %q", got, want)
+	}
+}
+
+func TestFormatPlans(t *testing.T) {
+	plans := []Plan{{
+		SQLID: "abc", ChildNumber: 0, Executions: 12,
+		SQLText: "SELECT *\n\t FROM order_details WHERE order_id = :1",
+		Lines:   []string{"Plan hash value: 1", "| 0 | SELECT STATEMENT |"},
+	}}
+	got := string(FormatPlans(plans, nil))
+	for _, want := range []string{"SQL_ID abc（子カーソル 0、実行 12回）", "SELECT * FROM order_details WHERE order_id = :1", "Plan hash value: 1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPlans() = %q, want containing %q", got, want)
+		}
+	}
+
+	if got := string(FormatPlans(nil, fmt.Errorf("ORA-00942"))); !strings.Contains(got, "ORA-00942") {
+		t.Errorf("FormatPlans() with error = %q", got)
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	original := os.Stdout
+	transcript, err := StartTranscript()
+	if err != nil {
+		t.Fatalf("StartTranscript() error = %v", err)
+	}
+	fmt.Println("console line")
+	if _, err := transcript.Write([]byte("log line\n")); err != nil {
+		t.Fatal(err)
+	}
+	got := string(transcript.Stop())
+	if os.Stdout != original {
+		t.Error("Stop() did not restore os.Stdout")
+	}
+	if !strings.Contains(got, "console line\n") || !strings.Contains(got, "log line\n") {
+		t.Errorf("transcript = %q", got)
+	}
+	if again := string(transcript.Stop()); again != got {
+		t.Errorf("second Stop() = %q, want %q", again, got)
+	}
+}
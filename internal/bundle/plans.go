@@ -0,0 +1,138 @@
+package bundle
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultMaxPlans - アーカイブに含める実行計画の上限（実行回数の多い順）
+const DefaultMaxPlans = 30
+
+// Plan - 実行中に使われたSQLの実行計画（カーソルキャッシュ上の実際の計画）
+type Plan struct {
+	SQLID       string
+	ChildNumber int64
+	Executions  int64
+	SQLText     string
+	Lines       []string
+}
+
+// DBTime - データベースの現在時刻（V$SQLの最終実行時刻と比べるため、アプリ側の時刻は使わない）
+func DBTime(db *sql.DB) (time.Time, error) {
+	var now time.Time
+	if err := db.QueryRow("SELECT SYSDATE FROM dual").Scan(&now); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get database time: %w", err)
+	}
+	return now, nil
+}
+
+// CollectPlans - since以降に実行されたこのスキーマのSELECT文の実行計画をDBMS_XPLAN.DISPLAY_CURSORで取得
+//
+// V$SQLとDBMS_XPLANの参照にはSELECT_CATALOG_ROLEなどの権限が必要。
+// 同じスキーマで同時に実行された他のプログラムのSQLも含まれる。
+func CollectPlans(db *sql.DB, since time.Time, limit int) ([]Plan, error) {
+	if limit <= 0 {
+		limit = DefaultMaxPlans
+	}
+
+	plans, err := queryCursors(db, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range plans {
+		lines, err := DisplayCursor(db, plans[i].SQLID, plans[i].ChildNumber)
+		if err != nil {
+			return nil, err
+		}
+		plans[i].Lines = lines
+	}
+	return plans, nil
+}
+
+// queryCursors - since以降に実行されたSELECT文のカーソルを実行回数の多い順に取得
+func queryCursors(db *sql.DB, since time.Time, limit int) ([]Plan, error) {
+	rows, err := db.Query(`
+		SELECT sql_id, child_number, executions, SUBSTR(sql_text, 1, 1000)
+		FROM v$sql
+		WHERE parsing_schema_name = USER
+		  AND command_type = 3
+		  AND last_active_time >= :1
+		  AND UPPER(sql_text) NOT LIKE '%V$%'
+		  AND UPPER(sql_text) NOT LIKE '%DBMS_XPLAN%'
+		ORDER BY executions DESC, sql_id, child_number
+		FETCH FIRST :2 ROWS ONLY`, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v$sql: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var plans []Plan
+	for rows.Next() {
+		var p Plan
+		if err := rows.Scan(&p.SQLID, &p.ChildNumber, &p.Executions, &p.SQLText); err != nil {
+			return nil, fmt.Errorf("failed to scan v$sql: %w", err)
+		}
+		plans = append(plans, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read v$sql: %w", err)
+	}
+	return plans, nil
+}
+
+// DisplayCursor - カーソルキャッシュ上の実行計画を行ごとに取得
+func DisplayCursor(db *sql.DB, sqlID string, child int64) ([]string, error) {
+	rows, err := db.Query(
+		"SELECT plan_table_output FROM TABLE(DBMS_XPLAN.DISPLAY_CURSOR(:1, :2, 'TYPICAL'))", sqlID, child)
+	if err != nil {
+		return nil, fmt.Errorf("failed to display cursor %s: %w", sqlID, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var lines []string
+	for rows.Next() {
+		var line sql.NullString
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed to scan plan of %s: %w", sqlID, err)
+		}
+		lines = append(lines, line.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read plan of %s: %w", sqlID, err)
+	}
+	return lines, nil
+}
+
+// FormatPlans - 実行計画をテキストにまとめる（取得できなかった場合はその理由を記す）
+func FormatPlans(plans []Plan, collectErr error) []byte {
+	var b strings.Builder
+	if collectErr != nil {
+		fmt.Fprintf(&b, "実行計画を取得できませんでした（V$SQLとDBMS_XPLANの参照権限が必要です）: %v\n", collectErr)
+		return []byte(b.String())
+	}
+	if len(plans) == 0 {
+		b.WriteString("実行中に使われたSELECT文がカーソルキャッシュに見つかりませんでした\n")
+		return []byte(b.String())
+	}
+	for i, p := range plans {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "=== %d. SQL_ID %s（子カーソル %d、実行 %d回） ===\n", i+1, p.SQLID, p.ChildNumber, p.Executions)
+		fmt.Fprintf(&b, "%s\n\n", strings.Join(strings.Fields(p.SQLText), " "))
+		for _, line := range p.Lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	return []byte(b.String())
+}
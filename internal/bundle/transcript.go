@@ -0,0 +1,67 @@
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Transcript - 標準出力をそのまま表示しながら記録する
+//
+// 各処理の表示を個別に書き換えずに済むよう、記録中は os.Stdout をパイプに差し替える。
+// ログなど標準出力以外の出力は Write で同じ記録に加える。
+type Transcript struct {
+	stdout *os.File
+	pipe   *os.File
+	done   chan struct{}
+	once   sync.Once
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// StartTranscript - 標準出力の記録を開始
+func StartTranscript() (*Transcript, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	t := &Transcript{stdout: os.Stdout, pipe: w, done: make(chan struct{})}
+	go func() {
+		defer close(t.done)
+		if _, err := io.Copy(io.MultiWriter(t.stdout, t), r); err != nil {
+			fmt.Fprintf(os.Stderr, "標準出力の記録中にエラー: %v\n", err)
+		}
+		if err := r.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "pipe.Close() failed: %v\n", err)
+		}
+	}()
+	os.Stdout = w
+	return t, nil
+}
+
+// Write - 記録に加える（ログの出力先として使う）
+func (t *Transcript) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.Write(p)
+}
+
+// Stop - 標準出力を元に戻し、それまでの記録を返す（2回目以降は記録を返すだけ）
+func (t *Transcript) Stop() []byte {
+	t.once.Do(func() {
+		os.Stdout = t.stdout
+		if err := t.pipe.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "pipe.Close() failed: %v\n", err)
+		}
+		// パイプに残っている出力を表示・記録し終えるまで待つ
+		<-t.done
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return bytes.Clone(t.buf.Bytes())
+}
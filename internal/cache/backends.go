@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	backend "oracle-n-plus-1-demo/pkg/cache"
+)
+
+// BackendResult - 独自のキャッシュ実装（pkg/cache.Backend）の計測結果
+type BackendResult struct {
+	// Name / Backend - 手法名（Backend.Name）と登録名
+	Name         string          `json:"name"`
+	Backend      string          `json:"backend"`
+	Description  string          `json:"description"`
+	Runs         int             `json:"runs"`
+	AverageTime  time.Duration   `json:"average_time"`
+	HitRate      float64         `json:"hit_rate"`
+	RunDurations []time.Duration `json:"run_durations,omitempty"`
+	RunHits      []bool          `json:"run_hits,omitempty"`
+	// MemoryUsage - キャッシュが使っているメモリ（MemoryReporterを実装していない場合は0）
+	MemoryUsage int64 `json:"memory_usage_bytes,omitempty"`
+	// Error - 計測に失敗した理由（失敗した場合はほかの値は計測できた分のみ）
+	Error string `json:"error,omitempty"`
+}
+
+// namedBackend - 登録名付きのBackend
+type namedBackend struct {
+	name    string
+	backend backend.Backend
+}
+
+// AddBackend - 包括的性能分析で計測する独自のキャッシュ実装を追加
+func (pa *PerformanceAnalyzer) AddBackend(name string, b backend.Backend) {
+	pa.backends = append(pa.backends, namedBackend{name: name, backend: b})
+}
+
+// CloseBackends - 追加した独自のキャッシュ実装のうち io.Closer を実装しているものを閉じる
+func (pa *PerformanceAnalyzer) CloseBackends() error {
+	var errs []error
+	for _, nb := range pa.backends {
+		if c, ok := nb.backend.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close cache backend %s: %w", nb.name, err))
+			}
+		}
+	}
+	pa.backends = nil
+	return errors.Join(errs...)
+}
+
+// MeasureBackends - 追加した独自のキャッシュ実装をそれぞれruns回計測（失敗したものはErrorに理由を記録）
+func (pa *PerformanceAnalyzer) MeasureBackends(runs int) []BackendResult {
+	var results []BackendResult
+	for _, nb := range pa.backends {
+		results = append(results, pa.measureBackend(nb, runs))
+	}
+	return results
+}
+
+// measureBackend - 1つのキャッシュ実装を準備してからruns回取得し、実行時間とヒットを記録
+func (pa *PerformanceAnalyzer) measureBackend(nb namedBackend, runs int) BackendResult {
+	ctx := context.Background()
+	b := nb.backend
+	result := BackendResult{Name: b.Name(), Backend: nb.name, Description: b.Description(), Runs: runs}
+
+	if p, ok := b.(backend.Preparer); ok {
+		if err := p.Prepare(ctx); err != nil {
+			result.Error = fmt.Sprintf("準備に失敗: %v", err)
+			return result
+		}
+	}
+
+	var total time.Duration
+	var hits int
+	for i := 0; i < runs; i++ {
+		start := pa.clock.Now()
+		hit, err := b.Fetch(ctx)
+		if err != nil {
+			result.Error = fmt.Sprintf("%d回目の取得に失敗: %v", i+1, err)
+			return result
+		}
+		duration := pa.clock.Since(start)
+		total += duration
+		result.RunDurations = append(result.RunDurations, duration)
+		result.RunHits = append(result.RunHits, hit)
+		if hit {
+			hits++
+		}
+	}
+	if runs > 0 {
+		result.AverageTime = total / time.Duration(runs)
+		result.HitRate = float64(hits) / float64(runs) * 100
+	}
+
+	if m, ok := b.(backend.MemoryReporter); ok {
+		usage, err := m.MemoryUsage(ctx)
+		if err != nil {
+			result.Error = fmt.Sprintf("メモリ使用量の取得に失敗: %v", err)
+			return result
+		}
+		result.MemoryUsage = usage
+	}
+	return result
+}
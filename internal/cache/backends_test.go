@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+)
+
+// scriptedBackend - 回ごとに決めた時間だけ時計を進め、2回目以降はヒットを返すBackend
+type scriptedBackend struct {
+	clock    *clock.Fake
+	steps    []time.Duration
+	calls    int
+	failAt   int
+	prepared bool
+}
+
+func (b *scriptedBackend) Name() string        { return "Scripted_Cache" }
+func (b *scriptedBackend) Description() string { return "scripted" }
+
+func (b *scriptedBackend) Prepare(context.Context) error {
+	b.prepared = true
+	return nil
+}
+
+func (b *scriptedBackend) Fetch(context.Context) (bool, error) {
+	b.calls++
+	if b.calls == b.failAt {
+		return false, errors.New("connection refused")
+	}
+	b.clock.Advance(b.steps[b.calls-1])
+	return b.calls > 1, nil
+}
+
+func (b *scriptedBackend) MemoryUsage(context.Context) (int64, error) { return 2048, nil }
+
+func TestMeasureBackends(t *testing.T) {
+	clk := clock.NewFake(time.Time{})
+	sb := &scriptedBackend{clock: clk, steps: []time.Duration{9 * time.Millisecond, time.Millisecond, 2 * time.Millisecond}}
+
+	pa := NewPerformanceAnalyzer(nil)
+	pa.SetClock(clk)
+	pa.AddBackend("scripted", sb)
+
+	results := pa.MeasureBackends(3)
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(results))
+	}
+	r := results[0]
+	if !sb.prepared {
+		t.Error("Prepare was not called")
+	}
+	if r.Error != "" {
+		t.Fatalf("Error = %q, want empty", r.Error)
+	}
+	if r.Name != "Scripted_Cache" || r.Backend != "scripted" {
+		t.Errorf("Name, Backend = %q, %q, want Scripted_Cache, scripted", r.Name, r.Backend)
+	}
+	if r.AverageTime != 4*time.Millisecond {
+		t.Errorf("AverageTime = %v, want 4ms", r.AverageTime)
+	}
+	if want := 200.0 / 3; r.HitRate < want-0.001 || r.HitRate > want+0.001 {
+		t.Errorf("HitRate = %v, want %v", r.HitRate, want)
+	}
+	if r.MemoryUsage != 2048 {
+		t.Errorf("MemoryUsage = %d, want 2048", r.MemoryUsage)
+	}
+}
+
+func TestMeasureBackendsRecordsError(t *testing.T) {
+	clk := clock.NewFake(time.Time{})
+	sb := &scriptedBackend{clock: clk, steps: []time.Duration{time.Millisecond, time.Millisecond}, failAt: 2}
+
+	pa := NewPerformanceAnalyzer(nil)
+	pa.SetClock(clk)
+	pa.AddBackend("scripted", sb)
+
+	r := pa.MeasureBackends(3)[0]
+	if r.Error == "" {
+		t.Fatal("Error is empty, want failure reason")
+	}
+	if len(r.RunDurations) != 1 {
+		t.Errorf("len(RunDurations) = %d, want 1", len(r.RunDurations))
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
-	"strings"
 	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+	"oracle-n-plus-1-demo/internal/schema"
 )
 
 // PerformanceAnalyzer - キャッシュ性能分析ユーティリティ
@@ -16,6 +18,14 @@ type PerformanceAnalyzer struct {
 	resultCache       *OracleResultCache
 	analysisResults   *AnalysisResults
 	comparisonMetrics map[string]interface{}
+	// waitsBefore / waitErr - 分析開始時の待機イベント（取得できなかった場合はwaitErr）
+	waitsBefore map[string]WaitEventStat
+	waitErr     error
+	clock       clock.Clock
+	// backends - 計測に加える独自のキャッシュ実装（AddBackendで追加）
+	backends []namedBackend
+	// foreignKeyTables - 外部キーの索引を確認するテーブル（空の場合はスキーマのすべてのテーブル）
+	foreignKeyTables []string
 }
 
 // AnalysisResults - 統合分析結果
@@ -24,10 +34,18 @@ type AnalysisResults struct {
 	TestDuration          time.Duration          `json:"test_duration"`
 	OracleBufferMetrics   *BufferCacheMetrics    `json:"oracle_buffer_metrics"`
 	OracleResultMetrics   *ResultCacheMetrics    `json:"oracle_result_metrics"`
+	BufferCacheTest       *BufferCacheTest       `json:"buffer_cache_test,omitempty"`
+	ResultCacheTest       *ResultCacheTest       `json:"result_cache_test,omitempty"`
 	PerformanceComparison *PerformanceComparison `json:"performance_comparison"`
 	OptimizationAdvice    *OptimizationAdvice    `json:"optimization_advice"`
 	DetailedAnalysis      map[string]interface{} `json:"detailed_analysis"`
 	Recommendations       []Recommendation       `json:"recommendations"`
+	// Symptoms - 推奨事項の判定に使った観測結果
+	Symptoms *Symptoms `json:"symptoms"`
+	// ReadWriteMix - 読み書き混在ワークロードでの実効ヒット率と整合性（実行した場合のみ）
+	ReadWriteMix *ReadWriteMixResult `json:"read_write_mix,omitempty"`
+	// Backends - 独自のキャッシュ実装の計測結果（-cache-backends 指定時のみ）
+	Backends []BackendResult `json:"backends,omitempty"`
 }
 
 // PerformanceComparison - 性能比較結果
@@ -65,15 +83,27 @@ type OptimizationAdvice struct {
 	AvoidanceStrategies []string `json:"avoidance_strategies"`
 }
 
-// Recommendation - 推奨事項
+// Recommendation - 推奨事項（ツールから扱えるようルールIDと修正内容を含む）
 type Recommendation struct {
+	// RuleID - 推奨事項を導いた診断ルールの識別子（実行をまたいで安定）
+	RuleID      string   `json:"rule_id"`
 	Category    string   `json:"category"`
-	Priority    string   `json:"priority"`
+	Severity    Severity `json:"severity"`
 	Title       string   `json:"title"`
 	Description string   `json:"description"`
 	Impact      string   `json:"impact"`
 	Effort      string   `json:"effort"`
 	Benefits    []string `json:"benefits"`
+	// Evidence - ルールに該当した根拠（観測値）
+	Evidence string `json:"evidence,omitempty"`
+	// DocLinks - 説明の参照先ドキュメント
+	DocLinks []string `json:"doc_links,omitempty"`
+	// SuggestedSQL - 修正に使うSQL（終端の ; は含めない。PL/SQLブロックは END; まで）
+	SuggestedSQL []string `json:"suggested_sql,omitempty"`
+	// ParameterChanges - 推奨する初期化パラメータの変更
+	ParameterChanges []ParameterChange `json:"parameter_changes,omitempty"`
+	// AutoFixable - アプリケーションユーザーの権限で安全に自動適用できるか
+	AutoFixable bool `json:"auto_fixable"`
 }
 
 // NewPerformanceAnalyzer - 性能分析器を作成
@@ -83,66 +113,80 @@ func NewPerformanceAnalyzer(db *sql.DB) *PerformanceAnalyzer {
 		bufferCache:       NewOracleBufferCache(db),
 		resultCache:       NewOracleResultCache(db),
 		comparisonMetrics: make(map[string]interface{}),
+		clock:             clock.System,
+		foreignKeyTables:  schema.DemoTableNames(),
 	}
 }
 
-// PerformComprehensiveAnalysis - 包括的なキャッシュ性能分析を実行
-func (pa *PerformanceAnalyzer) PerformComprehensiveAnalysis(runs int) (*AnalysisResults, error) {
-	fmt.Println("\\n=== Oracle内蔵キャッシュ包括的性能分析 ===")
-	fmt.Printf("実行回数: %d回\\n", runs)
-	fmt.Println("分析項目: Buffer Cache, Result Cache, 統合効率性, 外部キャッシュ比較")
-	fmt.Println(strings.Repeat("=", 70))
+// SetClock - 計測に使う時計を差し替える（Buffer Cache・Result Cacheの計測にも使う）
+func (pa *PerformanceAnalyzer) SetClock(c clock.Clock) {
+	pa.clock = c
+	pa.bufferCache.clock = c
+	pa.resultCache.clock = c
+}
+
+// SetForeignKeyScope - 外部キーの索引の確認をデモのテーブルからスキーマのすべてのテーブルに広げる
+func (pa *PerformanceAnalyzer) SetForeignKeyScope(allTables bool) {
+	if allTables {
+		pa.foreignKeyTables = nil
+	} else {
+		pa.foreignKeyTables = schema.DemoTableNames()
+	}
+}
 
-	startTime := time.Now()
+// PerformComprehensiveAnalysis - 包括的なキャッシュ性能分析を実行（表示は行わない）
+func (pa *PerformanceAnalyzer) PerformComprehensiveAnalysis(runs int) (*AnalysisResults, error) {
+	startTime := pa.clock.Now()
+	pa.waitsBefore, pa.waitErr = collectWaitEvents(pa.db)
 
 	// 1. Buffer Cacheの詳細分析
-	fmt.Println("\\n1. Database Buffer Cache分析中...")
-	bufferMetrics, err := pa.bufferCache.TestBufferCachePerformance(runs)
+	bufferTest, err := pa.bufferCache.TestBufferCachePerformance(runs)
 	if err != nil {
 		return nil, fmt.Errorf("buffer cache分析エラー: %w", err)
 	}
 
 	// 2. Result Cacheの詳細分析
-	fmt.Println("\\n2. Server Result Cache分析中...")
-	resultMetrics, err := pa.resultCache.TestResultCachePerformance(runs)
+	resultTest, err := pa.resultCache.TestResultCachePerformance(runs)
 	if err != nil {
 		return nil, fmt.Errorf("result cache分析エラー: %w", err)
 	}
 
-	// 3. 統合分析の実行
-	fmt.Println("\\n3. 統合性能分析中...")
+	// 3. 独自のキャッシュ実装を同じ回数計測
+	backends := pa.MeasureBackends(runs)
+
+	// 4. 統合分析の実行
 	analysisResults := &AnalysisResults{
 		TestDate:            startTime,
-		TestDuration:        time.Since(startTime),
-		OracleBufferMetrics: bufferMetrics,
-		OracleResultMetrics: resultMetrics,
+		TestDuration:        pa.clock.Since(startTime),
+		OracleBufferMetrics: bufferTest.Delta,
+		OracleResultMetrics: resultTest.Delta,
+		BufferCacheTest:     bufferTest,
+		ResultCacheTest:     resultTest,
+		Backends:            backends,
 	}
 
-	// 4. 性能比較とリソース効率性の計算
+	// 5. 性能比較とリソース効率性の計算
 	if err := pa.calculatePerformanceComparison(analysisResults); err != nil {
 		return nil, fmt.Errorf("性能比較計算エラー: %w", err)
 	}
 
-	// 5. 最適化アドバイスの生成
+	// 6. 最適化アドバイスの生成
 	if err := pa.generateOptimizationAdvice(analysisResults); err != nil {
 		return nil, fmt.Errorf("最適化アドバイス生成エラー: %w", err)
 	}
 
-	// 6. 詳細分析の実行
+	// 7. 詳細分析の実行
 	if err := pa.performDetailedAnalysis(analysisResults); err != nil {
 		return nil, fmt.Errorf("詳細分析エラー: %w", err)
 	}
 
-	// 7. 推奨事項の生成
+	// 8. 推奨事項の生成
 	if err := pa.generateRecommendations(analysisResults); err != nil {
 		return nil, fmt.Errorf("推奨事項生成エラー: %w", err)
 	}
 
 	pa.analysisResults = analysisResults
 
-	// 8. 最終結果の表示
-	pa.DisplayComprehensiveResults()
-
 	return analysisResults, nil
 }
 
@@ -388,199 +432,51 @@ func (pa *PerformanceAnalyzer) analyzeScalability(_ *AnalysisResults) map[string
 	}
 }
 
-// generateRecommendations - 推奨事項を生成
+// generateRecommendations - 観測結果を診断ルールに照らして推奨事項を生成
 func (pa *PerformanceAnalyzer) generateRecommendations(results *AnalysisResults) error {
-	var recommendations []Recommendation
-
-	// Buffer Cache関連推奨事項
-	if results.OracleBufferMetrics.HitRatio < 90 {
-		recommendations = append(recommendations, Recommendation{
-			Category:    "Buffer Cache最適化",
-			Priority:    "高",
-			Title:       "Buffer Cacheサイズの最適化",
-			Description: "Buffer Cacheヒット率が90%未満のため、サイズ調整を検討",
-			Impact:      "I/O削減による大幅な性能向上",
-			Effort:      "低（パラメータ調整のみ）",
-			Benefits:    []string{"物理I/O削減", "レスポンス時間向上", "CPU使用率改善"},
-		})
-	}
-
-	// Result Cache関連推奨事項
-	if results.OracleResultMetrics.HitRatio < 70 {
-		recommendations = append(recommendations, Recommendation{
-			Category:    "Result Cache活用",
-			Priority:    "中",
-			Title:       "RESULT_CACHEヒントの積極活用",
-			Description: "集計クエリにRESULT_CACHEヒントを追加して効率化",
-			Impact:      "複雑クエリの大幅な高速化",
-			Effort:      "中（SQL修正が必要）",
-			Benefits:    []string{"集計処理高速化", "CPU負荷軽減", "同時実行性向上"},
-		})
-	}
-
-	// 外部キャッシュ見直し推奨事項
-	recommendations = append(recommendations, Recommendation{
-		Category:    "アーキテクチャ最適化",
-		Priority:    "高",
-		Title:       "外部キャッシュ依存度の削減",
-		Description: "Oracle内蔵キャッシュを最大限活用し、外部キャッシュ依存を削減",
-		Impact:      "システム複雑性の削減と運用性向上",
-		Effort:      "高（アーキテクチャ変更）",
-		Benefits:    []string{"システム複雑性削減", "運用コスト削減", "データ整合性向上", "レイテンシ削減"},
-	})
+	symptoms := pa.collectSymptoms(results)
+	recommendations := Diagnose(symptoms)
 
-	// N+1問題根本解決推奨事項
-	recommendations = append(recommendations, Recommendation{
-		Category:    "SQL最適化",
-		Priority:    "最高",
-		Title:       "N+1問題の根本的解決",
-		Description: "JOINやIN句を使用してN+1問題を根本から解決",
-		Impact:      "クエリ実行回数の劇的削減",
-		Effort:      "中（SQL設計見直し）",
-		Benefits:    []string{"実行時間短縮", "データベース負荷軽減", "スケーラビリティ向上"},
-	})
-
-	// 優先度でソート
-	sort.Slice(recommendations, func(i, j int) bool {
-		priorities := map[string]int{"最高": 4, "高": 3, "中": 2, "低": 1}
-		return priorities[recommendations[i].Priority] > priorities[recommendations[j].Priority]
+	// 重要度でソート
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Severity.Rank() > recommendations[j].Severity.Rank()
 	})
 
+	results.Symptoms = symptoms
 	results.Recommendations = recommendations
 	return nil
 }
 
-// DisplayComprehensiveResults - 包括的結果を表示
-func (pa *PerformanceAnalyzer) DisplayComprehensiveResults() {
-	if pa.analysisResults == nil {
-		fmt.Println("分析結果がありません")
-		return
-	}
-
-	results := pa.analysisResults
-
-	fmt.Println("\\n" + strings.Repeat("=", 80))
-	fmt.Println("Oracle内蔵キャッシュ vs 外部キャッシュ 包括的分析結果")
-	fmt.Println(strings.Repeat("=", 80))
-
-	// 1. エグゼクティブサマリー
-	pa.displayExecutiveSummary(results)
-
-	// 2. 性能メトリクス比較
-	pa.displayPerformanceMetrics(results)
-
-	// 3. 効率性分析
-	pa.displayEfficiencyAnalysis(results)
-
-	// 4. 推奨事項
-	pa.displayRecommendations(results)
-
-	// 5. 結論
-	pa.displayConclusion(results)
-}
-
-// displayExecutiveSummary - エグゼクティブサマリーを表示
-func (pa *PerformanceAnalyzer) displayExecutiveSummary(results *AnalysisResults) {
-	fmt.Println("\\n■ エグゼクティブサマリー")
-	fmt.Println(strings.Repeat("-", 50))
-
-	efficiency := results.PerformanceComparison.EfficiencyMetrics.OverallCacheEfficiency
-
-	if efficiency >= 90 {
-		fmt.Println("✅ 総合評価: 優秀（90%以上の効率性）")
-		fmt.Println("   Oracle内蔵キャッシュが効果的に機能しています")
-	} else if efficiency >= 70 {
-		fmt.Println("⚠️  総合評価: 良好（70-90%の効率性）")
-		fmt.Println("   改善の余地がありますが、基本的な機能は正常です")
+// collectSymptoms - メトリクス・待機イベント・分析中のエラーから症状をまとめる
+func (pa *PerformanceAnalyzer) collectSymptoms(results *AnalysisResults) *Symptoms {
+	symptoms := &Symptoms{
+		BufferHitRatio:      results.OracleBufferMetrics.HitRatio,
+		ResultHitRatio:      results.OracleResultMetrics.HitRatio,
+		ResultCreated:       results.OracleResultMetrics.CreatedObjects,
+		ResultInvalidations: results.OracleResultMetrics.InvalidatedObjects,
+		ResultMemoryBytes:   results.OracleResultMetrics.MemoryUsage,
+		FreeBufferWaits:     results.OracleBufferMetrics.FreeBufferWaits,
+		BufferBusyWaits:     results.OracleBufferMetrics.BufferBusyWaits,
+	}
+
+	// V$ビューの権限がない場合もエラーコードを症状として扱う
+	if pa.waitErr != nil {
+		symptoms.RecordError(pa.waitErr)
+	} else if waitsAfter, err := collectWaitEvents(pa.db); err != nil {
+		symptoms.RecordError(err)
 	} else {
-		fmt.Println("❌ 総合評価: 要改善（70%未満の効率性）")
-		fmt.Println("   早急な最適化が必要です")
-	}
-
-	fmt.Printf("\\n• 総合キャッシュ効率: %.1f%%\\n", efficiency)
-	fmt.Printf("• Buffer Cache効率: %.1f%%\\n", results.PerformanceComparison.EfficiencyMetrics.BufferCacheEfficiency)
-	fmt.Printf("• Result Cache効率: %.1f%%\\n", results.PerformanceComparison.EfficiencyMetrics.ResultCacheEfficiency)
-	fmt.Printf("• 総キャッシュメモリ: %.1f MB\\n", results.PerformanceComparison.ResourceUtilization.TotalCacheMemoryMB)
-	fmt.Printf("• 推定I/O削減: %d回\\n", results.PerformanceComparison.ResourceUtilization.EstimatedIOSavings)
-}
-
-// displayPerformanceMetrics - 性能メトリクス比較を表示
-func (pa *PerformanceAnalyzer) displayPerformanceMetrics(results *AnalysisResults) {
-	fmt.Println("\\n■ Oracle内蔵キャッシュ vs 外部キャッシュ 比較")
-	fmt.Println(strings.Repeat("-", 50))
-
-	fmt.Println("\\n✅ Oracle内蔵キャッシュの優位性:")
-	for _, advantage := range results.PerformanceComparison.OracleAdvantages {
-		fmt.Printf("   %s\\n", advantage)
+		symptoms.Waits = diffWaitEvents(pa.waitsBefore, waitsAfter)
 	}
-
-	fmt.Println("\\n❌ 外部キャッシュの課題:")
-	for _, issue := range results.PerformanceComparison.ExternalCacheIssues {
-		fmt.Printf("   %s\\n", issue)
+	for _, err := range pa.bufferCache.Errors() {
+		symptoms.RecordError(err)
 	}
-}
-
-// displayEfficiencyAnalysis - 効率性分析を表示
-func (pa *PerformanceAnalyzer) displayEfficiencyAnalysis(results *AnalysisResults) {
-	fmt.Println("\\n■ リソース効率性分析")
-	fmt.Println(strings.Repeat("-", 50))
-
-	util := results.PerformanceComparison.ResourceUtilization
-
-	fmt.Printf("\\n💾 メモリ使用効率:\\n")
-	fmt.Printf("   • 総キャッシュメモリ: %.1f MB\\n", util.TotalCacheMemoryMB)
-	fmt.Printf("   • Buffer Cache: %.1f MB\\n", util.BufferCacheUtilization)
-	fmt.Printf("   • Result Cache: %.1f MB\\n", util.ResultCacheUtilization)
-
-	fmt.Printf("\\n⚡ 性能向上効果:\\n")
-	fmt.Printf("   • I/O削減回数: %d回\\n", util.EstimatedIOSavings)
-	fmt.Printf("   • 推定CPU削減: %.1f%%\\n", util.EstimatedCPUSavings)
-	fmt.Printf("   • メモリ効率比: %.2f\\n", results.PerformanceComparison.EfficiencyMetrics.MemoryEfficiencyRatio)
-}
-
-// displayRecommendations - 推奨事項を表示
-func (pa *PerformanceAnalyzer) displayRecommendations(results *AnalysisResults) {
-	fmt.Println("\\n■ 推奨事項（優先度順）")
-	fmt.Println(strings.Repeat("-", 50))
-
-	for i, rec := range results.Recommendations {
-		fmt.Printf("\\n%d. [%s優先度] %s\\n", i+1, rec.Priority, rec.Title)
-		fmt.Printf("   カテゴリ: %s\\n", rec.Category)
-		fmt.Printf("   説明: %s\\n", rec.Description)
-		fmt.Printf("   期待効果: %s\\n", rec.Impact)
-		fmt.Printf("   実装工数: %s\\n", rec.Effort)
-		if len(rec.Benefits) > 0 {
-			fmt.Printf("   利益: %s\\n", strings.Join(rec.Benefits, ", "))
-		}
-	}
-}
-
-// displayConclusion - 結論を表示
-func (pa *PerformanceAnalyzer) displayConclusion(results *AnalysisResults) {
-	fmt.Println("\\n■ 結論とNext Steps")
-	fmt.Println(strings.Repeat("-", 50))
-
-	fmt.Println("\\n🎯 重要な結論:")
-	fmt.Println("   1. Oracle内蔵キャッシュメカニズムは外部キャッシュよりも効率的")
-	fmt.Println("   2. N+1問題は根本的なSQL設計で解決すべき")
-	fmt.Println("   3. 外部キャッシュは複雑性を増加させ運用コストを高める")
-	fmt.Println("   4. データ整合性はOracleの自動機能に任せるべき")
-
-	fmt.Printf("\\n📊 今回の分析結果: %s\\n", results.OptimizationAdvice.PriorityLevel)
-
-	fmt.Println("\\n🚀 Next Steps:")
-	if len(results.OptimizationAdvice.ImmediateActions) > 0 {
-		fmt.Println("   即座の対応:")
-		for _, action := range results.OptimizationAdvice.ImmediateActions {
-			fmt.Printf("     • %s\\n", action)
-		}
+	if keys, err := schema.UnindexedForeignKeys(pa.db, pa.foreignKeyTables); err != nil {
+		symptoms.RecordError(err)
+	} else {
+		symptoms.UnindexedForeignKeys = keys
 	}
 
-	fmt.Println("\\n💡 長期的な方向性:")
-	fmt.Println("   → Oracle Database中心のアーキテクチャ採用")
-	fmt.Println("   → 外部キャッシュ依存度の段階的削減")
-	fmt.Println("   → SQL最適化による根本的問題解決")
-	fmt.Println("   → 運用性とメンテナンス性の向上")
+	return symptoms
 }
 
 // ExportAnalysisResults - 分析結果をJSONでエクスポート
@@ -0,0 +1,470 @@
+package cache
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/schema"
+	"oracle-n-plus-1-demo/internal/sqlutil"
+)
+
+// 参照先ドキュメント
+const (
+	docWaitEvents  = "https://docs.oracle.com/en/database/oracle/oracle-database/19/refrn/descriptions-of-wait-events.html"
+	docTuningGuide = "https://docs.oracle.com/en/database/oracle/oracle-database/19/tgdba/"
+	docSQLTuning   = "https://docs.oracle.com/en/database/oracle/oracle-database/19/tgsql/"
+	docForeignKeys = "https://docs.oracle.com/en/database/oracle/oracle-database/19/cncpt/data-integrity.html"
+)
+
+// minDiagnosticWaits - 待機イベントを症状とみなす最小待機回数（偶発的な待機を除外）
+const minDiagnosticWaits = 100
+
+// oraCodePattern - エラーメッセージからORAエラーコードを抽出
+var oraCodePattern = regexp.MustCompile(`ORA-\d{5}`)
+
+// WaitEventStat - 待機イベントの統計
+type WaitEventStat struct {
+	TotalWaits int64         `json:"total_waits"`
+	TimeWaited time.Duration `json:"time_waited"`
+}
+
+// AverageWait - 1回あたりの平均待機時間
+func (w WaitEventStat) AverageWait() time.Duration {
+	if w.TotalWaits == 0 {
+		return 0
+	}
+	return w.TimeWaited / time.Duration(w.TotalWaits)
+}
+
+// Symptoms - 診断ルールの入力となる観測結果
+type Symptoms struct {
+	BufferHitRatio      float64                  `json:"buffer_hit_ratio"`
+	ResultHitRatio      float64                  `json:"result_hit_ratio"`
+	ResultCreated       int64                    `json:"result_created"`
+	ResultInvalidations int64                    `json:"result_invalidations"`
+	ResultMemoryBytes   int64                    `json:"result_memory_bytes"`
+	FreeBufferWaits     int64                    `json:"free_buffer_waits"`
+	BufferBusyWaits     int64                    `json:"buffer_busy_waits"`
+	Waits               map[string]WaitEventStat `json:"waits,omitempty"`
+	// ErrorCodes - 分析中に観測したORAエラーコード
+	ErrorCodes []string `json:"error_codes,omitempty"`
+	// UnindexedForeignKeys - 列を先頭に持つ索引がない外部キー
+	UnindexedForeignKeys []schema.ForeignKey `json:"unindexed_foreign_keys,omitempty"`
+}
+
+// HasError - 指定したORAエラーを観測したか
+func (s *Symptoms) HasError(codes ...string) (string, bool) {
+	for _, observed := range s.ErrorCodes {
+		for _, code := range codes {
+			if observed == code {
+				return observed, true
+			}
+		}
+	}
+	return "", false
+}
+
+// RecordError - エラーメッセージに含まれるORAエラーコードを記録
+func (s *Symptoms) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	for _, code := range oraCodePattern.FindAllString(err.Error(), -1) {
+		if _, ok := s.HasError(code); !ok {
+			s.ErrorCodes = append(s.ErrorCodes, code)
+		}
+	}
+}
+
+// diagnosticRule - 症状と説明・推奨事項の対応
+type diagnosticRule struct {
+	// waitEvent - ルールが参照する待機イベント（待機イベントのルールのみ）
+	waitEvent string
+	// match - 症状に該当する場合は根拠となる観測値を返す
+	match          func(s *Symptoms) (evidence string, ok bool)
+	recommendation Recommendation
+	// suggest - 観測結果から修正に使うSQLを作る（SQLが症状によって変わるルールのみ）
+	suggest func(s *Symptoms) []string
+}
+
+// errorRule - ORAエラーコードに対応するルール
+func errorRule(rec Recommendation, codes ...string) diagnosticRule {
+	for _, code := range codes {
+		rec.DocLinks = append(rec.DocLinks, "https://docs.oracle.com/error-help/db/"+strings.ToLower(code)+"/")
+	}
+	return diagnosticRule{
+		match: func(s *Symptoms) (string, bool) {
+			code, ok := s.HasError(codes...)
+			return fmt.Sprintf("%s を観測", code), ok
+		},
+		recommendation: rec,
+	}
+}
+
+// waitRule - 待機イベントに対応するルール（minDiagnosticWaits回以上の待機で該当）
+func waitRule(event string, rec Recommendation) diagnosticRule {
+	rec.DocLinks = append(rec.DocLinks, docWaitEvents)
+	return diagnosticRule{
+		waitEvent: event,
+		match: func(s *Symptoms) (string, bool) {
+			w, ok := s.Waits[event]
+			if !ok || w.TotalWaits < minDiagnosticWaits {
+				return "", false
+			}
+			return fmt.Sprintf("%s: %d回（平均%v）", event, w.TotalWaits, w.AverageWait()), true
+		},
+		recommendation: rec,
+	}
+}
+
+// always - 観測結果によらず提示するルール
+func always(evidence string) func(*Symptoms) (string, bool) {
+	return func(*Symptoms) (string, bool) { return evidence, true }
+}
+
+// diagnosticRules - 診断ルール（上から順に評価し、該当したものをすべて推奨事項にする）
+var diagnosticRules = []diagnosticRule{
+	// ORAエラーコード
+	errorRule(Recommendation{
+		RuleID:       "ora-view-privilege",
+		Category:     "権限",
+		Severity:     SeverityHigh,
+		Title:        "V$ビューの参照権限を付与する",
+		Description:  "統計ビューを参照できないため、キャッシュ効果を実行時間からしか判定できません。SELECT_CATALOG_ROLE または個別のV$ビューへのSELECT権限を付与してください",
+		Impact:       "ヒット率・待機イベントに基づく診断が可能になる",
+		Effort:       "低（GRANTのみ）",
+		Benefits:     []string{"根拠のある診断", "待機イベントの把握"},
+		SuggestedSQL: []string{"GRANT SELECT_CATALOG_ROLE TO &app_user"},
+	}, "ORA-00942", "ORA-01031"),
+	errorRule(Recommendation{
+		RuleID:           "ora-open-cursors",
+		Category:         "カーソル管理",
+		Severity:         SeverityCritical,
+		Title:            "オープンカーソルのリークを解消する",
+		Description:      "OPEN_CURSORSの上限に達しました。N+1でループ内のrows.Close()が漏れていると、クエリ回数に比例してカーソルが残ります",
+		Impact:           "実行中のエラー停止を防ぐ",
+		Effort:           "低（deferでのClose徹底）",
+		Benefits:         []string{"エラー防止", "共有プール・PGAの節約"},
+		ParameterChanges: []ParameterChange{{Name: "open_cursors", Value: "1000", Note: "リークの解消までの暫定対応"}},
+	}, "ORA-01000"),
+	errorRule(Recommendation{
+		RuleID:           "ora-shared-pool-memory",
+		Category:         "共有プール",
+		Severity:         SeverityCritical,
+		Title:            "リテラルSQLをバインド変数に置き換える",
+		Description:      "共有プールのメモリを確保できませんでした。リテラルを埋め込んだN+1は値ごとに別カーソルを作り、共有プールを断片化させます（-sharedpool-only で再現できます）",
+		Impact:           "ハードパースと共有プール消費の削減",
+		Effort:           "中（SQL修正）",
+		Benefits:         []string{"ハードパース削減", "ラッチ・ミューテックス競合の解消"},
+		ParameterChanges: []ParameterChange{{Name: "cursor_sharing", Value: "FORCE", Note: "SQL修正までの暫定対応（実行計画が変わる可能性あり）"}},
+	}, "ORA-04031"),
+	errorRule(Recommendation{
+		RuleID:      "ora-snapshot-too-old",
+		Category:    "読み取り一貫性",
+		Severity:    SeverityHigh,
+		Title:       "長時間のループ処理を短くする",
+		Description: "UNDOが上書きされ読み取り一貫性を保てませんでした。N+1で1件ずつ処理するとカーソルを開いている時間が長くなり発生しやすくなります",
+		Impact:      "長時間処理の失敗防止",
+		Effort:      "中（一括取得への変更）",
+		Benefits:    []string{"処理時間短縮", "UNDO保持期間の要求緩和"},
+	}, "ORA-01555"),
+	errorRule(Recommendation{
+		RuleID:      "ora-connectivity",
+		Category:    "接続",
+		Severity:    SeverityCritical,
+		Title:       "データベースへの接続経路を確認する",
+		Description: "リスナーまたはネットワークに到達できませんでした。ホスト・ポート・サービス名とファイアウォール設定を確認してください",
+		Impact:      "計測の前提条件",
+		Effort:      "低",
+		Benefits:    []string{"計測の再実行が可能になる"},
+	}, "ORA-12170", "ORA-12541", "ORA-12514"),
+	errorRule(Recommendation{
+		RuleID:      "ora-deadlock",
+		Category:    "ロック",
+		Severity:    SeverityHigh,
+		Title:       "更新順序を統一してデッドロックを防ぐ",
+		Description: "デッドロックを検出しました。1件ずつ更新するループで行の処理順序がセッションごとに異なると発生します",
+		Impact:      "トランザクションのロールバック防止",
+		Effort:      "中",
+		Benefits:    []string{"エラー率低下", "スループット安定化"},
+	}, "ORA-00060"),
+
+	// 待機イベント
+	waitRule("db file sequential read", Recommendation{
+		RuleID:       "wait-db-file-sequential-read",
+		Category:     "I/O",
+		Severity:     SeverityHigh,
+		Title:        "単一ブロック読み取りの多さを確認する",
+		Description:  "索引経由の1ブロックずつの読み取りが多く発生しています。N+1で主キー検索を繰り返すと増えるため、一括取得でまとめて読むか、クラスタリング・ファクターを確認してください",
+		Impact:       "物理I/Oの削減",
+		Effort:       "中",
+		Benefits:     []string{"物理I/O削減", "レスポンス時間安定化"},
+		SuggestedSQL: []string{"BEGIN DBMS_STATS.GATHER_SCHEMA_STATS(USER); END;"},
+		AutoFixable:  true,
+	}),
+	waitRule("free buffer waits", Recommendation{
+		RuleID:      "wait-free-buffer-waits",
+		Category:    "Buffer Cache最適化",
+		Severity:    SeverityHigh,
+		Title:       "Buffer CacheサイズまたはDBWRの書き込み能力を見直す",
+		Description: "空きバッファを確保できずに待機しています。Buffer Cacheが小さいか、DBWRの書き込みが追いついていません",
+		Impact:      "書き込み負荷時の待機解消",
+		Effort:      "低（パラメータ調整）",
+		Benefits:    []string{"待機時間削減"},
+	}),
+	waitRule("buffer busy waits", Recommendation{
+		RuleID:      "wait-buffer-busy-waits",
+		Category:    "Buffer Cache最適化",
+		Severity:    SeverityMedium,
+		Title:       "ホットブロックを分散する",
+		Description: "同じブロックへの同時アクセスで待機しています。ループ内の1件ずつの更新が同じブロックに集中していないか確認してください",
+		Impact:      "同時実行性の向上",
+		Effort:      "中",
+		Benefits:    []string{"競合削減"},
+	}),
+	waitRule("library cache: mutex X", Recommendation{
+		RuleID:           "wait-library-cache-mutex",
+		Category:         "共有プール",
+		Severity:         SeverityHigh,
+		Title:            "ハードパースを減らしてライブラリキャッシュ競合を解消する",
+		Description:      "ライブラリキャッシュのミューテックス待機が発生しています。リテラルを埋め込んだSQLを並行実行するとハードパースが集中します",
+		Impact:           "並行実行時のスループット向上",
+		Effort:           "中（バインド変数化）",
+		Benefits:         []string{"ハードパース削減", "CPU使用率低下"},
+		ParameterChanges: []ParameterChange{{Name: "cursor_sharing", Value: "FORCE", Note: "SQL修正までの暫定対応（実行計画が変わる可能性あり）"}},
+		DocLinks:         []string{docTuningGuide},
+	}),
+	waitRule("cursor: pin S wait on X", Recommendation{
+		RuleID:      "wait-cursor-pin-s",
+		Category:    "共有プール",
+		Severity:    SeverityHigh,
+		Title:       "同一SQLの同時ハードパースを避ける",
+		Description: "他セッションがハードパース中のカーソルを待っています。統計情報の更新直後やリテラルSQLの大量実行で発生します",
+		Impact:      "並行実行時の待機解消",
+		Effort:      "中",
+		Benefits:    []string{"パース待機削減"},
+	}),
+	waitRule("log file sync", Recommendation{
+		RuleID:      "wait-log-file-sync",
+		Category:    "コミット",
+		Severity:    SeverityMedium,
+		Title:       "ループ内のコミットをまとめる",
+		Description: "コミットごとのREDO書き込みを待っています。1件ずつ更新してコミットする処理は配列処理でまとめてください",
+		Impact:      "更新処理の高速化",
+		Effort:      "中",
+		Benefits:    []string{"REDO書き込み回数削減"},
+	}),
+
+	// 比率・件数
+	{
+		match: func(s *Symptoms) (string, bool) {
+			return fmt.Sprintf("Buffer Cacheヒット率 %.1f%%", s.BufferHitRatio), s.BufferHitRatio < 90
+		},
+		recommendation: Recommendation{
+			RuleID:           "ratio-buffer-cache-hit",
+			Category:         "Buffer Cache最適化",
+			Severity:         SeverityHigh,
+			Title:            "Buffer Cacheサイズの最適化",
+			Description:      "Buffer Cacheヒット率が90%未満のため、サイズ調整を検討",
+			Impact:           "I/O削減による大幅な性能向上",
+			Effort:           "低（パラメータ調整のみ）",
+			Benefits:         []string{"物理I/O削減", "レスポンス時間向上", "CPU使用率改善"},
+			ParameterChanges: []ParameterChange{{Name: "db_cache_size", Value: "<V$DB_CACHE_ADVICEで物理読み取りが下げ止まるサイズ>", Note: "ASMM/AMM使用時は最小値として扱われる"}},
+			DocLinks:         []string{docTuningGuide},
+		},
+	},
+	{
+		match: func(s *Symptoms) (string, bool) {
+			return fmt.Sprintf("Result Cacheヒット率 %.1f%%", s.ResultHitRatio), s.ResultHitRatio < 70
+		},
+		recommendation: Recommendation{
+			RuleID:      "ratio-result-cache-hit",
+			Category:    "Result Cache活用",
+			Severity:    SeverityMedium,
+			Title:       "RESULT_CACHEヒントの積極活用",
+			Description: "集計クエリにRESULT_CACHEヒントを追加して効率化",
+			Impact:      "複雑クエリの大幅な高速化",
+			Effort:      "中（SQL修正が必要）",
+			Benefits:    []string{"集計処理高速化", "CPU負荷軽減", "同時実行性向上"},
+			DocLinks:    []string{docTuningGuide},
+		},
+	},
+	{
+		match: func(s *Symptoms) (string, bool) {
+			return fmt.Sprintf("作成 %d件に対して無効化 %d件", s.ResultCreated, s.ResultInvalidations),
+				s.ResultCreated > 0 && s.ResultInvalidations > s.ResultCreated/2
+		},
+		recommendation: Recommendation{
+			RuleID:      "result-cache-invalidation",
+			Category:    "Result Cache活用",
+			Severity:    SeverityMedium,
+			Title:       "更新頻度の高いテーブルへのResult Cache使用を見直す",
+			Description: "キャッシュした結果の多くが依存表の更新で無効化されています",
+			Impact:      "無効なキャッシュ作成コストの削減",
+			Effort:      "低（ヒントの削除）",
+			Benefits:    []string{"共有プール消費削減"},
+		},
+	},
+	{
+		match: func(s *Symptoms) (string, bool) {
+			return fmt.Sprintf("Result Cacheメモリ %.1f MB", float64(s.ResultMemoryBytes)/(1024*1024)),
+				s.ResultMemoryBytes > 100*1024*1024
+		},
+		recommendation: Recommendation{
+			RuleID:           "result-cache-memory",
+			Category:         "Result Cache活用",
+			Severity:         SeverityLow,
+			Title:            "RESULT_CACHE_MAX_SIZEを見直す",
+			Description:      "Result Cacheのメモリ使用量が大きくなっています",
+			Impact:           "共有プールの圧迫防止",
+			Effort:           "低（パラメータ調整）",
+			Benefits:         []string{"メモリ使用量の適正化"},
+			ParameterChanges: []ParameterChange{{Name: "result_cache_max_size", Value: "<現在の使用量に合わせたサイズ>"}},
+		},
+	},
+
+	// スキーマ
+	{
+		match: func(s *Symptoms) (string, bool) {
+			keys := make([]string, len(s.UnindexedForeignKeys))
+			for i, fk := range s.UnindexedForeignKeys {
+				keys[i] = fk.String()
+			}
+			return "索引のない外部キー: " + strings.Join(keys, ", "), len(keys) > 0
+		},
+		suggest: func(s *Symptoms) []string {
+			stmts := make([]string, len(s.UnindexedForeignKeys))
+			for i, fk := range s.UnindexedForeignKeys {
+				stmts[i] = fk.IndexStatement()
+			}
+			return stmts
+		},
+		recommendation: Recommendation{
+			RuleID:      "schema-unindexed-foreign-key",
+			Category:    "索引",
+			Severity:    SeverityHigh,
+			Title:       "外部キーの列に索引を作成する",
+			Description: "外部キーの列を先頭に持つ索引がありません。親の行ごとに子表を引くN+1のループは、1回ごとに子表を全件走査します。親の行の削除や主キーの更新でも子表全体がロックされ、同時の更新が待たされます",
+			Impact:      "子表の検索の高速化とロック競合の解消",
+			Effort:      "低（索引の作成のみ）",
+			Benefits:    []string{"全表走査の削減", "表ロック（TM）の競合防止"},
+			DocLinks:    []string{docForeignKeys},
+		},
+	},
+
+	// 常に提示する基本方針
+	{
+		match: always("デモの前提"),
+		recommendation: Recommendation{
+			RuleID:      "baseline-external-cache",
+			Category:    "アーキテクチャ最適化",
+			Severity:    SeverityHigh,
+			Title:       "外部キャッシュ依存度の削減",
+			Description: "Oracle内蔵キャッシュを最大限活用し、外部キャッシュ依存を削減",
+			Impact:      "システム複雑性の削減と運用性向上",
+			Effort:      "高（アーキテクチャ変更）",
+			Benefits:    []string{"システム複雑性削減", "運用コスト削減", "データ整合性向上", "レイテンシ削減"},
+		},
+	},
+	{
+		match: always("デモの前提"),
+		recommendation: Recommendation{
+			RuleID:      "baseline-n-plus-1",
+			Category:    "SQL最適化",
+			Severity:    SeverityCritical,
+			Title:       "N+1問題の根本的解決",
+			Description: "JOINやIN句を使用してN+1問題を根本から解決",
+			Impact:      "クエリ実行回数の劇的削減",
+			Effort:      "中（SQL設計見直し）",
+			Benefits:    []string{"実行時間短縮", "データベース負荷軽減", "スケーラビリティ向上"},
+			DocLinks:    []string{docSQLTuning},
+		},
+	},
+}
+
+// Diagnose - 症状に該当するルールの推奨事項を返す
+func Diagnose(s *Symptoms) []Recommendation {
+	var recommendations []Recommendation
+	for _, rule := range diagnosticRules {
+		evidence, ok := rule.match(s)
+		if !ok {
+			continue
+		}
+		rec := rule.recommendation
+		rec.Evidence = evidence
+		if rule.suggest != nil {
+			rec.SuggestedSQL = rule.suggest(s)
+		}
+		recommendations = append(recommendations, rec)
+	}
+	return recommendations
+}
+
+// autoFixRecommendation - RuleIDが自動適用できる組み込みルールの推奨事項を返す
+//
+// 分析結果のJSONは共有されるファイルのため、自動適用する文は記録されたSQLではなく組み込みのルールから作る。
+// 症状によって文が変わるルール（suggest）は自動適用しない。
+func autoFixRecommendation(ruleID string) (Recommendation, bool) {
+	for _, rule := range diagnosticRules {
+		rec := rule.recommendation
+		if rec.RuleID == ruleID {
+			return rec, rec.AutoFixable && rule.suggest == nil
+		}
+	}
+	return Recommendation{}, false
+}
+
+// diagnosticWaitEvents - ルールが参照する待機イベント
+func diagnosticWaitEvents() []string {
+	var events []string
+	for _, rule := range diagnosticRules {
+		if rule.waitEvent != "" {
+			events = append(events, rule.waitEvent)
+		}
+	}
+	return events
+}
+
+// diffWaitEvents - 待機イベントの累積値の差分
+func diffWaitEvents(before, after map[string]WaitEventStat) map[string]WaitEventStat {
+	diff := make(map[string]WaitEventStat)
+	for event, a := range after {
+		b := before[event]
+		diff[event] = WaitEventStat{TotalWaits: a.TotalWaits - b.TotalWaits, TimeWaited: a.TimeWaited - b.TimeWaited}
+	}
+	return diff
+}
+
+// collectWaitEvents - ルールが参照する待機イベントの累積値を取得
+func collectWaitEvents(db *sql.DB) (map[string]WaitEventStat, error) {
+	events := diagnosticWaitEvents()
+	query := fmt.Sprintf(`
+		SELECT event, total_waits, time_waited_micro
+		FROM v$system_event
+		WHERE event IN (%s)`, sqlutil.Placeholders(len(events)))
+
+	rows, err := db.Query(query, sqlutil.StringArgs(events)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v$system_event: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	waits := make(map[string]WaitEventStat)
+	for rows.Next() {
+		var event string
+		var totalWaits, micros int64
+		if err := rows.Scan(&event, &totalWaits, &micros); err != nil {
+			return nil, fmt.Errorf("failed to scan v$system_event row: %w", err)
+		}
+		waits[event] = WaitEventStat{TotalWaits: totalWaits, TimeWaited: time.Duration(micros) * time.Microsecond}
+	}
+
+	return waits, rows.Err()
+}
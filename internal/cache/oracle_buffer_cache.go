@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
 )
 
 // BufferCacheMetrics - Buffer Cache性能メトリクス
@@ -20,10 +22,66 @@ type BufferCacheMetrics struct {
 	DbBlockGets       int64         `json:"db_block_gets"`
 }
 
+// BufferCacheTest - Buffer Cache性能テストの結果
+type BufferCacheTest struct {
+	Runs         int                 `json:"runs"`
+	RunDurations []time.Duration     `json:"run_durations"`
+	Initial      *BufferCacheMetrics `json:"initial"`
+	Final        *BufferCacheMetrics `json:"final"`
+	Delta        *BufferCacheMetrics `json:"delta"`
+	Pools        []BufferPool        `json:"pools,omitempty"`
+	WaitEvents   []BufferWaitEvent   `json:"wait_events,omitempty"`
+	Advice       []BufferCacheAdvice `json:"advice,omitempty"`
+	// Warnings - 分析の一部が失敗して続行した場合の理由
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Efficiency - テスト期間中にキャッシュから提供された読み取りの割合（%）（論理読み取りがなければ算出不可）
+func (t *BufferCacheTest) Efficiency() (float64, bool) {
+	if t.Delta == nil || t.Delta.LogicalReads <= 0 {
+		return 0, false
+	}
+	return float64(t.Delta.LogicalReads-t.Delta.PhysicalReads) / float64(t.Delta.LogicalReads) * 100, true
+}
+
+// BufferPool - Buffer Poolの構成（V$BUFFER_POOL）
+type BufferPool struct {
+	Name      string  `json:"name"`
+	BlockSize int     `json:"block_size"`
+	SizeMB    float64 `json:"size_mb"`
+}
+
+// BufferWaitEvent - Buffer Cache関連の待機イベント
+type BufferWaitEvent struct {
+	Event      string  `json:"event"`
+	TotalWaits int64   `json:"total_waits"`
+	AvgWaitMs  float64 `json:"avg_wait_ms"`
+}
+
+// BufferCacheAdvice - Buffer Cache Advisory（V$DB_CACHE_ADVICE）の1行
+type BufferCacheAdvice struct {
+	SizeForEstimate    int64   `json:"size_for_estimate"`
+	SizeFactor         float64 `json:"size_factor"`
+	PhysicalReadFactor float64 `json:"physical_read_factor"`
+}
+
+// Assessment - 物理読み取り係数から見たサイズの評価
+func (a BufferCacheAdvice) Assessment() string {
+	if a.PhysicalReadFactor > 1.1 {
+		return "サイズ不足の可能性"
+	} else if a.PhysicalReadFactor < 0.9 && a.SizeFactor > 1.0 {
+		return "サイズ過大の可能性"
+	}
+	return "適正"
+}
+
 // OracleBufferCache - Oracle Database Buffer Cacheの専用実装
 type OracleBufferCache struct {
 	db      *sql.DB
 	metrics *BufferCacheMetrics
+	clock   clock.Clock
+	// errors - 分析中に発生したエラー（警告として記録して続行したもの）
+	errors []error
 }
 
 // NewOracleBufferCache - Buffer Cacheインスタンスを作成
@@ -31,40 +89,35 @@ func NewOracleBufferCache(db *sql.DB) *OracleBufferCache {
 	return &OracleBufferCache{
 		db:      db,
 		metrics: &BufferCacheMetrics{},
+		clock:   clock.System,
 	}
 }
 
 // TestBufferCachePerformance - Buffer Cacheの性能テストを実行
-func (bc *OracleBufferCache) TestBufferCachePerformance(runs int) (*BufferCacheMetrics, error) {
-	fmt.Println("=== Oracle Database Buffer Cache 詳細性能テスト ===")
-	fmt.Printf("実行回数: %d回\n\n", runs)
+func (bc *OracleBufferCache) TestBufferCachePerformance(runs int) (*BufferCacheTest, error) {
+	test := &BufferCacheTest{Runs: runs}
 
 	// 初期メトリクス取得
 	initialMetrics, err := bc.collectMetrics()
 	if err != nil {
 		return nil, fmt.Errorf("初期メトリクス取得エラー: %w", err)
 	}
-
-	fmt.Println("1. Buffer Cache初期状態:")
-	bc.displayMetrics(initialMetrics)
+	test.Initial = initialMetrics
 
 	var totalDuration time.Duration
 
 	// 複数回のテスト実行
 	for i := 0; i < runs; i++ {
-		start := time.Now()
+		start := bc.clock.Now()
 
 		// Buffer Cacheの効果を測定するためのクエリ
-		if err := bc.executeBufferCacheTest(i == 0); err != nil {
+		if err := bc.executeBufferCacheTest(); err != nil {
 			return nil, fmt.Errorf("buffer Cacheテスト実行エラー: %w", err)
 		}
 
-		duration := time.Since(start)
+		duration := bc.clock.Since(start)
 		totalDuration += duration
-
-		if i < 3 {
-			fmt.Printf("%d回目実行時間: %v\n", i+1, duration)
-		}
+		test.RunDurations = append(test.RunDurations, duration)
 	}
 
 	// 最終メトリクス取得
@@ -72,27 +125,21 @@ func (bc *OracleBufferCache) TestBufferCachePerformance(runs int) (*BufferCacheM
 	if err != nil {
 		return nil, fmt.Errorf("最終メトリクス取得エラー: %w", err)
 	}
+	test.Final = finalMetrics
 
 	// 差分計算
 	bc.metrics = bc.calculateDifferential(initialMetrics, finalMetrics)
 	bc.metrics.TestExecutionTime = totalDuration / time.Duration(runs)
-
-	fmt.Println("\n2. Buffer Cache最終状態:")
-	bc.displayMetrics(finalMetrics)
-
-	fmt.Println("\n3. Buffer Cacheテスト期間中の差分メトリクス:")
-	bc.displayDifferentialMetrics()
+	test.Delta = bc.metrics
 
 	// Buffer Cacheの詳細分析
-	if err := bc.analyzeBufferCacheEfficiency(); err != nil {
-		fmt.Printf("Buffer Cache分析エラー: %v\n", err)
-	}
+	bc.analyzeBufferCacheEfficiency(test)
 
-	return bc.metrics, nil
+	return test, nil
 }
 
 // executeBufferCacheTest - Buffer Cacheテスト用クエリを実行
-func (bc *OracleBufferCache) executeBufferCacheTest(isFirstRun bool) error {
+func (bc *OracleBufferCache) executeBufferCacheTest() error {
 	queries := []string{
 		// 1. 大量のデータブロックアクセスを発生させる
 		`SELECT /*+ FULL(o) */ COUNT(*) 
@@ -119,10 +166,6 @@ func (bc *OracleBufferCache) executeBufferCacheTest(isFirstRun bool) error {
 	}
 
 	for i, query := range queries {
-		if isFirstRun {
-			fmt.Printf("実行中: クエリ%d\n", i+1)
-		}
-
 		rows, err := bc.db.Query(query)
 		if err != nil {
 			return fmt.Errorf("クエリ%d実行エラー: %w", i+1, err)
@@ -172,41 +215,34 @@ func (bc *OracleBufferCache) calculateDifferential(initial, final *BufferCacheMe
 	return diff
 }
 
-// displayMetrics - メトリクスを表示
-func (bc *OracleBufferCache) displayMetrics(metrics *BufferCacheMetrics) {
-	fmt.Printf("  Buffer Cache ヒット率: %.2f%%\n", metrics.HitRatio)
-	fmt.Printf("  物理読み取り: %d\n", metrics.PhysicalReads)
-	fmt.Printf("  論理読み取り: %d\n", metrics.LogicalReads)
-	fmt.Printf("  DB Block Gets: %d\n", metrics.DbBlockGets)
-	fmt.Printf("  Consistent Gets: %d\n", metrics.ConsistentGets)
-	fmt.Printf("  Free Buffer待機: %d\n", metrics.FreeBufferWaits)
-	fmt.Printf("  Buffer Busy待機: %d\n", metrics.BufferBusyWaits)
-	if metrics.TotalSizeBytes > 0 {
-		fmt.Printf("  Buffer Cacheサイズ: %.2f MB\n", float64(metrics.TotalSizeBytes)/(1024*1024))
+// analyzeBufferCacheEfficiency - Buffer Cacheの効率性を分析（失敗した項目は警告として記録）
+func (bc *OracleBufferCache) analyzeBufferCacheEfficiency(test *BufferCacheTest) {
+	warn := func(label string, err error) {
+		bc.errors = append(bc.errors, err)
+		test.Warnings = append(test.Warnings, fmt.Sprintf("%s: %v", label, err))
 	}
-}
 
-// displayDifferentialMetrics - 差分メトリクスを表示
-func (bc *OracleBufferCache) displayDifferentialMetrics() {
-	fmt.Printf("  テスト期間中のヒット率: %.2f%%\n", bc.metrics.HitRatio)
-	fmt.Printf("  追加物理読み取り: %d\n", bc.metrics.PhysicalReads)
-	fmt.Printf("  追加論理読み取り: %d\n", bc.metrics.LogicalReads)
-	fmt.Printf("  追加DB Block Gets: %d\n", bc.metrics.DbBlockGets)
-	fmt.Printf("  追加Consistent Gets: %d\n", bc.metrics.ConsistentGets)
-	fmt.Printf("  平均実行時間: %v\n", bc.metrics.TestExecutionTime)
-
-	// 効率指標の計算
-	if bc.metrics.LogicalReads > 0 {
-		efficiency := (float64(bc.metrics.LogicalReads-bc.metrics.PhysicalReads) / float64(bc.metrics.LogicalReads)) * 100
-		fmt.Printf("  Buffer Cache効率: %.2f%% (キャッシュから提供された割合)\n", efficiency)
+	// Buffer Poolの状況分析
+	pools, err := bc.collectBufferPools()
+	if err != nil {
+		warn("Buffer Cache分析エラー", err)
+		return
+	}
+	test.Pools = pools
+
+	// トップ待機イベントの分析
+	if test.WaitEvents, err = bc.analyzeTopWaitEvents(); err != nil {
+		warn("待機イベント分析エラー", err)
 	}
-}
 
-// analyzeBufferCacheEfficiency - Buffer Cacheの効率性を分析
-func (bc *OracleBufferCache) analyzeBufferCacheEfficiency() error {
-	fmt.Println("\n4. Buffer Cache効率性分析:")
+	// Buffer Cache Advisory の分析
+	if test.Advice, err = bc.analyzeBufferCacheAdvisory(); err != nil {
+		warn("Buffer Cache Advisory分析エラー", err)
+	}
+}
 
-	// Buffer Poolの状況分析
+// collectBufferPools - Buffer Poolの構成を取得
+func (bc *OracleBufferCache) collectBufferPools() ([]BufferPool, error) {
 	poolQuery := `
 		SELECT name, block_size, current_size/1024/1024 as size_mb
 		FROM V$BUFFER_POOL
@@ -214,7 +250,7 @@ func (bc *OracleBufferCache) analyzeBufferCacheEfficiency() error {
 
 	rows, err := bc.db.Query(poolQuery)
 	if err != nil {
-		return fmt.Errorf("buffer Pool情報取得エラー: %w", err)
+		return nil, fmt.Errorf("buffer Pool情報取得エラー: %w", err)
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
@@ -222,35 +258,20 @@ func (bc *OracleBufferCache) analyzeBufferCacheEfficiency() error {
 		}
 	}()
 
-	fmt.Println("  Buffer Pool構成:")
+	var pools []BufferPool
 	for rows.Next() {
-		var name string
-		var blockSize int
-		var sizeMB float64
-
-		err := rows.Scan(&name, &blockSize, &sizeMB)
-		if err != nil {
+		var pool BufferPool
+		if err := rows.Scan(&pool.Name, &pool.BlockSize, &pool.SizeMB); err != nil {
 			continue
 		}
-
-		fmt.Printf("    %s: %.1f MB (ブロックサイズ: %d bytes)\n", name, sizeMB, blockSize)
-	}
-
-	// トップ待機イベントの分析
-	if err := bc.analyzeTopWaitEvents(); err != nil {
-		fmt.Printf("  待機イベント分析エラー: %v\n", err)
-	}
-
-	// Buffer Cache Advisory の分析
-	if err := bc.analyzeBufferCacheAdvisory(); err != nil {
-		fmt.Printf("  Buffer Cache Advisory分析エラー: %v\n", err)
+		pools = append(pools, pool)
 	}
 
-	return nil
+	return pools, nil
 }
 
 // analyzeTopWaitEvents - Buffer Cache関連の待機イベントを分析
-func (bc *OracleBufferCache) analyzeTopWaitEvents() error {
+func (bc *OracleBufferCache) analyzeTopWaitEvents() ([]BufferWaitEvent, error) {
 	waitEventQuery := `
 		SELECT event, total_waits, total_timeouts, time_waited_micro
 		FROM V$SYSTEM_EVENT
@@ -261,7 +282,7 @@ func (bc *OracleBufferCache) analyzeTopWaitEvents() error {
 
 	rows, err := bc.db.Query(waitEventQuery)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
@@ -269,7 +290,7 @@ func (bc *OracleBufferCache) analyzeTopWaitEvents() error {
 		}
 	}()
 
-	fmt.Println("  主要な待機イベント（Buffer Cache関連）:")
+	var events []BufferWaitEvent
 	for rows.Next() {
 		var event string
 		var totalWaits, totalTimeouts, timeWaitedMicro int64
@@ -279,15 +300,18 @@ func (bc *OracleBufferCache) analyzeTopWaitEvents() error {
 			continue
 		}
 
-		avgWaitTime := float64(timeWaitedMicro) / float64(totalWaits) / 1000 // ms
-		fmt.Printf("    %s: %d回 (平均%.2fms)\n", event, totalWaits, avgWaitTime)
+		events = append(events, BufferWaitEvent{
+			Event:      event,
+			TotalWaits: totalWaits,
+			AvgWaitMs:  float64(timeWaitedMicro) / float64(totalWaits) / 1000,
+		})
 	}
 
-	return nil
+	return events, nil
 }
 
 // analyzeBufferCacheAdvisory - Buffer Cache Advisoryを分析
-func (bc *OracleBufferCache) analyzeBufferCacheAdvisory() error {
+func (bc *OracleBufferCache) analyzeBufferCacheAdvisory() ([]BufferCacheAdvice, error) {
 	advisoryQuery := `
 		SELECT size_for_estimate, size_factor, estd_physical_read_factor
 		FROM V$DB_CACHE_ADVICE
@@ -297,7 +321,7 @@ func (bc *OracleBufferCache) analyzeBufferCacheAdvisory() error {
 
 	rows, err := bc.db.Query(advisoryQuery)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
@@ -305,35 +329,16 @@ func (bc *OracleBufferCache) analyzeBufferCacheAdvisory() error {
 		}
 	}()
 
-	fmt.Println("  Buffer Cache Advisory (推奨サイズ分析):")
-
-	var hasData bool
+	var advice []BufferCacheAdvice
 	for rows.Next() {
-		hasData = true
-		var sizeForEstimate int64
-		var sizeFactor, physicalReadFactor float64
-
-		err := rows.Scan(&sizeForEstimate, &sizeFactor, &physicalReadFactor)
-		if err != nil {
+		var a BufferCacheAdvice
+		if err := rows.Scan(&a.SizeForEstimate, &a.SizeFactor, &a.PhysicalReadFactor); err != nil {
 			continue
 		}
-
-		status := "適正"
-		if physicalReadFactor > 1.1 {
-			status = "サイズ不足の可能性"
-		} else if physicalReadFactor < 0.9 && sizeFactor > 1.0 {
-			status = "サイズ過大の可能性"
-		}
-
-		fmt.Printf("    サイズ係数%.1fx: %.1fMB → 物理読み取り係数%.2fx (%s)\n",
-			sizeFactor, float64(sizeForEstimate)/(1024*1024), physicalReadFactor, status)
-	}
-
-	if !hasData {
-		fmt.Println("    Buffer Cache Advisoryデータが利用できません")
+		advice = append(advice, a)
 	}
 
-	return nil
+	return advice, nil
 }
 
 // GetOptimizationRecommendations - Buffer Cache最適化推奨事項を取得
@@ -375,6 +380,11 @@ func (bc *OracleBufferCache) GetOptimizationRecommendations() []string {
 	return recommendations
 }
 
+// Errors - 分析中に発生したエラー（警告として記録して続行したもの）
+func (bc *OracleBufferCache) Errors() []error {
+	return bc.errors
+}
+
 // GetMetrics - 現在のメトリクスを取得
 func (bc *OracleBufferCache) GetMetrics() *BufferCacheMetrics {
 	return bc.metrics
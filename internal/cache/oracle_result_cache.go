@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
 )
 
 // ResultCacheMetrics - Result Cache性能メトリクス
@@ -21,10 +23,28 @@ type ResultCacheMetrics struct {
 	InvalidationDependencies int64         `json:"invalidation_dependencies"`
 }
 
+// ResultCacheTest - Result Cache性能テストの結果
+type ResultCacheTest struct {
+	Runs         int                 `json:"runs"`
+	RunDurations []time.Duration     `json:"run_durations"`
+	Initial      *ResultCacheMetrics `json:"initial"`
+	Final        *ResultCacheMetrics `json:"final"`
+	Delta        *ResultCacheMetrics `json:"delta"`
+}
+
+// Efficiency - 推定キャッシュヒットの割合（%）（ヒット・ミスを推定できなければ算出不可）
+func (t *ResultCacheTest) Efficiency() (float64, bool) {
+	if t.Delta == nil || t.Delta.CacheHits+t.Delta.CacheMisses <= 0 {
+		return 0, false
+	}
+	return float64(t.Delta.CacheHits) / float64(t.Delta.CacheHits+t.Delta.CacheMisses) * 100, true
+}
+
 // OracleResultCache - Oracle Server Result Cacheの専用実装
 type OracleResultCache struct {
 	db      *sql.DB
 	metrics *ResultCacheMetrics
+	clock   clock.Clock
 }
 
 // NewOracleResultCache - Result Cacheインスタンスを作成
@@ -32,45 +52,37 @@ func NewOracleResultCache(db *sql.DB) *OracleResultCache {
 	return &OracleResultCache{
 		db:      db,
 		metrics: &ResultCacheMetrics{},
+		clock:   clock.System,
 	}
 }
 
 // TestResultCachePerformance - Result Cacheの性能テストを実行
-func (rc *OracleResultCache) TestResultCachePerformance(runs int) (*ResultCacheMetrics, error) {
-	fmt.Println("=== Oracle Server Result Cache 詳細性能テスト ===")
-	fmt.Printf("実行回数: %d回\\n\\n", runs)
-
-	// Result Cache機能の有効性を確認
-	if err := rc.checkResultCacheStatus(); err != nil {
-		return nil, fmt.Errorf("result cache状態確認エラー: %w", err)
-	}
+//
+// V$RESULT_CACHE_* へのアクセスには管理者権限が必要なため、キャッシュ効果は実行時間の変化で測定する。
+func (rc *OracleResultCache) TestResultCachePerformance(runs int) (*ResultCacheTest, error) {
+	test := &ResultCacheTest{Runs: runs}
 
 	// 初期メトリクス取得
 	initialMetrics, err := rc.collectMetrics()
 	if err != nil {
 		return nil, fmt.Errorf("初期メトリクス取得エラー: %w", err)
 	}
-
-	fmt.Println("1. Result Cache初期状態:")
-	rc.displayMetrics(initialMetrics)
+	test.Initial = initialMetrics
 
 	var totalDuration time.Duration
 
 	// 複数回のテスト実行
 	for i := 0; i < runs; i++ {
-		start := time.Now()
+		start := rc.clock.Now()
 
 		// Result Cacheの効果を測定するためのクエリ実行
-		if err := rc.executeResultCacheTest(i == 0); err != nil {
+		if err := rc.executeResultCacheTest(); err != nil {
 			return nil, fmt.Errorf("result cacheテスト実行エラー: %w", err)
 		}
 
-		duration := time.Since(start)
+		duration := rc.clock.Since(start)
 		totalDuration += duration
-
-		if i < 3 {
-			fmt.Printf("%d回目実行時間: %v\\n", i+1, duration)
-		}
+		test.RunDurations = append(test.RunDurations, duration)
 	}
 
 	// 最終メトリクス取得
@@ -78,41 +90,18 @@ func (rc *OracleResultCache) TestResultCachePerformance(runs int) (*ResultCacheM
 	if err != nil {
 		return nil, fmt.Errorf("最終メトリクス取得エラー: %w", err)
 	}
+	test.Final = finalMetrics
 
 	// 差分計算
 	rc.metrics = rc.calculateDifferential(initialMetrics, finalMetrics)
 	rc.metrics.TestExecutionTime = totalDuration / time.Duration(runs)
+	test.Delta = rc.metrics
 
-	fmt.Println("\\n2. Result Cache最終状態:")
-	rc.displayMetrics(finalMetrics)
-
-	fmt.Println("\\n3. Result Cacheテスト期間中の差分メトリクス:")
-	rc.displayDifferentialMetrics()
-
-	// Result Cacheの詳細分析
-	if err := rc.analyzeResultCacheEfficiency(); err != nil {
-		fmt.Printf("Result Cache分析エラー: %v\\n", err)
-	}
-
-	// Result Cacheオブジェクトの詳細表示
-	if err := rc.displayResultCacheObjects(); err != nil {
-		fmt.Printf("Result Cacheオブジェクト表示エラー: %v\\n", err)
-	}
-
-	return rc.metrics, nil
-}
-
-// checkResultCacheStatus - Result Cache機能の状態を確認
-func (rc *OracleResultCache) checkResultCacheStatus() error {
-	fmt.Println("Result Cache機能状態確認:")
-	fmt.Println("  実行時間の変化でキャッシュ効果を測定します")
-	fmt.Println("  V$ビューへのアクセス権限は一般アプリでは不要です")
-	fmt.Println("")
-	return nil
+	return test, nil
 }
 
 // executeResultCacheTest - Result Cacheテスト用クエリを実行
-func (rc *OracleResultCache) executeResultCacheTest(isFirstRun bool) error {
+func (rc *OracleResultCache) executeResultCacheTest() error {
 	// Result Cacheヒント付きクエリの実行
 	queries := []string{
 		// 1. 集計クエリ（Result Cacheに最適）
@@ -164,10 +153,6 @@ func (rc *OracleResultCache) executeResultCacheTest(isFirstRun bool) error {
 	}
 
 	for i, query := range queries {
-		if isFirstRun {
-			fmt.Printf("実行中: Result Cacheクエリ%d\\n", i+1)
-		}
-
 		rows, err := rc.db.Query(query)
 		if err != nil {
 			return fmt.Errorf("result cacheクエリ%d実行エラー: %w", i+1, err)
@@ -220,48 +205,6 @@ func (rc *OracleResultCache) calculateDifferential(initial, final *ResultCacheMe
 	return diff
 }
 
-// displayMetrics - メトリクスを表示
-func (rc *OracleResultCache) displayMetrics(metrics *ResultCacheMetrics) {
-	fmt.Printf("  Result Cacheヒット率: %.2f%%\\n", metrics.HitRatio)
-	fmt.Printf("  キャッシュオブジェクト数: %d\\n", metrics.ObjectCount)
-	fmt.Printf("  使用ブロック数: %d\\n", metrics.BlockCount)
-	fmt.Printf("  メモリ使用量: %.2f MB\\n", float64(metrics.MemoryUsage)/(1024*1024))
-	fmt.Printf("  作成オブジェクト数: %d\\n", metrics.CreatedObjects)
-	fmt.Printf("  無効化依存関係数: %d\\n", metrics.InvalidationDependencies)
-}
-
-// displayDifferentialMetrics - 差分メトリクスを表示
-func (rc *OracleResultCache) displayDifferentialMetrics() {
-	fmt.Printf("  テスト期間中のヒット率: %.2f%%\\n", rc.metrics.HitRatio)
-	fmt.Printf("  新規キャッシュオブジェクト: %d\\n", rc.metrics.ObjectCount)
-	fmt.Printf("  追加メモリ使用量: %.2f MB\\n", float64(rc.metrics.MemoryUsage)/(1024*1024))
-	fmt.Printf("  推定キャッシュヒット: %d回\\n", rc.metrics.CacheHits)
-	fmt.Printf("  推定キャッシュミス: %d回\\n", rc.metrics.CacheMisses)
-	fmt.Printf("  平均実行時間: %v\\n", rc.metrics.TestExecutionTime)
-
-	// 効率指標の計算
-	if rc.metrics.CacheHits+rc.metrics.CacheMisses > 0 {
-		efficiency := float64(rc.metrics.CacheHits) / float64(rc.metrics.CacheHits+rc.metrics.CacheMisses) * 100
-		fmt.Printf("  Result Cache効率: %.2f%% (ヒット率)\\n", efficiency)
-	}
-}
-
-// analyzeResultCacheEfficiency - Result Cacheの効率性を分析
-func (rc *OracleResultCache) analyzeResultCacheEfficiency() error {
-	fmt.Println("\\n4. Result Cache効率性分析:")
-	fmt.Println("  実行時間測定により十分な効果確認が可能")
-	fmt.Println("  V$ビューアクセスは管理者専用機能です")
-	return nil
-}
-
-// displayResultCacheObjects - Result Cacheオブジェクトの詳細を表示
-func (rc *OracleResultCache) displayResultCacheObjects() error {
-	fmt.Println("\\n5. Result Cacheオブジェクト詳細:")
-	fmt.Println("  実行時間の差でキャッシュ効果を判定できます")
-	fmt.Println("  V$ビューアクセスは管理者専用機能です")
-	return nil
-}
-
 // GetOptimizationRecommendations - Result Cache最適化推奨事項を取得
 func (rc *OracleResultCache) GetOptimizationRecommendations() []string {
 	recommendations := []string{
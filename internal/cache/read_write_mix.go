@@ -0,0 +1,343 @@
+package cache
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+)
+
+// DefaultReadWriteOperations - 読み書き混在ワークロードの既定の操作数（手法ごと）
+const DefaultReadWriteOperations = 500
+
+// mixCustomerLimit - 読み書きの対象とする顧客数（受注のある顧客を顧客ID順に選ぶ）
+const mixCustomerLimit = 50
+
+// ErrInvalidReadWriteRatio - 読み書き比率の指定が正しくない
+var ErrInvalidReadWriteRatio = errors.New("invalid read/write ratio")
+
+// ReadWriteMixConfig - 読み書き混在ワークロードの設定
+type ReadWriteMixConfig struct {
+	// Operations - 手法ごとの操作数（読み取り + 書き込み）
+	Operations int
+	// WriteRatio - 操作に占める書き込みの割合（0〜1）
+	WriteRatio float64
+	// Seed - 操作列の乱数シード（すべての手法で同じ操作列を使う）
+	Seed uint64
+	// Clock - 読み書きの時間の計測に使う時計（nilの場合はclock.System）
+	Clock clock.Clock
+}
+
+// ParseReadWriteRatio - "90/10" 形式（読み取り/書き込み）の比率から書き込みの割合を求める
+func ParseReadWriteRatio(value string) (float64, error) {
+	read, write, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, fmt.Errorf("%w: %q (例: 90/10)", ErrInvalidReadWriteRatio, value)
+	}
+	r, rerr := strconv.ParseFloat(strings.TrimSpace(read), 64)
+	w, werr := strconv.ParseFloat(strings.TrimSpace(write), 64)
+	if rerr != nil || werr != nil || r < 0 || w < 0 || r+w == 0 {
+		return 0, fmt.Errorf("%w: %q (例: 90/10)", ErrInvalidReadWriteRatio, value)
+	}
+	return w / (r + w), nil
+}
+
+// MixValue - 読み取り結果（顧客ごとの受注件数と売上合計）
+type MixValue struct {
+	OrderCount  int64
+	TotalAmount float64
+}
+
+// MixStrategy - 読み書き混在ワークロードで比較するキャッシュ手法
+type MixStrategy interface {
+	// Name - 手法名
+	Name() string
+	// Description - 手法の説明（無効化の単位など）
+	Description() string
+	// Prepare - 計測前にキャッシュを空にする
+	Prepare() error
+	// Read - 顧客の受注件数と売上合計を取得（キャッシュから返した場合はhit=true）
+	Read(customerID int64) (value MixValue, hit bool, err error)
+	// Written - 顧客の受注を更新した直後に呼ばれる（キャッシュの無効化）
+	Written(customerID int64) error
+	// HitEstimated - ヒットを直接観測できず推定しているか
+	HitEstimated() bool
+}
+
+// ReadWriteMixResult - 読み書き混在ワークロードの結果
+type ReadWriteMixResult struct {
+	Operations int     `json:"operations"`
+	WriteRatio float64 `json:"write_ratio"`
+	Seed       uint64  `json:"seed"`
+	Customers  int     `json:"customers"`
+	// Strategies - 手法ごとの結果（指定した順）
+	Strategies []MixStrategyResult `json:"strategies"`
+}
+
+// MixStrategyResult - 読み書き混在ワークロードでの1手法の結果
+type MixStrategyResult struct {
+	Strategy    string `json:"strategy"`
+	Description string `json:"description"`
+	Reads       int    `json:"reads"`
+	Writes      int    `json:"writes"`
+	Hits        int    `json:"hits"`
+	// HitRate - 実効ヒット率（読み取りのうちキャッシュから返した割合、%）
+	HitRate float64 `json:"hit_rate"`
+	// HitEstimated - ヒットを直接観測できず推定しているか
+	HitEstimated bool `json:"hit_estimated"`
+	// StaleReads - DBの最新値と一致しなかった読み取りの数
+	StaleReads int `json:"stale_reads"`
+	// Consistency - DBの最新値と一致した読み取りの割合（%）
+	Consistency float64       `json:"consistency"`
+	MeanRead    time.Duration `json:"mean_read"`
+	P95Read     time.Duration `json:"p95_read"`
+	MeanWrite   time.Duration `json:"mean_write"`
+}
+
+// mixOperation - 操作列の1件
+type mixOperation struct {
+	customerID int64
+	write      bool
+}
+
+// mixReadQuery - 顧客ごとの受注件数と売上合計（%sにヒントを埋め込む）
+const mixReadQuery = `
+	SELECT %s COUNT(*), NVL(SUM(total_amount), 0)
+	FROM orders
+	WHERE customer_id = :1`
+
+// mixWriteQuery - 顧客の最新の受注の金額を更新（計測後に元に戻す）
+const mixWriteQuery = `
+	UPDATE orders SET total_amount = total_amount + :1
+	WHERE order_id = (SELECT MAX(order_id) FROM orders WHERE customer_id = :2)`
+
+// QueryCustomerTotals - 顧客の受注件数と売上合計をDBから取得（hintは /*+ ... */ または空）
+func QueryCustomerTotals(db *sql.DB, customerID int64, hint string) (MixValue, error) {
+	var v MixValue
+	if err := db.QueryRow(fmt.Sprintf(mixReadQuery, hint), customerID).Scan(&v.OrderCount, &v.TotalAmount); err != nil {
+		return MixValue{}, fmt.Errorf("failed to query customer totals: %w", err)
+	}
+	return v, nil
+}
+
+// AdjustLatestOrder - 顧客の最新の受注の金額にdeltaを加えてコミット（計測後に-deltaで元に戻す）
+func AdjustLatestOrder(db *sql.DB, customerID int64, delta float64) error {
+	if _, err := db.Exec(mixWriteQuery, delta, customerID); err != nil {
+		return fmt.Errorf("failed to update latest order of customer %d: %w", customerID, err)
+	}
+	return nil
+}
+
+// RunReadWriteMix - 同じ読み書きの操作列を手法ごとに実行し、実効ヒット率と整合性を測定
+//
+// 書き込みは orders.total_amount を実際に更新してコミットするため、各手法の計測後に加えた差分を戻す。
+// 読み取りのたびにキャッシュを通さないクエリでDBの最新値を取得し（計測時間には含めない）、一致しない読み取りを古い値として数える。
+func RunReadWriteMix(db *sql.DB, cfg ReadWriteMixConfig, strategies []MixStrategy) (*ReadWriteMixResult, error) {
+	if cfg.Operations <= 0 {
+		cfg.Operations = DefaultReadWriteOperations
+	}
+
+	customers, err := mixCustomers(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(customers) == 0 {
+		return nil, errors.New("no customers with orders")
+	}
+
+	operations := mixOperations(cfg, customers)
+	result := &ReadWriteMixResult{
+		Operations: cfg.Operations,
+		WriteRatio: cfg.WriteRatio,
+		Seed:       cfg.Seed,
+		Customers:  len(customers),
+	}
+	for _, st := range strategies {
+		sr, err := runMixStrategy(db, clock.OrSystem(cfg.Clock), st, operations)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", st.Name(), err)
+		}
+		result.Strategies = append(result.Strategies, sr)
+	}
+	return result, nil
+}
+
+// mixCustomers - 読み書きの対象とする顧客ID
+func mixCustomers(db *sql.DB) ([]int64, error) {
+	rows, err := db.Query(`
+		SELECT customer_id FROM (
+			SELECT DISTINCT customer_id FROM orders ORDER BY customer_id
+		) WHERE ROWNUM <= :1`, mixCustomerLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var customers []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan customer: %w", err)
+		}
+		customers = append(customers, id)
+	}
+	return customers, rows.Err()
+}
+
+// mixOperations - シードから操作列を作る
+func mixOperations(cfg ReadWriteMixConfig, customers []int64) []mixOperation {
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed))
+	operations := make([]mixOperation, cfg.Operations)
+	for i := range operations {
+		operations[i] = mixOperation{
+			customerID: customers[rng.IntN(len(customers))],
+			write:      rng.Float64() < cfg.WriteRatio,
+		}
+	}
+	return operations
+}
+
+// runMixStrategy - 1手法分の操作列を実行（更新した金額は最後に元に戻す）
+func runMixStrategy(db *sql.DB, clk clock.Clock, st MixStrategy, operations []mixOperation) (result MixStrategyResult, err error) {
+	result = MixStrategyResult{
+		Strategy:     st.Name(),
+		Description:  st.Description(),
+		HitEstimated: st.HitEstimated(),
+	}
+	if err := st.Prepare(); err != nil {
+		return result, fmt.Errorf("failed to prepare cache: %w", err)
+	}
+
+	// 顧客ごとに加えた金額（計測後に差し引く）
+	deltas := make(map[int64]float64)
+	defer func() {
+		if rerr := revertMixWrites(db, deltas); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	var reads []time.Duration
+	var writeTotal time.Duration
+	for _, op := range operations {
+		if op.write {
+			start := clk.Now()
+			if err := AdjustLatestOrder(db, op.customerID, 1); err != nil {
+				return result, err
+			}
+			deltas[op.customerID]++
+			if err := st.Written(op.customerID); err != nil {
+				return result, fmt.Errorf("failed to invalidate cache: %w", err)
+			}
+			writeTotal += clk.Since(start)
+			result.Writes++
+			continue
+		}
+
+		start := clk.Now()
+		value, hit, err := st.Read(op.customerID)
+		reads = append(reads, clk.Since(start))
+		if err != nil {
+			return result, err
+		}
+		result.Reads++
+		if hit {
+			result.Hits++
+		}
+
+		latest, err := QueryCustomerTotals(db, op.customerID, "/*+ NO_RESULT_CACHE */")
+		if err != nil {
+			return result, err
+		}
+		if value != latest {
+			result.StaleReads++
+		}
+	}
+
+	if result.Reads > 0 {
+		result.HitRate = float64(result.Hits) / float64(result.Reads) * 100
+		result.Consistency = float64(result.Reads-result.StaleReads) / float64(result.Reads) * 100
+
+		sorted := make([]time.Duration, len(reads))
+		copy(sorted, reads)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		var total time.Duration
+		for _, d := range sorted {
+			total += d
+		}
+		result.MeanRead = total / time.Duration(len(sorted))
+		result.P95Read = sorted[(len(sorted)*95+99)/100-1]
+	}
+	if result.Writes > 0 {
+		result.MeanWrite = writeTotal / time.Duration(result.Writes)
+	}
+	return result, nil
+}
+
+// revertMixWrites - 計測中に加えた金額を元に戻す
+func revertMixWrites(db *sql.DB, deltas map[int64]float64) error {
+	for customerID, delta := range deltas {
+		if err := AdjustLatestOrder(db, customerID, -delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResultCacheMixStrategy - Oracle Server Result Cache（RESULT_CACHEヒント）
+//
+// Result Cacheは依存する表が更新されると、その表に依存する結果をすべて無効化する。
+// ヒットの有無はV$ビューなしでは観測できないため、「前回の書き込み以降に同じ顧客を読んだか」で推定する。
+type ResultCacheMixStrategy struct {
+	db *sql.DB
+	// cached - 前回の書き込み以降に読み取った顧客
+	cached map[int64]bool
+}
+
+// NewResultCacheMixStrategy - Result Cacheの手法を作成
+func NewResultCacheMixStrategy(db *sql.DB) *ResultCacheMixStrategy {
+	return &ResultCacheMixStrategy{db: db, cached: make(map[int64]bool)}
+}
+
+// Name - 手法名
+func (s *ResultCacheMixStrategy) Name() string { return "Oracle_Result_Cache" }
+
+// Description - 手法の説明
+func (s *ResultCacheMixStrategy) Description() string {
+	return "Oracle Server Result Cache（表単位で自動無効化）"
+}
+
+// Prepare - 推定用の状態を初期化（Result Cache自体は最初の書き込みで無効化される）
+func (s *ResultCacheMixStrategy) Prepare() error {
+	s.cached = make(map[int64]bool)
+	return nil
+}
+
+// Read - RESULT_CACHEヒント付きで取得
+func (s *ResultCacheMixStrategy) Read(customerID int64) (MixValue, bool, error) {
+	value, err := QueryCustomerTotals(s.db, customerID, "/*+ RESULT_CACHE */")
+	if err != nil {
+		return MixValue{}, false, err
+	}
+	hit := s.cached[customerID]
+	s.cached[customerID] = true
+	return value, hit, nil
+}
+
+// Written - ordersの更新で、ordersに依存するすべての結果が無効になる
+func (s *ResultCacheMixStrategy) Written(int64) error {
+	clear(s.cached)
+	return nil
+}
+
+// HitEstimated - ヒットは推定
+func (s *ResultCacheMixStrategy) HitEstimated() bool { return true }
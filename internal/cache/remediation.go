@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Severity - 推奨事項の重要度
+type Severity string
+
+// 重要度（JSONでは英語の列挙値、表示では日本語のラベルを使う）
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+)
+
+// Rank - 並べ替え用の順位（大きいほど重要）
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Label - 表示用のラベル
+func (s Severity) Label() string {
+	switch s {
+	case SeverityCritical:
+		return "最高"
+	case SeverityHigh:
+		return "高"
+	case SeverityMedium:
+		return "中"
+	case SeverityLow:
+		return "低"
+	default:
+		return string(s)
+	}
+}
+
+// ParameterChange - 推奨する初期化パラメータの変更
+type ParameterChange struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Note - 変更時の注意点
+	Note string `json:"note,omitempty"`
+}
+
+// SQL - ALTER SYSTEM文（値が山括弧の場合は確認が必要なプレースホルダー）
+func (p ParameterChange) SQL() string {
+	return fmt.Sprintf("ALTER SYSTEM SET %s = %s SCOPE=BOTH", p.Name, p.Value)
+}
+
+// NeedsReview - 値が決まっておらず、そのままでは実行できないか
+func (p ParameterChange) NeedsReview() bool {
+	return strings.HasPrefix(p.Value, "<")
+}
+
+// RemediationScript - 推奨事項から修正用のSQL*Plusスクリプトを作成
+//
+// 自動適用できないものや値の確認が必要なものはコメントアウトして出力する。
+// 自動適用できるかは組み込みのルールで判定し、有効にする文もルールのものを使う（AutoFixStatementsと同じ）。
+// appUserは &app_user の置換値（GRANT文の対象ユーザー）。
+func RemediationScript(recs []Recommendation, appUser string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "-- n1demo 推奨事項の修正スクリプト（%s 作成）\n", time.Now().Format("2006-01-02 15:04:05"))
+	b.WriteString("-- 自動適用できる文のみ有効、それ以外はDBAが内容を確認してからコメントを外してください\n")
+	if appUser == "" {
+		appUser = "YOUR_APP_USER"
+	}
+	fmt.Fprintf(&b, "DEFINE app_user = %s\n", appUser)
+
+	written := 0
+	for _, rec := range recs {
+		if len(rec.SuggestedSQL) == 0 && len(rec.ParameterChanges) == 0 {
+			continue
+		}
+		written++
+
+		fmt.Fprintf(&b, "\n-- [%s] %s: %s\n", rec.RuleID, rec.Severity, rec.Title)
+		if rec.Evidence != "" {
+			fmt.Fprintf(&b, "--   根拠: %s\n", rec.Evidence)
+		}
+
+		fix, autoFixable := autoFixRecommendation(rec.RuleID)
+		if autoFixable {
+			rec.SuggestedSQL, rec.ParameterChanges = fix.SuggestedSQL, fix.ParameterChanges
+		}
+		for _, stmt := range rec.SuggestedSQL {
+			writeStatement(&b, stmt, !autoFixable)
+		}
+		for _, change := range rec.ParameterChanges {
+			if change.Note != "" {
+				fmt.Fprintf(&b, "--   注意: %s\n", change.Note)
+			}
+			writeStatement(&b, change.SQL(), !autoFixable || change.NeedsReview())
+		}
+	}
+
+	if written == 0 {
+		b.WriteString("\n-- 修正SQLを伴う推奨事項はありません\n")
+	}
+
+	return b.String()
+}
+
+// AutoFixStatements - 自動適用できる推奨事項のSQL（Execにそのまま渡せる形式）
+//
+// 推奨事項のSuggestedSQL・ParameterChanges・AutoFixableは使わず、RuleIDに対応する組み込みのルールから作る。
+// 編集された分析結果のファイルを -from で読んでも、任意の文は実行されない。
+func AutoFixStatements(recs []Recommendation) []string {
+	var stmts []string
+	applied := make(map[string]bool)
+	for _, rec := range recs {
+		fix, ok := autoFixRecommendation(rec.RuleID)
+		if !ok || applied[rec.RuleID] {
+			continue
+		}
+		applied[rec.RuleID] = true
+		stmts = append(stmts, fix.SuggestedSQL...)
+		for _, change := range fix.ParameterChanges {
+			if !change.NeedsReview() {
+				stmts = append(stmts, change.SQL())
+			}
+		}
+	}
+	return stmts
+}
+
+// writeStatement - SQL*Plusで実行できる形式で1文を書き出す（PL/SQLブロックは / で終端）
+func writeStatement(b *strings.Builder, stmt string, commented bool) {
+	prefix := ""
+	if commented {
+		prefix = "-- "
+	}
+
+	if isPLSQLBlock(stmt) {
+		fmt.Fprintf(b, "%s%s\n%s/\n", prefix, stmt, prefix)
+		return
+	}
+	fmt.Fprintf(b, "%s%s;\n", prefix, stmt)
+}
+
+// isPLSQLBlock - 無名PL/SQLブロックか
+func isPLSQLBlock(stmt string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(stmt))
+	return strings.HasPrefix(upper, "BEGIN") || strings.HasPrefix(upper, "DECLARE")
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// tamperedResults - 自動適用の対象を書き換えた分析結果のJSON
+const tamperedResults = `{"recommendations": [
+	{"rule_id": "wait-db-file-sequential-read", "auto_fixable": true,
+	 "suggested_sql": ["DROP TABLE orders PURGE"],
+	 "parameter_changes": [{"name": "sga_target", "value": "0"}]},
+	{"rule_id": "ora-view-privilege", "auto_fixable": true, "suggested_sql": ["GRANT DBA TO scott"]},
+	{"rule_id": "custom-rule", "auto_fixable": true, "suggested_sql": ["TRUNCATE TABLE orders"]},
+	{"rule_id": "ora-open-cursors", "auto_fixable": true,
+	 "parameter_changes": [{"name": "open_cursors", "value": "1000 SCOPE=MEMORY; DROP TABLE orders"}]},
+	{"rule_id": "wait-db-file-sequential-read", "auto_fixable": true}
+]}`
+
+func TestAutoFixStatementsIgnoresSerializedSQL(t *testing.T) {
+	var results AnalysisResults
+	if err := json.Unmarshal([]byte(tamperedResults), &results); err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+
+	// 組み込みのルールで自動適用できるものだけが、ルールの文で1回だけ実行される
+	want := []string{"BEGIN DBMS_STATS.GATHER_SCHEMA_STATS(USER); END;"}
+	if got := AutoFixStatements(results.Recommendations); !reflect.DeepEqual(got, want) {
+		t.Errorf("AutoFixStatements(tampered) = %q, want %q", got, want)
+	}
+
+	script := RemediationScript(results.Recommendations, "APP")
+	for _, line := range strings.Split(script, "\n") {
+		if line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "DEFINE ") {
+			continue
+		}
+		if line != "BEGIN DBMS_STATS.GATHER_SCHEMA_STATS(USER); END;" && line != "/" {
+			t.Errorf("RemediationScript(tampered) enabled %q", line)
+		}
+	}
+	if !strings.Contains(script, "-- GRANT DBA TO scott;") {
+		t.Errorf("RemediationScript(tampered) does not show the recorded SQL as a comment:\n%s", script)
+	}
+}
+
+func TestAutoFixStatements(t *testing.T) {
+	recs := Diagnose(&Symptoms{
+		BufferHitRatio: 80,
+		ResultHitRatio: 90,
+		Waits:          map[string]WaitEventStat{"db file sequential read": {TotalWaits: minDiagnosticWaits}},
+		ErrorCodes:     []string{"ORA-01000"},
+	})
+	want := []string{"BEGIN DBMS_STATS.GATHER_SCHEMA_STATS(USER); END;"}
+	if got := AutoFixStatements(recs); !reflect.DeepEqual(got, want) {
+		t.Errorf("AutoFixStatements() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package cachekey
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+const (
+	// BenchNamespace - ベンチマークが作るキーの名前空間（終了時に削除する。cleanupコマンドの対象）
+	BenchNamespace = "nplus1:invbench"
+	// DefaultKeys - 既定のファミリーあたりのキーの数
+	DefaultKeys = 10000
+	// DefaultRuns - 既定の実行回数
+	DefaultRuns = 5
+	// DefaultScanCount - SCANの1回あたりのCOUNT
+	DefaultScanCount = 1000
+	// benchTTL - ベンチマークで作るキーの有効期限（バージョンを進めた後の古いキーはこの時間まで残る）
+	benchTTL = 10 * time.Minute
+	// benchValueBytes - 1キーあたりの値の大きさ
+	benchValueBytes = 256
+	// maxReads - 読み取りのオーバーヘッドを計測するキーの数の上限
+	maxReads = 1000
+	// pipelineSize - キーを作成するパイプラインの1回あたりのコマンド数
+	pipelineSize = 1000
+)
+
+// Config - ベンチマークの設定
+type Config struct {
+	// Keys - 1回の無効化で対象になるファミリーのキーの数
+	Keys int
+	// Runs - 実行回数（結果は中央値）
+	Runs int
+	// ScanCount - SCANの1回あたりのCOUNT
+	ScanCount int
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{Keys: DefaultKeys, Runs: DefaultRuns, ScanCount: DefaultScanCount}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if c.Keys <= 0 {
+		return fmt.Errorf("keys must be positive: %d", c.Keys)
+	}
+	if c.Runs <= 0 {
+		return fmt.Errorf("runs must be positive: %d", c.Runs)
+	}
+	if c.ScanCount <= 0 {
+		return fmt.Errorf("scan count must be positive: %d", c.ScanCount)
+	}
+	return nil
+}
+
+// Invalidation - 無効化の方法ごとの結果
+type Invalidation struct {
+	// Median - 無効化にかかった時間の中央値
+	Median    time.Duration   `json:"median"`
+	Durations []time.Duration `json:"durations"`
+	// RoundTrips - 1回の無効化でRedisに送ったコマンドの数
+	RoundTrips int `json:"round_trips"`
+	// Deleted - 1回の無効化で削除したキーの数（バージョンの更新では0）
+	Deleted int `json:"deleted"`
+}
+
+// Report - バージョンの更新とキーの走査による無効化の比較
+type Report struct {
+	Keys      int          `json:"keys"`
+	Runs      int          `json:"runs"`
+	ScanCount int          `json:"scan_count"`
+	Scan      Invalidation `json:"scan_delete"`
+	Version   Invalidation `json:"version_bump"`
+	// Reads - 読み取りのオーバーヘッドを計測したキーの数
+	Reads int `json:"reads"`
+	// DirectRead / VersionedRead - 1回の読み取りの時間の中央値（キーを組み立て済み / バージョン番号を取得してから）
+	DirectRead    time.Duration `json:"direct_read"`
+	VersionedRead time.Duration `json:"versioned_read"`
+	// StaleKeys - バージョンを進めた後に有効期限まで残る古いキーの数（実行回数分）
+	StaleKeys int           `json:"stale_keys"`
+	StaleTTL  time.Duration `json:"stale_ttl"`
+}
+
+// Speedup - キーの走査に対するバージョンの更新の速度比（計測できない場合はfalse）
+func (r *Report) Speedup() (float64, bool) {
+	if r.Version.Median <= 0 || r.Scan.Median <= 0 {
+		return 0, false
+	}
+	return float64(r.Scan.Median) / float64(r.Version.Median), true
+}
+
+// ReadOverhead - バージョン番号の取得で増える1回の読み取りの時間
+func (r *Report) ReadOverhead() time.Duration {
+	return r.VersionedRead - r.DirectRead
+}
+
+// Run - 同じ数のキーを、SCAN + DELとバージョンの更新でそれぞれ無効化する時間を比較
+//
+// 終了時にベンチマークの名前空間のキーを削除する。
+func Run(ctx context.Context, client *redis.Client, cfg Config) (report *Report, err error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if _, _, cerr := ScanDelete(ctx, client, BenchNamespace+":*", int64(cfg.ScanCount)); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to clean up benchmark keys: %w", cerr)
+		}
+	}()
+
+	builder := New(client, BenchNamespace)
+	report = &Report{Keys: cfg.Keys, Runs: cfg.Runs, ScanCount: cfg.ScanCount, StaleTTL: benchTTL}
+	var directReads, versionedReads []time.Duration
+
+	for range cfg.Runs {
+		// キーの走査による無効化（バージョンを含まないキー）
+		plain := make([]string, cfg.Keys)
+		for i := range plain {
+			plain[i] = BenchNamespace + ":plain:" + strconv.Itoa(i)
+		}
+		if err := populate(ctx, client, plain); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		deleted, roundTrips, err := ScanDelete(ctx, client, BenchNamespace+":plain:*", int64(cfg.ScanCount))
+		if err != nil {
+			return nil, err
+		}
+		report.Scan.Durations = append(report.Scan.Durations, time.Since(start))
+		report.Scan.RoundTrips, report.Scan.Deleted = roundTrips, deleted
+
+		// バージョンの更新による無効化
+		version, err := builder.Version(ctx, "versioned")
+		if err != nil {
+			return nil, err
+		}
+		versioned := make([]string, cfg.Keys)
+		for i := range versioned {
+			versioned[i] = Format(BenchNamespace, "versioned", version, strconv.Itoa(i))
+		}
+		if err := populate(ctx, client, versioned); err != nil {
+			return nil, err
+		}
+		direct, withVersion, err := measureReads(ctx, client, builder, versioned)
+		if err != nil {
+			return nil, err
+		}
+		directReads = append(directReads, direct)
+		versionedReads = append(versionedReads, withVersion)
+
+		start = time.Now()
+		if _, err := builder.Invalidate(ctx, "versioned"); err != nil {
+			return nil, err
+		}
+		report.Version.Durations = append(report.Version.Durations, time.Since(start))
+		report.Version.RoundTrips = 1
+		report.StaleKeys += cfg.Keys
+	}
+
+	report.Scan.Median = stats.MedianDuration(report.Scan.Durations)
+	report.Version.Median = stats.MedianDuration(report.Version.Durations)
+	report.Reads = min(cfg.Keys, maxReads)
+	report.DirectRead = stats.MedianDuration(directReads)
+	report.VersionedRead = stats.MedianDuration(versionedReads)
+	return report, nil
+}
+
+// populate - キーをパイプラインでまとめて作成
+func populate(ctx context.Context, client *redis.Client, keys []string) error {
+	value := strings.Repeat("x", benchValueBytes)
+	for start := 0; start < len(keys); start += pipelineSize {
+		pipe := client.Pipeline()
+		for _, key := range keys[start:min(start+pipelineSize, len(keys))] {
+			pipe.Set(ctx, key, value, benchTTL)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to populate benchmark keys: %w", err)
+		}
+	}
+	return nil
+}
+
+// measureReads - 先頭のmaxReads個のキーを、組み立て済みのキーとバージョン番号を取得して組み立てたキーで1つずつ読み、1回あたりの時間を返す
+func measureReads(ctx context.Context, client *redis.Client, builder *Builder, keys []string) (direct, versioned time.Duration, err error) {
+	keys = keys[:min(len(keys), maxReads)]
+
+	start := time.Now()
+	for _, key := range keys {
+		if err := client.Get(ctx, key).Err(); err != nil {
+			return 0, 0, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+	}
+	direct = time.Since(start) / time.Duration(len(keys))
+
+	start = time.Now()
+	for i := range keys {
+		key, err := builder.Key(ctx, "versioned", strconv.Itoa(i))
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := client.Get(ctx, key).Err(); err != nil {
+			return 0, 0, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+	}
+	versioned = time.Since(start) / time.Duration(len(keys))
+	return direct, versioned, nil
+}
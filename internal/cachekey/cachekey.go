@@ -0,0 +1,99 @@
+// Package cachekey - 名前空間とバージョン番号を含むRedisのキャッシュキーと、バージョンの更新によるまとめての無効化
+//
+// キーは <名前空間>:<ファミリー>:v<バージョン>:<部分...> の形で作る。ファミリーのバージョン番号は
+// <名前空間>:version:<ファミリー> に保存し、INCRで1つ進めるとそのファミリーの既存のキーはすべて参照されなくなる
+// （古いキーは削除せず、有効期限で消える）。キーを探して削除する（SCAN + DEL）代わりに、1回のコマンドで無効化できる。
+package cachekey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Builder - 名前空間内のファミリーごとにバージョン番号を持つキーを作る
+type Builder struct {
+	client    redis.Cmdable
+	namespace string
+}
+
+// New - 名前空間を指定してBuilderを作成
+func New(client redis.Cmdable, namespace string) *Builder {
+	return &Builder{client: client, namespace: namespace}
+}
+
+// VersionKey - ファミリーのバージョン番号を保存するキー
+func (b *Builder) VersionKey(family string) string {
+	return b.namespace + ":version:" + family
+}
+
+// Pattern - ファミリーのすべてのバージョンのキーに一致するSCANのパターン
+func (b *Builder) Pattern(family string) string {
+	return b.namespace + ":" + family + ":*"
+}
+
+// Version - ファミリーの現在のバージョン番号（一度も無効化していなければ0）
+func (b *Builder) Version(ctx context.Context, family string) (int64, error) {
+	version, err := b.client.Get(ctx, b.VersionKey(family)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get version of %s: %w", family, err)
+	}
+	return version, nil
+}
+
+// Key - ファミリーの現在のバージョンのキー（バージョン番号の取得に1回のラウンドトリップがかかる）
+func (b *Builder) Key(ctx context.Context, family string, parts ...string) (string, error) {
+	version, err := b.Version(ctx, family)
+	if err != nil {
+		return "", err
+	}
+	return Format(b.namespace, family, version, parts...), nil
+}
+
+// Invalidate - ファミリーのバージョン番号を進め、既存のキーをすべて参照されなくする（進めた後のバージョンを返す）
+func (b *Builder) Invalidate(ctx context.Context, family string) (int64, error) {
+	version, err := b.client.Incr(ctx, b.VersionKey(family)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to bump version of %s: %w", family, err)
+	}
+	return version, nil
+}
+
+// Format - バージョン番号を含むキー
+func Format(namespace, family string, version int64, parts ...string) string {
+	fields := append([]string{namespace, family, "v" + strconv.FormatInt(version, 10)}, parts...)
+	return strings.Join(fields, ":")
+}
+
+// ScanDelete - パターンに一致するキーをSCANで探して削除し、削除した数とRedisへのラウンドトリップ数を返す
+//
+// バージョンを使わずにファミリーをまとめて無効化する方法。キーの数に比例してSCANとDELのラウンドトリップがかかる。
+func ScanDelete(ctx context.Context, client redis.Cmdable, pattern string, count int64) (deleted int, roundTrips int, err error) {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, pattern, count).Result()
+		roundTrips++
+		if err != nil {
+			return deleted, roundTrips, fmt.Errorf("failed to scan %s: %w", pattern, err)
+		}
+		if len(keys) > 0 {
+			n, err := client.Del(ctx, keys...).Result()
+			roundTrips++
+			if err != nil {
+				return deleted, roundTrips, fmt.Errorf("failed to delete keys: %w", err)
+			}
+			deleted += int(n)
+		}
+		if next == 0 {
+			return deleted, roundTrips, nil
+		}
+		cursor = next
+	}
+}
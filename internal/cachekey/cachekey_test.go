@@ -0,0 +1,76 @@
+package cachekey
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		family  string
+		version int64
+		parts   []string
+		want    string
+	}{
+		{"部分なし", "customers", 0, nil, "app:customers:v0"},
+		{"部分あり", "customers", 3, []string{"42", "summary"}, "app:customers:v3:42:summary"},
+	}
+	for _, tt := range tests {
+		if got := Format("app", tt.family, tt.version, tt.parts...); got != tt.want {
+			t.Errorf("%s: Format() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuilderKeyNames(t *testing.T) {
+	b := New(nil, "app")
+	if got := b.VersionKey("customers"); got != "app:version:customers" {
+		t.Errorf("VersionKey() = %q", got)
+	}
+	pattern := b.Pattern("customers")
+	if got := Format("app", "customers", 7, "1"); !strings.HasPrefix(got, strings.TrimSuffix(pattern, "*")) {
+		t.Errorf("Pattern() = %q does not match %q", pattern, got)
+	}
+	// バージョン番号のキーはファミリーのパターンに含めない（走査して削除しても番号は残る）
+	if strings.HasPrefix(b.VersionKey("customers"), strings.TrimSuffix(pattern, "*")) {
+		t.Errorf("Pattern() = %q matches version key", pattern)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"既定", DefaultConfig(), false},
+		{"キーなし", Config{Keys: 0, Runs: 1, ScanCount: 10}, true},
+		{"実行回数なし", Config{Keys: 1, Runs: 0, ScanCount: 10}, true},
+		{"COUNTなし", Config{Keys: 1, Runs: 1, ScanCount: 0}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReportSpeedup(t *testing.T) {
+	r := &Report{
+		Scan:          Invalidation{Median: 40 * time.Millisecond},
+		Version:       Invalidation{Median: 200 * time.Microsecond},
+		DirectRead:    100 * time.Microsecond,
+		VersionedRead: 180 * time.Microsecond,
+	}
+	if got, ok := r.Speedup(); !ok || got != 200 {
+		t.Errorf("Speedup() = %v, %v, want 200", got, ok)
+	}
+	if got := r.ReadOverhead(); got != 80*time.Microsecond {
+		t.Errorf("ReadOverhead() = %v, want 80µs", got)
+	}
+	if _, ok := (&Report{}).Speedup(); ok {
+		t.Error("Speedup() of empty report ok = true, want false")
+	}
+}
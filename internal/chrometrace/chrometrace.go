@@ -0,0 +1,190 @@
+// Package chrometrace - 手法の実行区間と実行した文の区間をChrome trace形式（Trace Event Format）のJSONに書き出す
+//
+// 書き出したファイルは chrome://tracing や Perfetto（https://ui.perfetto.dev）で開ける。
+// N+1の手法では短い文が接続のトラックに隙間なく並び、JOINの手法では1つの長い区間になる様子を時間軸で見せられる。
+package chrometrace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"oracle-n-plus-1-demo/internal/querylog"
+)
+
+// maxNameLength - 区間名にする文の長さの上限（全文は引数に残す）
+const maxNameLength = 60
+
+// pid - すべての区間を載せるプロセスの番号（トレースは1プロセス分だけ書き出す）
+const pid = 1
+
+// connectionSortBase - 接続のトラックの並び順の起点（手法のトラックを上に、接続のトラックを下に並べる）
+const connectionSortBase = 1000
+
+// Event - Trace Event Formatのイベント（完了イベント "X" とメタデータ "M" だけを使う）
+type Event struct {
+	Name string `json:"name"`
+	Cat  string `json:"cat,omitempty"`
+	Ph   string `json:"ph"`
+	// TS / Dur - 記録の開始からの時刻と区間の長さ（マイクロ秒）
+	TS   float64                `json:"ts"`
+	Dur  float64                `json:"dur,omitempty"`
+	PID  int                    `json:"pid"`
+	TID  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// File - 書き出すJSONの全体
+type File struct {
+	TraceEvents     []Event `json:"traceEvents"`
+	DisplayTimeUnit string  `json:"displayTimeUnit"`
+}
+
+// Recorder - 区間を記録する（querylog.Recorderとして接続に渡すと実行した文も記録する）
+//
+// 複数のゴルーチン・接続から同時に呼び出してよい。
+type Recorder struct {
+	mu     sync.Mutex
+	origin time.Time
+	opts   querylog.Options
+	events []Event
+	// tracks - トラック名ごとのスレッド番号（区間が重ならないよう、手法はシナリオごと、文は接続ごとに分ける）
+	tracks map[string]int
+	names  []string
+	sorts  []int
+}
+
+// New - Recorderのコンストラクタ（作成した時刻をトレースの0とする）
+func New(opts querylog.Options) *Recorder {
+	if opts.Redact == "" {
+		opts.Redact = querylog.RedactNone
+	}
+	return &Recorder{origin: time.Now(), opts: opts, tracks: make(map[string]int)}
+}
+
+// Span - trackに名前nameの区間を開始し、戻り値の関数で終了する
+func (r *Recorder) Span(track, name string, args map[string]interface{}) func() {
+	start := time.Now()
+	return func() {
+		r.add(track, 0, Event{Name: name, Cat: "method", Args: args}, start, time.Since(start))
+	}
+}
+
+// Log - 実行した文を接続ごとのトラックに記録する（V$ビューへの問い合わせはIncludeDictionaryの指定がなければ除く）
+func (r *Recorder) Log(e querylog.Entry) {
+	query := querylog.Statement(e.Query)
+	if !r.opts.IncludeDictionary && querylog.IsDictionary(query) {
+		return
+	}
+
+	args := map[string]interface{}{"statement": query}
+	if len(e.Args) > 0 {
+		args["parameters"] = querylog.Parameters(e.Args, r.opts.Redact)
+	}
+	if e.Rows >= 0 {
+		args["rows"] = e.Rows
+	}
+	cat := "sql"
+	if e.Err != nil {
+		args["error"] = e.Err.Error()
+		cat = "sql,error"
+	}
+	start := e.Start
+	if start.IsZero() {
+		start = time.Now().Add(-e.Duration)
+	}
+	r.add(fmt.Sprintf("接続 #%d", e.Session), connectionSortBase, Event{Name: spanName(query), Cat: cat, Args: args}, start, e.Duration)
+}
+
+// add - 区間を記録する
+func (r *Recorder) add(track string, sortBase int, ev Event, start time.Time, d time.Duration) {
+	ev.Ph = "X"
+	ev.PID = pid
+	ev.TS = micros(start.Sub(r.origin))
+	ev.Dur = micros(d)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ev.TID = r.track(track, sortBase)
+	r.events = append(r.events, ev)
+}
+
+// track - トラックのスレッド番号（初めて使うトラックは番号を振る。呼び出し側でロックする）
+func (r *Recorder) track(name string, sortBase int) int {
+	if tid, ok := r.tracks[name]; ok {
+		return tid
+	}
+	r.names = append(r.names, name)
+	tid := len(r.names)
+	r.sorts = append(r.sorts, sortBase+tid)
+	r.tracks[name] = tid
+	return tid
+}
+
+// Len - 記録した区間の数
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+// Snapshot - 記録した区間とトラック名のメタデータ（区間は開始時刻の順）
+func (r *Recorder) Snapshot() File {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]Event, 0, len(r.events)+2*len(r.names)+1)
+	events = append(events, Event{Name: "process_name", Ph: "M", PID: pid, Args: map[string]interface{}{"name": "oracle-n-plus-1-demo"}})
+	for i, name := range r.names {
+		tid := i + 1
+		events = append(events,
+			Event{Name: "thread_name", Ph: "M", PID: pid, TID: tid, Args: map[string]interface{}{"name": name}},
+			Event{Name: "thread_sort_index", Ph: "M", PID: pid, TID: tid, Args: map[string]interface{}{"sort_index": r.sorts[i]}})
+	}
+	spans := append([]Event(nil), r.events...)
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].TS < spans[j].TS })
+	return File{TraceEvents: append(events, spans...), DisplayTimeUnit: "ms"}
+}
+
+// Write - トレースをJSONで書き出す
+func (r *Recorder) Write(w io.Writer) error {
+	data, err := json.Marshal(r.Snapshot())
+	if err != nil {
+		return fmt.Errorf("failed to marshal trace: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write trace: %w", err)
+	}
+	return nil
+}
+
+// WriteFile - トレースをファイルに書き出す
+func (r *Recorder) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create trace file: %w", err)
+	}
+	if err := r.Write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// spanName - 区間名（長い文は先頭だけを残す）
+func spanName(query string) string {
+	if utf8.RuneCountInString(query) <= maxNameLength {
+		return query
+	}
+	return string([]rune(query)[:maxNameLength]) + "…"
+}
+
+// micros - 時間をマイクロ秒で表す
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
@@ -0,0 +1,109 @@
+package chrometrace
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/querylog"
+)
+
+func TestRecorderTracks(t *testing.T) {
+	r := New(querylog.Options{Redact: querylog.RedactStrings})
+	start := r.origin.Add(2 * time.Millisecond)
+	r.Log(querylog.Entry{Query: "SELECT 1 FROM dual", Rows: 1, Start: r.origin, Duration: time.Millisecond, Session: 1})
+	end := r.Span("orders", "N+1問題あり", map[string]interface{}{"method": "N+1"})
+	for i, id := range []int64{1001, 1002} {
+		r.Log(querylog.Entry{
+			Query:    "SELECT detail_id\n\t\tFROM order_details\n\t\tWHERE order_id = :1",
+			Args:     []driver.NamedValue{{Ordinal: 1, Value: id}},
+			Rows:     3,
+			Start:    start.Add(time.Duration(i) * time.Millisecond),
+			Duration: 500 * time.Microsecond,
+			Session:  2,
+		})
+	}
+	r.Log(querylog.Entry{Query: "SELECT value FROM v$mystat", Rows: 10, Start: start, Session: 2})
+	r.Log(querylog.Entry{Query: "SELECT * FROM customers WHERE email = :1", Args: []driver.NamedValue{{Ordinal: 1, Value: "a@example.com"}},
+		Rows: -1, Start: start, Err: errors.New("ORA-00942"), Session: 2})
+	end()
+
+	if got := r.Len(); got != 5 {
+		t.Fatalf("Len() = %d, want 5 (dictionary query skipped)", got)
+	}
+
+	names := make(map[int]string)
+	sorts := make(map[int]int)
+	var spans []Event
+	for _, ev := range r.Snapshot().TraceEvents {
+		switch {
+		case ev.Name == "thread_name":
+			names[ev.TID] = ev.Args["name"].(string)
+		case ev.Name == "thread_sort_index":
+			sorts[ev.TID] = ev.Args["sort_index"].(int)
+		case ev.Ph == "X":
+			spans = append(spans, ev)
+		}
+	}
+	// 手法の区間は終了したときにトラックを割り当てる
+	if names[1] != "接続 #1" || names[2] != "接続 #2" || names[3] != "orders" {
+		t.Errorf("track names = %v", names)
+	}
+	if !(sorts[3] < sorts[1] && sorts[1] < sorts[2]) {
+		t.Errorf("sort indexes = %v, want the method track above the connection tracks", sorts)
+	}
+	for i := 1; i < len(spans); i++ {
+		if spans[i].TS < spans[i-1].TS {
+			t.Errorf("spans are not sorted by start: %v", spans)
+		}
+	}
+
+	var query, failed *Event
+	for i := range spans {
+		switch {
+		case spans[i].TID == 2 && spans[i].Args["parameters"] == ":1 = 1001":
+			query = &spans[i]
+		case spans[i].Cat == "sql,error":
+			failed = &spans[i]
+		}
+	}
+	if query == nil {
+		t.Fatalf("span for order 1001 not found: %v", spans)
+	}
+	if query.Name != "SELECT detail_id FROM order_details WHERE order_id = :1" || query.TS != 2000 || query.Dur != 500 || query.Args["rows"] != int64(3) {
+		t.Errorf("query span = %+v", *query)
+	}
+	if failed == nil || failed.Args["parameters"] != ":1 = <redacted>" || failed.Args["error"] != "ORA-00942" {
+		t.Errorf("failed span = %+v", failed)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := New(querylog.Options{})
+	r.Log(querylog.Entry{Query: "SELECT " + strings.Repeat("x, ", 40) + "y FROM dual", Rows: 1, Start: r.origin, Session: 1})
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	var got struct {
+		TraceEvents []struct {
+			Name string `json:"name"`
+			Ph   string `json:"ph"`
+		} `json:"traceEvents"`
+		DisplayTimeUnit string `json:"displayTimeUnit"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if got.DisplayTimeUnit != "ms" || len(got.TraceEvents) != 4 {
+		t.Fatalf("trace = %+v, want process/thread metadata and 1 span", got)
+	}
+	span := got.TraceEvents[3]
+	if span.Ph != "X" || !strings.HasSuffix(span.Name, "…") || len([]rune(span.Name)) != maxNameLength+1 {
+		t.Errorf("span = %+v, want a truncated complete event", span)
+	}
+}
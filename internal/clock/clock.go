@@ -0,0 +1,64 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock - 計測に使う時計（テストでは時刻を進める操作を明示できるFakeに差し替える）
+type Clock interface {
+	// Now - 現在時刻
+	Now() time.Time
+	// Since - tからの経過時間
+	Since(t time.Time) time.Duration
+}
+
+// System - 実際の時刻を返す時計
+var System Clock = systemClock{}
+
+// systemClock - time.Now / time.Since をそのまま使う時計
+type systemClock struct{}
+
+// Now - 現在時刻
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Since - tからの経過時間
+func (systemClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+// OrSystem - cがnilの場合はSystemを返す
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System
+	}
+	return c
+}
+
+// Fake - Advanceを呼んだ分だけ進む時計（並行して使ってもよい）
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake - startを現在時刻とするFakeのコンストラクタ
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now - 現在時刻
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since - tからの経過時間
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Advance - 時刻をdだけ進める
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
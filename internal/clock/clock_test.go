@@ -0,0 +1,36 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	if got := f.Now(); !got.Equal(start) {
+		t.Fatalf("Now() = %v, want %v", got, start)
+	}
+
+	mark := f.Now()
+	f.Advance(1500 * time.Millisecond)
+	f.Advance(500 * time.Millisecond)
+
+	if got, want := f.Since(mark), 2*time.Second; got != want {
+		t.Errorf("Since() = %v, want %v", got, want)
+	}
+	if got, want := f.Now(), start.Add(2*time.Second); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+}
+
+func TestOrSystem(t *testing.T) {
+	if got := OrSystem(nil); got != System {
+		t.Errorf("OrSystem(nil) = %v, want System", got)
+	}
+	f := NewFake(time.Time{})
+	if got := OrSystem(f); got != f {
+		t.Errorf("OrSystem(f) = %v, want f", got)
+	}
+}
@@ -0,0 +1,521 @@
+// Package clustering - 明細の物理的な並び（受注IDに対するクラスタリング・ファクター）や格納方式が、JOINとバッチ取得の読み取りブロック数に与える影響を計測する
+//
+// 直近の受注の明細を格納方式の異なる表に複製し、同じ受注をJOINとIN句のバッチ取得で読む。複製の組み合わせは2つある。
+//   - SetLayout: 受注IDの順に並べ直したヒープ表、無作為に並べたヒープ表、(受注ID, 明細ID) を主キーにした索引構成表（IOT）と元の表
+//   - SetStorage: 無作為に並べた行を、非圧縮・BASIC圧縮・OLTP圧縮・属性クラスタリングの表に読み込んだもの
+package clustering
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/sessionstats"
+	"oracle-n-plus-1-demo/internal/sqlutil"
+	"oracle-n-plus-1-demo/internal/stats"
+	"oracle-n-plus-1-demo/repository"
+)
+
+const (
+	// DefaultOrders - 既定で読む受注の件数（受注IDの大きい順）
+	DefaultOrders = 1000
+	// DefaultRuns - 既定の、手法ごとの実行回数
+	DefaultRuns = 5
+	// sortedTable / scatteredTable / iotTable - 複製する明細の表（計測後に削除する）
+	sortedTable    = "NPLUS1_OD_SORTED"
+	scatteredTable = "NPLUS1_OD_SCATTERED"
+	iotTable       = "NPLUS1_OD_IOT"
+	// plainTable / basicTable / oltpTable / attrTable / attrBasicTable - SetStorageで複製する明細の表（計測後に削除する）
+	plainTable     = "NPLUS1_OD_PLAIN"
+	basicTable     = "NPLUS1_OD_BASIC"
+	oltpTable      = "NPLUS1_OD_OLTP"
+	attrTable      = "NPLUS1_OD_ATTRCLUS"
+	attrBasicTable = "NPLUS1_OD_ATTRCLUS_BASIC"
+	// originalIndex - 元の明細の表の受注IDの索引
+	originalIndex = "IDX_ORDER_DETAILS_ORDER_ID"
+)
+
+// 表の構成（USER_TABLES.IOT_TYPEに対応）
+const (
+	OrganizationHeap = "HEAP"
+	OrganizationIOT  = "IOT"
+)
+
+// 比較する複製の組み合わせ
+const (
+	// SetLayout - 行の並び（受注IDの順・無作為・IOT）を比較する
+	SetLayout = "layout"
+	// SetStorage - 圧縮と属性クラスタリングを比較する
+	SetStorage = "storage"
+)
+
+// Sets - 指定できる複製の組み合わせ
+var Sets = []string{SetLayout, SetStorage}
+
+// Config - 計測の設定
+type Config struct {
+	// Orders - 読む受注の件数（明細はその受注のものすべて）
+	Orders int
+	// Runs - 手法ごとの実行回数
+	Runs int
+	// Set - 比較する複製の組み合わせ
+	Set string
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{Orders: DefaultOrders, Runs: DefaultRuns, Set: SetLayout}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if c.Orders <= 0 {
+		return fmt.Errorf("orders must be positive: %d", c.Orders)
+	}
+	if c.Runs <= 0 {
+		return fmt.Errorf("runs must be positive: %d", c.Runs)
+	}
+	if _, ok := variantSets[c.Set]; !ok {
+		return fmt.Errorf("unknown set %q (%s)", c.Set, strings.Join(Sets, ", "))
+	}
+	return nil
+}
+
+// Layout - 受注IDの順に明細を読んだときのブロックの切り替わり
+type Layout struct {
+	Rows int64 `json:"rows"`
+	// Blocks - 明細が入っているブロック数（クラスタリング・ファクターの下限）
+	Blocks int64 `json:"blocks"`
+	// ClusteringFactor - 受注IDの順に読んだとき、直前の行と別のブロックに移った回数
+	ClusteringFactor int64 `json:"clustering_factor"`
+}
+
+// Score - クラスタリング・ファクターがブロック数（0）と行数（1）のどちらに近いか（算出できなければ false）
+func (l Layout) Score() (float64, bool) {
+	if l.Rows <= l.Blocks {
+		return 0, false
+	}
+	score := float64(l.ClusteringFactor-l.Blocks) / float64(l.Rows-l.Blocks)
+	return min(max(score, 0), 1), true
+}
+
+// Measure - 1つの手法の計測結果
+type Measure struct {
+	// Elapsed - 実行時間の中央値
+	Elapsed time.Duration `json:"elapsed"`
+	Rows    int           `json:"rows"`
+	// LogicalReads - 最後の回の論理読み取り（V$MYSTATを参照できない場合は0）
+	LogicalReads int64 `json:"logical_reads"`
+}
+
+// Variant - 明細の表の並び方ごとの計測結果
+type Variant struct {
+	Name         string `json:"name"`
+	Table        string `json:"table"`
+	Organization string `json:"organization"`
+	// Layout - 読む範囲の明細の並び（IOTは主キーの順に格納されるためnil）
+	Layout *Layout `json:"layout,omitempty"`
+	// Bytes - 明細のセグメントのサイズ（複製のみ。索引は含まず、IOTは主キーの索引）
+	Bytes int64   `json:"bytes,omitempty"`
+	Join  Measure `json:"join"`
+	Batch Measure `json:"batch"`
+	// Error - 複製を作れなかった理由（エディションやオプションで使えない格納方式。計測は行わない）
+	Error string `json:"error,omitempty"`
+}
+
+// Report - 並び方ごとのJOINとバッチ取得の比較
+type Report struct {
+	Set    string `json:"set"`
+	Orders int    `json:"orders"`
+	Runs   int    `json:"runs"`
+	// Dictionary - 元の表の受注IDの索引のオプティマイザ統計（表全体。統計がない場合はnil）
+	Dictionary *Layout   `json:"dictionary,omitempty"`
+	Variants   []Variant `json:"variants"`
+	// StatsUnavailable - V$MYSTATを参照できなかった
+	StatsUnavailable bool `json:"stats_unavailable,omitempty"`
+}
+
+// variant - 計測する明細の表と、その作り方
+type variant struct {
+	name, table, organization string
+	// create - 複製を作るCTAS（%d は読む受注の最小ID。元の表は空）
+	create string
+	// index - 複製に作る受注IDの索引（IOTは主キーを使うため空）
+	index string
+	// segment - サイズを調べるセグメント（空の場合は表と同じ名前）
+	segment string
+	// optional - エディションやオプションによっては作れない（作れなければその複製だけを飛ばす）
+	optional bool
+}
+
+// variantSets - 組み合わせごとの計測する明細の表（先頭を基準にする）
+var variantSets = map[string][]variant{
+	SetLayout:  layoutVariants,
+	SetStorage: storageVariants,
+}
+
+// layoutVariants - 行の並びを比較する明細の表（元の表を先頭に、並びの良い順）
+var layoutVariants = []variant{
+	{name: "元の表", table: "order_details", organization: OrganizationHeap},
+	{
+		name: "IOT（受注ID, 明細ID）", table: iotTable, organization: OrganizationIOT, segment: iotTable + "_PK",
+		create: `CREATE TABLE ` + iotTable + ` (detail_id, order_id, product_id, quantity, unit_price,
+			CONSTRAINT ` + iotTable + `_PK PRIMARY KEY (order_id, detail_id))
+			ORGANIZATION INDEX
+			AS SELECT detail_id, order_id, product_id, quantity, unit_price FROM order_details WHERE order_id >= %d`,
+	},
+	{
+		name: "受注IDの順に再ロード", table: sortedTable, organization: OrganizationHeap,
+		create: `CREATE TABLE ` + sortedTable + ` AS SELECT * FROM order_details WHERE order_id >= %d ORDER BY order_id, detail_id`,
+		index:  `CREATE INDEX ` + sortedTable + `_OID ON ` + sortedTable + ` (order_id)`,
+	},
+	{
+		name: "無作為な順に再ロード", table: scatteredTable, organization: OrganizationHeap,
+		create: `CREATE TABLE ` + scatteredTable + ` AS SELECT * FROM order_details WHERE order_id >= %d ORDER BY DBMS_RANDOM.VALUE`,
+		index:  `CREATE INDEX ` + scatteredTable + `_OID ON ` + scatteredTable + ` (order_id)`,
+	},
+}
+
+// storageVariants - 圧縮と属性クラスタリングを比較する明細の表
+//
+// どの表も同じく無作為に並べた行をCTAS（ダイレクト・パス）で読み込む。BASIC圧縮と属性クラスタリングは
+// ダイレクト・パスの読み込みでだけ効くため、CTASで作った直後の状態を比べる。
+var storageVariants = []variant{
+	{
+		name: "非圧縮", table: plainTable, organization: OrganizationHeap,
+		create: `CREATE TABLE ` + plainTable + ` AS SELECT * FROM order_details WHERE order_id >= %d ORDER BY DBMS_RANDOM.VALUE`,
+		index:  `CREATE INDEX ` + plainTable + `_OID ON ` + plainTable + ` (order_id)`,
+	},
+	{
+		name: "BASIC圧縮", table: basicTable, organization: OrganizationHeap, optional: true,
+		create: `CREATE TABLE ` + basicTable + ` ROW STORE COMPRESS BASIC
+			AS SELECT * FROM order_details WHERE order_id >= %d ORDER BY DBMS_RANDOM.VALUE`,
+		index: `CREATE INDEX ` + basicTable + `_OID ON ` + basicTable + ` (order_id)`,
+	},
+	{
+		name: "OLTP圧縮", table: oltpTable, organization: OrganizationHeap, optional: true,
+		create: `CREATE TABLE ` + oltpTable + ` ROW STORE COMPRESS ADVANCED
+			AS SELECT * FROM order_details WHERE order_id >= %d ORDER BY DBMS_RANDOM.VALUE`,
+		index: `CREATE INDEX ` + oltpTable + `_OID ON ` + oltpTable + ` (order_id)`,
+	},
+	{
+		name: "属性クラスタリング（受注ID）", table: attrTable, organization: OrganizationHeap, optional: true,
+		create: `CREATE TABLE ` + attrTable + ` CLUSTERING BY LINEAR ORDER (order_id) YES ON LOAD
+			AS SELECT * FROM order_details WHERE order_id >= %d ORDER BY DBMS_RANDOM.VALUE`,
+		index: `CREATE INDEX ` + attrTable + `_OID ON ` + attrTable + ` (order_id)`,
+	},
+	{
+		name: "属性クラスタリング + BASIC圧縮", table: attrBasicTable, organization: OrganizationHeap, optional: true,
+		create: `CREATE TABLE ` + attrBasicTable + ` ROW STORE COMPRESS BASIC CLUSTERING BY LINEAR ORDER (order_id) YES ON LOAD
+			AS SELECT * FROM order_details WHERE order_id >= %d ORDER BY DBMS_RANDOM.VALUE`,
+		index: `CREATE INDEX ` + attrBasicTable + `_OID ON ` + attrBasicTable + ` (order_id)`,
+	},
+}
+
+// copyTables - 複製の表の名前（SQLに埋め込むため許可リストに登録する）
+func copyTables() []string {
+	var tables []string
+	for _, set := range Sets {
+		for _, v := range variantSets[set] {
+			if v.create != "" {
+				tables = append(tables, v.table)
+			}
+		}
+	}
+	return tables
+}
+
+// runner - 1つの接続（セッション）で並び方ごとの読み取りを計測する
+type runner struct {
+	q         repository.DBTX
+	collector *sessionstats.Collector
+	// variants - 計測する明細の表
+	variants []variant
+	// minOrderID - 読む受注の最小ID（受注IDの大きい順にcfg.Orders件）
+	minOrderID int64
+}
+
+// Run - 直近の受注cfg.Orders件の明細をcfg.Setの表に複製し、JOINとバッチ取得でcfg.Runs回ずつ読んで比較する
+//
+// V$MYSTATを同じセッションで参照するため、専用の接続を1本確保して順に実行する。複製は終了時に削除する。
+// 使えない格納方式（optionalな複製）は、作成のエラーを記録して計測を飛ばす。
+func Run(ctx context.Context, db *sql.DB, cfg Config) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := sqlutil.AllowIdentifiers(copyTables()...); err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			fmt.Printf("conn.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	r := &runner{q: repository.NewConnDB(conn), variants: variantSets[cfg.Set]}
+	report := &Report{Set: cfg.Set, Runs: cfg.Runs}
+	if r.collector, err = sessionstats.NewCollector(r.q, []string{sessionstats.LogicalReads}); err != nil {
+		report.StatsUnavailable = true
+	}
+
+	if err := r.q.QueryRow(`
+		SELECT NVL(MIN(order_id), 0), COUNT(*) FROM (SELECT order_id FROM orders ORDER BY order_id DESC)
+		WHERE ROWNUM <= :1`, cfg.Orders).Scan(&r.minOrderID, &report.Orders); err != nil {
+		return nil, fmt.Errorf("failed to query order range: %w", err)
+	}
+	if report.Orders == 0 {
+		return nil, errors.New("no orders found")
+	}
+	report.Dictionary = r.dictionary()
+
+	defer r.dropCopies()
+	skipped, err := r.createCopies()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range r.variants {
+		result := Variant{Name: v.name, Table: v.table, Organization: v.organization}
+		if err, ok := skipped[v.table]; ok {
+			result.Error = err.Error()
+			report.Variants = append(report.Variants, result)
+			continue
+		}
+		if v.create != "" {
+			if result.Bytes, err = r.segmentBytes(v); err != nil {
+				return nil, fmt.Errorf("%s: %w", v.name, err)
+			}
+		}
+		if v.organization == OrganizationHeap {
+			layout, err := r.layout(v.table)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.name, err)
+			}
+			result.Layout = layout
+		}
+		if result.Join, err = r.measure(cfg.Runs, func() (int, error) { return r.readJoin(v.table) }); err != nil {
+			return nil, fmt.Errorf("%s: JOIN: %w", v.name, err)
+		}
+		if result.Batch, err = r.measure(cfg.Runs, func() (int, error) { return r.readBatch(v.table) }); err != nil {
+			return nil, fmt.Errorf("%s: batch: %w", v.name, err)
+		}
+		report.Variants = append(report.Variants, result)
+	}
+	return report, nil
+}
+
+// createCopies - 複製を作り、オプティマイザ統計を収集する（前回の複製が残っていれば作り直す）
+//
+// optionalな複製を作れなかった場合は、途中まで作ったものを削除し、表ごとのエラーとして返す。
+func (r *runner) createCopies() (map[string]error, error) {
+	r.dropCopies()
+	skipped := make(map[string]error)
+	for _, v := range r.variants {
+		if v.create == "" {
+			continue
+		}
+		statements := []string{fmt.Sprintf(v.create, r.minOrderID)}
+		if v.index != "" {
+			statements = append(statements, v.index)
+		}
+		var err error
+		for _, statement := range statements {
+			if _, err = r.q.Exec(statement); err != nil {
+				break
+			}
+		}
+		if err != nil && v.optional {
+			skipped[v.table] = err
+			r.dropCopy(v.table)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s (CREATE TABLE権限が必要です): %w", v.table, err)
+		}
+		if _, err := r.q.Exec("BEGIN DBMS_STATS.GATHER_TABLE_STATS(USER, :1, cascade => TRUE); END;", v.table); err != nil {
+			return nil, fmt.Errorf("failed to gather stats on %s: %w", v.table, err)
+		}
+	}
+	return skipped, nil
+}
+
+// dropCopies - 複製の表を削除する
+func (r *runner) dropCopies() {
+	for _, v := range r.variants {
+		if v.create != "" {
+			r.dropCopy(v.table)
+		}
+	}
+}
+
+// dropCopy - 複製の表を1つ削除する（存在しない場合のエラーは無視する）
+func (r *runner) dropCopy(table string) {
+	var count int
+	if err := r.q.QueryRow("SELECT COUNT(*) FROM user_tables WHERE table_name = :1", table).Scan(&count); err != nil || count == 0 {
+		return
+	}
+	if _, err := r.q.Exec("DROP TABLE " + table + " PURGE"); err != nil {
+		fmt.Printf("failed to drop %s: %v\n", table, err)
+	}
+}
+
+// segmentBytes - 複製の明細のセグメントのサイズ（USER_SEGMENTS）
+func (r *runner) segmentBytes(v variant) (int64, error) {
+	segment := v.segment
+	if segment == "" {
+		segment = v.table
+	}
+	var bytes int64
+	if err := r.q.QueryRow("SELECT NVL(SUM(bytes), 0) FROM user_segments WHERE segment_name = :1", segment).Scan(&bytes); err != nil {
+		return 0, fmt.Errorf("failed to query segment size: %w", err)
+	}
+	return bytes, nil
+}
+
+// layout - 読む範囲の明細を受注IDの順に並べ、ブロックが切り替わる回数（クラスタリング・ファクター）を数える
+//
+// 索引のクラスタリング・ファクターと同じ定義を、統計を収集せずに読む範囲だけについて求める（ヒープ表のみ）。
+func (r *runner) layout(table string) (*Layout, error) {
+	var l Layout
+	if err := r.q.QueryRow(fmt.Sprintf(`
+		SELECT COUNT(*), COUNT(DISTINCT fno || '.' || blk),
+			NVL(SUM(CASE WHEN fno = prev_fno AND blk = prev_blk THEN 0 ELSE 1 END), 0)
+		FROM (
+			SELECT fno, blk,
+				LAG(fno) OVER (ORDER BY order_id, rid) prev_fno,
+				LAG(blk) OVER (ORDER BY order_id, rid) prev_blk
+			FROM (
+				SELECT order_id, ROWID rid,
+					DBMS_ROWID.ROWID_RELATIVE_FNO(ROWID) fno, DBMS_ROWID.ROWID_BLOCK_NUMBER(ROWID) blk
+				FROM %s
+				WHERE order_id >= :1
+			)
+		)`, table), r.minOrderID).Scan(&l.Rows, &l.Blocks, &l.ClusteringFactor); err != nil {
+		return nil, fmt.Errorf("failed to compute clustering factor: %w", err)
+	}
+	return &l, nil
+}
+
+// dictionary - 元の表の受注IDの索引のクラスタリング・ファクター（統計がなければnil）
+func (r *runner) dictionary() *Layout {
+	var rows, blocks, factor sql.NullInt64
+	if err := r.q.QueryRow(`
+		SELECT t.num_rows, t.blocks, i.clustering_factor
+		FROM user_indexes i
+		JOIN user_tables t ON t.table_name = i.table_name
+		WHERE i.index_name = :1`, originalIndex).Scan(&rows, &blocks, &factor); err != nil {
+		return nil
+	}
+	if !rows.Valid || !blocks.Valid || !factor.Valid {
+		return nil
+	}
+	return &Layout{Rows: rows.Int64, Blocks: blocks.Int64, ClusteringFactor: factor.Int64}
+}
+
+// measure - 1回読んでカーソルを共有プールに載せてから、runs回読んで実行時間の中央値と最後の回の論理読み取りを記録する
+func (r *runner) measure(runs int, read func() (int, error)) (Measure, error) {
+	var m Measure
+	if _, err := read(); err != nil {
+		return m, err
+	}
+	durations := make([]time.Duration, 0, runs)
+	for i := 0; i < runs; i++ {
+		var before sessionstats.Stats
+		var err error
+		if r.collector != nil {
+			if before, err = r.collector.Snapshot(); err != nil {
+				return m, err
+			}
+		}
+		start := time.Now()
+		m.Rows, err = read()
+		durations = append(durations, time.Since(start))
+		if err != nil {
+			return m, err
+		}
+		if r.collector != nil {
+			delta, err := r.collector.Delta(before)
+			if err != nil {
+				return m, err
+			}
+			m.LogicalReads = delta[sessionstats.LogicalReads]
+		}
+	}
+	m.Elapsed = stats.MedianDuration(durations)
+	return m, nil
+}
+
+// readJoin - 受注と明細をJOINで読み、行数を返す
+func (r *runner) readJoin(table string) (count int, err error) {
+	rows, err := r.q.Query(fmt.Sprintf(`
+		SELECT o.order_id, o.total_amount, od.detail_id, od.quantity, od.unit_price
+		FROM orders o
+		JOIN %s od ON od.order_id = o.order_id
+		WHERE o.order_id >= :1`, table), r.minOrderID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var orderID, detailID, quantity int64
+		var totalAmount, unitPrice float64
+		if err := rows.Scan(&orderID, &totalAmount, &detailID, &quantity, &unitPrice); err != nil {
+			return 0, fmt.Errorf("failed to scan order row: %w", err)
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// readBatch - 受注を読み、その受注IDのIN句で明細をまとめて読み、明細の行数を返す
+func (r *runner) readBatch(table string) (int, error) {
+	rows, err := r.q.Query("SELECT order_id, total_amount FROM orders WHERE order_id >= :1", r.minOrderID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query orders: %w", err)
+	}
+	var orderIDs []int64
+	for rows.Next() {
+		var orderID int64
+		var totalAmount float64
+		if err := rows.Scan(&orderID, &totalAmount); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("failed to scan order row: %w", err)
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return 0, err
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = sqlutil.QueryIn(r.q, fmt.Sprintf(`
+		SELECT detail_id, order_id, quantity, unit_price
+		FROM %s
+		WHERE order_id IN (?)`, table), sqlutil.DefaultInChunkSize, func(rows *sql.Rows) error {
+		var detailID, orderID, quantity int64
+		var unitPrice float64
+		if err := rows.Scan(&detailID, &orderID, &quantity, &unitPrice); err != nil {
+			return fmt.Errorf("failed to scan detail row: %w", err)
+		}
+		count++
+		return nil
+	}, orderIDs)
+	return count, err
+}
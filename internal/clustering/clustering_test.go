@@ -0,0 +1,82 @@
+package clustering
+
+import "testing"
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "既定", cfg: DefaultConfig()},
+		{name: "受注0件", cfg: Config{Orders: 0, Runs: 1, Set: SetLayout}, wantErr: true},
+		{name: "実行0回", cfg: Config{Orders: 1, Runs: 0, Set: SetLayout}, wantErr: true},
+		{name: "格納方式の比較", cfg: Config{Orders: 1, Runs: 1, Set: SetStorage}},
+		{name: "未知の組み合わせ", cfg: Config{Orders: 1, Runs: 1, Set: "partition"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := tt.cfg.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLayoutScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout Layout
+		want   float64
+		wantOK bool
+	}{
+		{name: "ブロック数と同じ（理想）", layout: Layout{Rows: 1000, Blocks: 10, ClusteringFactor: 10}, want: 0, wantOK: true},
+		{name: "行数と同じ（最悪）", layout: Layout{Rows: 1000, Blocks: 10, ClusteringFactor: 1000}, want: 1, wantOK: true},
+		{name: "中間", layout: Layout{Rows: 110, Blocks: 10, ClusteringFactor: 60}, want: 0.5, wantOK: true},
+		{name: "1ブロックに1行", layout: Layout{Rows: 10, Blocks: 10, ClusteringFactor: 10}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.layout.Score()
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("%s: Score() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestVariants(t *testing.T) {
+	for _, set := range Sets {
+		variants := variantSets[set]
+		if len(variants) == 0 {
+			t.Errorf("%s: no variants", set)
+			continue
+		}
+		if variants[0].optional {
+			t.Errorf("%s: baseline %s must not be optional", set, variants[0].name)
+		}
+		for _, v := range variants {
+			if v.create == "" {
+				if v.table != "order_details" {
+					t.Errorf("%s: copy without CTAS", v.name)
+				}
+				continue
+			}
+			if v.organization == OrganizationIOT && v.index != "" {
+				t.Errorf("%s: IOT should use its primary key instead of a separate index", v.name)
+			}
+			if v.organization == OrganizationHeap && v.index == "" {
+				t.Errorf("%s: heap copy needs an order_id index", v.name)
+			}
+		}
+	}
+}
+
+func TestCopyTablesAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, table := range copyTables() {
+		if seen[table] {
+			t.Errorf("duplicate copy table %s", table)
+		}
+		seen[table] = true
+	}
+}
@@ -0,0 +1,398 @@
+// Package coldread - バッファキャッシュに載っていない状態（コールド）からの読み取りを計測し、キャッシュのヒット率を評価する基準にする
+package coldread
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/sessionstats"
+	"oracle-n-plus-1-demo/internal/sqlutil"
+	"oracle-n-plus-1-demo/internal/stats"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// コールドな状態の作り方
+const (
+	// ModeAuto - バッファキャッシュをフラッシュし、権限がなければ新しいセグメントを読む
+	ModeAuto = "auto"
+	// ModeFlush - ALTER SYSTEM FLUSH BUFFER_CACHE でバッファキャッシュを空にしてから元の表を読む
+	ModeFlush = "flush"
+	// ModeFreshSegment - 元の表をCREATE TABLE AS SELECTで複製し、一度も読まれていないセグメントを読む
+	//
+	// CTASはダイレクト・パスで書き込むため、複製したブロックはバッファキャッシュに載らない
+	// （一度も読まれていないパーティションを読むのと同じ状態になる）。
+	ModeFreshSegment = "fresh-segment"
+)
+
+// Modes - 指定できるコールドな状態の作り方
+var Modes = []string{ModeAuto, ModeFlush, ModeFreshSegment}
+
+const (
+	// DefaultOrders - 既定で読む受注の件数（受注IDの大きい順）
+	DefaultOrders = 1000
+	// DefaultRuns - 既定のウォーム（2回目以降）の実行回数
+	DefaultRuns = 5
+	// copyOrders / copyDetails - ModeFreshSegmentで作成する複製の表（計測後に削除する）
+	copyOrders  = "NPLUS1_COLD_ORDERS"
+	copyDetails = "NPLUS1_COLD_ORDER_DETAILS"
+	// FastReadThreshold - これより短い1回あたりの読み取り待機は、ディスクではなくOSのファイルシステムキャッシュ
+	// （またはストレージのキャッシュ）から返った可能性が高い
+	FastReadThreshold = 500 * time.Microsecond
+)
+
+// statNames - 取得するセッション統計
+var statNames = []string{
+	sessionstats.LogicalReads,
+	sessionstats.PhysicalReads,
+	sessionstats.PhysicalReadsCache,
+	sessionstats.PhysicalReadsDirect,
+}
+
+// readEvents - データファイルの読み取りの待機イベント
+var readEvents = []string{
+	"db file sequential read",
+	"db file scattered read",
+	"db file parallel read",
+	"direct path read",
+}
+
+// Config - 計測の設定
+type Config struct {
+	// Orders - 読む受注の件数（明細はその受注のものすべて）
+	Orders int
+	// Runs - ウォーム（2回目以降）の実行回数
+	Runs int
+	// Mode - コールドな状態の作り方
+	Mode string
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{Orders: DefaultOrders, Runs: DefaultRuns, Mode: ModeAuto}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if c.Orders <= 0 {
+		return fmt.Errorf("orders must be positive: %d", c.Orders)
+	}
+	if c.Runs <= 0 {
+		return fmt.Errorf("runs must be positive: %d", c.Runs)
+	}
+	for _, mode := range Modes {
+		if c.Mode == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mode %q (%s)", c.Mode, strings.Join(Modes, ", "))
+}
+
+// Wait - 読み取りの待機イベントの回数と時間
+type Wait struct {
+	Event string        `json:"event"`
+	Waits int64         `json:"waits"`
+	Time  time.Duration `json:"time"`
+}
+
+// Phase - コールドまたはウォームの読み取りの計測結果
+type Phase struct {
+	Elapsed time.Duration `json:"elapsed"`
+	Rows    int           `json:"rows"`
+	// Stats - 読み取り中のセッション統計の差分（V$MYSTATを参照できない場合はnil）
+	Stats sessionstats.Stats `json:"session_stats,omitempty"`
+	// Waits - 読み取り中に増えた読み取りの待機（V$SESSION_EVENTを参照できない場合はnil）
+	Waits []Wait `json:"waits,omitempty"`
+}
+
+// HitRatio - バッファキャッシュのヒット率（%）（論理読み取りがなければ算出不可）
+//
+// ダイレクト・パス読み取りはキャッシュを経由しないため、キャッシュに読み込んだブロックだけをミスとして数える。
+func (p Phase) HitRatio() (float64, bool) {
+	logical := p.Stats[sessionstats.LogicalReads]
+	if logical <= 0 {
+		return 0, false
+	}
+	hit := float64(logical-p.Stats[sessionstats.PhysicalReadsCache]) / float64(logical) * 100
+	return max(hit, 0), true
+}
+
+// AvgReadWait - 読み取りの待機1回あたりの時間（待機がなければ算出不可）
+func (p Phase) AvgReadWait() (time.Duration, bool) {
+	var waits int64
+	var total time.Duration
+	for _, w := range p.Waits {
+		waits += w.Waits
+		total += w.Time
+	}
+	if waits == 0 {
+		return 0, false
+	}
+	return total / time.Duration(waits), true
+}
+
+// Report - コールドとウォームの読み取りの比較
+type Report struct {
+	// Mode - 実際に使ったコールドな状態の作り方（ModeAutoでは ModeFlush か ModeFreshSegment）
+	Mode string `json:"mode"`
+	// FlushError - ModeAutoでフラッシュできず新しいセグメントに切り替えた理由
+	FlushError string `json:"flush_error,omitempty"`
+	Orders     int    `json:"orders"`
+	Runs       int    `json:"runs"`
+	Cold       Phase  `json:"cold"`
+	// Warm - 2回目以降の実行（実行時間は中央値、統計と待機は最後の回）
+	Warm Phase `json:"warm"`
+	// FilesystemIO - 初期化パラメータ filesystemio_options（V$PARAMETERを参照できない場合は空）
+	FilesystemIO string `json:"filesystemio_options,omitempty"`
+	// StatsUnavailable / WaitsUnavailable - V$MYSTAT・V$SESSION_EVENTを参照できなかった
+	StatsUnavailable bool `json:"stats_unavailable,omitempty"`
+	WaitsUnavailable bool `json:"waits_unavailable,omitempty"`
+}
+
+// Speedup - ウォームの実行がコールドの何倍速いか
+func (r *Report) Speedup() float64 {
+	if r.Warm.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Cold.Elapsed) / float64(r.Warm.Elapsed)
+}
+
+// FilesystemCacheLikely - コールドの読み取りがディスクではなくファイルシステムキャッシュから返った可能性が高いか
+//
+// Oracleの物理読み取りはバッファキャッシュになかったことを示すだけで、OSのページキャッシュから返っても数えられる。
+func (r *Report) FilesystemCacheLikely() bool {
+	avg, ok := r.Cold.AvgReadWait()
+	return ok && r.Cold.Stats[sessionstats.PhysicalReads] > 0 && avg < FastReadThreshold
+}
+
+// reader - 1つの接続（セッション）でコールドとウォームの読み取りを計測する
+type reader struct {
+	q         repository.DBTX
+	collector *sessionstats.Collector
+	// waitsUnavailable - V$SESSION_EVENTを参照できない
+	waitsUnavailable bool
+	// orders / details - 読む表（ModeFreshSegmentでは複製）
+	orders, details string
+	// minOrderID - 読む受注の最小ID（受注IDの大きい順にcfg.Orders件）
+	minOrderID int64
+}
+
+// Run - 受注cfg.Orders件とその明細をコールドな状態から1回、続けてウォームでcfg.Runs回読み、統計を比較する
+//
+// V$MYSTAT・V$SESSION_EVENTを同じセッションで参照するため、専用の接続を1本確保して順に実行する。
+func Run(ctx context.Context, db *sql.DB, cfg Config) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			fmt.Printf("conn.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	r := &reader{q: repository.NewConnDB(conn), orders: "orders", details: "order_details"}
+	report := &Report{Mode: cfg.Mode, Runs: cfg.Runs}
+	if r.collector, err = sessionstats.NewCollector(r.q, statNames); err != nil {
+		report.StatsUnavailable = true
+	}
+	if _, err := r.sessionWaits(); err != nil {
+		r.waitsUnavailable = true
+		report.WaitsUnavailable = true
+	}
+	report.FilesystemIO = r.filesystemIO()
+
+	if err := r.q.QueryRow(`
+		SELECT NVL(MIN(order_id), 0), COUNT(*) FROM (SELECT order_id FROM orders ORDER BY order_id DESC)
+		WHERE ROWNUM <= :1`, cfg.Orders).Scan(&r.minOrderID, &report.Orders); err != nil {
+		return nil, fmt.Errorf("failed to query order range: %w", err)
+	}
+	if report.Orders == 0 {
+		return nil, errors.New("no orders found")
+	}
+
+	if cfg.Mode != ModeFreshSegment {
+		// カーソルを共有プールに載せておき、コールドの計測にハードパースのディクショナリ読み取りを含めない
+		if _, err := r.read(); err != nil {
+			return nil, err
+		}
+		_, err := r.q.Exec("ALTER SYSTEM FLUSH BUFFER_CACHE")
+		switch {
+		case err == nil:
+			report.Mode = ModeFlush
+		case cfg.Mode == ModeAuto:
+			report.Mode = ModeFreshSegment
+			report.FlushError = err.Error()
+		default:
+			return nil, fmt.Errorf("failed to flush buffer cache (ALTER SYSTEM権限が必要です): %w", err)
+		}
+	}
+	if report.Mode == ModeFreshSegment {
+		if err := r.createCopies(); err != nil {
+			return nil, err
+		}
+		defer r.dropCopies()
+	}
+
+	if report.Cold, err = r.measure(); err != nil {
+		return nil, fmt.Errorf("cold read: %w", err)
+	}
+
+	durations := make([]time.Duration, 0, cfg.Runs)
+	for i := 0; i < cfg.Runs; i++ {
+		if report.Warm, err = r.measure(); err != nil {
+			return nil, fmt.Errorf("warm read: %w", err)
+		}
+		durations = append(durations, report.Warm.Elapsed)
+	}
+	report.Warm.Elapsed = stats.MedianDuration(durations)
+
+	return report, nil
+}
+
+// measure - 1回読み、セッション統計と読み取りの待機の差分を記録する
+func (r *reader) measure() (Phase, error) {
+	var phase Phase
+	var beforeStats sessionstats.Stats
+	var beforeWaits map[string]Wait
+	var err error
+	if r.collector != nil {
+		if beforeStats, err = r.collector.Snapshot(); err != nil {
+			return phase, err
+		}
+	}
+	if !r.waitsUnavailable {
+		if beforeWaits, err = r.sessionWaits(); err != nil {
+			return phase, err
+		}
+	}
+
+	start := time.Now()
+	phase.Rows, err = r.read()
+	phase.Elapsed = time.Since(start)
+	if err != nil {
+		return phase, err
+	}
+
+	if r.collector != nil {
+		if phase.Stats, err = r.collector.Delta(beforeStats); err != nil {
+			return phase, err
+		}
+	}
+	if !r.waitsUnavailable {
+		after, err := r.sessionWaits()
+		if err != nil {
+			return phase, err
+		}
+		for _, event := range readEvents {
+			w := Wait{Event: event, Waits: after[event].Waits - beforeWaits[event].Waits, Time: after[event].Time - beforeWaits[event].Time}
+			if w.Waits > 0 {
+				phase.Waits = append(phase.Waits, w)
+			}
+		}
+	}
+	return phase, nil
+}
+
+// read - 受注とその明細をJOINで読み、行数を返す
+func (r *reader) read() (count int, err error) {
+	rows, err := r.q.Query(fmt.Sprintf(`
+		SELECT o.order_id, o.total_amount, od.detail_id, od.quantity, od.unit_price
+		FROM %s o
+		JOIN %s od ON od.order_id = o.order_id
+		WHERE o.order_id >= :1`, r.orders, r.details), r.minOrderID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var orderID, detailID, quantity int64
+		var totalAmount, unitPrice float64
+		if err := rows.Scan(&orderID, &totalAmount, &detailID, &quantity, &unitPrice); err != nil {
+			return 0, fmt.Errorf("failed to scan order row: %w", err)
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// createCopies - 読む受注とその明細をCTASで複製する（前回の複製が残っていれば作り直す）
+//
+// 複製は元の表から直接選択する。作成した複製の表を読んで次の複製を作ると、そのブロックがキャッシュに載ってしまう。
+func (r *reader) createCopies() error {
+	r.dropCopies()
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM orders WHERE order_id >= %d", copyOrders, r.minOrderID),
+		fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM order_details WHERE order_id >= %d", copyDetails, r.minOrderID),
+	}
+	for _, statement := range statements {
+		if _, err := r.q.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create copy (CREATE TABLE権限が必要です): %w", err)
+		}
+	}
+	r.orders, r.details = copyOrders, copyDetails
+	return nil
+}
+
+// dropCopies - 複製の表を削除する（存在しない場合のエラーは無視する）
+func (r *reader) dropCopies() {
+	for _, table := range []string{copyDetails, copyOrders} {
+		var count int
+		if err := r.q.QueryRow("SELECT COUNT(*) FROM user_tables WHERE table_name = :1", table).Scan(&count); err != nil || count == 0 {
+			continue
+		}
+		if _, err := r.q.Exec("DROP TABLE " + table + " PURGE"); err != nil {
+			fmt.Printf("failed to drop %s: %v\n", table, err)
+		}
+	}
+}
+
+// sessionWaits - 現在のセッションの読み取りの待機の累計（V$SESSION_EVENTの参照権限が必要）
+func (r *reader) sessionWaits() (map[string]Wait, error) {
+	rows, err := r.q.Query(fmt.Sprintf(`
+		SELECT event, total_waits, time_waited_micro
+		FROM v$session_event
+		WHERE sid = SYS_CONTEXT('USERENV', 'SID')
+		AND event IN (%s)`, sqlutil.Placeholders(len(readEvents))), sqlutil.StringArgs(readEvents)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v$session_event: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	waits := make(map[string]Wait)
+	for rows.Next() {
+		var w Wait
+		var micros int64
+		if err := rows.Scan(&w.Event, &w.Waits, &micros); err != nil {
+			return nil, fmt.Errorf("failed to scan session event row: %w", err)
+		}
+		w.Time = time.Duration(micros) * time.Microsecond
+		waits[w.Event] = w
+	}
+	return waits, rows.Err()
+}
+
+// filesystemIO - 初期化パラメータ filesystemio_options（参照できなければ空）
+func (r *reader) filesystemIO() string {
+	var value sql.NullString
+	if err := r.q.QueryRow("SELECT value FROM v$parameter WHERE name = 'filesystemio_options'").Scan(&value); err != nil {
+		return ""
+	}
+	return value.String
+}
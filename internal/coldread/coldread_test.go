@@ -0,0 +1,73 @@
+package coldread
+
+import (
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "default", cfg: DefaultConfig()},
+		{name: "fresh segment", cfg: Config{Orders: 10, Runs: 1, Mode: ModeFreshSegment}},
+		{name: "no orders", cfg: Config{Orders: 0, Runs: 5, Mode: ModeAuto}, wantErr: true},
+		{name: "no runs", cfg: Config{Orders: 10, Runs: 0, Mode: ModeAuto}, wantErr: true},
+		{name: "unknown mode", cfg: Config{Orders: 10, Runs: 5, Mode: "partition"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPhaseHitRatio(t *testing.T) {
+	phase := Phase{Stats: sessionstats.Stats{
+		sessionstats.LogicalReads:        200,
+		sessionstats.PhysicalReads:       80,
+		sessionstats.PhysicalReadsCache:  50,
+		sessionstats.PhysicalReadsDirect: 30,
+	}}
+	if got, ok := phase.HitRatio(); !ok || got != 75 {
+		t.Errorf("HitRatio() = %v, %v, want 75, true", got, ok)
+	}
+
+	if _, ok := (Phase{}).HitRatio(); ok {
+		t.Error("HitRatio() without logical reads should not be available")
+	}
+}
+
+func TestFilesystemCacheLikely(t *testing.T) {
+	cold := func(physical int64, waits ...Wait) *Report {
+		return &Report{Cold: Phase{Stats: sessionstats.Stats{sessionstats.PhysicalReads: physical}, Waits: waits}}
+	}
+	tests := []struct {
+		name   string
+		report *Report
+		want   bool
+	}{
+		{
+			name:   "page cache",
+			report: cold(100, Wait{Event: "db file sequential read", Waits: 100, Time: 5 * time.Millisecond}),
+			want:   true,
+		},
+		{
+			name: "disk",
+			report: cold(100,
+				Wait{Event: "db file sequential read", Waits: 60, Time: 300 * time.Millisecond},
+				Wait{Event: "db file scattered read", Waits: 40, Time: 200 * time.Millisecond}),
+		},
+		{name: "no waits", report: cold(100)},
+		{name: "no physical reads", report: cold(0, Wait{Event: "db file sequential read", Waits: 1, Time: time.Microsecond})},
+	}
+	for _, tt := range tests {
+		if got := tt.report.FilesystemCacheLikely(); got != tt.want {
+			t.Errorf("%s: FilesystemCacheLikely() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
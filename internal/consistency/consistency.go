@@ -0,0 +1,324 @@
+// Package consistency - 受注と明細を別々のクエリで読む手法が、同時に行われる更新に対して一貫した組み合わせを返すかを検証する
+//
+// 書き込み側は受注に明細を1行追加（または削除）し、同じトランザクションで受注の金額を同額だけ増やす（減らす）。
+// このため、どのコミット時点でも受注ごとの「金額 - 明細の合計」は変わらない。読み取り側は書き込みと並行して各手法で受注と明細を読み、
+// この差が計測前と異なる受注を、別々の時点のデータを組み合わせた（一貫していない）結果として数える。
+package consistency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"oracle-n-plus-1-demo/models"
+	"oracle-n-plus-1-demo/repository"
+)
+
+const (
+	// DefaultDays - 既定で読む受注の期間（過去何日間）
+	DefaultDays = 30
+	// DefaultRuns - 既定の手法ごとの読み取り回数
+	DefaultRuns = 20
+	// DefaultTargets - 既定の、書き込み側が明細を追加・削除する受注の数
+	DefaultTargets = 50
+	// tolerance - 金額の比較の許容誤差（金額は小数第2位まで）
+	tolerance = 0.005
+)
+
+// Config - 検証の設定
+type Config struct {
+	// Days - 読む受注の期間（過去何日間）
+	Days int
+	// Runs - 手法ごとの読み取り回数
+	Runs int
+	// Targets - 書き込み側が明細を追加・削除する受注の数
+	Targets int
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{Days: DefaultDays, Runs: DefaultRuns, Targets: DefaultTargets}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if c.Days <= 0 {
+		return fmt.Errorf("days must be positive: %d", c.Days)
+	}
+	if c.Runs <= 0 {
+		return fmt.Errorf("runs must be positive: %d", c.Runs)
+	}
+	if c.Targets <= 0 {
+		return fmt.Errorf("targets must be positive: %d", c.Targets)
+	}
+	return nil
+}
+
+// Method - 手法ごとの検証結果
+type Method struct {
+	Name string `json:"name"`
+	Runs int    `json:"runs"`
+	// InconsistentRuns - 一貫していない受注を1件以上返した回数
+	InconsistentRuns int `json:"inconsistent_runs"`
+	// InconsistentOrders - 一貫していない受注の延べ件数
+	InconsistentOrders int `json:"inconsistent_orders"`
+}
+
+// Consistent - すべての読み取りが一貫していたか
+func (m Method) Consistent() bool {
+	return m.InconsistentRuns == 0
+}
+
+// Report - 手法ごとの一貫性の検証結果
+type Report struct {
+	Days    int      `json:"days"`
+	Runs    int      `json:"runs"`
+	Targets int      `json:"targets"`
+	Orders  int      `json:"orders"`
+	Writes  int      `json:"writes"`
+	Methods []Method `json:"methods"`
+}
+
+// fetcher - 検証する手法（受注と明細を返す）
+type fetcher struct {
+	name  string
+	fetch func(days int) ([]models.OrderWithDetails, error)
+}
+
+// Run - 書き込み側が明細と金額を同時に更新し続ける間に、各手法で受注と明細をcfg.Runs回ずつ読み、一貫性を検証する
+//
+// 書き込みは実際にコミットするため、終了時に追加した明細を削除して金額を元に戻す。
+func Run(ctx context.Context, db *sql.DB, cfg Config) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	baseline, err := balances(ctx, db, cfg.Days)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newWriter(ctx, db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Days: cfg.Days, Runs: cfg.Runs, Targets: len(w.templates), Orders: len(baseline)}
+
+	repo := repository.NewOptimizedOrderRepository(db)
+	fetchers := []fetcher{
+		{"Batch_Optimized", repo.GetOrdersWithDetailsBatch},
+		{"Batch_SCN_Consistent", repo.GetOrdersWithDetailsBatchSCN},
+		{"JOIN_Optimized", repo.GetOrdersWithDetailsJoin},
+	}
+	methods := make([]Method, len(fetchers))
+	for i, f := range fetchers {
+		methods[i].Name = f.name
+	}
+
+	writeCtx, stop := context.WithCancel(ctx)
+	writeErr := make(chan error, 1)
+	go func() { writeErr <- w.run(writeCtx) }()
+
+	var readErr error
+	// 手法を1回ずつ交互に実行し、書き込みの頻度の変化が特定の手法に偏らないようにする
+	for run := 0; run < cfg.Runs && readErr == nil; run++ {
+		for i, f := range fetchers {
+			orders, err := f.fetch(cfg.Days)
+			if err != nil {
+				readErr = fmt.Errorf("%s: %w", f.name, err)
+				break
+			}
+			methods[i].Runs++
+			if n := inconsistentOrders(baseline, orders); n > 0 {
+				methods[i].InconsistentRuns++
+				methods[i].InconsistentOrders += n
+			}
+		}
+	}
+	stop()
+	err = <-writeErr
+	report.Writes = w.writes
+	if rerr := w.revert(); rerr != nil && err == nil {
+		err = rerr
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Methods = methods
+	return report, nil
+}
+
+// balances - 受注ごとの「金額 - 明細の合計」を1文で（同じ時点で）取得
+func balances(ctx context.Context, db *sql.DB, days int) (map[int64]float64, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT o.order_id, o.total_amount - NVL(SUM(od.quantity * od.unit_price), 0)
+		FROM orders o
+		LEFT JOIN order_details od ON od.order_id = o.order_id
+		WHERE o.order_date >= SYSDATE - :1
+		GROUP BY o.order_id, o.total_amount`, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order balances: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	result := make(map[int64]float64)
+	for rows.Next() {
+		var orderID int64
+		var balance float64
+		if err := rows.Scan(&orderID, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan order balance: %w", err)
+		}
+		result[orderID] = balance
+	}
+	return result, rows.Err()
+}
+
+// inconsistentOrders - 「金額 - 明細の合計」が計測前と異なる受注の件数（計測前に読んでいない受注は数えない）
+func inconsistentOrders(baseline map[int64]float64, orders []models.OrderWithDetails) int {
+	count := 0
+	for _, o := range orders {
+		want, ok := baseline[o.Order.OrderID]
+		if !ok {
+			continue
+		}
+		balance := o.Order.TotalAmount
+		for _, d := range o.Details {
+			balance -= float64(d.Quantity) * d.UnitPrice
+		}
+		if math.Abs(balance-want) > tolerance {
+			count++
+		}
+	}
+	return count
+}
+
+// template - 書き込み側が複製する明細（追加した明細のIDは、削除するまでaddedに保持する）
+type template struct {
+	orderID, detailID int64
+	added             int64
+}
+
+// writer - 受注に明細を追加・削除し、同じトランザクションで金額を同額だけ変える
+type writer struct {
+	db        *sql.DB
+	templates []template
+	nextID    int64
+	writes    int
+}
+
+// newWriter - 期間内の受注から明細のある受注をcfg.Targets件選ぶ
+func newWriter(ctx context.Context, db *sql.DB, cfg Config) (*writer, error) {
+	w := &writer{db: db}
+	if err := db.QueryRowContext(ctx, "SELECT NVL(MAX(detail_id), 0) FROM order_details").Scan(&w.nextID); err != nil {
+		return nil, fmt.Errorf("failed to query max detail id: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT o.order_id, MIN(od.detail_id)
+		FROM orders o
+		JOIN order_details od ON od.order_id = o.order_id
+		WHERE o.order_date >= SYSDATE - :1
+		GROUP BY o.order_id
+		ORDER BY o.order_id DESC
+		FETCH FIRST :2 ROWS ONLY`, cfg.Days, cfg.Targets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query target orders: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+	for rows.Next() {
+		var t template
+		if err := rows.Scan(&t.orderID, &t.detailID); err != nil {
+			return nil, fmt.Errorf("failed to scan target order: %w", err)
+		}
+		w.templates = append(w.templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(w.templates) == 0 {
+		return nil, errors.New("no orders with details found")
+	}
+	return w, nil
+}
+
+// run - ctxが取り消されるまで、対象の受注を順に明細の追加と削除を交互に行う
+func (w *writer) run(ctx context.Context) error {
+	for i := 0; ctx.Err() == nil; i = (i + 1) % len(w.templates) {
+		if err := w.toggle(&w.templates[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toggle - 明細を追加していなければ追加し、追加していれば削除する（金額も同じトランザクションで変える）
+func (w *writer) toggle(t *template) (err error) {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rerr := tx.Rollback(); rerr != nil {
+				fmt.Printf("tx.Rollback() failed: %v\n", rerr)
+			}
+		}
+	}()
+
+	if t.added == 0 {
+		id := w.nextID + 1
+		if _, err := tx.Exec(`
+			INSERT INTO order_details (detail_id, order_id, product_id, product_name, quantity, unit_price)
+			SELECT :1, order_id, product_id, product_name, 1, unit_price FROM order_details WHERE detail_id = :2`,
+			id, t.detailID); err != nil {
+			return fmt.Errorf("failed to insert detail into order %d: %w", t.orderID, err)
+		}
+		if _, err := tx.Exec(`
+			UPDATE orders SET total_amount = total_amount + (SELECT unit_price FROM order_details WHERE detail_id = :1)
+			WHERE order_id = :2`, id, t.orderID); err != nil {
+			return fmt.Errorf("failed to update order %d: %w", t.orderID, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+		w.nextID, t.added = id, id
+	} else {
+		if _, err := tx.Exec(`
+			UPDATE orders SET total_amount = total_amount - (SELECT quantity * unit_price FROM order_details WHERE detail_id = :1)
+			WHERE order_id = :2`, t.added, t.orderID); err != nil {
+			return fmt.Errorf("failed to update order %d: %w", t.orderID, err)
+		}
+		if _, err := tx.Exec("DELETE FROM order_details WHERE detail_id = :1", t.added); err != nil {
+			return fmt.Errorf("failed to delete detail %d: %w", t.added, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+		t.added = 0
+	}
+	w.writes++
+	return nil
+}
+
+// revert - 追加したままの明細を削除し、金額を元に戻す
+func (w *writer) revert() error {
+	for i := range w.templates {
+		if w.templates[i].added == 0 {
+			continue
+		}
+		if err := w.toggle(&w.templates[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
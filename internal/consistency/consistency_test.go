@@ -0,0 +1,54 @@
+package consistency
+
+import (
+	"testing"
+
+	"oracle-n-plus-1-demo/models"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "default", cfg: DefaultConfig()},
+		{name: "no days", cfg: Config{Days: 0, Runs: 1, Targets: 1}, wantErr: true},
+		{name: "no runs", cfg: Config{Days: 1, Runs: 0, Targets: 1}, wantErr: true},
+		{name: "no targets", cfg: Config{Days: 1, Runs: 1, Targets: 0}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInconsistentOrders(t *testing.T) {
+	order := func(id int64, total float64, prices ...float64) models.OrderWithDetails {
+		o := models.OrderWithDetails{Order: models.Order{OrderID: id, TotalAmount: total}}
+		for _, p := range prices {
+			o.Details = append(o.Details, models.OrderDetail{OrderID: id, Quantity: 1, UnitPrice: p})
+		}
+		return o
+	}
+	// 受注1は金額と明細の合計が一致、受注2は明細以外の金額（送料など）が10
+	baseline := map[int64]float64{1: 0, 2: 10}
+
+	tests := []struct {
+		name   string
+		orders []models.OrderWithDetails
+		want   int
+	}{
+		{"計測前と同じ", []models.OrderWithDetails{order(1, 30, 10, 20), order(2, 40, 30)}, 0},
+		{"明細と金額を同時に追加", []models.OrderWithDetails{order(1, 35.5, 10, 20, 5.5), order(2, 40, 30)}, 0},
+		{"金額は更新前・明細は更新後", []models.OrderWithDetails{order(1, 30, 10, 20, 5.5), order(2, 40, 30)}, 1},
+		{"金額は更新後・明細は更新前", []models.OrderWithDetails{order(1, 35.5, 10, 20), order(2, 45, 30)}, 2},
+		{"計測前に読んでいない受注", []models.OrderWithDetails{order(3, 99, 1)}, 0},
+	}
+	for _, tt := range tests {
+		if got := inconsistentOrders(baseline, tt.orders); got != tt.want {
+			t.Errorf("%s: inconsistentOrders() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
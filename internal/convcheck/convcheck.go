@@ -0,0 +1,278 @@
+// Package convcheck はクエリのバインド変数の型と列の型を突き合わせ、暗黙の型変換を検出する。
+//
+// Oracleは型の異なる値を比較するとき、優先順位の低い側を暗黙に変換する。変換される側が
+// 列の場合（VARCHAR2列と数値のバインド、DATE列とTIMESTAMPのバインドなど）、述語は
+// TO_NUMBER("列") や INTERNAL_FUNCTION("列") となり、その列の索引は使えなくなる。
+// デモのクエリのほか、利用者のクエリ（ワークロードファイル）も同じ規則で検査できる。
+package convcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/schema"
+)
+
+// BindType - アプリケーションがバインドする値の型
+type BindType string
+
+const (
+	BindNumber    BindType = "number"    // int / int64 / float64 など
+	BindString    BindType = "string"    // string（VARCHAR2としてバインド）
+	BindNString   BindType = "nstring"   // NVARCHAR2としてバインドされる文字列
+	BindDate      BindType = "date"      // DATEとしてバインドされる日時
+	BindTimestamp BindType = "timestamp" // TIMESTAMPとしてバインドされる日時（time.Time など）
+)
+
+// validBindTypes - ワークロードファイルで指定できるバインドの型
+var validBindTypes = map[BindType]bool{
+	BindNumber: true, BindString: true, BindNString: true, BindDate: true, BindTimestamp: true,
+}
+
+// pseudoColumns - 表の列ではない疑似列（型の解決を行わない）
+var pseudoColumns = map[string]bool{"ROWNUM": true, "LEVEL": true, "ROWID": true}
+
+// Query - 検査するクエリ1件
+type Query struct {
+	Name  string     `json:"name"`
+	SQL   string     `json:"sql"`
+	Binds []BindType `json:"binds"` // バインド位置（:1, :2 ... または初出順）ごとの型
+}
+
+// Catalog - 列の型と索引の先頭列
+type Catalog struct {
+	Columns map[string]map[string]string // 表名 → 列名 → データ型
+	Leading map[string]map[string]string // 表名 → 索引の先頭列 → 索引名
+}
+
+// ExpectedCatalog - 期待スキーマ（scripts/ddl/create_tables.sql）から作るカタログ
+func ExpectedCatalog() *Catalog {
+	c := &Catalog{Columns: make(map[string]map[string]string), Leading: make(map[string]map[string]string)}
+	for _, t := range schema.ExpectedTables {
+		c.Columns[t.Name] = make(map[string]string)
+		c.Leading[t.Name] = make(map[string]string)
+		for _, col := range t.Columns {
+			c.Columns[t.Name][col.Name] = col.DataType
+		}
+		for _, idx := range t.Indexes {
+			if len(idx.Columns) > 0 {
+				c.Leading[t.Name][idx.Columns[0]] = idx.Name
+			}
+		}
+	}
+	return c
+}
+
+// Finding - 検出した型変換1件
+type Finding struct {
+	Query      string          `json:"query"`
+	Bind       string          `json:"bind"`
+	Position   int             `json:"position"` // 1始まりのバインド位置
+	Column     string          `json:"column,omitempty"`
+	ColumnType string          `json:"column_type,omitempty"`
+	BindType   BindType        `json:"bind_type,omitempty"`
+	Severity   schema.Severity `json:"severity"`
+	Conversion string          `json:"conversion"`
+	Message    string          `json:"message"`
+}
+
+// Report - 検査結果
+type Report struct {
+	Queries    int       `json:"queries"`
+	Checked    int       `json:"checked"`    // 型を判定したバインド変数の使用箇所の数
+	Findings   []Finding `json:"findings"`   // 重要度の高い順
+	Unresolved []string  `json:"unresolved"` // 型が分からず判定できなかった列（クエリ名: 列）
+}
+
+// Count - 指定した重要度の件数
+func (r *Report) Count(severity schema.Severity) int {
+	count := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// HasProblems - 索引が使えなくなる変換やエラーになる組み合わせがあるか
+func (r *Report) HasProblems() bool {
+	return r.Count(schema.SeverityError) > 0 || r.Count(schema.SeverityWarning) > 0
+}
+
+// Analyze - クエリのバインド変数の型をカタログの列の型と突き合わせる
+func Analyze(queries []Query, catalog *Catalog) *Report {
+	report := &Report{Queries: len(queries)}
+	for _, q := range queries {
+		parsed := parse(q.SQL)
+		for _, use := range parsed.uses {
+			finding := Finding{Query: q.Name, Bind: ":" + use.bind, Position: use.position + 1}
+			if use.position < 0 || use.position >= len(q.Binds) {
+				finding.Severity = schema.SeverityError
+				finding.Message = fmt.Sprintf("バインド位置%dの型が指定されていません（指定は%d個）", use.position+1, len(q.Binds))
+				report.Findings = append(report.Findings, finding)
+				continue
+			}
+			finding.BindType = q.Binds[use.position]
+
+			var ok bool
+			switch use.kind {
+			case contextNumber:
+				ok = classifyNumberContext(&finding, use.context)
+			default:
+				if pseudoColumns[use.column] {
+					continue
+				}
+				table, dataType, found := catalog.resolve(parsed, use.alias, use.column)
+				if !found {
+					report.Unresolved = append(report.Unresolved, fmt.Sprintf("%s: %s", q.Name, qualified(use.alias, use.column)))
+					continue
+				}
+				finding.Column = table + "." + use.column
+				finding.ColumnType = dataType
+				ok = classifyColumn(&finding, use.column, catalog.Leading[table][use.column])
+			}
+
+			report.Checked++
+			if !ok {
+				report.Findings = append(report.Findings, finding)
+			}
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank(report.Findings[i].Severity) < severityRank(report.Findings[j].Severity)
+	})
+	return report
+}
+
+// severityRank - 並べ替え用の重要度の順位
+func severityRank(s schema.Severity) int {
+	switch s {
+	case schema.SeverityError:
+		return 0
+	case schema.SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// qualified - 修飾子付きの列名
+func qualified(alias, column string) string {
+	if alias == "" {
+		return column
+	}
+	return alias + "." + column
+}
+
+// resolve - 列の表とデータ型を求める
+//
+// 修飾子がない場合はクエリ中の表から列を探し、同名の列が型の異なる複数の表にあれば解決しない。
+func (c *Catalog) resolve(q parsedQuery, alias, column string) (table, dataType string, ok bool) {
+	if alias != "" {
+		table, exists := q.tables[alias]
+		if !exists {
+			return "", "", false
+		}
+		dataType, ok = c.Columns[table][column]
+		return table, dataType, ok
+	}
+
+	for _, t := range q.ordered {
+		dt, exists := c.Columns[t][column]
+		if !exists {
+			continue
+		}
+		if ok && dt != dataType {
+			return "", "", false
+		}
+		if !ok {
+			table, dataType, ok = t, dt, true
+		}
+	}
+	return table, dataType, ok
+}
+
+// typeFamily - データ型の分類
+func typeFamily(dataType string) string {
+	dt := strings.ToUpper(dataType)
+	switch {
+	case dt == "NUMBER" || dt == "FLOAT" || dt == "INTEGER" || strings.HasPrefix(dt, "BINARY_"):
+		return "number"
+	case dt == "VARCHAR2" || dt == "CHAR" || dt == "VARCHAR":
+		return "char"
+	case dt == "NVARCHAR2" || dt == "NCHAR":
+		return "nchar"
+	case dt == "DATE":
+		return "date"
+	case strings.HasPrefix(dt, "TIMESTAMP"):
+		return "timestamp"
+	default:
+		return "other"
+	}
+}
+
+// classifyColumn - 列との比較で起きる変換を判定し、問題がなければtrueを返す
+//
+// 列側が変換される組み合わせは、列が索引の先頭列ならWARN（索引が使えない）、そうでなければINFO。
+func classifyColumn(f *Finding, column, index string) bool {
+	columnSide := func(conversion, reason string) {
+		f.Conversion = conversion
+		if index != "" {
+			f.Severity = schema.SeverityWarning
+			f.Message = fmt.Sprintf("%s。列側が変換されるため索引 %s を使えません", reason, index)
+			return
+		}
+		f.Severity = schema.SeverityInfo
+		f.Message = fmt.Sprintf("%s。列側が変換されるため、索引を作成しても使えません", reason)
+	}
+
+	switch family, bind := typeFamily(f.ColumnType), f.BindType; {
+	case family == "number" && bind == BindNumber,
+		family == "char" && bind == BindString,
+		family == "nchar" && (bind == BindString || bind == BindNString),
+		family == "date" && bind == BindDate,
+		family == "timestamp" && (bind == BindTimestamp || bind == BindDate),
+		family == "other":
+		return true
+	case family == "char" && bind == BindNumber:
+		columnSide(fmt.Sprintf(`TO_NUMBER("%s")`, column), "文字列の列と数値を比較すると列が数値に変換されます（数値以外の値があると ORA-01722）")
+	case family == "char" && bind == BindNString:
+		columnSide(fmt.Sprintf(`SYS_OP_C2C("%s")`, column), "VARCHAR2の列とNVARCHAR2のバインドを比較すると列が各国語文字セットに変換されます")
+	case family == "char" && (bind == BindDate || bind == BindTimestamp):
+		columnSide(fmt.Sprintf(`TO_DATE("%s")`, column), "文字列の列と日時を比較すると列がNLS_DATE_FORMATで日付に変換されます")
+	case family == "date" && bind == BindTimestamp:
+		columnSide(fmt.Sprintf(`INTERNAL_FUNCTION("%s")`, column), "DATEの列とTIMESTAMPのバインドを比較すると列がTIMESTAMPに変換されます（バインドをDATEにしてください）")
+	case (family == "number" || family == "date" || family == "timestamp") && (bind == BindString || bind == BindNString):
+		f.Severity = schema.SeverityInfo
+		f.Conversion = fmt.Sprintf("TO_%s(:%d)", strings.ToUpper(family), f.Position)
+		f.Message = "バインド側が変換されるため索引は使えますが、変換に失敗すると実行時エラーになります"
+		if family != "number" {
+			f.Message += "（書式はセッションのNLS設定に依存します）"
+		}
+	default:
+		f.Severity = schema.SeverityError
+		f.Conversion = "-"
+		f.Message = fmt.Sprintf("%sの列と%sのバインドは比較できません（ORA-00932）", f.ColumnType, f.BindType)
+	}
+	return false
+}
+
+// classifyNumberContext - 日数・月数・件数として使われるバインドの変換を判定し、問題がなければtrueを返す
+func classifyNumberContext(f *Finding, context string) bool {
+	switch f.BindType {
+	case BindNumber:
+		return true
+	case BindString, BindNString:
+		f.Severity = schema.SeverityInfo
+		f.Conversion = fmt.Sprintf("TO_NUMBER(:%d)", f.Position)
+		f.Message = fmt.Sprintf("%sに文字列をバインドしているため数値に変換されます（数値以外の値は ORA-01722）", context)
+	default:
+		f.Severity = schema.SeverityError
+		f.Conversion = "-"
+		f.Message = fmt.Sprintf("%sには数値が必要ですが、%sをバインドしています（ORA-00932 または意図しない日数の計算になります）", context, f.BindType)
+	}
+	return false
+}
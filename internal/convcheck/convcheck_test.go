@@ -0,0 +1,149 @@
+package convcheck
+
+import (
+	"strings"
+	"testing"
+
+	"oracle-n-plus-1-demo/internal/schema"
+)
+
+func TestDemoWorkloadHasNoProblems(t *testing.T) {
+	report := Analyze(DemoWorkload(), ExpectedCatalog())
+	for _, f := range report.Findings {
+		t.Errorf("unexpected finding: %s %s %s", f.Query, f.Column, f.Message)
+	}
+	if report.Checked == 0 {
+		t.Error("no bind variable was checked")
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name           string
+		sql            string
+		binds          []BindType
+		wantSeverity   schema.Severity // 空なら検出なし
+		wantConversion string
+	}{
+		{name: "number to number", sql: "SELECT * FROM orders WHERE order_id = :1", binds: []BindType{BindNumber}},
+		{name: "string to indexed varchar2", sql: "SELECT * FROM orders WHERE status = :1", binds: []BindType{BindString}},
+		{
+			name: "number to indexed varchar2", sql: "SELECT * FROM orders WHERE status = :1", binds: []BindType{BindNumber},
+			wantSeverity: schema.SeverityWarning, wantConversion: `TO_NUMBER("STATUS")`,
+		},
+		{
+			name: "number to unindexed varchar2", sql: "SELECT * FROM orders o WHERE o.customer_name = :1", binds: []BindType{BindNumber},
+			wantSeverity: schema.SeverityInfo, wantConversion: `TO_NUMBER("CUSTOMER_NAME")`,
+		},
+		{
+			name: "timestamp to indexed date", sql: "SELECT * FROM orders WHERE order_date >= :1", binds: []BindType{BindTimestamp},
+			wantSeverity: schema.SeverityWarning, wantConversion: `INTERNAL_FUNCTION("ORDER_DATE")`,
+		},
+		{
+			name: "nstring to varchar2", sql: "SELECT * FROM employees WHERE email = :1", binds: []BindType{BindNString},
+			wantSeverity: schema.SeverityWarning, wantConversion: `SYS_OP_C2C("EMAIL")`,
+		},
+		{
+			name: "string to number is bind side", sql: "SELECT * FROM order_details WHERE order_id = :1", binds: []BindType{BindString},
+			wantSeverity: schema.SeverityInfo, wantConversion: "TO_NUMBER(:1)",
+		},
+		{
+			name: "bind first", sql: "SELECT * FROM orders o WHERE :1 = o.status", binds: []BindType{BindNumber},
+			wantSeverity: schema.SeverityWarning, wantConversion: `TO_NUMBER("STATUS")`,
+		},
+		{
+			name: "in list", sql: "SELECT * FROM orders WHERE status IN (:1, :2)", binds: []BindType{BindString, BindNumber},
+			wantSeverity: schema.SeverityWarning, wantConversion: `TO_NUMBER("STATUS")`,
+		},
+		{name: "days as number", sql: "SELECT * FROM orders WHERE order_date >= SYSDATE - :days", binds: []BindType{BindNumber}},
+		{
+			name: "days as string", sql: "SELECT * FROM orders WHERE order_date >= SYSDATE - :days", binds: []BindType{BindString},
+			wantSeverity: schema.SeverityInfo, wantConversion: "TO_NUMBER(:1)",
+		},
+		{
+			name: "days as date", sql: "SELECT * FROM orders WHERE order_date >= SYSDATE - :1", binds: []BindType{BindDate},
+			wantSeverity: schema.SeverityError,
+		},
+		{
+			name: "fetch first as string", sql: "SELECT * FROM orders FETCH FIRST :1 ROWS ONLY", binds: []BindType{BindString},
+			wantSeverity: schema.SeverityInfo, wantConversion: "TO_NUMBER(:1)",
+		},
+		{
+			name: "number to date", sql: "SELECT * FROM employees e WHERE e.hire_date = :1", binds: []BindType{BindNumber},
+			wantSeverity: schema.SeverityError,
+		},
+		{
+			name: "missing bind type", sql: "SELECT * FROM orders WHERE order_id = :1 AND customer_id = :2", binds: []BindType{BindNumber},
+			wantSeverity: schema.SeverityError,
+		},
+		{name: "literal is ignored", sql: "SELECT * FROM orders WHERE status = ':1' AND order_id = :1", binds: []BindType{BindNumber}},
+		{name: "comment is ignored", sql: "SELECT * FROM orders -- status = :1\nWHERE order_id = :1", binds: []BindType{BindNumber}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Analyze([]Query{{Name: "q", SQL: tt.sql, Binds: tt.binds}}, ExpectedCatalog())
+			if tt.wantSeverity == "" {
+				if len(report.Findings) != 0 {
+					t.Fatalf("Findings = %+v, want none", report.Findings)
+				}
+				return
+			}
+			if len(report.Findings) == 0 {
+				t.Fatalf("no finding, want %s", tt.wantSeverity)
+			}
+			f := report.Findings[0]
+			if f.Severity != tt.wantSeverity {
+				t.Errorf("Severity = %s, want %s (%s)", f.Severity, tt.wantSeverity, f.Message)
+			}
+			if tt.wantConversion != "" && f.Conversion != tt.wantConversion {
+				t.Errorf("Conversion = %s, want %s", f.Conversion, tt.wantConversion)
+			}
+		})
+	}
+}
+
+func TestAnalyzeUnresolved(t *testing.T) {
+	report := Analyze([]Query{{Name: "q", SQL: "SELECT * FROM unknown_table u WHERE u.code = :1", Binds: []BindType{BindNumber}}}, ExpectedCatalog())
+	if len(report.Findings) != 0 || len(report.Unresolved) != 1 {
+		t.Fatalf("Findings = %v, Unresolved = %v, want one unresolved column", report.Findings, report.Unresolved)
+	}
+}
+
+func TestParseWorkload(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{name: "valid", json: `{"queries":[{"name":"a","sql":"SELECT 1 FROM dual","binds":["number","timestamp"]}]}`},
+		{name: "no queries", json: `{"queries":[]}`, wantErr: "no queries"},
+		{name: "missing sql", json: `{"queries":[{"name":"a"}]}`, wantErr: "must have name and sql"},
+		{name: "unknown bind type", json: `{"queries":[{"name":"a","sql":"x","binds":["int"]}]}`, wantErr: "unknown bind type"},
+		{name: "malformed", json: `{`, wantErr: "failed to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWorkload([]byte(tt.json))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseWorkload() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseWorkload() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTables(t *testing.T) {
+	got := Tables([]Query{
+		{SQL: "SELECT * FROM orders o LEFT JOIN order_details od ON o.order_id = od.order_id"},
+		{SQL: "SELECT * FROM employees WHERE employee_id IN (SELECT employee_id FROM employee_projects)"},
+	})
+	want := []string{"EMPLOYEES", "EMPLOYEE_PROJECTS", "ORDERS", "ORDER_DETAILS"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Tables() = %v, want %v", got, want)
+	}
+}
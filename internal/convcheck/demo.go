@@ -0,0 +1,111 @@
+package convcheck
+
+// DemoWorkload - デモのリポジトリが発行する主なクエリとバインドする値の型
+//
+// 日数・月数・件数はintで、IDはint64でバインドしている（いずれもNUMBER）。
+// 件数の上限（-max-orders / -max-employees）を付けた形も含める。
+func DemoWorkload() []Query {
+	return []Query{
+		{
+			Name:  "GetOrdersByDays",
+			SQL:   `SELECT order_id, customer_id, order_date, total_amount FROM orders WHERE order_date >= SYSDATE - :1 ORDER BY order_id`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name: "GetOrdersByDays（-max-orders）",
+			SQL: `SELECT order_id, customer_id, order_date, total_amount FROM orders
+				WHERE order_id IN (
+					SELECT order_id FROM orders
+					WHERE order_date >= SYSDATE - :1
+					ORDER BY order_date DESC, order_id DESC
+					FETCH FIRST :2 ROWS ONLY)
+				ORDER BY order_id`,
+			Binds: []BindType{BindNumber, BindNumber},
+		},
+		{
+			Name:  "GetOrderDetailsByOrderID",
+			SQL:   `SELECT detail_id, order_id, product_id, quantity, unit_price FROM order_details WHERE order_id = :1`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name:  "GetOrderDetailsByOrderIDs",
+			SQL:   `SELECT detail_id, order_id, product_id, quantity, unit_price FROM order_details WHERE order_id IN (?) ORDER BY order_id, detail_id`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name: "GetOrdersWithDetailsJoin",
+			SQL: `SELECT o.order_id, od.detail_id FROM orders o
+				LEFT JOIN order_details od ON o.order_id = od.order_id
+				WHERE o.order_date >= SYSDATE - :1
+				ORDER BY o.order_id, od.detail_id`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name:  "GetProductsByIDs",
+			SQL:   `SELECT product_id, product_name, category, list_price FROM products WHERE product_id IN (?)`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name: "GetAllEmployees（-max-employees）",
+			SQL: `SELECT employee_id, first_name, last_name FROM employees
+				WHERE employee_id IN (SELECT employee_id FROM employees ORDER BY employee_id FETCH FIRST :1 ROWS ONLY)
+				ORDER BY employee_id`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name:  "GetDepartmentByID",
+			SQL:   `SELECT department_id, department_name, location FROM departments WHERE department_id = :1`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name:  "GetDepartmentsByIDs",
+			SQL:   `SELECT department_id, department_name, location FROM departments WHERE department_id IN (?)`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name:  "GetProjectAssignments",
+			SQL:   `SELECT project_id, project_role FROM employee_projects WHERE employee_id = :1 ORDER BY project_id`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name:  "GetProjectByID",
+			SQL:   `SELECT project_id, project_name, NVL(budget, 0) FROM projects WHERE project_id = :1`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name: "GetProjectsByEmployeeIDs",
+			SQL: `SELECT ep.employee_id, p.project_id FROM employee_projects ep
+				JOIN projects p ON ep.project_id = p.project_id
+				WHERE ep.employee_id IN (?)`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name: "GetCustomerSummary",
+			SQL: `SELECT MAX(o.customer_name), COUNT(DISTINCT o.order_id) FROM orders o
+				LEFT JOIN order_details od ON o.order_id = od.order_id
+				WHERE o.customer_id = :1`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name: "GetRecentOrdersByCustomerID",
+			SQL: `SELECT order_id, customer_id, order_date, total_amount FROM orders
+				WHERE customer_id = :1
+				ORDER BY order_date DESC, order_id DESC
+				FETCH FIRST :2 ROWS ONLY`,
+			Binds: []BindType{BindNumber, BindNumber},
+		},
+		{
+			Name: "GetMonthlySalesGroupBy",
+			SQL: `SELECT o.customer_id, COUNT(DISTINCT o.order_id) FROM orders o
+				JOIN order_details od ON o.order_id = od.order_id
+				WHERE o.order_date >= ADD_MONTHS(TRUNC(SYSDATE, 'MM'), -:1)
+				GROUP BY o.customer_id, TRUNC(o.order_date, 'MM')`,
+			Binds: []BindType{BindNumber},
+		},
+		{
+			Name:  "GetMonthlySalesMaterializedView",
+			SQL:   `SELECT customer_id, order_count, revenue FROM mv_monthly_customer_sales WHERE sales_month >= ADD_MONTHS(TRUNC(SYSDATE, 'MM'), -:1)`,
+			Binds: []BindType{BindNumber},
+		},
+	}
+}
@@ -0,0 +1,179 @@
+package convcheck
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 識別子・バインド変数・比較演算子の正規表現（大文字化した後のSQLに使う）
+const (
+	identPattern  = `[A-Z][A-Z0-9_$#]*`
+	colRefPattern = `(?:(` + identPattern + `)\.)?(` + identPattern + `)`
+	bindPattern   = `:([A-Z0-9_]+)`
+	opPattern     = `(=|<>|!=|>=|<=|<|>|(?:NOT\s+)?LIKE)`
+)
+
+var (
+	lineCommentRe  = regexp.MustCompile(`--[^\n]*`)
+	blockCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	stringRe       = regexp.MustCompile(`'(?:[^']|'')*'`)
+	spaceRe        = regexp.MustCompile(`\s+`)
+	bindRe         = regexp.MustCompile(bindPattern)
+
+	tableRe = regexp.MustCompile(`\b(?:FROM|JOIN)\s+(` + identPattern + `)(?:\s+(?:AS\s+)?(` + identPattern + `))?`)
+
+	// 列 演算子 バインド変数（例: o.customer_id = :1）
+	columnFirstRe = regexp.MustCompile(`(?:^|[^A-Z0-9_$#.:])` + colRefPattern + `\s*` + opPattern + `\s*` + bindPattern)
+	// バインド変数 演算子 列（例: :1 = o.customer_id）
+	bindFirstRe = regexp.MustCompile(bindPattern + `\s*(=|<>|!=|>=|<=|<|>)\s*` + colRefPattern + `\b\s*(?:[^(\s]|$)`)
+	// 列 IN (バインド変数, ...)（例: order_id IN (:1, :2)）
+	inListRe = regexp.MustCompile(`(?:^|[^A-Z0-9_$#.:])` + colRefPattern + `\s+(?:NOT\s+)?IN\s*\(\s*((?::[A-Z0-9_]+\s*,?\s*)+)\)`)
+	// 日付の加減算（例: SYSDATE - :1）。バインド変数は日数（NUMBER）として使われる
+	dateArithmeticRe = regexp.MustCompile(`\b(?:SYSDATE|SYSTIMESTAMP|CURRENT_DATE|CURRENT_TIMESTAMP|TRUNC\s*\([^()]*\))\s*[-+]\s*` + bindPattern)
+	// ADD_MONTHS(日付, [-]バインド変数)。バインド変数は月数（NUMBER）として使われる
+	addMonthsRe = regexp.MustCompile(`\bADD_MONTHS\s*\((?:[^()]|\([^()]*\))*?,\s*-?\s*` + bindPattern + `\s*\)`)
+	// FETCH FIRST/NEXT :n ROWS、ROWNUM <= :n。バインド変数は件数（NUMBER）として使われる
+	rowLimitRe = regexp.MustCompile(`\b(?:FETCH\s+(?:FIRST|NEXT)\s+` + bindPattern + `|ROWNUM\s*(?:<=|<|=)\s*` + bindPattern + `)`)
+)
+
+// aliasKeywords - FROM/JOIN の表名の後に続いても別名ではない語
+var aliasKeywords = map[string]bool{
+	"WHERE": true, "ON": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true,
+	"OUTER": true, "FULL": true, "CROSS": true, "NATURAL": true, "GROUP": true, "ORDER": true,
+	"FETCH": true, "UNION": true, "MINUS": true, "INTERSECT": true, "CONNECT": true,
+	"START": true, "USING": true, "HAVING": true, "PARTITION": true, "OFFSET": true,
+}
+
+// contextKind - バインド変数が使われる文脈
+type contextKind int
+
+const (
+	contextColumn contextKind = iota // 列との比較（列の型に変換される）
+	contextNumber                    // 日数・月数・件数など数値として使われる
+)
+
+// bindUse - SQL中のバインド変数の使われ方1件
+type bindUse struct {
+	bind     string // 大文字化したバインド変数名（:1 / :DAYS）
+	position int    // 0始まりのバインド位置
+	kind     contextKind
+	alias    string // contextColumn の場合の列の修飾子（なければ空）
+	column   string // contextColumn の場合の列名
+	context  string // contextNumber の場合の説明
+}
+
+// parsedQuery - 解析したSQL
+type parsedQuery struct {
+	tables  map[string]string // 別名（なければ表名）→ 表名
+	ordered []string          // 出現順の表名（修飾子のない列の解決に使う）
+	uses    []bindUse
+	binds   int // SQL中の異なるバインド変数の数
+}
+
+// normalize - コメントと文字列リテラルを除き、大文字化して空白をまとめる
+//
+// sqlutil.QueryIn の「IN (?)」はバインド変数1つとみなせるよう :QMARKn に置き換える。
+func normalize(sql string) string {
+	sql = blockCommentRe.ReplaceAllString(sql, " ")
+	sql = lineCommentRe.ReplaceAllString(sql, " ")
+	sql = stringRe.ReplaceAllString(sql, "''")
+	sql = strings.ToUpper(spaceRe.ReplaceAllString(sql, " "))
+
+	var b strings.Builder
+	n := 0
+	for _, r := range sql {
+		if r == '?' {
+			n++
+			b.WriteString(":QMARK" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// bindPositions - バインド変数名から0始まりの位置を求める
+//
+// すべて :1, :2 のような番号なら番号順、名前付きを含む場合は初出順とする。
+func bindPositions(sql string) map[string]int {
+	var names []string
+	seen := make(map[string]bool)
+	numeric := true
+	for _, m := range bindRe.FindAllStringSubmatch(sql, -1) {
+		if seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		names = append(names, m[1])
+		if _, err := strconv.Atoi(m[1]); err != nil {
+			numeric = false
+		}
+	}
+
+	positions := make(map[string]int, len(names))
+	for i, name := range names {
+		if numeric {
+			n, _ := strconv.Atoi(name)
+			positions[name] = n - 1
+			continue
+		}
+		positions[name] = i
+	}
+	return positions
+}
+
+// parse - SQLから表の別名とバインド変数の使われ方を取り出す
+func parse(sql string) parsedQuery {
+	norm := normalize(sql)
+	positions := bindPositions(norm)
+	q := parsedQuery{tables: make(map[string]string), binds: len(positions)}
+
+	for _, m := range tableRe.FindAllStringSubmatch(norm, -1) {
+		table, alias := m[1], m[2]
+		if _, exists := q.tables[table]; !exists {
+			q.ordered = append(q.ordered, table)
+		}
+		q.tables[table] = table
+		if alias != "" && !aliasKeywords[alias] {
+			q.tables[alias] = table
+		}
+	}
+
+	seen := make(map[string]bool)
+	add := func(u bindUse) {
+		u.position = positions[u.bind]
+		key := u.bind + "|" + u.alias + "|" + u.column + "|" + u.context
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		q.uses = append(q.uses, u)
+	}
+
+	for _, m := range columnFirstRe.FindAllStringSubmatch(norm, -1) {
+		add(bindUse{bind: m[4], kind: contextColumn, alias: m[1], column: m[2]})
+	}
+	for _, m := range bindFirstRe.FindAllStringSubmatch(norm, -1) {
+		add(bindUse{bind: m[1], kind: contextColumn, alias: m[3], column: m[4]})
+	}
+	for _, m := range inListRe.FindAllStringSubmatch(norm, -1) {
+		for _, b := range bindRe.FindAllStringSubmatch(m[3], -1) {
+			add(bindUse{bind: b[1], kind: contextColumn, alias: m[1], column: m[2]})
+		}
+	}
+	for _, m := range dateArithmeticRe.FindAllStringSubmatch(norm, -1) {
+		add(bindUse{bind: m[1], kind: contextNumber, context: "日付の加減算"})
+	}
+	for _, m := range addMonthsRe.FindAllStringSubmatch(norm, -1) {
+		add(bindUse{bind: m[1], kind: contextNumber, context: "ADD_MONTHSの月数"})
+	}
+	for _, m := range rowLimitRe.FindAllStringSubmatch(norm, -1) {
+		bind := m[1]
+		if bind == "" {
+			bind = m[2]
+		}
+		add(bindUse{bind: bind, kind: contextNumber, context: "取得件数"})
+	}
+	return q
+}
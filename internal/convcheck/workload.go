@@ -0,0 +1,126 @@
+package convcheck
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"oracle-n-plus-1-demo/internal/sqlutil"
+)
+
+// Workload - 検査するクエリの一覧（ワークロードファイルの形式）
+type Workload struct {
+	Queries []Query `json:"queries"`
+}
+
+// LoadWorkload - ワークロードファイル（JSON）を読み込む
+func LoadWorkload(path string) ([]Query, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workload file: %w", err)
+	}
+	return ParseWorkload(data)
+}
+
+// ParseWorkload - ワークロードのJSONを解析して検証する
+func ParseWorkload(data []byte) ([]Query, error) {
+	var w Workload
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("failed to parse workload: %w", err)
+	}
+	if len(w.Queries) == 0 {
+		return nil, errors.New("workload has no queries")
+	}
+	for i, q := range w.Queries {
+		if q.Name == "" || q.SQL == "" {
+			return nil, fmt.Errorf("query %d must have name and sql", i+1)
+		}
+		for _, b := range q.Binds {
+			if !validBindTypes[b] {
+				return nil, fmt.Errorf("query %s: unknown bind type %q (number, string, nstring, date, timestamp)", q.Name, b)
+			}
+		}
+	}
+	return w.Queries, nil
+}
+
+// Tables - クエリが参照する表名（重複なし、名前順）
+func Tables(queries []Query) []string {
+	seen := make(map[string]bool)
+	var tables []string
+	for _, q := range queries {
+		for _, t := range parse(q.SQL).ordered {
+			if !seen[t] {
+				seen[t] = true
+				tables = append(tables, t)
+			}
+		}
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// LoadCatalog - 実スキーマ（USER_TAB_COLUMNS・USER_IND_COLUMNS）から指定した表のカタログを作る
+func LoadCatalog(db *sql.DB, tables []string) (*Catalog, error) {
+	c := &Catalog{Columns: make(map[string]map[string]string), Leading: make(map[string]map[string]string)}
+	if len(tables) == 0 {
+		return c, nil
+	}
+	placeholders := sqlutil.Placeholders(len(tables))
+	args := sqlutil.StringArgs(tables)
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT table_name, column_name, data_type
+		FROM user_tab_columns
+		WHERE table_name IN (%s)`, placeholders), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user_tab_columns: %w", err)
+	}
+	err = scanPairs(rows, func(table, column, value string) {
+		if c.Columns[table] == nil {
+			c.Columns[table] = make(map[string]string)
+		}
+		c.Columns[table][column] = value
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(fmt.Sprintf(`
+		SELECT table_name, column_name, index_name
+		FROM user_ind_columns
+		WHERE table_name IN (%s) AND column_position = 1`, placeholders), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user_ind_columns: %w", err)
+	}
+	err = scanPairs(rows, func(table, column, value string) {
+		if c.Leading[table] == nil {
+			c.Leading[table] = make(map[string]string)
+		}
+		c.Leading[table][column] = value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// scanPairs - 「表名・列名・値」の3列の結果を読み込む
+func scanPairs(rows *sql.Rows, fn func(table, column, value string)) error {
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+	for rows.Next() {
+		var table, column, value string
+		if err := rows.Scan(&table, &column, &value); err != nil {
+			return fmt.Errorf("failed to scan dictionary row: %w", err)
+		}
+		fn(table, column, value)
+	}
+	return rows.Err()
+}
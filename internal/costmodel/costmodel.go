@@ -0,0 +1,145 @@
+package costmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// HoursPerMonth - 月額換算に使う1か月の時間（365日 × 24時間 / 12か月）
+const HoursPerMonth = 730
+
+// bytesPerGB - 転送量の単価の単位（GB = 10^9バイト。クラウドの課金単位に合わせる）
+const bytesPerGB = 1e9
+
+// Model - リソース使用量を月額コストへ換算する単価と想定ワークロード
+//
+// 単価は利用するクラウドや契約で大きく異なるため、既定値は目安にすぎない。
+// 実際の見積もりには -cost-model で自分の環境の単価を指定する。
+type Model struct {
+	// Currency - 表示する通貨
+	Currency string `json:"currency"`
+	// DBCPUSecondPrice - DBサーバーのCPU 1秒あたりの単価
+	DBCPUSecondPrice float64 `json:"db_cpu_second_price"`
+	// RedisHourlyPrice - Redisインスタンス1台の1時間あたりの単価
+	RedisHourlyPrice float64 `json:"redis_hourly_price"`
+	// RedisInstances - Redisインスタンスの台数（レプリカを含む）
+	RedisInstances int `json:"redis_instances"`
+	// EgressPerGB - DBからアプリケーションへの転送量1GBあたりの単価
+	EgressPerGB float64 `json:"egress_per_gb"`
+	// RequestsPerSecond - 想定する平均リクエスト数（1リクエスト = 手法の1回の実行）
+	RequestsPerSecond float64 `json:"requests_per_second"`
+}
+
+// Default - 既定の単価（USD、汎用的なクラウドの価格帯を想定した目安）
+func Default() Model {
+	return Model{
+		Currency:          "USD",
+		DBCPUSecondPrice:  0.00005,
+		RedisHourlyPrice:  0.068,
+		RedisInstances:    1,
+		EgressPerGB:       0.09,
+		RequestsPerSecond: 10,
+	}
+}
+
+// Load - 単価ファイル（JSON）を読み込む（省略した項目は既定値）
+func Load(path string) (Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Model{}, fmt.Errorf("failed to read cost model: %w", err)
+	}
+
+	model := Default()
+	if err := json.Unmarshal(data, &model); err != nil {
+		return Model{}, fmt.Errorf("failed to parse cost model %s: %w", path, err)
+	}
+	if err := model.Validate(); err != nil {
+		return Model{}, fmt.Errorf("invalid cost model %s: %w", path, err)
+	}
+	return model, nil
+}
+
+// Validate - 単価と想定ワークロードの値を確認
+func (m Model) Validate() error {
+	switch {
+	case m.DBCPUSecondPrice < 0 || m.RedisHourlyPrice < 0 || m.EgressPerGB < 0:
+		return errors.New("prices must not be negative")
+	case m.RedisInstances < 0:
+		return fmt.Errorf("redis_instances must not be negative: %d", m.RedisInstances)
+	case m.RequestsPerSecond <= 0:
+		return fmt.Errorf("requests_per_second must be positive: %g", m.RequestsPerSecond)
+	}
+	return nil
+}
+
+// RequestsPerMonth - 想定ワークロードの1か月あたりのリクエスト数
+func (m Model) RequestsPerMonth() float64 {
+	return m.RequestsPerSecond * HoursPerMonth * 3600
+}
+
+// RedisMonthly - Redisインスタンスの月額（リクエスト数によらない固定費）
+func (m Model) RedisMonthly() float64 {
+	return m.RedisHourlyPrice * float64(m.RedisInstances) * HoursPerMonth
+}
+
+// Usage - 1リクエストあたりのリソース使用量
+type Usage struct {
+	Scenario string
+	Method   string
+	// DBCPUSeconds - DBサーバーのCPU時間（秒）
+	DBCPUSeconds float64
+	// CPUEstimated - CPU時間を計測できず、経過時間などから推定したか
+	CPUEstimated bool
+	// EgressBytes - DBからアプリケーションへの転送量（バイト）
+	EgressBytes int64
+	// EgressUnknown - 転送量を計測していないか（月額は0として扱う）
+	EgressUnknown bool
+	// UsesRedis - Redisインスタンスを必要とする手法か
+	UsesRedis bool
+}
+
+// Estimate - 手法ごとの月額コストの見積もり
+type Estimate struct {
+	Scenario               string  `json:"scenario,omitempty"`
+	Method                 string  `json:"method"`
+	DBCPUSecondsPerRequest float64 `json:"db_cpu_seconds_per_request"`
+	CPUEstimated           bool    `json:"cpu_estimated,omitempty"`
+	EgressBytesPerRequest  int64   `json:"egress_bytes_per_request"`
+	EgressUnknown          bool    `json:"egress_unknown,omitempty"`
+	// DBCPU / Redis / Egress / Total - 月額（Model.Currency）
+	DBCPU  float64 `json:"db_cpu_monthly"`
+	Redis  float64 `json:"redis_monthly"`
+	Egress float64 `json:"egress_monthly"`
+	Total  float64 `json:"total_monthly"`
+}
+
+// Estimate - 1リクエストあたりの使用量を想定ワークロードの月額に換算
+func (m Model) Estimate(u Usage) Estimate {
+	requests := m.RequestsPerMonth()
+	e := Estimate{
+		Scenario:               u.Scenario,
+		Method:                 u.Method,
+		DBCPUSecondsPerRequest: u.DBCPUSeconds,
+		CPUEstimated:           u.CPUEstimated,
+		EgressBytesPerRequest:  u.EgressBytes,
+		EgressUnknown:          u.EgressUnknown,
+		DBCPU:                  u.DBCPUSeconds * requests * m.DBCPUSecondPrice,
+		Egress:                 float64(u.EgressBytes) * requests / bytesPerGB * m.EgressPerGB,
+	}
+	if u.UsesRedis {
+		e.Redis = m.RedisMonthly()
+	}
+	e.Total = e.DBCPU + e.Redis + e.Egress
+	return e
+}
+
+// EstimateAll - 複数の手法の月額コストを見積もる
+func (m Model) EstimateAll(usages []Usage) []Estimate {
+	estimates := make([]Estimate, len(usages))
+	for i, u := range usages {
+		estimates[i] = m.Estimate(u)
+	}
+	return estimates
+}
@@ -0,0 +1,172 @@
+package costmodel
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testModel - 手計算しやすい単価（1リクエスト/秒 = 月2,628,000リクエスト）
+func testModel() Model {
+	return Model{
+		Currency:          "USD",
+		DBCPUSecondPrice:  0.001,
+		RedisHourlyPrice:  0.5,
+		RedisInstances:    2,
+		EgressPerGB:       0.1,
+		RequestsPerSecond: 1,
+	}
+}
+
+// closeTo - 浮動小数点の誤差を許して等しいか
+func closeTo(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9*math.Max(1, math.Abs(want))
+}
+
+func TestRequestsPerMonth(t *testing.T) {
+	tests := []struct {
+		rps  float64
+		want float64
+	}{
+		{rps: 1, want: 2628000},
+		{rps: 10, want: 26280000},
+		{rps: 0.5, want: 1314000},
+	}
+	for _, tt := range tests {
+		m := Model{RequestsPerSecond: tt.rps}
+		if got := m.RequestsPerMonth(); !closeTo(got, tt.want) {
+			t.Errorf("RequestsPerMonth() with %g rps = %g, want %g", tt.rps, got, tt.want)
+		}
+	}
+}
+
+func TestRedisMonthly(t *testing.T) {
+	tests := []struct {
+		price     float64
+		instances int
+		want      float64
+	}{
+		{price: 0.5, instances: 2, want: 730},
+		{price: 0.068, instances: 1, want: 49.64},
+		{price: 0.5, instances: 0, want: 0},
+	}
+	for _, tt := range tests {
+		m := Model{RedisHourlyPrice: tt.price, RedisInstances: tt.instances}
+		if got := m.RedisMonthly(); !closeTo(got, tt.want) {
+			t.Errorf("RedisMonthly() with %g x %d = %g, want %g", tt.price, tt.instances, got, tt.want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage Usage
+		want  Estimate
+	}{
+		{
+			name:  "db cpu and egress",
+			usage: Usage{Scenario: "orders", Method: "N+1_Problem", DBCPUSeconds: 0.002, EgressBytes: 1000},
+			want: Estimate{Scenario: "orders", Method: "N+1_Problem", DBCPUSecondsPerRequest: 0.002, EgressBytesPerRequest: 1000,
+				DBCPU: 5.256, Egress: 0.2628, Total: 5.5188},
+		},
+		{
+			// Redisの固定費はリクエスト数によらず加わる
+			name:  "redis fixed cost",
+			usage: Usage{Method: "Redis_Cache", DBCPUSeconds: 0.0001, EgressBytes: 1000, UsesRedis: true},
+			want: Estimate{Method: "Redis_Cache", DBCPUSecondsPerRequest: 0.0001, EgressBytesPerRequest: 1000,
+				DBCPU: 0.2628, Redis: 730, Egress: 0.2628, Total: 730.5256},
+		},
+		{
+			// 転送量を計測していない場合は0として扱い、そのことを記録する
+			name:  "unknown egress",
+			usage: Usage{Method: "JOIN_Optimized", DBCPUSeconds: 0.001, EgressUnknown: true},
+			want: Estimate{Method: "JOIN_Optimized", DBCPUSecondsPerRequest: 0.001, EgressUnknown: true,
+				DBCPU: 2.628, Total: 2.628},
+		},
+		{
+			name:  "estimated cpu",
+			usage: Usage{Method: "N+1_Problem", DBCPUSeconds: 0.01, CPUEstimated: true, EgressBytes: 0},
+			want:  Estimate{Method: "N+1_Problem", DBCPUSecondsPerRequest: 0.01, CPUEstimated: true, DBCPU: 26.28, Total: 26.28},
+		},
+	}
+
+	m := testModel()
+	for _, tt := range tests {
+		got := m.Estimate(tt.usage)
+		if got.Scenario != tt.want.Scenario || got.Method != tt.want.Method ||
+			got.DBCPUSecondsPerRequest != tt.want.DBCPUSecondsPerRequest || got.EgressBytesPerRequest != tt.want.EgressBytesPerRequest ||
+			got.CPUEstimated != tt.want.CPUEstimated || got.EgressUnknown != tt.want.EgressUnknown {
+			t.Errorf("%s: Estimate() = %+v, want %+v", tt.name, got, tt.want)
+			continue
+		}
+		if !closeTo(got.DBCPU, tt.want.DBCPU) || !closeTo(got.Redis, tt.want.Redis) ||
+			!closeTo(got.Egress, tt.want.Egress) || !closeTo(got.Total, tt.want.Total) {
+			t.Errorf("%s: Estimate() monthly = db %g, redis %g, egress %g, total %g, want %g, %g, %g, %g", tt.name,
+				got.DBCPU, got.Redis, got.Egress, got.Total, tt.want.DBCPU, tt.want.Redis, tt.want.Egress, tt.want.Total)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Model)
+		wantErr string
+	}{
+		{name: "default", modify: func(*Model) {}},
+		{name: "no redis", modify: func(m *Model) { m.RedisInstances = 0 }},
+		{name: "free", modify: func(m *Model) { m.DBCPUSecondPrice, m.RedisHourlyPrice, m.EgressPerGB = 0, 0, 0 }},
+		{name: "negative cpu price", modify: func(m *Model) { m.DBCPUSecondPrice = -1 }, wantErr: "prices must not be negative"},
+		{name: "negative redis price", modify: func(m *Model) { m.RedisHourlyPrice = -0.1 }, wantErr: "prices must not be negative"},
+		{name: "negative egress price", modify: func(m *Model) { m.EgressPerGB = -0.1 }, wantErr: "prices must not be negative"},
+		{name: "negative instances", modify: func(m *Model) { m.RedisInstances = -1 }, wantErr: "redis_instances"},
+		{name: "zero rps", modify: func(m *Model) { m.RequestsPerSecond = 0 }, wantErr: "requests_per_second"},
+		{name: "negative rps", modify: func(m *Model) { m.RequestsPerSecond = -5 }, wantErr: "requests_per_second"},
+	}
+	for _, tt := range tests {
+		m := Default()
+		tt.modify(&m)
+		err := m.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: Validate() = %v, want nil", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: Validate() = %v, want error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// 省略した項目は既定値になる
+	m, err := Load(write("partial.json", `{"currency": "JPY", "requests_per_second": 50}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := Default()
+	want.Currency, want.RequestsPerSecond = "JPY", 50
+	if m != want {
+		t.Errorf("Load() = %+v, want %+v", m, want)
+	}
+
+	if _, err := Load(write("zero.json", `{"requests_per_second": 0}`)); err == nil || !strings.Contains(err.Error(), "requests_per_second") {
+		t.Errorf("Load() with zero rps error = %v, want requests_per_second error", err)
+	}
+	if _, err := Load(write("broken.json", `{`)); err == nil {
+		t.Error("Load() with broken JSON error = nil, want error")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Load() with missing file error = nil, want error")
+	}
+}
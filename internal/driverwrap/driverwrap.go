@@ -0,0 +1,241 @@
+package driverwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+)
+
+// Hooks - 包んだ接続で実行する文に割り込むフック（接続ごとに作る）
+//
+// database/sqlは1つの接続を同時に1つの処理にしか使わないため、接続ごとの状態はロックなしで持ってよい。
+type Hooks interface {
+	// Rewrite - DBへ送る文（準備・実行の前に呼ぶ。書き換えない場合はそのまま返す）
+	Rewrite(query string) string
+	// Exec - DMLなどの実行（executeでDBへ送る）
+	Exec(query string, args []driver.NamedValue, execute func() (driver.Result, error)) (driver.Result, error)
+	// Query - 問い合わせ（executeでDBへ送る。返した行をdatabase/sqlが読む）
+	Query(query string, args []driver.NamedValue, execute func() (driver.Rows, error)) (driver.Rows, error)
+	// Begin - トランザクションを開始した
+	Begin()
+	// End - トランザクションの終了（statementは COMMIT か ROLLBACK。executeでDBへ送る）
+	End(statement string, execute func() error) error
+}
+
+// Passthrough - 何もしないフック（埋め込んで必要なメソッドだけ上書きする）
+type Passthrough struct{}
+
+// Rewrite - 文をそのまま返す
+func (Passthrough) Rewrite(query string) string { return query }
+
+// Exec - そのまま実行
+func (Passthrough) Exec(_ string, _ []driver.NamedValue, execute func() (driver.Result, error)) (driver.Result, error) {
+	return execute()
+}
+
+// Query - そのまま問い合わせる
+func (Passthrough) Query(_ string, _ []driver.NamedValue, execute func() (driver.Rows, error)) (driver.Rows, error) {
+	return execute()
+}
+
+// Begin - 何もしない
+func (Passthrough) Begin() {}
+
+// End - そのまま終了
+func (Passthrough) End(_ string, execute func() error) error { return execute() }
+
+// Connector - baseが作る接続を包み、接続ごとにnewHooksで作ったフックを通して文を実行するコネクター
+//
+// 接続を包むため、sql.Conn.Raw でドライバー固有の接続の型を必要とする処理（go-oraの配列バインドの型の登録など）は使えない。
+func Connector(base driver.Connector, newHooks func() Hooks) driver.Connector {
+	return &connector{base: base, newHooks: newHooks}
+}
+
+// connector - 接続を包むコネクター
+type connector struct {
+	base     driver.Connector
+	newHooks func() Hooks
+}
+
+// Connect - 接続を作成して包む
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, hooks: c.newHooks()}, nil
+}
+
+// Driver - 元のドライバー
+func (c *connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// conn - フックを通して文を実行する接続（ドライバーが対応していない任意のインターフェースは既定の動作に戻す）
+type conn struct {
+	driver.Conn
+	hooks Hooks
+}
+
+// Prepare - 文を準備して包む
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext - 文を準備して包む
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	query = c.hooks.Rewrite(query)
+	var s driver.Stmt
+	var err error
+	if cp, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = cp.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, query: query, hooks: c.hooks}, nil
+}
+
+// BeginTx - トランザクションを開始して包む
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if cb, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = cb.BeginTx(ctx, opts)
+	} else {
+		if opts.Isolation != driver.IsolationLevel(0) || opts.ReadOnly {
+			return nil, errors.New("driver does not support non-default transaction options")
+		}
+		tx, err = c.Conn.Begin() // BeginTxに対応していないドライバー向け
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.hooks.Begin()
+	return &transaction{Tx: tx, hooks: c.hooks}, nil
+}
+
+// ExecContext - DMLなどを実行（ドライバーが対応していない場合は準備した文で実行させる）
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	query = c.hooks.Rewrite(query)
+	return c.hooks.Exec(query, args, func() (driver.Result, error) { return ec.ExecContext(ctx, query, args) })
+}
+
+// QueryContext - 問い合わせを実行（ドライバーが対応していない場合は準備した文で実行させる）
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	query = c.hooks.Rewrite(query)
+	return c.hooks.Query(query, args, func() (driver.Rows, error) { return qc.QueryContext(ctx, query, args) })
+}
+
+// Ping - 接続を確認
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession - 接続プールに戻す前の初期化
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid - 接続を再利用できるか
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue - ドライバー固有の型（go-oraのObjectなど）のバインドをドライバーに任せる
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if ch, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt - フックを通して実行する準備済みの文（queryは書き換えた後の文）
+type stmt struct {
+	driver.Stmt
+	query string
+	hooks Hooks
+}
+
+// ExecContext - 準備した文を実行
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.hooks.Exec(s.query, args, func() (driver.Result, error) {
+		if se, ok := s.Stmt.(driver.StmtExecContext); ok {
+			return se.ExecContext(ctx, args)
+		}
+		values, err := positional(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Stmt.Exec(values) // StmtExecContextに対応していないドライバー向け
+	})
+}
+
+// QueryContext - 準備した文で問い合わせる
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.hooks.Query(s.query, args, func() (driver.Rows, error) {
+		if sq, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			return sq.QueryContext(ctx, args)
+		}
+		values, err := positional(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Stmt.Query(values) // StmtQueryContextに対応していないドライバー向け
+	})
+}
+
+// CheckNamedValue - ドライバー固有の型のバインドをドライバーに任せる
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if ch, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// transaction - 終了をフックに通すトランザクション
+type transaction struct {
+	driver.Tx
+	hooks Hooks
+}
+
+// Commit - コミット
+func (t *transaction) Commit() error {
+	return t.hooks.End("COMMIT", t.Tx.Commit)
+}
+
+// Rollback - ロールバック
+func (t *transaction) Rollback() error {
+	return t.hooks.End("ROLLBACK", t.Tx.Rollback)
+}
+
+// positional - 位置で渡すバインド変数の値（名前付きのバインドはドライバーが対応していない）
+func positional(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
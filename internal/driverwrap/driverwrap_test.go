@@ -0,0 +1,200 @@
+package driverwrap
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConnectorHooks(t *testing.T) {
+	base := &fakeConnector{}
+	var recorded *recordingHooks
+	db := sql.OpenDB(Connector(base, func() Hooks {
+		recorded = &recordingHooks{}
+		return recorded
+	}))
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	rows, err := db.Query("SELECT 1 FROM dual")
+	if err != nil {
+		t.Fatalf("Query() failed: %v", err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+	if _, err := db.Exec("UPDATE orders SET status = 'DONE'"); err != nil {
+		t.Fatalf("Exec() failed: %v", err)
+	}
+
+	// 準備した文は準備の時点で1回だけ書き換える
+	st, err := db.Prepare("SELECT :1 FROM dual")
+	if err != nil {
+		t.Fatalf("Prepare() failed: %v", err)
+	}
+	rows, err = st.Query(1)
+	if err != nil {
+		t.Fatalf("stmt.Query() failed: %v", err)
+	}
+	rows.Close()
+	if _, err := st.Exec(2); err != nil {
+		t.Fatalf("stmt.Exec() failed: %v", err)
+	}
+	// StmtQueryContextに対応していないドライバーには名前付きのバインドを渡せない
+	if _, err := st.Query(sql.Named("id", 1)); err == nil || !strings.Contains(err.Error(), "named parameters") {
+		t.Errorf("stmt.Query(named) error = %v, want named parameters error", err)
+	}
+	st.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin() failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatalf("Begin() failed: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback() failed: %v", err)
+	}
+
+	wantSent := []string{
+		"query SELECT 1 FROM dual /* wrapped */",
+		"exec UPDATE orders SET status = 'DONE' /* wrapped */",
+		"prepare SELECT :1 FROM dual /* wrapped */",
+		"stmt query",
+		"stmt exec",
+		"commit",
+		"rollback",
+	}
+	if !reflect.DeepEqual(base.sent, wantSent) {
+		t.Errorf("driver received %q, want %q", base.sent, wantSent)
+	}
+
+	wantCalls := []string{
+		"query SELECT 1 FROM dual /* wrapped */",
+		"exec UPDATE orders SET status = 'DONE' /* wrapped */",
+		"query SELECT :1 FROM dual /* wrapped */",
+		"exec SELECT :1 FROM dual /* wrapped */",
+		"query SELECT :1 FROM dual /* wrapped */",
+		"begin",
+		"end COMMIT",
+		"begin",
+		"end ROLLBACK",
+	}
+	if !reflect.DeepEqual(recorded.calls, wantCalls) {
+		t.Errorf("hooks received %q, want %q", recorded.calls, wantCalls)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	base := &fakeConnector{}
+	db := sql.OpenDB(Connector(base, func() Hooks { return Passthrough{} }))
+	defer db.Close()
+
+	var n int64
+	if err := db.QueryRow("SELECT 1 FROM dual").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("QueryRow() = %d, %v, want 1", n, err)
+	}
+	if want := []string{"query SELECT 1 FROM dual"}; !reflect.DeepEqual(base.sent, want) {
+		t.Errorf("driver received %q, want %q", base.sent, want)
+	}
+}
+
+// recordingHooks - 文に印を付け、呼ばれたフックを記録するテスト用のフック
+type recordingHooks struct {
+	calls []string
+}
+
+func (h *recordingHooks) Rewrite(query string) string { return query + " /* wrapped */" }
+
+func (h *recordingHooks) Exec(query string, _ []driver.NamedValue, execute func() (driver.Result, error)) (driver.Result, error) {
+	h.calls = append(h.calls, "exec "+query)
+	return execute()
+}
+
+func (h *recordingHooks) Query(query string, _ []driver.NamedValue, execute func() (driver.Rows, error)) (driver.Rows, error) {
+	h.calls = append(h.calls, "query "+query)
+	return execute()
+}
+
+func (h *recordingHooks) Begin() { h.calls = append(h.calls, "begin") }
+
+func (h *recordingHooks) End(statement string, execute func() error) error {
+	h.calls = append(h.calls, "end "+statement)
+	return execute()
+}
+
+// fakeConnector - ドライバーに届いた操作を記録するテスト用のドライバー（文は古いインターフェースだけに対応する）
+type fakeConnector struct {
+	sent []string
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{connector: c}, nil
+}
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+func (c *fakeConnector) record(op string) { c.sent = append(c.sent, op) }
+
+type fakeConn struct{ connector *fakeConnector }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.connector.record("prepare " + query)
+	return &fakeStmt{connector: c.connector}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{connector: c.connector}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.connector.record("query " + query)
+	return &fakeRows{}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.connector.record("exec " + query)
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct{ connector *fakeConnector }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.connector.record("stmt exec")
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.connector.record("stmt query")
+	return &fakeRows{}, nil
+}
+
+type fakeTx struct{ connector *fakeConnector }
+
+func (t fakeTx) Commit() error   { t.connector.record("commit"); return nil }
+func (t fakeTx) Rollback() error { t.connector.record("rollback"); return nil }
+
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"N"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
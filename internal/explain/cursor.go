@@ -0,0 +1,229 @@
+package explain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/querylog"
+)
+
+// maxCursorText - V$SQLから読む文の長さ（VARCHAR2で読める上限。超える文は先頭で照合する）
+const maxCursorText = 4000
+
+// Step - 実行した計画の1ステップの推定と実績（V$SQL_PLAN_STATISTICS_ALLの最後の実行の値）
+type Step struct {
+	ID        int    `json:"id"`
+	Depth     int    `json:"depth"`
+	Operation string `json:"operation"`
+	Options   string `json:"options,omitempty"`
+	Object    string `json:"object,omitempty"`
+	// EstimatedRows - 1回の開始あたりの推定行数（E-Rows）
+	EstimatedRows int64 `json:"estimated_rows"`
+	// Starts / ActualRows - ステップを開始した回数と、すべての開始で返した行数の合計（Starts・A-Rows）
+	Starts     int64 `json:"starts"`
+	ActualRows int64 `json:"actual_rows"`
+	// Buffers / Elapsed - 論理読み取りと、子のステップを含む経過時間（Buffers・A-Time）
+	Buffers int64         `json:"buffers"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Name - 操作名（例: INDEX RANGE SCAN IDX_ORDER_DETAILS_ORDER）
+func (s Step) Name() string {
+	return Operation{Operation: s.Operation, Options: s.Options, Object: s.Object}.Name()
+}
+
+// Misestimate - 推定行数（E-Rows × Starts）と実際の行数の比（大きい方を小さい方で割った値、どちらも0なら1）
+func (s Step) Misestimate() float64 {
+	estimated := s.EstimatedRows * max(1, s.Starts)
+	lo, hi := min(estimated, s.ActualRows), max(estimated, s.ActualRows)
+	if hi == 0 {
+		return 1
+	}
+	return float64(hi) / float64(max(1, lo))
+}
+
+// ActualPlan - GATHER_PLAN_STATISTICSを付けて実行した文の、カーソルキャッシュ上の計画と実績
+type ActualPlan struct {
+	SQL         string `json:"sql"`
+	SQLID       string `json:"sql_id"`
+	ChildNumber int64  `json:"child_number"`
+	// Executions - 手法の実行中にこの文を実行した回数（Stepsは最後の1回の値）
+	Executions int    `json:"executions"`
+	Steps      []Step `json:"steps"`
+	// Text - DBMS_XPLAN.DISPLAY_CURSOR の出力（'ALLSTATS LAST'）
+	Text []string `json:"text"`
+}
+
+// WorstStep - 推定と実績の差が最も大きいステップ（ステップがなければok=false）
+func (p ActualPlan) WorstStep() (step Step, ok bool) {
+	for _, s := range p.Steps {
+		if s.ID == 0 {
+			continue
+		}
+		if !ok || s.Misestimate() > step.Misestimate() {
+			step, ok = s, true
+		}
+	}
+	return step, ok
+}
+
+// Summary - 計画の1行表示（例: SQL_ID 7h35uxf5uhmm1 最大の見積もり誤差 120.0倍 INDEX RANGE SCAN ...（推定 5行 × 1回 / 実際 600行））
+func (p ActualPlan) Summary() string {
+	summary := "SQL_ID " + p.SQLID
+	if step, ok := p.WorstStep(); ok {
+		summary += fmt.Sprintf(" 最大の見積もり誤差 %.1f倍 %s（推定 %d行 × %d回 / 実際 %d行）",
+			step.Misestimate(), step.Name(), step.EstimatedRows, step.Starts, step.ActualRows)
+	}
+	return summary
+}
+
+// CursorStats - GATHER_PLAN_STATISTICSを付けて実行した文の実績をカーソルキャッシュから取得する（-plan-stats）
+//
+// V$SQL・V$SQL_PLAN_STATISTICS_ALLの参照とDBMS_XPLANの実行にはSELECT_CATALOG_ROLEなどの権限が必要。
+type CursorStats struct {
+	db *sql.DB
+}
+
+// NewCursorStats - CursorStatsのコンストラクタ
+func NewCursorStats(db *sql.DB) *CursorStats {
+	return &CursorStats{db: db}
+}
+
+// cursor - V$SQLのカーソル
+type cursor struct {
+	sqlID string
+	child int64
+	text  string
+}
+
+// CollectAll - Captureで集めた文（ヒントを加えた後の文）の実績を最大MaxStatements件取得
+//
+// カーソルキャッシュに見つからない文（追い出された文など）は含めない。Executionsは引数の値を引き継ぐ。
+func (c *CursorStats) CollectAll(ctx context.Context, statements []Plan) ([]ActualPlan, error) {
+	if len(statements) > MaxStatements {
+		statements = statements[:MaxStatements]
+	}
+	if len(statements) == 0 {
+		return nil, nil
+	}
+	cursors, err := c.loadCursors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]ActualPlan, 0, len(statements))
+	for _, st := range statements {
+		cur, ok := matchCursor(cursors, st.SQL)
+		if !ok {
+			continue
+		}
+		plan := ActualPlan{SQL: st.SQL, SQLID: cur.sqlID, ChildNumber: cur.child, Executions: st.Executions}
+		if plan.Steps, err = c.loadSteps(ctx, cur); err != nil {
+			return plans, err
+		}
+		if plan.Text, err = c.displayCursor(ctx, cur); err != nil {
+			return plans, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// loadCursors - このスキーマでGATHER_PLAN_STATISTICSを付けて実行した文のカーソル（最後に実行した順）
+func (c *CursorStats) loadCursors(ctx context.Context) (cursors []cursor, err error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT sql_id, child_number, DBMS_LOB.SUBSTR(sql_fulltext, :1, 1)
+		FROM v$sql
+		WHERE parsing_schema_name = USER
+		  AND sql_text LIKE '%`+GatherHint+`%'
+		  AND UPPER(sql_text) NOT LIKE '%V$%'
+		  AND UPPER(sql_text) NOT LIKE '%DBMS_XPLAN%'
+		ORDER BY last_active_time DESC, child_number DESC`, maxCursorText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v$sql: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var cur cursor
+		if err := rows.Scan(&cur.sqlID, &cur.child, &cur.text); err != nil {
+			return nil, fmt.Errorf("failed to scan v$sql: %w", err)
+		}
+		cursors = append(cursors, cur)
+	}
+	return cursors, rows.Err()
+}
+
+// matchCursor - 空白をまとめた文が一致する最初のカーソル（V$SQLの文が上限で切れている場合は先頭で照合する）
+func matchCursor(cursors []cursor, query string) (cursor, bool) {
+	for _, cur := range cursors {
+		text := querylog.Statement(cur.text)
+		if text == query || (len(cur.text) >= maxCursorText && strings.HasPrefix(query, text)) {
+			return cur, true
+		}
+	}
+	return cursor{}, false
+}
+
+// loadSteps - 最後の実行のステップごとの推定と実績
+func (c *CursorStats) loadSteps(ctx context.Context, cur cursor) (steps []Step, err error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT id, depth, operation, options, object_name, cardinality,
+		       last_starts, last_output_rows, last_cr_buffer_gets, last_elapsed_time
+		FROM v$sql_plan_statistics_all
+		WHERE sql_id = :1 AND child_number = :2
+		ORDER BY id`, cur.sqlID, cur.child)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v$sql_plan_statistics_all: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var s Step
+		var options, object sql.NullString
+		var cardinality, starts, output, buffers, elapsed sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.Depth, &s.Operation, &options, &object, &cardinality,
+			&starts, &output, &buffers, &elapsed); err != nil {
+			return nil, fmt.Errorf("failed to scan plan statistics: %w", err)
+		}
+		s.Options, s.Object = options.String, object.String
+		s.EstimatedRows, s.Starts, s.ActualRows, s.Buffers = cardinality.Int64, starts.Int64, output.Int64, buffers.Int64
+		s.Elapsed = time.Duration(elapsed.Int64) * time.Microsecond
+		steps = append(steps, s)
+	}
+	return steps, rows.Err()
+}
+
+// displayCursor - DBMS_XPLAN.DISPLAY_CURSORで整形した最後の実行の計画と実績を行ごとに取得
+func (c *CursorStats) displayCursor(ctx context.Context, cur cursor) (lines []string, err error) {
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT plan_table_output FROM TABLE(DBMS_XPLAN.DISPLAY_CURSOR(:1, :2, 'ALLSTATS LAST'))", cur.sqlID, cur.child)
+	if err != nil {
+		return nil, fmt.Errorf("failed to display cursor %s: %w", cur.sqlID, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var line sql.NullString
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed to scan plan of %s: %w", cur.sqlID, err)
+		}
+		lines = append(lines, line.String)
+	}
+	return lines, rows.Err()
+}
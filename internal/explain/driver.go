@@ -0,0 +1,58 @@
+package explain
+
+import (
+	"database/sql/driver"
+	"regexp"
+
+	"oracle-n-plus-1-demo/internal/driverwrap"
+	"oracle-n-plus-1-demo/internal/querylog"
+)
+
+// GatherHint - 実行時に計画のステップごとの実際の行数・時間を集めさせるヒント
+const GatherHint = "GATHER_PLAN_STATISTICS"
+
+// selectRe - 最初のSELECTと、その直後にすでにあるヒントの開始
+var selectRe = regexp.MustCompile(`(?i)\bSELECT\b(\s*/\*\+)?`)
+
+// WithPlanStatistics - 問い合わせの最初のSELECTにGATHER_PLAN_STATISTICSヒントを加える
+//
+// すでにヒントがある場合は同じコメントに加える（2つ目のヒントのコメントは無視されるため）。
+// 問い合わせ以外の文とV$ビューへの問い合わせはそのまま返す。
+func WithPlanStatistics(query string) string {
+	switch querylog.Keyword(querylog.Statement(query)) {
+	case "SELECT", "WITH":
+	default:
+		return query
+	}
+	if querylog.IsDictionary(query) {
+		return query
+	}
+	loc := selectRe.FindStringSubmatchIndex(query)
+	if loc == nil {
+		return query
+	}
+	if loc[2] >= 0 {
+		// 既存のヒントの先頭（/*+ の直後）に加える
+		return query[:loc[1]] + " " + GatherHint + query[loc[1]:]
+	}
+	return query[:loc[1]] + " /*+ " + GatherHint + " */" + query[loc[1]:]
+}
+
+// StatisticsConnector - baseが作る接続で実行する問い合わせにGATHER_PLAN_STATISTICSヒントを加えるコネクター（-plan-stats）
+//
+// 文の文字列が変わるため、ヒントのない文とは別のカーソルになる。querylog.Connector より外側で包むと、
+// 記録・計数・Captureにはヒントを加えた後の文（DBに送った文）が渡る。
+// 接続は driverwrap.Connector で包むため、sql.Conn.Raw でドライバー固有の接続の型を必要とする処理は使えない。
+func StatisticsConnector(base driver.Connector) driver.Connector {
+	return driverwrap.Connector(base, func() driverwrap.Hooks { return statisticsHooks{} })
+}
+
+// statisticsHooks - 問い合わせにヒントを加えるフック（問い合わせ以外の文は WithPlanStatistics がそのまま返す）
+type statisticsHooks struct {
+	driverwrap.Passthrough
+}
+
+// Rewrite - ヒントを加えた文
+func (statisticsHooks) Rewrite(query string) string {
+	return WithPlanStatistics(query)
+}
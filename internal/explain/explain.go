@@ -0,0 +1,269 @@
+// Package explain - 手法が実行した問い合わせを EXPLAIN PLAN で説明し、DBMS_XPLAN.DISPLAY の実行計画を添える
+//
+// 手法の実行中に流れた文をCaptureで集め（ドライバーの接続を包むRecorderとして登録する）、計測の後で
+// Explainerが文ごとに EXPLAIN PLAN FOR を実行する。バインド変数には値を入れずに説明するため、
+// 実際の実行時の計画（バインドピーク後の計画）とは異なることがある。
+//
+// -plan-stats では問い合わせに GATHER_PLAN_STATISTICS ヒントを加えて実行し（StatisticsConnector）、
+// CursorStatsがカーソルキャッシュから DBMS_XPLAN.DISPLAY_CURSOR（'ALLSTATS LAST'）の実際の行数を取得する。
+package explain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"oracle-n-plus-1-demo/internal/querylog"
+)
+
+// MaxStatements - 1つの手法について説明する文の数の上限（IN句の端数のチャンクなどで文が増えすぎないようにする）
+const MaxStatements = 5
+
+// Operation - 実行計画の1ステップ（PLAN_TABLEの1行）
+type Operation struct {
+	ID          int    `json:"id"`
+	Depth       int    `json:"depth"`
+	Operation   string `json:"operation"`
+	Options     string `json:"options,omitempty"`
+	Object      string `json:"object,omitempty"`
+	Cost        int64  `json:"cost"`
+	Cardinality int64  `json:"cardinality"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// Name - 操作名（例: INDEX RANGE SCAN IDX_ORDER_DETAILS_ORDER）
+func (o Operation) Name() string {
+	name := o.Operation
+	if o.Options != "" {
+		name += " " + o.Options
+	}
+	if o.Object != "" {
+		name += " " + o.Object
+	}
+	return name
+}
+
+// Plan - 1つの文の実行計画
+type Plan struct {
+	// SQL - 説明した文（改行と字下げを空白1つにまとめたもの）
+	SQL string `json:"sql"`
+	// Executions - 手法の実行中にこの文を実行した回数
+	Executions int `json:"executions"`
+	// Cost / Cardinality - 計画全体（ID 0）のコストと推定行数
+	Cost        int64       `json:"cost"`
+	Cardinality int64       `json:"cardinality"`
+	Operations  []Operation `json:"operations"`
+	// Text - DBMS_XPLAN.DISPLAY の出力（'TYPICAL'）
+	Text []string `json:"text"`
+}
+
+// AccessPaths - 表と索引へのアクセス方法（全表走査・索引の使い方が分かる操作）
+func (p Plan) AccessPaths() []string {
+	var paths []string
+	for _, op := range p.Operations {
+		if strings.HasPrefix(op.Operation, "TABLE ACCESS") || strings.HasPrefix(op.Operation, "INDEX") ||
+			strings.HasPrefix(op.Operation, "MAT_VIEW ACCESS") {
+			paths = append(paths, op.Name())
+		}
+	}
+	return paths
+}
+
+// Summary - 計画の1行表示（例: cost=3 rows=5 INDEX RANGE SCAN IDX_..., TABLE ACCESS BY INDEX ROWID ORDER_DETAILS）
+func (p Plan) Summary() string {
+	summary := fmt.Sprintf("cost=%d rows=%d", p.Cost, p.Cardinality)
+	if paths := p.AccessPaths(); len(paths) > 0 {
+		summary += " " + strings.Join(paths, ", ")
+	}
+	return summary
+}
+
+// Capture - 実行された問い合わせの文を、初めて実行された順に重複なく集める（Recorderとして Connector に渡す）
+//
+// V$ビューへの問い合わせとDML・COMMITは集めない。すべての接続の文を集めるため、Resetから次のStatementsまでの間に
+// 計測する手法以外の文を流さないこと。
+type Capture struct {
+	mu     sync.Mutex
+	order  []string
+	counts map[string]int
+}
+
+// NewCapture - Captureのコンストラクタ
+func NewCapture() *Capture {
+	return &Capture{counts: make(map[string]int)}
+}
+
+// Log - 問い合わせの文を1件集める
+func (c *Capture) Log(e querylog.Entry) {
+	query := querylog.Statement(e.Query)
+	if querylog.IsDictionary(query) {
+		return
+	}
+	switch querylog.Keyword(query) {
+	case "SELECT", "WITH":
+	default:
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[query] == 0 {
+		c.order = append(c.order, query)
+	}
+	c.counts[query]++
+}
+
+// Reset - 集めた文を捨てる（手法の実行の直前に呼ぶ）
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order = nil
+	c.counts = make(map[string]int)
+}
+
+// Statements - Resetの後に集めた文（初めて実行された順）と実行回数
+func (c *Capture) Statements() []Plan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	statements := make([]Plan, len(c.order))
+	for i, query := range c.order {
+		statements[i] = Plan{SQL: query, Executions: c.counts[query]}
+	}
+	return statements
+}
+
+// Explainer - 文の実行計画を取得する（同じ文の計画は一度だけ取得して使い回す）
+type Explainer struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	cache map[string]Plan
+	seq   atomic.Int64
+}
+
+// NewExplainer - Explainerのコンストラクタ
+func NewExplainer(db *sql.DB) *Explainer {
+	return &Explainer{db: db, cache: make(map[string]Plan)}
+}
+
+// ExplainAll - Captureで集めた文の実行計画を最大MaxStatements件取得（Executionsは引数の値を引き継ぐ）
+func (e *Explainer) ExplainAll(ctx context.Context, statements []Plan) ([]Plan, error) {
+	if len(statements) > MaxStatements {
+		statements = statements[:MaxStatements]
+	}
+	plans := make([]Plan, 0, len(statements))
+	for _, st := range statements {
+		plan, err := e.Explain(ctx, st.SQL)
+		if err != nil {
+			return plans, err
+		}
+		plan.Executions = st.Executions
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// Explain - 文の実行計画を取得
+//
+// PLAN_TABLEはセッションごとの一時表のため、EXPLAIN PLANから読み取りまでを1つの接続で行い、読み終えたら行を削除する。
+func (e *Explainer) Explain(ctx context.Context, query string) (Plan, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[query]
+	e.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			fmt.Printf("conn.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	id := fmt.Sprintf("nplus1-%d", e.seq.Add(1))
+	// STATEMENT_IDはバインドできないため、自前で振った英数字だけの値を埋め込む
+	if _, err := conn.ExecContext(ctx, "EXPLAIN PLAN SET STATEMENT_ID = '"+id+"' FOR "+query); err != nil {
+		return Plan{}, fmt.Errorf("failed to explain plan: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "DELETE FROM plan_table WHERE statement_id = :1", id); err != nil {
+			fmt.Printf("failed to delete plan %s: %v\n", id, err)
+		}
+	}()
+
+	plan := Plan{SQL: query}
+	if plan.Operations, err = loadOperations(ctx, conn, id); err != nil {
+		return Plan{}, err
+	}
+	if len(plan.Operations) > 0 {
+		plan.Cost, plan.Cardinality = plan.Operations[0].Cost, plan.Operations[0].Cardinality
+	}
+	if plan.Text, err = display(ctx, conn, id); err != nil {
+		return Plan{}, err
+	}
+
+	e.mu.Lock()
+	e.cache[query] = plan
+	e.mu.Unlock()
+	return plan, nil
+}
+
+// loadOperations - PLAN_TABLEから計画のステップを読む
+func loadOperations(ctx context.Context, conn *sql.Conn, id string) (ops []Operation, err error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT id, depth, operation, options, object_name, cost, cardinality, bytes
+		FROM plan_table
+		WHERE statement_id = :1
+		ORDER BY id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query plan_table: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var op Operation
+		var options, object sql.NullString
+		var cost, cardinality, bytes sql.NullInt64
+		if err := rows.Scan(&op.ID, &op.Depth, &op.Operation, &options, &object, &cost, &cardinality, &bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan plan_table row: %w", err)
+		}
+		op.Options, op.Object = options.String, object.String
+		op.Cost, op.Cardinality, op.Bytes = cost.Int64, cardinality.Int64, bytes.Int64
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
+
+// display - DBMS_XPLAN.DISPLAYで整形した計画を行ごとに取得
+func display(ctx context.Context, conn *sql.Conn, id string) (lines []string, err error) {
+	rows, err := conn.QueryContext(ctx,
+		"SELECT plan_table_output FROM TABLE(DBMS_XPLAN.DISPLAY('PLAN_TABLE', :1, 'TYPICAL'))", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to display plan: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var line sql.NullString
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed to scan plan output: %w", err)
+		}
+		lines = append(lines, line.String)
+	}
+	return lines, rows.Err()
+}
@@ -0,0 +1,128 @@
+package explain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"oracle-n-plus-1-demo/internal/querylog"
+)
+
+func TestCapture(t *testing.T) {
+	c := NewCapture()
+	c.Log(querylog.Entry{Query: "SELECT 1 FROM dual"})
+	c.Reset()
+
+	for _, query := range []string{
+		"SELECT order_id FROM orders\n\t\tWHERE order_date >= SYSDATE - :1",
+		"SELECT detail_id FROM order_details WHERE order_id = :1",
+		"SELECT detail_id FROM order_details WHERE order_id = :1",
+		"SELECT value FROM v$mystat",
+		"INSERT INTO nplus1_in_list_ids (id) VALUES (:1)",
+		"SELECT detail_id FROM order_details WHERE order_id = :1",
+		"COMMIT",
+	} {
+		c.Log(querylog.Entry{Query: query})
+	}
+
+	want := []Plan{
+		{SQL: "SELECT order_id FROM orders WHERE order_date >= SYSDATE - :1", Executions: 1},
+		{SQL: "SELECT detail_id FROM order_details WHERE order_id = :1", Executions: 3},
+	}
+	if got := c.Statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Statements() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanSummary(t *testing.T) {
+	plan := Plan{
+		Cost:        4,
+		Cardinality: 5,
+		Operations: []Operation{
+			{ID: 0, Operation: "SELECT STATEMENT", Cost: 4, Cardinality: 5},
+			{ID: 1, Depth: 1, Operation: "TABLE ACCESS", Options: "BY INDEX ROWID BATCHED", Object: "ORDER_DETAILS"},
+			{ID: 2, Depth: 2, Operation: "INDEX", Options: "RANGE SCAN", Object: "IDX_ORDER_DETAILS_ORDER"},
+		},
+	}
+	want := "cost=4 rows=5 TABLE ACCESS BY INDEX ROWID BATCHED ORDER_DETAILS, INDEX RANGE SCAN IDX_ORDER_DETAILS_ORDER"
+	if got := plan.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	if got := (Plan{Operations: []Operation{{Operation: "SELECT STATEMENT"}, {Operation: "FAST DUAL"}}}).Summary(); got != "cost=0 rows=0" {
+		t.Errorf("Summary() without access paths = %q", got)
+	}
+}
+
+func TestWithPlanStatistics(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "\n\t\tSELECT order_id FROM orders WHERE order_id = :1",
+			want:  "\n\t\tSELECT /*+ GATHER_PLAN_STATISTICS */ order_id FROM orders WHERE order_id = :1",
+		},
+		{
+			query: "SELECT /*+ RESULT_CACHE */ customer_id FROM orders",
+			want:  "SELECT /*+ GATHER_PLAN_STATISTICS RESULT_CACHE */ customer_id FROM orders",
+		},
+		{
+			query: "select /* inlist-bench 1 */ detail_id from order_details",
+			want:  "select /*+ GATHER_PLAN_STATISTICS */ /* inlist-bench 1 */ detail_id from order_details",
+		},
+		{
+			query: "WITH t AS (SELECT 1 FROM dual) SELECT * FROM t",
+			want:  "WITH t AS (SELECT /*+ GATHER_PLAN_STATISTICS */ 1 FROM dual) SELECT * FROM t",
+		},
+		{query: "SELECT value FROM v$mystat", want: "SELECT value FROM v$mystat"},
+		{query: "UPDATE orders SET status = (SELECT 'X' FROM dual)", want: "UPDATE orders SET status = (SELECT 'X' FROM dual)"},
+	}
+	for _, tt := range tests {
+		if got := WithPlanStatistics(tt.query); got != tt.want {
+			t.Errorf("WithPlanStatistics(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestActualPlanWorstStep(t *testing.T) {
+	plan := ActualPlan{
+		SQLID: "7h35uxf5uhmm1",
+		Steps: []Step{
+			{ID: 0, Operation: "SELECT STATEMENT", Starts: 1, ActualRows: 600},
+			{ID: 1, Depth: 1, Operation: "TABLE ACCESS", Options: "BY INDEX ROWID BATCHED", Object: "ORDER_DETAILS", EstimatedRows: 5, Starts: 120, ActualRows: 600},
+			{ID: 2, Depth: 2, Operation: "INDEX", Options: "RANGE SCAN", Object: "IDX_ORDER_DETAILS_ORDER", EstimatedRows: 5, Starts: 1, ActualRows: 600},
+		},
+	}
+	if got := plan.Steps[1].Misestimate(); got != 1 {
+		t.Errorf("Misestimate() with starts = %v, want 1", got)
+	}
+	want := "SQL_ID 7h35uxf5uhmm1 最大の見積もり誤差 120.0倍 INDEX RANGE SCAN IDX_ORDER_DETAILS_ORDER（推定 5行 × 1回 / 実際 600行）"
+	if got := plan.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if got := (Step{}).Misestimate(); got != 1 {
+		t.Errorf("Misestimate() without rows = %v, want 1", got)
+	}
+}
+
+func TestMatchCursor(t *testing.T) {
+	long := "SELECT /*+ GATHER_PLAN_STATISTICS */ detail_id FROM order_details WHERE order_id IN (" + strings.Repeat(":1, ", 1000) + ":2)"
+	cursors := []cursor{
+		{sqlID: "a", text: "SELECT /*+ GATHER_PLAN_STATISTICS */ order_id\n\t\tFROM orders"},
+		{sqlID: "b", text: long[:maxCursorText]},
+	}
+	for _, tt := range []struct {
+		query string
+		want  string
+	}{
+		{query: "SELECT /*+ GATHER_PLAN_STATISTICS */ order_id FROM orders", want: "a"},
+		{query: long, want: "b"},
+		{query: "SELECT /*+ GATHER_PLAN_STATISTICS */ order_id FROM orders WHERE order_id = :1", want: ""},
+	} {
+		got, ok := matchCursor(cursors, tt.query)
+		if got.sqlID != tt.want || ok != (tt.want != "") {
+			t.Errorf("matchCursor(%.40q) = %q, %v, want %q", tt.query, got.sqlID, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Package export - 受注・社員などのシナリオとキャッシュ比較の計測結果を、改善率付きでファイルに書き出す（-output）
+//
+// グラフ作成や実行どうしの比較に使えるよう、JSON・CSV・Markdownの表で出力する。
+// 実行時間はミリ秒の小数、改善率は基準の実行時間 / 手法の実行時間（何倍速いか）で表す。
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/fileutil"
+	"oracle-n-plus-1-demo/internal/runmeta"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// ErrUnknownFormat - 対応していない出力形式
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// 出力形式
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+// Formats - 指定できる出力形式
+var Formats = []string{FormatJSON, FormatCSV, FormatMarkdown}
+
+// extensions - 出力形式ごとの既定のファイルの拡張子
+var extensions = map[string]string{
+	FormatJSON:     ".json",
+	FormatCSV:      ".csv",
+	FormatMarkdown: ".md",
+}
+
+// cacheScenario - CSVでキャッシュ比較の行のscenario列に書く名前
+const cacheScenario = "cache"
+
+// Report - 書き出す計測結果
+type Report struct {
+	Metadata   *runmeta.Metadata     `json:"metadata,omitempty"`
+	Parameters service.RunParameters `json:"parameters"`
+	Results    []Row                 `json:"results"`
+	Cache      []CacheRow            `json:"cache,omitempty"`
+}
+
+// Row - シナリオの手法1件の結果
+type Row struct {
+	Scenario        string  `json:"scenario"`
+	Method          string  `json:"method"`
+	Repetition      int     `json:"repetition,omitempty"`
+	ExecutionTimeMs float64 `json:"execution_time_ms"`
+	RecordCount     int     `json:"record_count"`
+	AllocBytes      uint64  `json:"alloc_bytes"`
+	Allocs          uint64  `json:"allocs"`
+	// Baseline / Improvement - 改善率の基準にした手法と、基準に対する改善率（基準の手法が計測されていなければ0）
+	Baseline    string  `json:"baseline,omitempty"`
+	Improvement float64 `json:"improvement,omitempty"`
+	Description string  `json:"description"`
+	// Statements / BindExecutions - 発行したSQLの数とバインド実行の回数（数えなかった場合は0）
+	Statements     int64 `json:"statements,omitempty"`
+	BindExecutions int64 `json:"bind_executions,omitempty"`
+}
+
+// CacheRow - キャッシュ方式1件の結果
+type CacheRow struct {
+	Method           string  `json:"method"`
+	ExecutionTimeMs  float64 `json:"execution_time_ms"`
+	HitRate          float64 `json:"hit_rate"`
+	MemoryUsageBytes int64   `json:"memory_usage_bytes"`
+	// Baseline / Improvement - 改善率の基準にした方式（最初に計測した方式）と、基準に対する改善率
+	Baseline    string  `json:"baseline,omitempty"`
+	Improvement float64 `json:"improvement,omitempty"`
+	Description string  `json:"description"`
+}
+
+// CheckFormat - 出力形式を確認する（計測を終えてから誤りに気付かないよう、計測の前に呼ぶ）
+func CheckFormat(format string) error {
+	if _, ok := extensions[format]; !ok {
+		return fmt.Errorf("%w: %q (available: %s)", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+	}
+	return nil
+}
+
+// DefaultPath - 出力先を指定しなかった場合のファイル名（benchmark_results.csv など）
+func DefaultPath(format string) string {
+	return "benchmark_results" + extensions[format]
+}
+
+// Build - シナリオとキャッシュ比較の結果に改善率を付けて、書き出す内容を組み立てる
+//
+// シナリオの改善率は、同じ繰り返し・同じシナリオで基準にした手法（service.PerformanceResult.Baseline）と比べる。
+// キャッシュ比較には基準になるキャッシュなしの計測がないため、最初に計測した方式と比べる。
+func Build(meta *runmeta.Metadata, params service.RunParameters, results []service.PerformanceResult, cache []service.CacheResult) *Report {
+	type key struct {
+		repetition int
+		scenario   string
+	}
+	baselines := make(map[key]service.PerformanceResult)
+	for _, r := range results {
+		if r.Baseline {
+			baselines[key{r.Repetition, r.Scenario}] = r
+		}
+	}
+
+	report := &Report{Metadata: meta, Parameters: params, Results: []Row{}}
+	for _, r := range results {
+		row := Row{
+			Scenario:        r.Scenario,
+			Method:          r.Method,
+			Repetition:      r.Repetition,
+			ExecutionTimeMs: milliseconds(r.ExecutionTime),
+			RecordCount:     r.RecordCount,
+			AllocBytes:      r.AllocBytes,
+			Allocs:          r.Allocs,
+			Description:     r.Description,
+		}
+		if r.Queries != nil {
+			row.Statements = r.Queries.Statements()
+			row.BindExecutions = r.Queries.BindExecutions
+		}
+		if base, ok := baselines[key{r.Repetition, r.Scenario}]; ok {
+			row.Baseline = base.Method
+			row.Improvement = ratio(base.ExecutionTime, r.ExecutionTime)
+		}
+		report.Results = append(report.Results, row)
+	}
+
+	for _, c := range cache {
+		report.Cache = append(report.Cache, CacheRow{
+			Method:           c.Method,
+			ExecutionTimeMs:  milliseconds(c.ExecutionTime),
+			HitRate:          c.HitRate,
+			MemoryUsageBytes: c.MemoryUsage,
+			Baseline:         cache[0].Method,
+			Improvement:      ratio(cache[0].ExecutionTime, c.ExecutionTime),
+			Description:      c.Description,
+		})
+	}
+	return report
+}
+
+// Write - 指定した形式で書き出す
+func Write(w io.Writer, format string, report *Report) error {
+	var err error
+	switch format {
+	case FormatJSON:
+		err = writeJSON(w, report)
+	case FormatCSV:
+		err = writeCSV(w, report)
+	case FormatMarkdown:
+		err = writeMarkdown(w, report)
+	default:
+		return CheckFormat(format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s report: %w", format, err)
+	}
+	return nil
+}
+
+// WriteFile - 指定した形式でファイルに書き出す（書き込み途中のファイルを残さない）
+func WriteFile(path, format string, report *Report) error {
+	var buf bytes.Buffer
+	if err := Write(&buf, format, report); err != nil {
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("結果ファイルの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// writeJSON - 実行メタデータとパラメーターを含めてJSONで書き出す
+func writeJSON(w io.Writer, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// csvHeader - CSVの列（キャッシュ比較の行は scenario が cache で、シナリオの行は hit_rate・memory_usage_bytes が空）
+//
+// 後から加えた列は既存の列の位置を変えないよう末尾に足す。
+var csvHeader = []string{
+	"scenario", "method", "repetition", "execution_time_ms", "record_count", "alloc_bytes", "allocs",
+	"hit_rate", "memory_usage_bytes", "baseline", "improvement", "description",
+	"statements", "bind_executions",
+}
+
+// writeCSV - シナリオとキャッシュ比較の結果を1つの表として書き出す
+func writeCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range report.Results {
+		if err := cw.Write([]string{
+			r.Scenario, r.Method, optionalInt(r.Repetition), formatFloat(r.ExecutionTimeMs, 3),
+			strconv.Itoa(r.RecordCount), strconv.FormatUint(r.AllocBytes, 10), strconv.FormatUint(r.Allocs, 10),
+			"", "", r.Baseline, optionalRatio(r.Improvement), r.Description,
+			optionalInt(int(r.Statements)), optionalInt(int(r.BindExecutions)),
+		}); err != nil {
+			return err
+		}
+	}
+	for _, c := range report.Cache {
+		if err := cw.Write([]string{
+			cacheScenario, c.Method, "", formatFloat(c.ExecutionTimeMs, 3),
+			"", "", "",
+			formatFloat(c.HitRate, 1), strconv.FormatInt(c.MemoryUsageBytes, 10), c.Baseline, optionalRatio(c.Improvement), c.Description,
+			"", "",
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeMarkdown - シナリオとキャッシュ比較の結果をMarkdownの表で書き出す
+func writeMarkdown(w io.Writer, report *Report) error {
+	var b strings.Builder
+	b.WriteString("# N+1問題デモの計測結果\n\n")
+	if m := report.Metadata; m != nil {
+		fmt.Fprintf(&b, "- 実行日時: %s\n", m.CollectedAt.Format(time.RFC3339))
+		if m.Environment != "" {
+			fmt.Fprintf(&b, "- 実行環境: %s\n", m.Environment)
+		}
+		if m.DBVersion != "" {
+			fmt.Fprintf(&b, "- Oracle: %s\n", m.DBVersion)
+		}
+	}
+	fmt.Fprintf(&b, "- 対象期間: 過去%d日間\n", report.Parameters.Days)
+
+	b.WriteString("\n## シナリオ別の計測結果\n\n")
+	b.WriteString("| シナリオ | 手法 | 実行時間 (ms) | 件数 | 割り当て (B) | 改善率 |\n")
+	b.WriteString("|---|---|---:|---:|---:|---:|\n")
+	for _, r := range report.Results {
+		scenario := r.Scenario
+		if r.Repetition > 0 {
+			scenario = fmt.Sprintf("%s（%d回目）", r.Scenario, r.Repetition)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d | %s |\n",
+			markdownCell(scenario), markdownCell(r.Method), formatFloat(r.ExecutionTimeMs, 3),
+			r.RecordCount, r.AllocBytes, markdownRatio(r.Method, r.Baseline, r.Improvement))
+	}
+
+	if len(report.Cache) > 0 {
+		b.WriteString("\n## キャッシュ比較\n\n")
+		b.WriteString("| 方式 | 平均実行時間 (ms) | ヒット率 (%) | メモリ使用量 (B) | 改善率 |\n")
+		b.WriteString("|---|---:|---:|---:|---:|\n")
+		for _, c := range report.Cache {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n",
+				markdownCell(c.Method), formatFloat(c.ExecutionTimeMs, 3), formatFloat(c.HitRate, 1),
+				c.MemoryUsageBytes, markdownRatio(c.Method, c.Baseline, c.Improvement))
+		}
+	}
+	b.WriteString("\n改善率は基準の手法の実行時間 / 手法の実行時間（何倍速いか）です。\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// milliseconds - 実行時間をミリ秒の小数にする
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Nanoseconds()) / 1e6
+}
+
+// ratio - 基準の実行時間に対する改善率（どちらかが0以下の場合は0）
+func ratio(base, d time.Duration) float64 {
+	if base <= 0 || d <= 0 {
+		return 0
+	}
+	return float64(base.Nanoseconds()) / float64(d.Nanoseconds())
+}
+
+// formatFloat - 小数点以下precision桁の文字列にする
+func formatFloat(v float64, precision int) string {
+	return strconv.FormatFloat(v, 'f', precision, 64)
+}
+
+// optionalInt - 0を空欄にする
+func optionalInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+// optionalRatio - 改善率を小数点以下2桁にする（求められなかった場合は空欄）
+func optionalRatio(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return formatFloat(v, 2)
+}
+
+// markdownRatio - 改善率の列（基準の行は「基準」）
+func markdownRatio(method, baseline string, v float64) string {
+	switch {
+	case method == baseline:
+		return "基準"
+	case v == 0:
+		return "-"
+	}
+	return formatFloat(v, 1) + "x"
+}
+
+// markdownCell - 表の区切りにならないよう | をエスケープする
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/querylog"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// sampleReport - 2回繰り返した受注シナリオ（2回目は手法の順序を入れ替えた）とキャッシュ比較の結果
+func sampleReport() *Report {
+	results := []service.PerformanceResult{
+		{Scenario: service.ScenarioOrders, Method: "N+1_Problem", Repetition: 1, ExecutionTime: 100 * time.Millisecond, RecordCount: 50, Baseline: true},
+		{Scenario: service.ScenarioOrders, Method: "JOIN_Optimized", Repetition: 1, ExecutionTime: 10 * time.Millisecond, RecordCount: 50,
+			Queries: &service.QueryCounts{Counts: querylog.Counts{Queries: 1, BindExecutions: 1}}},
+		{Scenario: service.ScenarioOrders, Method: "JOIN_Optimized", Repetition: 2, ExecutionTime: 20 * time.Millisecond, RecordCount: 50},
+		{Scenario: service.ScenarioOrders, Method: "N+1_Problem", Repetition: 2, ExecutionTime: 80 * time.Millisecond, RecordCount: 50, Baseline: true},
+		{Scenario: service.ScenarioSharedPool, Method: "Literal_N+1", ExecutionTime: 30 * time.Millisecond, Description: "リテラル埋め込み, 並列"},
+	}
+	cache := []service.CacheResult{
+		{Method: "Oracle_Buffer_Cache", ExecutionTime: 4 * time.Millisecond, HitRate: 90},
+		{Method: "Redis_External_Cache", ExecutionTime: time.Millisecond, HitRate: 100, MemoryUsage: 2048},
+	}
+	return Build(nil, service.RunParameters{Days: 30}, results, cache)
+}
+
+func TestBuild(t *testing.T) {
+	report := sampleReport()
+	tests := []struct {
+		method      string
+		repetition  int
+		baseline    string
+		improvement float64
+	}{
+		{method: "N+1_Problem", repetition: 1, baseline: "N+1_Problem", improvement: 1},
+		{method: "JOIN_Optimized", repetition: 1, baseline: "N+1_Problem", improvement: 10},
+		// 基準の手法が後に計測されても、同じ繰り返しの基準と比べる
+		{method: "JOIN_Optimized", repetition: 2, baseline: "N+1_Problem", improvement: 4},
+		{method: "N+1_Problem", repetition: 2, baseline: "N+1_Problem", improvement: 1},
+		// 基準のないシナリオは改善率を求めない
+		{method: "Literal_N+1"},
+	}
+	if len(report.Results) != len(tests) {
+		t.Fatalf("Build() returned %d rows, want %d", len(report.Results), len(tests))
+	}
+	for i, tt := range tests {
+		row := report.Results[i]
+		if row.Method != tt.method || row.Repetition != tt.repetition || row.Baseline != tt.baseline || row.Improvement != tt.improvement {
+			t.Errorf("row %d = %+v, want %s #%d baseline %q improvement %v", i, row, tt.method, tt.repetition, tt.baseline, tt.improvement)
+		}
+	}
+
+	if len(report.Cache) != 2 || report.Cache[1].Baseline != "Oracle_Buffer_Cache" || report.Cache[1].Improvement != 4 {
+		t.Errorf("Cache = %+v, want Redis_External_Cache 4x faster than Oracle_Buffer_Cache", report.Cache)
+	}
+	if report.Results[1].ExecutionTimeMs != 10 {
+		t.Errorf("ExecutionTimeMs = %v, want 10", report.Results[1].ExecutionTimeMs)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, sampleReport()); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("written CSV is invalid: %v", err)
+	}
+	if len(records) != 1+5+2 {
+		t.Fatalf("CSV has %d records, want header + 7 rows", len(records))
+	}
+	for i, record := range records {
+		if len(record) != len(csvHeader) {
+			t.Errorf("record %d has %d columns, want %d", i, len(record), len(csvHeader))
+		}
+	}
+	if got := strings.Join(records[2], ","); got != "orders,JOIN_Optimized,1,10.000,50,0,0,,,N+1_Problem,10.00,,1,1" {
+		t.Errorf("scenario row = %s", got)
+	}
+	if got := records[5][11]; got != "リテラル埋め込み, 並列" {
+		t.Errorf("description with comma = %q", got)
+	}
+	if got := strings.Join(records[7], ","); got != "cache,Redis_External_Cache,,1.000,,,,100.0,2048,Oracle_Buffer_Cache,4.00,,," {
+		t.Errorf("cache row = %s", got)
+	}
+}
+
+func TestWriteJSONAndMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, sampleReport()); err != nil {
+		t.Fatalf("Write(json) failed: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("written JSON is invalid: %v", err)
+	}
+	if len(decoded.Results) != 5 || decoded.Results[1].Improvement != 10 || decoded.Parameters.Days != 30 {
+		t.Errorf("decoded JSON = %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, FormatMarkdown, sampleReport()); err != nil {
+		t.Fatalf("Write(markdown) failed: %v", err)
+	}
+	for _, want := range []string{
+		"| orders（1回目） | N+1_Problem | 100.000 | 50 | 0 | 基準 |",
+		"| orders（1回目） | JOIN_Optimized | 10.000 | 50 | 0 | 10.0x |",
+		"| shared_pool | Literal_N+1 | 30.000 | 0 | 0 | - |",
+		"| Redis_External_Cache | 1.000 | 100.0 | 2048 | 4.0x |",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("markdown does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	for _, format := range Formats {
+		if err := CheckFormat(format); err != nil {
+			t.Errorf("CheckFormat(%q) = %v", format, err)
+		}
+	}
+	if err := CheckFormat("xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("CheckFormat(xml) = %v, want ErrUnknownFormat", err)
+	}
+	if got := DefaultPath(FormatMarkdown); got != "benchmark_results.md" {
+		t.Errorf("DefaultPath(markdown) = %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+// Package flashback - 計測の問い合わせを固定する時点（SCN）の指定と解決
+//
+// 計測中にほかのセッションがデータを更新しても手法間の比較が崩れないよう、すべての接続を
+// DBMS_FLASHBACK.ENABLE_AT_SYSTEM_CHANGE_NUMBERで同じSCNに固定する（config.SessionSettings.FlashbackSCN）。
+package flashback
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Start - 実行開始時のSCNに固定する指定
+const Start = "start"
+
+// TimestampLayout - 時刻で指定する場合の書式（データベースのセッションのタイムゾーンで解釈する）
+const TimestampLayout = "2006-01-02 15:04:05"
+
+// AsOf - 問い合わせを固定する時点の指定（Start、SCN、時刻のいずれか1つ）
+type AsOf struct {
+	Start     bool
+	SCN       uint64
+	Timestamp time.Time
+}
+
+// Parse - -as-of の値を解釈する（空ならnil）
+func Parse(value string) (*AsOf, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return nil, nil
+	case value == Start:
+		return &AsOf{Start: true}, nil
+	}
+	if scn, err := strconv.ParseUint(value, 10, 64); err == nil {
+		if scn == 0 {
+			return nil, errors.New("SCN must be positive")
+		}
+		return &AsOf{SCN: scn}, nil
+	}
+	ts, err := time.Parse(TimestampLayout, value)
+	if err != nil {
+		return nil, fmt.Errorf("must be %q, an SCN or a timestamp %q: %q", Start, "YYYY-MM-DD HH24:MI:SS", value)
+	}
+	return &AsOf{Timestamp: ts}, nil
+}
+
+// String - 指定の表示
+func (a AsOf) String() string {
+	switch {
+	case a.Start:
+		return "実行開始時"
+	case a.SCN != 0:
+		return fmt.Sprintf("SCN %d", a.SCN)
+	default:
+		return a.Timestamp.Format(TimestampLayout)
+	}
+}
+
+// Resolve - 指定をSCNに解決する（実行開始時はDBMS_FLASHBACK、時刻はTIMESTAMP_TO_SCNで取得）
+//
+// 時刻からのSCNは約3秒の精度で、UNDO_RETENTIONより古い時刻は解決できない。
+func Resolve(ctx context.Context, db *sql.DB, a AsOf) (uint64, error) {
+	var scn uint64
+	switch {
+	case a.SCN != 0:
+		return a.SCN, nil
+	case a.Start:
+		if err := db.QueryRowContext(ctx, "SELECT DBMS_FLASHBACK.GET_SYSTEM_CHANGE_NUMBER FROM dual").Scan(&scn); err != nil {
+			return 0, fmt.Errorf("failed to get current SCN (DBMS_FLASHBACKのEXECUTE権限が必要です): %w", err)
+		}
+	default:
+		if err := db.QueryRowContext(ctx, "SELECT TIMESTAMP_TO_SCN(TO_TIMESTAMP(:1, 'YYYY-MM-DD HH24:MI:SS')) FROM dual",
+			a.Timestamp.Format(TimestampLayout)).Scan(&scn); err != nil {
+			return 0, fmt.Errorf("failed to convert %s to SCN: %w", a, err)
+		}
+	}
+	return scn, nil
+}
@@ -0,0 +1,49 @@
+package flashback
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *AsOf
+		wantErr bool
+	}{
+		{name: "empty", value: ""},
+		{name: "start", value: "start", want: &AsOf{Start: true}},
+		{name: "scn", value: " 1234567 ", want: &AsOf{SCN: 1234567}},
+		{name: "timestamp", value: "2026-10-16 09:30:00", want: &AsOf{Timestamp: time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)}},
+		{name: "zero scn", value: "0", wantErr: true},
+		{name: "negative scn", value: "-1", wantErr: true},
+		{name: "date only", value: "2026-10-16", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Parse() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && (got.Start != tt.want.Start || got.SCN != tt.want.SCN || !got.Timestamp.Equal(tt.want.Timestamp))) {
+			t.Errorf("%s: Parse() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAsOfString(t *testing.T) {
+	tests := []struct {
+		asOf AsOf
+		want string
+	}{
+		{AsOf{Start: true}, "実行開始時"},
+		{AsOf{SCN: 42}, "SCN 42"},
+		{AsOf{Timestamp: time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)}, "2026-10-16 09:30:00"},
+	}
+	for _, tt := range tests {
+		if got := tt.asOf.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
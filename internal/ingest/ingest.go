@@ -0,0 +1,507 @@
+package ingest
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/sqlutil"
+)
+
+// ColumnType - 取り込み対象列の型
+type ColumnType int
+
+const (
+	// TypeInt - 整数（NUMBER(10)など）
+	TypeInt ColumnType = iota
+	// TypeFloat - 小数（NUMBER(12,2)など）
+	TypeFloat
+	// TypeString - 文字列（VARCHAR2）
+	TypeString
+	// TypeDate - 日付（DATE）
+	TypeDate
+)
+
+// Column - 取り込み対象列の定義
+type Column struct {
+	Name     string
+	Type     ColumnType
+	Required bool
+}
+
+// TableSpec - 取り込み対象テーブルの定義
+type TableSpec struct {
+	Name    string
+	Columns []Column
+}
+
+// LoadResult - 1ファイル分の取り込み結果
+type LoadResult struct {
+	Table    string        `json:"table"`
+	File     string        `json:"file"`
+	Rows     int           `json:"rows"`
+	Batches  int           `json:"batches"`
+	Duration time.Duration `json:"duration"`
+}
+
+// DefaultBatchSize - 配列バインド1回あたりのデフォルト行数
+const DefaultBatchSize = 1000
+
+// ErrParquetUnsupported - Parquet形式が指定された場合のエラー
+//
+// Parquetの読み取りは保守されたライブラリなしに正しく実装する範囲が大きいため、このデモでは対象外とする。
+// 黙って読み飛ばすと取り込んだつもりのデータが欠けるため、.parquet のファイルがあればエラーにする。
+var ErrParquetUnsupported = errors.New("parquet形式は未対応です（CSVに変換して取り込んでください）")
+
+// dateLayouts - 日付列として受け付けるレイアウト
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006/01/02",
+	"2006/01/02 15:04:05",
+	time.RFC3339,
+}
+
+// TableSpecs - デモスキーマの取り込み定義（外部キーの依存順）
+var TableSpecs = []TableSpec{
+	{
+		Name: "departments",
+		Columns: []Column{
+			{Name: "department_id", Type: TypeInt, Required: true},
+			{Name: "department_name", Type: TypeString, Required: true},
+			{Name: "location", Type: TypeString},
+		},
+	},
+	{
+		Name: "employees",
+		Columns: []Column{
+			{Name: "employee_id", Type: TypeInt, Required: true},
+			{Name: "first_name", Type: TypeString, Required: true},
+			{Name: "last_name", Type: TypeString, Required: true},
+			{Name: "email", Type: TypeString, Required: true},
+			{Name: "department_id", Type: TypeInt},
+			{Name: "salary", Type: TypeFloat},
+			{Name: "hire_date", Type: TypeDate},
+		},
+	},
+	{
+		Name: "projects",
+		Columns: []Column{
+			{Name: "project_id", Type: TypeInt, Required: true},
+			{Name: "project_name", Type: TypeString, Required: true},
+			{Name: "budget", Type: TypeFloat},
+		},
+	},
+	{
+		Name: "employee_projects",
+		Columns: []Column{
+			{Name: "employee_id", Type: TypeInt, Required: true},
+			{Name: "project_id", Type: TypeInt, Required: true},
+			{Name: "project_role", Type: TypeString},
+		},
+	},
+	{
+		Name: "products",
+		Columns: []Column{
+			{Name: "product_id", Type: TypeInt, Required: true},
+			{Name: "product_name", Type: TypeString, Required: true},
+			{Name: "category", Type: TypeString},
+			{Name: "list_price", Type: TypeFloat},
+		},
+	},
+	{
+		Name: "orders",
+		Columns: []Column{
+			{Name: "order_id", Type: TypeInt, Required: true},
+			{Name: "customer_id", Type: TypeInt, Required: true},
+			{Name: "customer_name", Type: TypeString, Required: true},
+			{Name: "order_date", Type: TypeDate},
+			{Name: "total_amount", Type: TypeFloat},
+			{Name: "status", Type: TypeString},
+			{Name: "notes", Type: TypeString},
+		},
+	},
+	{
+		Name: "order_details",
+		Columns: []Column{
+			{Name: "detail_id", Type: TypeInt, Required: true},
+			{Name: "order_id", Type: TypeInt, Required: true},
+			{Name: "product_id", Type: TypeInt, Required: true},
+			{Name: "product_name", Type: TypeString, Required: true},
+			{Name: "quantity", Type: TypeInt, Required: true},
+			{Name: "unit_price", Type: TypeFloat, Required: true},
+		},
+	},
+}
+
+// Loader - CSVファイルをデモスキーマへ一括取り込みするローダー
+type Loader struct {
+	db        *sql.DB
+	batchSize int
+}
+
+// NewLoader - ローダーのコンストラクタ
+func NewLoader(db *sql.DB, batchSize int) *Loader {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Loader{db: db, batchSize: batchSize}
+}
+
+// LookupTableSpec - テーブル名から取り込み定義を取得
+func LookupTableSpec(table string) (TableSpec, bool) {
+	for _, spec := range TableSpecs {
+		if strings.EqualFold(spec.Name, table) {
+			return spec, true
+		}
+	}
+	return TableSpec{}, false
+}
+
+// LoadDirectory - ディレクトリ内の <テーブル名>.csv を依存順に取り込む
+func (l *Loader) LoadDirectory(dir string) ([]LoadResult, error) {
+	var results []LoadResult
+
+	for _, spec := range TableSpecs {
+		path, err := findTableFile(dir, spec.Name)
+		if err != nil {
+			return results, err
+		}
+		if path == "" {
+			continue // ファイルがないテーブルはスキップ
+		}
+
+		result, err := l.LoadFile(spec.Name, path)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *result)
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("取り込み対象のファイルが見つかりません: %s", dir)
+	}
+
+	return results, nil
+}
+
+// findTableFile - テーブルに対応するファイルを探す
+func findTableFile(dir, table string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, table+".parquet")); err == nil {
+		return "", fmt.Errorf("%s.parquet: %w", table, ErrParquetUnsupported)
+	}
+
+	path := filepath.Join(dir, table+".csv")
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	return path, nil
+}
+
+// LoadFile - 1つのCSVファイルを指定テーブルへ取り込む
+func (l *Loader) LoadFile(table, path string) (*LoadResult, error) {
+	spec, ok := LookupTableSpec(table)
+	if !ok {
+		return nil, fmt.Errorf("取り込み対象外のテーブルです: %s", table)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".parquet") {
+		return nil, fmt.Errorf("%s: %w", path, ErrParquetUnsupported)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil {
+			fmt.Printf("file.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	start := time.Now()
+	result := &LoadResult{Table: spec.Name, File: path}
+
+	reader := csv.NewReader(f)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header of %s: %w", path, err)
+	}
+
+	columns, err := mapHeader(spec, header)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	insertSQL, err := buildInsertSQL(spec.Name, columns)
+	if err != nil {
+		return nil, err
+	}
+	batch := newColumnBatch(columns, l.batchSize)
+	line := 1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: failed to read record: %w", path, line, err)
+		}
+
+		if err := batch.append(record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		if batch.len() >= l.batchSize {
+			if err := l.flush(insertSQL, batch); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			result.Rows += batch.len()
+			result.Batches++
+			batch.reset()
+		}
+	}
+
+	if batch.len() > 0 {
+		if err := l.flush(insertSQL, batch); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		result.Rows += batch.len()
+		result.Batches++
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// flush - 配列バインドでバッチをINSERT
+func (l *Loader) flush(insertSQL string, batch *columnBatch) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// go-oraはスライスを引数に渡すと配列バインド（1ラウンドトリップ）で実行する
+	if _, err := tx.Exec(insertSQL, batch.args()...); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			fmt.Printf("tx.Rollback() failed: %v\n", rerr)
+		}
+		return fmt.Errorf("failed to execute batch insert: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	return nil
+}
+
+// mapHeader - CSVヘッダーをテーブル定義の列へ対応付ける
+func mapHeader(spec TableSpec, header []string) ([]Column, error) {
+	byName := make(map[string]Column, len(spec.Columns))
+	for _, col := range spec.Columns {
+		byName[col.Name] = col
+	}
+
+	seen := make(map[string]bool, len(header))
+	columns := make([]Column, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		col, ok := byName[key]
+		if !ok {
+			return nil, fmt.Errorf("未知の列です: %s（テーブル %s）", name, spec.Name)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("列が重複しています: %s", name)
+		}
+		seen[key] = true
+		columns[i] = col
+	}
+
+	for _, col := range spec.Columns {
+		if col.Required && !seen[col.Name] {
+			return nil, fmt.Errorf("必須列がありません: %s（テーブル %s）", col.Name, spec.Name)
+		}
+	}
+
+	return columns, nil
+}
+
+// buildInsertSQL - 列リストからINSERT文を組み立てる
+//
+// 列名はmapHeaderでテーブル定義と照合済みのもののみが渡される。
+func buildInsertSQL(table string, columns []Column) (string, error) {
+	quotedTable, err := sqlutil.QuoteIdentifier(table)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quotedTable, strings.Join(names, ", "), sqlutil.Placeholders(len(columns))), nil
+}
+
+// columnBatch - 列ごとのスライスに値を蓄積するバッチ
+type columnBatch struct {
+	columns []Column
+	ints    map[int][]sql.NullInt64
+	floats  map[int][]sql.NullFloat64
+	strings map[int][]sql.NullString
+	dates   map[int][]sql.NullTime
+	rows    int
+}
+
+// newColumnBatch - バッチのコンストラクタ
+func newColumnBatch(columns []Column, capacity int) *columnBatch {
+	b := &columnBatch{
+		columns: columns,
+		ints:    make(map[int][]sql.NullInt64),
+		floats:  make(map[int][]sql.NullFloat64),
+		strings: make(map[int][]sql.NullString),
+		dates:   make(map[int][]sql.NullTime),
+	}
+	for i, col := range columns {
+		switch col.Type {
+		case TypeInt:
+			b.ints[i] = make([]sql.NullInt64, 0, capacity)
+		case TypeFloat:
+			b.floats[i] = make([]sql.NullFloat64, 0, capacity)
+		case TypeString:
+			b.strings[i] = make([]sql.NullString, 0, capacity)
+		case TypeDate:
+			b.dates[i] = make([]sql.NullTime, 0, capacity)
+		}
+	}
+	return b
+}
+
+// append - 1レコード分の値を型変換して追加
+func (b *columnBatch) append(record []string) error {
+	if len(record) != len(b.columns) {
+		return fmt.Errorf("列数が一致しません: 期待値 %d, 実際 %d", len(b.columns), len(record))
+	}
+
+	for i, col := range b.columns {
+		raw := strings.TrimSpace(record[i])
+		if raw == "" && col.Required {
+			return fmt.Errorf("必須列 %s が空です", col.Name)
+		}
+
+		switch col.Type {
+		case TypeInt:
+			v := sql.NullInt64{}
+			if raw != "" {
+				n, err := strconv.ParseInt(raw, 10, 64)
+				if err != nil {
+					return fmt.Errorf("列 %s を整数に変換できません: %q", col.Name, raw)
+				}
+				v = sql.NullInt64{Int64: n, Valid: true}
+			}
+			b.ints[i] = append(b.ints[i], v)
+		case TypeFloat:
+			v := sql.NullFloat64{}
+			if raw != "" {
+				f, err := strconv.ParseFloat(raw, 64)
+				if err != nil {
+					return fmt.Errorf("列 %s を数値に変換できません: %q", col.Name, raw)
+				}
+				v = sql.NullFloat64{Float64: f, Valid: true}
+			}
+			b.floats[i] = append(b.floats[i], v)
+		case TypeString:
+			b.strings[i] = append(b.strings[i], sql.NullString{String: raw, Valid: raw != ""})
+		case TypeDate:
+			v := sql.NullTime{}
+			if raw != "" {
+				t, err := parseDate(raw)
+				if err != nil {
+					return fmt.Errorf("列 %s を日付に変換できません: %q", col.Name, raw)
+				}
+				v = sql.NullTime{Time: t, Valid: true}
+			}
+			b.dates[i] = append(b.dates[i], v)
+		}
+	}
+
+	b.rows++
+	return nil
+}
+
+// args - 列順にスライスを並べたバインド引数
+func (b *columnBatch) args() []interface{} {
+	args := make([]interface{}, len(b.columns))
+	for i, col := range b.columns {
+		switch col.Type {
+		case TypeInt:
+			args[i] = b.ints[i]
+		case TypeFloat:
+			args[i] = b.floats[i]
+		case TypeString:
+			args[i] = b.strings[i]
+		case TypeDate:
+			args[i] = b.dates[i]
+		}
+	}
+	return args
+}
+
+// len - 蓄積済みの行数
+func (b *columnBatch) len() int {
+	return b.rows
+}
+
+// reset - スライスを再利用してバッチを空にする
+func (b *columnBatch) reset() {
+	for i := range b.ints {
+		b.ints[i] = b.ints[i][:0]
+	}
+	for i := range b.floats {
+		b.floats[i] = b.floats[i][:0]
+	}
+	for i := range b.strings {
+		b.strings[i] = b.strings[i][:0]
+	}
+	for i := range b.dates {
+		b.dates[i] = b.dates[i][:0]
+	}
+	b.rows = 0
+}
+
+// parseDate - 受け付けるレイアウトのいずれかで日付を解析
+func parseDate(raw string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported date format: %s", raw)
+}
+
+// GatherStats - 取り込んだテーブルのオプティマイザ統計を更新
+func (l *Loader) GatherStats(results []LoadResult) error {
+	for _, result := range results {
+		_, err := l.db.Exec("BEGIN DBMS_STATS.GATHER_TABLE_STATS(USER, :1); END;", strings.ToUpper(result.Table))
+		if err != nil {
+			return fmt.Errorf("failed to gather stats for %s: %w", result.Table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package ingest
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMapHeader(t *testing.T) {
+	spec, _ := LookupTableSpec("PRODUCTS")
+
+	tests := []struct {
+		name    string
+		header  []string
+		want    []string
+		wantErr string
+	}{
+		{name: "reordered", header: []string{"product_name", "product_id"}, want: []string{"product_name", "product_id"}},
+		{name: "bom and case", header: []string{"\ufeffPRODUCT_ID", " Product_Name ", "list_price"}, want: []string{"product_id", "product_name", "list_price"}},
+		{name: "unknown column", header: []string{"product_id", "product_name", "color"}, wantErr: "未知の列です: color"},
+		{name: "duplicate column", header: []string{"product_id", "product_name", "PRODUCT_ID"}, wantErr: "列が重複しています: PRODUCT_ID"},
+		{name: "missing required", header: []string{"product_id", "category"}, wantErr: "必須列がありません: product_name"},
+	}
+	for _, tt := range tests {
+		columns, err := mapHeader(spec, tt.header)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: mapHeader(%q) error = %v, want %q", tt.name, tt.header, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: mapHeader(%q) failed: %v", tt.name, tt.header, err)
+			continue
+		}
+		got := make([]string, len(columns))
+		for i, col := range columns {
+			got[i] = col.Name
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: mapHeader(%q) = %q, want %q", tt.name, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{raw: "2024-01-31", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+		{raw: "2024-01-31 09:15:30", want: time.Date(2024, 1, 31, 9, 15, 30, 0, time.Local)},
+		{raw: "2024/01/31", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+		{raw: "2024/01/31 09:15:30", want: time.Date(2024, 1, 31, 9, 15, 30, 0, time.Local)},
+		{raw: "2024-01-31T09:15:30+09:00", want: time.Date(2024, 1, 31, 0, 15, 30, 0, time.UTC)},
+		{raw: "2024-01-31T00:15:30.123456Z", want: time.Date(2024, 1, 31, 0, 15, 30, 123456000, time.UTC)},
+		{raw: "2024-01-31 09:15:30.5", want: time.Date(2024, 1, 31, 9, 15, 30, 500000000, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.raw)
+		if err != nil {
+			t.Errorf("parseDate(%q) failed: %v", tt.raw, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+
+	for _, raw := range []string{"31/01/2024", "2024-13-01", "yesterday"} {
+		if _, err := parseDate(raw); err == nil {
+			t.Errorf("parseDate(%q) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestColumnBatchAppend(t *testing.T) {
+	spec, _ := LookupTableSpec("employees")
+	columns, err := mapHeader(spec, []string{"employee_id", "first_name", "last_name", "email", "department_id", "salary", "hire_date"})
+	if err != nil {
+		t.Fatalf("mapHeader() failed: %v", err)
+	}
+	batch := newColumnBatch(columns, 2)
+
+	records := [][]string{
+		{"1", "太郎", "山田", "taro@example.com", "10", "5000.50", "2020-04-01"},
+		{" 2 ", "Hanako", "Sato", "hanako@example.com", "", "", ""},
+	}
+	for _, record := range records {
+		if err := batch.append(record); err != nil {
+			t.Fatalf("append(%q) failed: %v", record, err)
+		}
+	}
+	if batch.len() != 2 {
+		t.Fatalf("len() = %d, want 2", batch.len())
+	}
+
+	want := []interface{}{
+		[]sql.NullInt64{{Int64: 1, Valid: true}, {Int64: 2, Valid: true}},
+		[]sql.NullString{{String: "太郎", Valid: true}, {String: "Hanako", Valid: true}},
+		[]sql.NullString{{String: "山田", Valid: true}, {String: "Sato", Valid: true}},
+		[]sql.NullString{{String: "taro@example.com", Valid: true}, {String: "hanako@example.com", Valid: true}},
+		[]sql.NullInt64{{Int64: 10, Valid: true}, {}},
+		[]sql.NullFloat64{{Float64: 5000.5, Valid: true}, {}},
+		[]sql.NullTime{{Time: time.Date(2020, 4, 1, 0, 0, 0, 0, time.Local), Valid: true}, {}},
+	}
+	if got := batch.args(); !reflect.DeepEqual(got, want) {
+		t.Errorf("args() = %v, want %v", got, want)
+	}
+
+	batch.reset()
+	if batch.len() != 0 {
+		t.Errorf("len() after reset() = %d, want 0", batch.len())
+	}
+	for i, arg := range batch.args() {
+		if reflect.ValueOf(arg).Len() != 0 {
+			t.Errorf("args()[%d] after reset() has %d values, want 0", i, reflect.ValueOf(arg).Len())
+		}
+	}
+}
+
+func TestColumnBatchAppendErrors(t *testing.T) {
+	spec, _ := LookupTableSpec("employees")
+	columns, err := mapHeader(spec, []string{"employee_id", "first_name", "last_name", "email", "salary", "hire_date"})
+	if err != nil {
+		t.Fatalf("mapHeader() failed: %v", err)
+	}
+
+	tests := []struct {
+		record  []string
+		wantErr string
+	}{
+		{record: []string{"1", "a", "b", "c", "1"}, wantErr: "列数が一致しません"},
+		{record: []string{"", "a", "b", "c", "1", ""}, wantErr: "必須列 employee_id が空です"},
+		{record: []string{"1.5", "a", "b", "c", "1", ""}, wantErr: "列 employee_id を整数に変換できません"},
+		{record: []string{"1", "a", "b", "c", "abc", ""}, wantErr: "列 salary を数値に変換できません"},
+		{record: []string{"1", "a", "b", "c", "1", "01/04/2020"}, wantErr: "列 hire_date を日付に変換できません"},
+	}
+	for _, tt := range tests {
+		batch := newColumnBatch(columns, 1)
+		err := batch.append(tt.record)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("append(%q) error = %v, want %q", tt.record, err, tt.wantErr)
+		}
+		if batch.len() != 0 {
+			t.Errorf("append(%q) counted a failed row", tt.record)
+		}
+	}
+}
+
+func TestBuildInsertSQL(t *testing.T) {
+	spec, _ := LookupTableSpec("projects")
+	got, err := buildInsertSQL(spec.Name, spec.Columns)
+	if err != nil {
+		t.Fatalf("buildInsertSQL() failed: %v", err)
+	}
+	if want := `INSERT INTO "PROJECTS" (project_id, project_name, budget) VALUES (:1,:2,:3)`; got != want {
+		t.Errorf("buildInsertSQL() = %q, want %q", got, want)
+	}
+}
+
+func TestFindTableFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+	}
+	write("orders.csv", "order_id\n")
+	write("products.parquet", "")
+	write("projects.csv", "")
+	write("projects.parquet", "")
+
+	tests := []struct {
+		table   string
+		want    string
+		wantErr error
+	}{
+		{table: "orders", want: filepath.Join(dir, "orders.csv")},
+		{table: "departments", want: ""},
+		// Parquetは対象外のため、CSVと並んでいても黙って無視せずエラーにする
+		{table: "products", wantErr: ErrParquetUnsupported},
+		{table: "projects", wantErr: ErrParquetUnsupported},
+	}
+	for _, tt := range tests {
+		got, err := findTableFile(dir, tt.table)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("findTableFile(%q) error = %v, want %v", tt.table, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("findTableFile(%q) = %q, want %q", tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFileRejectsParquet(t *testing.T) {
+	// 拡張子で判定するため、ファイルを開く前（DBに接続する前）にエラーになる
+	loader := NewLoader(nil, 0)
+	if _, err := loader.LoadFile("orders", filepath.Join(t.TempDir(), "orders.PARQUET")); !errors.Is(err, ErrParquetUnsupported) {
+		t.Errorf("LoadFile(parquet) error = %v, want %v", err, ErrParquetUnsupported)
+	}
+}
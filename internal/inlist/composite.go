@@ -0,0 +1,170 @@
+package inlist
+
+import (
+	"errors"
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/sqlutil"
+)
+
+// 複合キー（受注ID・商品ID）で比較する取得方法
+const (
+	// MethodPerKey - 組ごとに1回SQLを実行する（N+1と同じ形）
+	MethodPerKey = "Per_Key"
+	// MethodTupleIn - sqlutil.Tuplesで (order_id, product_id) IN ((:1,:2),...) に展開する
+	MethodTupleIn = "Tuple_IN"
+	// MethodOrChain - sqlutil.OrChainで (order_id = :1 AND product_id = :2) OR ... に展開する
+	MethodOrChain = "OR_Chain"
+)
+
+// KeyTempTableName - 複合キーの一時表（ベンチマーク中に作成し、終了時に削除する）
+const KeyTempTableName = "NPLUS1_IN_LIST_KEYS"
+
+// keyColumns - 複合キーの列（OrChainに埋め込む）
+var keyColumns = []string{"od.order_id", "od.product_id"}
+
+// runComposite - 受注ID・商品IDの組をcfg.IDs件取得し、複合キーの取得方法で明細をcfg.Runs回ずつ取得して計測する
+//
+// 複合キーはコレクション型1つでは渡せない（オブジェクト型の作成が必要な）ため、配列バインドは比較しない。
+func (b *bench) runComposite(report *Report, cfg Config) error {
+	keys, err := b.loadOrderProductKeys(cfg.IDs)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no order details found")
+	}
+	report.IDs = len(keys)
+
+	perKey := Result{Method: MethodPerKey, Executions: len(keys)}
+	if err := b.measure(&perKey, cfg.Runs, func() (int, error) { return b.fetchPerKey(keys) }); err != nil {
+		return err
+	}
+	report.Results = append(report.Results, perKey)
+
+	tuples := sqlutil.Int64Tuples(keys)
+	for _, method := range []string{MethodTupleIn, MethodOrChain} {
+		for _, size := range cfg.ChunkSizes {
+			result := Result{Method: method, ChunkSize: size, Executions: (len(keys) + size - 1) / size}
+			err := b.measure(&result, cfg.Runs, func() (int, error) { return b.fetchTuples(method, tuples, size) })
+			if err != nil {
+				return err
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+
+	tempTable, err := b.measureKeyTempTable(keys, cfg.Runs)
+	if err != nil {
+		return err
+	}
+	report.Results = append(report.Results, tempTable)
+	return nil
+}
+
+// loadOrderProductKeys - 明細の取得に使う受注ID・商品IDの組を受注ID順に重複なく最大n件取得
+func (b *bench) loadOrderProductKeys(n int) (keys [][]int64, err error) {
+	rows, err := b.q.Query(`
+		SELECT order_id, product_id FROM (
+			SELECT DISTINCT order_id, product_id FROM order_details ORDER BY order_id, product_id)
+		WHERE ROWNUM <= :1`, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order and product ids: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var orderID, productID int64
+		if err := rows.Scan(&orderID, &productID); err != nil {
+			return nil, fmt.Errorf("failed to scan order and product id: %w", err)
+		}
+		keys = append(keys, []int64{orderID, productID})
+	}
+	return keys, rows.Err()
+}
+
+// fetchPerKey - 組ごとに明細を取得
+func (b *bench) fetchPerKey(keys [][]int64) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT /* %s */ %s
+		FROM order_details od
+		WHERE od.order_id = :1 AND od.product_id = :2`, b.tag, detailColumns)
+
+	count := 0
+	for _, key := range keys {
+		rows, err := b.q.Query(query, key[0], key[1])
+		if err != nil {
+			return 0, fmt.Errorf("failed to query details of order %d and product %d: %w", key[0], key[1], err)
+		}
+		n, err := scanAll(rows)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
+// fetchTuples - 組をsizeずつtuple INまたは等価条件のORに展開して明細を取得
+func (b *bench) fetchTuples(method string, tuples sqlutil.Tuples, size int) (int, error) {
+	condition, arg := "(od.order_id, od.product_id) IN (?)", interface{}(tuples)
+	if method == MethodOrChain {
+		condition, arg = "(?)", sqlutil.OrChain{Columns: keyColumns, Tuples: tuples}
+	}
+	query := fmt.Sprintf(`
+		SELECT /* %s */ %s
+		FROM order_details od
+		WHERE %s`, b.tag, detailColumns, condition)
+
+	count := 0
+	err := sqlutil.QueryIn(b.q, query, size, scanDetails(&count), arg)
+	return count, err
+}
+
+// measureKeyTempTable - 複合キーの一時表を用意して計測し、作成した一時表を削除する（作成できない場合はSkipped）
+func (b *bench) measureKeyTempTable(keys [][]int64, runs int) (Result, error) {
+	result := Result{Method: MethodTempTable, Executions: 3}
+
+	created, err := b.createTempTable(KeyTempTableName,
+		"order_id NUMBER(10), product_id NUMBER(10), PRIMARY KEY (order_id, product_id)")
+	if err != nil {
+		result.Skipped = err.Error()
+		return result, nil
+	}
+	if created {
+		defer b.dropTempTable(KeyTempTableName)
+	}
+
+	orderIDs := make([]int64, len(keys))
+	productIDs := make([]int64, len(keys))
+	for i, key := range keys {
+		orderIDs[i], productIDs[i] = key[0], key[1]
+	}
+	err = b.measure(&result, runs, func() (int, error) { return b.fetchKeyTempTable(orderIDs, productIDs) })
+	return result, err
+}
+
+// fetchKeyTempTable - 一時表を空にして組を配列バインドでINSERTし、2つの列の結合で明細を取得
+func (b *bench) fetchKeyTempTable(orderIDs, productIDs []int64) (int, error) {
+	if _, err := b.q.Exec(fmt.Sprintf("DELETE /* %s */ FROM %s", b.tag, KeyTempTableName)); err != nil {
+		return 0, fmt.Errorf("failed to delete from temporary table: %w", err)
+	}
+	// go-oraはスライスを引数に渡すと配列バインド（1ラウンドトリップ）で実行する
+	if _, err := b.q.Exec(fmt.Sprintf("INSERT /* %s */ INTO %s (order_id, product_id) VALUES (:1, :2)", b.tag, KeyTempTableName),
+		orderIDs, productIDs); err != nil {
+		return 0, fmt.Errorf("failed to insert into temporary table: %w", err)
+	}
+
+	rows, err := b.q.Query(fmt.Sprintf(`
+		SELECT /* %s */ %s
+		FROM order_details od
+		JOIN %s t ON od.order_id = t.order_id AND od.product_id = t.product_id`, b.tag, detailColumns, KeyTempTableName))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query temporary table join: %w", err)
+	}
+	return scanAll(rows)
+}
@@ -0,0 +1,460 @@
+// Package inlist - IDの一括取得について、IN句のバインド数・一時表との結合・配列バインドのパースと実行のコストを比較する
+//
+// 受注ID・商品IDの組（複合キー）での取得は、1組ずつの取得・tuple IN・等価条件のOR・一時表との結合で比較する（composite.go）。
+package inlist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	go_ora "github.com/sijms/go-ora/v2"
+
+	"oracle-n-plus-1-demo/internal/sessionstats"
+	"oracle-n-plus-1-demo/internal/sqlutil"
+	"oracle-n-plus-1-demo/internal/stats"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// 比較する取得方法
+const (
+	// MethodInList - sqlutil.QueryInでチャンクサイズずつIN句に展開する
+	MethodInList = "IN_List"
+	// MethodTempTable - IDを一時表に配列バインドでINSERTして結合する
+	MethodTempTable = "TempTable_Join"
+	// MethodArrayBind - IDをコレクション型として1つのバインド変数で渡し、TABLE()で展開する
+	MethodArrayBind = "Array_Bind"
+)
+
+const (
+	// DefaultIDs - 既定で取得する受注IDの件数
+	DefaultIDs = 1000
+	// DefaultRuns - 既定の取得方法ごとの実行回数
+	DefaultRuns = 20
+	// TempTableName - 一時表（グローバル一時表。ベンチマーク中に作成し、終了時に削除する）
+	TempTableName = "NPLUS1_IN_LIST_IDS"
+	// arrayTypeOwner / arrayTypeName - 配列バインドに使う組み込みのコレクション型（VARRAY OF NUMBER）
+	arrayTypeOwner = "SYS"
+	arrayTypeName  = "ODCINUMBERLIST"
+)
+
+// DefaultChunkSizes - 既定で比較するIN句のバインド数
+var DefaultChunkSizes = []int{10, 100, 1000}
+
+// statNames - 取得するセッション統計
+var statNames = []string{
+	sessionstats.ParseCountTotal,
+	sessionstats.ParseCountHard,
+	sessionstats.ParseTimeElapsed,
+	sessionstats.ParseTimeCPU,
+	sessionstats.ExecuteCount,
+	sessionstats.RoundTrips,
+	sessionstats.CPUUsed,
+}
+
+// centisecond - V$MYSTATの時間統計の単位
+const centisecond = 10 * time.Millisecond
+
+// Config - ベンチマークの設定
+type Config struct {
+	// IDs - 1回の取得で渡す受注IDの件数（Compositeでは受注ID・商品IDの組の数）
+	IDs int
+	// ChunkSizes - 比較するIN句のバインド数（1回のSQLに展開するIDの件数）
+	ChunkSizes []int
+	// Runs - 取得方法ごとの実行回数（1回目はハードパースを含むため中央値は2回目以降で求める）
+	Runs int
+	// Composite - 受注IDの代わりに受注ID・商品IDの組（複合キー）で明細を取得する
+	Composite bool
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{IDs: DefaultIDs, ChunkSizes: DefaultChunkSizes, Runs: DefaultRuns}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if c.IDs <= 0 {
+		return fmt.Errorf("ids must be positive: %d", c.IDs)
+	}
+	if c.Runs < 2 {
+		return fmt.Errorf("runs must be at least 2: %d", c.Runs)
+	}
+	if len(c.ChunkSizes) == 0 {
+		return errors.New("at least one chunk size is required")
+	}
+	for _, size := range c.ChunkSizes {
+		if size <= 0 || size > sqlutil.MaxInListSize {
+			return fmt.Errorf("chunk size must be between 1 and %d: %d", sqlutil.MaxInListSize, size)
+		}
+	}
+	return nil
+}
+
+// ParseChunkSizes - カンマ区切りのバインド数を重複を除いて昇順に並べる
+func ParseChunkSizes(s string) ([]int, error) {
+	seen := make(map[int]bool)
+	var sizes []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		size, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk size %q", part)
+		}
+		if !seen[size] {
+			seen[size] = true
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Ints(sizes)
+	return sizes, nil
+}
+
+// Result - 1つの取得方法の計測結果
+type Result struct {
+	Method string `json:"method"`
+	// ChunkSize - IN句のバインド数（MethodInList・MethodTupleIn・MethodOrChainでは1回のSQLに展開するキーの数）
+	ChunkSize int `json:"chunk_size,omitempty"`
+	// Executions - 1回の取得あたりに実行するSQLの数
+	Executions int `json:"executions"`
+	Rows       int `json:"rows"`
+	// First - 初回の実行時間（SQL文にベンチマークごとの目印を入れているためハードパースを含む）
+	First time.Duration `json:"first"`
+	// Median - 2回目以降（ソフトパースまたはカーソルキャッシュ）の実行時間の中央値
+	Median time.Duration `json:"median"`
+	// Stats - 全実行回分のセッション統計の差分（V$MYSTATを参照できない場合はnil）
+	Stats sessionstats.Stats `json:"session_stats,omitempty"`
+	// Skipped - 実行できなかった理由（権限不足など）
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Label - 表示用の手法名
+func (r Result) Label() string {
+	switch r.Method {
+	case MethodInList:
+		return fmt.Sprintf("IN(%d)", r.ChunkSize)
+	case MethodTupleIn:
+		return fmt.Sprintf("Tuple_IN(%d)", r.ChunkSize)
+	case MethodOrChain:
+		return fmt.Sprintf("OR_Chain(%d)", r.ChunkSize)
+	}
+	return r.Method
+}
+
+// PerID - 2回目以降の中央値をID1件あたりに換算した時間
+func (r Result) PerID(ids int) time.Duration {
+	if ids <= 0 {
+		return 0
+	}
+	return r.Median / time.Duration(ids)
+}
+
+// ParseTime - 全実行回分のパースの経過時間（V$MYSTATはセンチ秒単位のため短い処理では0になりやすい）
+func (r Result) ParseTime() time.Duration {
+	return time.Duration(r.Stats[sessionstats.ParseTimeElapsed]) * centisecond
+}
+
+// Report - ベンチマークの結果
+type Report struct {
+	// IDs - 取得した受注IDの件数（Compositeでは受注ID・商品IDの組の数）
+	IDs       int      `json:"ids"`
+	Composite bool     `json:"composite,omitempty"`
+	Runs      int      `json:"runs"`
+	Results   []Result `json:"results"`
+	// StatsUnavailable - V$MYSTATを参照できずパース回数・時間を取得できなかった
+	StatsUnavailable bool `json:"stats_unavailable,omitempty"`
+}
+
+// BestChunkSize - 2回目以降の中央値が最も短いIN句のバインド数（複合キーではtuple INの組の数）
+func (r *Report) BestChunkSize() (int, bool) {
+	best, found := Result{}, false
+	for _, res := range r.Results {
+		if (res.Method != MethodInList && res.Method != MethodTupleIn) || res.Skipped != "" {
+			continue
+		}
+		if !found || res.Median < best.Median {
+			best, found = res, true
+		}
+	}
+	return best.ChunkSize, found
+}
+
+// bench - 1つの接続（セッション）で各取得方法を計測する
+type bench struct {
+	db        *sql.DB
+	q         repository.DBTX
+	collector *sessionstats.Collector
+	// tag - SQL文に入れる目印（実行ごとに変え、初回の実行を必ずハードパースにする）
+	tag string
+}
+
+// Run - 受注IDをcfg.IDs件取得し、各取得方法でその明細をcfg.Runs回ずつ取得して計測する
+//
+// V$MYSTATを同じセッションで参照するため、専用の接続を1本確保して順に実行する。
+func Run(ctx context.Context, db *sql.DB, cfg Config) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			fmt.Printf("conn.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	b := &bench{
+		db:  db,
+		q:   repository.NewConnDB(conn),
+		tag: fmt.Sprintf("inlist-bench %d", time.Now().UnixNano()),
+	}
+	report := &Report{Runs: cfg.Runs, Composite: cfg.Composite}
+	if b.collector, err = sessionstats.NewCollector(b.q, statNames); err != nil {
+		report.StatsUnavailable = true
+	}
+	if cfg.Composite {
+		if err := b.runComposite(report, cfg); err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+
+	ids, err := b.loadOrderIDs(cfg.IDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no orders found")
+	}
+	report.IDs = len(ids)
+
+	for _, size := range cfg.ChunkSizes {
+		result := Result{Method: MethodInList, ChunkSize: size, Executions: (len(ids) + size - 1) / size}
+		err := b.measure(&result, cfg.Runs, func() (int, error) { return b.fetchInList(ids, size) })
+		if err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	tempTable, err := b.measureTempTable(ids, cfg.Runs)
+	if err != nil {
+		return nil, err
+	}
+	report.Results = append(report.Results, tempTable)
+
+	arrayBind, err := b.measureArrayBind(ids, cfg.Runs)
+	if err != nil {
+		return nil, err
+	}
+	report.Results = append(report.Results, arrayBind)
+
+	return report, nil
+}
+
+// loadOrderIDs - 明細の取得に使う受注IDを受注ID順に最大n件取得
+func (b *bench) loadOrderIDs(n int) (ids []int64, err error) {
+	rows, err := b.q.Query(`
+		SELECT order_id FROM (SELECT order_id FROM orders ORDER BY order_id)
+		WHERE ROWNUM <= :1`, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order ids: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan order id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// measure - fetchをruns回実行し、初回と2回目以降の中央値、セッション統計の差分を記録する
+func (b *bench) measure(result *Result, runs int, fetch func() (int, error)) error {
+	var before sessionstats.Stats
+	if b.collector != nil {
+		var err error
+		if before, err = b.collector.Snapshot(); err != nil {
+			return err
+		}
+	}
+
+	durations := make([]time.Duration, 0, runs-1)
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		rows, err := fetch()
+		elapsed := time.Since(start)
+		if err != nil {
+			return fmt.Errorf("%s: %w", result.Label(), err)
+		}
+		result.Rows = rows
+		if i == 0 {
+			result.First = elapsed
+			continue
+		}
+		durations = append(durations, elapsed)
+	}
+	result.Median = stats.MedianDuration(durations)
+
+	if b.collector != nil {
+		delta, err := b.collector.Delta(before)
+		if err != nil {
+			return err
+		}
+		result.Stats = delta
+	}
+	return nil
+}
+
+// detailColumns - 各取得方法で取得する明細の列
+const detailColumns = "od.detail_id, od.order_id, od.product_id, od.quantity, od.unit_price"
+
+// scanDetails - 明細の行を読み捨てて件数を数えるscan関数
+func scanDetails(count *int) func(rows *sql.Rows) error {
+	return func(rows *sql.Rows) error {
+		var detailID, orderID, productID, quantity int64
+		var unitPrice float64
+		if err := rows.Scan(&detailID, &orderID, &productID, &quantity, &unitPrice); err != nil {
+			return fmt.Errorf("failed to scan detail row: %w", err)
+		}
+		*count++
+		return nil
+	}
+}
+
+// fetchInList - IDをsizeずつIN句に展開して明細を取得
+func (b *bench) fetchInList(ids []int64, size int) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT /* %s */ %s
+		FROM order_details od
+		WHERE od.order_id IN (?)`, b.tag, detailColumns)
+
+	count := 0
+	err := sqlutil.QueryIn(b.q, query, size, scanDetails(&count), ids)
+	return count, err
+}
+
+// measureTempTable - 一時表を用意して計測し、作成した一時表を削除する（作成できない場合はSkipped）
+func (b *bench) measureTempTable(ids []int64, runs int) (Result, error) {
+	result := Result{Method: MethodTempTable, Executions: 3}
+
+	created, err := b.createTempTable(TempTableName, "id NUMBER(10) PRIMARY KEY")
+	if err != nil {
+		result.Skipped = err.Error()
+		return result, nil
+	}
+	if created {
+		defer b.dropTempTable(TempTableName)
+	}
+
+	err = b.measure(&result, runs, func() (int, error) { return b.fetchTempTable(ids) })
+	return result, err
+}
+
+// createTempTable - 列の定義columnsで一時表nameがなければ作成する（作成した場合はtrue）
+//
+// 自動コミットでINSERTした行を結合で参照するため、ON COMMIT PRESERVE ROWS とする。
+func (b *bench) createTempTable(name, columns string) (bool, error) {
+	var count int
+	if err := b.q.QueryRow("SELECT COUNT(*) FROM user_tables WHERE table_name = :1", name).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to query user_tables: %w", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if _, err := b.q.Exec("CREATE GLOBAL TEMPORARY TABLE " + name +
+		" (" + columns + ") ON COMMIT PRESERVE ROWS"); err != nil {
+		return false, fmt.Errorf("failed to create temporary table %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// dropTempTable - 一時表を削除する（このセッションの行が残っていると削除できないため先にTRUNCATEする）
+func (b *bench) dropTempTable(name string) {
+	if _, err := b.q.Exec("TRUNCATE TABLE " + name); err != nil {
+		fmt.Printf("failed to truncate %s: %v\n", name, err)
+	}
+	if _, err := b.q.Exec("DROP TABLE " + name + " PURGE"); err != nil {
+		fmt.Printf("failed to drop %s: %v\n", name, err)
+	}
+}
+
+// fetchTempTable - 一時表を空にしてIDを配列バインドでINSERTし、結合で明細を取得
+func (b *bench) fetchTempTable(ids []int64) (count int, err error) {
+	if _, err := b.q.Exec(fmt.Sprintf("DELETE /* %s */ FROM %s", b.tag, TempTableName)); err != nil {
+		return 0, fmt.Errorf("failed to delete from temporary table: %w", err)
+	}
+	// go-oraはスライスを引数に渡すと配列バインド（1ラウンドトリップ）で実行する
+	if _, err := b.q.Exec(fmt.Sprintf("INSERT /* %s */ INTO %s (id) VALUES (:1)", b.tag, TempTableName), ids); err != nil {
+		return 0, fmt.Errorf("failed to insert into temporary table: %w", err)
+	}
+
+	rows, err := b.q.Query(fmt.Sprintf(`
+		SELECT /* %s */ %s
+		FROM order_details od
+		JOIN %s t ON od.order_id = t.id`, b.tag, detailColumns, TempTableName))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query temporary table join: %w", err)
+	}
+	return scanAll(rows)
+}
+
+// measureArrayBind - SYS.ODCINUMBERLISTをgo-oraに登録して計測する（登録できない場合はSkipped）
+func (b *bench) measureArrayBind(ids []int64, runs int) (Result, error) {
+	result := Result{Method: MethodArrayBind, Executions: 1}
+	if err := go_ora.RegisterTypeWithOwner(b.db, arrayTypeOwner, "NUMBER", arrayTypeName, nil); err != nil {
+		result.Skipped = fmt.Sprintf("failed to register %s.%s: %v", arrayTypeOwner, arrayTypeName, err)
+		return result, nil
+	}
+
+	err := b.measure(&result, runs, func() (int, error) { return b.fetchArrayBind(ids) })
+	return result, err
+}
+
+// fetchArrayBind - IDをコレクション1つとしてバインドし、TABLE()で展開して明細を取得
+func (b *bench) fetchArrayBind(ids []int64) (int, error) {
+	rows, err := b.q.Query(fmt.Sprintf(`
+		SELECT /* %s */ %s
+		FROM order_details od
+		WHERE od.order_id IN (SELECT column_value FROM TABLE(:1))`, b.tag, detailColumns),
+		go_ora.Object{Owner: arrayTypeOwner, Name: arrayTypeName, Value: ids})
+	if err != nil {
+		return 0, fmt.Errorf("failed to query with array bind: %w", err)
+	}
+	return scanAll(rows)
+}
+
+// scanAll - 明細の行をすべて読み、件数を返す
+func scanAll(rows *sql.Rows) (count int, err error) {
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	scan := scanDetails(&count)
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return 0, err
+		}
+	}
+	return count, rows.Err()
+}
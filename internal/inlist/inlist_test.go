@@ -0,0 +1,68 @@
+package inlist
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseChunkSizes(t *testing.T) {
+	got, err := ParseChunkSizes("1000, 10,100,,10")
+	if err != nil {
+		t.Fatalf("ParseChunkSizes() error = %v", err)
+	}
+	if want := []int{10, 100, 1000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseChunkSizes() = %v, want %v", got, want)
+	}
+
+	if _, err := ParseChunkSizes("10,abc"); err == nil {
+		t.Error("ParseChunkSizes() with non-number should fail")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "default", cfg: DefaultConfig()},
+		{name: "no ids", cfg: Config{IDs: 0, ChunkSizes: []int{10}, Runs: 5}, wantErr: true},
+		{name: "single run", cfg: Config{IDs: 10, ChunkSizes: []int{10}, Runs: 1}, wantErr: true},
+		{name: "no chunk sizes", cfg: Config{IDs: 10, Runs: 5}, wantErr: true},
+		{name: "chunk over limit", cfg: Config{IDs: 10, ChunkSizes: []int{1001}, Runs: 5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBestChunkSize(t *testing.T) {
+	report := &Report{Results: []Result{
+		{Method: MethodInList, ChunkSize: 10, Median: 30 * time.Millisecond},
+		{Method: MethodInList, ChunkSize: 100, Median: 8 * time.Millisecond},
+		{Method: MethodInList, ChunkSize: 1000, Median: 9 * time.Millisecond},
+		{Method: MethodArrayBind, Median: time.Millisecond},
+		{Method: MethodTempTable, Skipped: "ORA-01031"},
+	}}
+	if got, ok := report.BestChunkSize(); !ok || got != 100 {
+		t.Errorf("BestChunkSize() = %d, %v, want 100, true", got, ok)
+	}
+
+	composite := &Report{Composite: true, Results: []Result{
+		{Method: MethodPerKey, Median: 90 * time.Millisecond},
+		{Method: MethodTupleIn, ChunkSize: 10, Median: 12 * time.Millisecond},
+		{Method: MethodTupleIn, ChunkSize: 100, Median: 7 * time.Millisecond},
+		{Method: MethodOrChain, ChunkSize: 10, Median: 5 * time.Millisecond},
+	}}
+	// OR_Chainは比較しない（チャンクサイズの推奨はtuple INの結果から選ぶ）
+	if got, ok := composite.BestChunkSize(); !ok || got != 100 {
+		t.Errorf("composite BestChunkSize() = %d, %v, want 100, true", got, ok)
+	}
+
+	if _, ok := (&Report{}).BestChunkSize(); ok {
+		t.Error("BestChunkSize() of empty report should not be found")
+	}
+}
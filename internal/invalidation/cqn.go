@@ -0,0 +1,172 @@
+package invalidation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/cache"
+)
+
+const (
+	// CallbackProcedure - CQNの通知を受け取り、DBMS_ALERTで待っているセッションに伝えるプロシージャ（計測中だけ作成する）
+	CallbackProcedure = "NPLUS1_CQN_CALLBACK"
+	// AlertName - 通知を伝えるDBMS_ALERTのアラート名
+	AlertName = "NPLUS1_CQN"
+	// alertWait - DBMS_ALERT.WAITONEの1回の待機時間（秒。停止の確認の間隔）
+	alertWait = 1
+	// alertTimeout - DBMS_ALERT.WAITONEがタイムアウトしたときのstatus
+	alertTimeout = 1
+)
+
+// createCallbackSQL - 通知の種類をメッセージにしてアラートを送るコールバック（ジョブとして実行されるためCOMMITで送信する）
+const createCallbackSQL = `CREATE OR REPLACE PROCEDURE ` + CallbackProcedure + `(ntfnds IN CQ_NOTIFICATION$_DESCRIPTOR) AS
+BEGIN
+  DBMS_ALERT.SIGNAL('` + AlertName + `', TO_CHAR(ntfnds.event_type));
+  COMMIT;
+END;`
+
+// registerSQL - 顧客の受注と明細を返すクエリをクエリ単位（QOS_QUERY）で登録し、登録IDを返す
+const registerSQL = `DECLARE
+  reginfo CQ_NOTIFICATION$_REG_INFO;
+  regid   NUMBER;
+  c       SYS_REFCURSOR;
+BEGIN
+  reginfo := CQ_NOTIFICATION$_REG_INFO('` + CallbackProcedure + `', DBMS_CQ_NOTIFICATION.QOS_QUERY, 0, 0, 0);
+  regid := DBMS_CQ_NOTIFICATION.NEW_REG_START(reginfo);
+  OPEN c FOR SELECT o.order_id, o.total_amount FROM orders o WHERE o.customer_id = :1;
+  CLOSE c;
+  OPEN c FOR SELECT d.detail_id, d.product_id, d.quantity FROM order_details d JOIN orders o ON o.order_id = d.order_id WHERE o.customer_id = :2;
+  CLOSE c;
+  DBMS_CQ_NOTIFICATION.REG_END;
+  :3 := regid;
+END;`
+
+// CQNReport - Continuous Query Notificationによるローカルキャッシュの無効化の結果
+type CQNReport struct {
+	CustomerID   int64         `json:"customer_id"`
+	Writes       int           `json:"writes"`
+	Interval     time.Duration `json:"interval"`
+	PollInterval time.Duration `json:"poll_interval"`
+	// RegistrationID - 計測中に使ったCQNの登録ID（終了時に登録を解除する）
+	RegistrationID int64  `json:"registration_id"`
+	CQN            Method `json:"cqn"`
+}
+
+// RunCQN - 顧客の受注と明細のクエリをCQNに登録し、コミットのたびにOracleが送る通知でローカルキャッシュを無効化する
+//
+// 通知はコールバックのプロシージャからDBMS_ALERTで、専用の接続で待っているこのプロセスに伝える。
+// CHANGE NOTIFICATION権限、DBMS_CQ_NOTIFICATION・DBMS_ALERTのEXECUTE権限と、JOB_QUEUE_PROCESSES > 0 が必要。
+// 書き込みは受注の金額を実際に更新してコミットするため、終了時に元に戻す。
+func RunCQN(ctx context.Context, db *sql.DB, cfg Config) (report *CQNReport, err error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	customerID, err := demoCustomer(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	report = &CQNReport{CustomerID: customerID, Writes: cfg.Writes, Interval: cfg.Interval, PollInterval: cfg.PollInterval}
+
+	n := &cqnNotifier{db: db, customerID: customerID}
+	method, written, err := runNotified(ctx, db, customerID, cfg, "Oracle_CQN_Invalidation", n)
+	if written > 0 {
+		if rerr := cache.AdjustLatestOrder(db, customerID, -float64(written)); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.RegistrationID = n.regID
+	report.CQN = *method
+	return report, nil
+}
+
+// cqnNotifier - Oracleがコミット時に送るCQNの通知を、DBMS_ALERTで受け取る
+type cqnNotifier struct {
+	db         *sql.DB
+	customerID int64
+	regID      int64
+}
+
+// subscribe - コールバックを作成してクエリを登録し、アラートを待つ接続を開く
+func (n *cqnNotifier) subscribe(ctx context.Context, received func(i int)) (func() error, error) {
+	if _, err := n.db.ExecContext(ctx, createCallbackSQL); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", CallbackProcedure, err)
+	}
+	conn, err := n.db.Conn(ctx)
+	if err != nil {
+		_ = n.dropCallback()
+		return nil, fmt.Errorf("failed to open alert connection: %w", err)
+	}
+	cleanup := func() error {
+		var errs []error
+		if _, err := conn.ExecContext(context.Background(), `BEGIN DBMS_ALERT.REMOVE(:1); END;`, AlertName); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove alert: %w", err))
+		}
+		if n.regID != 0 {
+			if _, err := n.db.ExecContext(context.Background(), `BEGIN DBMS_CQ_NOTIFICATION.DEREGISTER(:1); END;`, n.regID); err != nil {
+				errs = append(errs, fmt.Errorf("failed to deregister %d: %w", n.regID, err))
+			}
+		}
+		if err := n.dropCallback(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close alert connection: %w", err))
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to clean up change notification: %v", errs)
+		}
+		return nil
+	}
+
+	if _, err := conn.ExecContext(ctx, `BEGIN DBMS_ALERT.REGISTER(:1); END;`, AlertName); err != nil {
+		_ = cleanup()
+		return nil, fmt.Errorf("failed to register alert: %w", err)
+	}
+	if _, err := n.db.ExecContext(ctx, registerSQL, n.customerID, n.customerID, sql.Out{Dest: &n.regID}); err != nil {
+		_ = cleanup()
+		return nil, fmt.Errorf("failed to register change notification: %w", err)
+	}
+
+	waitCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+		for waitCtx.Err() == nil {
+			var status int
+			// 待機中に取り消すと接続が使えなくなるため、取り消しはalertWaitごとに確認する
+			if _, err := conn.ExecContext(context.Background(), `DECLARE msg VARCHAR2(1800); BEGIN DBMS_ALERT.WAITONE(:1, msg, :2, :3); END;`,
+				AlertName, sql.Out{Dest: &status}, alertWait); err != nil {
+				done <- fmt.Errorf("failed to wait for alert: %w", err)
+				return
+			}
+			if status != alertTimeout {
+				received(allStarted)
+			}
+		}
+	}()
+
+	return func() error {
+		cancel()
+		waitErr := <-done
+		if err := cleanup(); err != nil {
+			return err
+		}
+		return waitErr
+	}, nil
+}
+
+// notify - コミットするとOracleが通知するため何もしない
+func (n *cqnNotifier) notify(context.Context, int) error { return nil }
+
+// dropCallback - コールバックのプロシージャを削除
+func (n *cqnNotifier) dropCallback() error {
+	if _, err := n.db.Exec(`DROP PROCEDURE ` + CallbackProcedure); err != nil {
+		return fmt.Errorf("failed to drop %s: %w", CallbackProcedure, err)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+// Package invalidation - 更新をほかのプロセスのローカルキャッシュに伝えて無効化する仕組みの伝播遅延と、古い値を返す期間を計測する
+//
+// 書き込み側が受注を更新してコミットし、購読側は通知（Redis Pub/Sub、Continuous Query Notification）を受け取ると
+// ローカルキャッシュから削除し、読み取り側はローカルキャッシュ（なければDB）から読み続ける。コミットから無効化までの時間（伝播遅延）と、
+// 読み取り側が古い値を返し続けた期間を計測する。
+package invalidation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/cache"
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+const (
+	// DefaultWrites - 既定の書き込み回数
+	DefaultWrites = 20
+	// DefaultInterval - 既定の書き込みの間隔
+	DefaultInterval = 200 * time.Millisecond
+	// DefaultPollInterval - 既定の読み取り側の読み取りの間隔
+	DefaultPollInterval = time.Millisecond
+	// drainTimeout - 最後の書き込みの後、通知と新しい値の読み取りを待つ時間
+	drainTimeout = 2 * time.Second
+)
+
+// Config - 計測の設定
+type Config struct {
+	// Writes - 書き込み（更新・コミット・通知）の回数
+	Writes int
+	// Interval - 書き込みの間隔
+	Interval time.Duration
+	// PollInterval - 読み取り側がローカルキャッシュを読む間隔
+	PollInterval time.Duration
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{Writes: DefaultWrites, Interval: DefaultInterval, PollInterval: DefaultPollInterval}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if c.Writes <= 0 {
+		return fmt.Errorf("writes must be positive: %d", c.Writes)
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be positive: %v", c.Interval)
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("poll interval must be positive: %v", c.PollInterval)
+	}
+	return nil
+}
+
+// Delays - 書き込みごとの遅延の要約
+type Delays struct {
+	Median time.Duration   `json:"median"`
+	P95    time.Duration   `json:"p95"`
+	Max    time.Duration   `json:"max"`
+	Values []time.Duration `json:"values"`
+}
+
+// summarize - 遅延の中央値・95パーセンタイル・最大
+func summarize(values []time.Duration) Delays {
+	d := Delays{Values: values}
+	if len(values) == 0 {
+		return d
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	d.Median = stats.MedianDuration(sorted)
+	d.P95 = sorted[(len(sorted)*95+99)/100-1]
+	d.Max = sorted[len(sorted)-1]
+	return d
+}
+
+// Method - 無効化の方法ごとの結果
+type Method struct {
+	Name string `json:"name"`
+	// Propagation - コミットからローカルキャッシュの削除までの時間（Result Cacheでは計測しない）
+	Propagation *Delays `json:"propagation,omitempty"`
+	// StaleWindow - コミットから、読み取り側が新しい値を初めて返すまでの時間
+	StaleWindow Delays `json:"stale_window"`
+	// Reads / StaleReads - 読み取りの回数と、コミット済みの値より古い値を返した回数
+	Reads      int `json:"reads"`
+	StaleReads int `json:"stale_reads"`
+	// Missed - 計測の終わりまで新しい値を返さなかった書き込みの数
+	Missed int `json:"missed,omitempty"`
+}
+
+// read - 読み取り側の1回の読み取り（開始時刻と返した金額）
+type read struct {
+	start time.Time
+	end   time.Time
+	total float64
+}
+
+// staleness - 書き込みごとのコミット時刻と読み取りから、古い値の読み取りと、新しい値を返すまでの時間を求める
+//
+// base は最初の書き込みの前の金額で、i番目の書き込みは金額を1増やす。読み取りを始めた時点でコミット済みの書き込みを
+// 反映していない値を古い値とする。i番目の書き込みの期間は、コミットから、その書き込み以降の値を初めて返した読み取りの終了までとする。
+func staleness(base float64, commits []time.Time, reads []read) (windows []time.Duration, stale, missed int) {
+	for _, r := range reads {
+		committed := sort.Search(len(commits), func(i int) bool { return commits[i].After(r.start) })
+		if r.total < base+float64(committed) {
+			stale++
+		}
+	}
+	for i, committed := range commits {
+		found := false
+		for _, r := range reads {
+			if !r.end.Before(committed) && r.total >= base+float64(i+1) {
+				windows = append(windows, r.end.Sub(committed))
+				found = true
+				break
+			}
+		}
+		if !found {
+			missed++
+		}
+	}
+	return windows, stale, missed
+}
+
+// localCache - 購読側のローカルキャッシュ（1人の顧客の値）
+type localCache struct {
+	mu    sync.Mutex
+	value cache.MixValue
+	ok    bool
+}
+
+func (c *localCache) get() (cache.MixValue, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value, c.ok
+}
+
+func (c *localCache) set(v cache.MixValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value, c.ok = v, true
+}
+
+func (c *localCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ok = false
+}
+
+// allStarted - notifierが書き込みを特定できない通知（始めたすべての書き込みを無効化済みとする）
+const allStarted = -1
+
+// notifier - 書き込みを購読側に伝える仕組み
+type notifier interface {
+	// subscribe - 通知の受信を始め、準備ができてから返る。通知を受け取るたびに、i番目までの書き込み（allStartedではすべて）についてreceivedを呼ぶ
+	subscribe(ctx context.Context, received func(i int)) (stop func() error, err error)
+	// notify - コミットしたi番目の書き込みを通知する（DBが通知する場合は何もしない）
+	notify(ctx context.Context, i int) error
+}
+
+// runNotified - 書き込み側が更新・コミットし、notifierの通知で購読側がローカルキャッシュから削除する（書き込んだ回数を返す）
+func runNotified(ctx context.Context, db *sql.DB, customerID int64, cfg Config, name string, n notifier) (_ *Method, written int, err error) {
+	base, err := cache.QueryCustomerTotals(db, customerID, "/*+ NO_RESULT_CACHE */")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	local := &localCache{}
+	var mu sync.Mutex
+	commits := make([]time.Time, cfg.Writes)
+	invalidated := make([]time.Time, cfg.Writes)
+	started := 0
+	remaining := cfg.Writes
+	allInvalidated := make(chan struct{})
+
+	// 購読側: 通知を受け取ったらローカルキャッシュから削除する
+	stopSubscription, err := n.subscribe(ctx, func(i int) {
+		local.invalidate()
+		now := time.Now()
+		mu.Lock()
+		defer mu.Unlock()
+		upTo := started - 1
+		if i != allStarted {
+			upTo = min(i, upTo)
+		}
+		for j := 0; j <= upTo; j++ {
+			if invalidated[j].IsZero() {
+				invalidated[j] = now
+				if remaining--; remaining == 0 {
+					close(allInvalidated)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if serr := stopSubscription(); serr != nil && err == nil {
+			err = serr
+		}
+	}()
+
+	// 読み取り側: ローカルキャッシュになければDBから読んで保存する
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	var reads []read
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(readErr)
+		for runCtx.Err() == nil {
+			start := time.Now()
+			value, ok := local.get()
+			if !ok {
+				var err error
+				if value, err = cache.QueryCustomerTotals(db, customerID, "/*+ NO_RESULT_CACHE */"); err != nil {
+					readErr <- err
+					return
+				}
+				local.set(value)
+			}
+			reads = append(reads, read{start: start, end: time.Now(), total: value.TotalAmount})
+			time.Sleep(cfg.PollInterval)
+		}
+	}()
+
+	// 書き込み側: 更新してコミットしてから通知する
+	for i := range cfg.Writes {
+		time.Sleep(cfg.Interval)
+		mu.Lock()
+		started++
+		mu.Unlock()
+		if err := cache.AdjustLatestOrder(db, customerID, 1); err != nil {
+			return nil, written, err
+		}
+		written++
+		mu.Lock()
+		commits[i] = time.Now()
+		mu.Unlock()
+		if err := n.notify(ctx, i); err != nil {
+			return nil, written, err
+		}
+	}
+
+	// すべての通知が届くのを待ち、最後の書き込みの新しい値を読み取る時間を置く
+	select {
+	case <-allInvalidated:
+	case <-time.After(drainTimeout):
+	}
+	time.Sleep(cfg.Interval)
+	stop()
+	if err := <-readErr; err != nil {
+		return nil, written, err
+	}
+
+	method := &Method{Name: name, Reads: len(reads)}
+	var propagation []time.Duration
+	mu.Lock()
+	defer mu.Unlock()
+	for i, at := range invalidated {
+		if !at.IsZero() {
+			// 通知がコミットの時刻を記録する前に届いた場合は0とする
+			propagation = append(propagation, max(at.Sub(commits[i]), 0))
+		}
+	}
+	delays := summarize(propagation)
+	method.Propagation = &delays
+	windows, stale, missed := staleness(base.TotalAmount, commits, reads)
+	method.StaleWindow = summarize(windows)
+	method.StaleReads = stale
+	method.Missed = missed
+	return method, written, nil
+}
+
+// demoCustomer - 計測に使う顧客（受注のある顧客のうちIDが最小のもの）
+func demoCustomer(ctx context.Context, db *sql.DB) (int64, error) {
+	var customerID sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT MIN(customer_id) FROM orders").Scan(&customerID); err != nil {
+		return 0, fmt.Errorf("failed to query customer: %w", err)
+	}
+	if !customerID.Valid {
+		return 0, errors.New("no customers with orders")
+	}
+	return customerID.Int64, nil
+}
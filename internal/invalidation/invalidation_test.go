@@ -0,0 +1,91 @@
+package invalidation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleness(t *testing.T) {
+	t0 := time.Unix(0, 0)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	commits := []time.Time{at(10), at(20)}
+
+	tests := []struct {
+		name        string
+		reads       []read
+		wantWindows []time.Duration
+		wantStale   int
+		wantMissed  int
+	}{
+		{
+			name: "通知の後に新しい値を読む",
+			reads: []read{
+				{start: at(5), end: at(6), total: 100},
+				{start: at(11), end: at(12), total: 100}, // コミット後の古い値
+				{start: at(13), end: at(15), total: 101},
+				{start: at(21), end: at(24), total: 102},
+			},
+			wantWindows: []time.Duration{5 * time.Millisecond, 4 * time.Millisecond},
+			wantStale:   1,
+		},
+		{
+			name: "最後の書き込みを反映しないまま終わる",
+			reads: []read{
+				{start: at(11), end: at(12), total: 101},
+				{start: at(25), end: at(26), total: 101},
+			},
+			wantWindows: []time.Duration{2 * time.Millisecond},
+			wantStale:   1,
+			wantMissed:  1,
+		},
+	}
+	for _, tt := range tests {
+		windows, stale, missed := staleness(100, commits, tt.reads)
+		if len(windows) != len(tt.wantWindows) {
+			t.Fatalf("%s: windows = %v, want %v", tt.name, windows, tt.wantWindows)
+		}
+		for i := range windows {
+			if windows[i] != tt.wantWindows[i] {
+				t.Errorf("%s: windows[%d] = %v, want %v", tt.name, i, windows[i], tt.wantWindows[i])
+			}
+		}
+		if stale != tt.wantStale || missed != tt.wantMissed {
+			t.Errorf("%s: stale, missed = %d, %d, want %d, %d", tt.name, stale, missed, tt.wantStale, tt.wantMissed)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var values []time.Duration
+	for i := 20; i >= 1; i-- {
+		values = append(values, time.Duration(i)*time.Millisecond)
+	}
+	d := summarize(values)
+	if d.Max != 20*time.Millisecond || d.P95 != 19*time.Millisecond {
+		t.Errorf("summarize() max, p95 = %v, %v, want 20ms, 19ms", d.Max, d.P95)
+	}
+	if d.Values[0] != 20*time.Millisecond {
+		t.Errorf("summarize() reordered values: %v", d.Values)
+	}
+	if empty := summarize(nil); empty.Median != 0 {
+		t.Errorf("summarize(nil) median = %v, want 0", empty.Median)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"既定", DefaultConfig(), false},
+		{"書き込みなし", Config{Writes: 0, Interval: time.Millisecond, PollInterval: time.Millisecond}, true},
+		{"間隔なし", Config{Writes: 1, Interval: 0, PollInterval: time.Millisecond}, true},
+		{"読み取り間隔なし", Config{Writes: 1, Interval: time.Millisecond, PollInterval: 0}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
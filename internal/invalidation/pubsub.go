@@ -0,0 +1,158 @@
+package invalidation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"oracle-n-plus-1-demo/internal/cache"
+)
+
+const (
+	// Channel - 無効化を通知するRedis Pub/Subのチャネル
+	Channel = "nplus1:invalidate:orders"
+	// subscribeTimeout - 購読の開始を待つ時間
+	subscribeTimeout = 5 * time.Second
+)
+
+// Report - Pub/Subによる無効化とResult Cacheの同期的な無効化の比較
+type Report struct {
+	CustomerID   int64         `json:"customer_id"`
+	Writes       int           `json:"writes"`
+	Interval     time.Duration `json:"interval"`
+	PollInterval time.Duration `json:"poll_interval"`
+	PubSub       Method        `json:"pubsub"`
+	ResultCache  Method        `json:"result_cache"`
+}
+
+// Run - 書き込み側・購読側・読み取り側を並行して動かし、Redis Pub/Subによる無効化とResult Cacheを計測する
+//
+// 書き込みは受注の金額を実際に更新してコミットするため、終了時に元に戻す。
+func Run(ctx context.Context, db *sql.DB, client *redis.Client, cfg Config) (report *Report, err error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	customerID, err := demoCustomer(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	report = &Report{CustomerID: customerID, Writes: cfg.Writes, Interval: cfg.Interval, PollInterval: cfg.PollInterval}
+
+	var written float64
+	defer func() {
+		if written == 0 {
+			return
+		}
+		if rerr := cache.AdjustLatestOrder(db, customerID, -written); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	pubsub, n, err := runNotified(ctx, db, customerID, cfg, "Redis_PubSub_Invalidation", &redisNotifier{client: client})
+	written += float64(n)
+	if err != nil {
+		return nil, err
+	}
+	report.PubSub = *pubsub
+
+	resultCache, n, err := runResultCache(ctx, db, customerID, cfg)
+	written += float64(n)
+	if err != nil {
+		return nil, err
+	}
+	report.ResultCache = *resultCache
+	return report, nil
+}
+
+// redisNotifier - 書き込み側が書き込みの番号をPUBLISHし、購読側がSUBSCRIBEで受け取る
+type redisNotifier struct {
+	client *redis.Client
+}
+
+// subscribe - チャネルを購読し、届いた番号までの書き込みについてreceivedを呼ぶ
+func (n *redisNotifier) subscribe(ctx context.Context, received func(i int)) (func() error, error) {
+	sub := n.client.Subscribe(ctx, Channel)
+	subscribeCtx, cancel := context.WithTimeout(ctx, subscribeTimeout)
+	defer cancel()
+	if _, err := sub.Receive(subscribeCtx); err != nil {
+		if cerr := sub.Close(); cerr != nil {
+			fmt.Printf("failed to close subscription: %v\n", cerr)
+		}
+		return nil, fmt.Errorf("failed to subscribe %s: %w", Channel, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range sub.Channel() {
+			if i, err := strconv.Atoi(msg.Payload); err == nil {
+				received(i)
+			}
+		}
+	}()
+	return func() error {
+		err := sub.Close()
+		<-done
+		if err != nil {
+			return fmt.Errorf("failed to close subscription: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// notify - 書き込みの番号をPUBLISH
+func (n *redisNotifier) notify(ctx context.Context, i int) error {
+	if err := n.client.Publish(ctx, Channel, strconv.Itoa(i)).Err(); err != nil {
+		return fmt.Errorf("failed to publish invalidation: %w", err)
+	}
+	return nil
+}
+
+// runResultCache - 書き込みごとに、RESULT_CACHEヒント付きの読み取りを温めてから更新・コミットし、直後に読み取る（書き込んだ回数を返す）
+func runResultCache(ctx context.Context, db *sql.DB, customerID int64, cfg Config) (*Method, int, error) {
+	base, err := cache.QueryCustomerTotals(db, customerID, "/*+ NO_RESULT_CACHE */")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var commits []time.Time
+	var reads []read
+	written := 0
+	for range cfg.Writes {
+		if err := ctx.Err(); err != nil {
+			return nil, written, err
+		}
+		for range 2 {
+			start := time.Now()
+			value, err := cache.QueryCustomerTotals(db, customerID, "/*+ RESULT_CACHE */")
+			if err != nil {
+				return nil, written, err
+			}
+			reads = append(reads, read{start: start, end: time.Now(), total: value.TotalAmount})
+		}
+		time.Sleep(cfg.Interval)
+		if err := cache.AdjustLatestOrder(db, customerID, 1); err != nil {
+			return nil, written, err
+		}
+		written++
+		commits = append(commits, time.Now())
+
+		start := time.Now()
+		value, err := cache.QueryCustomerTotals(db, customerID, "/*+ RESULT_CACHE */")
+		if err != nil {
+			return nil, written, err
+		}
+		reads = append(reads, read{start: start, end: time.Now(), total: value.TotalAmount})
+	}
+
+	method := &Method{Name: "Oracle_Result_Cache", Reads: len(reads)}
+	windows, stale, missed := staleness(base.TotalAmount, commits, reads)
+	method.StaleWindow = summarize(windows)
+	method.StaleReads = stale
+	method.Missed = missed
+	return method, written, nil
+}
@@ -0,0 +1,135 @@
+// Package lesson - 研修向けウォークスルー（-walkthrough）の教材カタログ
+//
+// 説明文・実行するSQL・予想される動きはコードに埋め込まず catalog.json にまとめ、
+// 各ステップはサービス側の手法（Action）を名前で参照する。
+package lesson
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//go:embed catalog.json
+var catalogJSON []byte
+
+// Catalog - 教材の一覧
+type Catalog struct {
+	Lessons []Lesson `json:"lessons"`
+}
+
+// Lesson - ひとまとまりの教材（ステップを順に実行する）
+type Lesson struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+	Steps   []Step `json:"steps"`
+}
+
+// Step - 1つのステップ（説明 → SQL → 予想 → 実行 → 観測結果）
+type Step struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Narration string `json:"narration"`
+	// SQL - 実行するSQLの要点（表示用。実際のSQLはActionの手法が発行する）
+	SQL []string `json:"sql"`
+	// Expected - 予想される動き（実行回数・ラウンドトリップなど）
+	Expected string `json:"expected"`
+	// Action - 実行する手法の名前（service.LessonActions のいずれか）
+	Action string `json:"action"`
+	// Takeaway - 観測結果を見た後に伝えるまとめ（省略可）
+	Takeaway string `json:"takeaway,omitempty"`
+}
+
+// ErrLessonNotFound - 指定したIDの教材がない
+var ErrLessonNotFound = errors.New("lesson not found")
+
+// Load - 埋め込みの教材カタログを読み込む
+func Load() (Catalog, error) {
+	return Parse(catalogJSON)
+}
+
+// Parse - 教材カタログ（JSON）を解析して構造を確認する
+func Parse(data []byte) (Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return Catalog{}, fmt.Errorf("failed to parse lesson catalog: %w", err)
+	}
+	if err := catalog.Validate(); err != nil {
+		return Catalog{}, err
+	}
+	return catalog, nil
+}
+
+// Validate - IDの重複や必須項目の欠落がないか確認
+func (c Catalog) Validate() error {
+	if len(c.Lessons) == 0 {
+		return errors.New("lesson catalog has no lessons")
+	}
+	lessons := make(map[string]bool)
+	for _, l := range c.Lessons {
+		if l.ID == "" || l.Title == "" {
+			return fmt.Errorf("lesson %q must have id and title", l.ID)
+		}
+		if lessons[l.ID] {
+			return fmt.Errorf("duplicate lesson id: %s", l.ID)
+		}
+		lessons[l.ID] = true
+		if len(l.Steps) == 0 {
+			return fmt.Errorf("lesson %s has no steps", l.ID)
+		}
+
+		steps := make(map[string]bool)
+		for _, st := range l.Steps {
+			if st.ID == "" || st.Title == "" || st.Action == "" {
+				return fmt.Errorf("step %q in lesson %s must have id, title and action", st.ID, l.ID)
+			}
+			if steps[st.ID] {
+				return fmt.Errorf("duplicate step id in lesson %s: %s", l.ID, st.ID)
+			}
+			steps[st.ID] = true
+		}
+	}
+	return nil
+}
+
+// CheckActions - すべてのステップのActionが実行可能な手法か確認
+func (c Catalog) CheckActions(known []string) error {
+	available := make(map[string]bool, len(known))
+	for _, name := range known {
+		available[name] = true
+	}
+	var unknown []string
+	for _, l := range c.Lessons {
+		for _, st := range l.Steps {
+			if !available[st.Action] {
+				unknown = append(unknown, l.ID+"/"+st.ID+": "+st.Action)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown lesson actions: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Find - IDで教材を探す
+func (c Catalog) Find(id string) (Lesson, error) {
+	for _, l := range c.Lessons {
+		if l.ID == id {
+			return l, nil
+		}
+	}
+	return Lesson{}, fmt.Errorf("%w: %s (available: %s)", ErrLessonNotFound, id, strings.Join(c.IDs(), ", "))
+}
+
+// IDs - 教材のID一覧（カタログの順）
+func (c Catalog) IDs() []string {
+	ids := make([]string, len(c.Lessons))
+	for i, l := range c.Lessons {
+		ids[i] = l.ID
+	}
+	return ids
+}
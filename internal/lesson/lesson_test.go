@@ -0,0 +1,70 @@
+package lesson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	catalog, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, l := range catalog.Lessons {
+		for _, st := range l.Steps {
+			if len(st.SQL) == 0 || st.Expected == "" {
+				t.Errorf("step %s/%s has no SQL or expected behavior", l.ID, st.ID)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{name: "valid", json: `{"lessons":[{"id":"a","title":"A","steps":[{"id":"s","title":"S","action":"x"}]}]}`},
+		{name: "no lessons", json: `{"lessons":[]}`, wantErr: "no lessons"},
+		{name: "no steps", json: `{"lessons":[{"id":"a","title":"A"}]}`, wantErr: "no steps"},
+		{name: "duplicate lesson", json: `{"lessons":[{"id":"a","title":"A","steps":[{"id":"s","title":"S","action":"x"}]},{"id":"a","title":"B","steps":[{"id":"s","title":"S","action":"x"}]}]}`, wantErr: "duplicate lesson id"},
+		{name: "duplicate step", json: `{"lessons":[{"id":"a","title":"A","steps":[{"id":"s","title":"S","action":"x"},{"id":"s","title":"T","action":"y"}]}]}`, wantErr: "duplicate step id"},
+		{name: "missing action", json: `{"lessons":[{"id":"a","title":"A","steps":[{"id":"s","title":"S"}]}]}`, wantErr: "must have id, title and action"},
+		{name: "malformed", json: `{"lessons":`, wantErr: "failed to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.json))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCatalogFindAndCheckActions(t *testing.T) {
+	catalog, err := Parse([]byte(`{"lessons":[{"id":"a","title":"A","steps":[{"id":"s","title":"S","action":"x"},{"id":"t","title":"T","action":"y"}]}]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := catalog.Find("a"); err != nil {
+		t.Errorf("Find(a) error = %v", err)
+	}
+	if _, err := catalog.Find("b"); !errors.Is(err, ErrLessonNotFound) {
+		t.Errorf("Find(b) error = %v, want %v", err, ErrLessonNotFound)
+	}
+	if err := catalog.CheckActions([]string{"x", "y"}); err != nil {
+		t.Errorf("CheckActions() error = %v", err)
+	}
+	if err := catalog.CheckActions([]string{"x"}); err == nil || !strings.Contains(err.Error(), "a/t: y") {
+		t.Errorf("CheckActions() error = %v, want unknown a/t: y", err)
+	}
+}
@@ -0,0 +1,274 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config - 負荷テストの設定
+type Config struct {
+	// BaseURL - APIのベースURL（例: http://localhost:8080）
+	BaseURL string
+	// Strategies - 比較する取得手法（strategyクエリパラメーターの値）
+	Strategies []string
+	// Requests - 手法ごとのリクエスト数
+	Requests int
+	// Concurrency - 同時実行数
+	Concurrency int
+	// CustomerIDs - リクエスト対象の顧客ID（順番に巡回する）
+	CustomerIDs []int64
+	// Mix - リクエストの構成（指定時はCustomerIDsの代わりに構成に従って顧客を選ぶ）
+	Mix *Mix
+	// Client - 使用するHTTPクライアント（nilの場合はhttp.DefaultClient）
+	Client *http.Client
+}
+
+// Result - 手法ごとの負荷テスト結果
+type Result struct {
+	Strategy   string        `json:"strategy"`
+	Requests   int           `json:"requests"`
+	Errors     int64         `json:"errors"`
+	NotFound   int64         `json:"not_found"`
+	CacheHits  int64         `json:"cache_hits"`
+	Duration   time.Duration `json:"duration"`
+	Throughput float64       `json:"throughput_rps"`
+	Mean       time.Duration `json:"mean"`
+	P50        time.Duration `json:"p50"`
+	P95        time.Duration `json:"p95"`
+	P99        time.Duration `json:"p99"`
+	Max        time.Duration `json:"max"`
+	// Segments - リクエスト構成のセグメント別の内訳（Mix指定時のみ）
+	Segments []SegmentResult `json:"segments,omitempty"`
+}
+
+// SegmentResult - セグメント別の負荷テスト結果
+type SegmentResult struct {
+	Name      string        `json:"name"`
+	Requests  int           `json:"requests"`
+	Errors    int64         `json:"errors"`
+	NotFound  int64         `json:"not_found"`
+	CacheHits int64         `json:"cache_hits"`
+	Mean      time.Duration `json:"mean"`
+	P95       time.Duration `json:"p95"`
+}
+
+// HitRate - キャッシュヒット率（%）
+func (r SegmentResult) HitRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.CacheHits) / float64(r.Requests) * 100
+}
+
+// outcome - 1リクエストの結果の分類
+type outcome int
+
+const (
+	outcomeOK outcome = iota
+	outcomeHit
+	outcomeNotFound
+	outcomeError
+)
+
+// Run - 手法ごとに順番に負荷をかけてレイテンシ分布を測定
+func Run(ctx context.Context, cfg Config) ([]Result, error) {
+	if cfg.Requests <= 0 {
+		return nil, errors.New("requests must be positive")
+	}
+	if len(cfg.CustomerIDs) == 0 && cfg.Mix == nil {
+		return nil, errors.New("no customer ids")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	// すべての手法に同じリクエスト列を流す
+	sequence := requestSequence(cfg)
+
+	results := make([]Result, 0, len(cfg.Strategies))
+	for _, strategy := range cfg.Strategies {
+		result, err := runStrategy(ctx, cfg, strategy, sequence)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// requestSequence - リクエスト列（Mix未指定の場合はCustomerIDsを順番に巡回）
+func requestSequence(cfg Config) []MixRequest {
+	if cfg.Mix != nil {
+		return cfg.Mix.Sequence(cfg.Requests)
+	}
+	sequence := make([]MixRequest, cfg.Requests)
+	for i := range sequence {
+		sequence[i] = MixRequest{CustomerID: cfg.CustomerIDs[i%len(cfg.CustomerIDs)]}
+	}
+	return sequence
+}
+
+// runStrategy - 1手法分の負荷テスト
+func runStrategy(ctx context.Context, cfg Config, strategy string, sequence []MixRequest) (Result, error) {
+	result := Result{Strategy: strategy, Requests: cfg.Requests}
+	latencies := make([]time.Duration, cfg.Requests)
+	outcomes := make([]outcome, cfg.Requests)
+
+	var next atomic.Int64
+	var errCount, notFound, cacheHits atomic.Int64
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= cfg.Requests || ctx.Err() != nil {
+					return
+				}
+
+				customerID := sequence[i].CustomerID
+				url := fmt.Sprintf("%s/customers/%d/summary?strategy=%s", cfg.BaseURL, customerID, strategy)
+
+				reqStart := time.Now()
+				status, hit, err := doRequest(ctx, cfg.Client, url)
+				latencies[i] = time.Since(reqStart)
+
+				switch {
+				case err != nil, status != http.StatusOK && status != http.StatusNotFound:
+					errCount.Add(1)
+					outcomes[i] = outcomeError
+				case status == http.StatusNotFound:
+					notFound.Add(1)
+					outcomes[i] = outcomeNotFound
+				case hit:
+					cacheHits.Add(1)
+					outcomes[i] = outcomeHit
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	result.Duration = time.Since(start)
+	result.Errors = errCount.Load()
+	result.NotFound = notFound.Load()
+	result.CacheHits = cacheHits.Load()
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if result.Duration > 0 {
+		result.Throughput = float64(cfg.Requests) / result.Duration.Seconds()
+	}
+	summarizeLatencies(&result, latencies)
+	if cfg.Mix != nil {
+		result.Segments = summarizeSegments(cfg.Mix, sequence, latencies, outcomes)
+	}
+
+	return result, nil
+}
+
+// summarizeSegments - セグメント別にリクエスト数・ヒット数・レイテンシを集計
+func summarizeSegments(mix *Mix, sequence []MixRequest, latencies []time.Duration, outcomes []outcome) []SegmentResult {
+	segments := make([]SegmentResult, len(mix.Segments))
+	perSegment := make([][]time.Duration, len(mix.Segments))
+	for i, seg := range mix.Segments {
+		segments[i].Name = seg.Name
+	}
+
+	for i, req := range sequence {
+		seg := &segments[req.Segment]
+		seg.Requests++
+		switch outcomes[i] {
+		case outcomeHit:
+			seg.CacheHits++
+		case outcomeNotFound:
+			seg.NotFound++
+		case outcomeError:
+			seg.Errors++
+		}
+		perSegment[req.Segment] = append(perSegment[req.Segment], latencies[i])
+	}
+
+	for i := range segments {
+		var summary Result
+		summarizeLatencies(&summary, perSegment[i])
+		segments[i].Mean = summary.Mean
+		segments[i].P95 = summary.P95
+	}
+	return segments
+}
+
+// doRequest - 1リクエストを実行してステータスとキャッシュヒット有無を返す
+func doRequest(ctx context.Context, client *http.Client, url string) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, false, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			fmt.Printf("resp.Body.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	// 接続を再利用するためにボディを読み切る
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return resp.StatusCode, false, err
+	}
+
+	return resp.StatusCode, resp.Header.Get("X-Cache") == "HIT", nil
+}
+
+// summarizeLatencies - 平均とパーセンタイルを計算
+func summarizeLatencies(result *Result, latencies []time.Duration) {
+	if len(latencies) == 0 {
+		return
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+
+	result.Mean = total / time.Duration(len(sorted))
+	result.P50 = percentile(sorted, 50)
+	result.P95 = percentile(sorted, 95)
+	result.P99 = percentile(sorted, 99)
+	result.Max = sorted[len(sorted)-1]
+}
+
+// percentile - ソート済みスライスのパーセンタイル（最近傍法）
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (len(sorted)*p + 99) / 100
+	if idx < 1 {
+		idx = 1
+	}
+	if idx > len(sorted) {
+		idx = len(sorted)
+	}
+	return sorted[idx-1]
+}
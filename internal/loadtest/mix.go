@@ -0,0 +1,176 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 顧客の選び方
+const (
+	// DistributionUniform - セグメント内の顧客を均等に選ぶ
+	DistributionUniform = "uniform"
+	// DistributionZipf - 先頭の顧客ほど多く選ぶ（一部の顧客にアクセスが集中する）
+	DistributionZipf = "zipf"
+)
+
+// defaultZipfSkew - Zipf分布の偏りの既定値（1より大きいほど先頭に集中する）
+const defaultZipfSkew = 1.1
+
+// Mix - リクエストの構成（どの顧客にどの割合でリクエストが来るか）
+//
+// 同じ顧客への同じクエリを繰り返すとキャッシュが常にヒットし、N+1の回数も一定になるため、
+// 本番に近い偏りのあるアクセスを再現する。
+type Mix struct {
+	// Seed - リクエスト列の乱数シード（手法間で同じリクエスト列を使う）
+	Seed     uint64    `json:"seed"`
+	Segments []Segment `json:"segments"`
+}
+
+// Segment - 同じ傾向の顧客のまとまり
+type Segment struct {
+	Name string `json:"name"`
+	// Customers - 顧客IDの範囲（例: 1001-1005）またはカンマ区切り
+	Customers string `json:"customers"`
+	// Weight - リクエスト全体に占める割合（相対値）
+	Weight float64 `json:"weight"`
+	// Distribution - セグメント内の顧客の選び方（uniform, zipf。省略時はuniform）
+	Distribution string `json:"distribution,omitempty"`
+	// Skew - zipfの偏り（1より大きい値、省略時は1.1）
+	Skew float64 `json:"skew,omitempty"`
+
+	ids []int64
+}
+
+// MixRequest - リクエスト列の1件
+type MixRequest struct {
+	CustomerID int64
+	// Segment - Mix.Segmentsの添字
+	Segment int
+}
+
+// LoadMix - リクエスト構成ファイル（JSON）を読み込む
+func LoadMix(path string) (*Mix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request mix: %w", err)
+	}
+
+	var mix Mix
+	if err := json.Unmarshal(data, &mix); err != nil {
+		return nil, fmt.Errorf("failed to parse request mix %s: %w", path, err)
+	}
+	if err := mix.validate(); err != nil {
+		return nil, fmt.Errorf("invalid request mix %s: %w", path, err)
+	}
+	return &mix, nil
+}
+
+// validate - セグメントの指定を確認し、顧客IDを展開する
+func (m *Mix) validate() error {
+	if len(m.Segments) == 0 {
+		return errors.New("no segments")
+	}
+
+	for i := range m.Segments {
+		seg := &m.Segments[i]
+		if seg.Name == "" {
+			seg.Name = fmt.Sprintf("segment%d", i+1)
+		}
+		if seg.Weight <= 0 {
+			return fmt.Errorf("segment %q: weight must be positive", seg.Name)
+		}
+		ids, err := ParseCustomerIDs(seg.Customers)
+		if err != nil {
+			return fmt.Errorf("segment %q: %w", seg.Name, err)
+		}
+		seg.ids = ids
+
+		switch seg.Distribution {
+		case "":
+			seg.Distribution = DistributionUniform
+		case DistributionUniform:
+		case DistributionZipf:
+			if seg.Skew == 0 {
+				seg.Skew = defaultZipfSkew
+			}
+			if seg.Skew <= 1 {
+				return fmt.Errorf("segment %q: zipf skew must be greater than 1", seg.Name)
+			}
+		default:
+			return fmt.Errorf("segment %q: unknown distribution %q (%s, %s)", seg.Name, seg.Distribution, DistributionUniform, DistributionZipf)
+		}
+	}
+	return nil
+}
+
+// Sequence - n件のリクエスト列を作る（同じシードなら同じ列になる）
+func (m *Mix) Sequence(n int) []MixRequest {
+	rng := rand.New(rand.NewPCG(m.Seed, m.Seed))
+
+	var total float64
+	for _, seg := range m.Segments {
+		total += seg.Weight
+	}
+	zipfs := make([]*rand.Zipf, len(m.Segments))
+	for i, seg := range m.Segments {
+		if seg.Distribution == DistributionZipf && len(seg.ids) > 1 {
+			zipfs[i] = rand.NewZipf(rng, seg.Skew, 1, uint64(len(seg.ids)-1))
+		}
+	}
+
+	requests := make([]MixRequest, n)
+	for i := range requests {
+		// 重みに応じてセグメントを選ぶ
+		pick := rng.Float64() * total
+		segment := len(m.Segments) - 1
+		for j, seg := range m.Segments {
+			if pick < seg.Weight {
+				segment = j
+				break
+			}
+			pick -= seg.Weight
+		}
+
+		ids := m.Segments[segment].ids
+		index := rng.IntN(len(ids))
+		if z := zipfs[segment]; z != nil {
+			index = int(z.Uint64())
+		}
+		requests[i] = MixRequest{CustomerID: ids[index], Segment: segment}
+	}
+	return requests
+}
+
+// ParseCustomerIDs - "1001-1050" 形式の範囲またはカンマ区切りの顧客IDを解析
+func ParseCustomerIDs(value string) ([]int64, error) {
+	if from, to, ok := strings.Cut(value, "-"); ok {
+		start, err := strconv.ParseInt(strings.TrimSpace(from), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("顧客IDの範囲が不正です: %q", value)
+		}
+		end, err := strconv.ParseInt(strings.TrimSpace(to), 10, 64)
+		if err != nil || end < start {
+			return nil, fmt.Errorf("顧客IDの範囲が不正です: %q", value)
+		}
+		ids := make([]int64, 0, end-start+1)
+		for id := start; id <= end; id++ {
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+
+	var ids []int64
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("顧客IDが不正です: %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
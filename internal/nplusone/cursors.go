@@ -0,0 +1,46 @@
+package nplusone
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LoadStatements - スキーマで解析されたSELECT文を、V$SQLAREAから実行回数の多い順に取得
+//
+// schemaが空の場合は接続したユーザーのスキーマ。V$SQLAREAの参照にはSELECT_CATALOG_ROLEなどの権限が必要。
+// 実行回数はインスタンスの起動（または共有プールからの追い出し）以降の累計。
+func LoadStatements(db *sql.DB, schema string, minExecutions int64, limit int) ([]Statement, error) {
+	rows, err := db.Query(`
+		SELECT sql_id, sql_text, executions, rows_processed, elapsed_time
+		FROM v$sqlarea
+		WHERE parsing_schema_name = NVL(UPPER(:1), USER)
+		  AND command_type = 3
+		  AND executions >= :2
+		  AND UPPER(sql_text) NOT LIKE '%V$%'
+		ORDER BY executions DESC, sql_id
+		FETCH FIRST :3 ROWS ONLY`, schema, minExecutions, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v$sqlarea: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var statements []Statement
+	for rows.Next() {
+		var s Statement
+		var elapsedMicros int64
+		if err := rows.Scan(&s.SQLID, &s.Text, &s.Executions, &s.Rows, &elapsedMicros); err != nil {
+			return nil, fmt.Errorf("failed to scan v$sqlarea: %w", err)
+		}
+		s.Elapsed = time.Duration(elapsedMicros) * time.Microsecond
+		statements = append(statements, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read v$sqlarea: %w", err)
+	}
+	return statements, nil
+}
@@ -0,0 +1,187 @@
+// Package nplusone - カーソルキャッシュ（V$SQLAREA）から、キー1つで少数の行を読む文が繰り返し実行されている箇所（N+1の疑い）を検出する
+//
+// ループ内の問い合わせは「SELECT ... FROM 表 WHERE 列 = :1」の形で、実行回数が多く1回あたりの行数が少ない。
+// この形に合う文を、生成したORM（GORM・ent・sqlc）の文の特徴で見分け、フレームワークごとの直し方を示す。
+package nplusone
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMinExecutions - 既定の、N+1とみなす実行回数の下限
+	DefaultMinExecutions = 100
+	// DefaultMaxRowsPerExecution - 既定の、N+1とみなす1回あたりの行数の上限
+	DefaultMaxRowsPerExecution = 20
+	// DefaultLimit - 既定の、調べるカーソルの数（実行回数の多い順）
+	DefaultLimit = 500
+)
+
+var (
+	lineCommentRe  = regexp.MustCompile(`--[^\n]*`)
+	blockCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	stringRe       = regexp.MustCompile(`'(?:[^']|'')*'`)
+	spaceRe        = regexp.MustCompile(`\s+`)
+
+	// shapeRe - 1つの表を読むSELECT文（列リスト・表・別名・WHERE句）。ORDER BYと件数の制限は読み飛ばす
+	shapeRe = regexp.MustCompile(`^SELECT (?:DISTINCT )?(.+?) FROM ([A-Z][A-Z0-9_$#]*)(?: (?:AS )?([A-Z][A-Z0-9_$#]*))? WHERE (.+?)(?: ORDER BY .+?)?(?: (?:OFFSET|FETCH) .+)?$`)
+	// selectListRe - 列リスト（引用符で囲んだ識別子を含むSQLから取り出す）
+	selectListRe = regexp.MustCompile(`^SELECT (?:DISTINCT )?(.+?) FROM `)
+	// keyPredicateRe - 列 = バインド変数（例: od.order_id = :1）
+	keyPredicateRe = regexp.MustCompile(`^(?:([A-Z][A-Z0-9_$#]*)\.)?([A-Z][A-Z0-9_$#]*) = :[A-Z0-9_]+$`)
+	// filterPredicateRe - キーの条件と一緒に付くことのある条件（論理削除の IS NULL、件数の制限）
+	filterPredicateRe = regexp.MustCompile(`^(?:(?:[A-Z][A-Z0-9_$#]*\.)?[A-Z][A-Z0-9_$#]* IS (?:NOT )?NULL|ROWNUM <=? [0-9:A-Z_]+)$`)
+)
+
+// Config - 検出の設定
+type Config struct {
+	// Schema - 文を解析したスキーマ（空の場合は接続したユーザー）
+	Schema string
+	// MinExecutions - N+1とみなす実行回数の下限
+	MinExecutions int64
+	// MaxRowsPerExecution - N+1とみなす1回あたりの行数の上限
+	MaxRowsPerExecution float64
+	// Limit - 調べるカーソルの数（実行回数の多い順）
+	Limit int
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{MinExecutions: DefaultMinExecutions, MaxRowsPerExecution: DefaultMaxRowsPerExecution, Limit: DefaultLimit}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if c.MinExecutions <= 0 {
+		return fmt.Errorf("min executions must be positive: %d", c.MinExecutions)
+	}
+	if c.MaxRowsPerExecution <= 0 {
+		return fmt.Errorf("max rows per execution must be positive: %v", c.MaxRowsPerExecution)
+	}
+	if c.Limit <= 0 {
+		return fmt.Errorf("limit must be positive: %d", c.Limit)
+	}
+	return nil
+}
+
+// Statement - カーソルキャッシュ上の文1件（子カーソルをまとめたもの）
+type Statement struct {
+	SQLID      string        `json:"sql_id"`
+	Text       string        `json:"sql_text"`
+	Executions int64         `json:"executions"`
+	Rows       int64         `json:"rows_processed"`
+	Elapsed    time.Duration `json:"elapsed_ns"`
+}
+
+// RowsPerExecution - 1回あたりの行数
+func (s Statement) RowsPerExecution() float64 {
+	if s.Executions == 0 {
+		return 0
+	}
+	return float64(s.Rows) / float64(s.Executions)
+}
+
+// Finding - N+1の疑いのある文1件
+type Finding struct {
+	Statement
+	// Table / Column - キーで読んでいる表と列
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	// RuleID / Framework - 文の形から見分けたルールとフレームワーク（手書きまたは不明の場合はFrameworkが空）
+	RuleID    string `json:"rule_id"`
+	Framework string `json:"framework,omitempty"`
+	// Name - 生成元のクエリ名（sqlcの -- name: の値）
+	Name string `json:"name,omitempty"`
+	// Hint - フレームワークに合わせた直し方
+	Hint string `json:"hint"`
+}
+
+// Report - 検出結果
+type Report struct {
+	Schema              string    `json:"schema"`
+	MinExecutions       int64     `json:"min_executions"`
+	MaxRowsPerExecution float64   `json:"max_rows_per_execution"`
+	Scanned             int       `json:"scanned"`
+	Findings            []Finding `json:"findings"`
+}
+
+// Detect - 文のうち、キー1つで少数の行を繰り返し読むものを実行回数の多い順に返す
+func Detect(statements []Statement, cfg Config) *Report {
+	report := &Report{Schema: cfg.Schema, MinExecutions: cfg.MinExecutions, MaxRowsPerExecution: cfg.MaxRowsPerExecution, Scanned: len(statements)}
+	for _, s := range statements {
+		if s.Executions < cfg.MinExecutions || s.RowsPerExecution() > cfg.MaxRowsPerExecution {
+			continue
+		}
+		shape, ok := parseShape(s.Text)
+		if !ok {
+			continue
+		}
+		f := Finding{Statement: s, Table: shape.table, Column: shape.column}
+		f.RuleID, f.Framework, f.Name, f.Hint = recognize(s.Text, shape)
+		report.Findings = append(report.Findings, f)
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Executions > report.Findings[j].Executions
+	})
+	return report
+}
+
+// shape - キー1つで1つの表を読む文の構成要素
+type shape struct {
+	// quoted - コメントと文字列を除き、大文字化したSQL（二重引用符を残す）
+	quoted string
+	// selectList - 列リスト（二重引用符を残す）
+	selectList string
+	table      string
+	column     string
+}
+
+// normalize - コメントと文字列リテラルを除き、大文字化して空白をまとめる
+func normalize(text string) string {
+	text = blockCommentRe.ReplaceAllString(text, " ")
+	text = lineCommentRe.ReplaceAllString(text, " ")
+	text = stringRe.ReplaceAllString(text, "''")
+	return strings.TrimSpace(strings.ToUpper(spaceRe.ReplaceAllString(text, " ")))
+}
+
+// parseShape - 1つの表をキー1つの等価条件（と論理削除・件数の条件）で読む文かを判定し、表と列を取り出す
+//
+// JOIN・副問合せ・IN句・複数のキーを含む文は対象にしない。ORMが引用符で囲んだ識別子も同じように扱う。
+func parseShape(text string) (shape, bool) {
+	quoted := normalize(text)
+	plain := strings.ReplaceAll(quoted, `"`, "")
+	m := shapeRe.FindStringSubmatch(plain)
+	if m == nil || strings.Contains(m[1], "SELECT") || strings.Contains(m[1], " FROM ") {
+		return shape{}, false
+	}
+	table, alias := m[2], m[3]
+
+	var column string
+	for _, predicate := range strings.Split(m[4], " AND ") {
+		predicate = strings.TrimSpace(predicate)
+		if filterPredicateRe.MatchString(predicate) {
+			continue
+		}
+		k := keyPredicateRe.FindStringSubmatch(predicate)
+		if k == nil || column != "" {
+			return shape{}, false
+		}
+		if k[1] != "" && k[1] != table && k[1] != alias {
+			return shape{}, false
+		}
+		column = k[2]
+	}
+	if column == "" {
+		return shape{}, false
+	}
+
+	selectList := m[1]
+	if qm := selectListRe.FindStringSubmatch(quoted); qm != nil {
+		selectList = qm[1]
+	}
+	return shape{quoted: quoted, selectList: selectList, table: table, column: column}, true
+}
@@ -0,0 +1,164 @@
+package nplusone
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseShape(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		wantOK     bool
+		wantTable  string
+		wantColumn string
+	}{
+		{
+			name:       "demo n+1",
+			sql:        "\n\t\tSELECT detail_id, order_id, product_id, quantity, unit_price\n\t\tFROM order_details\n\t\tWHERE order_id = :1\n\t\tORDER BY detail_id",
+			wantOK:     true,
+			wantTable:  "ORDER_DETAILS",
+			wantColumn: "ORDER_ID",
+		},
+		{
+			name:       "alias",
+			sql:        "SELECT od.* FROM order_details od WHERE od.order_id = :order_id",
+			wantOK:     true,
+			wantTable:  "ORDER_DETAILS",
+			wantColumn: "ORDER_ID",
+		},
+		{
+			name:       "quoted with soft delete and limit",
+			sql:        `SELECT * FROM "ORDERS" WHERE "ORDERS"."ID" = :1 AND "ORDERS"."DELETED_AT" IS NULL ORDER BY "ORDERS"."ID" FETCH NEXT 1 ROWS ONLY`,
+			wantOK:     true,
+			wantTable:  "ORDERS",
+			wantColumn: "ID",
+		},
+		{name: "in list", sql: "SELECT * FROM order_details WHERE order_id IN (:1, :2)"},
+		{name: "join", sql: "SELECT * FROM orders o JOIN order_details od ON od.order_id = o.order_id WHERE o.customer_id = :1"},
+		{name: "two keys", sql: "SELECT * FROM order_details WHERE order_id = :1 AND product_id = :2"},
+		{name: "subquery", sql: "SELECT * FROM orders WHERE customer_id = (SELECT customer_id FROM customers WHERE name = :1)"},
+		{name: "scalar subquery in select list", sql: "SELECT (SELECT COUNT(*) FROM order_details) FROM orders WHERE order_id = :1"},
+		{name: "range", sql: "SELECT * FROM orders WHERE order_date >= :1"},
+		{name: "other qualifier", sql: "SELECT * FROM orders o WHERE x.order_id = :1"},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseShape(tt.sql)
+		if ok != tt.wantOK {
+			t.Errorf("%s: parseShape() ok = %v, want %v", tt.name, ok, tt.wantOK)
+			continue
+		}
+		if ok && (got.table != tt.wantTable || got.column != tt.wantColumn) {
+			t.Errorf("%s: parseShape() = %s.%s, want %s.%s", tt.name, got.table, got.column, tt.wantTable, tt.wantColumn)
+		}
+	}
+}
+
+func TestDetectRecognizesFrameworks(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		wantRule      string
+		wantFramework string
+		wantName      string
+		wantHint      string
+	}{
+		{
+			name:          "gorm association",
+			sql:           `SELECT * FROM "ORDER_DETAILS" WHERE "ORDER_DETAILS"."ORDER_ID" = :1 AND "ORDER_DETAILS"."DELETED_AT" IS NULL`,
+			wantRule:      "gorm-select-star",
+			wantFramework: "GORM",
+			wantHint:      "Preload",
+		},
+		{
+			name:          "gorm where",
+			sql:           `SELECT * FROM "ORDER_DETAILS" WHERE order_id = :1 AND "ORDER_DETAILS"."DELETED_AT" IS NULL`,
+			wantRule:      "gorm-select-star",
+			wantFramework: "GORM",
+			wantHint:      `Where("order_id = ?", id)`,
+		},
+		{
+			name:          "ent",
+			sql:           `SELECT DISTINCT "ORDER_DETAILS"."ID", "ORDER_DETAILS"."ORDER_ID", "ORDER_DETAILS"."QUANTITY" FROM "ORDER_DETAILS" WHERE "ORDER_DETAILS"."ORDER_ID" = :1`,
+			wantRule:      "ent-qualified-columns",
+			wantFramework: "ent",
+			wantHint:      "With<エッジ名>()",
+		},
+		{
+			name:          "sqlc",
+			sql:           "-- name: ListOrderDetails :many\nSELECT detail_id, order_id, quantity FROM order_details\nWHERE order_id = :1\n",
+			wantRule:      "sqlc-named-query",
+			wantFramework: "sqlc",
+			wantName:      "ListOrderDetails",
+			wantHint:      "sqlc.slice('order_ids')",
+		},
+		{
+			name:     "hand-written",
+			sql:      "SELECT detail_id, quantity FROM order_details WHERE order_id = :1",
+			wantRule: "hand-written",
+			wantHint: "dataloader",
+		},
+	}
+
+	cfg := DefaultConfig()
+	for _, tt := range tests {
+		report := Detect([]Statement{{SQLID: "abc", Text: tt.sql, Executions: 1000, Rows: 3000}}, cfg)
+		if len(report.Findings) != 1 {
+			t.Errorf("%s: findings = %d, want 1", tt.name, len(report.Findings))
+			continue
+		}
+		f := report.Findings[0]
+		if f.RuleID != tt.wantRule || f.Framework != tt.wantFramework || f.Name != tt.wantName {
+			t.Errorf("%s: rule = %q, framework = %q, name = %q, want %q, %q, %q",
+				tt.name, f.RuleID, f.Framework, f.Name, tt.wantRule, tt.wantFramework, tt.wantName)
+		}
+		if !strings.Contains(f.Hint, tt.wantHint) {
+			t.Errorf("%s: hint %q does not contain %q", tt.name, f.Hint, tt.wantHint)
+		}
+	}
+}
+
+func TestDetectThresholds(t *testing.T) {
+	const sql = "SELECT * FROM order_details WHERE order_id = :1"
+	statements := []Statement{
+		{SQLID: "few", Text: sql, Executions: 10, Rows: 30},
+		{SQLID: "wide", Text: sql, Executions: 500, Rows: 500 * 100},
+		{SQLID: "most", Text: sql, Executions: 5000, Rows: 15000},
+		{SQLID: "many", Text: sql, Executions: 200, Rows: 600},
+		{SQLID: "batch", Text: "SELECT * FROM order_details WHERE order_id IN (:1, :2)", Executions: 5000, Rows: 15000},
+	}
+
+	report := Detect(statements, DefaultConfig())
+	var got []string
+	for _, f := range report.Findings {
+		got = append(got, f.SQLID)
+	}
+	if strings.Join(got, ",") != "most,many" {
+		t.Errorf("findings = %v, want [most many]", got)
+	}
+	if report.Scanned != len(statements) {
+		t.Errorf("scanned = %d, want %d", report.Scanned, len(statements))
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{name: "default", modify: func(*Config) {}},
+		{name: "zero executions", modify: func(c *Config) { c.MinExecutions = 0 }, wantErr: true},
+		{name: "zero rows", modify: func(c *Config) { c.MaxRowsPerExecution = 0 }, wantErr: true},
+		{name: "zero limit", modify: func(c *Config) { c.Limit = 0 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		tt.modify(&cfg)
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
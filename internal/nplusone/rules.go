@@ -0,0 +1,90 @@
+package nplusone
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// sqlcNameRe - sqlcが生成するクエリの先頭のコメント（例: -- name: GetOrderDetails :many）
+	sqlcNameRe = regexp.MustCompile(`^\s*--\s*name:\s*([A-Za-z0-9_]+)\s+:(?:one|many|exec\w*|copyfrom|batch\w*)`)
+	// quotedColumnRe - 引用符で囲んだ表で修飾した列（例: "ORDER_DETAILS"."ORDER_ID"）
+	quotedColumnRe = regexp.MustCompile(`^"([A-Z][A-Z0-9_$#]*)"\."[A-Z][A-Z0-9_$#]*"$`)
+)
+
+// rule - ORMが生成する文の特徴と、そのフレームワークでの直し方
+type rule struct {
+	id        string
+	framework string
+	// match - 文がこのルールに合うか（合う場合は生成元のクエリ名も返す）
+	match func(raw string, s shape) (name string, ok bool)
+	// hint - 直し方（表・列・クエリ名を埋め込む）
+	hint func(s shape, name string) string
+}
+
+// rulepack - 上から順に照合する（どれにも合わない場合は手書きのSQLとみなす）
+var rulepack = []rule{
+	{
+		id:        "sqlc-named-query",
+		framework: "sqlc",
+		match: func(raw string, _ shape) (string, bool) {
+			m := sqlcNameRe.FindStringSubmatch(raw)
+			if m == nil {
+				return "", false
+			}
+			return m[1], true
+		},
+		hint: func(s shape, name string) string {
+			return fmt.Sprintf("sqlc: %s をループで呼ばず、キーの配列を受け取るクエリ（WHERE %s IN (sqlc.slice('%s'))）を追加して一括取得し、呼び出し側で振り分けてください。"+
+				"GraphQLのリゾルバーなど呼び出しをまとめられない場合は、dataloaderで同じリクエスト内の呼び出しをまとめます",
+				name, strings.ToLower(s.column), strings.ToLower(s.column)+"s")
+		},
+	},
+	{
+		// GORMは列を列挙せず SELECT * とし、表名を引用符で囲む（Association・論理削除の条件では列も表名で修飾して引用符で囲む）
+		id:        "gorm-select-star",
+		framework: "GORM",
+		match: func(_ string, s shape) (string, bool) {
+			return "", s.selectList == "*" && strings.Contains(s.quoted, fmt.Sprintf(`FROM "%s" WHERE `, s.table))
+		},
+		hint: func(s shape, _ string) string {
+			return fmt.Sprintf("GORM: ループ内の Where(\"%s = ?\", id).Find(...) をやめ、親の取得に Preload(\"<関連名>\") を付けて %s をIN句の1クエリで読み込んでください。"+
+				"1対1・多対1の関連なら Joins(\"<関連名>\") でJOINにできます", strings.ToLower(s.column), s.table)
+		},
+	},
+	{
+		// entは列を1つずつ表名で修飾して引用符で囲んで列挙する
+		id:        "ent-qualified-columns",
+		framework: "ent",
+		match: func(_ string, s shape) (string, bool) {
+			for _, col := range strings.Split(s.selectList, ",") {
+				m := quotedColumnRe.FindStringSubmatch(strings.TrimSpace(col))
+				if m == nil || m[1] != s.table {
+					return "", false
+				}
+			}
+			return "", true
+		},
+		hint: func(s shape, _ string) string {
+			return fmt.Sprintf("ent: 関連をループで Query<エッジ名>() せず、親のクエリに With<エッジ名>() を付けてeager loadingしてください（entは親のIDをIN句にまとめて %s を読みます）。"+
+				"entgqlではフィールドの収集（CollectFields）でeager loadingされます", s.table)
+		},
+	},
+}
+
+// handWrittenHint - どのORMのルールにも合わない文の直し方
+func handWrittenHint(s shape) string {
+	return fmt.Sprintf("%s を %s = :1 で1件ずつ読まず、キーをIN句にまとめて一括取得する（Batch_Optimized）か、親の問い合わせとJOINしてください（JOIN_Optimized）。"+
+		"呼び出し元をまとめられない場合は、dataloaderで同じリクエスト内の呼び出しをまとめます", s.table, strings.ToLower(s.column))
+}
+
+// recognize - 文をルールと照合し、ルールID・フレームワーク・クエリ名・直し方を返す
+func recognize(raw string, s shape) (ruleID, framework, name, hint string) {
+	for _, r := range rulepack {
+		if name, ok := r.match(raw, s); ok {
+			return r.id, r.framework, name, r.hint(s, name)
+		}
+	}
+	return "hand-written", "", "", handWrittenHint(s)
+}
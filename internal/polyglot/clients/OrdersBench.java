@@ -0,0 +1,157 @@
+// 受注明細の取得（N+1とJOIN）をJDBC（ojdbc）で計測する参照実装（compare-clientsコマンドが実行する）
+//
+// Goの ProblemOrderRepository.GetOrdersWithDetails / OptimizedOrderRepository.GetOrdersWithDetailsJoin と
+// 同じSQLを同じ順に実行し、結果を1つのJSONとして標準出力に書き出す。
+// 接続先は環境変数 DB_HOST / DB_PORT / DB_SERVICE_NAME / DB_USERNAME / DB_PASSWORD で受け取る。
+// 単一ファイルのソースとして実行する（テキストブロックとrecordを使うためJDK 17以降）。
+//
+//   java -cp ojdbc11.jar OrdersBench.java --days 30 --max-orders 200 --runs 5 --warmup 1
+
+import java.sql.Connection;
+import java.sql.DatabaseMetaData;
+import java.sql.DriverManager;
+import java.sql.PreparedStatement;
+import java.sql.ResultSet;
+import java.sql.SQLException;
+import java.util.ArrayList;
+import java.util.List;
+
+public class OrdersBench {
+    // FETCH_SIZE - 1回のラウンドトリップで取得する行数（python-oracledbのarraysizeの既定値に揃える）
+    private static final int FETCH_SIZE = 100;
+
+    private static final String DETAILS_BY_ORDER_ID = """
+            SELECT detail_id, order_id, product_id, quantity, unit_price
+            FROM order_details
+            WHERE order_id = ?
+            ORDER BY detail_id""";
+
+    // Outcome - 1回の実行で読んだ明細の件数と実行した文の数
+    record Outcome(long records, long statements) {}
+
+    interface Method {
+        Outcome run(Connection conn, int days, int maxOrders) throws SQLException;
+    }
+
+    // orderWindow - 過去N日間の受注の条件（Goの Limits.orderWindow と同じ。上限がある場合は新しい順に絞る）
+    static String orderWindow(String alias, int maxOrders) {
+        String prefix = alias.isEmpty() ? "" : alias + ".";
+        if (maxOrders <= 0) {
+            return prefix + "order_date >= SYSDATE - ?";
+        }
+        return prefix + """
+                order_id IN (
+                    SELECT order_id FROM orders
+                    WHERE order_date >= SYSDATE - ?
+                    ORDER BY order_date DESC, order_id DESC
+                    FETCH FIRST ? ROWS ONLY)""";
+    }
+
+    static void bindWindow(PreparedStatement ps, int days, int maxOrders) throws SQLException {
+        ps.setInt(1, days);
+        if (maxOrders > 0) {
+            ps.setInt(2, maxOrders);
+        }
+    }
+
+    // nPlus1 - 受注一覧を取得し、受注ごとに明細を取得する（1 + N回の実行）
+    static Outcome nPlus1(Connection conn, int days, int maxOrders) throws SQLException {
+        List<Long> orderIds = new ArrayList<>();
+        String sql = "SELECT order_id, customer_id, order_date, total_amount FROM orders WHERE "
+                + orderWindow("", maxOrders) + " ORDER BY order_id";
+        try (PreparedStatement ps = conn.prepareStatement(sql)) {
+            ps.setFetchSize(FETCH_SIZE);
+            bindWindow(ps, days, maxOrders);
+            try (ResultSet rs = ps.executeQuery()) {
+                while (rs.next()) {
+                    orderIds.add(rs.getLong(1));
+                }
+            }
+        }
+        long records = 0;
+        for (long orderId : orderIds) {
+            try (PreparedStatement ps = conn.prepareStatement(DETAILS_BY_ORDER_ID)) {
+                ps.setFetchSize(FETCH_SIZE);
+                ps.setLong(1, orderId);
+                try (ResultSet rs = ps.executeQuery()) {
+                    while (rs.next()) {
+                        records++;
+                    }
+                }
+            }
+        }
+        return new Outcome(records, 1 + orderIds.size());
+    }
+
+    // join - 受注と明細をLEFT JOINで1回に取得する
+    static Outcome join(Connection conn, int days, int maxOrders) throws SQLException {
+        String sql = """
+                SELECT o.order_id, o.customer_id, o.order_date, o.total_amount,
+                       od.detail_id, od.product_id, od.quantity, od.unit_price
+                FROM orders o
+                LEFT JOIN order_details od ON o.order_id = od.order_id
+                WHERE %s
+                ORDER BY o.order_id, od.detail_id""".formatted(orderWindow("o", maxOrders));
+        long records = 0;
+        try (PreparedStatement ps = conn.prepareStatement(sql)) {
+            ps.setFetchSize(FETCH_SIZE);
+            bindWindow(ps, days, maxOrders);
+            try (ResultSet rs = ps.executeQuery()) {
+                while (rs.next()) {
+                    rs.getLong(5);
+                    if (!rs.wasNull()) {
+                        records++;
+                    }
+                }
+            }
+        }
+        return new Outcome(records, 1);
+    }
+
+    public static void main(String[] args) throws Exception {
+        int days = 30, maxOrders = 0, runs = 5, warmup = 1;
+        for (int i = 0; i + 1 < args.length; i += 2) {
+            int value = Integer.parseInt(args[i + 1]);
+            switch (args[i]) {
+                case "--days" -> days = value;
+                case "--max-orders" -> maxOrders = value;
+                case "--runs" -> runs = value;
+                case "--warmup" -> warmup = value;
+                default -> throw new IllegalArgumentException("unknown option: " + args[i]);
+            }
+        }
+
+        String url = "jdbc:oracle:thin:@//" + System.getenv("DB_HOST") + ":" + System.getenv("DB_PORT")
+                + "/" + System.getenv("DB_SERVICE_NAME");
+        StringBuilder out = new StringBuilder();
+        try (Connection conn = DriverManager.getConnection(url, System.getenv("DB_USERNAME"), System.getenv("DB_PASSWORD"))) {
+            DatabaseMetaData meta = conn.getMetaData();
+            out.append("{\"client\":\"jdbc\",\"runtime\":\"Java ").append(System.getProperty("java.version"))
+                    .append("\",\"driver\":\"").append(meta.getDriverName()).append(' ').append(meta.getDriverVersion())
+                    .append("\",\"results\":[");
+
+            String[] names = {"n_plus_1", "join"};
+            Method[] methods = {OrdersBench::nPlus1, OrdersBench::join};
+            for (int m = 0; m < methods.length; m++) {
+                for (int i = 0; i < warmup; i++) {
+                    methods[m].run(conn, days, maxOrders);
+                }
+                List<Long> samples = new ArrayList<>();
+                Outcome outcome = new Outcome(0, 0);
+                for (int i = 0; i < runs; i++) {
+                    long start = System.nanoTime();
+                    outcome = methods[m].run(conn, days, maxOrders);
+                    samples.add(System.nanoTime() - start);
+                }
+                if (m > 0) {
+                    out.append(',');
+                }
+                out.append("{\"method\":\"").append(names[m]).append("\",\"records\":").append(outcome.records())
+                        .append(",\"statements\":").append(outcome.statements()).append(",\"samples_ns\":")
+                        .append(samples.toString().replace(" ", "")).append('}');
+            }
+        }
+        out.append("]}");
+        System.out.println(out);
+    }
+}
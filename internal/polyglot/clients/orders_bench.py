@@ -0,0 +1,131 @@
+"""受注明細の取得（N+1とJOIN）をpython-oracledbで計測する参照実装（compare-clientsコマンドが実行する）
+
+Goの ProblemOrderRepository.GetOrdersWithDetails / OptimizedOrderRepository.GetOrdersWithDetailsJoin と
+同じSQLを同じ順に実行し、結果を1つのJSONとして標準出力に書き出す。
+接続先は環境変数 DB_HOST / DB_PORT / DB_SERVICE_NAME / DB_USERNAME / DB_PASSWORD で受け取る。
+
+    python3 orders_bench.py --days 30 --max-orders 200 --runs 5 --warmup 1
+"""
+
+import argparse
+import json
+import os
+import platform
+import sys
+import time
+
+try:
+    import oracledb
+except ImportError:
+    print("python-oracledb is not installed (pip install oracledb)", file=sys.stderr)
+    sys.exit(3)
+
+DETAILS_BY_ORDER_ID = """
+        SELECT detail_id, order_id, product_id, quantity, unit_price
+        FROM order_details
+        WHERE order_id = :1
+        ORDER BY detail_id"""
+
+
+def order_window(alias, max_orders):
+    """過去N日間の受注の条件（Goの Limits.orderWindow と同じ。上限がある場合は新しい順に絞る）"""
+    prefix = alias + "." if alias else ""
+    if max_orders <= 0:
+        return prefix + "order_date >= SYSDATE - :1"
+    return prefix + """order_id IN (
+            SELECT order_id FROM orders
+            WHERE order_date >= SYSDATE - :1
+            ORDER BY order_date DESC, order_id DESC
+            FETCH FIRST :2 ROWS ONLY)"""
+
+
+def window_binds(days, max_orders):
+    return [days] if max_orders <= 0 else [days, max_orders]
+
+
+def n_plus_1(conn, days, max_orders):
+    """受注一覧を取得し、受注ごとに明細を取得する（1 + N回の実行）"""
+    statements = 0
+    records = 0
+    with conn.cursor() as cur:
+        cur.execute(
+            "SELECT order_id, customer_id, order_date, total_amount FROM orders WHERE %s ORDER BY order_id"
+            % order_window("", max_orders),
+            window_binds(days, max_orders),
+        )
+        orders = cur.fetchall()
+        statements += 1
+    for order in orders:
+        with conn.cursor() as cur:
+            cur.execute(DETAILS_BY_ORDER_ID, [order[0]])
+            records += len(cur.fetchall())
+            statements += 1
+    return records, statements
+
+
+def join(conn, days, max_orders):
+    """受注と明細をLEFT JOINで1回に取得する"""
+    records = 0
+    with conn.cursor() as cur:
+        cur.execute(
+            """
+        SELECT o.order_id, o.customer_id, o.order_date, o.total_amount,
+               od.detail_id, od.product_id, od.quantity, od.unit_price
+        FROM orders o
+        LEFT JOIN order_details od ON o.order_id = od.order_id
+        WHERE %s
+        ORDER BY o.order_id, od.detail_id"""
+            % order_window("o", max_orders),
+            window_binds(days, max_orders),
+        )
+        for row in cur:
+            if row[4] is not None:
+                records += 1
+    return records, 1
+
+
+METHODS = [("n_plus_1", n_plus_1), ("join", join)]
+
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument("--days", type=int, default=30)
+    parser.add_argument("--max-orders", type=int, default=0)
+    parser.add_argument("--runs", type=int, default=5)
+    parser.add_argument("--warmup", type=int, default=1)
+    args = parser.parse_args()
+
+    conn = oracledb.connect(
+        user=os.environ["DB_USERNAME"],
+        password=os.environ["DB_PASSWORD"],
+        dsn="%s:%s/%s" % (os.environ["DB_HOST"], os.environ["DB_PORT"], os.environ["DB_SERVICE_NAME"]),
+    )
+    try:
+        results = []
+        for name, method in METHODS:
+            for _ in range(args.warmup):
+                method(conn, args.days, args.max_orders)
+            samples = []
+            records = statements = 0
+            for _ in range(args.runs):
+                start = time.perf_counter_ns()
+                records, statements = method(conn, args.days, args.max_orders)
+                samples.append(time.perf_counter_ns() - start)
+            results.append({"method": name, "records": records, "statements": statements, "samples_ns": samples})
+    finally:
+        conn.close()
+
+    json.dump(
+        {
+            "client": "python-oracledb",
+            "runtime": "Python " + platform.python_version(),
+            "driver": "oracledb " + oracledb.__version__ + (" (thin)" if oracledb.is_thin_mode() else " (thick)"),
+            "results": results,
+        },
+        sys.stdout,
+    )
+    sys.stdout.write("\n")
+
+
+if __name__ == "__main__":
+    main()
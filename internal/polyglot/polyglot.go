@@ -0,0 +1,254 @@
+// Package polyglot - 同じ受注明細の取得（N+1とJOIN）をGo・JDBC・python-oracledbで計測して比べる（compare-clientsコマンド）
+//
+// Java・Pythonの参照実装（clients/）はバイナリに埋め込み、実行時に一時ディレクトリへ書き出してから起動する。
+// 参照実装はGoのリポジトリと同じSQLを同じ順に実行し、計測結果を1つのJSONとして標準出力に書き出す。
+// N+1のコストが言語やドライバーではなく、ラウンドトリップの数で決まることを示すのが目的。
+package polyglot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// クライアントの識別子
+const (
+	ClientGo     = "go"
+	ClientJava   = "java"
+	ClientPython = "python"
+)
+
+// 手法の識別子（参照実装の出力の method と同じ）
+const (
+	MethodNPlus1 = "n_plus_1"
+	MethodJoin   = "join"
+)
+
+// Clients - 比較できるクライアント（表示の順）
+var Clients = []string{ClientGo, ClientJava, ClientPython}
+
+// Config - 比較の設定
+type Config struct {
+	// Clients - 計測するクライアント（Clientsのいずれか）
+	Clients   []string
+	Days      int
+	MaxOrders int
+	Runs      int
+	Warmup    int
+	// Java / OJDBC - javaコマンドとojdbcのJAR（OJDBCが空の場合はJDBCを計測しない）
+	Java  string
+	OJDBC string
+	// Python - python-oracledbを導入したPythonのコマンド
+	Python string
+	// Timeout - 参照実装1つあたりの実行時間の上限（JVMの起動とウォームアップを含む）
+	Timeout time.Duration
+	// Env - 参照実装に渡す接続先の環境変数（DB_HOST=... の形式）
+	Env []string
+}
+
+// DefaultConfig - 既定の設定（N+1が現実的な時間で終わるよう受注を200件に絞る）
+func DefaultConfig() Config {
+	return Config{
+		Clients:   Clients,
+		Days:      30,
+		MaxOrders: 200,
+		Runs:      5,
+		Warmup:    1,
+		Java:      "java",
+		Python:    "python3",
+		Timeout:   10 * time.Minute,
+	}
+}
+
+// Validate - 設定の確認
+func (c Config) Validate() error {
+	if len(c.Clients) == 0 {
+		return errors.New("no clients selected")
+	}
+	for _, name := range c.Clients {
+		if !known(name) {
+			return fmt.Errorf("unknown client %q (%s)", name, strings.Join(Clients, ", "))
+		}
+	}
+	if c.Days < 1 {
+		return fmt.Errorf("days must be positive: %d", c.Days)
+	}
+	if c.MaxOrders < 0 {
+		return fmt.Errorf("max orders must not be negative: %d", c.MaxOrders)
+	}
+	if c.Runs < 1 {
+		return fmt.Errorf("runs must be positive: %d", c.Runs)
+	}
+	if c.Warmup < 0 {
+		return fmt.Errorf("warmup must not be negative: %d", c.Warmup)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive: %v", c.Timeout)
+	}
+	return nil
+}
+
+// ParseClients - カンマ区切りのクライアントの指定を解釈（空の場合はすべて）
+func ParseClients(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return Clients, nil
+	}
+	var clients []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !known(name) {
+			return nil, fmt.Errorf("unknown client %q (%s)", name, strings.Join(Clients, ", "))
+		}
+		if !seen[name] {
+			seen[name] = true
+			clients = append(clients, name)
+		}
+	}
+	return clients, nil
+}
+
+// known - 比較できるクライアントか
+func known(name string) bool {
+	for _, c := range Clients {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Output - 参照実装が標準出力に書き出すJSON（Goの計測も同じ形にまとめる）
+type Output struct {
+	Client  string         `json:"client"`
+	Runtime string         `json:"runtime"`
+	Driver  string         `json:"driver"`
+	Results []MethodResult `json:"results"`
+}
+
+// MethodResult - 手法1つの計測結果
+type MethodResult struct {
+	Method string `json:"method"`
+	// Records - 1回の実行で読んだ明細の件数
+	Records int64 `json:"records"`
+	// Statements - 1回の実行で実行した文の数（N+1では1 + 受注の数）
+	Statements int64 `json:"statements"`
+	// Samples - 各回の実行時間（ウォームアップを除く）
+	Samples []time.Duration `json:"samples_ns"`
+	Median  time.Duration   `json:"median_ns"`
+}
+
+// ParseOutput - 参照実装の出力を解析して中央値を求める
+func ParseOutput(data []byte) (Output, error) {
+	var out Output
+	if err := json.Unmarshal(data, &out); err != nil {
+		return Output{}, fmt.Errorf("failed to parse client output: %w", err)
+	}
+	if out.Client == "" {
+		return Output{}, errors.New("client output has no client name")
+	}
+	for _, method := range []string{MethodNPlus1, MethodJoin} {
+		if out.Method(method) == nil {
+			return Output{}, fmt.Errorf("client output has no %s result", method)
+		}
+	}
+	for i := range out.Results {
+		r := &out.Results[i]
+		if len(r.Samples) == 0 {
+			return Output{}, fmt.Errorf("client output has no samples for %s", r.Method)
+		}
+		r.Median = median(r.Samples)
+	}
+	return out, nil
+}
+
+// Method - 手法の計測結果（ない場合はnil）
+func (o *Output) Method(method string) *MethodResult {
+	for i := range o.Results {
+		if o.Results[i].Method == method {
+			return &o.Results[i]
+		}
+	}
+	return nil
+}
+
+// ClientReport - クライアント1つの比較結果
+type ClientReport struct {
+	// Name - クライアントの識別子（Clientsのいずれか）
+	Name string `json:"name"`
+	Output
+	// Skipped - 計測できなかった理由（コマンドやドライバーがない場合など）
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Ratio - N+1の中央値がJOINの何倍か（計測できなかった場合は0）
+func (c ClientReport) Ratio() float64 {
+	if c.Skipped != "" {
+		return 0
+	}
+	nPlus1, join := c.Method(MethodNPlus1), c.Method(MethodJoin)
+	if nPlus1 == nil || join == nil || join.Median <= 0 {
+		return 0
+	}
+	return float64(nPlus1.Median) / float64(join.Median)
+}
+
+// Report - 比較の結果
+type Report struct {
+	Days      int            `json:"days"`
+	MaxOrders int            `json:"max_orders"`
+	Runs      int            `json:"runs"`
+	Clients   []ClientReport `json:"clients"`
+}
+
+// RatioRange - 計測できたクライアントのN+1/JOINの倍率の最小と最大（計測できたクライアントがない場合はok=false）
+func (r *Report) RatioRange() (lo, hi float64, ok bool) {
+	for _, c := range r.Clients {
+		ratio := c.Ratio()
+		if ratio == 0 {
+			continue
+		}
+		if !ok || ratio < lo {
+			lo = ratio
+		}
+		if !ok || ratio > hi {
+			hi = ratio
+		}
+		ok = true
+	}
+	return lo, hi, ok
+}
+
+// RecordMismatch - 計測できたクライアントで読んだ明細の件数が食い違う手法（同じデータを読んでいなければ比較にならない）
+func (r *Report) RecordMismatch() []string {
+	var mismatched []string
+	for _, method := range []string{MethodNPlus1, MethodJoin} {
+		counts := make(map[int64]bool)
+		for _, c := range r.Clients {
+			if c.Skipped != "" {
+				continue
+			}
+			if m := c.Method(method); m != nil {
+				counts[m.Records] = true
+			}
+		}
+		if len(counts) > 1 {
+			mismatched = append(mismatched, method)
+		}
+	}
+	return mismatched
+}
+
+// median - 実行時間の中央値
+func median(samples []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,205 @@
+package polyglot
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantErr    bool
+		wantMedian time.Duration
+	}{
+		{
+			name:       "valid",
+			data:       `{"client":"jdbc","results":[{"method":"n_plus_1","records":10,"statements":4,"samples_ns":[30,10,20,40]},{"method":"join","records":10,"statements":1,"samples_ns":[5]}]}`,
+			wantMedian: 25,
+		},
+		{name: "not json", data: "Exception in thread main", wantErr: true},
+		{name: "no client", data: `{"results":[]}`, wantErr: true},
+		{name: "missing join", data: `{"client":"jdbc","results":[{"method":"n_plus_1","samples_ns":[1]}]}`, wantErr: true},
+		{name: "no samples", data: `{"client":"jdbc","results":[{"method":"n_plus_1","samples_ns":[1]},{"method":"join","samples_ns":[]}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		out, err := ParseOutput([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ParseOutput() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && out.Method(MethodNPlus1).Median != tt.wantMedian {
+			t.Errorf("%s: median = %v, want %v", tt.name, out.Method(MethodNPlus1).Median, tt.wantMedian)
+		}
+	}
+}
+
+func TestParseClients(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "", want: Clients},
+		{in: "Python, go,python", want: []string{"python", "go"}},
+		{in: "go,rust", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseClients(tt.in)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseClients(%q) = %v, %v, want %v (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	client := func(name string, nPlus1, join time.Duration, records int64) ClientReport {
+		return ClientReport{Name: name, Output: Output{Results: []MethodResult{
+			{Method: MethodNPlus1, Median: nPlus1, Records: records},
+			{Method: MethodJoin, Median: join, Records: records},
+		}}}
+	}
+	r := &Report{Clients: []ClientReport{
+		client(ClientGo, 40*time.Millisecond, 4*time.Millisecond, 500),
+		client(ClientJava, 60*time.Millisecond, 5*time.Millisecond, 500),
+		{Name: ClientPython, Skipped: "python3 が見つかりません"},
+	}}
+
+	lo, hi, ok := r.RatioRange()
+	if !ok || lo != 10 || hi != 12 {
+		t.Errorf("RatioRange() = %v, %v, %v, want 10, 12, true", lo, hi, ok)
+	}
+	if got := r.RecordMismatch(); len(got) != 0 {
+		t.Errorf("RecordMismatch() = %v, want none", got)
+	}
+
+	r.Clients[1].Results[1].Records = 499
+	if got := r.RecordMismatch(); !reflect.DeepEqual(got, []string{MethodJoin}) {
+		t.Errorf("RecordMismatch() = %v, want [join]", got)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	cfg := DefaultConfig()
+	if _, err := Command(ClientJava, "/tmp/c", cfg); err == nil {
+		t.Errorf("Command(java) without -ojdbc succeeded")
+	}
+	cfg.OJDBC = "ojdbc11.jar"
+	got, err := Command(ClientJava, "/tmp/c", cfg)
+	want := "java -cp ojdbc11.jar /tmp/c/OrdersBench.java --days 30 --max-orders 200 --runs 5 --warmup 1"
+	if err != nil || strings.Join(got, " ") != want {
+		t.Errorf("Command(java) = %q, %v, want %q", strings.Join(got, " "), err, want)
+	}
+	if _, err := Command(ClientGo, "/tmp/c", cfg); err == nil {
+		t.Errorf("Command(go) succeeded")
+	}
+}
+
+func TestErrorLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   string
+	}{
+		{
+			name:   "python traceback",
+			stderr: "Traceback (most recent call last):\n  File \"orders_bench.py\", line 1\noracledb.exceptions.DatabaseError: ORA-01017\n",
+			want:   "oracledb.exceptions.DatabaseError: ORA-01017",
+		},
+		{
+			name:   "java stack trace",
+			stderr: "Exception in thread \"main\" java.sql.SQLException: IO Error\n\tat OrdersBench.main(OrdersBench.java:127)\nCaused by: java.net.ConnectException: Connection refused\n\t... 3 more\n",
+			want:   "Caused by: java.net.ConnectException: Connection refused",
+		},
+		{name: "empty", stderr: "", want: "出力なし"},
+	}
+
+	for _, tt := range tests {
+		if got := errorLine(tt.stderr); got != tt.want {
+			t.Errorf("%s: errorLine() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// fakeOracledb - python-oracledbの代わりに、受注3件（明細は受注IDの数だけ）を返すモジュール
+const fakeOracledb = `
+__version__ = "0.0-test"
+
+def is_thin_mode():
+    return True
+
+class Cursor:
+    def __enter__(self):
+        return self
+    def __exit__(self, *args):
+        pass
+    def execute(self, sql, binds):
+        if "order_details" not in sql:
+            self.rows = [(i, 1, "2024-01-01", 10.0) for i in (1, 2, 3)]
+        elif "LEFT JOIN" in sql:
+            self.rows = [(o, 1, "2024-01-01", 10.0, d, 1, 1, 1.0) for o in (1, 2, 3) for d in range(o)]
+        else:
+            self.rows = [(d, binds[0], 1, 1, 1.0) for d in range(binds[0])]
+    def fetchall(self):
+        return self.rows
+    def __iter__(self):
+        return iter(self.rows)
+
+class Connection:
+    def cursor(self):
+        return Cursor()
+    def close(self):
+        pass
+
+def connect(user, password, dsn):
+    assert dsn == "db.example.com:1521/ORCLPDB1", dsn
+    return Connection()
+`
+
+func TestPythonClient(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not found")
+	}
+	dir := t.TempDir()
+	if err := writeClients(dir); err != nil {
+		t.Fatalf("writeClients() failed: %v", err)
+	}
+	stub := t.TempDir()
+	if err := os.WriteFile(filepath.Join(stub, "oracledb.py"), []byte(fakeOracledb), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Python = python
+	cfg.Runs = 3
+	cfg.Env = []string{
+		"PYTHONPATH=" + stub,
+		"DB_HOST=db.example.com", "DB_PORT=1521", "DB_SERVICE_NAME=ORCLPDB1", "DB_USERNAME=demo", "DB_PASSWORD=secret",
+	}
+	out, err := runClient(context.Background(), ClientPython, dir, cfg)
+	if err != nil {
+		t.Fatalf("runClient() failed: %v", err)
+	}
+	if out.Client != "python-oracledb" || out.Driver != "oracledb 0.0-test (thin)" {
+		t.Errorf("client = %q, driver = %q", out.Client, out.Driver)
+	}
+	for _, want := range []MethodResult{
+		{Method: MethodNPlus1, Records: 6, Statements: 4},
+		{Method: MethodJoin, Records: 6, Statements: 1},
+	} {
+		got := out.Method(want.Method)
+		if got.Records != want.Records || got.Statements != want.Statements || len(got.Samples) != cfg.Runs {
+			t.Errorf("%s: records = %d, statements = %d, samples = %d, want %d, %d, %d",
+				want.Method, got.Records, got.Statements, len(got.Samples), want.Records, want.Statements, cfg.Runs)
+		}
+	}
+}
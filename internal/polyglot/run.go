@@ -0,0 +1,241 @@
+package polyglot
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/models"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// clientSources - Java・Pythonの参照実装
+//
+//go:embed clients/OrdersBench.java clients/orders_bench.py
+var clientSources embed.FS
+
+// exitMissingDriver - 参照実装がドライバーを読み込めなかったときの終了コード（orders_bench.py）
+const exitMissingDriver = 3
+
+// goDriverModule - Goのドライバーのモジュール（バージョンをビルド情報から取る）
+const goDriverModule = "github.com/sijms/go-ora/v2"
+
+// Run - 設定したクライアントで計測して結果をまとめる
+//
+// Java・Pythonが起動できない場合やドライバーがない場合は、そのクライアントを計測できなかった理由とともに残して続ける。
+func Run(ctx context.Context, db *sql.DB, cfg Config) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "nplus1-clients-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	if err := writeClients(dir); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Days: cfg.Days, MaxOrders: cfg.MaxOrders, Runs: cfg.Runs}
+	for _, name := range Clients {
+		if !selected(cfg.Clients, name) {
+			continue
+		}
+		fmt.Printf("%sで計測中...\n", name)
+		var out Output
+		if name == ClientGo {
+			out, err = measureGo(ctx, db, cfg)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			out, err = runClient(ctx, name, dir, cfg)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				report.Clients = append(report.Clients, ClientReport{Name: name, Skipped: err.Error()})
+				continue
+			}
+		}
+		report.Clients = append(report.Clients, ClientReport{Name: name, Output: out})
+	}
+	return report, nil
+}
+
+// selected - クライアントが計測の対象か
+func selected(clients []string, name string) bool {
+	for _, c := range clients {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// writeClients - 埋め込みの参照実装をdirに書き出す
+func writeClients(dir string) error {
+	entries, err := clientSources.ReadDir("clients")
+	if err != nil {
+		return fmt.Errorf("failed to read embedded clients: %w", err)
+	}
+	for _, e := range entries {
+		data, err := clientSources.ReadFile("clients/" + e.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read embedded client %s: %w", e.Name(), err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, e.Name()), data, 0o644); err != nil {
+			return fmt.Errorf("failed to write client %s: %w", e.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Command - 参照実装を起動するコマンドと引数
+func Command(name, dir string, cfg Config) ([]string, error) {
+	args := []string{
+		"--days", strconv.Itoa(cfg.Days),
+		"--max-orders", strconv.Itoa(cfg.MaxOrders),
+		"--runs", strconv.Itoa(cfg.Runs),
+		"--warmup", strconv.Itoa(cfg.Warmup),
+	}
+	switch name {
+	case ClientJava:
+		if cfg.OJDBC == "" {
+			return nil, errors.New("-ojdbc でojdbcのJAR（ojdbc11.jarなど）を指定してください")
+		}
+		return append([]string{cfg.Java, "-cp", cfg.OJDBC, filepath.Join(dir, "OrdersBench.java")}, args...), nil
+	case ClientPython:
+		return append([]string{cfg.Python, filepath.Join(dir, "orders_bench.py")}, args...), nil
+	default:
+		return nil, fmt.Errorf("client %q has no reference implementation", name)
+	}
+}
+
+// runClient - 参照実装を起動して出力を解析
+func runClient(ctx context.Context, name, dir string, cfg Config) (Output, error) {
+	argv, err := Command(name, dir, cfg)
+	if err != nil {
+		return Output{}, err
+	}
+	if _, err := exec.LookPath(argv[0]); err != nil {
+		return Output{}, fmt.Errorf("%s が見つかりません", argv[0])
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), cfg.Env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Output{}, fmt.Errorf("%vで終わりませんでした", cfg.Timeout)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == exitMissingDriver {
+			return Output{}, errors.New(errorLine(stderr.String()))
+		}
+		return Output{}, fmt.Errorf("%v: %s", err, errorLine(stderr.String()))
+	}
+	return ParseOutput(stdout.Bytes())
+}
+
+// errorLine - 標準エラーのうち原因を表す行（字下げしたスタックトレースの行を除いた最後の行）
+//
+// Pythonのトレースバックは最後の行が例外、Javaのスタックトレースは字下げのない最後の行（Caused by）が根本の原因になる。
+func errorLine(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		return strings.TrimSpace(line)
+	}
+	return "出力なし"
+}
+
+// measureGo - Goのリポジトリ（N+1とJOIN）を参照実装と同じ手順で計測
+//
+// 参照実装と同じく1つの接続で実行する。
+func measureGo(ctx context.Context, db *sql.DB, cfg Config) (Output, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return Output{}, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	q := repository.NewConnDBContext(ctx, conn)
+	limits := repository.Limits{MaxOrders: cfg.MaxOrders}
+	problem := repository.NewProblemOrderRepository(q)
+	problem.SetLimits(limits)
+	optimized := repository.NewOptimizedOrderRepository(q)
+	optimized.SetLimits(limits)
+
+	methods := []struct {
+		name string
+		run  func(days int) ([]models.OrderWithDetails, error)
+		// statements - 取得した受注の数から求める実行した文の数
+		statements func(orders int) int64
+	}{
+		{MethodNPlus1, problem.GetOrdersWithDetails, func(orders int) int64 { return 1 + int64(orders) }},
+		{MethodJoin, optimized.GetOrdersWithDetailsJoin, func(int) int64 { return 1 }},
+	}
+
+	out := Output{Client: ClientGo, Runtime: runtime.Version(), Driver: goDriver()}
+	for _, m := range methods {
+		for i := 0; i < cfg.Warmup; i++ {
+			if _, err := m.run(cfg.Days); err != nil {
+				return Output{}, fmt.Errorf("%s warmup failed: %w", m.name, err)
+			}
+		}
+		result := MethodResult{Method: m.name}
+		for i := 0; i < cfg.Runs; i++ {
+			start := time.Now()
+			orders, err := m.run(cfg.Days)
+			result.Samples = append(result.Samples, time.Since(start))
+			if err != nil {
+				return Output{}, fmt.Errorf("%s failed: %w", m.name, err)
+			}
+			result.Records = countDetails(orders)
+			result.Statements = m.statements(len(orders))
+		}
+		result.Median = median(result.Samples)
+		out.Results = append(out.Results, result)
+	}
+	return out, nil
+}
+
+// countDetails - 取得した明細の件数
+func countDetails(orders []models.OrderWithDetails) int64 {
+	var n int64
+	for _, o := range orders {
+		n += int64(len(o.Details))
+	}
+	return n
+}
+
+// goDriver - Goのドライバーの名前とバージョン
+func goDriver() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == goDriverModule {
+				return "go-ora " + dep.Version
+			}
+		}
+	}
+	return "go-ora"
+}
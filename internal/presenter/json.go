@@ -0,0 +1,74 @@
+package presenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"oracle-n-plus-1-demo/internal/cache"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// jsonPresenter - 結果ごとに1行のJSON（{"kind": ..., "data": ...}）を書き出す
+type jsonPresenter struct {
+	enc *json.Encoder
+}
+
+// jsonRecord - JSON Lines の1行
+type jsonRecord struct {
+	Kind string      `json:"kind"`
+	Data interface{} `json:"data"`
+}
+
+// newJSONPresenter - JSON Lines 形式のPresenterを作成
+func newJSONPresenter(out io.Writer) *jsonPresenter {
+	return &jsonPresenter{enc: json.NewEncoder(out)}
+}
+
+// write - 1件の結果を書き出す
+func (p *jsonPresenter) write(kind string, data interface{}) error {
+	if err := p.enc.Encode(jsonRecord{Kind: kind, Data: data}); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", kind, err)
+	}
+	return nil
+}
+
+// InternalCacheTest - Oracle内蔵キャッシュテストの結果
+func (p *jsonPresenter) InternalCacheTest(test *service.InternalCacheTest) error {
+	return p.write("internal_cache_test", test)
+}
+
+// ExternalCacheTest - 外部キャッシュテストの結果
+func (p *jsonPresenter) ExternalCacheTest(test *service.ExternalCacheTest) error {
+	return p.write("external_cache_test", test)
+}
+
+// CacheComparison - キャッシュ方式の比較
+func (p *jsonPresenter) CacheComparison(comparison *service.CacheComparison) error {
+	return p.write("cache_comparison", comparison)
+}
+
+// MemoryUsage - メモリ使用状況
+func (p *jsonPresenter) MemoryUsage(usage *service.MemoryUsage) error {
+	return p.write("memory_usage", usage)
+}
+
+// Analysis - 包括的性能分析の結果
+func (p *jsonPresenter) Analysis(results *cache.AnalysisResults) error {
+	return p.write("analysis", results)
+}
+
+// ReadWriteMix - 読み書き混在ワークロードの結果
+func (p *jsonPresenter) ReadWriteMix(result *cache.ReadWriteMixResult) error {
+	return p.write("read_write_mix", result)
+}
+
+// Cost - 手法ごとの月額コストの見積もり
+func (p *jsonPresenter) Cost(report *service.CostReport) error {
+	return p.write("cost", report)
+}
+
+// Capacity - 想定リクエスト数で必要なDBリソースの見積もり
+func (p *jsonPresenter) Capacity(report *service.CapacityReport) error {
+	return p.write("capacity", report)
+}
@@ -0,0 +1,67 @@
+package presenter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/cache"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// ErrUnknownFormat - 対応していない表示形式
+var ErrUnknownFormat = errors.New("unknown format")
+
+// 表示形式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Formats - 指定できる表示形式
+var Formats = []string{FormatText, FormatJSON}
+
+// Presenter - キャッシュ計測結果の表示
+//
+// CacheService / PerformanceAnalyzer は計測結果を返すだけなので、表示形式の違いはここで吸収する。
+type Presenter interface {
+	// InternalCacheTest - Oracle内蔵キャッシュテスト（包括分析を含む）の結果
+	InternalCacheTest(test *service.InternalCacheTest) error
+	// ExternalCacheTest - 外部キャッシュ（Redis）テストの結果
+	ExternalCacheTest(test *service.ExternalCacheTest) error
+	// CacheComparison - キャッシュ方式の比較
+	CacheComparison(comparison *service.CacheComparison) error
+	// MemoryUsage - メモリ使用状況
+	MemoryUsage(usage *service.MemoryUsage) error
+	// Analysis - 包括的性能分析の結果
+	Analysis(results *cache.AnalysisResults) error
+	// ReadWriteMix - 読み書き混在ワークロードの結果
+	ReadWriteMix(result *cache.ReadWriteMixResult) error
+	// Cost - 手法ごとの月額コストの見積もり
+	Cost(report *service.CostReport) error
+	// Capacity - 想定リクエスト数で必要なDBリソースの見積もり
+	Capacity(report *service.CapacityReport) error
+}
+
+// Options - 表示の指定
+type Options struct {
+	// Table - 比較表の並べ替えと表示する列（text形式のみ）
+	Table report.TableOptions
+}
+
+// New - 表示形式を指定してPresenterを作成
+func New(format string, out io.Writer, opts Options) (Presenter, error) {
+	switch format {
+	case FormatText, "":
+		// 計測を終えてから列指定の誤りに気付かないよう、先に確認する
+		if err := newComparisonTable().Check(opts.Table); err != nil {
+			return nil, err
+		}
+		return &textPresenter{w: report.New(out), opts: opts}, nil
+	case FormatJSON:
+		return newJSONPresenter(out), nil
+	}
+	return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+}
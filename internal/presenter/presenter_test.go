@@ -0,0 +1,70 @@
+package presenter
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// sampleComparison - ヒット率を測れない方式（N/A）と全角の説明を含むキャッシュ比較
+func sampleComparison() *service.CacheComparison {
+	results := []service.CacheResult{
+		{Method: "Oracle_Result_Cache", ExecutionTime: 800 * time.Microsecond, HitRate: 95.5, Description: "Server Result Cache"},
+		{Method: "Redis_External_Cache", ExecutionTime: 2 * time.Millisecond, MemoryUsage: 4096, Description: "外部キャッシュ"},
+		{Method: "Oracle_Buffer_Cache", ExecutionTime: 1200 * time.Microsecond, HitRate: 99, Description: "Buffer Cache"},
+	}
+	return &service.CacheComparison{Results: results, FastestOracle: &results[0], Redis: &results[1]}
+}
+
+func TestCacheComparisonGolden(t *testing.T) {
+	tests := []struct {
+		format string
+		opts   Options
+		golden string
+	}{
+		{format: FormatText, golden: "cache_comparison.txt"},
+		{format: FormatText, opts: Options{Table: report.ParseTableOptions("-hit_rate", "hit_rate,method")}, golden: "cache_comparison_sorted.txt"},
+		{format: FormatJSON, golden: "cache_comparison.jsonl"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		p, err := New(tt.format, &buf, tt.opts)
+		if err != nil {
+			t.Fatalf("New(%q) failed: %v", tt.format, err)
+		}
+		if err := p.CacheComparison(sampleComparison()); err != nil {
+			t.Fatalf("%s: CacheComparison() failed: %v", tt.golden, err)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", tt.golden))
+		if err != nil {
+			t.Fatalf("ReadFile() failed: %v", err)
+		}
+		if got := buf.String(); got != string(want) {
+			t.Errorf("%s: CacheComparison() =\n%s\nwant\n%s", tt.golden, got, want)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New("yaml", &bytes.Buffer{}, Options{}); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("New(yaml) error = %v, want %v", err, ErrUnknownFormat)
+	}
+	// 列指定の誤りは計測の前に分かる
+	opts := Options{Table: report.ParseTableOptions("memory", "")}
+	if _, err := New(FormatText, &bytes.Buffer{}, opts); !errors.Is(err, report.ErrUnknownColumn) {
+		t.Errorf("New(text, -sort=memory) error = %v, want %v", err, report.ErrUnknownColumn)
+	}
+	// 空の表示形式はtextとして扱い、json形式では列指定を使わない
+	if _, err := New("", &bytes.Buffer{}, Options{}); err != nil {
+		t.Errorf("New(\"\") failed: %v", err)
+	}
+	if _, err := New(FormatJSON, &bytes.Buffer{}, opts); err != nil {
+		t.Errorf("New(json, -sort=memory) failed: %v", err)
+	}
+}
@@ -0,0 +1,915 @@
+package presenter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/cache"
+	"oracle-n-plus-1-demo/internal/costmodel"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+// shownRuns - 各回の実行時間を表示する回数（以降は平均のみ）
+const shownRuns = 3
+
+// shownRedisKeys - キーごとのRedis使用量を表示するキー数
+const shownRedisKeys = 10
+
+// fallbackTitles - 従来の計測（フォールバック）の手法ごとの見出し
+var fallbackTitles = map[string]string{
+	"Oracle_Buffer_Cache":   "Database Buffer Cache",
+	"Oracle_Result_Cache":   "Oracle Result Cache",
+	"Oracle_Function_Cache": "PL/SQL Function Result Cache",
+}
+
+// textPresenter - コンソール向けの表示
+type textPresenter struct {
+	w    *report.Writer
+	opts Options
+}
+
+// InternalCacheTest - Oracle内蔵キャッシュテストの結果を表示
+func (p *textPresenter) InternalCacheTest(test *service.InternalCacheTest) error {
+	w := p.w
+	w.Line("=== Oracle内蔵キャッシュ詳細性能テスト ===")
+
+	if !test.Fallback() {
+		w.Linef("新しい詳細分析エンジンを使用（%d回実行）", test.Runs)
+		w.Blank()
+		if err := p.Analysis(test.Analysis); err != nil {
+			return err
+		}
+		w.Blank()
+		w.Line("✅ Oracle内蔵キャッシュ詳細分析が完了しました")
+		w.Line("より詳細な分析結果は包括的レポートで確認できます")
+		return nil
+	}
+
+	w.Linef("包括的分析でエラーが発生したため、従来の分析を実行しました: %s", test.AnalysisError)
+	w.Line("=== Oracle内蔵キャッシュ基本性能テスト（フォールバック）===")
+	for _, result := range test.Results {
+		title, ok := fallbackTitles[result.Method]
+		if !ok {
+			title = result.Method
+		}
+		w.Blank()
+		w.Linef("--- %s テスト ---", title)
+		p.runDurations(result.RunDurations, func(int) string { return "" })
+		w.Linef("平均実行時間: %v", result.ExecutionTime)
+		if result.Method == "Oracle_Buffer_Cache" {
+			w.Linef("推定キャッシュヒット率: %.1f%%", result.HitRate)
+		}
+	}
+	if stats := test.Stats; stats != nil {
+		if stats.BufferHitRatio != nil {
+			w.Linef("Database Buffer Cache ヒット率: %.2f%%", *stats.BufferHitRatio)
+		}
+		if stats.ResultCacheObjects != nil && stats.ResultCacheBlocks != nil {
+			w.Linef("Result Cache: %d個のオブジェクト, %dブロック使用", *stats.ResultCacheObjects, *stats.ResultCacheBlocks)
+		}
+		for _, msg := range stats.Errors {
+			w.Line(msg)
+		}
+	}
+	for _, msg := range test.Warnings {
+		w.Line(msg)
+	}
+	return nil
+}
+
+// ExternalCacheTest - 外部キャッシュ（Redis）テストの結果を表示
+func (p *textPresenter) ExternalCacheTest(test *service.ExternalCacheTest) error {
+	w := p.w
+	if !test.Available {
+		w.Line("=== 外部キャッシュ（Redis）テスト ===")
+		w.Line("Redis接続が利用できないため、外部キャッシュテストをスキップします。")
+		return nil
+	}
+
+	w.Line("=== 外部キャッシュ（Redis）性能テスト ===")
+	w.Blank()
+	w.Line("--- Redis外部キャッシュ テスト ---")
+	if result := test.Result; result != nil {
+		p.runDurations(result.RunDurations, func(i int) string {
+			if i < len(result.RunHits) && result.RunHits[i] {
+				return "キャッシュヒット"
+			}
+			return "データベース + キャッシュ保存"
+		})
+		w.Linef("平均実行時間: %v", result.ExecutionTime)
+		w.Linef("キャッシュヒット率: %.1f%%", result.HitRate)
+	}
+	if test.Memory != nil {
+		p.redisMemory(test.Memory)
+	} else if test.UsedMemory != "" {
+		w.Linef("Redis使用メモリ: %s", test.UsedMemory)
+	}
+	if t := test.TwoTier; t != nil {
+		p.twoTierCache(t)
+	}
+	for _, msg := range test.Warnings {
+		w.Line(msg)
+	}
+	return nil
+}
+
+// twoTierCache - 2層キャッシュ（ローカルLRU + Redis）テストの結果と層ごとのヒットを表示
+func (p *textPresenter) twoTierCache(t *service.TwoTierCacheTest) {
+	w := p.w
+	w.Blank()
+	w.Line("--- 2層キャッシュ（ローカルLRU + Redis） テスト ---")
+	p.runDurations(t.Result.RunDurations, func(i int) string {
+		if i >= len(t.RunTiers) {
+			return ""
+		}
+		switch t.RunTiers[i] {
+		case service.TierLocal:
+			return "ローカルヒット"
+		case service.TierRedis:
+			return "Redisヒット（ローカルへ昇格）"
+		}
+		return "データベース + 両方の層へ保存"
+	})
+	w.Linef("平均実行時間: %v", t.Result.ExecutionTime)
+	w.Linef("キャッシュヒット率: %.1f%%（ローカル %.1f%%, Redis %.1f%%）",
+		t.Result.HitRate, t.Stats.TierHitRate(service.TierLocal), t.Stats.TierHitRate(service.TierRedis))
+	w.Linef("昇格: %d回, 降格: %d回（追い出し %d回）, ローカルの層: %d件・%s",
+		t.Stats.Promotions, t.Stats.Demotions, t.Stats.Evictions, t.Stats.LocalEntries, report.FormatBytes(t.Stats.LocalBytes))
+}
+
+// ReadWriteMix - 読み書き混在ワークロードの結果を表示
+func (p *textPresenter) ReadWriteMix(result *cache.ReadWriteMixResult) error {
+	w := p.w
+	w.Heading("読み書き混在ワークロード")
+	w.Linef("操作数: %d/手法, 読み取り:書き込み = %.0f:%.0f, 顧客数: %d, シード: %d",
+		result.Operations, (1-result.WriteRatio)*100, result.WriteRatio*100, result.Customers, result.Seed)
+	w.Blank()
+
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "キャッシュ方式"},
+		report.Column{Key: "reads", Header: "読み取り", Align: report.AlignRight},
+		report.Column{Key: "writes", Header: "書き込み", Align: report.AlignRight},
+		report.Column{Key: "hit_rate", Header: "実効ヒット率", Align: report.AlignRight},
+		report.Column{Key: "consistency", Header: "整合性", Align: report.AlignRight},
+		report.Column{Key: "stale", Header: "古い値", Align: report.AlignRight},
+		report.Column{Key: "mean_read", Header: "読み取り平均", Align: report.AlignRight},
+		report.Column{Key: "p95_read", Header: "読み取りp95", Align: report.AlignRight},
+		report.Column{Key: "mean_write", Header: "書き込み平均", Align: report.AlignRight},
+	)
+	estimated := false
+	for _, sr := range result.Strategies {
+		hitRate := fmt.Sprintf("%.1f%%", sr.HitRate)
+		if sr.HitEstimated {
+			hitRate += "*"
+			estimated = true
+		}
+		table.AddRow(
+			report.Text(sr.Strategy),
+			report.Int(int64(sr.Reads)),
+			report.Int(int64(sr.Writes)),
+			report.Number(hitRate, sr.HitRate),
+			report.Float("%.1f%%", sr.Consistency),
+			report.Int(int64(sr.StaleReads)),
+			report.Duration(sr.MeanRead),
+			report.Duration(sr.P95Read),
+			report.Duration(sr.MeanWrite))
+	}
+	w.Table(table)
+
+	if estimated {
+		w.Line("* Result Cacheのヒットは、依存する表（orders）の更新で結果がすべて無効化されることから推定しています")
+	}
+	w.Line("整合性は、読み取った値がキャッシュを通さずに取得したDBの最新値と一致した割合です")
+	for _, sr := range result.Strategies {
+		if sr.StaleReads > 0 {
+			w.Linef("⚠️  %s: %d件の読み取りが更新前の古い値を返しました（%s）", sr.Strategy, sr.StaleReads, sr.Description)
+		}
+	}
+	return nil
+}
+
+// Cost - 手法ごとの月額コストを表示（シナリオごとに先頭の手法を基準とした比率を付ける）
+func (p *textPresenter) Cost(cost *service.CostReport) error {
+	w := p.w
+	m := cost.Model
+	w.Heading("月額コストの見積もり")
+	w.Linef("想定ワークロード: %.1f req/s（%.0f req/月）, 単価: DB CPU %g %s/秒, Redis %g %s/時間 × %d台, 転送 %g %s/GB",
+		m.RequestsPerSecond, m.RequestsPerMonth(), m.DBCPUSecondPrice, m.Currency,
+		m.RedisHourlyPrice, m.Currency, m.RedisInstances, m.EgressPerGB, m.Currency)
+	w.Blank()
+
+	table := report.NewTable(
+		report.Column{Key: "scenario", Header: "シナリオ"},
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "cpu", Header: "DB CPU/req", Align: report.AlignRight},
+		report.Column{Key: "egress", Header: "転送量/req", Align: report.AlignRight},
+		report.Column{Key: "cpu_monthly", Header: "DB CPU/月", Align: report.AlignRight},
+		report.Column{Key: "redis_monthly", Header: "Redis/月", Align: report.AlignRight},
+		report.Column{Key: "egress_monthly", Header: "転送/月", Align: report.AlignRight},
+		report.Column{Key: "total", Header: "合計/月", Align: report.AlignRight},
+		report.Column{Key: "ratio", Header: "基準比", Align: report.AlignRight},
+	)
+	estimated, unknown := false, false
+	var baseline costmodel.Estimate
+	for i, e := range cost.Estimates {
+		if i == 0 || e.Scenario != cost.Estimates[i-1].Scenario {
+			baseline = e
+		}
+		cpu := fmt.Sprintf("%.2fms", e.DBCPUSecondsPerRequest*1000)
+		if e.CPUEstimated {
+			cpu += "*"
+			estimated = true
+		}
+		egress := report.Bytes(e.EgressBytesPerRequest)
+		if e.EgressUnknown {
+			egress = report.Text("-")
+			unknown = true
+		}
+		ratio := report.Text("-")
+		if baseline.Total > 0 {
+			ratio = report.Float("%.2fx", e.Total/baseline.Total)
+		}
+		table.AddRow(
+			report.Text(e.Scenario),
+			report.Text(e.Method),
+			report.Number(cpu, e.DBCPUSecondsPerRequest),
+			egress,
+			report.Float("%.2f", e.DBCPU),
+			report.Float("%.2f", e.Redis),
+			report.Float("%.2f", e.Egress),
+			report.Float("%.2f", e.Total),
+			ratio)
+	}
+	w.Table(table)
+
+	if estimated {
+		w.Line("* CPU時間を計測していないため実行時間を上限として使っています（-session-stats で V$MYSTAT のCPU時間を使います）")
+	}
+	if unknown {
+		w.Line("- 転送量を計測していない手法は転送コストを0としています")
+	}
+	w.Linef("金額は %s、1か月 = %d時間で換算した目安です。単価は -cost-model で指定したファイルの値を使います", m.Currency, costmodel.HoursPerMonth)
+	return nil
+}
+
+// Capacity - 想定リクエスト数で必要なDBリソースを表示し、シナリオごとに基準（先頭の手法）と
+// 必要なCPUが最も少ない手法を比べる
+func (p *textPresenter) Capacity(capacity *service.CapacityReport) error {
+	w := p.w
+	w.Heading("容量見積もり")
+	w.Linef("想定ワークロード: %.1f req/s（1リクエスト = 手法の1回の実行）", capacity.RequestsPerSecond)
+	w.Blank()
+
+	table := report.NewTable(
+		report.Column{Key: "scenario", Header: "シナリオ"},
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "cpu", Header: "DB CPU（コア）", Align: report.AlignRight},
+		report.Column{Key: "roundtrips", Header: "ラウンドトリップ/秒", Align: report.AlignRight},
+		report.Column{Key: "executions", Header: "SQL実行/秒", Align: report.AlignRight},
+		report.Column{Key: "egress", Header: "転送量/秒", Align: report.AlignRight},
+	)
+	estimated, unavailable := false, false
+	for _, e := range capacity.Projections {
+		cpu := fmt.Sprintf("%.2f", e.DBCPUCores)
+		if e.CPUEstimated {
+			cpu += "*"
+			estimated = true
+		}
+		roundtrips, executions, egress := report.Text("-"), report.Text("-"), report.Text("-")
+		if e.StatsUnavailable {
+			unavailable = true
+		} else {
+			roundtrips = report.Float("%.0f", e.RoundTripsPerSecond)
+			executions = report.Float("%.0f", e.ExecutionsPerSecond)
+			egress = report.Bytes(int64(e.EgressBytesPerSecond))
+		}
+		table.AddRow(
+			report.Text(e.Scenario),
+			report.Text(e.Method),
+			report.Number(cpu, e.DBCPUCores),
+			roundtrips,
+			executions,
+			egress)
+	}
+	w.Table(table)
+
+	for _, s := range capacitySummaries(capacity.Projections) {
+		w.Linef("%s: %.0f req/s では %s が %s、%s なら %s",
+			s.baseline.Scenario, capacity.RequestsPerSecond,
+			s.baseline.Method, capacityStatement(s.baseline), s.best.Method, capacityStatement(s.best))
+	}
+	if estimated {
+		w.Line("* CPU時間を計測していないため実行時間を上限として使っています（-session-stats で V$MYSTAT のCPU時間を使います）")
+	}
+	if unavailable {
+		w.Line("- ラウンドトリップ・SQL実行・転送量は -session-stats 指定時のみ見積もります")
+	}
+	return nil
+}
+
+// capacitySummary - シナリオの基準の手法と必要なCPUが最も少ない手法
+type capacitySummary struct {
+	baseline, best service.CapacityEstimate
+}
+
+// capacitySummaries - シナリオごとに基準（先頭の手法）と最も少ないCPUで済む手法を組にする（手法が1つのシナリオは除く）
+func capacitySummaries(projections []service.CapacityEstimate) []capacitySummary {
+	var summaries []capacitySummary
+	for i := 0; i < len(projections); {
+		j := i + 1
+		best := j
+		for ; j < len(projections) && projections[j].Scenario == projections[i].Scenario; j++ {
+			if projections[j].DBCPUCores < projections[best].DBCPUCores {
+				best = j
+			}
+		}
+		if j > i+1 {
+			summaries = append(summaries, capacitySummary{baseline: projections[i], best: projections[best]})
+		}
+		i = j
+	}
+	return summaries
+}
+
+// capacityStatement - 必要なDBリソースを1文で表す
+func capacityStatement(e service.CapacityEstimate) string {
+	statement := fmt.Sprintf("DB CPU %.2fコア", e.DBCPUCores)
+	if !e.StatsUnavailable {
+		statement += fmt.Sprintf("・ラウンドトリップ %.0f回/秒", e.RoundTripsPerSecond)
+	}
+	return statement
+}
+
+// redisMemory - テスト前後のRedisの使用メモリとデモのキーごとの使用量を表示
+func (p *textPresenter) redisMemory(m *service.RedisMemory) {
+	w := p.w
+	if m.Before != nil && m.After != nil {
+		delta, _ := m.Delta()
+		w.Linef("Redis使用メモリ: %s → %s（%+d bytes）, キー数: %d → %d",
+			report.FormatBytes(m.Before.UsedMemory), report.FormatBytes(m.After.UsedMemory), delta, m.Before.Keys, m.After.Keys)
+	} else if m.After != nil {
+		w.Linef("Redis使用メモリ: %s, キー数: %d", report.FormatBytes(m.After.UsedMemory), m.After.Keys)
+	}
+	if len(m.Keys) == 0 {
+		return
+	}
+
+	table := report.NewTable(
+		report.Column{Key: "key", Header: "キー"},
+		report.Column{Key: "bytes", Header: "使用量", Align: report.AlignRight},
+	)
+	for i, k := range m.Keys {
+		if i >= shownRedisKeys {
+			break
+		}
+		table.AddRow(report.Text(k.Key), report.Bytes(k.Bytes))
+	}
+	w.Linef("デモのキーの使用量（MEMORY USAGE）: %d個, 合計 %s", len(m.Keys), report.FormatBytes(m.KeyBytes()))
+	w.IndentTable(1, table)
+	if len(m.Keys) > shownRedisKeys {
+		w.Indentf(1, "…ほか%d個", len(m.Keys)-shownRedisKeys)
+	}
+	if m.KeysTruncated {
+		w.Indentf(1, "キーが多いため先頭の%d個のみ集計しました", len(m.Keys))
+	}
+}
+
+// runDurations - 最初の数回の実行時間を表示（noteが空でなければ括弧書きで添える）
+func (p *textPresenter) runDurations(durations []time.Duration, note func(i int) string) {
+	for i, d := range durations {
+		if i >= shownRuns {
+			break
+		}
+		n := note(i)
+		if i == 0 && n == "" {
+			n = "キャッシュなし"
+		}
+		label := fmt.Sprintf("%d回目実行時間", i+1)
+		if i == 0 {
+			label = "初回実行時間"
+		}
+		if n == "" {
+			p.w.Linef("%s: %v", label, d)
+			continue
+		}
+		p.w.Linef("%s: %v (%s)", label, d, n)
+	}
+}
+
+// CacheComparison - キャッシュ比較結果を表示
+func (p *textPresenter) CacheComparison(comparison *service.CacheComparison) error {
+	w := p.w
+	w.Heading("キャッシュ性能比較結果")
+
+	// 結果を表形式で表示（ヒット率を測れない方式は N/A とし、並べ替えでは末尾に置く）
+	table := newComparisonTable()
+	for _, result := range comparison.Results {
+		hitRate := report.Float("%.1f%%", result.HitRate)
+		if result.HitRate == 0 {
+			hitRate = report.Text("N/A")
+		}
+		table.AddRow(
+			report.Text(result.Method),
+			report.Duration(result.ExecutionTime),
+			hitRate,
+			report.Text(result.Description))
+	}
+	if err := table.Apply(p.opts.Table); err != nil {
+		return err
+	}
+	w.Table(table)
+
+	// 性能分析
+	p.cachePerformance(comparison)
+	return nil
+}
+
+// newComparisonTable - キャッシュ比較表（列定義のみ）
+func newComparisonTable() *report.Table {
+	return report.NewTable(
+		report.Column{Key: "method", Header: "キャッシュ方式"},
+		report.Column{Key: "time", Header: "平均実行時間", Align: report.AlignRight},
+		report.Column{Key: "hit_rate", Header: "ヒット率", Align: report.AlignRight},
+		report.Column{Key: "description", Header: "説明"},
+	)
+}
+
+// cachePerformance - 性能分析とOracle内蔵キャッシュの優位性を説明
+func (p *textPresenter) cachePerformance(comparison *service.CacheComparison) {
+	w := p.w
+	w.Heading("性能分析結果")
+
+	w.Step(1, "Oracle内蔵キャッシュの優位性")
+	w.Bullets(1, "✓", []string{
+		"データの移動が不要（メモリ効率）",
+		"シリアライゼーション/デシリアライゼーション不要",
+		"ネットワークI/Oなし",
+		"自動的なキャッシュ無効化とデータ整合性",
+		"複数レベルのキャッシュ（Buffer Cache + Result Cache + Function Cache）",
+	})
+
+	if comparison.Redis != nil && comparison.FastestOracle != nil {
+		w.Step(2, "外部キャッシュ（Redis）の課題")
+		w.Bullets(1, "✗", []string{
+			"ネットワーク通信のオーバーヘッド",
+			"JSONシリアライゼーション/デシリアライゼーションのコスト",
+			"データ整合性管理の複雑さ",
+			"追加のインフラストラクチャとメンテナンス",
+			"メモリの二重使用（Oracle + Redis）",
+		})
+
+		// 最速のOracle結果と比較
+		if speedup, ok := comparison.Speedup(); ok {
+			w.Step(3, "性能比較結果")
+			w.Indentf(1, "Oracle内蔵キャッシュ(%s): %v", comparison.FastestOracle.Method, comparison.FastestOracle.ExecutionTime)
+			w.Indentf(1, "Redis外部キャッシュ: %v", comparison.Redis.ExecutionTime)
+			w.Indentf(1, "Oracle内蔵キャッシュが%.1fx高速", speedup)
+		}
+	}
+
+	w.Step(4, "推奨事項")
+	w.Indentf(1, "→ Oracle環境ではDatabase固有のキャッシュメカニズムを最大限活用する")
+	w.Indentf(1, "→ 外部キャッシュは以下の場合のみ検討:")
+	w.Bullets(2, "-", []string{
+		"マイクロサービス間でのデータ共有",
+		"外部APIからの取得データ",
+		"Oracleでカバーできない計算集約的な結果",
+	})
+	w.Indentf(1, "→ N+1問題はSQL設計の改善で根本的に解決する")
+}
+
+// MemoryUsage - メモリ使用量比較を表示
+func (p *textPresenter) MemoryUsage(usage *service.MemoryUsage) error {
+	w := p.w
+	w.Heading("メモリ使用量分析")
+
+	// キャッシュ方式ごとの使用量（取得できない方式は N/A）
+	if len(usage.Results) > 0 {
+		table := report.NewTable(
+			report.Column{Key: "method", Header: "キャッシュ方式"},
+			report.Column{Key: "memory", Header: "メモリ使用量", Align: report.AlignRight},
+			report.Column{Key: "description", Header: "説明"},
+		)
+		for _, result := range usage.Results {
+			memory := report.Bytes(result.MemoryUsage)
+			if result.MemoryUsage == 0 {
+				memory = report.Text("N/A")
+			}
+			table.AddRow(report.Text(result.Method), memory, report.Text(result.Description))
+		}
+		w.Table(table)
+	}
+
+	if usage.SGAError != "" {
+		w.Linef("Oracle SGA情報取得でエラー: %s", usage.SGAError)
+	} else if len(usage.SGA) > 0 {
+		w.Blank()
+		w.Line("Oracle SGA構成:")
+		var before *service.OracleMemorySnapshot
+		if usage.Oracle != nil {
+			before = usage.Oracle.Before
+		}
+		for _, c := range usage.SGA {
+			if b, ok := before.Component(c.Component); ok && b != c.Bytes {
+				w.Indentf(1, "%s: %.1f MB（テスト前 %.1f MB）", c.Component, c.SizeMB, float64(b)/(1024*1024))
+				continue
+			}
+			w.Indentf(1, "%s: %.1f MB", c.Component, c.SizeMB)
+		}
+	}
+
+	if m := usage.Oracle; m != nil && m.Before != nil && m.After != nil {
+		w.Blank()
+		w.Line("Oracle内蔵キャッシュ（テスト前 → テスト後）:")
+		if m.Before.ResultCacheBytes != nil && m.After.ResultCacheBytes != nil {
+			w.Indentf(1, "Result Cache: %s → %s（%+d bytes）",
+				report.FormatBytes(*m.Before.ResultCacheBytes), report.FormatBytes(*m.After.ResultCacheBytes),
+				*m.After.ResultCacheBytes-*m.Before.ResultCacheBytes)
+		}
+		if m.Before.SessionPGABytes != nil && m.After.SessionPGABytes != nil {
+			w.Indentf(1, "セッションPGA（接続ユーザーの全セッション）: %s → %s（%+d bytes）",
+				report.FormatBytes(*m.Before.SessionPGABytes), report.FormatBytes(*m.After.SessionPGABytes),
+				*m.After.SessionPGABytes-*m.Before.SessionPGABytes)
+		}
+		for _, msg := range m.After.Errors {
+			w.Indentf(1, "取得できません: %s", msg)
+		}
+	}
+
+	if usage.RedisAvailable {
+		w.Blank()
+		w.Line("Redis外部キャッシュ（テスト前 → テスト後）:")
+		if m := usage.Redis; m != nil && m.Before != nil && m.After != nil {
+			delta, _ := m.Delta()
+			w.Indentf(1, "使用メモリ: %s → %s（%+d bytes）", report.FormatBytes(m.Before.UsedMemory), report.FormatBytes(m.After.UsedMemory), delta)
+			w.Indentf(1, "キー数: %d → %d", m.Before.Keys, m.After.Keys)
+			if len(m.Keys) > 0 {
+				w.Indentf(1, "デモのキー: %d個, 合計 %s", len(m.Keys), report.FormatBytes(m.KeyBytes()))
+			}
+		} else {
+			w.Indentf(1, "使用メモリを取得できませんでした")
+		}
+		w.Indentf(1, "Redisのメモリはキャッシュしたデータの複製で、Oracleのバッファキャッシュとは別に確保されます")
+	}
+	return nil
+}
+
+// Analysis - 包括的性能分析の計測経過と結果を表示
+func (p *textPresenter) Analysis(results *cache.AnalysisResults) error {
+	w := p.w
+	w.Heading("Oracle内蔵キャッシュ包括的性能分析")
+	if results.BufferCacheTest != nil {
+		w.Linef("実行回数: %d回", results.BufferCacheTest.Runs)
+	}
+	w.Line("分析項目: Buffer Cache, Result Cache, 統合効率性, 外部キャッシュ比較")
+	w.Line(strings.Repeat("=", 70))
+
+	if results.BufferCacheTest != nil {
+		w.Blank()
+		w.Line("1. Database Buffer Cache分析")
+		p.bufferCacheTest(results.BufferCacheTest)
+	}
+	if results.ResultCacheTest != nil {
+		w.Blank()
+		w.Line("2. Server Result Cache分析")
+		p.resultCacheTest(results.ResultCacheTest)
+	}
+
+	if len(results.Backends) > 0 {
+		w.Blank()
+		w.Line("3. 独自のキャッシュ実装")
+		p.backendResults(results.Backends)
+	}
+
+	p.comprehensiveResults(results)
+	return nil
+}
+
+// backendResults - 独自のキャッシュ実装（pkg/cache.Backend）の計測結果を表示
+func (p *textPresenter) backendResults(backends []cache.BackendResult) {
+	w := p.w
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "backend", Header: "登録名"},
+		report.Column{Key: "time", Header: "平均実行時間", Align: report.AlignRight},
+		report.Column{Key: "hit_rate", Header: "ヒット率", Align: report.AlignRight},
+		report.Column{Key: "memory", Header: "メモリ", Align: report.AlignRight},
+	)
+	for _, b := range backends {
+		if b.Error != "" {
+			continue
+		}
+		memory := report.Text("N/A")
+		if b.MemoryUsage > 0 {
+			memory = report.Bytes(b.MemoryUsage)
+		}
+		table.AddRow(
+			report.Text(b.Name),
+			report.Text(b.Backend),
+			report.Duration(b.AverageTime),
+			report.Float("%.1f%%", b.HitRate),
+			memory)
+	}
+	w.IndentTable(1, table)
+	for _, b := range backends {
+		if b.Error != "" {
+			w.Indentf(1, "⚠️  %s（%s）: %s", b.Name, b.Backend, b.Error)
+		}
+	}
+}
+
+// bufferCacheTest - Buffer Cache性能テストの結果を表示
+func (p *textPresenter) bufferCacheTest(t *cache.BufferCacheTest) {
+	w := p.w
+	w.Line("=== Oracle Database Buffer Cache 詳細性能テスト ===")
+	w.Linef("実行回数: %d回", t.Runs)
+	w.Blank()
+
+	w.Line("1. Buffer Cache初期状態:")
+	p.bufferMetrics(t.Initial)
+	p.numberedRunDurations(t.RunDurations)
+
+	w.Step(2, "Buffer Cache最終状態")
+	p.bufferMetrics(t.Final)
+
+	w.Step(3, "Buffer Cacheテスト期間中の差分メトリクス")
+	if d := t.Delta; d != nil {
+		w.Indentf(1, "テスト期間中のヒット率: %.2f%%", d.HitRatio)
+		w.Indentf(1, "追加物理読み取り: %d", d.PhysicalReads)
+		w.Indentf(1, "追加論理読み取り: %d", d.LogicalReads)
+		w.Indentf(1, "追加DB Block Gets: %d", d.DbBlockGets)
+		w.Indentf(1, "追加Consistent Gets: %d", d.ConsistentGets)
+		w.Indentf(1, "平均実行時間: %v", d.TestExecutionTime)
+	}
+	if efficiency, ok := t.Efficiency(); ok {
+		w.Indentf(1, "Buffer Cache効率: %.2f%% (キャッシュから提供された割合)", efficiency)
+	}
+
+	w.Step(4, "Buffer Cache効率性分析")
+	if len(t.Pools) > 0 {
+		w.Indentf(1, "Buffer Pool構成:")
+		for _, pool := range t.Pools {
+			w.Indentf(2, "%s: %.1f MB (ブロックサイズ: %d bytes)", pool.Name, pool.SizeMB, pool.BlockSize)
+		}
+	}
+	if len(t.WaitEvents) > 0 {
+		w.Indentf(1, "主要な待機イベント（Buffer Cache関連）:")
+		for _, e := range t.WaitEvents {
+			w.Indentf(2, "%s: %d回 (平均%.2fms)", e.Event, e.TotalWaits, e.AvgWaitMs)
+		}
+	}
+	if len(t.Pools) > 0 {
+		w.Indentf(1, "Buffer Cache Advisory (推奨サイズ分析):")
+		for _, a := range t.Advice {
+			w.Indentf(2, "サイズ係数%.1fx: %.1fMB → 物理読み取り係数%.2fx (%s)",
+				a.SizeFactor, float64(a.SizeForEstimate)/(1024*1024), a.PhysicalReadFactor, a.Assessment())
+		}
+		if len(t.Advice) == 0 {
+			w.Indentf(2, "Buffer Cache Advisoryデータが利用できません")
+		}
+	}
+	for _, msg := range t.Warnings {
+		w.Indentf(1, "%s", msg)
+	}
+}
+
+// bufferMetrics - Buffer Cacheメトリクスを表示
+func (p *textPresenter) bufferMetrics(m *cache.BufferCacheMetrics) {
+	if m == nil {
+		return
+	}
+	w := p.w
+	w.Indentf(1, "Buffer Cache ヒット率: %.2f%%", m.HitRatio)
+	w.Indentf(1, "物理読み取り: %d", m.PhysicalReads)
+	w.Indentf(1, "論理読み取り: %d", m.LogicalReads)
+	w.Indentf(1, "DB Block Gets: %d", m.DbBlockGets)
+	w.Indentf(1, "Consistent Gets: %d", m.ConsistentGets)
+	w.Indentf(1, "Free Buffer待機: %d", m.FreeBufferWaits)
+	w.Indentf(1, "Buffer Busy待機: %d", m.BufferBusyWaits)
+	if m.TotalSizeBytes > 0 {
+		w.Indentf(1, "Buffer Cacheサイズ: %.2f MB", float64(m.TotalSizeBytes)/(1024*1024))
+	}
+}
+
+// resultCacheTest - Result Cache性能テストの結果を表示
+func (p *textPresenter) resultCacheTest(t *cache.ResultCacheTest) {
+	w := p.w
+	w.Line("=== Oracle Server Result Cache 詳細性能テスト ===")
+	w.Linef("実行回数: %d回", t.Runs)
+	w.Blank()
+
+	w.Line("Result Cache機能状態確認:")
+	w.Indentf(1, "実行時間の変化でキャッシュ効果を測定します")
+	w.Indentf(1, "V$ビューへのアクセス権限は一般アプリでは不要です")
+	w.Blank()
+
+	w.Line("1. Result Cache初期状態:")
+	p.resultMetrics(t.Initial)
+	p.numberedRunDurations(t.RunDurations)
+
+	w.Step(2, "Result Cache最終状態")
+	p.resultMetrics(t.Final)
+
+	w.Step(3, "Result Cacheテスト期間中の差分メトリクス")
+	if d := t.Delta; d != nil {
+		w.KeyValue(1, "テスト期間中のヒット率", fmt.Sprintf("%.2f%%", d.HitRatio))
+		w.KeyValue(1, "新規キャッシュオブジェクト", d.ObjectCount)
+		w.KeyValue(1, "追加メモリ使用量", fmt.Sprintf("%.2f MB", float64(d.MemoryUsage)/(1024*1024)))
+		w.KeyValue(1, "推定キャッシュヒット", fmt.Sprintf("%d回", d.CacheHits))
+		w.KeyValue(1, "推定キャッシュミス", fmt.Sprintf("%d回", d.CacheMisses))
+		w.KeyValue(1, "平均実行時間", d.TestExecutionTime)
+	}
+	if efficiency, ok := t.Efficiency(); ok {
+		w.KeyValue(1, "Result Cache効率", fmt.Sprintf("%.2f%% (ヒット率)", efficiency))
+	}
+
+	w.Step(4, "Result Cache効率性分析")
+	w.Indentf(1, "実行時間測定により十分な効果確認が可能")
+	w.Indentf(1, "V$ビューアクセスは管理者専用機能です")
+
+	w.Step(5, "Result Cacheオブジェクト詳細")
+	w.Indentf(1, "実行時間の差でキャッシュ効果を判定できます")
+	w.Indentf(1, "V$ビューアクセスは管理者専用機能です")
+}
+
+// resultMetrics - Result Cacheメトリクスを表示
+func (p *textPresenter) resultMetrics(m *cache.ResultCacheMetrics) {
+	if m == nil {
+		return
+	}
+	w := p.w
+	w.KeyValue(1, "Result Cacheヒット率", fmt.Sprintf("%.2f%%", m.HitRatio))
+	w.KeyValue(1, "キャッシュオブジェクト数", m.ObjectCount)
+	w.KeyValue(1, "使用ブロック数", m.BlockCount)
+	w.KeyValue(1, "メモリ使用量", fmt.Sprintf("%.2f MB", float64(m.MemoryUsage)/(1024*1024)))
+	w.KeyValue(1, "作成オブジェクト数", m.CreatedObjects)
+	w.KeyValue(1, "無効化依存関係数", m.InvalidationDependencies)
+}
+
+// numberedRunDurations - 最初の数回の実行時間を「N回目実行時間」で表示
+func (p *textPresenter) numberedRunDurations(durations []time.Duration) {
+	for i, d := range durations {
+		if i >= shownRuns {
+			break
+		}
+		p.w.Linef("%d回目実行時間: %v", i+1, d)
+	}
+}
+
+// comprehensiveResults - 包括的分析結果を表示
+func (p *textPresenter) comprehensiveResults(results *cache.AnalysisResults) {
+	p.w.Title("Oracle内蔵キャッシュ vs 外部キャッシュ 包括的分析結果")
+
+	// 1. エグゼクティブサマリー
+	p.executiveSummary(results)
+
+	// 2. 性能メトリクス比較
+	p.performanceMetrics(results)
+
+	// 3. 効率性分析
+	p.efficiencyAnalysis(results)
+
+	// 4. 推奨事項
+	p.recommendations(results)
+
+	// 5. 結論
+	p.conclusion(results)
+}
+
+// executiveSummary - エグゼクティブサマリーを表示
+func (p *textPresenter) executiveSummary(results *cache.AnalysisResults) {
+	w := p.w
+	w.Section("エグゼクティブサマリー")
+
+	efficiency := results.PerformanceComparison.EfficiencyMetrics.OverallCacheEfficiency
+
+	if efficiency >= 90 {
+		w.Line("✅ 総合評価: 優秀（90%以上の効率性）")
+		w.Indentf(1, "Oracle内蔵キャッシュが効果的に機能しています")
+	} else if efficiency >= 70 {
+		w.Line("⚠️  総合評価: 良好（70-90%の効率性）")
+		w.Indentf(1, "改善の余地がありますが、基本的な機能は正常です")
+	} else {
+		w.Line("❌ 総合評価: 要改善（70%未満の効率性）")
+		w.Indentf(1, "早急な最適化が必要です")
+	}
+
+	w.Blank()
+	w.Bullets(0, "•", []string{
+		fmt.Sprintf("総合キャッシュ効率: %.1f%%", efficiency),
+		fmt.Sprintf("Buffer Cache効率: %.1f%%", results.PerformanceComparison.EfficiencyMetrics.BufferCacheEfficiency),
+		fmt.Sprintf("Result Cache効率: %.1f%%", results.PerformanceComparison.EfficiencyMetrics.ResultCacheEfficiency),
+		fmt.Sprintf("総キャッシュメモリ: %.1f MB", results.PerformanceComparison.ResourceUtilization.TotalCacheMemoryMB),
+		fmt.Sprintf("推定I/O削減: %d回", results.PerformanceComparison.ResourceUtilization.EstimatedIOSavings),
+	})
+}
+
+// performanceMetrics - 性能メトリクス比較を表示
+func (p *textPresenter) performanceMetrics(results *cache.AnalysisResults) {
+	w := p.w
+	w.Section("Oracle内蔵キャッシュ vs 外部キャッシュ 比較")
+
+	w.Blank()
+	w.Line("✅ Oracle内蔵キャッシュの優位性:")
+	w.Bullets(1, "", results.PerformanceComparison.OracleAdvantages)
+
+	w.Blank()
+	w.Line("❌ 外部キャッシュの課題:")
+	w.Bullets(1, "", results.PerformanceComparison.ExternalCacheIssues)
+}
+
+// efficiencyAnalysis - 効率性分析を表示
+func (p *textPresenter) efficiencyAnalysis(results *cache.AnalysisResults) {
+	w := p.w
+	w.Section("リソース効率性分析")
+
+	util := results.PerformanceComparison.ResourceUtilization
+
+	w.Blank()
+	w.Line("💾 メモリ使用効率:")
+	w.Bullets(1, "•", []string{
+		fmt.Sprintf("総キャッシュメモリ: %.1f MB", util.TotalCacheMemoryMB),
+		fmt.Sprintf("Buffer Cache: %.1f MB", util.BufferCacheUtilization),
+		fmt.Sprintf("Result Cache: %.1f MB", util.ResultCacheUtilization),
+	})
+
+	w.Blank()
+	w.Line("⚡ 性能向上効果:")
+	w.Bullets(1, "•", []string{
+		fmt.Sprintf("I/O削減回数: %d回", util.EstimatedIOSavings),
+		fmt.Sprintf("推定CPU削減: %.1f%%", util.EstimatedCPUSavings),
+		fmt.Sprintf("メモリ効率比: %.2f", results.PerformanceComparison.EfficiencyMetrics.MemoryEfficiencyRatio),
+	})
+}
+
+// recommendations - 推奨事項を表示
+func (p *textPresenter) recommendations(results *cache.AnalysisResults) {
+	w := p.w
+	w.Section("推奨事項（優先度順）")
+
+	for i, rec := range results.Recommendations {
+		w.Blank()
+		w.Linef("%d. [%s優先度] %s（%s）", i+1, rec.Severity.Label(), rec.Title, rec.RuleID)
+		w.KeyValue(1, "カテゴリ", rec.Category)
+		w.KeyValue(1, "説明", rec.Description)
+		w.KeyValue(1, "期待効果", rec.Impact)
+		w.KeyValue(1, "実装工数", rec.Effort)
+		if len(rec.Benefits) > 0 {
+			w.KeyValue(1, "利益", strings.Join(rec.Benefits, ", "))
+		}
+		if rec.Evidence != "" {
+			w.KeyValue(1, "根拠", rec.Evidence)
+		}
+		for _, link := range rec.DocLinks {
+			w.KeyValue(1, "参考", link)
+		}
+		if len(rec.SuggestedSQL) > 0 || len(rec.ParameterChanges) > 0 {
+			w.KeyValue(1, "修正スクリプト",
+				fmt.Sprintf("apply-recommendations -dry-run で出力（自動適用: %s）", yesNo(rec.AutoFixable)))
+		}
+	}
+}
+
+// conclusion - 結論を表示
+func (p *textPresenter) conclusion(results *cache.AnalysisResults) {
+	w := p.w
+	w.Section("結論とNext Steps")
+
+	w.Blank()
+	w.Line("🎯 重要な結論:")
+	w.Bullets(1, "", []string{
+		"1. Oracle内蔵キャッシュメカニズムは外部キャッシュよりも効率的",
+		"2. N+1問題は根本的なSQL設計で解決すべき",
+		"3. 外部キャッシュは複雑性を増加させ運用コストを高める",
+		"4. データ整合性はOracleの自動機能に任せるべき",
+	})
+
+	w.Blank()
+	w.Linef("📊 今回の分析結果: %s", results.OptimizationAdvice.PriorityLevel)
+
+	w.Blank()
+	w.Line("🚀 Next Steps:")
+	if len(results.OptimizationAdvice.ImmediateActions) > 0 {
+		w.Indentf(1, "即座の対応:")
+		w.Bullets(2, "•", results.OptimizationAdvice.ImmediateActions)
+	}
+
+	w.Blank()
+	w.Line("💡 長期的な方向性:")
+	w.Bullets(1, "→", []string{
+		"Oracle Database中心のアーキテクチャ採用",
+		"外部キャッシュ依存度の段階的削減",
+		"SQL最適化による根本的問題解決",
+		"運用性とメンテナンス性の向上",
+	})
+}
+
+// yesNo - 真偽値の表示
+func yesNo(v bool) string {
+	if v {
+		return "可"
+	}
+	return "不可"
+}
@@ -0,0 +1,460 @@
+// Package profile - 利用者が指定したSELECT文を、デモと同じ方法（繰り返し実行・セッション統計・実行計画・キャッシュの分析）で計測する
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/bundle"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+	"oracle-n-plus-1-demo/internal/stats"
+	"oracle-n-plus-1-demo/repository"
+)
+
+const (
+	// DefaultRuns - 既定の計測回数
+	DefaultRuns = 10
+	// DefaultWarmup - 既定の、計測前に実行して捨てる回数
+	DefaultWarmup = 1
+	// tagPrefix - V$SQLでこの計測のカーソルを見分けるため、文の先頭に付けるコメントの接頭辞
+	tagPrefix = "/* nplus1-profile "
+)
+
+// statNames - 実行ごとに取得するセッション統計
+var statNames = []string{
+	sessionstats.LogicalReads,
+	sessionstats.PhysicalReads,
+	sessionstats.RoundTrips,
+	sessionstats.BytesSent,
+	sessionstats.ParseCountTotal,
+	sessionstats.ParseCountHard,
+	sessionstats.CPUUsed,
+}
+
+// Bind - バインド変数（Nameが空の場合は位置で渡す）
+type Bind struct {
+	Name  string      `json:"name,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// ParseBind - "値" または "名前=値" の形式のバインド変数を解析
+//
+// 値は整数・小数・文字列の順に解釈する。"str:" を付けると文字列、"date:YYYY-MM-DD" は日付、"null" はNULLとして渡す。
+func ParseBind(spec string) (Bind, error) {
+	var b Bind
+	if name, value, ok := strings.Cut(spec, "="); ok && isIdentifier(name) {
+		b.Name, spec = name, value
+	}
+	value, err := parseValue(spec)
+	if err != nil {
+		return Bind{}, err
+	}
+	b.Value = value
+	return b, nil
+}
+
+// parseValue - バインド変数の値を型付きで解釈
+func parseValue(s string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(s, "str:"):
+		return strings.TrimPrefix(s, "str:"), nil
+	case strings.HasPrefix(s, "date:"):
+		t, err := time.ParseInLocation("2006-01-02", strings.TrimPrefix(s, "date:"), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date bind %q (YYYY-MM-DD): %w", s, err)
+		}
+		return t, nil
+	case s == "null":
+		return nil, nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// isIdentifier - バインド変数の名前として使えるか（英字で始まる英数字と_）
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeSQL - 貼り付けたSQLの前後の空白と終端の ; や / を取り除き、問い合わせ（SELECTまたはWITH）であることを確認
+func NormalizeSQL(text string) (string, error) {
+	query := strings.TrimSpace(text)
+	for {
+		trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(query, "/"), ";"))
+		if trimmed == query {
+			break
+		}
+		query = trimmed
+	}
+	if query == "" {
+		return "", errors.New("sql is empty")
+	}
+	switch firstKeyword(query) {
+	case "SELECT", "WITH":
+		return query, nil
+	default:
+		return "", errors.New("only queries (SELECT or WITH) can be profiled")
+	}
+}
+
+// firstKeyword - 先頭のコメントと空白を飛ばした最初の語（大文字）
+func firstKeyword(query string) string {
+	s := query
+	for {
+		s = strings.TrimSpace(s)
+		switch {
+		case strings.HasPrefix(s, "--"):
+			_, rest, _ := strings.Cut(s, "\n")
+			s = rest
+		case strings.HasPrefix(s, "/*"):
+			_, rest, ok := strings.Cut(s[2:], "*/")
+			if !ok {
+				return ""
+			}
+			s = rest
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			})
+			if end < 0 {
+				end = len(s)
+			}
+			return strings.ToUpper(s[:end])
+		}
+	}
+}
+
+// withResultCache - 問い合わせをインライン・ビューで包み、RESULT_CACHEヒントを付ける（WITH句の問い合わせにもヒントを付けられるようにする）
+func withResultCache(query string) string {
+	return "SELECT /*+ RESULT_CACHE */ * FROM (\n" + query + "\n)"
+}
+
+// Config - 計測の設定
+type Config struct {
+	// SQL - 計測する問い合わせ（NormalizeSQLで整えたもの）
+	SQL   string
+	Binds []Bind
+	// Runs - 計測回数
+	Runs int
+	// Warmup - 計測前に実行して捨てる回数（カーソルを共有プールに載せ、ブロックをキャッシュに読み込む）
+	Warmup int
+	// Plan - カーソルキャッシュ上の実行計画を取得するか
+	Plan bool
+	// ResultCache - RESULT_CACHEヒントを付けた場合も計測して比べるか
+	ResultCache bool
+}
+
+// DefaultConfig - 既定の設定
+func DefaultConfig() Config {
+	return Config{Runs: DefaultRuns, Warmup: DefaultWarmup, Plan: true}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	if _, err := NormalizeSQL(c.SQL); err != nil {
+		return err
+	}
+	if c.Runs <= 0 {
+		return fmt.Errorf("runs must be positive: %d", c.Runs)
+	}
+	if c.Warmup < 0 {
+		return fmt.Errorf("warmup must not be negative: %d", c.Warmup)
+	}
+	named := 0
+	for _, b := range c.Binds {
+		if b.Name != "" {
+			named++
+		}
+	}
+	if named > 0 && named != len(c.Binds) {
+		return errors.New("binds must be either all positional or all named")
+	}
+	return nil
+}
+
+// Execution - 1回の実行の結果
+type Execution struct {
+	Elapsed time.Duration      `json:"elapsed_ns"`
+	Rows    int64              `json:"rows"`
+	Stats   sessionstats.Stats `json:"stats,omitempty"`
+}
+
+// HitRatio - バッファキャッシュのヒット率（論理読み取りのうちディスクから読まなかった割合。論理読み取りがない場合は-1）
+func (e Execution) HitRatio() float64 {
+	logical := e.Stats[sessionstats.LogicalReads]
+	if logical == 0 {
+		return -1
+	}
+	hit := float64(logical-e.Stats[sessionstats.PhysicalReads]) / float64(logical)
+	if hit < 0 {
+		return 0
+	}
+	return hit
+}
+
+// Summary - 実行時間の要約
+type Summary struct {
+	Median time.Duration `json:"median_ns"`
+	Mean   time.Duration `json:"mean_ns"`
+	StdDev time.Duration `json:"stddev_ns"`
+	Min    time.Duration `json:"min_ns"`
+	Max    time.Duration `json:"max_ns"`
+}
+
+// summarize - 実行時間を要約
+func summarize(executions []Execution) Summary {
+	durations := make([]time.Duration, len(executions))
+	for i, e := range executions {
+		durations[i] = e.Elapsed
+	}
+	values := stats.Float64s(durations)
+	s := Summary{
+		Median: stats.MedianDuration(durations),
+		Mean:   time.Duration(stats.Mean(values)),
+		StdDev: time.Duration(stats.StdDev(values)),
+	}
+	for i, d := range durations {
+		if i == 0 || d < s.Min {
+			s.Min = d
+		}
+		if d > s.Max {
+			s.Max = d
+		}
+	}
+	return s
+}
+
+// medianStats - 統計ごとの実行間の中央値
+func medianStats(executions []Execution) sessionstats.Stats {
+	if len(executions) == 0 || executions[0].Stats == nil {
+		return nil
+	}
+	result := make(sessionstats.Stats, len(statNames))
+	for _, name := range statNames {
+		values := make([]float64, len(executions))
+		for i, e := range executions {
+			values[i] = float64(e.Stats[name])
+		}
+		result[name] = int64(stats.Median(values))
+	}
+	return result
+}
+
+// Variant - 同じ問い合わせの1つの実行方法（そのまま、またはRESULT_CACHEヒント付き）の計測結果
+type Variant struct {
+	Name       string             `json:"name"`
+	Elapsed    Summary            `json:"elapsed"`
+	Rows       int64              `json:"rows"`
+	Stats      sessionstats.Stats `json:"stats,omitempty"`
+	Executions []Execution        `json:"executions"`
+}
+
+// Report - 計測結果
+type Report struct {
+	SQL    string `json:"sql"`
+	Binds  []Bind `json:"binds,omitempty"`
+	Runs   int    `json:"runs"`
+	Warmup int    `json:"warmup"`
+	// First - 最初の実行（ウォームアップがない場合は計測の1回目、ある場合はウォームアップの1回目）。ハードパースとディスクからの読み取りを含みやすい
+	First    Execution `json:"first"`
+	Variants []Variant `json:"variants"`
+	// SQLID / ChildNumber - カーソルキャッシュ上のカーソル（実行計画を取得しない場合や見つからない場合は空）
+	SQLID       string   `json:"sql_id,omitempty"`
+	ChildNumber int64    `json:"child_number"`
+	Plan        []string `json:"plan,omitempty"`
+	// PlanError - 実行計画を取得できなかった理由
+	PlanError string `json:"plan_error,omitempty"`
+	// StatsUnavailable - V$MYSTATを参照できず、セッション統計を取得できなかった
+	StatsUnavailable bool `json:"stats_unavailable,omitempty"`
+}
+
+// variant - 実行方法の名前と、実際に実行する文
+type variant struct {
+	name, query string
+}
+
+// runner - 専用の接続で問い合わせを実行する
+type runner struct {
+	q         *repository.ConnDB
+	collector *sessionstats.Collector
+	args      []interface{}
+}
+
+// Run - cfg.SQLをcfg.Warmup回実行してから、cfg.Runs回実行して計測する
+//
+// V$MYSTATを同じセッションで参照するため、専用の接続を1本確保して順に実行する。
+// 文の先頭に計測ごとに異なるコメントを付け、V$SQLでこの計測のカーソルを見分けて実行計画を取得する。
+func Run(ctx context.Context, db *sql.DB, cfg Config) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	query, _ := NormalizeSQL(cfg.SQL)
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			fmt.Printf("conn.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	r := &runner{q: repository.NewConnDBContext(ctx, conn), args: bindArgs(cfg.Binds)}
+	report := &Report{SQL: query, Binds: cfg.Binds, Runs: cfg.Runs, Warmup: cfg.Warmup}
+	if r.collector, err = sessionstats.NewCollector(r.q, statNames); err != nil {
+		report.StatsUnavailable = true
+	}
+
+	// 実行方法ごとに異なるコメントにして、RESULT_CACHEヒント付きのカーソルと区別する
+	nonce := time.Now().UnixNano()
+	plain := fmt.Sprintf("%s%d */\n%s", tagPrefix, nonce, query)
+	variants := []variant{{"そのまま", plain}}
+	if cfg.ResultCache {
+		variants = append(variants, variant{"RESULT_CACHE", fmt.Sprintf("%s%d rc */\n%s", tagPrefix, nonce, withResultCache(query))})
+	}
+
+	for i, v := range variants {
+		result := Variant{Name: v.name}
+		for w := 0; w < cfg.Warmup; w++ {
+			e, err := r.execute(v.query)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.name, err)
+			}
+			if i == 0 && w == 0 {
+				report.First = e
+			}
+		}
+		for n := 0; n < cfg.Runs; n++ {
+			e, err := r.execute(v.query)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.name, err)
+			}
+			if i == 0 && n == 0 && cfg.Warmup == 0 {
+				report.First = e
+			}
+			result.Executions = append(result.Executions, e)
+		}
+		result.Elapsed = summarize(result.Executions)
+		result.Rows = result.Executions[len(result.Executions)-1].Rows
+		result.Stats = medianStats(result.Executions)
+		report.Variants = append(report.Variants, result)
+	}
+
+	if cfg.Plan {
+		if err := report.loadPlan(db, plain); err != nil {
+			report.PlanError = err.Error()
+		}
+	}
+	return report, nil
+}
+
+// bindArgs - バインド変数をdatabase/sqlの引数に変換
+func bindArgs(binds []Bind) []interface{} {
+	args := make([]interface{}, len(binds))
+	for i, b := range binds {
+		if b.Name != "" {
+			args[i] = sql.Named(b.Name, b.Value)
+		} else {
+			args[i] = b.Value
+		}
+	}
+	return args
+}
+
+// execute - 問い合わせを1回実行してすべての行を読み、実行時間・行数・セッション統計の差分を返す
+func (r *runner) execute(query string) (Execution, error) {
+	var e Execution
+	var before sessionstats.Stats
+	var err error
+	if r.collector != nil {
+		if before, err = r.collector.Snapshot(); err != nil {
+			return e, err
+		}
+	}
+	start := time.Now()
+	e.Rows, err = r.fetchAll(query)
+	e.Elapsed = time.Since(start)
+	if err != nil {
+		return e, err
+	}
+	if r.collector != nil {
+		if e.Stats, err = r.collector.Delta(before); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// fetchAll - 問い合わせのすべての行を読み捨て、行数を返す
+func (r *runner) fetchAll(query string) (count int64, err error) {
+	rows, err := r.q.Query(query, r.args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read columns: %w", err)
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return count, fmt.Errorf("failed to scan row: %w", err)
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// loadPlan - 計測した文のカーソルをV$SQLで探し、DBMS_XPLAN.DISPLAY_CURSORで実行計画を取得
+func (r *Report) loadPlan(db *sql.DB, text string) error {
+	// V$SQL.SQL_TEXTは先頭1000バイトまでのため、計測ごとに異なる先頭のコメントで探す
+	prefix, _, _ := strings.Cut(text, "\n")
+	err := db.QueryRow(`
+		SELECT sql_id, child_number FROM v$sql
+		WHERE parsing_schema_name = USER
+		  AND sql_text LIKE :1 || '%'
+		ORDER BY last_active_time DESC
+		FETCH FIRST 1 ROWS ONLY`, prefix).Scan(&r.SQLID, &r.ChildNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("計測した文がカーソルキャッシュに見つかりませんでした")
+	}
+	if err != nil {
+		return fmt.Errorf("V$SQLの参照権限が必要です: %w", err)
+	}
+	if r.Plan, err = bundle.DisplayCursor(db, r.SQLID, r.ChildNumber); err != nil {
+		return fmt.Errorf("DBMS_XPLANの実行権限が必要です: %w", err)
+	}
+	return nil
+}
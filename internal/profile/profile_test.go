@@ -0,0 +1,133 @@
+package profile
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+func TestParseBind(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Bind
+		wantErr bool
+	}{
+		{name: "integer", spec: "42", want: Bind{Value: int64(42)}},
+		{name: "float", spec: "1.5", want: Bind{Value: 1.5}},
+		{name: "string", spec: "Tokyo", want: Bind{Value: "Tokyo"}},
+		{name: "forced string", spec: "str:42", want: Bind{Value: "42"}},
+		{name: "date", spec: "date:2024-01-31", want: Bind{Value: time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)}},
+		{name: "null", spec: "null", want: Bind{Value: nil}},
+		{name: "named", spec: "customer_id=7", want: Bind{Name: "customer_id", Value: int64(7)}},
+		{name: "equals in value", spec: "a=b=c", want: Bind{Name: "a", Value: "b=c"}},
+		{name: "not a name", spec: "1=1", want: Bind{Value: "1=1"}},
+		{name: "invalid date", spec: "date:2024/01/31", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBind(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ParseBind(%q) error = %v, wantErr %v", tt.name, tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseBind(%q) = %#v, want %#v", tt.name, tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", text: "SELECT * FROM orders", want: "SELECT * FROM orders"},
+		{name: "semicolon", text: "  select 1 from dual;\n", want: "select 1 from dual"},
+		{name: "sqlplus slash", text: "SELECT 1 FROM dual\n/\n", want: "SELECT 1 FROM dual"},
+		{name: "with", text: "WITH t AS (SELECT 1 x FROM dual) SELECT x FROM t", want: "WITH t AS (SELECT 1 x FROM dual) SELECT x FROM t"},
+		{name: "leading comments", text: "-- 受注\n/* hint */ SELECT 1 FROM dual", want: "-- 受注\n/* hint */ SELECT 1 FROM dual"},
+		{name: "empty", text: " ;\n", wantErr: true},
+		{name: "dml", text: "DELETE FROM orders", wantErr: true},
+		{name: "unterminated comment", text: "/* SELECT 1 FROM dual", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeSQL(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: NormalizeSQL() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: NormalizeSQL() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := DefaultConfig()
+	valid.SQL = "SELECT 1 FROM dual"
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{name: "default", modify: func(*Config) {}},
+		{name: "no sql", modify: func(c *Config) { c.SQL = "" }, wantErr: true},
+		{name: "zero runs", modify: func(c *Config) { c.Runs = 0 }, wantErr: true},
+		{name: "negative warmup", modify: func(c *Config) { c.Warmup = -1 }, wantErr: true},
+		{name: "positional binds", modify: func(c *Config) { c.Binds = []Bind{{Value: 1}, {Value: 2}} }},
+		{name: "mixed binds", modify: func(c *Config) { c.Binds = []Bind{{Name: "a", Value: 1}, {Value: 2}} }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		cfg := valid
+		tt.modify(&cfg)
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	executions := []Execution{
+		{Elapsed: 3 * time.Millisecond, Stats: sessionstats.Stats{sessionstats.LogicalReads: 30}},
+		{Elapsed: 1 * time.Millisecond, Stats: sessionstats.Stats{sessionstats.LogicalReads: 10}},
+		{Elapsed: 2 * time.Millisecond, Stats: sessionstats.Stats{sessionstats.LogicalReads: 20}},
+	}
+
+	s := summarize(executions)
+	if s.Median != 2*time.Millisecond || s.Min != time.Millisecond || s.Max != 3*time.Millisecond {
+		t.Errorf("summarize() = %+v, want median 2ms, min 1ms, max 3ms", s)
+	}
+	if got := medianStats(executions)[sessionstats.LogicalReads]; got != 20 {
+		t.Errorf("medianStats() logical reads = %d, want 20", got)
+	}
+	if got := medianStats([]Execution{{Elapsed: time.Millisecond}}); got != nil {
+		t.Errorf("medianStats() without stats = %v, want nil", got)
+	}
+}
+
+func TestHitRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats sessionstats.Stats
+		want  float64
+	}{
+		{name: "all cached", stats: sessionstats.Stats{sessionstats.LogicalReads: 100}, want: 1},
+		{name: "quarter from disk", stats: sessionstats.Stats{sessionstats.LogicalReads: 100, sessionstats.PhysicalReads: 25}, want: 0.75},
+		{name: "direct reads exceed logical", stats: sessionstats.Stats{sessionstats.LogicalReads: 10, sessionstats.PhysicalReads: 50}, want: 0},
+		{name: "no reads", stats: sessionstats.Stats{}, want: -1},
+	}
+
+	for _, tt := range tests {
+		if got := (Execution{Stats: tt.stats}).HitRatio(); got != tt.want {
+			t.Errorf("%s: HitRatio() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Package prompt - 研修向けモード（ウォークスルー・クイズ）の対話的な入力
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrQuit - q が入力された
+	ErrQuit = errors.New("quit requested")
+	// ErrNoInput - 回答が必要な質問の前に入力が終わった
+	ErrNoInput = errors.New("no more input")
+)
+
+// Prompter - 質問を表示して1行ずつ回答を読む
+//
+// 入力が終わった後（パイプやCIで標準入力が閉じている場合）は、Pauseは待たずに進み、
+// Chooseは ErrNoInput を返す。
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	eof bool
+}
+
+// New - 入力と表示先を指定して作成
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out}
+}
+
+// Interactive - まだ入力を読めるか
+func (p *Prompter) Interactive() bool {
+	return !p.eof
+}
+
+// Pause - メッセージを表示してEnterを待つ（q で ErrQuit）
+func (p *Prompter) Pause(message string) error {
+	if p.eof {
+		return nil
+	}
+	if _, err := fmt.Fprintf(p.out, "\n%s（Enterで続行、qで終了）", message); err != nil {
+		return fmt.Errorf("failed to write prompt: %w", err)
+	}
+	line, ok := p.readLine()
+	if ok && strings.EqualFold(line, "q") {
+		return ErrQuit
+	}
+	return nil
+}
+
+// Choose - 選択肢から1つを選ばせる（大文字・小文字は区別せず、選択肢にない回答は聞き直す）
+//
+// 戻り値は choices の表記のまま返す。選択肢にない q は ErrQuit になる。
+func (p *Prompter) Choose(question string, choices []string) (string, error) {
+	for {
+		if p.eof {
+			return "", ErrNoInput
+		}
+		if _, err := fmt.Fprintf(p.out, "\n%s [%s]（qで終了）: ", question, strings.Join(choices, "/")); err != nil {
+			return "", fmt.Errorf("failed to write prompt: %w", err)
+		}
+		line, ok := p.readLine()
+		if !ok {
+			return "", ErrNoInput
+		}
+		for _, choice := range choices {
+			if strings.EqualFold(line, choice) {
+				return choice, nil
+			}
+		}
+		if strings.EqualFold(line, "q") {
+			return "", ErrQuit
+		}
+		if _, err := fmt.Fprintf(p.out, "%s のいずれかを入力してください\n", strings.Join(choices, ", ")); err != nil {
+			return "", fmt.Errorf("failed to write prompt: %w", err)
+		}
+	}
+}
+
+// readLine - 1行読んで前後の空白を除く（入力が終わった場合は以降の読み取りを行わない）
+//
+// 改行で終わらない最後の行は回答として扱う。
+func (p *Prompter) readLine() (string, bool) {
+	line, err := p.in.ReadString('\n')
+	if err != nil {
+		p.eof = true
+		// 入力待ちの表示の後で改行しておく（表示先への書き込みエラーは回答に影響しない）
+		_, _ = fmt.Fprintln(p.out)
+		if line == "" {
+			return "", false
+		}
+	}
+	return strings.TrimSpace(line), true
+}
@@ -0,0 +1,64 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "exact", input: "B\n", want: "B"},
+		{name: "lower case", input: "c\n", want: "C"},
+		{name: "spaces", input: "  a  \n", want: "A"},
+		{name: "retry after invalid", input: "x\n\nb\n", want: "B"},
+		{name: "last line without newline", input: "a", want: "A"},
+		{name: "quit", input: "q\n", wantErr: ErrQuit},
+		{name: "no input", input: "", wantErr: ErrNoInput},
+		{name: "invalid then eof", input: "x\n", wantErr: ErrNoInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := New(strings.NewReader(tt.input), &out)
+			got, err := p.Choose("どれですか？", []string{"A", "B", "C"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Choose() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Choose() = %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(out.String(), "[A/B/C]") {
+				t.Errorf("Choose() output %q does not list choices", out.String())
+			}
+		})
+	}
+}
+
+func TestPause(t *testing.T) {
+	var out bytes.Buffer
+	p := New(strings.NewReader("\nq\n"), &out)
+	if err := p.Pause("次へ"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if err := p.Pause("次へ"); !errors.Is(err, ErrQuit) {
+		t.Fatalf("Pause() error = %v, want %v", err, ErrQuit)
+	}
+
+	// 入力が終わった後は待たずに進む
+	if err := p.Pause("次へ"); err != nil {
+		t.Fatalf("Pause() after EOF error = %v", err)
+	}
+	if p.Interactive() {
+		t.Error("Interactive() = true after EOF")
+	}
+	if err := p.Pause("次へ"); err != nil {
+		t.Fatalf("Pause() after EOF error = %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package provision
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// オブジェクトの種類（USER_OBJECTS.OBJECT_TYPE）
+const (
+	TypeTable            = "TABLE"
+	TypeIndex            = "INDEX"
+	TypeMaterializedView = "MATERIALIZED VIEW"
+	TypeSequence         = "SEQUENCE"
+)
+
+// Object - DDLスクリプトで作成するオブジェクト
+type Object struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Statement - オブジェクトを作成するDDL（末尾のセミコロンなし）
+	Statement string `json:"-"`
+	// Definition - 比較に使う正規化した定義（1行1項目、順序に意味がある項目以外は並べ替え済み）
+	Definition []string `json:"-"`
+}
+
+var (
+	createTablePattern    = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+(\w+)\s*\((.*)\)$`)
+	createIndexPattern    = regexp.MustCompile(`(?is)^CREATE\s+INDEX\s+(\w+)\s+ON\s+(\w+)\s*\(([^)]*)\)$`)
+	createMViewPattern    = regexp.MustCompile(`(?is)^CREATE\s+MATERIALIZED\s+VIEW\s+(\w+)\s+(.*?)\s+AS\s+(SELECT\s.*)$`)
+	createSequencePattern = regexp.MustCompile(`(?is)^CREATE\s+SEQUENCE\s+(\w+)(.*)$`)
+	refreshPattern        = regexp.MustCompile(`(?i)REFRESH\s+(COMPLETE|FAST|FORCE)\s+ON\s+(DEMAND|COMMIT)`)
+	incrementPattern      = regexp.MustCompile(`(?i)INCREMENT\s+BY\s+(-?\d+)`)
+	cachePattern          = regexp.MustCompile(`(?i)\bCACHE\s+(\d+)`)
+	tablePrimaryKey       = regexp.MustCompile(`(?i)PRIMARY\s+KEY\s*\(([^)]*)\)`)
+	whitespace            = regexp.MustCompile(`\s+`)
+	spaceAroundPunct      = regexp.MustCompile(`\s*([(),.])\s*`)
+)
+
+// defaultSequenceCache - CACHE / NOCACHE を省略したシーケンスのキャッシュ数
+const defaultSequenceCache = 20
+
+// ParseScript - DDLスクリプトから作成するオブジェクトを記述順に取り出す
+//
+// SQL*Plusのコマンド（EXEC）、COMMIT、行コメントは無視する。文は行末のセミコロンで区切る。
+func ParseScript(script string) ([]Object, error) {
+	var objects []Object
+	for _, stmt := range splitStatements(script) {
+		upper := strings.ToUpper(stmt)
+		if strings.HasPrefix(upper, "EXEC") || upper == "COMMIT" {
+			continue
+		}
+		obj, err := parseStatement(stmt)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// splitStatements - 行コメントを除いて、行末のセミコロンで文に分ける
+func splitStatements(script string) []string {
+	var stmts []string
+	var current []string
+	for _, line := range strings.Split(script, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasSuffix(line, ";") {
+			current = append(current, strings.TrimSuffix(line, ";"))
+			stmts = append(stmts, strings.TrimSpace(strings.Join(current, "\n")))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	return stmts
+}
+
+// parseStatement - CREATE文1つからオブジェクトと正規化した定義を取り出す
+func parseStatement(stmt string) (Object, error) {
+	if m := createTablePattern.FindStringSubmatch(stmt); m != nil {
+		def, err := tableDefinition(m[2])
+		if err != nil {
+			return Object{}, fmt.Errorf("table %s: %w", m[1], err)
+		}
+		return Object{Type: TypeTable, Name: strings.ToUpper(m[1]), Statement: stmt, Definition: def}, nil
+	}
+	if m := createIndexPattern.FindStringSubmatch(stmt); m != nil {
+		return Object{Type: TypeIndex, Name: strings.ToUpper(m[1]), Statement: stmt,
+			Definition: []string{indexLine(m[2], splitNames(m[3]))}}, nil
+	}
+	if m := createMViewPattern.FindStringSubmatch(stmt); m != nil {
+		refresh := refreshPattern.FindStringSubmatch(m[2])
+		if refresh == nil {
+			return Object{}, fmt.Errorf("materialized view %s: REFRESH ... ON ... is required", m[1])
+		}
+		return Object{Type: TypeMaterializedView, Name: strings.ToUpper(m[1]), Statement: stmt,
+			Definition: mviewDefinition(refresh[1], refresh[2], m[3])}, nil
+	}
+	if m := createSequencePattern.FindStringSubmatch(stmt); m != nil {
+		increment, cache := 1, defaultSequenceCache
+		if inc := incrementPattern.FindStringSubmatch(m[2]); inc != nil {
+			increment, _ = strconv.Atoi(inc[1])
+		}
+		if c := cachePattern.FindStringSubmatch(m[2]); c != nil {
+			cache, _ = strconv.Atoi(c[1])
+		} else if strings.Contains(strings.ToUpper(m[2]), "NOCACHE") {
+			cache = 0
+		}
+		return Object{Type: TypeSequence, Name: strings.ToUpper(m[1]), Statement: stmt,
+			Definition: []string{sequenceLine(int64(increment), int64(cache))}}, nil
+	}
+
+	first := strings.SplitN(stmt, "\n", 2)[0]
+	return Object{}, fmt.Errorf("unsupported statement: %s", first)
+}
+
+// tableDefinition - 列定義から「列名 型 NULL可否 [VIRTUAL]」の行を作る（列の順序は問わないため名前順）
+//
+// 表レベルの制約は列のNULL可否（主キー列はNOT NULL）にだけ反映する。
+func tableDefinition(body string) ([]string, error) {
+	type column struct {
+		name, dataType   string
+		notNull, virtual bool
+	}
+	var columns []*column
+	byName := make(map[string]*column)
+	var primaryKey []string
+
+	for _, item := range splitTopLevel(body) {
+		item = strings.TrimSpace(whitespace.ReplaceAllString(item, " "))
+		upper := strings.ToUpper(item)
+		first := strings.SplitN(upper, " ", 2)[0]
+		switch first {
+		case "CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK":
+			if m := tablePrimaryKey.FindStringSubmatch(item); m != nil {
+				primaryKey = append(primaryKey, splitNames(m[1])...)
+			}
+			continue
+		}
+
+		fields := strings.SplitN(upper, " ", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("cannot parse column %q", item)
+		}
+		c := &column{
+			name:     fields[0],
+			dataType: strings.ReplaceAll(fields[1], " ", ""),
+			notNull:  strings.Contains(upper, "NOT NULL") || strings.Contains(upper, "PRIMARY KEY"),
+			virtual:  strings.Contains(upper, "GENERATED ALWAYS AS") || strings.Contains(upper, " AS ("),
+		}
+		columns = append(columns, c)
+		byName[c.name] = c
+	}
+	for _, name := range primaryKey {
+		if c, ok := byName[name]; ok {
+			c.notNull = true
+		}
+	}
+
+	lines := make([]string, len(columns))
+	for i, c := range columns {
+		lines[i] = columnLine(c.name, c.dataType, !c.notNull, c.virtual)
+	}
+	sort.Strings(lines)
+	return lines, nil
+}
+
+// splitTopLevel - 括弧の外にあるカンマで分ける（NUMBER(10,2) などを分けない）
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// splitNames - カンマ区切りの識別子を大文字にして分ける
+func splitNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, strings.ToUpper(name))
+		}
+	}
+	return names
+}
+
+// columnLine - 列の正規化した定義
+func columnLine(name, dataType string, nullable, virtual bool) string {
+	line := name + " " + dataType
+	if nullable {
+		line += " NULL"
+	} else {
+		line += " NOT NULL"
+	}
+	if virtual {
+		line += " VIRTUAL"
+	}
+	return line
+}
+
+// indexLine - 索引の正規化した定義（列の順序は意味があるため並べ替えない）
+func indexLine(table string, columns []string) string {
+	return "ON " + strings.ToUpper(table) + "(" + strings.Join(columns, ",") + ")"
+}
+
+// sequenceLine - シーケンスの正規化した定義
+func sequenceLine(increment, cache int64) string {
+	return fmt.Sprintf("INCREMENT BY %d CACHE %d", increment, cache)
+}
+
+// mviewDefinition - マテリアライズドビューの正規化した定義（リフレッシュ方法とクエリ）
+func mviewDefinition(method, mode, query string) []string {
+	return []string{
+		"REFRESH " + strings.ToUpper(method) + " ON " + strings.ToUpper(mode),
+		"QUERY " + normalizeQuery(query),
+	}
+}
+
+// normalizeQuery - 空白と大文字小文字の違いを無視できるようクエリを正規化
+func normalizeQuery(query string) string {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	q = whitespace.ReplaceAllString(q, " ")
+	return spaceAroundPunct.ReplaceAllString(q, "$1")
+}
@@ -0,0 +1,147 @@
+package provision
+
+import (
+	"database/sql"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"oracle-n-plus-1-demo/internal/schema"
+	"oracle-n-plus-1-demo/scripts/ddl"
+)
+
+// TestParseScriptMatchesSchema - DDLスクリプトのオブジェクトが期待スキーマ・cleanupの対象と一致するか
+func TestParseScriptMatchesSchema(t *testing.T) {
+	objects, err := ParseScript(ddl.CreateTables)
+	if err != nil {
+		t.Fatalf("ParseScript() error = %v", err)
+	}
+
+	got := map[string][]string{}
+	for _, o := range objects {
+		got[o.Type] = append(got[o.Type], o.Name)
+	}
+
+	var tables, indexes []string
+	for _, table := range schema.ExpectedTables {
+		tables = append(tables, table.Name)
+		for _, index := range table.Indexes {
+			if strings.HasPrefix(index.Name, "IDX_") {
+				indexes = append(indexes, index.Name)
+			}
+		}
+	}
+	indexes = append(indexes, "IDX_MV_MONTHLY_SALES_MONTH")
+
+	for typ, want := range map[string][]string{
+		TypeTable:            tables,
+		TypeIndex:            indexes,
+		TypeSequence:         schema.ExpectedSequences,
+		TypeMaterializedView: schema.ExpectedMaterializedViews,
+	} {
+		g := append([]string(nil), got[typ]...)
+		w := append([]string(nil), want...)
+		sort.Strings(g)
+		sort.Strings(w)
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("%s = %v, want %v", typ, g, w)
+		}
+	}
+}
+
+func TestParseStatementDefinitions(t *testing.T) {
+	objects, err := ParseScript(ddl.CreateTables)
+	if err != nil {
+		t.Fatalf("ParseScript() error = %v", err)
+	}
+	byName := map[string]Object{}
+	for _, o := range objects {
+		byName[o.Name] = o
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"EMPLOYEE_PROJECTS", []string{
+			"ASSIGNED_AT DATE NULL",
+			"EMPLOYEE_ID NUMBER(10) NOT NULL",
+			"PROJECT_ID NUMBER(10) NOT NULL",
+			"PROJECT_ROLE VARCHAR2(50) NULL",
+		}},
+		{"IDX_EMPLOYEES_NAME", []string{"ON EMPLOYEES(LAST_NAME,FIRST_NAME)"}},
+		{"SEQ_ORDERS", []string{"INCREMENT BY 1 CACHE 0"}},
+	}
+	for _, tt := range tests {
+		if got := byName[tt.name].Definition; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s definition = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	details := byName["ORDER_DETAILS"].Definition
+	for _, want := range []string{"DETAIL_ID NUMBER(10) NOT NULL", "LINE_AMOUNT NUMBER(12,2) NULL VIRTUAL", "UNIT_PRICE NUMBER(10,2) NOT NULL"} {
+		if !contains(details, want) {
+			t.Errorf("ORDER_DETAILS definition %q does not contain %q", details, want)
+		}
+	}
+
+	mview := byName["MV_MONTHLY_CUSTOMER_SALES"].Definition
+	if len(mview) != 2 || mview[0] != "REFRESH COMPLETE ON DEMAND" {
+		t.Errorf("MV_MONTHLY_CUSTOMER_SALES definition = %q", mview)
+	}
+}
+
+func TestLiveDefinitionMatchesScript(t *testing.T) {
+	// USER_MVIEWS.QUERY は改行や大文字小文字がスクリプトと異なっても同じ定義とみなす
+	script := mviewDefinition("COMPLETE", "DEMAND", "SELECT o.customer_id,\n    COUNT(*) AS n\nFROM orders o GROUP BY o.customer_id")
+	live := mviewDefinition("COMPLETE", "DEMAND", "select O.CUSTOMER_ID, COUNT( * ) AS N from ORDERS O group by O.CUSTOMER_ID")
+	if Checksum(script) != Checksum(live) {
+		t.Errorf("checksums differ:\n%q\n%q", script, live)
+	}
+
+	tests := []struct {
+		dataType         string
+		precision, scale sql.NullInt64
+		charLength       int64
+		want             string
+	}{
+		{"NUMBER", sql.NullInt64{Int64: 10, Valid: true}, sql.NullInt64{Int64: 0, Valid: true}, 0, "NUMBER(10)"},
+		{"NUMBER", sql.NullInt64{Int64: 12, Valid: true}, sql.NullInt64{Int64: 2, Valid: true}, 0, "NUMBER(12,2)"},
+		{"NUMBER", sql.NullInt64{}, sql.NullInt64{}, 0, "NUMBER"},
+		{"VARCHAR2", sql.NullInt64{}, sql.NullInt64{}, 100, "VARCHAR2(100)"},
+		{"CLOB", sql.NullInt64{}, sql.NullInt64{}, 0, "CLOB"},
+	}
+	for _, tt := range tests {
+		if got := liveDataType(tt.dataType, tt.precision, tt.scale, tt.charLength); got != tt.want {
+			t.Errorf("liveDataType(%s) = %q, want %q", tt.dataType, got, tt.want)
+		}
+	}
+}
+
+func TestReportErrAndDiff(t *testing.T) {
+	missing, unexpected := diffLines(
+		[]string{"A NUMBER(10) NOT NULL", "B DATE NULL"},
+		[]string{"A NUMBER(10) NOT NULL", "B VARCHAR2(10) NULL"})
+	if !reflect.DeepEqual(missing, []string{"B DATE NULL"}) || !reflect.DeepEqual(unexpected, []string{"B VARCHAR2(10) NULL"}) {
+		t.Errorf("diffLines() = %q, %q", missing, unexpected)
+	}
+
+	ok := &Report{Checks: []Check{{Status: StatusOK}, {Status: StatusCreated}}}
+	if err := ok.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+	bad := &Report{Checks: []Check{{Status: StatusOK}, {Status: StatusMismatch}}}
+	if err := bad.Err(); err == nil {
+		t.Error("Err() = nil with mismatch, want error")
+	}
+}
+
+func contains(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
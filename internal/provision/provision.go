@@ -0,0 +1,311 @@
+// Package provision - DDLスクリプトのオブジェクトのうち不足しているものを作成し、既存のものを定義のチェックサムで検証する
+package provision
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Status - オブジェクトごとの確認結果
+type Status string
+
+const (
+	// StatusOK - 存在し、定義がDDLスクリプトと一致する
+	StatusOK Status = "OK"
+	// StatusCreated - 存在しなかったため作成した
+	StatusCreated Status = "CREATED"
+	// StatusMissing - 存在しない（確認のみのため作成していない）
+	StatusMissing Status = "MISSING"
+	// StatusMismatch - 存在するが定義がDDLスクリプトと異なる
+	StatusMismatch Status = "MISMATCH"
+	// StatusFailed - 作成または確認に失敗した
+	StatusFailed Status = "FAILED"
+)
+
+// checksumLength - 表示するチェックサムの長さ（16進数の桁数）
+const checksumLength = 12
+
+// Check - 1つのオブジェクトの確認結果
+type Check struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Expected / Actual - DDLスクリプトと実際の定義のチェックサム（実際の定義は存在する場合のみ）
+	Expected string `json:"expected_checksum"`
+	Actual   string `json:"actual_checksum,omitempty"`
+	// Missing / Unexpected - 定義が異なる場合に、スクリプトにあって実際にない項目と、その逆
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Report - setupの結果
+type Report struct {
+	Checks []Check `json:"checks"`
+}
+
+// Count - 指定した状態のオブジェクト数
+func (r *Report) Count(status Status) int {
+	count := 0
+	for _, c := range r.Checks {
+		if c.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// ErrNotReady - 不足・定義の不一致・失敗がある
+var ErrNotReady = errors.New("demo schema is not ready")
+
+// Err - 不足（確認のみの場合）・定義の不一致・失敗があればErrNotReadyを返す
+func (r *Report) Err() error {
+	bad := r.Count(StatusMissing) + r.Count(StatusMismatch) + r.Count(StatusFailed)
+	if bad == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d missing, %d mismatched, %d failed", ErrNotReady,
+		r.Count(StatusMissing), r.Count(StatusMismatch), r.Count(StatusFailed))
+}
+
+// Checksum - 正規化した定義のチェックサム（SHA-256の先頭12桁）
+func Checksum(definition []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(definition, "\n")))
+	return hex.EncodeToString(sum[:])[:checksumLength]
+}
+
+// Provisioner - DDLスクリプトのオブジェクトを作成・検証する
+type Provisioner struct {
+	db      *sql.DB
+	objects []Object
+}
+
+// New - DDLスクリプトを解析してProvisionerを作成
+func New(db *sql.DB, script string) (*Provisioner, error) {
+	objects, err := ParseScript(script)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ddl script: %w", err)
+	}
+	return &Provisioner{db: db, objects: objects}, nil
+}
+
+// Objects - DDLスクリプトのオブジェクト（記述順）
+func (p *Provisioner) Objects() []Object {
+	return p.objects
+}
+
+// Run - スクリプトの記述順にオブジェクトを確認し、applyの場合は存在しないものを作成する
+//
+// 既存のオブジェクトは変更しない（定義が異なる場合は StatusMismatch として報告するだけ）。
+// 作成した表は DDLスクリプトと同じくオプティマイザ統計を収集する。
+// 各DDLを実行する前にonCreateを呼ぶ（進捗の表示用、nilでもよい）。
+func (p *Provisioner) Run(ctx context.Context, apply bool, onCreate func(stmt string)) (*Report, error) {
+	report := &Report{}
+	var createdTables []string
+	for _, obj := range p.objects {
+		check := Check{Type: obj.Type, Name: obj.Name, Expected: Checksum(obj.Definition)}
+
+		exists, err := p.exists(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !exists && !apply:
+			check.Status = StatusMissing
+		case !exists:
+			if onCreate != nil {
+				onCreate(obj.Statement)
+			}
+			if _, err := p.db.ExecContext(ctx, obj.Statement); err != nil {
+				check.Status = StatusFailed
+				check.Error = err.Error()
+				break
+			}
+			check.Status = StatusCreated
+			if obj.Type == TypeTable {
+				createdTables = append(createdTables, obj.Name)
+			}
+		default:
+			actual, err := p.liveDefinition(ctx, obj)
+			if err != nil {
+				check.Status = StatusFailed
+				check.Error = err.Error()
+				break
+			}
+			check.Actual = Checksum(actual)
+			check.Missing, check.Unexpected = diffLines(obj.Definition, actual)
+			check.Status = StatusOK
+			if check.Actual != check.Expected {
+				check.Status = StatusMismatch
+			}
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	for _, table := range createdTables {
+		if _, err := p.db.ExecContext(ctx, "BEGIN DBMS_STATS.GATHER_TABLE_STATS(USER, :1); END;", table); err != nil {
+			return report, fmt.Errorf("failed to gather stats for %s: %w", table, err)
+		}
+	}
+	return report, nil
+}
+
+// exists - オブジェクトが接続ユーザーのスキーマに存在するか
+func (p *Provisioner) exists(ctx context.Context, obj Object) (bool, error) {
+	var count int
+	err := p.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM user_objects WHERE object_type = :1 AND object_name = :2",
+		obj.Type, obj.Name).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to query %s %s: %w", obj.Type, obj.Name, err)
+	}
+	return count > 0, nil
+}
+
+// liveDefinition - データディクショナリから、DDLスクリプトと同じ形式に正規化した定義を作る
+func (p *Provisioner) liveDefinition(ctx context.Context, obj Object) ([]string, error) {
+	switch obj.Type {
+	case TypeTable:
+		return p.liveTable(ctx, obj.Name)
+	case TypeIndex:
+		return p.liveIndex(ctx, obj.Name)
+	case TypeMaterializedView:
+		return p.liveMaterializedView(ctx, obj.Name)
+	case TypeSequence:
+		return p.liveSequence(ctx, obj.Name)
+	default:
+		return nil, fmt.Errorf("unsupported object type %q", obj.Type)
+	}
+}
+
+// liveTable - USER_TAB_COLSの列定義（隠し列を除く）
+func (p *Provisioner) liveTable(ctx context.Context, name string) (lines []string, err error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT column_name, data_type, data_precision, data_scale, char_length, nullable, virtual_column
+		FROM user_tab_cols
+		WHERE table_name = :1 AND hidden_column = 'NO'`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of %s: %w", name, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var column, dataType, nullable, virtual string
+		var precision, scale sql.NullInt64
+		var charLength int64
+		if err := rows.Scan(&column, &dataType, &precision, &scale, &charLength, &nullable, &virtual); err != nil {
+			return nil, fmt.Errorf("failed to scan columns of %s: %w", name, err)
+		}
+		lines = append(lines, columnLine(column, liveDataType(dataType, precision, scale, charLength), nullable == "Y", virtual == "YES"))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate columns of %s: %w", name, err)
+	}
+	sort.Strings(lines)
+	return lines, nil
+}
+
+// liveDataType - データディクショナリの型をDDLの書き方（NUMBER(10,2)、VARCHAR2(100)）にする
+func liveDataType(dataType string, precision, scale sql.NullInt64, charLength int64) string {
+	switch dataType {
+	case "NUMBER":
+		switch {
+		case !precision.Valid:
+			return "NUMBER"
+		case !scale.Valid || scale.Int64 == 0:
+			return fmt.Sprintf("NUMBER(%d)", precision.Int64)
+		default:
+			return fmt.Sprintf("NUMBER(%d,%d)", precision.Int64, scale.Int64)
+		}
+	case "VARCHAR2", "CHAR", "NVARCHAR2", "NCHAR":
+		return fmt.Sprintf("%s(%d)", dataType, charLength)
+	default:
+		return dataType
+	}
+}
+
+// liveIndex - USER_IND_COLUMNSの索引の表と列（列の位置順）
+func (p *Provisioner) liveIndex(ctx context.Context, name string) (lines []string, err error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT table_name, column_name FROM user_ind_columns
+		WHERE index_name = :1
+		ORDER BY column_position`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index %s: %w", name, err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var table string
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("failed to scan index %s: %w", name, err)
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate index %s: %w", name, err)
+	}
+	return []string{indexLine(table, columns)}, nil
+}
+
+// liveMaterializedView - USER_MVIEWSのリフレッシュ方法とクエリ
+func (p *Provisioner) liveMaterializedView(ctx context.Context, name string) ([]string, error) {
+	var method, mode, query string
+	err := p.db.QueryRowContext(ctx,
+		"SELECT refresh_method, refresh_mode, query FROM user_mviews WHERE mview_name = :1", name).
+		Scan(&method, &mode, &query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query materialized view %s: %w", name, err)
+	}
+	return mviewDefinition(method, mode, query), nil
+}
+
+// liveSequence - USER_SEQUENCESの増分とキャッシュ数
+func (p *Provisioner) liveSequence(ctx context.Context, name string) ([]string, error) {
+	var increment, cache int64
+	err := p.db.QueryRowContext(ctx,
+		"SELECT increment_by, cache_size FROM user_sequences WHERE sequence_name = :1", name).
+		Scan(&increment, &cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sequence %s: %w", name, err)
+	}
+	return []string{sequenceLine(increment, cache)}, nil
+}
+
+// diffLines - expectedにあってactualにない行と、その逆
+func diffLines(expected, actual []string) (missing, unexpected []string) {
+	inActual := make(map[string]bool, len(actual))
+	for _, line := range actual {
+		inActual[line] = true
+	}
+	inExpected := make(map[string]bool, len(expected))
+	for _, line := range expected {
+		inExpected[line] = true
+		if !inActual[line] {
+			missing = append(missing, line)
+		}
+	}
+	for _, line := range actual {
+		if !inExpected[line] {
+			unexpected = append(unexpected, line)
+		}
+	}
+	return missing, unexpected
+}
@@ -0,0 +1,110 @@
+package querylog
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Counts - 実行した文の数
+type Counts struct {
+	// Queries - 問い合わせ（SELECT・WITH）の実行回数
+	Queries int64 `json:"queries"`
+	// DML - 問い合わせ以外（INSERT・UPDATE・DELETE・MERGE・PL/SQLブロックなど）の実行回数
+	DML int64 `json:"dml"`
+	// BindExecutions - バインド変数の組で数えた実行回数（配列バインドは1回の実行で配列の行数だけ数える）
+	BindExecutions int64 `json:"bind_executions"`
+	// Transactions - COMMIT・ROLLBACKの回数
+	Transactions int64 `json:"transactions,omitempty"`
+}
+
+// Statements - 実行した文の数（COMMIT・ROLLBACKを除く）
+func (c Counts) Statements() int64 {
+	return c.Queries + c.DML
+}
+
+// Sub - 2つのスナップショットの差分（c - before）
+func (c Counts) Sub(before Counts) Counts {
+	return Counts{
+		Queries:        c.Queries - before.Queries,
+		DML:            c.DML - before.DML,
+		BindExecutions: c.BindExecutions - before.BindExecutions,
+		Transactions:   c.Transactions - before.Transactions,
+	}
+}
+
+// Counter - 実行した文を数える（Recorderとして Connector に渡す）
+//
+// V$ビューへの問い合わせ（セッション統計のスナップショットなど計測のための文）は数えない。
+// すべての接続の文を合計するため、手法ごとの数は実行の前後のSnapshotの差分で求め、同時に別の処理を流さないこと。
+type Counter struct {
+	mu     sync.Mutex
+	counts Counts
+}
+
+// NewCounter - Counterのコンストラクタ
+func NewCounter() *Counter {
+	return &Counter{}
+}
+
+// Log - 文を1件数える
+func (c *Counter) Log(e Entry) {
+	query := Statement(e.Query)
+	if IsDictionary(query) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch Keyword(query) {
+	case "COMMIT", "ROLLBACK":
+		c.counts.Transactions++
+		return
+	case "SELECT", "WITH":
+		c.counts.Queries++
+	default:
+		c.counts.DML++
+	}
+	c.counts.BindExecutions += bindRows(e.Args)
+}
+
+// Snapshot - これまでに数えた文の数
+func (c *Counter) Snapshot() Counts {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts
+}
+
+// Keyword - 文の最初の単語（大文字、コメント・括弧で始まる問い合わせにも対応する）
+func Keyword(query string) string {
+	for {
+		query = strings.TrimLeft(query, " (")
+		if !strings.HasPrefix(query, "/*") {
+			break
+		}
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			return ""
+		}
+		query = query[end+2:]
+	}
+	if i := strings.IndexAny(query, " (;"); i >= 0 {
+		query = query[:i]
+	}
+	return strings.ToUpper(query)
+}
+
+// bindRows - 1回の実行で渡したバインド変数の組の数（スライスの引数は配列バインドとして要素数を返す）
+func bindRows(args []driver.NamedValue) int64 {
+	rows := int64(1)
+	for _, arg := range args {
+		if _, ok := arg.Value.([]byte); ok {
+			continue
+		}
+		if v := reflect.ValueOf(arg.Value); v.Kind() == reflect.Slice && int64(v.Len()) > rows {
+			rows = int64(v.Len())
+		}
+	}
+	return rows
+}
@@ -0,0 +1,105 @@
+package querylog
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/driverwrap"
+)
+
+// Connector - baseが作る接続で実行した文をrに渡すコネクター
+//
+// 問い合わせの時間と行数は、最後の行を読んでカーソルを閉じた時点で記録する（アプリケーションから見た取得の時間）。
+// 接続は driverwrap.Connector で包むため、sql.Conn.Raw でドライバー固有の接続の型を必要とする処理は使えない。
+func Connector(base driver.Connector, r Recorder) driver.Connector {
+	var sessions atomic.Int64
+	return driverwrap.Connector(base, func() driverwrap.Hooks {
+		return &hooks{log: r, session: sessions.Add(1)}
+	})
+}
+
+// hooks - 1つの接続で実行した文を記録するフック
+type hooks struct {
+	driverwrap.Passthrough
+	log     Recorder
+	session int64
+}
+
+// Exec - DMLなどを実行して記録
+func (h *hooks) Exec(query string, args []driver.NamedValue, execute func() (driver.Result, error)) (driver.Result, error) {
+	start := time.Now()
+	result, err := execute()
+	if errors.Is(err, driver.ErrSkip) {
+		return result, err
+	}
+	h.log.Log(Entry{Query: query, Args: args, Rows: rowsAffected(result, err), Start: start, Duration: time.Since(start), Err: err, Session: h.session})
+	return result, err
+}
+
+// Query - 問い合わせを実行し、行を読み終えたときに記録する
+func (h *hooks) Query(query string, args []driver.NamedValue, execute func() (driver.Rows, error)) (driver.Rows, error) {
+	start := time.Now()
+	rs, err := execute()
+	if errors.Is(err, driver.ErrSkip) {
+		return rs, err
+	}
+	if err != nil {
+		h.log.Log(Entry{Query: query, Args: args, Rows: -1, Start: start, Duration: time.Since(start), Err: err, Session: h.session})
+		return nil, err
+	}
+	return &rows{Rows: rs, entry: Entry{Query: query, Args: args, Start: start, Session: h.session}, log: h.log}, nil
+}
+
+// End - COMMIT・ROLLBACKを実行して記録
+func (h *hooks) End(statement string, execute func() error) error {
+	start := time.Now()
+	err := execute()
+	h.log.Log(Entry{Query: statement, Rows: -1, Start: start, Duration: time.Since(start), Err: err, Session: h.session})
+	return err
+}
+
+// rows - 読んだ行を数え、閉じたときに1回だけ記録する
+type rows struct {
+	driver.Rows
+	entry  Entry
+	log    Recorder
+	closed bool
+}
+
+// Next - 次の行を読む
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.entry.Rows++
+	case !errors.Is(err, io.EOF) && r.entry.Err == nil:
+		r.entry.Err = err
+	}
+	return err
+}
+
+// Close - カーソルを閉じて記録
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.entry.Duration = time.Since(r.entry.Start)
+		r.log.Log(r.entry)
+	}
+	return err
+}
+
+// rowsAffected - 変更した行数（分からない場合は-1）
+func rowsAffected(result driver.Result, err error) int64 {
+	if err != nil || result == nil {
+		return -1
+	}
+	n, rerr := result.RowsAffected()
+	if rerr != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,211 @@
+// Package querylog - 実行したSQL・バインド変数・行数・時間を1文ずつ書き出す（PostgreSQLの log_min_duration_statement に似た形式）
+//
+// ドライバーの接続を包むため、リポジトリのコードを変えずにすべての文を記録できる。
+// デモでは、N+1の手法が同じ文を受注の数だけ流し、一括取得の手法が1〜2文で終わる様子をそのまま見せられる。
+package querylog
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redact - バインド変数の値の伏せ方
+type Redact string
+
+const (
+	// RedactNone - すべての値を表示する
+	RedactNone Redact = "none"
+	// RedactStrings - 文字列（氏名・メールアドレスなど）とバイト列の値を伏せ、数値と日時は表示する
+	RedactStrings Redact = "strings"
+	// RedactAll - すべての値を伏せる（型だけを表示する）
+	RedactAll Redact = "all"
+)
+
+// maxValueLength - 表示する文字列の値の長さの上限（超えた分は省略する）
+const maxValueLength = 64
+
+// redactedValue - 伏せた値の表示
+const redactedValue = "<redacted>"
+
+var spaceRe = regexp.MustCompile(`\s+`)
+
+// ParseRedact - 伏せ方の指定を解釈（空の場合はRedactNone）
+func ParseRedact(s string) (Redact, error) {
+	switch r := Redact(strings.ToLower(strings.TrimSpace(s))); r {
+	case "":
+		return RedactNone, nil
+	case RedactNone, RedactStrings, RedactAll:
+		return r, nil
+	default:
+		return "", fmt.Errorf("unknown redact mode %q (none, strings, all)", s)
+	}
+}
+
+// Options - 記録の設定
+type Options struct {
+	Redact Redact
+	// IncludeDictionary - V$ビューへの問い合わせ（セッション統計のスナップショットなど計測のための文）も記録するか
+	IncludeDictionary bool
+}
+
+// Entry - 実行した文1件
+type Entry struct {
+	Query string
+	Args  []driver.NamedValue
+	// Rows - 問い合わせでは読んだ行数、DMLでは変更した行数（分からない場合は-1）
+	Rows     int64
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+	// Session - 文を実行した接続の通し番号（1始まり、Connectorが接続ごとに振る）
+	Session int64
+}
+
+// Recorder - 実行した文を受け取る（LoggerのほかChrome trace形式の記録など）
+type Recorder interface {
+	Log(e Entry)
+}
+
+// Tee - 実行した文を複数のRecorderに渡す
+func Tee(recorders ...Recorder) Recorder {
+	return tee(recorders)
+}
+
+type tee []Recorder
+
+// Log - すべてのRecorderに渡す
+func (t tee) Log(e Entry) {
+	for _, r := range t {
+		r.Log(e)
+	}
+}
+
+// Logger - 実行した文を書き出す（複数の接続から同時に呼ばれても1文ずつ書き出す）
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	opts  Options
+	count int64
+}
+
+// New - Loggerのコンストラクタ
+func New(w io.Writer, opts Options) *Logger {
+	if opts.Redact == "" {
+		opts.Redact = RedactNone
+	}
+	return &Logger{w: w, opts: opts}
+}
+
+// Count - 記録した文の数
+func (l *Logger) Count() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// Log - 文を1件書き出す
+//
+//	LOG:  duration: 0.412 ms  rows: 3  statement: SELECT ... WHERE order_id = :1
+//	DETAIL:  parameters: :1 = 1001
+func (l *Logger) Log(e Entry) {
+	query := Statement(e.Query)
+	if !l.opts.IncludeDictionary && IsDictionary(query) {
+		return
+	}
+
+	var b strings.Builder
+	level := "LOG"
+	if e.Err != nil {
+		level = "ERROR"
+	}
+	fmt.Fprintf(&b, "%s:  duration: %.3f ms", level, float64(e.Duration)/float64(time.Millisecond))
+	if e.Rows >= 0 {
+		fmt.Fprintf(&b, "  rows: %d", e.Rows)
+	}
+	fmt.Fprintf(&b, "  statement: %s\n", query)
+	if len(e.Args) > 0 {
+		fmt.Fprintf(&b, "DETAIL:  parameters: %s\n", Parameters(e.Args, l.opts.Redact))
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, "DETAIL:  error: %v\n", e.Err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.count++
+	_, _ = io.WriteString(l.w, b.String())
+}
+
+// Statement - 改行や字下げを空白1つにまとめた文
+func Statement(query string) string {
+	return strings.TrimSpace(spaceRe.ReplaceAllString(query, " "))
+}
+
+// IsDictionary - V$ビューへの問い合わせ（計測のための文）か
+func IsDictionary(query string) bool {
+	return strings.Contains(strings.ToUpper(query), "V$")
+}
+
+// Parameters - バインド変数の一覧の表示（:1 = 1001, :2 = <redacted>）
+func Parameters(args []driver.NamedValue, redact Redact) string {
+	var b strings.Builder
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s = %s", bindName(arg), FormatValue(arg.Value, redact))
+	}
+	return b.String()
+}
+
+// bindName - バインド変数の表示名（名前付きは :名前、位置は :1, :2, ...）
+func bindName(arg driver.NamedValue) string {
+	if arg.Name != "" {
+		return ":" + arg.Name
+	}
+	return fmt.Sprintf(":%d", arg.Ordinal)
+}
+
+// FormatValue - バインド変数の値を表示用に整える（文字列は引用符で囲み、長い値は省略する）
+func FormatValue(v driver.Value, redact Redact) string {
+	if v == nil {
+		return "NULL"
+	}
+	if redact == RedactAll {
+		return fmt.Sprintf("%s(%T)", redactedValue, v)
+	}
+	switch x := v.(type) {
+	case string:
+		if redact == RedactStrings {
+			return redactedValue
+		}
+		return quote(x)
+	case []byte:
+		if redact == RedactStrings {
+			return redactedValue
+		}
+		return fmt.Sprintf("<%d bytes>", len(x))
+	case time.Time:
+		return quote(x.Format("2006-01-02 15:04:05.999999999"))
+	case fmt.Stringer:
+		if redact == RedactStrings {
+			return redactedValue
+		}
+		return quote(x.String())
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+// quote - 値を引用符で囲む（長い値は先頭だけを残す）
+func quote(s string) string {
+	if r := []rune(s); len(r) > maxValueLength {
+		s = string(r[:maxValueLength]) + "…"
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,219 @@
+package querylog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatValue(t *testing.T) {
+	ts := time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  driver.Value
+		redact Redact
+		want   string
+	}{
+		{name: "null", value: nil, redact: RedactAll, want: "NULL"},
+		{name: "number", value: int64(42), redact: RedactNone, want: "42"},
+		{name: "string", value: "O'Brien", redact: RedactNone, want: "'O''Brien'"},
+		{name: "long string", value: strings.Repeat("あ", 70), redact: RedactNone, want: "'" + strings.Repeat("あ", 64) + "…'"},
+		{name: "bytes", value: []byte{1, 2, 3}, redact: RedactNone, want: "<3 bytes>"},
+		{name: "time", value: ts, redact: RedactNone, want: "'2024-01-31 09:30:00'"},
+		{name: "redact strings keeps numbers", value: int64(42), redact: RedactStrings, want: "42"},
+		{name: "redact strings keeps time", value: ts, redact: RedactStrings, want: "'2024-01-31 09:30:00'"},
+		{name: "redact strings", value: "taro@example.com", redact: RedactStrings, want: "<redacted>"},
+		{name: "redact all", value: int64(42), redact: RedactAll, want: "<redacted>(int64)"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatValue(tt.value, tt.redact); got != tt.want {
+			t.Errorf("%s: FormatValue() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseRedact(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Redact
+		wantErr bool
+	}{
+		{in: "", want: RedactNone},
+		{in: "Strings", want: RedactStrings},
+		{in: "all", want: RedactAll},
+		{in: "some", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRedact(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRedact(%q) = %q, %v, want %q (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		entry Entry
+		want  string
+	}{
+		{
+			name: "query",
+			entry: Entry{
+				Query:    "SELECT detail_id\n\t\tFROM order_details\n\t\tWHERE order_id = :1",
+				Args:     []driver.NamedValue{{Ordinal: 1, Value: int64(1001)}},
+				Rows:     3,
+				Duration: 1500 * time.Microsecond,
+			},
+			want: "LOG:  duration: 1.500 ms  rows: 3  statement: SELECT detail_id FROM order_details WHERE order_id = :1\n" +
+				"DETAIL:  parameters: :1 = 1001\n",
+		},
+		{
+			name:  "named and redacted",
+			opts:  Options{Redact: RedactStrings},
+			entry: Entry{Query: "SELECT * FROM customers WHERE email = :email", Args: []driver.NamedValue{{Name: "email", Ordinal: 1, Value: "a@example.com"}}, Rows: 1},
+			want: "LOG:  duration: 0.000 ms  rows: 1  statement: SELECT * FROM customers WHERE email = :email\n" +
+				"DETAIL:  parameters: :email = <redacted>\n",
+		},
+		{
+			name:  "error",
+			entry: Entry{Query: "COMMIT", Rows: -1, Err: errors.New("ORA-02091")},
+			want:  "ERROR:  duration: 0.000 ms  statement: COMMIT\nDETAIL:  error: ORA-02091\n",
+		},
+		{
+			name:  "dictionary skipped",
+			entry: Entry{Query: "SELECT sn.name, ms.value FROM v$mystat ms JOIN v$statname sn ON 1 = 1", Rows: 10},
+			want:  "",
+		},
+		{
+			name:  "dictionary included",
+			opts:  Options{IncludeDictionary: true},
+			entry: Entry{Query: "SELECT value FROM v$mystat", Rows: 10},
+			want:  "LOG:  duration: 0.000 ms  rows: 10  statement: SELECT value FROM v$mystat\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		New(&b, tt.opts).Log(tt.entry)
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s: Log() =\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConnectorLogsRowsAfterClose(t *testing.T) {
+	var b strings.Builder
+	logger := New(&b, Options{})
+	db := sql.OpenDB(Connector(fakeConnector{rows: 3}, logger))
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() failed: %v", err)
+		}
+	}()
+
+	for _, id := range []int64{1, 2} {
+		rows, err := db.Query("SELECT detail_id FROM order_details WHERE order_id = :1", id)
+		if err != nil {
+			t.Fatalf("Query() failed: %v", err)
+		}
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		if err := rows.Close(); err != nil {
+			t.Fatalf("rows.Close() failed: %v", err)
+		}
+		if n != 3 {
+			t.Fatalf("rows = %d, want 3", n)
+		}
+	}
+	if _, err := db.Exec("UPDATE orders SET status = :1", "SHIPPED"); err != nil {
+		t.Fatalf("Exec() failed: %v", err)
+	}
+
+	if got := logger.Count(); got != 3 {
+		t.Errorf("Count() = %d, want 3", got)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"rows: 3  statement: SELECT detail_id FROM order_details WHERE order_id = :1\nDETAIL:  parameters: :1 = 1\n",
+		"rows: 3  statement: SELECT detail_id FROM order_details WHERE order_id = :1\nDETAIL:  parameters: :1 = 2\n",
+		"rows: 5  statement: UPDATE orders SET status = :1\nDETAIL:  parameters: :1 = 'SHIPPED'\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+	before := c.Snapshot()
+	for _, e := range []Entry{
+		{Query: "\n\t\tSELECT /* inlist-bench 1 */ detail_id FROM order_details WHERE order_id = :1", Args: []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}},
+		{Query: "WITH t AS (SELECT 1 FROM dual) SELECT * FROM t"},
+		{Query: "(SELECT 1 FROM dual) UNION ALL (SELECT 2 FROM dual)"},
+		// 配列バインドは1回の実行で3組
+		{Query: "INSERT INTO nplus1_in_list_ids (id) VALUES (:1)", Args: []driver.NamedValue{{Ordinal: 1, Value: []int64{1, 2, 3}}}},
+		{Query: "UPDATE orders SET notes = :1", Args: []driver.NamedValue{{Ordinal: 1, Value: []byte("abc")}}},
+		{Query: "COMMIT"},
+		{Query: "SELECT value FROM v$mystat"},
+	} {
+		c.Log(e)
+	}
+
+	got := c.Snapshot().Sub(before)
+	want := Counts{Queries: 3, DML: 2, BindExecutions: 7, Transactions: 1}
+	if got != want {
+		t.Errorf("Snapshot() = %+v, want %+v", got, want)
+	}
+	if got.Statements() != 5 {
+		t.Errorf("Statements() = %d, want 5", got.Statements())
+	}
+}
+
+// fakeConnector - 問い合わせにはrows行の結果を、DMLには変更5行を返すテスト用のドライバー
+type fakeConnector struct{ rows int }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{rows: c.rows}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{ rows int }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{left: c.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(5), nil
+}
+
+type fakeRows struct{ left int }
+
+func (r *fakeRows) Columns() []string { return []string{"DETAIL_ID"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.left == 0 {
+		return io.EOF
+	}
+	r.left--
+	dest[0] = int64(r.left)
+	return nil
+}
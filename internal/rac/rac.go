@@ -0,0 +1,180 @@
+// Package rac はRAC（Real Application Clusters）環境で、セッションの接続先インスタンスと
+// キャッシュ・フュージョン（インスタンス間のブロック転送）による待機イベントを取得する。
+//
+// RACでは、他のインスタンスのバッファキャッシュにあるブロックをインターコネクト経由で受け取るため、
+// 単一インスタンスではバッファキャッシュのヒットになる読み取りでも gc cr block 2-way などの待機が発生する。
+package rac
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/repository"
+)
+
+// Instance - データベース・インスタンス
+type Instance struct {
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	Host   string `json:"host,omitempty"`
+}
+
+// String - 表示用のインスタンス名（例: ORCL1（#1, node1））
+func (i Instance) String() string {
+	if i.Host == "" {
+		return fmt.Sprintf("%s（#%d）", i.Name, i.Number)
+	}
+	return fmt.Sprintf("%s（#%d, %s）", i.Name, i.Number, i.Host)
+}
+
+// Instances - クラスタを構成するインスタンスの一覧（GV$INSTANCEの参照権限が必要）
+//
+// 単一インスタンスのデータベースでは1件になる。
+func Instances(q repository.DBTX) ([]Instance, error) {
+	rows, err := q.Query(`SELECT inst_id, instance_name, host_name FROM gv$instance ORDER BY inst_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query gv$instance: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var instances []Instance
+	for rows.Next() {
+		var inst Instance
+		if err := rows.Scan(&inst.Number, &inst.Name, &inst.Host); err != nil {
+			return nil, fmt.Errorf("failed to scan instance row: %w", err)
+		}
+		instances = append(instances, inst)
+	}
+	return instances, rows.Err()
+}
+
+// Current - セッションの接続先インスタンス（USERENVから取得するため権限は不要）
+func Current(q repository.DBTX) (Instance, error) {
+	var inst Instance
+	query := `
+		SELECT
+			TO_NUMBER(SYS_CONTEXT('USERENV', 'INSTANCE')),
+			SYS_CONTEXT('USERENV', 'INSTANCE_NAME'),
+			NVL(SYS_CONTEXT('USERENV', 'SERVER_HOST'), ' ')
+		FROM dual`
+	if err := q.QueryRow(query).Scan(&inst.Number, &inst.Name, &inst.Host); err != nil {
+		return Instance{}, fmt.Errorf("failed to query session instance: %w", err)
+	}
+	inst.Host = strings.TrimSpace(inst.Host)
+	return inst, nil
+}
+
+// Wait - 待機イベントの回数と時間
+type Wait struct {
+	Event string        `json:"event"`
+	Waits int64         `json:"waits"`
+	Time  time.Duration `json:"time"`
+}
+
+// Waits - 待機イベント名ごとの累計
+type Waits map[string]Wait
+
+// SessionWaits - 現在のセッションのClusterクラスの待機（gc cr/current block ...）の累計（V$SESSION_EVENTの参照権限が必要）
+func SessionWaits(q repository.DBTX) (Waits, error) {
+	rows, err := q.Query(`
+		SELECT event, total_waits, time_waited_micro
+		FROM v$session_event
+		WHERE sid = SYS_CONTEXT('USERENV', 'SID')
+		AND wait_class = 'Cluster'`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v$session_event: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	waits := make(Waits)
+	for rows.Next() {
+		var w Wait
+		var micros int64
+		if err := rows.Scan(&w.Event, &w.Waits, &micros); err != nil {
+			return nil, fmt.Errorf("failed to scan session event row: %w", err)
+		}
+		w.Time = time.Duration(micros) * time.Microsecond
+		waits[w.Event] = w
+	}
+	return waits, rows.Err()
+}
+
+// Sub - before以降に増えた待機（時間の長い順、増えていないイベントは含めない）
+func (w Waits) Sub(before Waits) []Wait {
+	var diff []Wait
+	for event, after := range w {
+		d := Wait{Event: event, Waits: after.Waits - before[event].Waits, Time: after.Time - before[event].Time}
+		if d.Waits > 0 || d.Time > 0 {
+			diff = append(diff, d)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		if diff[i].Time != diff[j].Time {
+			return diff[i].Time > diff[j].Time
+		}
+		return diff[i].Event < diff[j].Event
+	})
+	return diff
+}
+
+// Measurement - 手法の計測に使ったインスタンスと、計測中のClusterクラスの待機
+type Measurement struct {
+	Instance Instance `json:"instance"`
+	// Pinned - -rac-pin でインスタンスを固定した接続で計測したか
+	Pinned  bool   `json:"pinned,omitempty"`
+	GCWaits []Wait `json:"gc_waits,omitempty"`
+	// WaitsUnavailable - V$SESSION_EVENTを参照できず待機を取得していない
+	WaitsUnavailable bool `json:"waits_unavailable,omitempty"`
+}
+
+// GCWaitCount - Clusterクラスの待機回数の合計
+func (m *Measurement) GCWaitCount() int64 {
+	var total int64
+	for _, w := range m.GCWaits {
+		total += w.Waits
+	}
+	return total
+}
+
+// GCWaitTime - Clusterクラスの待機時間の合計
+func (m *Measurement) GCWaitTime() time.Duration {
+	var total time.Duration
+	for _, w := range m.GCWaits {
+		total += w.Time
+	}
+	return total
+}
+
+// ParsePins - 「シナリオ=インスタンス名」のカンマ区切りを解釈する
+func ParsePins(spec string) (map[string]string, error) {
+	pins := make(map[string]string)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		scenario, instance, ok := strings.Cut(part, "=")
+		scenario, instance = strings.TrimSpace(scenario), strings.TrimSpace(instance)
+		if !ok || scenario == "" || instance == "" {
+			return nil, fmt.Errorf("invalid pin %q (want SCENARIO=INSTANCE)", part)
+		}
+		if _, exists := pins[scenario]; exists {
+			return nil, fmt.Errorf("duplicate pin for scenario %q", scenario)
+		}
+		pins[scenario] = instance
+	}
+	if len(pins) == 0 {
+		return nil, fmt.Errorf("no pins in %q", spec)
+	}
+	return pins, nil
+}
@@ -0,0 +1,65 @@
+package rac
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePins(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "two scenarios", spec: "orders=ORCL1, employees = ORCL2", want: map[string]string{"orders": "ORCL1", "employees": "ORCL2"}},
+		{name: "empty", spec: " ", wantErr: "no pins"},
+		{name: "missing instance", spec: "orders=", wantErr: "want SCENARIO=INSTANCE"},
+		{name: "duplicate", spec: "orders=ORCL1,orders=ORCL2", wantErr: "duplicate pin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePins(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePins() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePins() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePins() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitsSub(t *testing.T) {
+	before := Waits{
+		"gc cr block 2-way": {Event: "gc cr block 2-way", Waits: 10, Time: time.Millisecond},
+		"gc buffer busy":    {Event: "gc buffer busy", Waits: 3, Time: 5 * time.Millisecond},
+	}
+	after := Waits{
+		"gc cr block 2-way":      {Event: "gc cr block 2-way", Waits: 110, Time: 11 * time.Millisecond},
+		"gc buffer busy":         {Event: "gc buffer busy", Waits: 3, Time: 5 * time.Millisecond},
+		"gc current block 2-way": {Event: "gc current block 2-way", Waits: 4, Time: 20 * time.Millisecond},
+	}
+
+	got := after.Sub(before)
+	want := []Wait{
+		{Event: "gc current block 2-way", Waits: 4, Time: 20 * time.Millisecond},
+		{Event: "gc cr block 2-way", Waits: 100, Time: 10 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Sub() = %v, want %v", got, want)
+	}
+
+	m := Measurement{GCWaits: got}
+	if m.GCWaitCount() != 104 || m.GCWaitTime() != 30*time.Millisecond {
+		t.Errorf("GCWaitCount() = %d, GCWaitTime() = %v, want 104, 30ms", m.GCWaitCount(), m.GCWaitTime())
+	}
+}
@@ -0,0 +1,265 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownColumn - 並べ替え・列選択で存在しない列を指定した
+var ErrUnknownColumn = errors.New("unknown column")
+
+// Align - 列の寄せ方向
+type Align int
+
+// 寄せ方向（数値の列は右寄せ）
+const (
+	AlignLeft Align = iota
+	AlignRight
+)
+
+// Column - 表の列定義（Keyは -sort / -columns で指定する名前）
+type Column struct {
+	Key    string
+	Header string
+	Align  Align
+}
+
+// Cell - 表のセル（数値のセルは表示文字列ではなく値で並べ替える）
+type Cell struct {
+	Text    string
+	value   float64
+	numeric bool
+}
+
+// Text - 文字列のセル
+func Text(s string) Cell {
+	return Cell{Text: s}
+}
+
+// Number - 表示文字列と並べ替え用の値を持つセル
+func Number(text string, value float64) Cell {
+	return Cell{Text: text, value: value, numeric: true}
+}
+
+// Int - 整数のセル
+func Int(v int64) Cell {
+	return Number(strconv.FormatInt(v, 10), float64(v))
+}
+
+// Float - 書式を指定した小数のセル
+func Float(format string, v float64) Cell {
+	return Number(fmt.Sprintf(format, v), v)
+}
+
+// Duration - 実行時間のセル
+func Duration(d time.Duration) Cell {
+	return Number(d.String(), float64(d))
+}
+
+// Bytes - バイト数のセル（読みやすい単位で表示し、バイト数で並べ替える）
+func Bytes(b int64) Cell {
+	return Number(FormatBytes(b), float64(b))
+}
+
+// FormatBytes - バイト数を読みやすい単位に変換
+func FormatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// Table - 列定義と行からなる表
+type Table struct {
+	columns []Column
+	rows    [][]Cell
+}
+
+// NewTable - 列を指定して表を作成
+func NewTable(columns ...Column) *Table {
+	return &Table{columns: columns}
+}
+
+// AddRow - 行を追加（足りないセルは空として扱う）
+func (t *Table) AddRow(cells ...Cell) {
+	row := make([]Cell, len(t.columns))
+	copy(row, cells)
+	t.rows = append(t.rows, row)
+}
+
+// Len - 行数
+func (t *Table) Len() int {
+	return len(t.rows)
+}
+
+// columnIndex - Keyから列の位置を求める
+func (t *Table) columnIndex(key string) (int, error) {
+	for i, c := range t.columns {
+		if c.Key == key {
+			return i, nil
+		}
+	}
+	keys := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		keys[i] = c.Key
+	}
+	return 0, fmt.Errorf("%w: %q (available: %s)", ErrUnknownColumn, key, strings.Join(keys, ", "))
+}
+
+// SortBy - 指定した列で安定ソート（値のないセルは向きに関係なく末尾に置く）
+func (t *Table) SortBy(key string, descending bool) error {
+	col, err := t.columnIndex(key)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(t.rows, func(i, j int) bool {
+		a, b := t.rows[i][col], t.rows[j][col]
+		if a.numeric != b.numeric {
+			return a.numeric
+		}
+		if a.numeric {
+			if descending {
+				return a.value > b.value
+			}
+			return a.value < b.value
+		}
+		if descending {
+			return a.Text > b.Text
+		}
+		return a.Text < b.Text
+	})
+	return nil
+}
+
+// Select - 表示する列を指定した順に絞り込む
+func (t *Table) Select(keys ...string) error {
+	indexes := make([]int, len(keys))
+	for i, key := range keys {
+		col, err := t.columnIndex(key)
+		if err != nil {
+			return err
+		}
+		indexes[i] = col
+	}
+
+	columns := make([]Column, len(indexes))
+	for i, col := range indexes {
+		columns[i] = t.columns[col]
+	}
+	for r, row := range t.rows {
+		selected := make([]Cell, len(indexes))
+		for i, col := range indexes {
+			selected[i] = row[col]
+		}
+		t.rows[r] = selected
+	}
+	t.columns = columns
+	return nil
+}
+
+// TableOptions - 並べ替えと列選択の指定
+type TableOptions struct {
+	// Sort - 並べ替える列のKey（先頭に - を付けると降順、空なら追加順のまま）
+	Sort string
+	// Columns - 表示する列のKey（空ならすべて）
+	Columns []string
+}
+
+// ParseTableOptions - -sort / -columns の値を解析（列はカンマ区切り）
+func ParseTableOptions(sortKey, columns string) TableOptions {
+	opts := TableOptions{Sort: strings.TrimSpace(sortKey)}
+	for _, key := range strings.Split(columns, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			opts.Columns = append(opts.Columns, key)
+		}
+	}
+	return opts
+}
+
+// Check - 指定された列がすべて存在するか（表は変更しない）
+func (t *Table) Check(opts TableOptions) error {
+	if opts.Sort != "" {
+		if _, err := t.columnIndex(strings.TrimPrefix(opts.Sort, "-")); err != nil {
+			return err
+		}
+	}
+	for _, key := range opts.Columns {
+		if _, err := t.columnIndex(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply - 並べ替えてから列を絞り込む（表示しない列でも並べ替えに使える）
+func (t *Table) Apply(opts TableOptions) error {
+	if opts.Sort != "" {
+		key, descending := strings.CutPrefix(opts.Sort, "-")
+		if err := t.SortBy(key, descending); err != nil {
+			return err
+		}
+	}
+	if len(opts.Columns) > 0 {
+		return t.Select(opts.Columns...)
+	}
+	return nil
+}
+
+// lines - 見出し行・区切り線・各行を列幅を揃えた文字列にする
+func (t *Table) lines() []string {
+	widths := make([]int, len(t.columns))
+	for i, c := range t.columns {
+		widths[i] = displayWidth(c.Header)
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell.Text))
+		}
+	}
+
+	headers := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		headers[i] = c.Header
+	}
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	total += len(columnSeparator) * max(len(widths)-1, 0)
+
+	lines := []string{t.formatRow(headers, widths), strings.Repeat("-", total)}
+	for _, row := range t.rows {
+		texts := make([]string, len(row))
+		for i, cell := range row {
+			texts[i] = cell.Text
+		}
+		lines = append(lines, t.formatRow(texts, widths))
+	}
+	return lines
+}
+
+// columnSeparator - 列の区切り
+const columnSeparator = "  "
+
+// formatRow - 列ごとの寄せ方向に従って表示幅で揃える
+func (t *Table) formatRow(texts []string, widths []int) string {
+	parts := make([]string, len(texts))
+	for i, text := range texts {
+		if t.columns[i].Align == AlignRight {
+			parts[i] = padLeft(text, widths[i])
+		} else {
+			parts[i] = padRight(text, widths[i])
+		}
+	}
+	return strings.TrimRight(strings.Join(parts, columnSeparator), " ")
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestTable - 全角の見出し・値と、値のないセルを含む表
+func newTestTable() *Table {
+	table := NewTable(
+		Column{Key: "name", Header: "手法"},
+		Column{Key: "count", Header: "件数", Align: AlignRight},
+		Column{Key: "time", Header: "時間", Align: AlignRight},
+	)
+	table.AddRow(Text("Redis"), Int(12), Duration(1500*time.Microsecond))
+	table.AddRow(Text("結果キャッシュ"), Int(3), Duration(250*time.Microsecond))
+	table.AddRow(Text("なし"), Text("-"), Duration(10*time.Millisecond))
+	return table
+}
+
+func TestTableLines(t *testing.T) {
+	want := []string{
+		"手法            件数   時間",
+		"---------------------------",
+		"Redis             12  1.5ms",
+		"結果キャッシュ     3  250µs",
+		"なし               -   10ms",
+	}
+	if got := newTestTable().lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTableApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		sortKey string
+		columns string
+		want    []string
+	}{
+		{
+			// 数値は表示文字列ではなく値で並べ、値のないセルは末尾に置く
+			name:    "numeric descending",
+			sortKey: "-count",
+			columns: "count,name",
+			want: []string{
+				"件数  手法",
+				"--------------------",
+				"  12  Redis",
+				"   3  結果キャッシュ",
+				"   -  なし",
+			},
+		},
+		{
+			name:    "numeric ascending",
+			sortKey: "count",
+			want: []string{
+				"手法            件数   時間",
+				"---------------------------",
+				"結果キャッシュ     3  250µs",
+				"Redis             12  1.5ms",
+				"なし               -   10ms",
+			},
+		},
+		{
+			// 表示しない列でも並べ替えに使える
+			name:    "sort by hidden column",
+			sortKey: "-time",
+			columns: " name ",
+			want: []string{
+				"手法",
+				"--------------",
+				"なし",
+				"Redis",
+				"結果キャッシュ",
+			},
+		},
+		{
+			name:    "text ascending",
+			sortKey: "name",
+			columns: "time,name",
+			want: []string{
+				" 時間  手法",
+				"---------------------",
+				"1.5ms  Redis",
+				" 10ms  なし",
+				"250µs  結果キャッシュ",
+			},
+		},
+	}
+	for _, tt := range tests {
+		table := newTestTable()
+		opts := ParseTableOptions(tt.sortKey, tt.columns)
+		if err := table.Check(opts); err != nil {
+			t.Errorf("%s: Check() failed: %v", tt.name, err)
+			continue
+		}
+		if err := table.Apply(opts); err != nil {
+			t.Errorf("%s: Apply() failed: %v", tt.name, err)
+			continue
+		}
+		if got := table.lines(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: lines() =\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTableUnknownColumn(t *testing.T) {
+	for _, opts := range []TableOptions{{Sort: "-memory"}, {Columns: []string{"name", "memory"}}} {
+		table := newTestTable()
+		if err := table.Check(opts); !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("Check(%+v) error = %v, want %v", opts, err, ErrUnknownColumn)
+		}
+		if err := table.Apply(opts); !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("Apply(%+v) error = %v, want %v", opts, err, ErrUnknownColumn)
+		}
+	}
+}
+
+func TestTableAddRow(t *testing.T) {
+	table := NewTable(Column{Key: "a", Header: "A"}, Column{Key: "b", Header: "B"})
+	table.AddRow(Text("x"))
+	if table.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", table.Len())
+	}
+	// 足りないセルは空として扱い、末尾の空白は削る
+	if got := table.lines()[2]; got != "x" {
+		t.Errorf("lines()[2] = %q, want %q", got, "x")
+	}
+}
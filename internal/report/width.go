@@ -0,0 +1,111 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// runeRange - コードポイントの範囲（両端を含む）
+type runeRange struct {
+	lo, hi rune
+}
+
+// wideRanges - 端末で2桁幅に表示される文字の範囲（East Asian Width の W / F と主な絵文字、昇順）
+var wideRanges = []runeRange{
+	{0x1100, 0x115F}, // ハングル字母（初声）
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x23F0, 0x23F0},
+	{0x23F3, 0x23F3},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267F, 0x267F},
+	{0x2693, 0x2693},
+	{0x26A1, 0x26A1},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x26CE, 0x26CE},
+	{0x26D4, 0x26D4},
+	{0x26EA, 0x26EA},
+	{0x26F2, 0x26F3},
+	{0x26F5, 0x26F5},
+	{0x26FA, 0x26FA},
+	{0x26FD, 0x26FD},
+	{0x2705, 0x2705}, // ✅
+	{0x270A, 0x270B},
+	{0x2728, 0x2728},
+	{0x274C, 0x274C}, // ❌
+	{0x274E, 0x274E},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27B0, 0x27B0},
+	{0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x2E80, 0x303E},   // CJK部首・記号と句読点
+	{0x3041, 0x33FF},   // ひらがな・カタカナ・CJK互換文字
+	{0x3400, 0x4DBF},   // CJK統合漢字拡張A
+	{0x4E00, 0x9FFF},   // CJK統合漢字
+	{0xA000, 0xA4CF},   // イ文字
+	{0xA960, 0xA97F},   // ハングル字母拡張A
+	{0xAC00, 0xD7A3},   // ハングル音節
+	{0xF900, 0xFAFF},   // CJK互換漢字
+	{0xFE10, 0xFE19},   // 縦書き形
+	{0xFE30, 0xFE6F},   // CJK互換形・小字形
+	{0xFF00, 0xFF60},   // 全角英数・記号
+	{0xFFE0, 0xFFE6},   // 全角記号
+	{0x1F300, 0x1F64F}, // 絵文字（記号・顔文字）
+	{0x1F680, 0x1F6FF}, // 絵文字（乗り物・地図記号）
+	{0x1F900, 0x1F9FF}, // 絵文字（補助）
+	{0x20000, 0x2FFFD}, // CJK統合漢字拡張B以降
+	{0x30000, 0x3FFFD},
+}
+
+// displayWidth - 端末上の表示幅（全角文字は2桁として数える）
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth - 1文字の表示幅（結合文字・異体字セレクタ・ゼロ幅文字は0）
+func runeWidth(r rune) int {
+	switch {
+	case r == 0x200D, r >= 0xFE00 && r <= 0xFE0F:
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// isWide - 2桁幅の文字か
+func isWide(r rune) bool {
+	i := sort.Search(len(wideRanges), func(i int) bool { return wideRanges[i].hi >= r })
+	return i < len(wideRanges) && wideRanges[i].lo <= r
+}
+
+// padRight / padLeft - 表示幅がwidthになるよう空白で埋める
+func padRight(s string, width int) string {
+	if n := width - displayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+func padLeft(s string, width int) string {
+	if n := width - displayWidth(s); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
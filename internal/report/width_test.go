@@ -0,0 +1,86 @@
+package report
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{s: "", want: 0},
+		{s: "Redis", want: 5},
+		{s: "あいう", want: 6},
+		{s: "結果キャッシュ", want: 14},
+		{s: "ｱｲｳ", want: 3}, // 半角カナ
+		{s: "ＡＢ", want: 4},  // 全角英字
+		{s: "한글", want: 4},
+		{s: "、。", want: 4},
+		{s: "A列1件✅", want: 8},
+		{s: "✅ ❌ 🚀", want: 8},
+		// 結合文字・濁点の結合形は前の文字に重なる
+		{s: "e\u0301", want: 1},
+		{s: "\u304b\u3099", want: 2},
+		// 異体字セレクタは幅を持たない（❤ はW/Fではないので1桁）
+		{s: "\u2764\ufe0f", want: 1},
+		{s: "\u263a\ufe0e", want: 1},
+		// ゼロ幅接合子は0桁として、つながった絵文字はそれぞれ数える
+		{s: "\U0001F468\u200d\U0001F469\u200d\U0001F467", want: 6},
+		{s: "a\u200bb", want: 2},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.s); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRuneWidthBoundaries(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{r: 0x10FF, want: 1},
+		{r: 0x1100, want: 2},
+		{r: 0x115F, want: 2},
+		{r: 0x1160, want: 1},
+		{r: 0x2E7F, want: 1},
+		{r: 0x2E80, want: 2},
+		{r: 0x303E, want: 2},
+		{r: 0x303F, want: 1},
+		{r: 0x3041, want: 2},
+		{r: 0xFF60, want: 2},
+		{r: 0xFF61, want: 1},
+		{r: 0x1F64F, want: 2},
+		{r: 0x1F650, want: 1},
+		{r: 0x3FFFD, want: 2},
+		{r: 0x3FFFE, want: 1},
+	}
+	for _, tt := range tests {
+		if got := runeWidth(tt.r); got != tt.want {
+			t.Errorf("runeWidth(%U) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		s         string
+		width     int
+		wantLeft  string
+		wantRight string
+	}{
+		{s: "ab", width: 4, wantLeft: "  ab", wantRight: "ab  "},
+		{s: "漢字", width: 6, wantLeft: "  漢字", wantRight: "漢字  "},
+		{s: "✅", width: 3, wantLeft: " ✅", wantRight: "✅ "},
+		// 幅を超える場合はそのまま
+		{s: "キャッシュ", width: 4, wantLeft: "キャッシュ", wantRight: "キャッシュ"},
+	}
+	for _, tt := range tests {
+		if got := padLeft(tt.s, tt.width); got != tt.wantLeft {
+			t.Errorf("padLeft(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.wantLeft)
+		}
+		if got := padRight(tt.s, tt.width); got != tt.wantRight {
+			t.Errorf("padRight(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.wantRight)
+		}
+	}
+}
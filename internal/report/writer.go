@@ -0,0 +1,109 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 区切り線の幅
+const (
+	titleRuleWidth   = 80
+	sectionRuleWidth = 50
+)
+
+// Writer - コンソール向けレポートの書き出し（見出し・箇条書き・キーと値・表）
+//
+// 表示関数がそれぞれ改行や区切り線を組み立てると書式が崩れやすいため、
+// 改行の付け方と字下げをここに集約する。
+type Writer struct {
+	out io.Writer
+}
+
+// New - 出力先を指定してWriterを作成
+func New(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Stdout - 標準出力に書き出すWriter（-json で差し替えた os.Stdout にも追従するよう書き込み時に参照する）
+func Stdout() *Writer {
+	return &Writer{}
+}
+
+// writer - 書き込み先
+func (w *Writer) writer() io.Writer {
+	if w.out == nil {
+		return os.Stdout
+	}
+	return w.out
+}
+
+// Title - レポート全体の見出し（上下を = の線で囲む）
+func (w *Writer) Title(title string) {
+	rule := strings.Repeat("=", titleRuleWidth)
+	w.Linef("\n%s\n%s\n%s", rule, title, rule)
+}
+
+// Heading - 処理単位の見出し（=== 見出し ===）
+func (w *Writer) Heading(title string) {
+	w.Linef("\n=== %s ===", title)
+}
+
+// Section - レポート内の節（■ 見出し と区切り線）
+func (w *Writer) Section(title string) {
+	w.Linef("\n■ %s\n%s", title, strings.Repeat("-", sectionRuleWidth))
+}
+
+// Step - 番号付きの手順見出し（1. 見出し:）
+func (w *Writer) Step(number int, title string) {
+	w.Linef("\n%d. %s:", number, title)
+}
+
+// Blank - 空行
+func (w *Writer) Blank() {
+	w.Line("")
+}
+
+// Line - 1行を書き出す
+func (w *Writer) Line(s string) {
+	fmt.Fprintln(w.writer(), s)
+}
+
+// Linef - 書式を指定して1行を書き出す（末尾の改行は不要）
+func (w *Writer) Linef(format string, args ...interface{}) {
+	fmt.Fprintf(w.writer(), format+"\n", args...)
+}
+
+// Indentf - 字下げして1行を書き出す（level 1 につき空白2つ）
+func (w *Writer) Indentf(level int, format string, args ...interface{}) {
+	w.Linef(strings.Repeat("  ", level)+format, args...)
+}
+
+// KeyValue - 「キー: 値」の行を字下げして書き出す
+func (w *Writer) KeyValue(level int, key string, value interface{}) {
+	w.Indentf(level, "%s: %v", key, value)
+}
+
+// Bullets - 箇条書き（markerが空の場合は項目にマーカーが含まれているものとして扱う）
+func (w *Writer) Bullets(level int, marker string, items []string) {
+	for _, item := range items {
+		if marker == "" {
+			w.Indentf(level, "%s", item)
+			continue
+		}
+		w.Indentf(level, "%s %s", marker, item)
+	}
+}
+
+// Table - 表を書き出す
+func (w *Writer) Table(t *Table) {
+	w.IndentTable(0, t)
+}
+
+// IndentTable - 表を字下げして書き出す
+func (w *Writer) IndentTable(level int, t *Table) {
+	for _, line := range t.lines() {
+		w.Indentf(level, "%s", line)
+	}
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf)
+
+	w.Title("キャッシュ比較")
+	w.Heading("N+1")
+	w.Section("結果")
+	w.Step(1, "準備")
+	w.Linef("%d件 / %s", 3, "12ms")
+	w.KeyValue(1, "ヒット率", "75.0%")
+	w.Bullets(1, "-", []string{"a", "b"})
+	w.Bullets(2, "", []string{"✅ c"})
+	w.Blank()
+	table := NewTable(Column{Key: "name", Header: "手法"}, Column{Key: "count", Header: "件数", Align: AlignRight})
+	table.AddRow(Text("Redis"), Int(12))
+	w.Table(table)
+	w.IndentTable(1, table)
+
+	want := strings.Join([]string{
+		"",
+		strings.Repeat("=", 80),
+		"キャッシュ比較",
+		strings.Repeat("=", 80),
+		"",
+		"=== N+1 ===",
+		"",
+		"■ 結果",
+		strings.Repeat("-", 50),
+		"",
+		"1. 準備:",
+		"3件 / 12ms",
+		"  ヒット率: 75.0%",
+		"  - a",
+		"  - b",
+		"    ✅ c",
+		"",
+		"手法   件数",
+		"-----------",
+		"Redis    12",
+		"  手法   件数",
+		"  -----------",
+		"  Redis    12",
+		"",
+	}, "\n")
+	got := buf.String()
+	if got != want {
+		t.Errorf("Writer output =\n%s\nwant\n%s", got, want)
+	}
+	// 改行はエスケープされずに書き出される
+	if strings.Contains(got, `\n`) {
+		t.Errorf("Writer output contains a literal \\n: %q", got)
+	}
+}
+
+func TestWriterLinefKeepsPercent(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf)
+	w.Line("100%")
+	w.Linef("%s", "50%")
+	if got, want := buf.String(), "100%\n50%\n"; got != want {
+		t.Errorf("Writer output = %q, want %q", got, want)
+	}
+}
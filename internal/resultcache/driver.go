@@ -0,0 +1,132 @@
+package resultcache
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+
+	"oracle-n-plus-1-demo/internal/driverwrap"
+)
+
+// Connector - baseが作る接続の問い合わせの結果をcacheに保存し、同じ問い合わせにはDBへ送らずに返すコネクター
+//
+// 結果は最後の行まで読んでから返すため、ミスしたときの最初の行までの時間はキャッシュなしより長くなる。
+// トランザクション中の問い合わせはキャッシュを使わない（自分の未コミットの変更が見えなくなるため）。
+// 同じ接続プールでDMLを実行するとすべてのエントリを捨てるが、ほかの接続プール・ほかのセッションの変更は分からない。
+func Connector(base driver.Connector, cache *Cache) driver.Connector {
+	return driverwrap.Connector(base, func() driverwrap.Hooks {
+		return &hooks{cache: cache}
+	})
+}
+
+// hooks - 1つの接続の問い合わせの結果をキャッシュするフック
+type hooks struct {
+	driverwrap.Passthrough
+	cache *Cache
+	// inTx - トランザクション中か
+	inTx bool
+}
+
+// Begin - トランザクションが終わるまでキャッシュを使わない
+func (h *hooks) Begin() {
+	h.inTx = true
+}
+
+// End - トランザクションを終え、キャッシュの利用を再開する
+func (h *hooks) End(_ string, execute func() error) error {
+	h.inTx = false
+	return execute()
+}
+
+// Exec - DMLなどを実行し、成功したらキャッシュを捨てる
+func (h *hooks) Exec(_ string, _ []driver.NamedValue, execute func() (driver.Result, error)) (driver.Result, error) {
+	result, err := execute()
+	if err == nil {
+		h.cache.invalidate()
+	}
+	return result, err
+}
+
+// Query - キャッシュにあれば返し、なければexecuteで問い合わせて全行を保存する
+func (h *hooks) Query(query string, args []driver.NamedValue, execute func() (driver.Rows, error)) (driver.Rows, error) {
+	if h.inTx || !Cacheable(query) {
+		h.cache.bypass()
+		return execute()
+	}
+	key := Key(query, args)
+	if r, ok := h.cache.get(key); ok {
+		return &replay{result: r}, nil
+	}
+
+	rs, err := execute()
+	if err != nil {
+		return rs, err
+	}
+	r, err := readAll(rs)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.rows) <= h.cache.opts.MaxRows {
+		h.cache.put(key, r)
+	} else {
+		h.cache.bypass()
+	}
+	return &replay{result: r}, nil
+}
+
+// readAll - すべての行を読んでカーソルを閉じる（バイト列はドライバーのバッファーを再利用されないようコピーする）
+func readAll(rs driver.Rows) (r *result, err error) {
+	defer func() {
+		if cerr := rs.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	r = &result{columns: rs.Columns()}
+	for {
+		dest := make([]driver.Value, len(r.columns))
+		if err := rs.Next(dest); err != nil {
+			if errors.Is(err, io.EOF) {
+				return r, nil
+			}
+			return nil, err
+		}
+		for i, v := range dest {
+			if b, ok := v.([]byte); ok {
+				dest[i] = append([]byte(nil), b...)
+			}
+		}
+		r.rows = append(r.rows, dest)
+	}
+}
+
+// replay - キャッシュした結果を先頭から返すカーソル
+type replay struct {
+	result *result
+	next   int
+}
+
+// Columns - 列名
+func (r *replay) Columns() []string {
+	return r.result.columns
+}
+
+// Close - 閉じる（DBのカーソルはすでに閉じている）
+func (r *replay) Close() error {
+	return nil
+}
+
+// Next - 次の行（キャッシュした値は共有するため、バイト列はコピーして渡す）
+func (r *replay) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	for i, v := range r.result.rows[r.next] {
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+		dest[i] = v
+	}
+	r.next++
+	return nil
+}
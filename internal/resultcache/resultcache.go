@@ -0,0 +1,255 @@
+// Package resultcache - ドライバーの接続を包み、問い合わせの結果（全行）をプロセス内にキャッシュする
+//
+// OracleのサーバーResult Cache（RESULT_CACHEヒント）に最も近いGoの実装で、キーは正規化したSQLとバインド変数の値。
+// Oracleは依存する表へのDMLがコミットされると結果を無効化するが、このキャッシュは表の変更を知らないため、
+// ほかのセッション・ほかのプロセスがコミットした変更はTTLが切れるまで反映されない。
+// SYSDATEなどを含む問い合わせも同じキーのまま返す（Oracleはこうした問い合わせをResult Cacheに載せない）。
+package resultcache
+
+import (
+	"container/list"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+	"oracle-n-plus-1-demo/internal/querylog"
+)
+
+const (
+	// DefaultMaxEntries - キャッシュするエントリ数の既定の上限（超えたら最も古く使われたものから追い出す）
+	DefaultMaxEntries = 128
+	// DefaultMaxRows - 1つの結果としてキャッシュする行数の既定の上限（超えた結果はキャッシュせずにそのまま返す）
+	DefaultMaxRows = 10000
+)
+
+// Options - キャッシュの設定
+type Options struct {
+	// TTL - エントリの有効期限（ほかのセッションの更新はこの時間まで反映されない）
+	TTL time.Duration
+	// MaxEntries - エントリ数の上限（0なら DefaultMaxEntries）
+	MaxEntries int
+	// MaxRows - 1つの結果の行数の上限（0なら DefaultMaxRows）
+	MaxRows int
+	// Clock - 有効期限の判定に使う時計（nilなら実際の時刻）
+	Clock clock.Clock
+}
+
+// Validate - 設定の値を確認
+func (o Options) Validate() error {
+	if o.TTL <= 0 {
+		return errors.New("ttl must be positive")
+	}
+	if o.MaxEntries < 0 {
+		return fmt.Errorf("invalid max entries: %d", o.MaxEntries)
+	}
+	if o.MaxRows < 0 {
+		return fmt.Errorf("invalid max rows: %d", o.MaxRows)
+	}
+	return nil
+}
+
+// Stats - キャッシュの利用状況
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Expired - 有効期限を過ぎていたためDBから取得し直した回数（Missesに含む）
+	Expired int64 `json:"expired"`
+	// Evictions - エントリ数の上限を超えて追い出した回数
+	Evictions int64 `json:"evictions"`
+	// Bypassed - キャッシュを使わずに実行した問い合わせ（トランザクション中・V$ビュー・行数の上限超え）
+	Bypassed int64 `json:"bypassed"`
+	// Invalidations - 同じ接続プールで実行したDMLのためにすべてのエントリを捨てた回数
+	Invalidations int64 `json:"invalidations"`
+	// Entries / Rows - 現在のエントリ数と、キャッシュしている行数の合計
+	Entries int   `json:"entries"`
+	Rows    int64 `json:"rows"`
+}
+
+// Lookups - キャッシュを引いた回数
+func (s Stats) Lookups() int64 {
+	return s.Hits + s.Misses
+}
+
+// HitRate - 引いた回数のうちヒットした割合（%）
+func (s Stats) HitRate() float64 {
+	if s.Lookups() == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups()) * 100
+}
+
+// Sub - 2つのスナップショットの差分（回数は s - before、エントリ数と行数は s の値）
+func (s Stats) Sub(before Stats) Stats {
+	return Stats{
+		Hits:          s.Hits - before.Hits,
+		Misses:        s.Misses - before.Misses,
+		Expired:       s.Expired - before.Expired,
+		Evictions:     s.Evictions - before.Evictions,
+		Bypassed:      s.Bypassed - before.Bypassed,
+		Invalidations: s.Invalidations - before.Invalidations,
+		Entries:       s.Entries,
+		Rows:          s.Rows,
+	}
+}
+
+// result - キャッシュした問い合わせの結果
+type result struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// entry - LRUの1エントリ
+type entry struct {
+	key       string
+	result    *result
+	expiresAt time.Time
+}
+
+// Cache - 問い合わせの結果のLRUキャッシュ（Connector に渡し、複数の接続から並行して使ってよい）
+type Cache struct {
+	opts  Options
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   Stats
+}
+
+// New - Cacheのコンストラクタ
+func New(opts Options) *Cache {
+	if opts.MaxEntries == 0 {
+		opts.MaxEntries = DefaultMaxEntries
+	}
+	if opts.MaxRows == 0 {
+		opts.MaxRows = DefaultMaxRows
+	}
+	return &Cache{
+		opts:    opts,
+		clock:   clock.OrSystem(opts.Clock),
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// TTL - エントリの有効期限
+func (c *Cache) TTL() time.Duration {
+	return c.opts.TTL
+}
+
+// Stats - これまでの利用状況
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Purge - すべてのエントリを捨てる（回数の統計は残す）
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purgeLocked()
+}
+
+// invalidate - DMLを実行したためすべてのエントリを捨てる
+func (c *Cache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lru.Len() > 0 {
+		c.stats.Invalidations++
+	}
+	c.purgeLocked()
+}
+
+func (c *Cache) purgeLocked() {
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.stats.Entries = 0
+	c.stats.Rows = 0
+}
+
+// get - キーの結果（ないか有効期限を過ぎている場合はok=false、ミスとして数える）
+func (c *Cache) get(key string) (*result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.clock.Now().Before(e.expiresAt) {
+		c.removeLocked(el)
+		c.stats.Misses++
+		c.stats.Expired++
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	c.stats.Hits++
+	return e.result, true
+}
+
+// put - 結果を保存（上限を超えたら最も古く使われたエントリを追い出す）
+func (c *Cache) put(key string, r *result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.removeLocked(el)
+	}
+	c.entries[key] = c.lru.PushFront(&entry{key: key, result: r, expiresAt: c.clock.Now().Add(c.opts.TTL)})
+	c.stats.Entries++
+	c.stats.Rows += int64(len(r.rows))
+	for c.lru.Len() > c.opts.MaxEntries {
+		c.removeLocked(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// bypass - キャッシュを使わずに実行した問い合わせを数える
+func (c *Cache) bypass() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Bypassed++
+}
+
+func (c *Cache) removeLocked(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.key)
+	c.stats.Entries--
+	c.stats.Rows -= int64(len(e.result.rows))
+}
+
+// Cacheable - キャッシュの対象となる問い合わせか（SELECT・WITHのうち、V$ビューと FOR UPDATE を除く）
+func Cacheable(query string) bool {
+	query = querylog.Statement(query)
+	switch querylog.Keyword(query) {
+	case "SELECT", "WITH":
+	default:
+		return false
+	}
+	return !querylog.IsDictionary(query) && !strings.Contains(strings.ToUpper(query), "FOR UPDATE")
+}
+
+// Key - 問い合わせのキャッシュのキー（空白をまとめたSQLと、バインド変数の名前・型・値）
+//
+// セッションのNLS設定やフラッシュバックのSCNはキーに含めない。同じ接続プールの接続は同じ設定で作ること。
+func Key(query string, args []driver.NamedValue) string {
+	var b strings.Builder
+	b.WriteString(querylog.Statement(query))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\x00%d:%s:%T:", arg.Ordinal, arg.Name, arg.Value)
+		switch v := arg.Value.(type) {
+		case time.Time:
+			b.WriteString(v.Format(time.RFC3339Nano))
+		case []byte:
+			fmt.Fprintf(&b, "%x", v)
+		default:
+			fmt.Fprintf(&b, "%v", v)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,205 @@
+package resultcache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+)
+
+func TestKey(t *testing.T) {
+	query := "SELECT revenue FROM sales\n\t\tWHERE month = :1"
+	args := func(v driver.Value) []driver.NamedValue { return []driver.NamedValue{{Ordinal: 1, Value: v}} }
+
+	if Key(query, args(int64(1))) != Key("SELECT revenue FROM sales WHERE month = :1", args(int64(1))) {
+		t.Error("Key() differs by whitespace")
+	}
+	for _, other := range []driver.Value{int64(2), "1", float64(1), nil} {
+		if Key(query, args(int64(1))) == Key(query, args(other)) {
+			t.Errorf("Key() with %T(%v) equals int64(1)", other, other)
+		}
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "SELECT * FROM orders", want: true},
+		{query: "\n\t\tWITH t AS (SELECT 1 FROM dual) SELECT * FROM t", want: true},
+		{query: "SELECT * FROM orders WHERE order_id = :1 FOR UPDATE", want: false},
+		{query: "SELECT value FROM v$mystat", want: false},
+		{query: "UPDATE orders SET status = :1", want: false},
+	}
+	for _, tt := range tests {
+		if got := Cacheable(tt.query); got != tt.want {
+			t.Errorf("Cacheable(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestConnectorCachesUntilTTL(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC))
+	cache := New(Options{TTL: 30 * time.Second, MaxEntries: 2, Clock: clk})
+	base := &fakeConnector{}
+	db := sql.OpenDB(Connector(base, cache))
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() failed: %v", err)
+		}
+	}()
+
+	const query = "SELECT revenue FROM sales WHERE month = :1"
+	queryRevenue := func(month int64) int64 {
+		t.Helper()
+		var revenue int64
+		if err := db.QueryRow(query, month).Scan(&revenue); err != nil {
+			t.Fatalf("QueryRow() failed: %v", err)
+		}
+		return revenue
+	}
+
+	if got := queryRevenue(1); got != 100 {
+		t.Fatalf("revenue = %d, want 100", got)
+	}
+	// ほかのセッションの更新はTTLが切れるまで反映されない
+	base.version.Store(1)
+	if got := queryRevenue(1); got != 100 {
+		t.Errorf("revenue before TTL = %d, want stale 100", got)
+	}
+	clk.Advance(30 * time.Second)
+	if got := queryRevenue(1); got != 101 {
+		t.Errorf("revenue after TTL = %d, want 101", got)
+	}
+
+	// 上限を超えたら最も古く使われたエントリから追い出す
+	queryRevenue(2)
+	queryRevenue(1)
+	queryRevenue(3)
+	if got := base.queries.Load(); got != 4 {
+		t.Errorf("queries = %d, want 4", got)
+	}
+
+	want := Stats{Hits: 2, Misses: 4, Expired: 1, Evictions: 1, Entries: 2, Rows: 2}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestConnectorBypassesTransactionsAndInvalidatesOnDML(t *testing.T) {
+	cache := New(Options{TTL: time.Minute})
+	base := &fakeConnector{}
+	db := sql.OpenDB(Connector(base, cache))
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() failed: %v", err)
+		}
+	}()
+
+	const query = "SELECT revenue FROM sales WHERE month = :1"
+	var revenue int64
+	if err := db.QueryRow(query, 1).Scan(&revenue); err != nil {
+		t.Fatalf("QueryRow() failed: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin() failed: %v", err)
+	}
+	base.version.Store(1)
+	if err := tx.QueryRow(query, 1).Scan(&revenue); err != nil {
+		t.Fatalf("tx.QueryRow() failed: %v", err)
+	}
+	if revenue != 101 {
+		t.Errorf("revenue in transaction = %d, want 101", revenue)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback() failed: %v", err)
+	}
+
+	if _, err := db.Exec("UPDATE sales SET revenue = revenue + 1"); err != nil {
+		t.Fatalf("Exec() failed: %v", err)
+	}
+	if err := db.QueryRow(query, 1).Scan(&revenue); err != nil {
+		t.Fatalf("QueryRow() failed: %v", err)
+	}
+	if revenue != 101 {
+		t.Errorf("revenue after DML = %d, want 101", revenue)
+	}
+
+	want := Stats{Misses: 2, Bypassed: 1, Invalidations: 1, Entries: 1, Rows: 1}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	if err := (Options{TTL: time.Second}).Validate(); err != nil {
+		t.Errorf("Validate() failed: %v", err)
+	}
+	for _, opts := range []Options{{}, {TTL: time.Second, MaxEntries: -1}, {TTL: time.Second, MaxRows: -1}} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want error", opts)
+		}
+	}
+}
+
+// fakeConnector - 問い合わせには 100*month + version の1行を返し、実行した問い合わせを数えるテスト用のドライバー
+type fakeConnector struct {
+	version atomic.Int64
+	queries atomic.Int64
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{connector: c}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{ connector *fakeConnector }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.HasPrefix(query, "SELECT revenue") {
+		return nil, errors.New("unexpected query")
+	}
+	c.connector.queries.Add(1)
+	return &fakeRows{value: 100*args[0].Value.(int64) + c.connector.version.Load()}, nil
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	value int64
+	done  bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"REVENUE"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
@@ -0,0 +1,319 @@
+// Package runlock は同じスキーマに対する計測の同時実行を防ぐアドバイザリ・ロック（DBMS_LOCK）を扱う。
+//
+// 2人が同じスキーマで同時に計測すると、互いのSQLがバッファキャッシュ・共有プール・CPUを奪い合い、
+// どちらの計測結果も信頼できなくなる。ロックは専用のセッションで取得するため、プロセスが異常終了して
+// セッションが切断されればOracleが自動的に解放する。
+//
+// ネットワークの切断などでクライアントだけが終了し、セッションがデータベースに残った場合はロックも残る。
+// 保持中はハートビートでセッションの最終呼び出しからの経過時間（LAST_CALL_ET）を更新するため、
+// 経過時間がハートビートの間隔を大きく超えた保持者は、古いロック（stale）と判定できる。
+package runlock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHeartbeat - 保持中にセッションの最終呼び出し時刻を更新する間隔
+	DefaultHeartbeat = 30 * time.Second
+	// DefaultStaleAfter - 保持者のセッションがこの時間以上呼び出しをしていなければ古いロックとみなす
+	DefaultStaleAfter = 5 * time.Minute
+
+	// maxLockID - DBMS_LOCK.REQUEST に指定できるユーザー・ロックIDの上限
+	maxLockID = 1073741823
+	// module / action - 保持者のセッションを識別するためにV$SESSIONへ設定する値
+	module = "oracle-n-plus-1-demo"
+	action = "run-lock"
+)
+
+// DBMS_LOCK.REQUEST / RELEASE の戻り値
+const (
+	statusSuccess     = 0
+	statusTimeout     = 1
+	statusDeadlock    = 2
+	statusParameter   = 3
+	statusAlreadyOwns = 4
+	statusIllegal     = 5
+)
+
+// ErrLocked - 別の実行がロックを保持している
+var ErrLocked = errors.New("benchmark run lock is held by another session")
+
+// ErrUnavailable - DBMS_LOCKを実行できない（EXECUTE権限がない）
+var ErrUnavailable = errors.New("DBMS_LOCK is not available (EXECUTE privilege is required)")
+
+// Options - ロックの取得方法
+type Options struct {
+	// Wait - 他の実行が保持している場合に待つ時間（0なら待たない）
+	Wait time.Duration
+	// Heartbeat - 保持中の最終呼び出し時刻の更新間隔（0なら DefaultHeartbeat）
+	Heartbeat time.Duration
+	// StaleAfter - 古いロックとみなす保持者の無通信時間（0なら DefaultStaleAfter）
+	StaleAfter time.Duration
+}
+
+// withDefaults - 未指定の項目に既定値を設定
+func (o Options) withDefaults() Options {
+	if o.Heartbeat <= 0 {
+		o.Heartbeat = DefaultHeartbeat
+	}
+	if o.StaleAfter <= 0 {
+		o.StaleAfter = DefaultStaleAfter
+	}
+	return o
+}
+
+// Validate - 古いロックの判定がハートビートで誤検知されない設定か検証
+func (o Options) Validate() error {
+	o = o.withDefaults()
+	if o.Wait < 0 {
+		return fmt.Errorf("lock wait must not be negative: %v", o.Wait)
+	}
+	if o.StaleAfter < 2*o.Heartbeat {
+		return fmt.Errorf("stale threshold %v must be at least twice the heartbeat interval %v", o.StaleAfter, o.Heartbeat)
+	}
+	return nil
+}
+
+// LockID - スキーマごとのユーザー・ロックID（同じスキーマなら同じIDになる）
+func LockID(schema string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(module + ":" + strings.ToUpper(schema)))
+	return int(h.Sum32() % (maxLockID + 1))
+}
+
+// Holder - ロックを保持しているセッション
+type Holder struct {
+	Instance   int
+	SID        int
+	Serial     int
+	Username   string
+	Machine    string
+	Program    string
+	ClientInfo string
+	Status     string
+	// Idle - セッションの最終呼び出しからの経過時間（LAST_CALL_ET）
+	Idle time.Duration
+}
+
+// String - 表示用の保持者（例: SCOTT@host1 nplus1-demo（SID 123,456 @1、無通信 12s））
+func (h Holder) String() string {
+	who := h.Username
+	if h.Machine != "" {
+		who += "@" + h.Machine
+	}
+	if h.ClientInfo != "" {
+		who += " " + h.ClientInfo
+	} else if h.Program != "" {
+		who += " " + h.Program
+	}
+	return fmt.Sprintf("%s（SID %d,%d @%d、無通信 %v）", who, h.SID, h.Serial, h.Instance, h.Idle)
+}
+
+// LockedError - 別の実行がロックを保持している（保持者を特定できない場合はHolderがnil）
+type LockedError struct {
+	Schema     string
+	Holder     *Holder
+	StaleAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	if e.Holder == nil {
+		return fmt.Sprintf("%v (schema %s)", ErrLocked, e.Schema)
+	}
+	return fmt.Sprintf("%v (schema %s, holder %s)", ErrLocked, e.Schema, e.Holder)
+}
+
+func (e *LockedError) Unwrap() error { return ErrLocked }
+
+// Stale - 保持者のセッションが古いロックとみなす時間以上呼び出しをしていないか
+func (e *LockedError) Stale() bool {
+	return e.Holder != nil && e.Holder.Idle >= e.StaleAfter
+}
+
+// Lock - 取得したロック（Releaseで解放する）
+type Lock struct {
+	conn   *sql.Conn
+	id     int
+	Schema string
+
+	stop     chan struct{}
+	done     chan struct{}
+	mu       sync.Mutex
+	released bool
+}
+
+// Acquire - スキーマのロックを専用のセッションで取得する
+//
+// 他の実行が保持している場合は *LockedError（errors.Is で ErrLocked）を返す。
+// DBMS_LOCKを実行できない場合は ErrUnavailable を返す。
+func Acquire(ctx context.Context, db *sql.DB, opts Options) (*Lock, error) {
+	opts = opts.withDefaults()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock connection: %w", err)
+	}
+	closeConn := func() {
+		if cerr := conn.Close(); cerr != nil {
+			fmt.Printf("conn.Close() failed: %v\n", cerr)
+		}
+	}
+
+	var schema string
+	if err := conn.QueryRowContext(ctx, `SELECT SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA') FROM dual`).Scan(&schema); err != nil {
+		closeConn()
+		return nil, fmt.Errorf("failed to query current schema: %w", err)
+	}
+	id := LockID(schema)
+
+	// 保持者として表示できるよう、このセッションに実行の情報を設定する
+	host, _ := os.Hostname()
+	info := fmt.Sprintf("host=%s pid=%d since=%s", host, os.Getpid(), time.Now().Format(time.RFC3339))
+	if _, err := conn.ExecContext(ctx, `BEGIN DBMS_APPLICATION_INFO.SET_MODULE(:1, :2); DBMS_APPLICATION_INFO.SET_CLIENT_INFO(:3); END;`,
+		module, action, info); err != nil {
+		closeConn()
+		return nil, fmt.Errorf("failed to set session info: %w", err)
+	}
+
+	var status int
+	_, err = conn.ExecContext(ctx, `BEGIN :1 := DBMS_LOCK.REQUEST(id => :2, lockmode => DBMS_LOCK.X_MODE, timeout => :3, release_on_commit => FALSE); END;`,
+		sql.Out{Dest: &status}, id, int(opts.Wait/time.Second))
+	if err != nil {
+		closeConn()
+		if isUnavailable(err) {
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		return nil, fmt.Errorf("failed to request lock: %w", err)
+	}
+
+	switch status {
+	case statusSuccess, statusAlreadyOwns:
+	case statusTimeout:
+		lockedErr := &LockedError{Schema: schema, StaleAfter: opts.StaleAfter}
+		if holder, err := findHolder(ctx, conn, id); err == nil {
+			lockedErr.Holder = holder
+		}
+		closeConn()
+		return nil, lockedErr
+	default:
+		closeConn()
+		return nil, fmt.Errorf("DBMS_LOCK.REQUEST failed with status %d", status)
+	}
+
+	lock := &Lock{conn: conn, id: id, Schema: schema, stop: make(chan struct{}), done: make(chan struct{})}
+	go lock.heartbeat(opts.Heartbeat)
+	return lock, nil
+}
+
+// heartbeat - 保持中のセッションの最終呼び出し時刻を定期的に更新する
+func (l *Lock) heartbeat(interval time.Duration) {
+	defer close(l.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if _, err := l.conn.ExecContext(context.Background(), `BEGIN DBMS_APPLICATION_INFO.SET_ACTION(:1); END;`, action); err != nil {
+				fmt.Printf("実行ロックのハートビートに失敗しました: %v\n", err)
+			}
+		}
+	}
+}
+
+// Release - ロックを解放して専用のセッションを閉じる（2回目以降は何もしない）
+func (l *Lock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return nil
+	}
+	l.released = true
+	close(l.stop)
+	<-l.done
+
+	var status int
+	_, err := l.conn.ExecContext(context.Background(), `BEGIN :1 := DBMS_LOCK.RELEASE(id => :2); END;`, sql.Out{Dest: &status}, l.id)
+	if cerr := l.conn.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// セッションを閉じればOracleがロックを解放する
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	if status != statusSuccess && status != statusIllegal {
+		return fmt.Errorf("DBMS_LOCK.RELEASE failed with status %d", status)
+	}
+	return nil
+}
+
+// findHolder - ロックを保持しているセッション（GV$LOCK・GV$SESSIONの参照権限が必要）
+func findHolder(ctx context.Context, conn *sql.Conn, id int) (*Holder, error) {
+	var h Holder
+	var idleSeconds int64
+	err := conn.QueryRowContext(ctx, `
+		SELECT s.inst_id, s.sid, s.serial#,
+			NVL(s.username, ' '), NVL(s.machine, ' '), NVL(s.program, ' '), NVL(s.client_info, ' '),
+			s.status, s.last_call_et
+		FROM gv$lock l
+		JOIN gv$session s ON s.inst_id = l.inst_id AND s.sid = l.sid
+		WHERE l.type = 'UL' AND l.id1 = :1 AND l.lmode > 0`, id).Scan(
+		&h.Instance, &h.SID, &h.Serial, &h.Username, &h.Machine, &h.Program, &h.ClientInfo, &h.Status, &idleSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lock holder: %w", err)
+	}
+	h.Username = strings.TrimSpace(h.Username)
+	h.Machine = strings.TrimSpace(h.Machine)
+	h.Program = strings.TrimSpace(h.Program)
+	h.ClientInfo = strings.TrimSpace(h.ClientInfo)
+	h.Idle = time.Duration(idleSeconds) * time.Second
+	return &h, nil
+}
+
+// BreakStale - 古いロックを保持しているセッションを切断する（ALTER SYSTEM権限が必要）
+//
+// 切断の直前に保持者を確認し直し、同じセッションがまだ古いロックを保持している場合だけ切断する。
+func BreakStale(ctx context.Context, db *sql.DB, lockedErr *LockedError) error {
+	if !lockedErr.Stale() {
+		return errors.New("lock is not stale")
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			fmt.Printf("conn.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	current, err := findHolder(ctx, conn, LockID(lockedErr.Schema))
+	if err != nil {
+		return err
+	}
+	stale := lockedErr.Holder
+	if current.Instance != stale.Instance || current.SID != stale.SID || current.Serial != stale.Serial || current.Idle < lockedErr.StaleAfter {
+		return fmt.Errorf("lock holder changed or became active: %s", current)
+	}
+
+	statement := fmt.Sprintf("ALTER SYSTEM KILL SESSION '%d,%d,@%d' IMMEDIATE", current.SID, current.Serial, current.Instance)
+	if _, err := conn.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to kill stale lock holder: %w", err)
+	}
+	return nil
+}
+
+// isUnavailable - DBMS_LOCKが見えない（PLS-00201）か実行権限がないエラーか
+func isUnavailable(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "PLS-00201") || strings.Contains(msg, "ORA-01031")
+}
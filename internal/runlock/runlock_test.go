@@ -0,0 +1,61 @@
+package runlock
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockID(t *testing.T) {
+	if LockID("scott") != LockID("SCOTT") {
+		t.Error("LockID() differs by schema case")
+	}
+	if LockID("SCOTT") == LockID("HR") {
+		t.Error("LockID() is the same for different schemas")
+	}
+	for _, schema := range []string{"SCOTT", "HR", "DEMO_USER", ""} {
+		if id := LockID(schema); id < 0 || id > maxLockID {
+			t.Errorf("LockID(%q) = %d, want 0..%d", schema, id, maxLockID)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "defaults", opts: Options{}},
+		{name: "wait", opts: Options{Wait: time.Minute}},
+		{name: "negative wait", opts: Options{Wait: -time.Second}, wantErr: true},
+		{name: "stale shorter than two heartbeats", opts: Options{Heartbeat: time.Minute, StaleAfter: 90 * time.Second}, wantErr: true},
+		{name: "stale after default heartbeat", opts: Options{StaleAfter: time.Minute}},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLockedError(t *testing.T) {
+	holder := &Holder{Instance: 1, SID: 123, Serial: 456, Username: "SCOTT", Machine: "host1", Idle: 10 * time.Minute}
+	tests := []struct {
+		name      string
+		err       *LockedError
+		wantStale bool
+	}{
+		{name: "unknown holder", err: &LockedError{Schema: "SCOTT", StaleAfter: time.Minute}},
+		{name: "stale holder", err: &LockedError{Schema: "SCOTT", Holder: holder, StaleAfter: 5 * time.Minute}, wantStale: true},
+		{name: "active holder", err: &LockedError{Schema: "SCOTT", Holder: holder, StaleAfter: time.Hour}},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, ErrLocked) {
+			t.Errorf("%s: errors.Is(err, ErrLocked) = false", tt.name)
+		}
+		if got := tt.err.Stale(); got != tt.wantStale {
+			t.Errorf("%s: Stale() = %v, want %v", tt.name, got, tt.wantStale)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package runmeta
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"oracle-n-plus-1-demo/config"
+)
+
+// Version - ツールのバージョン（ビルド時に -ldflags "-X oracle-n-plus-1-demo/internal/runmeta.Version=v1.2.3" で設定）
+var Version = "dev"
+
+// driverModule - 実行メタデータに記録するOracleドライバーのモジュール
+const driverModule = "github.com/sijms/go-ora/v2"
+
+// Connection - 接続設定（パスワードは含めない）
+type Connection struct {
+	Host         string `json:"host"`
+	Port         int    `json:"port"`
+	ServiceName  string `json:"service_name"`
+	InstanceName string `json:"instance_name,omitempty"` // RACで接続先を固定したインスタンス（DB_INSTANCE_NAME）
+	Username     string `json:"username"`
+	MaxOpenConns int    `json:"max_open_conns"`
+	MaxIdleConns int    `json:"max_idle_conns"`
+}
+
+// Session - 計測に使ったセッションのNLS・タイムゾーン（日付の文字列表現や SYSDATE との比較に影響する）
+type Session struct {
+	NLSDateFormat string `json:"nls_date_format,omitempty"`
+	NLSTerritory  string `json:"nls_territory,omitempty"`
+	NLSLanguage   string `json:"nls_language,omitempty"`
+	TimeZone      string `json:"time_zone,omitempty"`
+	DBTimeZone    string `json:"db_time_zone,omitempty"`
+	// Instance - セッションの接続先インスタンス（RACではインスタンスごとにバッファキャッシュが異なる）
+	Instance string `json:"instance,omitempty"`
+	// ConsumerGroup - リソース・マネージャのコンシューマ・グループ（V$SESSIONを参照できない場合は空）
+	ConsumerGroup string `json:"consumer_group,omitempty"`
+	// FlashbackSCN - すべての手法の問い合わせを固定したSCN（-as-of。同じSCNを指定すると同じデータで再計測できる）
+	FlashbackSCN uint64 `json:"flashback_scn,omitempty"`
+}
+
+// Metadata - 結果を共有・比較するための実行環境の情報
+type Metadata struct {
+	// Environment - 実行環境の名前（dev / staging / prod-replica など、-env で指定）
+	Environment   string     `json:"environment,omitempty"`
+	ToolVersion   string     `json:"tool_version"`
+	GitCommit     string     `json:"git_commit,omitempty"`
+	GitModified   bool       `json:"git_modified,omitempty"`
+	GoVersion     string     `json:"go_version"`
+	OS            string     `json:"os"`
+	Arch          string     `json:"arch"`
+	NumCPU        int        `json:"num_cpu"`
+	GOMAXPROCS    int        `json:"gomaxprocs"`
+	Hostname      string     `json:"hostname,omitempty"`
+	DriverVersion string     `json:"driver_version,omitempty"`
+	DBVersion     string     `json:"db_version,omitempty"`
+	DBBanner      string     `json:"db_banner,omitempty"`
+	Connection    Connection `json:"connection"`
+	Session       Session    `json:"session"`
+	CollectedAt   time.Time  `json:"collected_at"`
+	// Errors - 取得できなかった項目
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Collect - 実行環境の情報を収集（取得できない項目はErrorsに記録して続行）
+func Collect(db *sql.DB, cfg *config.Config) *Metadata {
+	meta := &Metadata{
+		ToolVersion: Version,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		NumCPU:      runtime.NumCPU(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		CollectedAt: time.Now(),
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		meta.Hostname = hostname
+	}
+	meta.collectBuildInfo()
+
+	if cfg != nil {
+		meta.Connection = Connection{
+			Host:         cfg.DBHost,
+			Port:         cfg.DBPort,
+			ServiceName:  cfg.DBServiceName,
+			InstanceName: cfg.DBInstanceName,
+			Username:     cfg.DBUsername,
+			MaxOpenConns: config.MaxOpenConns,
+			MaxIdleConns: config.MaxIdleConns,
+		}
+		meta.Session.FlashbackSCN = cfg.Session.FlashbackSCN
+	}
+
+	if db != nil {
+		meta.collectDBVersion(db)
+		meta.collectSession(db)
+	}
+
+	return meta
+}
+
+// collectBuildInfo - ビルド情報からgitコミットとドライバーのバージョンを取得
+func (m *Metadata) collectBuildInfo() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		m.Errors = append(m.Errors, "build info: not available")
+		return
+	}
+
+	if m.ToolVersion == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		m.ToolVersion = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			m.GitCommit = setting.Value
+		case "vcs.modified":
+			m.GitModified = setting.Value == "true"
+		}
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == driverModule {
+			m.DriverVersion = dep.Path + " " + dep.Version
+		}
+	}
+}
+
+// collectDBVersion - データベースのバージョンとバナーを取得
+func (m *Metadata) collectDBVersion(db *sql.DB) {
+	versionQuery := `
+		SELECT version
+		FROM product_component_version
+		WHERE product LIKE 'Oracle%'
+		AND ROWNUM = 1`
+	if err := db.QueryRow(versionQuery).Scan(&m.DBVersion); err != nil {
+		m.Errors = append(m.Errors, fmt.Sprintf("db version: %v", err))
+	}
+
+	bannerQuery := `SELECT banner FROM v$version WHERE ROWNUM = 1`
+	if err := db.QueryRow(bannerQuery).Scan(&m.DBBanner); err != nil {
+		m.Errors = append(m.Errors, fmt.Sprintf("db banner: %v", err))
+	}
+}
+
+// collectSession - セッションのNLSパラメータとタイムゾーンを取得
+func (m *Metadata) collectSession(db *sql.DB) {
+	query := `
+		SELECT
+			(SELECT value FROM nls_session_parameters WHERE parameter = 'NLS_DATE_FORMAT'),
+			(SELECT value FROM nls_session_parameters WHERE parameter = 'NLS_TERRITORY'),
+			(SELECT value FROM nls_session_parameters WHERE parameter = 'NLS_LANGUAGE'),
+			SESSIONTIMEZONE,
+			DBTIMEZONE,
+			SYS_CONTEXT('USERENV', 'INSTANCE_NAME')
+		FROM dual`
+	err := db.QueryRow(query).Scan(
+		&m.Session.NLSDateFormat,
+		&m.Session.NLSTerritory,
+		&m.Session.NLSLanguage,
+		&m.Session.TimeZone,
+		&m.Session.DBTimeZone,
+		&m.Session.Instance,
+	)
+	if err != nil {
+		m.Errors = append(m.Errors, fmt.Sprintf("session parameters: %v", err))
+	}
+
+	group, err := ConsumerGroup(db)
+	if err != nil {
+		m.Errors = append(m.Errors, fmt.Sprintf("consumer group: %v", err))
+		return
+	}
+	m.Session.ConsumerGroup = group
+}
+
+// ConsumerGroup - 現在のセッションが割り当てられたコンシューマ・グループ（V$SESSIONの参照権限が必要）
+func ConsumerGroup(db *sql.DB) (string, error) {
+	var group sql.NullString
+	query := `SELECT resource_consumer_group FROM v$session WHERE sid = SYS_CONTEXT('USERENV', 'SID')`
+	if err := db.QueryRow(query).Scan(&group); err != nil {
+		return "", fmt.Errorf("failed to query v$session: %w", err)
+	}
+	return group.String, nil
+}
@@ -0,0 +1,142 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/sqlutil"
+)
+
+// maxIdentifierLength - 索引名の長さの上限（12.2以降のOracleの識別子の上限）
+const maxIdentifierLength = 128
+
+// ForeignKey - 外部キー制約
+type ForeignKey struct {
+	Table      string   `json:"table"`
+	Constraint string   `json:"constraint"`
+	Columns    []string `json:"columns"`
+	// RefTable - 参照先（親）のテーブル
+	RefTable string `json:"ref_table"`
+}
+
+// String - 表示用の文字列（例: ORDER_DETAILS(ORDER_ID) → ORDERS）
+func (fk ForeignKey) String() string {
+	return fmt.Sprintf("%s(%s) → %s", fk.Table, strings.Join(fk.Columns, ", "), fk.RefTable)
+}
+
+// IndexStatement - 外部キーの列に索引を作成するCREATE INDEX文（終端の ; は含めない）
+func (fk ForeignKey) IndexStatement() string {
+	name := "IDX_" + fk.Table + "_" + strings.Join(fk.Columns, "_")
+	if len(name) > maxIdentifierLength {
+		name = name[:maxIdentifierLength]
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", name, fk.Table, strings.Join(fk.Columns, ", "))
+}
+
+// DemoTableNames - デモのテーブル名（ExpectedTablesの順）
+func DemoTableNames() []string {
+	names := make([]string, len(ExpectedTables))
+	for i, t := range ExpectedTables {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// UnindexedForeignKeys - 列を先頭に持つ索引がない外部キーを取得（tableNamesが空の場合はすべてのテーブル）
+//
+// 子表の外部キーに索引がないと、親の受注から明細を引くたびに子表を全件走査し、
+// 親の行の削除や主キーの更新では子表全体に共有ロック（TM）がかかる。
+func UnindexedForeignKeys(db *sql.DB, tableNames []string) ([]ForeignKey, error) {
+	keys, err := loadForeignKeys(db, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := loadIndexes(db, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	return unindexed(keys, indexes), nil
+}
+
+// unindexed - 列を先頭に持つ索引がない外部キー
+func unindexed(keys []ForeignKey, indexes map[string][][]string) []ForeignKey {
+	var result []ForeignKey
+	for _, fk := range keys {
+		if !hasLeadingColumns(indexes[fk.Table], fk.Columns) {
+			result = append(result, fk)
+		}
+	}
+	return result
+}
+
+// hasLeadingColumns - 指定列を（順序を問わず）先頭に持つ索引があるか
+//
+// 外部キーのロックを避けるには、索引の先頭の列が外部キーの列と一致していればよく、列の順序は問わない。
+func hasLeadingColumns(live [][]string, columns []string) bool {
+	for _, idxCols := range live {
+		if len(idxCols) < len(columns) {
+			continue
+		}
+		leading := make(map[string]bool, len(columns))
+		for _, col := range idxCols[:len(columns)] {
+			leading[col] = true
+		}
+		match := true
+		for _, col := range columns {
+			if !leading[col] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// loadForeignKeys - 対象テーブルの外部キー制約と列を取得
+func loadForeignKeys(db *sql.DB, tableNames []string) ([]ForeignKey, error) {
+	where := ""
+	var args []interface{}
+	if len(tableNames) > 0 {
+		where = fmt.Sprintf("AND c.table_name IN (%s)", sqlutil.Placeholders(len(tableNames)))
+		args = sqlutil.StringArgs(tableNames)
+	}
+	query := fmt.Sprintf(`
+		SELECT c.table_name, c.constraint_name, cc.column_name,
+			NVL(r.table_name, c.r_owner || '.' || c.r_constraint_name)
+		FROM user_constraints c
+		JOIN user_cons_columns cc ON cc.constraint_name = c.constraint_name
+		-- 別のスキーマの表を参照する場合は、所有者と制約名を参照先として示す
+		LEFT JOIN user_constraints r ON r.constraint_name = c.r_constraint_name
+		WHERE c.constraint_type = 'R'
+		%s
+		ORDER BY c.table_name, c.constraint_name, cc.position`, where)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user_constraints: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var keys []ForeignKey
+	for rows.Next() {
+		var tableName, constraintName, columnName, refTable string
+		if err := rows.Scan(&tableName, &constraintName, &columnName, &refTable); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key row: %w", err)
+		}
+		if n := len(keys); n > 0 && keys[n-1].Constraint == constraintName {
+			keys[n-1].Columns = append(keys[n-1].Columns, columnName)
+			continue
+		}
+		keys = append(keys, ForeignKey{Table: tableName, Constraint: constraintName, Columns: []string{columnName}, RefTable: refTable})
+	}
+
+	return keys, rows.Err()
+}
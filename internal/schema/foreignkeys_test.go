@@ -0,0 +1,76 @@
+package schema
+
+import "testing"
+
+func TestUnindexed(t *testing.T) {
+	orderFK := ForeignKey{Table: "ORDER_DETAILS", Constraint: "FK_OD_ORDER", Columns: []string{"ORDER_ID"}, RefTable: "ORDERS"}
+	pairFK := ForeignKey{Table: "SHIPMENTS", Constraint: "FK_SHIP_LINE", Columns: []string{"ORDER_ID", "LINE_NO"}, RefTable: "ORDER_LINES"}
+
+	tests := []struct {
+		name    string
+		keys    []ForeignKey
+		indexes map[string][][]string
+		want    []string
+	}{
+		{
+			name:    "先頭の列の索引あり",
+			keys:    []ForeignKey{orderFK},
+			indexes: map[string][][]string{"ORDER_DETAILS": {{"DETAIL_ID"}, {"ORDER_ID"}}},
+			want:    nil,
+		},
+		{
+			name:    "複合索引の先頭",
+			keys:    []ForeignKey{orderFK},
+			indexes: map[string][][]string{"ORDER_DETAILS": {{"ORDER_ID", "PRODUCT_ID"}}},
+			want:    nil,
+		},
+		{
+			name:    "複合索引の2列目だけ",
+			keys:    []ForeignKey{orderFK},
+			indexes: map[string][][]string{"ORDER_DETAILS": {{"PRODUCT_ID", "ORDER_ID"}}},
+			want:    []string{"FK_OD_ORDER"},
+		},
+		{
+			name:    "索引なし",
+			keys:    []ForeignKey{orderFK},
+			indexes: map[string][][]string{},
+			want:    []string{"FK_OD_ORDER"},
+		},
+		{
+			name:    "複数列の外部キーは先頭の列の順序を問わない",
+			keys:    []ForeignKey{pairFK},
+			indexes: map[string][][]string{"SHIPMENTS": {{"LINE_NO", "ORDER_ID", "SHIPPED_AT"}}},
+			want:    nil,
+		},
+		{
+			name:    "複数列の外部キーの一部だけ",
+			keys:    []ForeignKey{pairFK},
+			indexes: map[string][][]string{"SHIPMENTS": {{"ORDER_ID"}}},
+			want:    []string{"FK_SHIP_LINE"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := unindexed(tt.keys, tt.indexes)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d keys, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i, fk := range got {
+			if fk.Constraint != tt.want[i] {
+				t.Errorf("%s: got %s, want %s", tt.name, fk.Constraint, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestForeignKeyIndexStatement(t *testing.T) {
+	fk := ForeignKey{Table: "SHIPMENTS", Constraint: "FK_SHIP_LINE", Columns: []string{"ORDER_ID", "LINE_NO"}, RefTable: "ORDER_LINES"}
+	want := "CREATE INDEX IDX_SHIPMENTS_ORDER_ID_LINE_NO ON SHIPMENTS (ORDER_ID, LINE_NO)"
+	if got := fk.IndexStatement(); got != want {
+		t.Errorf("IndexStatement() = %q, want %q", got, want)
+	}
+	if got, want := fk.String(), "SHIPMENTS(ORDER_ID, LINE_NO) → ORDER_LINES"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package schema
+
+// ColumnDef - 期待される列定義
+type ColumnDef struct {
+	Name     string
+	DataType string
+	Nullable bool
+}
+
+// IndexDef - 期待される索引定義（索引名ではなく列構成で照合する）
+type IndexDef struct {
+	Name    string
+	Columns []string
+}
+
+// TableDef - 期待されるテーブル定義
+type TableDef struct {
+	Name    string
+	Columns []ColumnDef
+	Indexes []IndexDef
+}
+
+// ExpectedTables - scripts/ddl/create_tables.sql 適用後に期待されるスキーマ
+var ExpectedTables = []TableDef{
+	{
+		Name: "DEPARTMENTS",
+		Columns: []ColumnDef{
+			{Name: "DEPARTMENT_ID", DataType: "NUMBER"},
+			{Name: "DEPARTMENT_NAME", DataType: "VARCHAR2"},
+			{Name: "LOCATION", DataType: "VARCHAR2", Nullable: true},
+			{Name: "CREATED_AT", DataType: "DATE", Nullable: true},
+			{Name: "UPDATED_AT", DataType: "DATE", Nullable: true},
+		},
+		Indexes: []IndexDef{
+			{Name: "主キー", Columns: []string{"DEPARTMENT_ID"}},
+			{Name: "IDX_DEPARTMENTS_NAME", Columns: []string{"DEPARTMENT_NAME"}},
+		},
+	},
+	{
+		Name: "EMPLOYEES",
+		Columns: []ColumnDef{
+			{Name: "EMPLOYEE_ID", DataType: "NUMBER"},
+			{Name: "FIRST_NAME", DataType: "VARCHAR2"},
+			{Name: "LAST_NAME", DataType: "VARCHAR2"},
+			{Name: "EMAIL", DataType: "VARCHAR2"},
+			{Name: "DEPARTMENT_ID", DataType: "NUMBER", Nullable: true},
+			{Name: "SALARY", DataType: "NUMBER", Nullable: true},
+			{Name: "HIRE_DATE", DataType: "DATE", Nullable: true},
+			{Name: "CREATED_AT", DataType: "DATE", Nullable: true},
+			{Name: "UPDATED_AT", DataType: "DATE", Nullable: true},
+		},
+		Indexes: []IndexDef{
+			{Name: "主キー", Columns: []string{"EMPLOYEE_ID"}},
+			{Name: "一意制約(email)", Columns: []string{"EMAIL"}},
+			{Name: "IDX_EMPLOYEES_DEPARTMENT_ID", Columns: []string{"DEPARTMENT_ID"}},
+			{Name: "IDX_EMPLOYEES_NAME", Columns: []string{"LAST_NAME", "FIRST_NAME"}},
+		},
+	},
+	{
+		Name: "PROJECTS",
+		Columns: []ColumnDef{
+			{Name: "PROJECT_ID", DataType: "NUMBER"},
+			{Name: "PROJECT_NAME", DataType: "VARCHAR2"},
+			{Name: "BUDGET", DataType: "NUMBER", Nullable: true},
+			{Name: "CREATED_AT", DataType: "DATE", Nullable: true},
+			{Name: "UPDATED_AT", DataType: "DATE", Nullable: true},
+		},
+		Indexes: []IndexDef{
+			{Name: "主キー", Columns: []string{"PROJECT_ID"}},
+		},
+	},
+	{
+		Name: "EMPLOYEE_PROJECTS",
+		Columns: []ColumnDef{
+			{Name: "EMPLOYEE_ID", DataType: "NUMBER"},
+			{Name: "PROJECT_ID", DataType: "NUMBER"},
+			{Name: "PROJECT_ROLE", DataType: "VARCHAR2", Nullable: true},
+			{Name: "ASSIGNED_AT", DataType: "DATE", Nullable: true},
+		},
+		Indexes: []IndexDef{
+			{Name: "PK_EMPLOYEE_PROJECTS", Columns: []string{"EMPLOYEE_ID", "PROJECT_ID"}},
+			{Name: "IDX_EMP_PROJECTS_PROJECT_ID", Columns: []string{"PROJECT_ID"}},
+		},
+	},
+	{
+		Name: "PRODUCTS",
+		Columns: []ColumnDef{
+			{Name: "PRODUCT_ID", DataType: "NUMBER"},
+			{Name: "PRODUCT_NAME", DataType: "VARCHAR2"},
+			{Name: "CATEGORY", DataType: "VARCHAR2", Nullable: true},
+			{Name: "LIST_PRICE", DataType: "NUMBER", Nullable: true},
+			{Name: "CREATED_AT", DataType: "DATE", Nullable: true},
+			{Name: "UPDATED_AT", DataType: "DATE", Nullable: true},
+		},
+		Indexes: []IndexDef{
+			{Name: "主キー", Columns: []string{"PRODUCT_ID"}},
+		},
+	},
+	{
+		Name: "ORDERS",
+		Columns: []ColumnDef{
+			{Name: "ORDER_ID", DataType: "NUMBER"},
+			{Name: "CUSTOMER_ID", DataType: "NUMBER"},
+			{Name: "CUSTOMER_NAME", DataType: "VARCHAR2"},
+			{Name: "ORDER_DATE", DataType: "DATE", Nullable: true},
+			{Name: "TOTAL_AMOUNT", DataType: "NUMBER", Nullable: true},
+			{Name: "STATUS", DataType: "VARCHAR2", Nullable: true},
+			{Name: "NOTES", DataType: "CLOB", Nullable: true},
+			{Name: "DELETED_FLAG", DataType: "CHAR"},
+			{Name: "CREATED_AT", DataType: "DATE", Nullable: true},
+			{Name: "UPDATED_AT", DataType: "DATE", Nullable: true},
+		},
+		Indexes: []IndexDef{
+			{Name: "主キー", Columns: []string{"ORDER_ID"}},
+			{Name: "IDX_ORDERS_CUSTOMER_ID", Columns: []string{"CUSTOMER_ID"}},
+			{Name: "IDX_ORDERS_ORDER_DATE", Columns: []string{"ORDER_DATE"}},
+			{Name: "IDX_ORDERS_STATUS", Columns: []string{"STATUS"}},
+		},
+	},
+	{
+		Name: "ORDER_DETAILS",
+		Columns: []ColumnDef{
+			{Name: "DETAIL_ID", DataType: "NUMBER"},
+			{Name: "ORDER_ID", DataType: "NUMBER"},
+			{Name: "PRODUCT_ID", DataType: "NUMBER"},
+			{Name: "PRODUCT_NAME", DataType: "VARCHAR2"},
+			{Name: "QUANTITY", DataType: "NUMBER"},
+			{Name: "UNIT_PRICE", DataType: "NUMBER"},
+			{Name: "LINE_AMOUNT", DataType: "NUMBER", Nullable: true},
+			{Name: "CREATED_AT", DataType: "DATE", Nullable: true},
+			{Name: "UPDATED_AT", DataType: "DATE", Nullable: true},
+		},
+		Indexes: []IndexDef{
+			{Name: "主キー", Columns: []string{"DETAIL_ID"}},
+			{Name: "IDX_ORDER_DETAILS_ORDER_ID", Columns: []string{"ORDER_ID"}},
+			{Name: "IDX_ORDER_DETAILS_PRODUCT_ID", Columns: []string{"PRODUCT_ID"}},
+		},
+	},
+}
+
+// ExpectedMaterializedViews - scripts/ddl/create_tables.sql で作成するマテリアライズドビュー
+var ExpectedMaterializedViews = []string{"MV_MONTHLY_CUSTOMER_SALES"}
+
+// ExpectedSequences - scripts/ddl/create_tables.sql で作成するシーケンス
+var ExpectedSequences = []string{
+	"SEQ_DEPARTMENTS",
+	"SEQ_EMPLOYEES",
+	"SEQ_PROJECTS",
+	"SEQ_ORDERS",
+	"SEQ_ORDER_DETAILS",
+}
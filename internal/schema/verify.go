@@ -0,0 +1,302 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/sqlutil"
+)
+
+// Severity - ドリフトの重要度
+type Severity string
+
+const (
+	// SeverityError - ベンチマークが失敗・誤動作する差分
+	SeverityError Severity = "ERROR"
+	// SeverityWarning - 結果に影響しうる差分
+	SeverityWarning Severity = "WARN"
+	// SeverityInfo - 参考情報（期待定義にない追加オブジェクトなど）
+	SeverityInfo Severity = "INFO"
+)
+
+// Drift - 期待スキーマとの差分1件
+type Drift struct {
+	Severity Severity `json:"severity"`
+	Table    string   `json:"table"`
+	Object   string   `json:"object"`
+	Message  string   `json:"message"`
+}
+
+// DriftReport - スキーマ差分レポート
+type DriftReport struct {
+	Drifts        []Drift `json:"drifts"`
+	CheckedTables int     `json:"checked_tables"`
+}
+
+// HasErrors - ERRORレベルの差分があるか
+func (r *DriftReport) HasErrors() bool {
+	for _, d := range r.Drifts {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Count - 指定した重要度の差分件数
+func (r *DriftReport) Count(severity Severity) int {
+	count := 0
+	for _, d := range r.Drifts {
+		if d.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// liveColumn - USER_TAB_COLUMNSから取得した列情報
+type liveColumn struct {
+	dataType string
+	nullable bool
+}
+
+// Verifier - 実スキーマと期待スキーマを比較する
+type Verifier struct {
+	db       *sql.DB
+	expected []TableDef
+}
+
+// NewVerifier - 検証器のコンストラクタ
+func NewVerifier(db *sql.DB) *Verifier {
+	return &Verifier{db: db, expected: ExpectedTables}
+}
+
+// Verify - テーブル・列・索引の定義を比較してドリフトレポートを作成
+func (v *Verifier) Verify() (*DriftReport, error) {
+	report := &DriftReport{CheckedTables: len(v.expected)}
+
+	tableNames := make([]string, len(v.expected))
+	for i, t := range v.expected {
+		tableNames[i] = t.Name
+	}
+
+	columns, err := v.loadColumns(tableNames)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, err := loadIndexes(v.db, tableNames)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range v.expected {
+		liveCols, exists := columns[table.Name]
+		if !exists {
+			report.Drifts = append(report.Drifts, Drift{
+				Severity: SeverityError,
+				Table:    table.Name,
+				Object:   table.Name,
+				Message:  "テーブルが存在しません",
+			})
+			continue
+		}
+
+		report.Drifts = append(report.Drifts, compareColumns(table, liveCols)...)
+		report.Drifts = append(report.Drifts, compareIndexes(table, indexes[table.Name])...)
+	}
+
+	return report, nil
+}
+
+// compareColumns - 列定義を比較
+func compareColumns(table TableDef, live map[string]liveColumn) []Drift {
+	var drifts []Drift
+	expectedNames := make(map[string]bool, len(table.Columns))
+
+	for _, col := range table.Columns {
+		expectedNames[col.Name] = true
+
+		lc, ok := live[col.Name]
+		if !ok {
+			drifts = append(drifts, Drift{
+				Severity: SeverityError,
+				Table:    table.Name,
+				Object:   col.Name,
+				Message:  "列が存在しません",
+			})
+			continue
+		}
+
+		if lc.dataType != col.DataType {
+			drifts = append(drifts, Drift{
+				Severity: SeverityWarning,
+				Table:    table.Name,
+				Object:   col.Name,
+				Message:  fmt.Sprintf("データ型が異なります（期待値: %s, 実際: %s）", col.DataType, lc.dataType),
+			})
+		}
+
+		if lc.nullable != col.Nullable {
+			drifts = append(drifts, Drift{
+				Severity: SeverityWarning,
+				Table:    table.Name,
+				Object:   col.Name,
+				Message:  fmt.Sprintf("NULL許可が異なります（期待値: %s, 実際: %s）", nullableLabel(col.Nullable), nullableLabel(lc.nullable)),
+			})
+		}
+	}
+
+	extras := make([]string, 0)
+	for name := range live {
+		if !expectedNames[name] {
+			extras = append(extras, name)
+		}
+	}
+	sort.Strings(extras)
+
+	for _, name := range extras {
+		drifts = append(drifts, Drift{
+			Severity: SeverityInfo,
+			Table:    table.Name,
+			Object:   name,
+			Message:  "期待定義にない列があります",
+		})
+	}
+
+	return drifts
+}
+
+// compareIndexes - 列構成が一致する索引があるかを比較
+func compareIndexes(table TableDef, live [][]string) []Drift {
+	var drifts []Drift
+
+	for _, idx := range table.Indexes {
+		if !hasIndexOn(live, idx.Columns) {
+			drifts = append(drifts, Drift{
+				Severity: SeverityError,
+				Table:    table.Name,
+				Object:   idx.Name,
+				Message:  fmt.Sprintf("索引がありません（列: %s）", strings.Join(idx.Columns, ", ")),
+			})
+		}
+	}
+
+	return drifts
+}
+
+// hasIndexOn - 指定列を先頭に持つ索引があるか
+func hasIndexOn(live [][]string, columns []string) bool {
+	for _, idxCols := range live {
+		if len(idxCols) < len(columns) {
+			continue
+		}
+		match := true
+		for i, col := range columns {
+			if idxCols[i] != col {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// loadColumns - 対象テーブルの列定義を取得
+func (v *Verifier) loadColumns(tableNames []string) (map[string]map[string]liveColumn, error) {
+	placeholders := sqlutil.Placeholders(len(tableNames))
+	args := sqlutil.StringArgs(tableNames)
+	query := fmt.Sprintf(`
+		SELECT table_name, column_name, data_type, nullable
+		FROM user_tab_columns
+		WHERE table_name IN (%s)
+		ORDER BY table_name, column_id`, placeholders)
+
+	rows, err := v.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user_tab_columns: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	result := make(map[string]map[string]liveColumn)
+	for rows.Next() {
+		var tableName, columnName, dataType, nullable string
+		if err := rows.Scan(&tableName, &columnName, &dataType, &nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column row: %w", err)
+		}
+		if result[tableName] == nil {
+			result[tableName] = make(map[string]liveColumn)
+		}
+		result[tableName][columnName] = liveColumn{dataType: dataType, nullable: nullable == "Y"}
+	}
+
+	return result, rows.Err()
+}
+
+// loadIndexes - 対象テーブルの索引の列構成を取得（tableNamesが空の場合はすべてのテーブル）
+func loadIndexes(db *sql.DB, tableNames []string) (map[string][][]string, error) {
+	where := ""
+	var args []interface{}
+	if len(tableNames) > 0 {
+		where = fmt.Sprintf("WHERE table_name IN (%s)", sqlutil.Placeholders(len(tableNames)))
+		args = sqlutil.StringArgs(tableNames)
+	}
+	query := fmt.Sprintf(`
+		SELECT table_name, index_name, column_name
+		FROM user_ind_columns
+		%s
+		ORDER BY table_name, index_name, column_position`, where)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user_ind_columns: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	type indexKey struct{ table, index string }
+	var order []indexKey
+	columnsByIndex := make(map[indexKey][]string)
+
+	for rows.Next() {
+		var tableName, indexName, columnName string
+		if err := rows.Scan(&tableName, &indexName, &columnName); err != nil {
+			return nil, fmt.Errorf("failed to scan index row: %w", err)
+		}
+		key := indexKey{tableName, indexName}
+		if _, seen := columnsByIndex[key]; !seen {
+			order = append(order, key)
+		}
+		columnsByIndex[key] = append(columnsByIndex[key], columnName)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][][]string)
+	for _, key := range order {
+		result[key.table] = append(result[key.table], columnsByIndex[key])
+	}
+
+	return result, nil
+}
+
+// nullableLabel - NULL許可の表示用ラベル
+func nullableLabel(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
@@ -0,0 +1,267 @@
+package seed
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// 合成データに使う名前（scripts/load_test_data.sh と同じ傾向の値）
+var (
+	surnames = []string{
+		"田中", "佐藤", "鈴木", "高橋", "渡辺", "伊藤", "山田", "中村", "小林", "加藤",
+		"吉田", "山口", "松本", "井上", "木村", "林", "清水", "山崎", "森", "池田",
+	}
+	givenNames = []string{
+		"太郎", "花子", "一郎", "美咲", "健太", "雅子", "次郎", "真理", "大輝", "由美",
+		"正樹", "麻衣", "康夫", "智子", "秀樹", "恵子", "博之", "直美", "光男", "裕子",
+	}
+	departmentNames = []string{
+		"営業部", "開発部", "人事部", "総務部", "マーケティング部", "経理部", "法務部", "IT企画部",
+		"技術部", "品質管理部", "生産管理部", "物流部", "広報部", "企画部", "海外事業部",
+		"新規事業部", "データサイエンス部", "セキュリティ部", "クラウド事業部", "モバイル事業部",
+	}
+	locations = []string{
+		"東京", "大阪", "名古屋", "横浜", "福岡", "札幌", "仙台", "広島", "京都", "神戸",
+	}
+	projectNames = []string{
+		"基幹システム刷新", "顧客ポータル開発", "採用プロセス改善", "データ基盤構築", "クラウド移行",
+		"モバイルアプリ開発", "セキュリティ強化", "BIダッシュボード", "在庫最適化", "物流網再編",
+	}
+	projectRoles = []string{"オーナー", "リーダー", "メンバー", "アドバイザー"}
+	productNames = []string{
+		"ノートパソコン", "デスクトップPC", "タブレット", "スマートフォン", "モニター 24インチ",
+		"モニター 27インチ", "ワイヤレスマウス", "キーボード", "ヘッドセット", "Webカメラ",
+		"プリンター", "外付けHDD", "SSD", "USB-Cハブ", "モバイルバッテリー",
+		"ルーター", "スイッチングハブ", "サーバー", "NAS", "ソフトウェアライセンス",
+	}
+	categories = []string{
+		"PC", "周辺機器", "ディスプレイ", "オフィス機器", "ストレージ",
+		"サーバー・ネットワーク", "ソフトウェア", "サービス",
+	}
+	companies = []string{
+		"株式会社ABC商事", "有限会社XYZ販売", "株式会社DEF企画", "合同会社GHI物産", "株式会社JKL工業",
+		"有限会社MNO貿易", "株式会社PQR技術", "合資会社STU建設", "株式会社VWX情報", "有限会社YZA商会",
+	}
+	statuses = []string{"COMPLETED", "PENDING", "PROCESSING", "CANCELLED"}
+)
+
+// notesPhrase - 受注備考の本文に繰り返す文
+const notesPhrase = "Delivery and acceptance conditions. "
+
+// firstCustomerID - 顧客IDの先頭（初期データ・scripts/load_test_data.sh と同じ 1001 から）
+const firstCustomerID = 1001
+
+// baseIDs - 投入前の各表の最大ID（生成する行のIDはこの次から振る）
+type baseIDs struct {
+	departments int64
+	employees   int64
+	projects    int64
+	products    int64
+	orders      int64
+	details     int64
+}
+
+// table - 投入する表
+type table struct {
+	name    string
+	columns []string
+	// units - 生成の単位の数（社員・プロジェクトの割り当ては社員ごとに件数が変わるため社員数）
+	units int
+	// rows - from番目からto番目の手前までの単位の行を、列ごとのスライス（配列バインドの引数）と行数で返す
+	rows func(from, to int) ([]interface{}, int)
+}
+
+// generator - 設定とシードから決まる合成データを作る（同じ設定・シード・既存の最大IDなら同じ行になる）
+type generator struct {
+	cfg  Config
+	rng  *rand.Rand
+	base baseIDs
+	now  time.Time
+}
+
+// newGenerator - ジェネレーターのコンストラクタ
+func newGenerator(cfg Config, base baseIDs, now time.Time) *generator {
+	return &generator{cfg: cfg, rng: rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)), base: base, now: now}
+}
+
+// tables - 外部キーの依存順の投入する表
+func (g *generator) tables() []table {
+	return []table{
+		{name: "departments", columns: []string{"department_id", "department_name", "location"},
+			units: g.cfg.Departments, rows: g.departments},
+		{name: "employees", columns: []string{"employee_id", "first_name", "last_name", "email", "department_id", "salary", "hire_date"},
+			units: g.cfg.Departments * g.cfg.EmployeesPerDepartment, rows: g.employees},
+		{name: "projects", columns: []string{"project_id", "project_name", "budget"},
+			units: g.cfg.Projects, rows: g.projects},
+		{name: "employee_projects", columns: []string{"employee_id", "project_id", "project_role"},
+			units: g.cfg.Departments * g.cfg.EmployeesPerDepartment, rows: g.employeeProjects},
+		{name: "products", columns: []string{"product_id", "product_name", "category", "list_price"},
+			units: g.cfg.Products, rows: g.products},
+		{name: "orders", columns: []string{"order_id", "customer_id", "customer_name", "order_date", "total_amount", "status", "notes", "deleted_flag"},
+			units: g.cfg.Orders, rows: g.orders},
+		{name: "order_details", columns: []string{"detail_id", "order_id", "product_id", "product_name", "quantity", "unit_price"},
+			units: g.cfg.Orders * g.cfg.DetailsPerOrder, rows: g.orderDetails},
+	}
+}
+
+// departments - 部署（部署名は一覧を使い切ると _2, _3 と番号を付ける）
+func (g *generator) departments(from, to int) ([]interface{}, int) {
+	n := to - from
+	ids, names, locs := make([]int64, n), make([]string, n), make([]string, n)
+	for i := range n {
+		d := from + i
+		ids[i] = g.base.departments + int64(d) + 1
+		names[i] = numbered(departmentNames, d, "_")
+		locs[i] = locations[d%len(locations)]
+	}
+	return []interface{}{ids, names, locs}, n
+}
+
+// employees - 部署ごとにEmployeesPerDepartment人の社員
+func (g *generator) employees(from, to int) ([]interface{}, int) {
+	n := to - from
+	ids, firsts, lasts, emails := make([]int64, n), make([]string, n), make([]string, n), make([]string, n)
+	depts, salaries, hired := make([]int64, n), make([]float64, n), make([]time.Time, n)
+	for i := range n {
+		e := from + i
+		ids[i] = g.base.employees + int64(e) + 1
+		firsts[i] = givenNames[(e+13)%len(givenNames)]
+		lasts[i] = surnames[e%len(surnames)]
+		// 既存の社員のメールアドレス（一意制約）と重ならないよう、IDから作る
+		emails[i] = fmt.Sprintf("seed%d@company.com", ids[i])
+		depts[i] = g.base.departments + int64(e/g.cfg.EmployeesPerDepartment) + 1
+		salaries[i] = roundTo(g.between(3000000, 8000000), 10000)
+		hired[i] = g.daysAgo(30, 2500)
+	}
+	return []interface{}{ids, firsts, lasts, emails, depts, salaries, hired}, n
+}
+
+// projects - プロジェクト
+func (g *generator) projects(from, to int) ([]interface{}, int) {
+	n := to - from
+	ids, names, budgets := make([]int64, n), make([]string, n), make([]float64, n)
+	for i := range n {
+		p := from + i
+		ids[i] = g.base.projects + int64(p) + 1
+		names[i] = numbered(projectNames, p, " フェーズ")
+		budgets[i] = roundTo(g.between(1000000, 100000000), 100000)
+	}
+	return []interface{}{ids, names, budgets}, n
+}
+
+// employeeProjects - 社員ごとに1〜ProjectsPerEmployee件のプロジェクトを重複なく割り当てる
+func (g *generator) employeeProjects(from, to int) ([]interface{}, int) {
+	var employees, projects []int64
+	var roles []string
+	for e := from; e < to; e++ {
+		count := 1 + g.rng.IntN(g.cfg.ProjectsPerEmployee)
+		start := g.rng.IntN(g.cfg.Projects)
+		for j := range count {
+			employees = append(employees, g.base.employees+int64(e)+1)
+			// 連続するプロジェクトを割り当てるため、ProjectsPerEmployee ≦ Projects なら重複しない
+			projects = append(projects, g.base.projects+int64((start+j)%g.cfg.Projects)+1)
+			roles = append(roles, projectRoles[(e+j)%len(projectRoles)])
+		}
+	}
+	return []interface{}{employees, projects, roles}, len(employees)
+}
+
+// products - 商品（商品名は一覧を使い切ると「後継モデル」と番号を付ける）
+func (g *generator) products(from, to int) ([]interface{}, int) {
+	n := to - from
+	ids, names, cats, prices := make([]int64, n), make([]string, n), make([]string, n), make([]float64, n)
+	for i := range n {
+		p := from + i
+		ids[i] = g.base.products + int64(p) + 1
+		names[i] = productName(p)
+		cats[i] = categories[p%len(categories)]
+		prices[i] = roundTo(g.between(1000, 100000), 100)
+	}
+	return []interface{}{ids, names, cats, prices}, n
+}
+
+// orders - 受注（3件に1件は備考なし、DeletedPercent%を論理削除済みにする）
+func (g *generator) orders(from, to int) ([]interface{}, int) {
+	n := to - from
+	ids, customers, names := make([]int64, n), make([]int64, n), make([]string, n)
+	dates, totals, sts := make([]time.Time, n), make([]float64, n), make([]string, n)
+	notes, deleted := make([]sql.NullString, n), make([]string, n)
+	for i := range n {
+		o := from + i
+		ids[i] = g.base.orders + int64(o) + 1
+		c := g.rng.IntN(g.cfg.Customers)
+		customers[i] = firstCustomerID + int64(c)
+		names[i] = numbered(companies, c, "_")
+		dates[i] = g.daysAgo(0, float64(g.cfg.Days))
+		totals[i] = roundTo(g.between(50000, 1000000), 1000)
+		sts[i] = statuses[o%len(statuses)]
+		if o%3 != 0 && g.cfg.NotesMaxChars > 0 {
+			notes[i] = sql.NullString{String: g.note(ids[i]), Valid: true}
+		}
+		deleted[i] = "N"
+		if g.rng.IntN(100) < g.cfg.DeletedPercent {
+			deleted[i] = "Y"
+		}
+	}
+	return []interface{}{ids, customers, names, dates, totals, sts, notes, deleted}, n
+}
+
+// orderDetails - 受注ごとにDetailsPerOrder件の明細（商品名は受注時点のスナップショットとして商品と揃える）
+func (g *generator) orderDetails(from, to int) ([]interface{}, int) {
+	n := to - from
+	ids, orders, products := make([]int64, n), make([]int64, n), make([]int64, n)
+	names, quantities, prices := make([]string, n), make([]int64, n), make([]float64, n)
+	for i := range n {
+		d := from + i
+		p := g.rng.IntN(g.cfg.Products)
+		ids[i] = g.base.details + int64(d) + 1
+		orders[i] = g.base.orders + int64(d/g.cfg.DetailsPerOrder) + 1
+		products[i] = g.base.products + int64(p) + 1
+		names[i] = productName(p)
+		quantities[i] = 1 + g.rng.Int64N(10)
+		prices[i] = roundTo(g.between(1000, 100000), 100)
+	}
+	return []interface{}{ids, orders, products, names, quantities, prices}, n
+}
+
+// note - 受注備考（NotesMaxCharsの1/8〜NotesMaxChars文字）
+func (g *generator) note(orderID int64) string {
+	length := g.cfg.NotesMaxChars/8 + g.rng.IntN(g.cfg.NotesMaxChars-g.cfg.NotesMaxChars/8+1)
+	prefix := fmt.Sprintf("Order note #%d: ", orderID)
+	body := prefix + strings.Repeat(notesPhrase, length/len(notesPhrase)+1)
+	return body[:max(length, len(prefix))]
+}
+
+// between - loからhiの一様な値
+func (g *generator) between(lo, hi float64) float64 {
+	return lo + g.rng.Float64()*(hi-lo)
+}
+
+// daysAgo - 現在からminDays〜maxDays日前の日時（秒単位）
+func (g *generator) daysAgo(minDays, maxDays float64) time.Time {
+	ago := time.Duration(g.between(minDays, maxDays) * float64(24*time.Hour))
+	return g.now.Add(-ago).Truncate(time.Second)
+}
+
+// productName - i番目の商品の名前
+func productName(i int) string {
+	return numbered(productNames, i, " 後継モデル")
+}
+
+// numbered - 一覧のi番目の名前（一覧を使い切った2周目以降は sep と周回数を付ける）
+func numbered(names []string, i int, sep string) string {
+	name := names[i%len(names)]
+	if round := i / len(names); round > 0 {
+		name = fmt.Sprintf("%s%s%d", name, sep, round+1)
+	}
+	return name
+}
+
+// roundTo - unitの倍数に丸める
+func roundTo(v, unit float64) float64 {
+	return math.Round(v/unit) * unit
+}
@@ -0,0 +1,300 @@
+// Package seed - デモスキーマを作成し、指定した件数の合成データを配列バインドで一括投入する（seedコマンド）
+//
+// 部署・社員・プロジェクト・商品・受注・明細を外部キーの依存順に、既存の最大IDの次から投入する。
+// 同じ設定とシードなら同じデータになるため、件数を変えてN+1の影響を再現できる。
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/provision"
+	"oracle-n-plus-1-demo/internal/sqlutil"
+	"oracle-n-plus-1-demo/scripts/ddl"
+)
+
+const (
+	// DefaultOrders / DefaultDetailsPerOrder - 既定の受注の件数と受注あたりの明細数（scripts/load_test_data.sh と同じ）
+	DefaultOrders          = 1000
+	DefaultDetailsPerOrder = 5
+	// DefaultBatchSize - 配列バインド1回（1コミット）あたりの行数
+	DefaultBatchSize = 1000
+	// maxNotesChars - 受注備考の上限（VARCHAR2としてバインドできる4000バイト。備考は1バイト文字だけで作る）
+	maxNotesChars = 4000
+	// monthlySalesView - 投入後に完全リフレッシュする月次売上のマテリアライズドビュー
+	monthlySalesView = "MV_MONTHLY_CUSTOMER_SALES"
+)
+
+// sequences - 投入後に最大IDの次へ進めるシーケンスと、その表・ID列
+var sequences = []struct {
+	name, table, column string
+}{
+	{"SEQ_DEPARTMENTS", "departments", "department_id"},
+	{"SEQ_EMPLOYEES", "employees", "employee_id"},
+	{"SEQ_PROJECTS", "projects", "project_id"},
+	{"SEQ_ORDERS", "orders", "order_id"},
+	{"SEQ_ORDER_DETAILS", "order_details", "detail_id"},
+}
+
+// ErrSchemaNotReady - デモスキーマのオブジェクトを作成できなかった
+var ErrSchemaNotReady = errors.New("failed to create demo schema objects")
+
+// Config - 投入するデータの量と分布
+type Config struct {
+	// Departments / EmployeesPerDepartment - 部署の数と部署あたりの社員数
+	Departments            int `json:"departments"`
+	EmployeesPerDepartment int `json:"employees_per_department"`
+	// Projects / ProjectsPerEmployee - プロジェクトの数と社員あたりの割り当て数の上限（1〜上限件を割り当てる）
+	Projects            int `json:"projects"`
+	ProjectsPerEmployee int `json:"projects_per_employee"`
+	// Products / Customers - 商品の数と受注の顧客の数（顧客IDは1001から）
+	Products  int `json:"products"`
+	Customers int `json:"customers"`
+	// Orders / DetailsPerOrder - 受注の件数と受注あたりの明細数
+	Orders          int `json:"orders"`
+	DetailsPerOrder int `json:"details_per_order"`
+	// Days - 受注日を散らす期間（過去何日間）
+	Days int `json:"days"`
+	// DeletedPercent - 論理削除済み（deleted_flag = 'Y'）にする受注の割合（%）
+	DeletedPercent int `json:"deleted_percent"`
+	// NotesMaxChars - 受注備考（CLOB）の最大文字数（0: 備考を付けない）
+	NotesMaxChars int `json:"notes_max_chars"`
+	// BatchSize - 配列バインド1回（1コミット）あたりの行数
+	BatchSize int `json:"batch_size"`
+	// Seed - 乱数シード（同じ設定とシードなら同じデータになる）
+	Seed uint64 `json:"seed"`
+}
+
+// DefaultConfig - 既定の設定（scripts/load_test_data.sh と同じ量と分布）
+func DefaultConfig() Config {
+	return Config{
+		Departments:            20,
+		EmployeesPerDepartment: 50,
+		Projects:               30,
+		ProjectsPerEmployee:    3,
+		Products:               100,
+		Customers:              50,
+		Orders:                 DefaultOrders,
+		DetailsPerOrder:        DefaultDetailsPerOrder,
+		Days:                   365,
+		DeletedPercent:         80,
+		NotesMaxChars:          maxNotesChars,
+		BatchSize:              DefaultBatchSize,
+		Seed:                   1,
+	}
+}
+
+// Validate - 設定の範囲を確認
+func (c Config) Validate() error {
+	for _, v := range []struct {
+		name  string
+		value int
+	}{
+		{"departments", c.Departments},
+		{"employees per department", c.EmployeesPerDepartment},
+		{"projects", c.Projects},
+		{"projects per employee", c.ProjectsPerEmployee},
+		{"products", c.Products},
+		{"customers", c.Customers},
+		{"orders", c.Orders},
+		{"details per order", c.DetailsPerOrder},
+		{"days", c.Days},
+		{"batch size", c.BatchSize},
+	} {
+		if v.value <= 0 {
+			return fmt.Errorf("%s must be positive: %d", v.name, v.value)
+		}
+	}
+	if c.ProjectsPerEmployee > c.Projects {
+		return fmt.Errorf("projects per employee must not exceed projects: %d > %d", c.ProjectsPerEmployee, c.Projects)
+	}
+	if c.DeletedPercent < 0 || c.DeletedPercent > 100 {
+		return fmt.Errorf("deleted percent must be between 0 and 100: %d", c.DeletedPercent)
+	}
+	if c.NotesMaxChars < 0 || c.NotesMaxChars > maxNotesChars {
+		return fmt.Errorf("notes max chars must be between 0 and %d: %d", maxNotesChars, c.NotesMaxChars)
+	}
+	return nil
+}
+
+// TableResult - 1つの表の投入結果
+type TableResult struct {
+	Table    string        `json:"table"`
+	Rows     int           `json:"rows"`
+	Batches  int           `json:"batches"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report - 投入結果
+type Report struct {
+	Config Config        `json:"config"`
+	Tables []TableResult `json:"tables"`
+	// Elapsed - 投入・シーケンスの調整・統計の収集を含めた時間
+	Elapsed time.Duration `json:"elapsed"`
+	// Warnings - 投入は終えたが失敗した後処理（シーケンスの調整・統計の収集・マテリアライズドビューの更新）
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Rows - 投入した行数の合計
+func (r *Report) Rows() int {
+	total := 0
+	for _, t := range r.Tables {
+		total += t.Rows
+	}
+	return total
+}
+
+// EnsureSchema - 埋め込んだDDLスクリプトのうち不足している表・索引・シーケンス・マテリアライズドビューを作成する（setupコマンドと同じ）
+//
+// 定義が異なる既存のオブジェクトは変更せず報告するだけで、作成に失敗した場合だけ ErrSchemaNotReady を返す。
+func EnsureSchema(ctx context.Context, db *sql.DB, onCreate func(stmt string)) (*provision.Report, error) {
+	p, err := provision.New(db, ddl.CreateTables)
+	if err != nil {
+		return nil, err
+	}
+	report, err := p.Run(ctx, true, onCreate)
+	if err != nil {
+		return nil, err
+	}
+	if failed := report.Count(provision.StatusFailed); failed > 0 {
+		return report, fmt.Errorf("%w: %d failed", ErrSchemaNotReady, failed)
+	}
+	return report, nil
+}
+
+// Run - 合成データを外部キーの依存順に投入し、シーケンス・統計・月次売上のマテリアライズドビューを更新する
+//
+// 既存のデータは残し、各表の最大IDの次からIDを振る。onTableは表ごとの投入を終えるたびに呼ばれる（nil可）。
+func Run(ctx context.Context, db *sql.DB, cfg Config, onTable func(TableResult)) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	base, err := currentMaxIDs(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Config: cfg}
+	g := newGenerator(cfg, base, start)
+	for _, t := range g.tables() {
+		result, err := insertTable(ctx, db, t, cfg.BatchSize)
+		if err != nil {
+			return report, err
+		}
+		report.Tables = append(report.Tables, result)
+		if onTable != nil {
+			onTable(result)
+		}
+	}
+
+	report.Warnings = append(report.Warnings, syncSequences(ctx, db)...)
+	report.Warnings = append(report.Warnings, refreshStatistics(ctx, db, report.Tables)...)
+	report.Elapsed = time.Since(start)
+	return report, nil
+}
+
+// insertTable - 1つの表の行をBatchSizeの単位ごとに配列バインドでINSERTし、単位ごとにコミットする
+func insertTable(ctx context.Context, db *sql.DB, t table, batchSize int) (TableResult, error) {
+	start := time.Now()
+	result := TableResult{Table: t.name}
+	insertSQL := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		t.name, strings.Join(t.columns, ", "), sqlutil.Placeholders(len(t.columns)))
+
+	for from := 0; from < t.units; from += batchSize {
+		args, rows := t.rows(from, min(from+batchSize, t.units))
+		if rows == 0 {
+			continue
+		}
+		if err := execBatch(ctx, db, insertSQL, args); err != nil {
+			return result, fmt.Errorf("failed to insert into %s (rows %d-): %w", t.name, result.Rows+1, err)
+		}
+		result.Rows += rows
+		result.Batches++
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// execBatch - 配列バインドで1回INSERTしてコミット
+func execBatch(ctx context.Context, db *sql.DB, insertSQL string, args []interface{}) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// go-oraはスライスを引数に渡すと配列バインド（1ラウンドトリップ）で実行する
+	if _, err := tx.ExecContext(ctx, insertSQL, args...); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			fmt.Printf("tx.Rollback() failed: %v\n", rerr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+	return nil
+}
+
+// currentMaxIDs - 各表の投入前の最大ID（空の表は0）
+func currentMaxIDs(ctx context.Context, db *sql.DB) (baseIDs, error) {
+	var base baseIDs
+	for _, target := range []struct {
+		table, column string
+		dest          *int64
+	}{
+		{"departments", "department_id", &base.departments},
+		{"employees", "employee_id", &base.employees},
+		{"projects", "project_id", &base.projects},
+		{"products", "product_id", &base.products},
+		{"orders", "order_id", &base.orders},
+		{"order_details", "detail_id", &base.details},
+	} {
+		query := fmt.Sprintf("SELECT NVL(MAX(%s), 0) FROM %s", target.column, target.table)
+		if err := db.QueryRowContext(ctx, query).Scan(target.dest); err != nil {
+			return baseIDs{}, fmt.Errorf("failed to get max id of %s: %w", target.table, err)
+		}
+	}
+	return base, nil
+}
+
+// syncSequences - シーケンスを表の最大IDの次から払い出すように進める（失敗は警告として返す）
+//
+// 投入したIDをシーケンスが後から払い出すと、初期データのスクリプトなどのINSERTが一意制約違反になるため。
+// RESTARTはOracle Database 18c以降で使える。
+func syncSequences(ctx context.Context, db *sql.DB) []string {
+	var warnings []string
+	for _, seq := range sequences {
+		var next int64
+		query := fmt.Sprintf("SELECT NVL(MAX(%s), 0) + 1 FROM %s", seq.column, seq.table)
+		if err := db.QueryRowContext(ctx, query).Scan(&next); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: failed to get next id: %v", seq.name, err))
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER SEQUENCE %s RESTART START WITH %d", seq.name, next)
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: failed to restart at %d: %v", seq.name, next, err))
+		}
+	}
+	return warnings
+}
+
+// refreshStatistics - 投入した表のオプティマイザ統計を収集し、月次売上のマテリアライズドビューを完全リフレッシュする（失敗は警告として返す）
+func refreshStatistics(ctx context.Context, db *sql.DB, tables []TableResult) []string {
+	var warnings []string
+	for _, t := range tables {
+		if _, err := db.ExecContext(ctx, "BEGIN DBMS_STATS.GATHER_TABLE_STATS(USER, :1); END;", strings.ToUpper(t.Table)); err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to gather stats for %s: %v", t.Table, err))
+		}
+	}
+	if _, err := db.ExecContext(ctx, "BEGIN DBMS_MVIEW.REFRESH(:1, 'C'); END;", monthlySalesView); err != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to refresh %s: %v", monthlySalesView, err))
+	}
+	return warnings
+}
@@ -0,0 +1,174 @@
+package seed
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// smallConfig - 表ごとに複数のバッチへ分かれる小さな設定
+func smallConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Departments = 3
+	cfg.EmployeesPerDepartment = 4
+	cfg.Projects = 5
+	cfg.Products = 7
+	cfg.Orders = 30
+	cfg.DetailsPerOrder = 4
+	cfg.NotesMaxChars = 200
+	return cfg
+}
+
+// generateAll - すべての表の行をbatchSizeごとに生成し、表ごとの列のスライスをつなげて返す
+func generateAll(cfg Config, base baseIDs, batchSize int) map[string][]interface{} {
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	tables := make(map[string][]interface{})
+	for _, t := range newGenerator(cfg, base, now).tables() {
+		for from := 0; from < t.units; from += batchSize {
+			args, _ := t.rows(from, min(from+batchSize, t.units))
+			if tables[t.name] == nil {
+				tables[t.name] = args
+				continue
+			}
+			for i, column := range args {
+				tables[t.name][i] = reflect.AppendSlice(reflect.ValueOf(tables[t.name][i]), reflect.ValueOf(column)).Interface()
+			}
+		}
+	}
+	return tables
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{name: "default", modify: func(c *Config) {}},
+		{name: "no notes", modify: func(c *Config) { c.NotesMaxChars = 0 }},
+		{name: "no orders", modify: func(c *Config) { c.Orders = 0 }, wantErr: true},
+		{name: "no details", modify: func(c *Config) { c.DetailsPerOrder = 0 }, wantErr: true},
+		{name: "too many projects per employee", modify: func(c *Config) { c.ProjectsPerEmployee = c.Projects + 1 }, wantErr: true},
+		{name: "deleted percent over 100", modify: func(c *Config) { c.DeletedPercent = 101 }, wantErr: true},
+		{name: "notes longer than a VARCHAR2 bind", modify: func(c *Config) { c.NotesMaxChars = 4001 }, wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		tt.modify(&cfg)
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGeneratorRows(t *testing.T) {
+	cfg := smallConfig()
+	base := baseIDs{departments: 8, employees: 20, projects: 3, products: 2010, orders: 5, details: 12}
+	tables := generateAll(cfg, base, 7)
+
+	orderIDs := tables["orders"][0].([]int64)
+	if len(orderIDs) != cfg.Orders || orderIDs[0] != 6 || orderIDs[len(orderIDs)-1] != 35 {
+		t.Errorf("order ids = %v, want 6..35", orderIDs)
+	}
+
+	detailIDs := tables["order_details"][0].([]int64)
+	detailOrders := tables["order_details"][1].([]int64)
+	detailProducts := tables["order_details"][2].([]int64)
+	if len(detailIDs) != cfg.Orders*cfg.DetailsPerOrder || detailIDs[0] != 13 {
+		t.Fatalf("got %d details starting at %d, want %d starting at 13", len(detailIDs), detailIDs[0], cfg.Orders*cfg.DetailsPerOrder)
+	}
+	perOrder := make(map[int64]int)
+	for i, orderID := range detailOrders {
+		perOrder[orderID]++
+		if p := detailProducts[i]; p <= base.products || p > base.products+int64(cfg.Products) {
+			t.Errorf("detail %d refers to product %d outside the seeded products", detailIDs[i], p)
+		}
+	}
+	for _, orderID := range orderIDs {
+		if perOrder[orderID] != cfg.DetailsPerOrder {
+			t.Errorf("order %d has %d details, want %d", orderID, perOrder[orderID], cfg.DetailsPerOrder)
+		}
+	}
+
+	// 社員は部署ごとに同じ人数で、メールアドレス（一意制約）は重複しない
+	emails := tables["employees"][3].([]string)
+	depts := tables["employees"][4].([]int64)
+	seen := make(map[string]bool)
+	for i, email := range emails {
+		if seen[email] {
+			t.Errorf("duplicate email %s", email)
+		}
+		seen[email] = true
+		if want := base.departments + int64(i/cfg.EmployeesPerDepartment) + 1; depts[i] != want {
+			t.Errorf("employee %d is in department %d, want %d", i, depts[i], want)
+		}
+	}
+
+	// 社員・プロジェクトの割り当ては主キー（社員, プロジェクト）が重複しない
+	assigned := make(map[[2]int64]bool)
+	employees := tables["employee_projects"][0].([]int64)
+	projects := tables["employee_projects"][1].([]int64)
+	for i := range employees {
+		key := [2]int64{employees[i], projects[i]}
+		if assigned[key] {
+			t.Errorf("employee %d is assigned to project %d twice", key[0], key[1])
+		}
+		assigned[key] = true
+		if projects[i] <= base.projects || projects[i] > base.projects+int64(cfg.Projects) {
+			t.Errorf("assignment refers to project %d outside the seeded projects", projects[i])
+		}
+	}
+
+	for i, note := range tables["orders"][6].([]sql.NullString) {
+		if (i%3 == 0) == note.Valid {
+			t.Errorf("order %d notes valid = %v, want every third order without notes", i, note.Valid)
+		}
+		if len(note.String) > cfg.NotesMaxChars {
+			t.Errorf("order %d notes has %d chars, want at most %d", i, len(note.String), cfg.NotesMaxChars)
+		}
+	}
+}
+
+func TestGeneratorDeletedPercent(t *testing.T) {
+	for _, percent := range []int{0, 80, 100} {
+		cfg := smallConfig()
+		cfg.Orders = 2000
+		cfg.DeletedPercent = percent
+		deleted := 0
+		for _, flag := range generateAll(cfg, baseIDs{}, 500)["orders"][7].([]string) {
+			if flag == "Y" {
+				deleted++
+			}
+		}
+		got := deleted * 100 / cfg.Orders
+		if got < percent-5 || got > percent+5 {
+			t.Errorf("DeletedPercent=%d: %d%% of orders are deleted", percent, got)
+		}
+	}
+}
+
+func TestGeneratorDeterministic(t *testing.T) {
+	cfg := smallConfig()
+	// バッチの大きさが変わっても、同じシードなら同じ行になる
+	a := generateAll(cfg, baseIDs{}, 3)
+	b := generateAll(cfg, baseIDs{}, 1000)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("rows differ between batch sizes with the same seed")
+	}
+
+	cfg.Seed = 2
+	c := generateAll(cfg, baseIDs{}, 1000)
+	if reflect.DeepEqual(a["orders"], c["orders"]) {
+		t.Error("rows are identical with a different seed")
+	}
+}
+
+func TestNumbered(t *testing.T) {
+	names := []string{"営業部", "開発部"}
+	for i, want := range []string{"営業部", "開発部", "営業部_2", "開発部_2", "営業部_3"} {
+		if got := numbered(names, i, "_"); got != want {
+			t.Errorf("numbered(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+)
+
+// bufferCacheHitThreshold - 2回目以降の実行がこの時間未満ならBuffer Cacheにヒットしたとみなす
+const bufferCacheHitThreshold = 50 * time.Millisecond
+
+// runTimings - 繰り返し実行した各回の実行時間とキャッシュヒット
+type runTimings struct {
+	durations []time.Duration
+	hits      []bool
+}
+
+// timeRuns - runをruns回実行し、各回の実行時間をclkで計測する
+//
+// runはその回がキャッシュヒットだったかを返す（ヒットを判定しない計測ではfalseでよい）。
+// ctxが取り消された場合は次の回を始めずErrStoppedを返す。
+func timeRuns(ctx context.Context, clk clock.Clock, runs int, run func(i int) (bool, error)) (runTimings, error) {
+	t := runTimings{
+		durations: make([]time.Duration, 0, runs),
+		hits:      make([]bool, 0, runs),
+	}
+	for i := 0; i < runs; i++ {
+		if err := stopped(ctx); err != nil {
+			return t, err
+		}
+		start := clk.Now()
+		hit, err := run(i)
+		if err != nil {
+			return t, err
+		}
+		t.durations = append(t.durations, clk.Since(start))
+		t.hits = append(t.hits, hit)
+	}
+	return t, nil
+}
+
+// average - 実行時間の平均（実行していない場合は0）
+func (t runTimings) average() time.Duration {
+	return averageDuration(t.durations)
+}
+
+// hitRate - キャッシュヒットした回の割合（%）
+func (t runTimings) hitRate() float64 {
+	if len(t.hits) == 0 {
+		return 0
+	}
+	var hits int
+	for _, hit := range t.hits {
+		if hit {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(t.hits)) * 100
+}
+
+// averageDuration - 実行時間の平均（空の場合は0）
+func averageDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
+
+// thresholdHitRate - 初回（キャッシュが温まる前）を除き、threshold未満で終わった回の割合（%）
+//
+// 2回未満の実行では判定できないため0を返す。
+func thresholdHitRate(durations []time.Duration, threshold time.Duration) float64 {
+	if len(durations) < 2 {
+		return 0
+	}
+	var hits int
+	for _, d := range durations[1:] {
+		if d < threshold {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(durations)-1) * 100
+}
+
+// improvementRatio - 基準の実行時間に対する高速化の倍率（基準 / 比較対象。比較対象が0以下の場合は0）
+func improvementRatio(base, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(base.Nanoseconds()) / float64(d.Nanoseconds())
+}
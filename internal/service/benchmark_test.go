@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+)
+
+func TestTimeRunsWithFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	steps := []time.Duration{120 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}
+
+	timings, err := timeRuns(context.Background(), clk, len(steps), func(i int) (bool, error) {
+		clk.Advance(steps[i])
+		return i > 0, nil
+	})
+	if err != nil {
+		t.Fatalf("timeRuns() error = %v", err)
+	}
+
+	if len(timings.durations) != len(steps) {
+		t.Fatalf("len(durations) = %d, want %d", len(timings.durations), len(steps))
+	}
+	for i, want := range steps {
+		if timings.durations[i] != want {
+			t.Errorf("durations[%d] = %v, want %v", i, timings.durations[i], want)
+		}
+	}
+	if got, want := timings.average(), 45*time.Millisecond; got != want {
+		t.Errorf("average() = %v, want %v", got, want)
+	}
+	if got, want := timings.hitRate(), 75.0; got != want {
+		t.Errorf("hitRate() = %v, want %v", got, want)
+	}
+}
+
+func TestTimeRunsStopsOnError(t *testing.T) {
+	clk := clock.NewFake(time.Time{})
+	errQuery := errors.New("query failed")
+	calls := 0
+
+	timings, err := timeRuns(context.Background(), clk, 5, func(i int) (bool, error) {
+		calls++
+		clk.Advance(time.Millisecond)
+		if i == 2 {
+			return false, errQuery
+		}
+		return false, nil
+	})
+	if !errors.Is(err, errQuery) {
+		t.Fatalf("timeRuns() error = %v, want %v", err, errQuery)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	if len(timings.durations) != 2 {
+		t.Errorf("len(durations) = %d, want 2 (failed run is not recorded)", len(timings.durations))
+	}
+}
+
+func TestAverageDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      time.Duration
+	}{
+		{"empty", nil, 0},
+		{"single", []time.Duration{7 * time.Millisecond}, 7 * time.Millisecond},
+		{"multiple", []time.Duration{time.Millisecond, 2 * time.Millisecond, 6 * time.Millisecond}, 3 * time.Millisecond},
+		{"truncates", []time.Duration{1, 2}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := averageDuration(tt.durations); got != tt.want {
+				t.Errorf("averageDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThresholdHitRate(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      float64
+	}{
+		{"no runs", nil, 0},
+		{"single run", []time.Duration{ms}, 0},
+		{"first run excluded", []time.Duration{ms, 80 * ms}, 0},
+		{"all later runs fast", []time.Duration{200 * ms, 10 * ms, 20 * ms}, 100},
+		{"threshold is exclusive", []time.Duration{200 * ms, 50 * ms, 49 * ms, 60 * ms, 5 * ms}, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thresholdHitRate(tt.durations, bufferCacheHitThreshold); got != tt.want {
+				t.Errorf("thresholdHitRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunTimingsHitRate(t *testing.T) {
+	tests := []struct {
+		name string
+		hits []bool
+		want float64
+	}{
+		{"no runs", nil, 0},
+		{"all misses", []bool{false, false}, 0},
+		{"first miss then hits", []bool{false, true, true, true, true}, 80},
+		{"all hits", []bool{true, true, true}, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (runTimings{hits: tt.hits}).hitRate(); got != tt.want {
+				t.Errorf("hitRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImprovementRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		base, d  time.Duration
+		want     float64
+		epsilon  float64
+		wantZero bool
+	}{
+		{name: "faster", base: 32 * time.Millisecond, d: 4 * time.Millisecond, want: 8},
+		{name: "same", base: 5 * time.Millisecond, d: 5 * time.Millisecond, want: 1},
+		{name: "slower", base: time.Millisecond, d: 4 * time.Millisecond, want: 0.25},
+		{name: "fractional", base: 3216 * time.Microsecond, d: 297 * time.Microsecond, want: 10.828, epsilon: 0.001},
+		{name: "zero duration", base: time.Millisecond, d: 0, wantZero: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := improvementRatio(tt.base, tt.d)
+			if tt.wantZero {
+				if got != 0 {
+					t.Errorf("improvementRatio() = %v, want 0", got)
+				}
+				return
+			}
+			if math.Abs(got-tt.want) > tt.epsilon {
+				t.Errorf("improvementRatio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheComparisonSpeedup(t *testing.T) {
+	oracle := &CacheResult{Method: "Oracle_Result_Cache", ExecutionTime: 2 * time.Millisecond}
+	redis := &CacheResult{Method: "Redis_External_Cache", ExecutionTime: 5 * time.Millisecond}
+
+	if got, ok := (&CacheComparison{FastestOracle: oracle, Redis: redis}).Speedup(); !ok || got != 2.5 {
+		t.Errorf("Speedup() = %v, %v, want 2.5, true", got, ok)
+	}
+	if _, ok := (&CacheComparison{FastestOracle: redis, Redis: oracle}).Speedup(); ok {
+		t.Error("Speedup() ok = true when Oracle is slower, want false")
+	}
+	if _, ok := (&CacheComparison{Redis: redis}).Speedup(); ok {
+		t.Error("Speedup() ok = true without Oracle result, want false")
+	}
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"oracle-n-plus-1-demo/config"
 	"oracle-n-plus-1-demo/internal/cache"
+	"oracle-n-plus-1-demo/internal/clock"
+	"oracle-n-plus-1-demo/internal/costmodel"
+	backend "oracle-n-plus-1-demo/pkg/cache"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -21,98 +26,301 @@ type CacheResult struct {
 	MemoryUsage   int64         `json:"memory_usage_bytes"`
 	HitRate       float64       `json:"hit_rate"`
 	Description   string        `json:"description"`
+	// RunDurations - 各回の実行時間（包括分析の結果を統合したものは含まない）
+	RunDurations []time.Duration `json:"run_durations,omitempty"`
+	// RunHits - 各回がキャッシュヒットだったか（外部キャッシュのみ）
+	RunHits []bool `json:"run_hits,omitempty"`
+}
+
+// InternalCacheTest - Oracle内蔵キャッシュテストの結果
+type InternalCacheTest struct {
+	Runs int `json:"runs"`
+	// Analysis - 包括的性能分析の結果（失敗した場合はnilで、従来の計測結果のみ）
+	Analysis *cache.AnalysisResults `json:"analysis,omitempty"`
+	// AnalysisError - 包括的性能分析に失敗して従来の計測を行った理由
+	AnalysisError string        `json:"analysis_error,omitempty"`
+	Results       []CacheResult `json:"results"`
+	// Stats - インスタンス全体のキャッシュ統計（従来の計測時のみ）
+	Stats *InstanceCacheStats `json:"stats,omitempty"`
+	// Warnings - 計測の一部をスキップした理由
+	Warnings []string `json:"warnings,omitempty"`
+	// Memory - テスト前後のSGA構成・Result Cache・セッションPGA
+	Memory *OracleMemory `json:"memory,omitempty"`
+}
+
+// Fallback - 包括的性能分析に失敗して従来の計測を行ったか
+func (t *InternalCacheTest) Fallback() bool {
+	return t.Analysis == nil
+}
+
+// InstanceCacheStats - インスタンス全体のキャッシュ統計（V$ビュー、取得できない項目はnil）
+type InstanceCacheStats struct {
+	BufferHitRatio     *float64 `json:"buffer_hit_ratio,omitempty"`
+	ResultCacheObjects *int64   `json:"result_cache_objects,omitempty"`
+	ResultCacheBlocks  *int64   `json:"result_cache_blocks,omitempty"`
+	Errors             []string `json:"errors,omitempty"`
+}
+
+// ExternalCacheTest - 外部キャッシュ（Redis）テストの結果
+type ExternalCacheTest struct {
+	Runs int `json:"runs"`
+	// Available - Redisに接続できたか（falseの場合はテストをスキップ）
+	Available bool         `json:"available"`
+	Result    *CacheResult `json:"result,omitempty"`
+	// UsedMemory - テスト後のRedisの使用メモリ（INFO memory の used_memory_human）
+	UsedMemory string `json:"used_memory,omitempty"`
+	// Memory - テスト前後の使用メモリ・キー数とデモのキーごとの使用量
+	Memory *RedisMemory `json:"memory,omitempty"`
+	// TwoTier - 2層キャッシュ（ローカルLRU + Redis）の結果（失敗した場合はnilで、理由はWarnings）
+	TwoTier  *TwoTierCacheTest `json:"two_tier,omitempty"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+// TwoTierCacheTest - 2層キャッシュ（ローカルLRU + Redis）テストの結果
+type TwoTierCacheTest struct {
+	Result *CacheResult `json:"result"`
+	// Stats - 層ごとのヒット数と昇格・降格
+	Stats TwoTierStats `json:"stats"`
+	// RunTiers - 各回に値を返した層
+	RunTiers []CacheTier `json:"run_tiers,omitempty"`
+}
+
+// CacheComparison - キャッシュ方式の比較結果
+type CacheComparison struct {
+	Results []CacheResult `json:"results"`
+	// FastestOracle / Redis - 最速のOracle内蔵キャッシュと外部キャッシュの結果（どちらかがなければnil）
+	FastestOracle *CacheResult `json:"fastest_oracle,omitempty"`
+	Redis         *CacheResult `json:"redis,omitempty"`
+}
+
+// Speedup - 外部キャッシュに対する最速のOracle内蔵キャッシュの速度比（Oracleの方が速い場合のみ）
+func (c *CacheComparison) Speedup() (float64, bool) {
+	if c.FastestOracle == nil || c.Redis == nil || c.FastestOracle.ExecutionTime <= 0 ||
+		c.FastestOracle.ExecutionTime >= c.Redis.ExecutionTime {
+		return 0, false
+	}
+	return improvementRatio(c.Redis.ExecutionTime, c.FastestOracle.ExecutionTime), true
+}
+
+// MemoryUsage - キャッシュのメモリ使用状況
+type MemoryUsage struct {
+	SGA            []SGAComponent `json:"sga,omitempty"`
+	SGAError       string         `json:"sga_error,omitempty"`
+	RedisAvailable bool           `json:"redis_available"`
+	// Results - キャッシュ方式ごとのメモリ使用量（計測した順）
+	Results []CacheResult `json:"results,omitempty"`
+	// Oracle / Redis - テスト前後のメモリ使用状況（テストを実行していなければnil）
+	Oracle *OracleMemory `json:"oracle,omitempty"`
+	Redis  *RedisMemory  `json:"redis,omitempty"`
+}
+
+// SGAComponent - SGAの構成要素（V$SGA_DYNAMIC_COMPONENTS）
+type SGAComponent struct {
+	Component string  `json:"component"`
+	Bytes     int64   `json:"bytes"`
+	SizeMB    float64 `json:"size_mb"`
 }
 
 // CacheService - キャッシュ性能比較サービス
+//
+// 計測結果は構造化して返すだけで、表示は呼び出し側（presenter）が行う。
 type CacheService struct {
-	db                  *sql.DB
-	redisClient         *redis.Client
-	config              *config.Config
+	db          *sql.DB
+	redisClient *redis.Client
+	// ownsRedis - redisClientをこのサービスが作成した（Closeで閉じる）
+	ownsRedis           bool
 	results             []CacheResult
 	performanceAnalyzer *cache.PerformanceAnalyzer
-	bufferCache         *cache.OracleBufferCache
-	resultCache         *cache.OracleResultCache
+	analysis            *cache.AnalysisResults
+	// oracleMemory / redisMemory - 直近のキャッシュテスト前後のメモリ使用状況
+	oracleMemory *OracleMemory
+	redisMemory  *RedisMemory
+	// costModel - 月額コストの見積もりに使う単価モデル（nilなら見積もらない）
+	costModel *costmodel.Model
+	// redisErr - Redisに接続できなかった理由
+	redisErr error
+	// clock - 実行時間の計測に使う時計
+	clock clock.Clock
+	// plsqlFunction - PL/SQL Function Result Cacheテストで作成する関数の設定
+	plsqlFunction PLSQLFunctionOptions
+	// ctx - 取り消されると新しい回の計測を始めない
+	ctx context.Context
+
+	// mu - dropPLSQLFunction を中断時の後片付けと共有するためのロック
+	mu sync.Mutex
+	// dropPLSQLFunction - テスト中に作成したPL/SQL関数の削除（テスト中以外はnil）
+	dropPLSQLFunction func() error
 }
 
-// NewCacheService - キャッシュサービスのコンストラクタ
-func NewCacheService(db *sql.DB, cfg *config.Config) *CacheService {
-	// Redis接続を試行（失敗してもサービスは動作する）
-	var redisClient *redis.Client
-	if cfg.RedisHost != "" {
-		redisClient = redis.NewClient(&redis.Options{
-			Addr:     fmt.Sprintf("%s:%d", cfg.RedisHost, cfg.RedisPort),
-			Password: cfg.RedisPassword,
-			DB:       cfg.RedisDB,
-		})
+// ErrRedisNotConfigured - Redisのクライアントが渡されなかった（NewCacheServiceWithRedis）
+var ErrRedisNotConfigured = errors.New("redis client not configured")
 
-		// 接続テスト
-		ctx := context.Background()
-		if err := redisClient.Ping(ctx).Err(); err != nil {
-			fmt.Printf("Redis接続に失敗しました（キャッシュ比較はスキップされます）: %v\n", err)
-			redisClient = nil
-		}
+// NewCacheService - 設定からRedisに接続してキャッシュサービスを作成
+//
+// Redisに接続できなくてもサービスは動作する（外部キャッシュテストはスキップ）。理由は RedisError で取得できる。
+func NewCacheService(db *sql.DB, cfg *config.Config) *CacheService {
+	redisClient, err := config.ConnectRedis(cfg)
+	c := NewCacheServiceWithRedis(db, redisClient)
+	c.ownsRedis = redisClient != nil
+	if err != nil {
+		c.redisErr = err
 	}
+	return c
+}
 
+// NewCacheServiceWithRedis - 設定済みの接続プールとRedisのクライアントでキャッシュサービスを作成（redisClientはnil可）
+//
+// アプリケーションに組み込む場合に使う。環境変数や.envは読まず、渡したクライアントはCloseで閉じない。
+func NewCacheServiceWithRedis(db *sql.DB, redisClient *redis.Client) *CacheService {
+	var redisErr error
+	if redisClient == nil {
+		redisErr = ErrRedisNotConfigured
+	}
 	return &CacheService{
 		db:                  db,
 		redisClient:         redisClient,
-		config:              cfg,
 		results:             make([]CacheResult, 0),
 		performanceAnalyzer: cache.NewPerformanceAnalyzer(db),
-		bufferCache:         cache.NewOracleBufferCache(db),
-		resultCache:         cache.NewOracleResultCache(db),
+		redisErr:            redisErr,
+		clock:               clock.System,
+		plsqlFunction:       DefaultPLSQLFunctionOptions(),
+		ctx:                 context.Background(),
 	}
 }
 
+// SetClock - 実行時間の計測に使う時計を差し替える（包括的性能分析・読み書き混在ワークロードにも使う）
+func (c *CacheService) SetClock(clk clock.Clock) {
+	c.clock = clk
+	c.performanceAnalyzer.SetClock(clk)
+}
+
+// RedisError - Redisに接続できなかった理由（接続できた場合はnil）
+func (c *CacheService) RedisError() error {
+	return c.redisErr
+}
+
 // TestOracleInternalCache - Oracle内蔵キャッシュのテスト
-func (c *CacheService) TestOracleInternalCache(runs int) error {
-	fmt.Println("=== Oracle内蔵キャッシュ詳細性能テスト ===")
-	fmt.Printf("新しい詳細分析エンジンを使用（%d回実行）\n\n", runs)
+func (c *CacheService) TestOracleInternalCache(runs int) (*InternalCacheTest, error) {
+	test := &InternalCacheTest{Runs: runs}
+	test.Memory = &OracleMemory{Before: c.oracleMemorySnapshot()}
+	c.oracleMemory = test.Memory
 
 	// 1. 包括的性能分析の実行
 	analysisResults, err := c.performanceAnalyzer.PerformComprehensiveAnalysis(runs)
 	if err != nil {
-		fmt.Printf("包括的分析でエラーが発生しましたが、従来の分析を実行します: %v\n", err)
 		// フォールバック：従来の分析を実行
-		return c.testOracleInternalCacheFallback(runs)
+		test.AnalysisError = err.Error()
+		if err := c.testOracleInternalCacheFallback(test); err != nil {
+			return nil, err
+		}
+	} else {
+		// 2. 分析結果の統合
+		test.Analysis = analysisResults
+		test.Results = c.integrateAnalysisResults(analysisResults)
+		c.addBackendResults(test, analysisResults.Backends)
 	}
 
-	// 2. 分析結果の統合
-	c.integrateAnalysisResults(analysisResults)
+	// 3. テスト後のメモリ使用状況（V$ビューで求めた使用量を各結果に反映）
+	test.Memory.After = c.oracleMemorySnapshot()
+	fillOracleMemoryUsage(test.Results, test.Memory.After)
+	fillOracleMemoryUsage(c.results, test.Memory.After)
 
-	fmt.Println("\n✅ Oracle内蔵キャッシュ詳細分析が完了しました")
-	fmt.Println("より詳細な分析結果は包括的レポートで確認できます")
+	return test, nil
+}
 
-	return nil
+// OverallEfficiency - 包括分析で算出した総合キャッシュ効率（%）（フォールバック時などは取得不可）
+func (c *CacheService) OverallEfficiency() (float64, bool) {
+	if c.analysis == nil || c.analysis.PerformanceComparison == nil || c.analysis.PerformanceComparison.EfficiencyMetrics == nil {
+		return 0, false
+	}
+	return c.analysis.PerformanceComparison.EfficiencyMetrics.OverallCacheEfficiency, true
 }
 
 // testOracleInternalCacheFallback - 従来のOracle内蔵キャッシュテスト（フォールバック用）
-func (c *CacheService) testOracleInternalCacheFallback(runs int) error {
-	fmt.Println("=== Oracle内蔵キャッシュ基本性能テスト（フォールバック）===")
-
+func (c *CacheService) testOracleInternalCacheFallback(test *InternalCacheTest) error {
 	// 1. Database Buffer Cacheテスト
-	if err := c.testDatabaseBufferCache(runs); err != nil {
+	buffer, err := c.testDatabaseBufferCache(test.Runs)
+	if err != nil {
 		return fmt.Errorf("database buffer cacheテストでエラー: %w", err)
 	}
+	test.Results = append(test.Results, *buffer)
 
 	// 2. Result Cacheテスト
-	if err := c.testResultCache(runs); err != nil {
+	result, err := c.testResultCache(test.Runs)
+	if err != nil {
 		return fmt.Errorf("result cacheテストでエラー: %w", err)
 	}
+	test.Results = append(test.Results, *result)
 
-	// 3. PL/SQL Function Result Cacheテスト
-	if err := c.testPLSQLFunctionCache(runs); err != nil {
-		return fmt.Errorf("pl/sql function cacheテストでエラー: %w", err)
+	// 3. PL/SQL Function Result Cacheテスト（関数の作成を無効にした場合はスキップ）
+	if c.plsqlFunction.Disabled {
+		test.Warnings = append(test.Warnings, "PL/SQL関数の作成を無効にしているため、PL/SQL Function Result Cacheテストをスキップしました")
+	} else {
+		function, notes, err := c.testPLSQLFunctionCache(test.Runs)
+		test.Warnings = append(test.Warnings, notes...)
+		if err != nil {
+			test.Warnings = append(test.Warnings, fmt.Sprintf("PL/SQL関数作成でエラー（スキップ）: %v", err))
+		} else {
+			test.Results = append(test.Results, *function)
+		}
 	}
 
+	// 4. 独自のキャッシュ実装（-cache-backends 指定時のみ）
+	c.addBackendResults(test, c.performanceAnalyzer.MeasureBackends(test.Runs))
+
+	// インスタンス全体のキャッシュ統計
+	test.Stats = c.collectInstanceCacheStats()
+
+	return nil
+}
+
+// SetCacheBackends - 登録名を指定して、独自のキャッシュ実装（pkg/cache.Backend）を計測に加える
+func (c *CacheService) SetCacheBackends(names []string) error {
+	for _, name := range names {
+		b, err := backend.Open(name, c.db)
+		if err != nil {
+			return err
+		}
+		c.performanceAnalyzer.AddBackend(name, b)
+	}
 	return nil
 }
 
+// SetForeignKeyScope - 推奨事項で外部キーの索引を確認する範囲をスキーマのすべてのテーブルに広げる
+func (c *CacheService) SetForeignKeyScope(allTables bool) {
+	c.performanceAnalyzer.SetForeignKeyScope(allTables)
+}
+
+// addBackendResults - 独自のキャッシュ実装の計測結果を比較対象に加える（失敗したものは警告として記録）
+func (c *CacheService) addBackendResults(test *InternalCacheTest, backends []cache.BackendResult) {
+	for _, b := range backends {
+		if b.Error != "" {
+			test.Warnings = append(test.Warnings, fmt.Sprintf("%s（%s）の計測でエラー（スキップ）: %s", b.Name, b.Backend, b.Error))
+			continue
+		}
+		result := CacheResult{
+			Method:        b.Name,
+			ExecutionTime: b.AverageTime,
+			MemoryUsage:   b.MemoryUsage,
+			HitRate:       b.HitRate,
+			Description:   b.Description,
+			RunDurations:  b.RunDurations,
+			RunHits:       b.RunHits,
+		}
+		test.Results = append(test.Results, result)
+		c.results = append(c.results, result)
+	}
+}
+
 // integrateAnalysisResults - 分析結果をCacheServiceに統合
-func (c *CacheService) integrateAnalysisResults(results *cache.AnalysisResults) {
+func (c *CacheService) integrateAnalysisResults(results *cache.AnalysisResults) []CacheResult {
+	c.analysis = results
+
+	var integrated []CacheResult
+
 	// Buffer Cache結果の統合
 	if results.OracleBufferMetrics != nil {
-		c.results = append(c.results, CacheResult{
+		integrated = append(integrated, CacheResult{
 			Method:        "Oracle_Buffer_Cache_Advanced",
 			ExecutionTime: results.OracleBufferMetrics.TestExecutionTime,
 			MemoryUsage:   results.OracleBufferMetrics.TotalSizeBytes,
@@ -123,7 +331,7 @@ func (c *CacheService) integrateAnalysisResults(results *cache.AnalysisResults)
 
 	// Result Cache結果の統合
 	if results.OracleResultMetrics != nil {
-		c.results = append(c.results, CacheResult{
+		integrated = append(integrated, CacheResult{
 			Method:        "Oracle_Result_Cache_Advanced",
 			ExecutionTime: results.OracleResultMetrics.TestExecutionTime,
 			MemoryUsage:   results.OracleResultMetrics.MemoryUsage,
@@ -134,7 +342,7 @@ func (c *CacheService) integrateAnalysisResults(results *cache.AnalysisResults)
 
 	// 総合効率性メトリクスの統合
 	if results.PerformanceComparison != nil && results.PerformanceComparison.EfficiencyMetrics != nil {
-		c.results = append(c.results, CacheResult{
+		integrated = append(integrated, CacheResult{
 			Method:        "Oracle_Integrated_Cache",
 			ExecutionTime: (results.OracleBufferMetrics.TestExecutionTime + results.OracleResultMetrics.TestExecutionTime) / 2,
 			MemoryUsage:   results.OracleBufferMetrics.TotalSizeBytes + results.OracleResultMetrics.MemoryUsage,
@@ -142,30 +350,26 @@ func (c *CacheService) integrateAnalysisResults(results *cache.AnalysisResults)
 			Description:   fmt.Sprintf("Oracle統合キャッシュ（総合効率%.1f%%）", results.PerformanceComparison.EfficiencyMetrics.OverallCacheEfficiency),
 		})
 	}
+
+	c.results = append(c.results, integrated...)
+	return integrated
 }
 
 // testDatabaseBufferCache - Database Buffer Cacheの性能テスト
-func (c *CacheService) testDatabaseBufferCache(runs int) error {
-	fmt.Println("\n--- Database Buffer Cache テスト ---")
-
-	var totalDuration time.Duration
-	var hitCount int64
-
-	for i := 0; i < runs; i++ {
-		start := time.Now()
-
-		// 複数回同じデータにアクセスしてBuffer Cacheの効果を測定
-		query := `
-			SELECT o.order_id, o.customer_id, o.total_amount,
-			       od.detail_id, od.product_id, od.quantity
-			FROM orders o
-			JOIN order_details od ON o.order_id = od.order_id
-			WHERE o.order_date >= SYSDATE - 7
-			AND ROWNUM <= 100`
+func (c *CacheService) testDatabaseBufferCache(runs int) (*CacheResult, error) {
+	// 複数回同じデータにアクセスしてBuffer Cacheの効果を測定
+	query := `
+		SELECT o.order_id, o.customer_id, o.total_amount,
+		       od.detail_id, od.product_id, od.quantity
+		FROM orders o
+		JOIN order_details od ON o.order_id = od.order_id
+		WHERE o.order_date >= SYSDATE - 7
+		AND ROWNUM <= 100`
 
+	timings, err := timeRuns(c.ctx, c.clock, runs, func(int) (bool, error) {
 		rows, err := c.db.Query(query)
 		if err != nil {
-			return fmt.Errorf("database buffer cacheクエリでエラー: %w", err)
+			return false, fmt.Errorf("database buffer cacheクエリでエラー: %w", err)
 		}
 
 		var count int
@@ -179,54 +383,34 @@ func (c *CacheService) testDatabaseBufferCache(runs int) error {
 				if cerr := rows.Close(); cerr != nil {
 					fmt.Printf("rows.Close() failed: %v\n", cerr)
 				}
-				return fmt.Errorf("スキャンエラー: %w", err)
+				return false, fmt.Errorf("スキャンエラー: %w", err)
 			}
 			count++
 		}
 		if cerr := rows.Close(); cerr != nil {
 			fmt.Printf("rows.Close() failed: %v\n", cerr)
 		}
-
-		duration := time.Since(start)
-		totalDuration += duration
-
-		if i > 0 && duration < 50*time.Millisecond { // 2回目以降で高速ならキャッシュヒット
-			hitCount++
-		}
-
-		if i == 0 {
-			fmt.Printf("初回実行時間: %v (キャッシュなし)\n", duration)
-		} else if i < 3 {
-			fmt.Printf("%d回目実行時間: %v\n", i+1, duration)
-		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	avgDuration := totalDuration / time.Duration(runs)
-	hitRate := float64(hitCount) / float64(runs-1) * 100
-
-	c.results = append(c.results, CacheResult{
+	result := CacheResult{
 		Method:        "Oracle_Buffer_Cache",
-		ExecutionTime: avgDuration,
+		ExecutionTime: timings.average(),
 		MemoryUsage:   0, // Buffer Cacheのサイズは別途取得
-		HitRate:       hitRate,
+		HitRate:       thresholdHitRate(timings.durations, bufferCacheHitThreshold),
 		Description:   "Oracle Database Buffer Cache（データブロックキャッシュ）",
-	})
-
-	fmt.Printf("平均実行時間: %v\n", avgDuration)
-	fmt.Printf("推定キャッシュヒット率: %.1f%%\n", hitRate)
-
-	// Buffer Cache統計の取得
-	if err := c.getBufferCacheStats(); err != nil {
-		fmt.Printf("Buffer Cache統計取得でエラー: %v\n", err)
+		RunDurations:  timings.durations,
 	}
+	c.results = append(c.results, result)
 
-	return nil
+	return &result, nil
 }
 
 // testResultCache - Result Cacheの性能テスト
-func (c *CacheService) testResultCache(runs int) error {
-	fmt.Println("\n--- Oracle Result Cache テスト ---")
-
+func (c *CacheService) testResultCache(runs int) (*CacheResult, error) {
 	// Result Cacheヒント付きクエリ
 	query := `
 		SELECT /*+ RESULT_CACHE */
@@ -237,14 +421,10 @@ func (c *CacheService) testResultCache(runs int) error {
 		GROUP BY customer_id
 		ORDER BY total_sales DESC`
 
-	var totalDuration time.Duration
-
-	for i := 0; i < runs; i++ {
-		start := time.Now()
-
+	timings, err := timeRuns(c.ctx, c.clock, runs, func(int) (bool, error) {
 		rows, err := c.db.Query(query)
 		if err != nil {
-			return fmt.Errorf("result cacheクエリでエラー: %w", err)
+			return false, fmt.Errorf("result cacheクエリでエラー: %w", err)
 		}
 
 		var count int
@@ -258,151 +438,153 @@ func (c *CacheService) testResultCache(runs int) error {
 				if cerr := rows.Close(); cerr != nil {
 					fmt.Printf("rows.Close() failed: %v\n", cerr)
 				}
-				return fmt.Errorf("スキャンエラー: %w", err)
+				return false, fmt.Errorf("スキャンエラー: %w", err)
 			}
 			count++
 		}
 		if cerr := rows.Close(); cerr != nil {
 			fmt.Printf("rows.Close() failed: %v\n", cerr)
 		}
-
-		duration := time.Since(start)
-		totalDuration += duration
-
-		if i == 0 {
-			fmt.Printf("初回実行時間: %v (キャッシュなし)\n", duration)
-		} else if i < 3 {
-			fmt.Printf("%d回目実行時間: %v\n", i+1, duration)
-		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	avgDuration := totalDuration / time.Duration(runs)
-
-	c.results = append(c.results, CacheResult{
+	result := CacheResult{
 		Method:        "Oracle_Result_Cache",
-		ExecutionTime: avgDuration,
+		ExecutionTime: timings.average(),
 		MemoryUsage:   0,
 		HitRate:       0, // Result Cache統計から後で取得
 		Description:   "Oracle Server Result Cache（クエリ結果キャッシュ）",
-	})
-
-	fmt.Printf("平均実行時間: %v\n", avgDuration)
-
-	// Result Cache統計の取得
-	if err := c.getResultCacheStats(); err != nil {
-		fmt.Printf("Result Cache統計取得でエラー: %v\n", err)
+		RunDurations:  timings.durations,
 	}
+	c.results = append(c.results, result)
 
-	return nil
+	return &result, nil
 }
 
-// testPLSQLFunctionCache - PL/SQL Function Result Cacheの性能テスト
-func (c *CacheService) testPLSQLFunctionCache(runs int) error {
-	fmt.Println("\n--- PL/SQL Function Result Cache テスト ---")
-
-	// Function Result Cache付きファンクションを作成
-	createFunctionSQL := `
-		CREATE OR REPLACE FUNCTION get_customer_order_summary(p_customer_id NUMBER)
-		RETURN VARCHAR2
-		RESULT_CACHE RELIES_ON (orders)
-		IS
-			l_summary VARCHAR2(1000);
-		BEGIN
-			SELECT 'Orders: ' || COUNT(*) || ', Total: $' || ROUND(SUM(total_amount), 2)
-			INTO l_summary
-			FROM orders
-			WHERE customer_id = p_customer_id
-			AND order_date >= SYSDATE - 90;
-			
-			RETURN l_summary;
-		EXCEPTION
-			WHEN NO_DATA_FOUND THEN
-				RETURN 'No orders found';
-		END;`
-
-	_, err := c.db.Exec(createFunctionSQL)
+// testPLSQLFunctionCache - PL/SQL Function Result Cacheの性能テスト（関数を作成できない場合はエラー）
+//
+// 関数は SetPLSQLFunctionOptions の名前で作成し、テスト後に削除する。関数の扱いに関する注意は戻り値の2番目で返す。
+func (c *CacheService) testPLSQLFunctionCache(runs int) (*CacheResult, []string, error) {
+	cleanup, notes, err := c.preparePLSQLFunction()
 	if err != nil {
-		fmt.Printf("PL/SQL関数作成でエラー（スキップ）: %v\n", err)
-		return nil
+		return nil, notes, err
 	}
+	// 中断時はCloseから削除するため、どちらか一方だけが削除する
+	c.mu.Lock()
+	c.dropPLSQLFunction = cleanup
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		drop := c.dropPLSQLFunction
+		c.dropPLSQLFunction = nil
+		c.mu.Unlock()
+		if drop == nil {
+			return
+		}
+		if err := drop(); err != nil {
+			fmt.Printf("PL/SQL関数の削除に失敗しました: %v\n", err)
+		}
+	}()
+	callSQL := fmt.Sprintf("SELECT %s(:1) FROM DUAL", c.plsqlFunction.FunctionName())
 
-	var totalDuration time.Duration
-
-	for i := 0; i < runs; i++ {
-		start := time.Now()
-
+	timings, err := timeRuns(c.ctx, c.clock, runs, func(int) (bool, error) {
 		// 複数の顧客IDで関数を呼び出し
 		for customerID := 1; customerID <= 10; customerID++ {
 			var result string
-			err := c.db.QueryRow("SELECT get_customer_order_summary(?) FROM DUAL", customerID).Scan(&result)
+			err := c.db.QueryRowContext(c.ctx, callSQL, customerID).Scan(&result)
 			if err != nil {
 				continue // エラーは無視して続行
 			}
 		}
-
-		duration := time.Since(start)
-		totalDuration += duration
-
-		if i == 0 {
-			fmt.Printf("初回実行時間: %v (キャッシュなし)\n", duration)
-		} else if i < 3 {
-			fmt.Printf("%d回目実行時間: %v\n", i+1, duration)
-		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, notes, err
 	}
 
-	avgDuration := totalDuration / time.Duration(runs)
-
-	c.results = append(c.results, CacheResult{
+	result := CacheResult{
 		Method:        "Oracle_Function_Cache",
-		ExecutionTime: avgDuration,
+		ExecutionTime: timings.average(),
 		MemoryUsage:   0,
 		HitRate:       0,
 		Description:   "Oracle PL/SQL Function Result Cache",
-	})
-
-	fmt.Printf("平均実行時間: %v\n", avgDuration)
+		RunDurations:  timings.durations,
+	}
+	c.results = append(c.results, result)
 
-	return nil
+	return &result, notes, nil
 }
 
 // TestExternalCache - 外部キャッシュ（Redis）のテスト
-func (c *CacheService) TestExternalCache(runs int) error {
-	if c.redisClient == nil {
-		fmt.Println("=== 外部キャッシュ（Redis）テスト ===")
-		fmt.Println("Redis接続が利用できないため、外部キャッシュテストをスキップします。")
-		return nil
+func (c *CacheService) TestExternalCache(runs int) (*ExternalCacheTest, error) {
+	test := &ExternalCacheTest{Runs: runs, Available: c.redisClient != nil}
+	if !test.Available {
+		return test, nil
 	}
 
-	fmt.Println("=== 外部キャッシュ（Redis）性能テスト ===")
+	memory := &RedisMemory{}
+	before, err := c.redisMemorySnapshot()
+	if err != nil {
+		test.Warnings = append(test.Warnings, fmt.Sprintf("Redis使用量取得でエラー（テスト前）: %v", err))
+	}
+	memory.Before = before
 
-	if err := c.testRedisCache(runs); err != nil {
-		return fmt.Errorf("redisキャッシュテストでエラー: %w", err)
+	result, err := c.testRedisCache(runs)
+	if err != nil {
+		return nil, fmt.Errorf("redisキャッシュテストでエラー: %w", err)
 	}
 
-	return nil
+	// Redis使用量の取得
+	after, err := c.redisMemorySnapshot()
+	if err != nil {
+		test.Warnings = append(test.Warnings, fmt.Sprintf("Redis使用量取得でエラー（テスト後）: %v", err))
+	} else {
+		test.UsedMemory = after.UsedMemoryHuman
+	}
+	memory.After = after
+
+	// MEMORY USAGE が使えない場合は前後の増分をキャッシュの使用量とする
+	memory.Keys, memory.KeysTruncated, err = c.redisKeyMemory()
+	if err != nil {
+		test.Warnings = append(test.Warnings, fmt.Sprintf("キーごとのRedis使用量取得でエラー: %v", err))
+	}
+
+	result.MemoryUsage = memory.CacheBytes()
+	c.results = append(c.results, *result)
+	test.Result = result
+	test.Memory = memory
+	c.redisMemory = memory
+
+	// 2層キャッシュ（Redisのキーは上のテストで保存したものを共有する）
+	twoTier, err := c.testTwoTierCache(runs)
+	if err != nil {
+		if errors.Is(err, ErrStopped) {
+			return nil, err
+		}
+		test.Warnings = append(test.Warnings, fmt.Sprintf("2層キャッシュテストでエラー（スキップ）: %v", err))
+	} else {
+		c.results = append(c.results, *twoTier.Result)
+		test.TwoTier = twoTier
+	}
+
+	return test, nil
 }
 
-// testRedisCache - Redisキャッシュの性能テスト
-func (c *CacheService) testRedisCache(runs int) error {
-	fmt.Println("\n--- Redis外部キャッシュ テスト ---")
+// redisOrdersCacheKey - Redisキャッシュテストで使うキー
+const redisOrdersCacheKey = "orders_with_details_last_7_days"
 
+// testRedisCache - Redisキャッシュの性能テスト（使用メモリは呼び出し側で設定する）
+func (c *CacheService) testRedisCache(runs int) (*CacheResult, error) {
 	ctx := context.Background()
-	var totalDuration time.Duration
-	var hitCount int64
 
-	// テストデータの準備
-	testQuery := `
-		SELECT o.order_id, o.customer_id, o.total_amount,
-		       od.detail_id, od.product_id, od.quantity
-		FROM orders o
-		JOIN order_details od ON o.order_id = od.order_id
-		WHERE o.order_date >= SYSDATE - 7
-		AND ROWNUM <= 100`
-
-	for i := 0; i < runs; i++ {
-		start := time.Now()
+	// テストデータの準備（独自のキャッシュ実装と同じクエリ）
+	testQuery := backend.BenchmarkQuery
 
-		cacheKey := "orders_with_details_last_7_days"
+	timings, err := timeRuns(c.ctx, c.clock, runs, func(int) (bool, error) {
+		cacheKey := redisOrdersCacheKey
 
 		// Redisからキャッシュ取得を試行
 		cachedData, err := c.redisClient.Get(ctx, cacheKey).Result()
@@ -410,7 +592,7 @@ func (c *CacheService) testRedisCache(runs int) error {
 			// キャッシュミス：データベースから取得してキャッシュに保存
 			rows, err := c.db.Query(testQuery)
 			if err != nil {
-				return fmt.Errorf("データベースクエリでエラー: %w", err)
+				return false, fmt.Errorf("データベースクエリでエラー: %w", err)
 			}
 
 			var results []map[string]interface{}
@@ -424,7 +606,7 @@ func (c *CacheService) testRedisCache(runs int) error {
 					if cerr := rows.Close(); cerr != nil {
 						fmt.Printf("rows.Close() failed: %v\n", cerr)
 					}
-					return fmt.Errorf("スキャンエラー: %w", err)
+					return false, fmt.Errorf("スキャンエラー: %w", err)
 				}
 
 				result := map[string]interface{}{
@@ -444,173 +626,158 @@ func (c *CacheService) testRedisCache(runs int) error {
 			// Redisにキャッシュ
 			jsonData, err := json.Marshal(results)
 			if err != nil {
-				return fmt.Errorf("JSON変換エラー: %w", err)
+				return false, fmt.Errorf("JSON変換エラー: %w", err)
 			}
 
 			err = c.redisClient.Set(ctx, cacheKey, jsonData, 5*time.Minute).Err()
 			if err != nil {
-				return fmt.Errorf("redisキャッシュ保存エラー: %w", err)
-			}
-
-			if i == 0 {
-				fmt.Printf("初回実行時間: %v (データベース + キャッシュ保存)\n", time.Since(start))
+				return false, fmt.Errorf("redisキャッシュ保存エラー: %w", err)
 			}
+			return false, nil
 		} else if err != nil {
-			return fmt.Errorf("redisアクセスエラー: %w", err)
-		} else {
-			// キャッシュヒット：Redisからデータを取得
-			var results []map[string]interface{}
-			err := json.Unmarshal([]byte(cachedData), &results)
-			if err != nil {
-				return fmt.Errorf("JSON解析エラー: %w", err)
-			}
-
-			hitCount++
-			if i < 3 {
-				fmt.Printf("%d回目実行時間: %v (キャッシュヒット)\n", i+1, time.Since(start))
-			}
+			return false, fmt.Errorf("redisアクセスエラー: %w", err)
 		}
 
-		duration := time.Since(start)
-		totalDuration += duration
+		// キャッシュヒット：Redisからデータを取得
+		var results []map[string]interface{}
+		if err := json.Unmarshal([]byte(cachedData), &results); err != nil {
+			return false, fmt.Errorf("JSON解析エラー: %w", err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	avgDuration := totalDuration / time.Duration(runs)
-	hitRate := float64(hitCount) / float64(runs) * 100
-
-	c.results = append(c.results, CacheResult{
+	result := CacheResult{
 		Method:        "Redis_External_Cache",
-		ExecutionTime: avgDuration,
-		MemoryUsage:   0, // Redis使用量は別途取得
-		HitRate:       hitRate,
+		ExecutionTime: timings.average(),
+		HitRate:       timings.hitRate(),
 		Description:   "Redis外部キャッシュ（JSONシリアライゼーション）",
-	})
-
-	fmt.Printf("平均実行時間: %v\n", avgDuration)
-	fmt.Printf("キャッシュヒット率: %.1f%%\n", hitRate)
-
-	// Redis使用量の取得
-	if err := c.getRedisMemoryUsage(); err != nil {
-		fmt.Printf("Redis使用量取得でエラー: %v\n", err)
+		RunDurations:  timings.durations,
+		RunHits:       timings.hits,
 	}
 
-	return nil
+	return &result, nil
 }
 
-// DisplayCacheComparison - キャッシュ比較結果を表示
-func (c *CacheService) DisplayCacheComparison() error {
-	if len(c.results) == 0 {
-		return fmt.Errorf("比較結果がありません")
-	}
-
-	fmt.Println("\n=== キャッシュ性能比較結果 ===")
-
-	// 結果を表形式で表示
-	fmt.Printf("%-25s | %-15s | %-10s | %s\n", "キャッシュ方式", "平均実行時間", "ヒット率", "説明")
-	fmt.Println(strings.Repeat("-", 80))
-
-	for _, result := range c.results {
-		hitRateStr := fmt.Sprintf("%.1f%%", result.HitRate)
-		if result.HitRate == 0 {
-			hitRateStr = "N/A"
+// testTwoTierCache - プロセス内のLRUを前に置いた2層キャッシュの性能テスト
+//
+// Redisのキーは外部キャッシュテストと共有するため、1回目はほかのインスタンスが保存した値をRedisから読んで昇格し、2回目以降はローカルの層から返す。
+func (c *CacheService) testTwoTierCache(runs int) (*TwoTierCacheTest, error) {
+	ctx := context.Background()
+	twoTier := newTwoTierCache(redisStore{client: c.redisClient}, c.clock, 5*time.Minute)
+	load := func() ([]byte, error) {
+		rows, err := backend.LoadBenchmarkRows(ctx, c.db)
+		if err != nil {
+			return nil, err
 		}
-
-		fmt.Printf("%-25s | %-15v | %-10s | %s\n",
-			result.Method,
-			result.ExecutionTime,
-			hitRateStr,
-			result.Description)
+		return json.Marshal(rows)
 	}
 
-	// 性能分析
-	c.analyzePerformance()
-
-	return nil
+	var tiers []CacheTier
+	timings, err := timeRuns(c.ctx, c.clock, runs, func(int) (bool, error) {
+		data, tier, err := twoTier.get(ctx, redisOrdersCacheKey, load)
+		if err != nil {
+			return false, err
+		}
+		var rows []backend.OrderDetailRow
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return false, fmt.Errorf("JSON解析エラー: %w", err)
+		}
+		tiers = append(tiers, tier)
+		return tier != TierDatabase, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := twoTier.snapshot()
+	return &TwoTierCacheTest{
+		Result: &CacheResult{
+			Method:        "Redis_TwoTier_Cache",
+			ExecutionTime: timings.average(),
+			MemoryUsage:   stats.LocalBytes,
+			HitRate:       timings.hitRate(),
+			Description:   fmt.Sprintf("2層キャッシュ（ローカルLRU %d件・TTL %v + Redis、JSONシリアライゼーション）", twoTierLocalEntries, twoTierLocalTTL),
+			RunDurations:  timings.durations,
+			RunHits:       timings.hits,
+		},
+		Stats:    stats,
+		RunTiers: tiers,
+	}, nil
 }
 
-// analyzePerformance - 性能分析とOracle内蔵キャッシュの優位性を説明
-func (c *CacheService) analyzePerformance() {
-	fmt.Println("\n=== 性能分析結果 ===")
+// Results - これまでに計測したキャッシュ方式の結果（計測した順）
+func (c *CacheService) Results() []CacheResult {
+	results := make([]CacheResult, len(c.results))
+	copy(results, c.results)
+	return results
+}
 
-	oracleResults := make([]CacheResult, 0)
-	var redisResult *CacheResult
+// CompareCaches - これまでに計測したキャッシュ方式を比較
+func (c *CacheService) CompareCaches() (*CacheComparison, error) {
+	if len(c.results) == 0 {
+		return nil, fmt.Errorf("比較結果がありません")
+	}
 
-	for _, result := range c.results {
+	comparison := &CacheComparison{Results: c.results}
+	for i := range c.results {
+		result := &c.results[i]
 		if strings.HasPrefix(result.Method, "Oracle_") {
-			oracleResults = append(oracleResults, result)
+			// 最速のOracle結果
+			if comparison.FastestOracle == nil || result.ExecutionTime < comparison.FastestOracle.ExecutionTime {
+				comparison.FastestOracle = result
+			}
 		} else if result.Method == "Redis_External_Cache" {
-			redisResult = &result
+			comparison.Redis = result
 		}
 	}
 
-	fmt.Println("\n1. Oracle内蔵キャッシュの優位性:")
-	fmt.Println("   ✓ データの移動が不要（メモリ効率）")
-	fmt.Println("   ✓ シリアライゼーション/デシリアライゼーション不要")
-	fmt.Println("   ✓ ネットワークI/Oなし")
-	fmt.Println("   ✓ 自動的なキャッシュ無効化とデータ整合性")
-	fmt.Println("   ✓ 複数レベルのキャッシュ（Buffer Cache + Result Cache + Function Cache）")
-
-	if redisResult != nil && len(oracleResults) > 0 {
-		fmt.Println("\n2. 外部キャッシュ（Redis）の課題:")
-		fmt.Println("   ✗ ネットワーク通信のオーバーヘッド")
-		fmt.Println("   ✗ JSONシリアライゼーション/デシリアライゼーションのコスト")
-		fmt.Println("   ✗ データ整合性管理の複雑さ")
-		fmt.Println("   ✗ 追加のインフラストラクチャとメンテナンス")
-		fmt.Println("   ✗ メモリの二重使用（Oracle + Redis）")
-
-		// 最速のOracle結果と比較
-		var fastestOracle CacheResult
-		for i, result := range oracleResults {
-			if i == 0 || result.ExecutionTime < fastestOracle.ExecutionTime {
-				fastestOracle = result
-			}
-		}
+	return comparison, nil
+}
 
-		if fastestOracle.ExecutionTime < redisResult.ExecutionTime {
-			improvement := float64(redisResult.ExecutionTime.Nanoseconds()) / float64(fastestOracle.ExecutionTime.Nanoseconds())
-			fmt.Printf("\n3. 性能比較結果:\n")
-			fmt.Printf("   Oracle内蔵キャッシュ(%s): %v\n", fastestOracle.Method, fastestOracle.ExecutionTime)
-			fmt.Printf("   Redis外部キャッシュ: %v\n", redisResult.ExecutionTime)
-			fmt.Printf("   Oracle内蔵キャッシュが%.1fx高速\n", improvement)
-		}
+// MemoryUsage - メモリ使用状況を取得
+func (c *CacheService) MemoryUsage() *MemoryUsage {
+	usage := &MemoryUsage{
+		RedisAvailable: c.redisClient != nil,
+		Results:        c.results,
+		Oracle:         c.oracleMemory,
+		Redis:          c.redisMemory,
+	}
+
+	// Oracle SGA情報の取得
+	sga, err := c.getOracleSGAInfo()
+	if err != nil {
+		usage.SGAError = err.Error()
 	}
+	usage.SGA = sga
 
-	fmt.Println("\n4. 推奨事項:")
-	fmt.Println("   → Oracle環境ではDatabase固有のキャッシュメカニズムを最大限活用する")
-	fmt.Println("   → 外部キャッシュは以下の場合のみ検討:")
-	fmt.Println("     - マイクロサービス間でのデータ共有")
-	fmt.Println("     - 外部APIからの取得データ")
-	fmt.Println("     - Oracleでカバーできない計算集約的な結果")
-	fmt.Println("   → N+1問題はSQL設計の改善で根本的に解決する")
+	return usage
 }
 
-// DisplayMemoryUsageComparison - メモリ使用量比較を表示
-func (c *CacheService) DisplayMemoryUsageComparison() error {
-	fmt.Println("\n=== メモリ使用量分析 ===")
+// collectInstanceCacheStats - Buffer Cache・Result Cacheのインスタンス統計を取得（取得できない項目は理由を記録）
+func (c *CacheService) collectInstanceCacheStats() *InstanceCacheStats {
+	stats := &InstanceCacheStats{}
 
-	// Oracle SGA情報の取得
-	if err := c.getOracleSGAInfo(); err != nil {
-		fmt.Printf("Oracle SGA情報取得でエラー: %v\n", err)
+	if hitRatio, err := c.getBufferCacheStats(); err != nil {
+		stats.Errors = append(stats.Errors, fmt.Sprintf("Buffer Cache統計取得でエラー: %v", err))
+	} else {
+		stats.BufferHitRatio = &hitRatio
 	}
 
-	fmt.Println("\nメモリ効率性:")
-	fmt.Println("Oracle内蔵キャッシュ:")
-	fmt.Println("  ✓ SGAで統合管理されたメモリ使用")
-	fmt.Println("  ✓ 自動的なメモリ最適化とパージ")
-	fmt.Println("  ✓ 複数のアプリケーションで共有")
-
-	if c.redisClient != nil {
-		fmt.Println("\nRedis外部キャッシュ:")
-		fmt.Println("  ✗ 専用メモリプール必要")
-		fmt.Println("  ✗ Oracle + Redis = メモリの二重使用")
-		fmt.Println("  ✗ 手動でのメモリ管理とTuning")
+	if objects, blocks, err := c.getResultCacheStats(); err != nil {
+		stats.Errors = append(stats.Errors, fmt.Sprintf("Result Cache統計は利用できません: %v", err))
+	} else {
+		stats.ResultCacheObjects = &objects
+		stats.ResultCacheBlocks = &blocks
 	}
 
-	return nil
+	return stats
 }
 
 // getBufferCacheStats - Buffer Cache統計を取得
-func (c *CacheService) getBufferCacheStats() error {
+func (c *CacheService) getBufferCacheStats() (float64, error) {
 	query := `
 		SELECT ROUND((1 - (phy.value / (cur.value + con.value))) * 100, 2) as buffer_hit_ratio
 		FROM V$SYSSTAT phy, V$SYSSTAT cur, V$SYSSTAT con
@@ -619,45 +786,36 @@ func (c *CacheService) getBufferCacheStats() error {
 		AND con.name = 'consistent gets from cache'`
 
 	var hitRatio float64
-	err := c.db.QueryRow(query).Scan(&hitRatio)
-	if err != nil {
-		return err
+	if err := c.db.QueryRow(query).Scan(&hitRatio); err != nil {
+		return 0, err
 	}
 
-	fmt.Printf("Database Buffer Cache ヒット率: %.2f%%\n", hitRatio)
-	return nil
+	return hitRatio, nil
 }
 
 // getResultCacheStats - Result Cache統計を取得
-func (c *CacheService) getResultCacheStats() error {
+func (c *CacheService) getResultCacheStats() (objectCount, blockCount int64, err error) {
 	query := `
 		SELECT COUNT(*) as cached_objects,
 		       SUM(block_count) as total_blocks
 		FROM V$RESULT_CACHE_OBJECTS
 		WHERE type = 'Result'`
 
-	var objectCount, blockCount int
-	err := c.db.QueryRow(query).Scan(&objectCount, &blockCount)
-	if err != nil {
-		fmt.Printf("Result Cache統計は利用できません: %v\n", err)
-		return nil
-	}
-
-	fmt.Printf("Result Cache: %d個のオブジェクト, %dブロック使用\n", objectCount, blockCount)
-	return nil
+	err = c.db.QueryRow(query).Scan(&objectCount, &blockCount)
+	return objectCount, blockCount, err
 }
 
 // getOracleSGAInfo - Oracle SGA情報を取得
-func (c *CacheService) getOracleSGAInfo() error {
+func (c *CacheService) getOracleSGAInfo() ([]SGAComponent, error) {
 	query := `
-		SELECT component, current_size/1024/1024 as size_mb
+		SELECT component, current_size
 		FROM V$SGA_DYNAMIC_COMPONENTS
-		WHERE component IN ('DEFAULT buffer_pool', 'Shared Pool', 'Result Cache')
+		WHERE current_size > 0
 		ORDER BY current_size DESC`
 
 	rows, err := c.db.Query(query)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if cerr := rows.Close(); cerr != nil {
@@ -665,42 +823,15 @@ func (c *CacheService) getOracleSGAInfo() error {
 		}
 	}()
 
-	fmt.Println("\nOracle SGA構成:")
+	var components []SGAComponent
 	for rows.Next() {
-		var component string
-		var sizeMB float64
-
-		err := rows.Scan(&component, &sizeMB)
-		if err != nil {
+		var component SGAComponent
+		if err := rows.Scan(&component.Component, &component.Bytes); err != nil {
 			continue
 		}
-
-		fmt.Printf("  %s: %.1f MB\n", component, sizeMB)
+		component.SizeMB = float64(component.Bytes) / (1024 * 1024)
+		components = append(components, component)
 	}
 
-	return nil
-}
-
-// getRedisMemoryUsage - Redis使用量を取得
-func (c *CacheService) getRedisMemoryUsage() error {
-	if c.redisClient == nil {
-		return nil
-	}
-
-	ctx := context.Background()
-	info, err := c.redisClient.Info(ctx, "memory").Result()
-	if err != nil {
-		return err
-	}
-
-	lines := strings.Split(info, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "used_memory_human:") {
-			memUsage := strings.TrimSpace(strings.Split(line, ":")[1])
-			fmt.Printf("Redis使用メモリ: %s\n", memUsage)
-			break
-		}
-	}
-
-	return nil
+	return components, nil
 }
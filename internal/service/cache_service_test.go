@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNewCacheServiceWithRedis(t *testing.T) {
+	c := NewCacheServiceWithRedis(nil, nil)
+	if !errors.Is(c.RedisError(), ErrRedisNotConfigured) {
+		t.Errorf("RedisError() = %v, want ErrRedisNotConfigured", c.RedisError())
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	c = NewCacheServiceWithRedis(nil, client)
+	if c.RedisError() != nil {
+		t.Errorf("RedisError() = %v, want nil", c.RedisError())
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	// 渡したクライアントは呼び出し側が閉じる
+	if err := client.Close(); err != nil {
+		t.Errorf("injected client was closed by CacheService: %v", err)
+	}
+}
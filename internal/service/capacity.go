@@ -0,0 +1,64 @@
+package service
+
+import "oracle-n-plus-1-demo/internal/sessionstats"
+
+// CapacityReport - 想定リクエスト数で必要になるDBリソースの見積もり
+type CapacityReport struct {
+	// RequestsPerSecond - 想定する平均リクエスト数（1リクエスト = 手法の1回の実行）
+	RequestsPerSecond float64            `json:"requests_per_second"`
+	Projections       []CapacityEstimate `json:"projections"`
+}
+
+// CapacityEstimate - 手法ごとに必要なDBリソース（1回の実行の計測値 × リクエスト数）
+type CapacityEstimate struct {
+	Scenario string `json:"scenario"`
+	Method   string `json:"method"`
+	// DBCPUCores - 必要なDBのCPUコア数（CPU秒/秒）
+	DBCPUCores float64 `json:"db_cpu_cores"`
+	// CPUEstimated - CPU時間を計測できず、実行時間を上限として使ったか
+	CPUEstimated bool `json:"cpu_estimated,omitempty"`
+	// RoundTripsPerSecond / ExecutionsPerSecond / EgressBytesPerSecond - 毎秒のラウンドトリップ・SQL実行・転送量
+	// （セッション統計を取得していない場合は0で、StatsUnavailableがtrue）
+	RoundTripsPerSecond  float64 `json:"roundtrips_per_second"`
+	ExecutionsPerSecond  float64 `json:"executions_per_second"`
+	EgressBytesPerSecond float64 `json:"egress_bytes_per_second"`
+	StatsUnavailable     bool    `json:"stats_unavailable,omitempty"`
+}
+
+// SetCapacityTarget - 結果に添付する容量見積もりの想定リクエスト数を設定（0の場合は見積もらない）
+func (s *DemoService) SetCapacityTarget(requestsPerSecond float64) {
+	s.capacityRPS = requestsPerSecond
+}
+
+// CapacityReport - これまでに完了した手法を想定リクエスト数に外挿する（想定リクエスト数が未設定の場合はnil）
+func (s *DemoService) CapacityReport() *CapacityReport {
+	if s.capacityRPS <= 0 {
+		return nil
+	}
+	results := s.ResultsSince(0)
+	projections := make([]CapacityEstimate, len(results))
+	for i, r := range results {
+		projections[i] = r.ProjectCapacity(s.capacityRPS)
+	}
+	return &CapacityReport{RequestsPerSecond: s.capacityRPS, Projections: projections}
+}
+
+// ProjectCapacity - 1回の実行を1リクエストとみなし、毎秒rps回実行した場合に必要なDBリソースを求める
+func (r PerformanceResult) ProjectCapacity(rps float64) CapacityEstimate {
+	usage := r.CostUsage()
+	estimate := CapacityEstimate{
+		Scenario:     r.Scenario,
+		Method:       r.Method,
+		DBCPUCores:   usage.DBCPUSeconds * rps,
+		CPUEstimated: usage.CPUEstimated,
+	}
+
+	if r.SessionStats == nil {
+		estimate.StatsUnavailable = true
+		return estimate
+	}
+	estimate.RoundTripsPerSecond = float64(r.SessionStats[sessionstats.RoundTrips]) * rps
+	estimate.ExecutionsPerSecond = float64(r.SessionStats[sessionstats.ExecuteCount]) * rps
+	estimate.EgressBytesPerSecond = float64(r.SessionStats[sessionstats.BytesSent]) * rps
+	return estimate
+}
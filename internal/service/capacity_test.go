@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+func TestProjectCapacity(t *testing.T) {
+	tests := []struct {
+		name   string
+		result PerformanceResult
+		rps    float64
+		want   CapacityEstimate
+	}{
+		{
+			name: "session stats",
+			result: PerformanceResult{Scenario: "orders", Method: "N+1_Problem", ExecutionTime: time.Second,
+				SessionStats: sessionstats.Stats{
+					sessionstats.CPUUsed:      25,
+					sessionstats.RoundTrips:   101,
+					sessionstats.ExecuteCount: 102,
+					sessionstats.BytesSent:    2048,
+				}},
+			rps: 40,
+			want: CapacityEstimate{Scenario: "orders", Method: "N+1_Problem", DBCPUCores: 10,
+				RoundTripsPerSecond: 4040, ExecutionsPerSecond: 4080, EgressBytesPerSecond: 81920},
+		},
+		{
+			// セッション統計がなければCPU時間は実行時間から推定し、ラウンドトリップなどは求めない
+			name:   "stats unavailable",
+			result: PerformanceResult{Scenario: "orders", Method: "JOIN_Optimized", ExecutionTime: 250 * time.Millisecond, PayloadBytes: 4096},
+			rps:    4,
+			want:   CapacityEstimate{Scenario: "orders", Method: "JOIN_Optimized", DBCPUCores: 1, CPUEstimated: true, StatsUnavailable: true},
+		},
+		{
+			// CPU時間を取得できなくても、取得できたセッション統計は外挿する
+			name: "cpu estimated with stats",
+			result: PerformanceResult{Method: "N+1_Problem", ExecutionTime: 500 * time.Millisecond,
+				SessionStats: sessionstats.Stats{sessionstats.RoundTrips: 3}},
+			rps:  2,
+			want: CapacityEstimate{Method: "N+1_Problem", DBCPUCores: 1, CPUEstimated: true, RoundTripsPerSecond: 6},
+		},
+		{
+			name:   "zero rps",
+			result: PerformanceResult{Method: "N+1_Problem", ExecutionTime: time.Second, SessionStats: sessionstats.Stats{sessionstats.CPUUsed: 50, sessionstats.RoundTrips: 10}},
+			rps:    0,
+			want:   CapacityEstimate{Method: "N+1_Problem"},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.result.ProjectCapacity(tt.rps); got != tt.want {
+			t.Errorf("%s: ProjectCapacity(%g) = %+v, want %+v", tt.name, tt.rps, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+// DefaultConcurrencyRounds - 同時実行の計測で、1つのゴルーチンあたりに手法を実行する回数
+const DefaultConcurrencyRounds = 5
+
+// ConcurrencyResult - 手法を複数のゴルーチンから同時に実行したときのレイテンシとスループット
+type ConcurrencyResult struct {
+	// Workers - 同時に実行したゴルーチンの数
+	Workers int `json:"workers"`
+	// Requests - 手法を実行した回数の合計（Workers × 1つあたりの回数）
+	Requests int   `json:"requests"`
+	Errors   int64 `json:"errors"`
+	// Duration - 最初の実行の開始から最後の実行の終了まで
+	Duration   time.Duration `json:"duration"`
+	Throughput float64       `json:"throughput_rps"`
+	Mean       time.Duration `json:"mean"`
+	P50        time.Duration `json:"p50"`
+	P95        time.Duration `json:"p95"`
+	P99        time.Duration `json:"p99"`
+	Max        time.Duration `json:"max"`
+	// PoolWaits / PoolWaitTime - 接続プールの空きを待った回数と時間の合計（sql.DBStatsの差分）
+	PoolWaits    int64         `json:"pool_waits"`
+	PoolWaitTime time.Duration `json:"pool_wait_time"`
+}
+
+// SetConcurrency - 各手法の計測の後に、workers個のゴルーチンからrounds回ずつ同時に実行してレイテンシの分布を測る
+//
+// workersが1以下なら同時実行の計測をしない。接続プールの上限（config.MaxOpenConns）を超える分は空きを待つため、
+// 接続を長く占有する手法ほどプールの待ちが増える。Forkしたサービスには引き継がない。
+func (s *DemoService) SetConcurrency(workers, rounds int) {
+	s.concurrency = workers
+	s.concurrencyRounds = max(1, rounds)
+}
+
+// measureConcurrency - 手法をs.concurrency個のゴルーチンから同時に実行し、結果に添える
+func (s *DemoService) measureConcurrency(st strategy, result *PerformanceResult) error {
+	if s.concurrency <= 1 {
+		return nil
+	}
+	fmt.Printf("   %d並列で%d回ずつ実行中...\n", s.concurrency, s.concurrencyRounds)
+
+	// JSON生成の計測は1回の実行の結果をサービスに保存するため、同時実行の間は止める
+	payloadTiming := s.payloadTiming
+	s.payloadTiming = false
+	defer func() { s.payloadTiming = payloadTiming }()
+
+	requests := s.concurrency * s.concurrencyRounds
+	latencies := make([]time.Duration, requests)
+	var next, errCount atomic.Int64
+	var firstErr error
+	var once sync.Once
+	var wg sync.WaitGroup
+
+	poolBefore := s.db.Stats()
+	start := s.clock.Now()
+	for w := 0; w < s.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= requests || s.ctx.Err() != nil {
+					return
+				}
+				reqStart := s.clock.Now()
+				_, err := st.run()
+				latencies[i] = s.clock.Since(reqStart)
+				if err != nil {
+					errCount.Add(1)
+					once.Do(func() { firstErr = err })
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := s.clock.Since(start)
+	poolAfter := s.db.Stats()
+	if err := stopped(s.ctx); err != nil {
+		return err
+	}
+
+	c := summarizeConcurrency(s.concurrency, latencies, elapsed)
+	c.Errors = errCount.Load()
+	c.PoolWaits = poolAfter.WaitCount - poolBefore.WaitCount
+	c.PoolWaitTime = poolAfter.WaitDuration - poolBefore.WaitDuration
+	result.Concurrency = &c
+
+	fmt.Printf("   %d並列: p50 %v, p95 %v, p99 %v, スループット %.1f回/秒, 接続プールの待ち %d回（%v）\n",
+		c.Workers, c.P50, c.P95, c.P99, c.Throughput, c.PoolWaits, c.PoolWaitTime)
+	if firstErr != nil {
+		fmt.Printf("   同時実行中のエラー: %d件（最初のエラー: %v）\n", c.Errors, firstErr)
+	}
+	return nil
+}
+
+// summarizeConcurrency - 実行ごとのレイテンシと全体の経過時間から分布とスループットを求める
+func summarizeConcurrency(workers int, latencies []time.Duration, elapsed time.Duration) ConcurrencyResult {
+	c := ConcurrencyResult{Workers: workers, Requests: len(latencies), Duration: elapsed}
+	if len(latencies) == 0 {
+		return c
+	}
+	if elapsed > 0 {
+		c.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+		c.Max = max(c.Max, l)
+	}
+	c.Mean = total / time.Duration(len(latencies))
+	c.P50 = stats.PercentileDuration(latencies, 50)
+	c.P95 = stats.PercentileDuration(latencies, 95)
+	c.P99 = stats.PercentileDuration(latencies, 99)
+	return c
+}
+
+// displayConcurrency - 手法ごとの同時実行のレイテンシの分布とスループットを表示
+func displayConcurrency(results []PerformanceResult) {
+	if len(results) == 0 || results[0].Concurrency == nil {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading(fmt.Sprintf("同時実行（%d並列）", results[0].Concurrency.Workers))
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "single", Header: "単独", Align: report.AlignRight},
+		report.Column{Key: "p50", Header: "p50", Align: report.AlignRight},
+		report.Column{Key: "p95", Header: "p95", Align: report.AlignRight},
+		report.Column{Key: "p99", Header: "p99", Align: report.AlignRight},
+		report.Column{Key: "throughput", Header: "回/秒", Align: report.AlignRight},
+		report.Column{Key: "pool_waits", Header: "プール待ち", Align: report.AlignRight},
+		report.Column{Key: "errors", Header: "エラー", Align: report.AlignRight},
+	)
+	for _, result := range results {
+		c := result.Concurrency
+		if c == nil {
+			continue
+		}
+		table.AddRow(
+			report.Text(result.Method),
+			report.Duration(result.ExecutionTime),
+			report.Duration(c.P50),
+			report.Duration(c.P95),
+			report.Duration(c.P99),
+			report.Float("%.1f", c.Throughput),
+			report.Int(c.PoolWaits),
+			report.Int(c.Errors))
+	}
+	w.Table(table)
+	w.Line("単独は1つずつ実行したときの実行時間。文の数が多い手法ほど、同時実行では接続プールとDBの待ちが重なってp95・p99が伸びる")
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeConcurrency(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		// 1ms〜100msを逆順に並べる（並び順に依存しないこと）
+		latencies[i] = time.Duration(100-i) * time.Millisecond
+	}
+
+	got := summarizeConcurrency(4, latencies, 2*time.Second)
+	want := ConcurrencyResult{
+		Workers:    4,
+		Requests:   100,
+		Duration:   2 * time.Second,
+		Throughput: 50,
+		Mean:       50500 * time.Microsecond,
+		P50:        50 * time.Millisecond,
+		P95:        95 * time.Millisecond,
+		P99:        99 * time.Millisecond,
+		Max:        100 * time.Millisecond,
+	}
+	if got != want {
+		t.Errorf("summarizeConcurrency() = %+v, want %+v", got, want)
+	}
+
+	if got := summarizeConcurrency(4, nil, 0); got != (ConcurrencyResult{Workers: 4}) {
+		t.Errorf("summarizeConcurrency() without requests = %+v", got)
+	}
+}
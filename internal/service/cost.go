@@ -0,0 +1,97 @@
+package service
+
+import (
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/costmodel"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+// centisecondsPerSecond - V$MYSTATの「CPU used by this session」の単位（センチ秒）
+const centisecondsPerSecond = 100
+
+// CostReport - 単価モデルと手法ごとの月額コストの見積もり
+type CostReport struct {
+	Model     costmodel.Model      `json:"model"`
+	Estimates []costmodel.Estimate `json:"estimates"`
+}
+
+// SetCostModel - 結果に月額コストの見積もりを添付する単価モデルを設定（nilの場合は見積もらない）
+func (s *DemoService) SetCostModel(model *costmodel.Model) {
+	s.costModel = model
+}
+
+// CostReport - これまでに完了した手法の月額コストを見積もる（単価モデル未設定の場合はnil）
+func (s *DemoService) CostReport() *CostReport {
+	if s.costModel == nil {
+		return nil
+	}
+	results := s.ResultsSince(0)
+	usages := make([]costmodel.Usage, len(results))
+	for i, r := range results {
+		usages[i] = r.CostUsage()
+	}
+	return &CostReport{Model: *s.costModel, Estimates: s.costModel.EstimateAll(usages)}
+}
+
+// CostUsage - 1回の実行を1リクエストとみなしたリソース使用量
+//
+// DBのCPU時間はセッション統計（CPU used by this session）を使い、取得していない場合は
+// 実行時間（-payload 指定時はDB時間）をCPU時間の上限として使う。
+// 転送量はセッション統計の送信バイト数、なければJSONレスポンスのサイズで代用する。
+func (r PerformanceResult) CostUsage() costmodel.Usage {
+	usage := costmodel.Usage{Scenario: r.Scenario, Method: r.Method}
+
+	if cpu, ok := r.SessionStats[sessionstats.CPUUsed]; ok {
+		usage.DBCPUSeconds = float64(cpu) / centisecondsPerSecond
+	} else {
+		elapsed := r.ExecutionTime
+		if r.DBTime > 0 {
+			elapsed = r.DBTime
+		}
+		usage.DBCPUSeconds = elapsed.Seconds()
+		usage.CPUEstimated = true
+	}
+
+	switch sent, ok := r.SessionStats[sessionstats.BytesSent]; {
+	case ok:
+		usage.EgressBytes = sent
+	case r.PayloadBytes > 0:
+		usage.EgressBytes = int64(r.PayloadBytes)
+	default:
+		usage.EgressUnknown = true
+	}
+	return usage
+}
+
+// SetCostModel - キャッシュ比較に月額コストの見積もりを添付する単価モデルを設定（nilの場合は見積もらない）
+func (c *CacheService) SetCostModel(model *costmodel.Model) {
+	c.costModel = model
+}
+
+// CostReport - キャッシュ手法ごとの月額コストを見積もる（単価モデル未設定または結果がない場合はnil）
+//
+// 1回の取得を1リクエストとみなし、実行時間をDBのCPU時間の上限として使う。
+// 外部キャッシュ（Redis・独自のキャッシュ実装）はヒットした取得ではDBを使わないため、ミス率の分だけDBのCPU時間を計上し、
+// Redisにはインスタンスの固定費を加える。キャッシュ手法の転送量は計測していない。
+func (c *CacheService) CostReport() *CostReport {
+	if c.costModel == nil || len(c.results) == 0 {
+		return nil
+	}
+
+	usages := make([]costmodel.Usage, len(c.results))
+	for i, r := range c.results {
+		usage := costmodel.Usage{
+			Method:        r.Method,
+			DBCPUSeconds:  r.ExecutionTime.Seconds(),
+			CPUEstimated:  true,
+			EgressUnknown: true,
+		}
+		if !strings.HasPrefix(r.Method, "Oracle_") {
+			usage.DBCPUSeconds *= 1 - r.HitRate/100
+			usage.UsesRedis = strings.HasPrefix(r.Method, "Redis_")
+		}
+		usages[i] = usage
+	}
+	return &CostReport{Model: *c.costModel, Estimates: c.costModel.EstimateAll(usages)}
+}
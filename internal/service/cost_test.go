@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/costmodel"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+func TestCostUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		result PerformanceResult
+		want   costmodel.Usage
+	}{
+		{
+			name: "session stats",
+			result: PerformanceResult{Scenario: "orders", Method: "N+1_Problem", ExecutionTime: 300 * time.Millisecond, PayloadBytes: 100,
+				SessionStats: sessionstats.Stats{sessionstats.CPUUsed: 150, sessionstats.BytesSent: 5000}},
+			want: costmodel.Usage{Scenario: "orders", Method: "N+1_Problem", DBCPUSeconds: 1.5, EgressBytes: 5000},
+		},
+		{
+			// セッション統計がなければ実行時間をCPU時間の上限として使う
+			name:   "estimated from execution time",
+			result: PerformanceResult{Method: "JOIN_Optimized", ExecutionTime: 200 * time.Millisecond, PayloadBytes: 300},
+			want:   costmodel.Usage{Method: "JOIN_Optimized", DBCPUSeconds: 0.2, CPUEstimated: true, EgressBytes: 300},
+		},
+		{
+			// -payload 指定時はJSON生成を除いたDB時間を使う
+			name:   "estimated from db time",
+			result: PerformanceResult{Method: "JOIN_Optimized", ExecutionTime: 200 * time.Millisecond, DBTime: 50 * time.Millisecond, PayloadBytes: 300},
+			want:   costmodel.Usage{Method: "JOIN_Optimized", DBCPUSeconds: 0.05, CPUEstimated: true, EgressBytes: 300},
+		},
+		{
+			name:   "unknown egress",
+			result: PerformanceResult{Method: "N+1_Problem", ExecutionTime: 100 * time.Millisecond},
+			want:   costmodel.Usage{Method: "N+1_Problem", DBCPUSeconds: 0.1, CPUEstimated: true, EgressUnknown: true},
+		},
+		{
+			// CPU時間だけ取得できた場合は転送量のみ不明
+			name: "cpu without bytes sent",
+			result: PerformanceResult{Method: "N+1_Problem", ExecutionTime: 100 * time.Millisecond,
+				SessionStats: sessionstats.Stats{sessionstats.CPUUsed: 0}},
+			want: costmodel.Usage{Method: "N+1_Problem", EgressUnknown: true},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.result.CostUsage(); got != tt.want {
+			t.Errorf("%s: CostUsage() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
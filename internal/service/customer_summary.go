@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/models"
+	"oracle-n-plus-1-demo/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SummaryStrategy - 顧客サマリーの取得手法
+type SummaryStrategy string
+
+const (
+	// SummaryNPlusOne - 受注ごとに明細を取得してアプリ側で集計（N+1）
+	SummaryNPlusOne SummaryStrategy = "n1"
+	// SummaryAggregate - 1回の集計SQLで取得
+	SummaryAggregate SummaryStrategy = "sql"
+	// SummaryCached - 集計SQLの結果をRedisにキャッシュ（キャッシュアサイド）
+	SummaryCached SummaryStrategy = "cached"
+)
+
+// SummaryStrategies - 比較対象の全手法
+var SummaryStrategies = []SummaryStrategy{SummaryNPlusOne, SummaryAggregate, SummaryCached}
+
+// DefaultSummaryTTL - 顧客サマリーのキャッシュ有効期限
+const DefaultSummaryTTL = 60 * time.Second
+
+// ErrUnknownStrategy - 未知の取得手法が指定された場合のエラー
+var ErrUnknownStrategy = errors.New("unknown summary strategy")
+
+// ErrRedisUnavailable - Redisが利用できない場合のエラー
+var ErrRedisUnavailable = errors.New("redis is not available")
+
+// ParseSummaryStrategy - 文字列から取得手法を解析（空文字はsql）
+func ParseSummaryStrategy(value string) (SummaryStrategy, error) {
+	if value == "" {
+		return SummaryAggregate, nil
+	}
+	for _, st := range SummaryStrategies {
+		if string(st) == value {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownStrategy, value)
+}
+
+// SummaryLookup - 1回の取得結果
+type SummaryLookup struct {
+	Summary  *models.CustomerSummary
+	Strategy SummaryStrategy
+	// CacheHit - cached手法でRedisから取得できたか
+	CacheHit bool
+}
+
+// CustomerSummaryService - 顧客サマリーを3通りの手法で提供するサービス
+type CustomerSummaryService struct {
+	problemRepo   *repository.ProblemOrderRepository
+	optimizedRepo *repository.OptimizedOrderRepository
+	redisClient   *redis.Client
+	ttl           time.Duration
+}
+
+// NewCustomerSummaryService - 顧客サマリーサービスのコンストラクタ（redisClientはnil可）
+func NewCustomerSummaryService(db *sql.DB, redisClient *redis.Client, ttl time.Duration) *CustomerSummaryService {
+	if ttl <= 0 {
+		ttl = DefaultSummaryTTL
+	}
+	return &CustomerSummaryService{
+		problemRepo:   repository.NewProblemOrderRepository(db),
+		optimizedRepo: repository.NewOptimizedOrderRepository(db),
+		redisClient:   redisClient,
+		ttl:           ttl,
+	}
+}
+
+// RedisAvailable - cached手法が利用可能か
+func (s *CustomerSummaryService) RedisAvailable() bool {
+	return s.redisClient != nil
+}
+
+// GetSummary - 指定した手法で顧客サマリーを取得（顧客が存在しない場合はSummaryがnil）
+func (s *CustomerSummaryService) GetSummary(ctx context.Context, customerID int64, strategy SummaryStrategy) (*SummaryLookup, error) {
+	lookup := &SummaryLookup{Strategy: strategy}
+
+	var err error
+	switch strategy {
+	case SummaryNPlusOne:
+		lookup.Summary, err = s.problemRepo.GetCustomerSummary(customerID)
+	case SummaryAggregate:
+		lookup.Summary, err = s.optimizedRepo.GetCustomerSummary(customerID)
+	case SummaryCached:
+		lookup.Summary, lookup.CacheHit, err = s.getCachedSummary(ctx, customerID)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return lookup, nil
+}
+
+// InvalidateSummary - 顧客サマリーのキャッシュを削除（受注更新時に呼び出す想定）
+func (s *CustomerSummaryService) InvalidateSummary(ctx context.Context, customerID int64) error {
+	if s.redisClient == nil {
+		return nil
+	}
+	return s.redisClient.Del(ctx, summaryCacheKey(customerID)).Err()
+}
+
+// getCachedSummary - キャッシュアサイド: Redisになければ集計SQLで取得して保存
+func (s *CustomerSummaryService) getCachedSummary(ctx context.Context, customerID int64) (*models.CustomerSummary, bool, error) {
+	if s.redisClient == nil {
+		return nil, false, ErrRedisUnavailable
+	}
+
+	key := summaryCacheKey(customerID)
+	cached, err := s.redisClient.Get(ctx, key).Result()
+	switch {
+	case err == nil:
+		var summary models.CustomerSummary
+		if err := json.Unmarshal([]byte(cached), &summary); err == nil {
+			return &summary, true, nil
+		}
+		// 壊れたキャッシュは無視してDBから取り直す
+	case !errors.Is(err, redis.Nil):
+		return nil, false, fmt.Errorf("failed to get cached summary: %w", err)
+	}
+
+	summary, err := s.optimizedRepo.GetCustomerSummary(customerID)
+	if err != nil {
+		return nil, false, err
+	}
+	// 存在しない顧客はキャッシュしない
+	if summary == nil {
+		return nil, false, nil
+	}
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	if err := s.redisClient.Set(ctx, key, data, s.ttl).Err(); err != nil {
+		// キャッシュへの保存失敗はレスポンスに影響させない
+		fmt.Printf("顧客サマリーのキャッシュ保存に失敗しました: %v\n", err)
+	}
+
+	return summary, false, nil
+}
+
+// summaryCacheKey - 顧客サマリーのキャッシュキー
+func summaryCacheKey(customerID int64) string {
+	return fmt.Sprintf("customer_summary:%d", customerID)
+}
@@ -1,19 +1,109 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"runtime"
+	"sync"
 	"time"
 
+	"oracle-n-plus-1-demo/internal/chrometrace"
+	"oracle-n-plus-1-demo/internal/clock"
+	"oracle-n-plus-1-demo/internal/costmodel"
+	"oracle-n-plus-1-demo/internal/explain"
+	"oracle-n-plus-1-demo/internal/querylog"
+	"oracle-n-plus-1-demo/internal/rac"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/resultcache"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+	"oracle-n-plus-1-demo/internal/sharedpool"
+	"oracle-n-plus-1-demo/internal/stmtcache"
+	"oracle-n-plus-1-demo/models"
 	"oracle-n-plus-1-demo/repository"
 )
 
+// シナリオ識別子（エクスポートした結果の集計・比較で使う）
+const (
+	ScenarioOrders           = "orders"
+	ScenarioEmployees        = "employees"
+	ScenarioEmployeeProjects = "employee_projects"
+	ScenarioMonthlySales     = "monthly_sales"
+	ScenarioTopCustomers     = "top_customers"
+	ScenarioWindowFunctions  = "window_functions"
+	ScenarioLOB              = "lob"
+	ScenarioCompositeFetch   = "composite_fetch"
+	ScenarioColumnPruning    = "column_pruning"
+	ScenarioPagination       = "pagination"
+	ScenarioSoftDelete       = "soft_delete"
+	ScenarioSharedPool       = "shared_pool"
+)
+
 // PerformanceResult - パフォーマンス測定結果
 type PerformanceResult struct {
-	Method        string        `json:"method"`
-	ExecutionTime time.Duration `json:"execution_time"`
-	RecordCount   int           `json:"record_count"`
-	Description   string        `json:"description"`
+	Scenario        string        `json:"scenario"`
+	Method          string        `json:"method"`
+	ExecutionTime   time.Duration `json:"execution_time"`
+	RecordCount     int           `json:"record_count"`
+	Description     string        `json:"description"`
+	StmtCacheHits   int64         `json:"stmt_cache_hits,omitempty"`
+	StmtCacheMisses int64         `json:"stmt_cache_misses,omitempty"`
+	AllocBytes      uint64        `json:"alloc_bytes"`
+	Allocs          uint64        `json:"allocs"`
+	// DBTime / EncodeTime / PayloadBytes - JSONレスポンス生成までを計測した場合の内訳（-payload 指定時のみ）
+	DBTime       time.Duration `json:"db_time,omitempty"`
+	EncodeTime   time.Duration `json:"encode_time,omitempty"`
+	PayloadBytes int           `json:"payload_bytes,omitempty"`
+	// SharedPool - 共有プール関連統計（インスタンス全体）の差分（共有プール負荷シナリオのみ）
+	SharedPool *sharedpool.Delta `json:"shared_pool,omitempty"`
+	// SessionStats - 手法実行中のセッション統計の差分（-session-stats 指定時または対象シナリオのみ）
+	SessionStats sessionstats.Stats `json:"session_stats,omitempty"`
+	// RAC - 計測したセッションの接続先インスタンスとgc待機（-rac 指定時のみ）
+	RAC *rac.Measurement `json:"rac,omitempty"`
+	// Repetition - 全体実行を繰り返した場合の何回目か（1始まり、繰り返さない場合は0）
+	Repetition int `json:"repetition,omitempty"`
+	// Position - シナリオ内で何番目に実行したか（1始まり）
+	Position int `json:"position"`
+	// Baseline - シナリオの改善率の基準にした手法（最初の手法、通常はN+1の手法）の結果か
+	Baseline bool `json:"baseline,omitempty"`
+	// ScenarioPosition - 繰り返しの中でシナリオを何番目に実行したか（1始まり、全体実行以外は0）
+	ScenarioPosition int `json:"scenario_position,omitempty"`
+	// Samples - 複数回計測した各回の実行時間（-iterations 指定時。ExecutionTimeはその中央値）
+	Samples []time.Duration `json:"samples,omitempty"`
+	// Paired - 交互実行で基準の手法と組にした差（-interleave 指定時、基準以外の手法のみ）
+	Paired *PairedDifference `json:"paired,omitempty"`
+	// Significance - 基準の手法に対する有意差検定（-iterations=2 以上、基準以外の手法のみ）
+	Significance *Significance `json:"significance,omitempty"`
+	// Warmup - シナリオの計測前に行ったウォームアップ（-warmup 指定時のみ）
+	Warmup WarmupMode `json:"warmup,omitempty"`
+	// Workload - セッション統計から求めたCPU・論理読み取り・物理読み取りのどれが主体か（セッション統計を取得した場合のみ）
+	Workload WorkloadClass `json:"workload_class,omitempty"`
+	// Queries - 手法の実行中に発行したSQLの数（並列実行では記録しない）
+	Queries *QueryCounts `json:"queries,omitempty"`
+	// Plans / PlanError - 手法の実行中に流れた問い合わせの実行計画と、取得できなかった理由（-explain 指定時のみ）
+	Plans     []explain.Plan `json:"plans,omitempty"`
+	PlanError string         `json:"plan_error,omitempty"`
+	// ActualPlans / ActualPlanError - 最後の実行の推定と実際の行数と、取得できなかった理由（-plan-stats 指定時のみ）
+	ActualPlans     []explain.ActualPlan `json:"actual_plans,omitempty"`
+	ActualPlanError string               `json:"actual_plan_error,omitempty"`
+	// GoResultCache - プロセス内の結果キャッシュのヒット・ミス（Go_Result_Cacheのみ）
+	GoResultCache *resultcache.Stats `json:"go_result_cache,omitempty"`
+	// Concurrency - 複数のゴルーチンから同時に実行したときのレイテンシとスループット（-concurrency 指定時のみ）
+	Concurrency *ConcurrencyResult `json:"concurrency,omitempty"`
+}
+
+// strategy - 比較対象の取得手法
+type strategy struct {
+	method      string
+	label       string
+	description string
+	run         func() (int, error)
+	// setup - 計測前の準備（任意、実行時間には含めない）
+	setup func() error
+	// after - 計測後に結果へ追加情報を付与する（任意）
+	after func(result *PerformanceResult)
+	// sessionStats - EnableSessionStatsの設定にかかわらずセッション統計を取得する
+	sessionStats bool
 }
 
 // DemoService - N+1問題のデモンストレーション用サービス
@@ -23,81 +113,340 @@ type DemoService struct {
 	problemEmpRepo   *repository.ProblemEmployeeRepository
 	optimizedRepo    *repository.OptimizedOrderRepository
 	optimizedEmpRepo *repository.OptimizedEmployeeRepository
+	stmtCache        *stmtcache.Cache
+
+	// conn - Fork(IsolationSession)で確保した専用の接続（nilなら接続プールを使う）
+	conn *sql.Conn
+
+	sessionStats            bool
+	sessionStatsUnavailable bool
+
+	// rac - 手法ごとに接続先インスタンスとgc待機を記録する（racPinsはシナリオごとの固定先の接続プール）
+	rac                 bool
+	racPins             map[string]*sql.DB
+	racWaitsUnavailable bool
+
+	payloadTiming bool
+	lastPayload   payloadMeasurement
+
+	reset ResetPolicy
+	// warmup - シナリオの最初の計測の前に行うウォームアップ
+	warmup WarmupPolicy
+
+	// costModel - 結果に添付する月額コストの見積もりの単価モデル（nilなら見積もらない）
+	costModel *costmodel.Model
+	// capacityRPS - 結果に添付する容量見積もりの想定リクエスト数（0なら見積もらない）
+	capacityRPS float64
+
+	// limits - すべての手法に同じ条件で適用する受注・社員の件数の上限
+	limits repository.Limits
+	// flashbackSCN - -as-of で接続を固定したSCN（0なら固定しない）
+	flashbackSCN uint64
+
+	// clock - 実行時間の計測に使う時計
+	clock clock.Clock
+	// trace - 手法の実行区間を記録する先（-trace、nilなら記録しない）
+	trace *chrometrace.Recorder
+	// ctx - 取り消されると新しい計測を始めず、固定した接続で実行中のSQLを中断する
+	ctx context.Context
+
+	shuffler         *Shuffler
+	iterations       int
+	interleave       bool
+	repetition       int
+	scenarioPosition int
+
+	// queryCounter - 接続を包んで発行したSQLを数えるRecorder（nilなら数えない）
+	queryCounter *querylog.Counter
+	// planCapture / explainer - 手法ごとに流れた問い合わせを集めて実行計画を取得する（nilなら取得しない）
+	planCapture *explain.Capture
+	explainer   *explain.Explainer
+	// cursorStats - GATHER_PLAN_STATISTICSで実行した問い合わせの実績を取得する（nilなら取得しない）
+	cursorStats *explain.CursorStats
+	// goResultCache / goResultCacheRepo - プロセス内の結果キャッシュと、それで包んだ接続プールのリポジトリ（nilなら比較しない）
+	goResultCache     *resultcache.Cache
+	goResultCacheRepo *repository.OptimizedOrderRepository
+
+	// concurrency / concurrencyRounds - 計測の後に手法を同時に実行するゴルーチンの数と、1つあたりの実行回数
+	concurrency       int
+	concurrencyRounds int
+
+	// history - これまでに完了した手法の結果（中断時の途中経過レポート用）
+	historyMu sync.Mutex
+	history   []PerformanceResult
 }
 
 // NewDemoService - デモサービスのコンストラクタ
 func NewDemoService(db *sql.DB) *DemoService {
-	return &DemoService{
-		db:               db,
-		problemRepo:      repository.NewProblemOrderRepository(db),
-		problemEmpRepo:   repository.NewProblemEmployeeRepository(db),
-		optimizedRepo:    repository.NewOptimizedOrderRepository(db),
-		optimizedEmpRepo: repository.NewOptimizedEmployeeRepository(db),
+	s := &DemoService{
+		db:        db,
+		stmtCache: stmtcache.New(db),
+		clock:     clock.System,
+		ctx:       context.Background(),
+	}
+	s.bindRepositories(db)
+	return s
+}
+
+// SetClock - 実行時間の計測に使う時計を差し替える（Forkしたサービスにも引き継ぐ）
+func (s *DemoService) SetClock(clk clock.Clock) {
+	s.clock = clk
+}
+
+// Close - サービスが保持するリソース（キャッシュ済みステートメント・専用の接続）を解放
+func (s *DemoService) Close() error {
+	err := s.stmtCache.Close()
+	if s.conn != nil {
+		if cerr := s.conn.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close dedicated connection: %w", cerr)
+		}
 	}
+	return err
 }
 
 // CompareOrderPerformance - 受注データの取得パフォーマンスを比較
 func (s *DemoService) CompareOrderPerformance(days int) ([]PerformanceResult, error) {
-	var results []PerformanceResult
-
 	fmt.Printf("=== 受注データ取得パフォーマンス比較（過去%d日間） ===\n\n", days)
 
-	// 1. N+1問題のあるアプローチ
-	fmt.Println("1. N+1問題のあるアプローチを実行中...")
-	start := time.Now()
+	results, err := s.runStrategies(ScenarioOrders, s.orderStrategies(days))
+	if err != nil {
+		return nil, err
+	}
 
-	problemOrders, err := s.problemRepo.GetOrdersWithDetails(days)
+	// パフォーマンス改善率を計算して表示
+	s.displayPerformanceComparison(results)
+
+	return results, nil
+}
+
+// orderStrategies - 受注データ取得の比較対象の手法
+func (s *DemoService) orderStrategies(days int) []strategy {
+	return []strategy{
+		{
+			method:      "N+1_Problem",
+			label:       "N+1問題のあるアプローチ",
+			description: "N+1問題のあるアプローチ（ループ内でDBアクセス）",
+			run:         func() (int, error) { return s.encodeResponse(s.problemRepo.GetOrdersWithDetails(days)) },
+		},
+		{
+			method:      "JOIN_Optimized",
+			label:       "JOIN使用の最適化アプローチ",
+			description: "JOIN使用の最適化アプローチ（一括取得）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithDetailsJoin(days)) },
+		},
+		{
+			method:      "Batch_Optimized",
+			label:       "IN句使用のバッチ取得アプローチ",
+			description: "IN句使用のバッチ取得アプローチ",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithDetailsBatch(days)) },
+		},
+		{
+			method:      "Batch_SCN_Consistent",
+			label:       "同一SCNのバッチ取得アプローチ",
+			description: "IN句使用のバッチ取得（受注と明細の2クエリをAS OF SCNで同じ時点に固定）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithDetailsBatchSCN(days)) },
+		},
+		{
+			method:      "N+1_PrepareInLoop",
+			label:       "ループ内Prepareのアプローチ",
+			description: "N+1をループ内で毎回Prepare（パース負荷が上乗せ）",
+			run:         func() (int, error) { return s.encodeResponse(s.problemRepo.GetOrdersWithDetailsPrepareInLoop(days)) },
+		},
+		{
+			method:      "N+1_StmtCache",
+			label:       "ステートメントキャッシュ付きN+1アプローチ",
+			description: "N+1 + アプリ側ステートメントキャッシュ（Prepareは1回、実行はN回）",
+			run: func() (int, error) {
+				s.stmtCache.ResetMetrics()
+				return s.encodeResponse(s.problemRepo.GetOrdersWithDetailsCachedStmt(days, s.stmtCache))
+			},
+			after: s.attachStmtCacheMetrics,
+		},
+	}
+}
+
+// CompareEmployeePerformance - 社員データの取得パフォーマンスを比較
+func (s *DemoService) CompareEmployeePerformance() ([]PerformanceResult, error) {
+	fmt.Println("\n=== 社員データ取得パフォーマンス比較 ===")
+	fmt.Println("段階的な改善: N+1 → メモ化 → バッチ取得 → JOIN")
+
+	results, err := s.runStrategies(ScenarioEmployees, s.employeeStrategies())
 	if err != nil {
-		return nil, fmt.Errorf("N+1問題のあるアプローチでエラー: %w", err)
+		return nil, err
 	}
 
-	problemDuration := time.Since(start)
-	results = append(results, PerformanceResult{
-		Method:        "N+1_Problem",
-		ExecutionTime: problemDuration,
-		RecordCount:   len(problemOrders),
-		Description:   "N+1問題のあるアプローチ（ループ内でDBアクセス）",
-	})
+	// パフォーマンス改善率を計算して表示
+	s.displayPerformanceComparison(results)
+
+	return results, nil
+}
 
-	fmt.Printf("   実行時間: %v, 取得件数: %d件\n", problemDuration, len(problemOrders))
+// employeeStrategies - 社員データ取得の比較対象の手法（段階的な改善の順）
+func (s *DemoService) employeeStrategies() []strategy {
+	return []strategy{
+		{
+			method:      "N+1_Problem",
+			label:       "N+1問題のあるアプローチ",
+			description: "N+1問題のあるアプローチ（ループ内でDBアクセス）",
+			run:         func() (int, error) { return s.encodeResponse(s.problemEmpRepo.GetEmployeesWithDepartment()) },
+		},
+		{
+			method:      "Memoized_Partial",
+			label:       "メモ化による部分的な改善アプローチ",
+			description: "ループ内DBアクセス + 部署のリクエスト内メモ化（部分的な改善）",
+			run:         func() (int, error) { return s.encodeResponse(s.problemEmpRepo.GetEmployeesWithDepartmentMemoized()) },
+		},
+		{
+			method:      "Batch_Optimized",
+			label:       "バッチ取得アプローチ",
+			description: "バッチ取得アプローチ",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedEmpRepo.GetEmployeesWithDepartmentBatch()) },
+		},
+		{
+			method:      "JOIN_Optimized",
+			label:       "JOIN使用の最適化アプローチ",
+			description: "JOIN使用の最適化アプローチ（一括取得）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedEmpRepo.GetEmployeesWithDepartmentJoin()) },
+		},
+	}
+}
 
-	// 2. JOIN使用の最適化アプローチ
-	fmt.Println("2. JOIN使用の最適化アプローチを実行中...")
-	start = time.Now()
+// CompareEmployeeProjectPerformance - 社員とプロジェクト（多対多）の取得パフォーマンスを比較
+func (s *DemoService) CompareEmployeeProjectPerformance() ([]PerformanceResult, error) {
+	fmt.Println("\n=== 社員・プロジェクト（多対多）取得パフォーマンス比較 ===")
+	fmt.Println("中間テーブル（employee_projects）を辿る際のN+1を比較します")
 
-	joinOrders, err := s.optimizedRepo.GetOrdersWithDetailsJoin(days)
+	results, err := s.runStrategies(ScenarioEmployeeProjects, s.employeeProjectStrategies())
 	if err != nil {
-		return nil, fmt.Errorf("JOIN最適化アプローチでエラー: %w", err)
+		return nil, err
+	}
+
+	// パフォーマンス改善率を計算して表示
+	s.displayPerformanceComparison(results)
+
+	return results, nil
+}
+
+// employeeProjectStrategies - 社員・プロジェクト（多対多）取得の比較対象の手法
+func (s *DemoService) employeeProjectStrategies() []strategy {
+	return []strategy{
+		{
+			method:      "N+1_Problem",
+			label:       "N+1問題のあるアプローチ",
+			description: "N+1問題のあるアプローチ（社員ごとに中間テーブル、割り当てごとにプロジェクトを取得）",
+			run:         func() (int, error) { return s.encodeResponse(s.problemEmpRepo.GetEmployeesWithProjects()) },
+		},
+		{
+			method:      "Batch_Optimized",
+			label:       "2クエリのバッチ取得アプローチ",
+			description: "2クエリのバッチ取得アプローチ（社員一覧 + IN句で割り当てを一括取得）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedEmpRepo.GetEmployeesWithProjectsBatch()) },
+		},
+		{
+			method:      "JOIN_Optimized",
+			label:       "JOIN + グルーピングのアプローチ",
+			description: "JOIN + グルーピングのアプローチ（1クエリで取得し社員ごとに集約）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedEmpRepo.GetEmployeesWithProjectsJoin()) },
+		},
 	}
+}
 
-	joinDuration := time.Since(start)
-	results = append(results, PerformanceResult{
-		Method:        "JOIN_Optimized",
-		ExecutionTime: joinDuration,
-		RecordCount:   len(joinOrders),
-		Description:   "JOIN使用の最適化アプローチ（一括取得）",
-	})
+// CompareMonthlySalesPerformance - 顧客別・月別売上レポートの集計方法を比較
+func (s *DemoService) CompareMonthlySalesPerformance(months int) ([]PerformanceResult, error) {
+	fmt.Printf("\n=== 月次売上レポート 集計方法比較（過去%dか月） ===\n", months)
+	fmt.Println("アプリ側集計 → GROUP BY → Result Cache → プロセス内キャッシュ → マテリアライズドビュー")
 
-	fmt.Printf("   実行時間: %v, 取得件数: %d件\n", joinDuration, len(joinOrders))
+	strategies := []strategy{
+		{
+			method:      "App_Side_Aggregation",
+			label:       "アプリ側集計アプローチ",
+			description: "全明細行を取得してGoで集計（転送量・メモリが最大）",
+			run:         func() (int, error) { return s.encodeResponse(s.problemRepo.GetMonthlySalesAppSide(months)) },
+		},
+		{
+			method:      "SQL_GroupBy",
+			label:       "SQL側GROUP BYアプローチ",
+			description: "GROUP BYでSQL側集計（集計結果のみ転送）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetMonthlySalesGroupBy(months)) },
+		},
+		{
+			method:      "SQL_ResultCache",
+			label:       "Result Cacheヒント付きGROUP BYアプローチ",
+			description: "RESULT_CACHEヒント付きGROUP BY（2回目以降はサーバー側キャッシュ）",
+			// 計測前に1回実行してサーバー側のResult Cacheを作成しておく
+			setup: func() error {
+				_, err := s.optimizedRepo.GetMonthlySalesResultCache(months)
+				return err
+			},
+			run: func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetMonthlySalesResultCache(months)) },
+		},
+	}
+	if st, ok := s.goResultCacheStrategy(months); ok {
+		strategies = append(strategies, st)
+	}
 
-	// 3. IN句使用のバッチ取得アプローチ
-	fmt.Println("3. IN句使用のバッチ取得アプローチを実行中...")
-	start = time.Now()
+	exists, lastRefresh, err := s.optimizedRepo.MonthlySalesViewStatus()
+	switch {
+	case err != nil:
+		fmt.Printf("マテリアライズドビューの確認に失敗しました（スキップします）: %v\n", err)
+	case !exists:
+		fmt.Println("マテリアライズドビューが存在しないためスキップします（scripts/ddl/create_tables.sql を参照）")
+	default:
+		if lastRefresh == nil {
+			fmt.Println("マテリアライズドビューが未リフレッシュのため更新します...")
+			if err := s.optimizedRepo.RefreshMonthlySalesView(); err != nil {
+				return nil, err
+			}
+		} else {
+			fmt.Printf("マテリアライズドビュー最終リフレッシュ: %s\n", lastRefresh.Format("2006-01-02 15:04:05"))
+		}
+		strategies = append(strategies, strategy{
+			method:      "Materialized_View",
+			label:       "マテリアライズドビューアプローチ",
+			description: "事前集計済みのマテリアライズドビューを参照（鮮度はリフレッシュ間隔に依存）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetMonthlySalesMaterializedView(months)) },
+		})
+	}
 
-	batchOrders, err := s.optimizedRepo.GetOrdersWithDetailsBatch(days)
+	results, err := s.runStrategies(ScenarioMonthlySales, strategies)
 	if err != nil {
-		return nil, fmt.Errorf("バッチ最適化アプローチでエラー: %w", err)
+		return nil, err
 	}
+	s.checkGoResultCache(months)
 
-	batchDuration := time.Since(start)
-	results = append(results, PerformanceResult{
-		Method:        "Batch_Optimized",
-		ExecutionTime: batchDuration,
-		RecordCount:   len(batchOrders),
-		Description:   "IN句使用のバッチ取得アプローチ",
-	})
+	// パフォーマンス改善率を計算して表示
+	s.displayPerformanceComparison(results)
 
-	fmt.Printf("   実行時間: %v, 取得件数: %d件\n", batchDuration, len(batchOrders))
+	return results, nil
+}
+
+// CompareTopCustomersPerformance - 売上上位顧客と直近受注（Top-N）の取得パフォーマンスを比較
+func (s *DemoService) CompareTopCustomersPerformance(topN, recentOrders int) ([]PerformanceResult, error) {
+	fmt.Printf("\n=== 売上上位%d顧客と直近%d件の受注 取得パフォーマンス比較 ===\n", topN, recentOrders)
+
+	strategies := []strategy{
+		{
+			method:      "N+1_Problem",
+			label:       "N+1問題のあるアプローチ",
+			description: "N+1問題のあるアプローチ（顧客ごとに直近受注を取得）",
+			run: func() (int, error) {
+				return s.encodeResponse(s.problemRepo.GetTopCustomersWithRecentOrders(topN, recentOrders))
+			},
+		},
+		{
+			method:      "Analytic_RowNumber",
+			label:       "ROW_NUMBER()分析関数のアプローチ",
+			description: "ROW_NUMBER() OVER (PARTITION BY customer_id) で1回のクエリに集約",
+			run: func() (int, error) {
+				return s.encodeResponse(s.optimizedRepo.GetTopCustomersWithRecentOrders(topN, recentOrders))
+			},
+		},
+	}
+
+	results, err := s.runStrategies(ScenarioTopCustomers, strategies)
+	if err != nil {
+		return nil, err
+	}
 
 	// パフォーマンス改善率を計算して表示
 	s.displayPerformanceComparison(results)
@@ -105,75 +454,452 @@ func (s *DemoService) CompareOrderPerformance(days int) ([]PerformanceResult, er
 	return results, nil
 }
 
-// CompareEmployeePerformance - 社員データの取得パフォーマンスを比較
-func (s *DemoService) CompareEmployeePerformance() ([]PerformanceResult, error) {
-	var results []PerformanceResult
+// CompareWindowFunctionPerformance - アプリ側ループと分析関数（累計・順位・LAG/LEAD）を比較
+func (s *DemoService) CompareWindowFunctionPerformance(days int) ([]PerformanceResult, error) {
+	fmt.Printf("\n=== 分析関数 vs アプリ側ループ（過去%d日間） ===\n", days)
 
-	fmt.Println("\n=== 社員データ取得パフォーマンス比較 ===")
+	type analyticsFunc func(days int) ([]models.OrderAnalytics, error)
+	scenarios := []struct {
+		name      string
+		loop      analyticsFunc
+		analytic  analyticsFunc
+		sqlMethod string
+		sqlLabel  string
+	}{
+		{"累計", s.problemRepo.GetCustomerRunningTotals, s.optimizedRepo.GetCustomerRunningTotals, "Analytic_RunningTotal", "SUM() OVER"},
+		{"順位", s.problemRepo.GetOrderAmountRanks, s.optimizedRepo.GetOrderAmountRanks, "Analytic_Rank", "RANK() OVER"},
+		{"前後比較", s.problemRepo.GetOrderAmountChanges, s.optimizedRepo.GetOrderAmountChanges, "Analytic_LagLead", "LAG()/LEAD() OVER"},
+	}
 
-	// 1. N+1問題のあるアプローチ
-	fmt.Println("1. N+1問題のあるアプローチを実行中...")
-	start := time.Now()
+	var all []PerformanceResult
+	for _, sc := range scenarios {
+		fmt.Printf("\n--- %s ---\n", sc.name)
 
-	problemEmployees, err := s.problemEmpRepo.GetEmployeesWithDepartment()
-	if err != nil {
-		return nil, fmt.Errorf("N+1問題のあるアプローチでエラー: %w", err)
+		loop, analytic := sc.loop, sc.analytic
+		strategies := []strategy{
+			{
+				method:      "App_Loop",
+				label:       sc.name + "（顧客ごとの取得 + アプリ側ループ）",
+				description: sc.name + "をアプリ側ループで計算（顧客ごとに受注を取得）",
+				run:         func() (int, error) { return s.encodeResponse(loop(days)) },
+			},
+			{
+				method:      sc.sqlMethod,
+				label:       sc.name + "（" + sc.sqlLabel + "）",
+				description: sc.name + "を分析関数 " + sc.sqlLabel + " で1回のクエリで計算",
+				run:         func() (int, error) { return s.encodeResponse(analytic(days)) },
+			},
+		}
+
+		results, err := s.runStrategies(ScenarioWindowFunctions, strategies)
+		if err != nil {
+			return nil, err
+		}
+		s.displayPerformanceComparison(results)
+		all = append(all, results...)
 	}
 
-	problemDuration := time.Since(start)
-	results = append(results, PerformanceResult{
-		Method:        "N+1_Problem",
-		ExecutionTime: problemDuration,
-		RecordCount:   len(problemEmployees),
-		Description:   "N+1問題のあるアプローチ（ループ内でDBアクセス）",
-	})
+	return all, nil
+}
 
-	fmt.Printf("   実行時間: %v, 取得件数: %d件\n", problemDuration, len(problemEmployees))
+// CompareLOBPerformance - 幅の広いLOB列（受注備考）がある場合のJOINとバッチ取得を比較
+func (s *DemoService) CompareLOBPerformance(days int) ([]PerformanceResult, error) {
+	fmt.Printf("\n=== LOB列（受注備考CLOB）を含む取得パフォーマンス比較（過去%d日間） ===\n", days)
+	fmt.Println("JOINではLOBが明細行の数だけ繰り返し転送されます")
 
-	// 2. JOIN使用の最適化アプローチ
-	fmt.Println("2. JOIN使用の最適化アプローチを実行中...")
-	start = time.Now()
+	strategies := []strategy{
+		{
+			method:      "JOIN_WithLOB",
+			label:       "LOBを含むJOINアプローチ",
+			description: "LOBを含むJOIN（明細行ごとにLOBを転送）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithNotesJoin(days)) },
+		},
+		{
+			method:      "Batch_WithLOB",
+			label:       "LOBを含むバッチ取得アプローチ",
+			description: "受注（LOBを含む）+ IN句で明細を一括取得（LOBは受注ごとに1回）",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithNotesBatch(days)) },
+		},
+		{
+			method:      "JOIN_DeferredLOB",
+			label:       "LOB遅延取得のJOINアプローチ",
+			description: "LOBを除いたJOIN + 備考がある受注のLOBのみIN句で取得",
+			run:         func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithNotesDeferred(days)) },
+		},
+	}
 
-	joinEmployees, err := s.optimizedEmpRepo.GetEmployeesWithDepartmentJoin()
+	results, err := s.runStrategies(ScenarioLOB, strategies)
 	if err != nil {
-		return nil, fmt.Errorf("JOIN最適化アプローチでエラー: %w", err)
+		return nil, err
 	}
 
-	joinDuration := time.Since(start)
-	results = append(results, PerformanceResult{
-		Method:        "JOIN_Optimized",
-		ExecutionTime: joinDuration,
-		RecordCount:   len(joinEmployees),
-		Description:   "JOIN使用の最適化アプローチ（一括取得）",
-	})
+	// パフォーマンス改善率を計算して表示（LOBを含むJOINを基準とする）
+	s.displayPerformanceComparison(results)
+
+	return results, nil
+}
 
-	fmt.Printf("   実行時間: %v, 取得件数: %d件\n", joinDuration, len(joinEmployees))
+// CompareCompositeFetchPerformance - 受注・明細・商品の3階層を取得する方法を比較
+//
+// 階層が深くなるとJOINの行数は最下層の件数に揃い、上位階層の列が繰り返し転送される。
+// 転送量を比べるため、-session-stats の指定にかかわらずセッション統計を取得する。
+func (s *DemoService) CompareCompositeFetchPerformance(days int) ([]PerformanceResult, error) {
+	fmt.Printf("\n=== 受注・明細・商品（3階層）取得パフォーマンス比較（過去%d日間） ===\n", days)
+	fmt.Println("入れ子のN+1、3クエリへの分割、1回の多段JOINを比較します")
 
-	// 3. バッチ取得アプローチ
-	fmt.Println("3. バッチ取得アプローチを実行中...")
-	start = time.Now()
+	strategies := []strategy{
+		{
+			method:       "N+1_Nested",
+			label:        "入れ子のN+1アプローチ",
+			description:  "入れ子のN+1（受注ごとに明細、明細ごとに商品を取得）",
+			run:          func() (int, error) { return s.encodeResponse(s.problemRepo.GetOrdersWithProducts(days)) },
+			sessionStats: true,
+		},
+		{
+			method:       "Batch_3Queries",
+			label:        "3クエリのバッチ取得アプローチ",
+			description:  "3クエリのバッチ取得（受注 + IN句で明細 + 重複を除いたIN句で商品）",
+			run:          func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithProductsBatch(days)) },
+			sessionStats: true,
+		},
+		{
+			method:       "WideJoin",
+			label:        "多段JOINアプローチ",
+			description:  "1回の多段JOIN（受注・商品の列を明細行ごとに繰り返し転送）",
+			run:          func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithProductsJoin(days)) },
+			sessionStats: true,
+		},
+	}
 
-	batchEmployees, err := s.optimizedEmpRepo.GetEmployeesWithDepartmentBatch()
+	results, err := s.runStrategies(ScenarioCompositeFetch, strategies)
 	if err != nil {
-		return nil, fmt.Errorf("バッチ最適化アプローチでエラー: %w", err)
+		return nil, err
 	}
 
-	batchDuration := time.Since(start)
-	results = append(results, PerformanceResult{
-		Method:        "Batch_Optimized",
-		ExecutionTime: batchDuration,
-		RecordCount:   len(batchEmployees),
-		Description:   "バッチ取得アプローチ",
-	})
+	// パフォーマンス改善率を計算して表示（入れ子のN+1を基準とする）
+	s.displayPerformanceComparison(results)
+	displayBytesComparison(results)
+	displaySplitVersusJoin(results[1], results[2])
+	if plan, err := s.Orders().Prefetch(repository.RelationDetails, repository.RelationProduct).Plan(); err == nil {
+		fmt.Printf("Prefetch(%q, %q) の取得計画: %s - %s\n", repository.RelationDetails, repository.RelationProduct, plan, plan.Reason)
+	}
 
-	fmt.Printf("   実行時間: %v, 取得件数: %d件\n", batchDuration, len(batchEmployees))
+	return results, nil
+}
 
-	// パフォーマンス改善率を計算して表示
+// displaySplitVersusJoin - クエリ分割と多段JOINのどちらが有利だったかを表示
+func displaySplitVersusJoin(split, join PerformanceResult) {
+	fmt.Println("\n=== クエリ分割 vs 多段JOIN ===")
+	if split.ExecutionTime < join.ExecutionTime {
+		fmt.Printf("クエリ分割が%.2f倍速い: ラウンドトリップ増加より重複データの削減が効いています\n",
+			float64(join.ExecutionTime)/float64(split.ExecutionTime))
+	} else {
+		fmt.Printf("多段JOINが%.2f倍速い: 重複データよりラウンドトリップ削減が効いています\n",
+			float64(split.ExecutionTime)/float64(join.ExecutionTime))
+	}
+	fmt.Println("明細数が多い・上位階層の列が幅広い・子の参照先が少数の商品に集中する場合ほど、分割が有利になります")
+}
+
+// CompareColumnPruningPerformance - SELECT * による過剰取得と必要な列だけのSELECTを比較
+//
+// どちらもクエリは1回なので差はラウンドトリップではなく転送量に現れる。
+// 転送量を示すため、-session-stats の指定にかかわらずセッション統計を取得する。
+func (s *DemoService) CompareColumnPruningPerformance(days int) ([]PerformanceResult, error) {
+	fmt.Printf("\n=== SELECT列の絞り込みと過剰取得の比較（過去%d日間） ===\n", days)
+	fmt.Println("クエリ回数は同じでも、使わない列（CLOB・名称・監査列）の転送量が差になります")
+
+	strategies := []strategy{
+		{
+			method:       "SelectStar",
+			label:        "SELECT * アプローチ",
+			description:  "SELECT o.*, od.* で全列を取得（過剰取得）",
+			run:          func() (int, error) { return s.encodeResponse(s.problemRepo.GetOrdersWithDetailsSelectStar(days)) },
+			sessionStats: true,
+		},
+		{
+			method:       "SelectColumns",
+			label:        "必要な列のみのSELECTアプローチ",
+			description:  "画面で使う8列だけを指定したJOIN",
+			run:          func() (int, error) { return s.encodeResponse(s.optimizedRepo.GetOrdersWithDetailsJoin(days)) },
+			sessionStats: true,
+		},
+	}
+
+	results, err := s.runStrategies(ScenarioColumnPruning, strategies)
+	if err != nil {
+		return nil, err
+	}
+
+	// パフォーマンス改善率を計算して表示（SELECT * を基準とする）
 	s.displayPerformanceComparison(results)
+	displayBytesComparison(results)
 
 	return results, nil
 }
 
+// displayBytesComparison - 基準（先頭）の手法に対する転送量の削減率を表示
+func displayBytesComparison(results []PerformanceResult) {
+	if len(results) < 2 || results[0].SessionStats == nil {
+		return
+	}
+
+	baseline := results[0].SessionStats[sessionstats.BytesSent]
+	if baseline == 0 {
+		return
+	}
+
+	fmt.Println("\n=== 転送量の比較 ===")
+	for _, result := range results[1:] {
+		if result.SessionStats == nil {
+			continue
+		}
+		sent := result.SessionStats[sessionstats.BytesSent]
+		fmt.Printf("%s: %s → %s（%.1f%%削減）\n",
+			result.Method, report.FormatBytes(baseline), report.FormatBytes(sent),
+			float64(baseline-sent)/float64(baseline)*100)
+	}
+}
+
+// runStrategies - 各手法を順に実行して実行時間と取得件数を計測
+//
+// SetShufflerで実行順序を並べ替えた場合も、結果は定義順で返す。
+// SetIterationsで複数回計測する場合は、手法ごとに連続して（交互実行では1回ずつ順番に）実行し、
+// 実行時間はその中央値とする。
+func (s *DemoService) runStrategies(scenario string, strategies []strategy) ([]PerformanceResult, error) {
+	iterations := max(1, s.iterations)
+	samples := make([][]PerformanceResult, len(strategies))
+	executions := 0
+	warmed := WarmupNone
+
+	measure := func(i, position, iteration int) error {
+		if err := stopped(s.ctx); err != nil {
+			return err
+		}
+		st := strategies[i]
+		if iterations > 1 {
+			fmt.Printf("%d. %sを実行中（%d/%d回目）...\n", position+1, st.label, iteration, iterations)
+		} else {
+			fmt.Printf("%d. %sを実行中...\n", position+1, st.label)
+		}
+
+		if err := s.resetBefore(executions); err != nil {
+			return fmt.Errorf("%sの前のリセットでエラー: %w", st.label, err)
+		}
+		if executions == 0 {
+			mode, err := s.warmUp(scenario, strategies)
+			if err != nil {
+				return err
+			}
+			warmed = mode
+		}
+		executions++
+
+		result, err := s.measureStrategy(scenario, st, position)
+		if err != nil {
+			return err
+		}
+		if iteration == iterations {
+			// 同時実行は手法ごとに最後の回の後だけ計測する
+			if err := s.measureConcurrency(st, &result); err != nil {
+				return err
+			}
+		}
+		if warmed != WarmupNone {
+			result.Warmup = warmed
+		}
+		result.Baseline = i == 0
+		samples[i] = append(samples[i], result)
+		if iterations == 1 {
+			s.recordResult(result)
+		}
+		return nil
+	}
+
+	if s.interleave {
+		// 手法を1回ずつ順番に実行し、時間とともに変わるDBの負荷を各手法に均等に割り振る
+		for iteration := 1; iteration <= iterations; iteration++ {
+			for position, i := range s.shuffler.Perm(len(strategies)) {
+				if err := measure(i, position, iteration); err != nil {
+					return nil, err
+				}
+			}
+		}
+	} else {
+		for position, i := range s.shuffler.Perm(len(strategies)) {
+			for iteration := 1; iteration <= iterations; iteration++ {
+				if err := measure(i, position, iteration); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	results := make([]PerformanceResult, len(strategies))
+	for i := range strategies {
+		results[i] = summarizeSamples(samples[i])
+	}
+	if iterations > 1 {
+		if s.interleave {
+			attachPairedDifferences(results)
+		}
+		attachSignificance(results)
+		displayIterations(results, s.interleave)
+		displaySignificance(results)
+		for _, result := range results {
+			s.recordResult(result)
+		}
+	}
+
+	return results, nil
+}
+
+// measureStrategy - 手法を1回実行して計測
+func (s *DemoService) measureStrategy(scenario string, st strategy, position int) (PerformanceResult, error) {
+	// セッション統計を取る場合は準備処理も含めて単一接続で実行する
+	var session *pinnedSession
+	if s.sessionStats || st.sessionStats {
+		session = s.beginSessionStats(scenario)
+	}
+	if session == nil && (len(s.reset.SessionStatements) > 0 || s.rac) {
+		// ALTER SESSIONの設定が計測中のSQLに効くよう、またRACの記録を計測中のセッションで行うよう、
+		// 統計を取らない場合も接続を固定する
+		pinned, err := s.pinSession(scenario, false)
+		if err != nil {
+			return PerformanceResult{}, fmt.Errorf("%sの前のリセットでエラー: %w", st.label, err)
+		}
+		session = pinned
+	}
+	release := func() {
+		if session != nil {
+			session.release()
+		}
+	}
+
+	if session != nil && len(s.reset.SessionStatements) > 0 {
+		if err := s.applySessionReset(session); err != nil {
+			release()
+			return PerformanceResult{}, fmt.Errorf("%sの前のリセットでエラー: %w", st.label, err)
+		}
+		if err := session.restartSessionStats(); err != nil {
+			release()
+			return PerformanceResult{}, fmt.Errorf("%sの前のリセットでエラー: %w", st.label, err)
+		}
+	}
+
+	if st.setup != nil {
+		if err := st.setup(); err != nil {
+			release()
+			return PerformanceResult{}, fmt.Errorf("%sの準備でエラー: %w", st.label, err)
+		}
+		if session != nil {
+			// 準備処理の負荷を計測対象から除く
+			if err := session.restartSessionStats(); err != nil {
+				release()
+				return PerformanceResult{}, fmt.Errorf("%sの準備でエラー: %w", st.label, err)
+			}
+		}
+	}
+
+	var probe *racProbe
+	if s.rac && session != nil {
+		probe = s.beginRAC(session)
+		// 接続先と待機の取得分をセッション統計から除く
+		if err := session.restartSessionStats(); err != nil {
+			release()
+			return PerformanceResult{}, fmt.Errorf("%sの前のRACの記録でエラー: %w", st.label, err)
+		}
+	}
+
+	// 手法ごとのヒープ割り当て量を計測（前の手法のゴミを回収してから開始）
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	s.lastPayload = payloadMeasurement{}
+	queriesBefore := s.beginQueryCount()
+	s.beginPlanCapture()
+	start := s.clock.Now()
+	endSpan := s.beginSpan(scenario, st)
+
+	count, err := st.run()
+	elapsed := s.clock.Since(start)
+	endSpan()
+	if err != nil {
+		release()
+		return PerformanceResult{}, fmt.Errorf("%sでエラー: %w", st.label, err)
+	}
+	runtime.ReadMemStats(&after)
+
+	result := PerformanceResult{
+		Scenario:         scenario,
+		Method:           st.method,
+		ExecutionTime:    elapsed,
+		RecordCount:      count,
+		Description:      st.description,
+		AllocBytes:       after.TotalAlloc - before.TotalAlloc,
+		Allocs:           after.Mallocs - before.Mallocs,
+		Repetition:       s.repetition,
+		Position:         position + 1,
+		ScenarioPosition: s.scenarioPosition,
+	}
+	if st.after != nil {
+		st.after(&result)
+	}
+
+	fmt.Printf("   実行時間: %v, 取得件数: %d件, メモリ割り当て: %s (%d回)\n",
+		result.ExecutionTime, result.RecordCount, report.FormatBytes(int64(result.AllocBytes)), result.Allocs)
+	s.endQueryCount(&result, queriesBefore)
+	s.attachPayload(&result)
+	if session != nil {
+		session.endSessionStats(&result)
+		attachWorkloadClass(&result)
+		attachRoundTrips(&result)
+	}
+	if probe != nil {
+		probe.end(&result)
+	}
+	release()
+	s.attachPlans(&result)
+
+	return result, nil
+}
+
+// recordResult - 完了した手法の結果を履歴に追加
+func (s *DemoService) recordResult(result PerformanceResult) {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	s.history = append(s.history, result)
+}
+
+// ResultCount - これまでに完了した手法の数
+func (s *DemoService) ResultCount() int {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	return len(s.history)
+}
+
+// ResultsSince - 指定した位置（ResultCountの戻り値）以降に完了した手法の結果
+//
+// 実行中のシナリオとは別のゴルーチン（シグナルハンドラーなど）から呼び出してもよい。
+func (s *DemoService) ResultsSince(from int) []PerformanceResult {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if from >= len(s.history) {
+		return nil
+	}
+	results := make([]PerformanceResult, len(s.history)-from)
+	copy(results, s.history[from:])
+	return results
+}
+
+// attachStmtCacheMetrics - ステートメントキャッシュのヒット・ミス件数を結果に付与
+func (s *DemoService) attachStmtCacheMetrics(result *PerformanceResult) {
+	metrics := s.stmtCache.Metrics()
+	result.StmtCacheHits = metrics.Hits
+	result.StmtCacheMisses = metrics.Misses
+	fmt.Printf("   ステートメントキャッシュ: ヒット %d回, ミス %d回 (ヒット率 %.1f%%)\n",
+		metrics.Hits, metrics.Misses, metrics.HitRate())
+}
+
 // displayPerformanceComparison - パフォーマンス比較結果を表示
 func (s *DemoService) displayPerformanceComparison(results []PerformanceResult) {
 	if len(results) < 2 {
@@ -188,18 +914,22 @@ func (s *DemoService) displayPerformanceComparison(results []PerformanceResult)
 		if i == 0 {
 			fmt.Printf("%s: %v (基準)\n", result.Method, result.ExecutionTime)
 		} else {
-			improvement := float64(baseDuration.Nanoseconds()) / float64(result.ExecutionTime.Nanoseconds())
-			fmt.Printf("%s: %v (%.1fx高速化)\n", result.Method, result.ExecutionTime, improvement)
+			improvement := improvementRatio(baseDuration, result.ExecutionTime)
+			if result.Significance != nil {
+				fmt.Printf("%s: %v (%.1fx高速化, %s)\n", result.Method, result.ExecutionTime, improvement, result.Significance.Statement())
+			} else {
+				fmt.Printf("%s: %v (%.1fx高速化)\n", result.Method, result.ExecutionTime, improvement)
+			}
 		}
 	}
 
 	// 最も効果的な改善を強調表示
 	if len(results) >= 2 {
 		bestResult := results[1]
-		bestImprovement := float64(baseDuration.Nanoseconds()) / float64(bestResult.ExecutionTime.Nanoseconds())
+		bestImprovement := improvementRatio(baseDuration, bestResult.ExecutionTime)
 
 		for i := 2; i < len(results); i++ {
-			improvement := float64(baseDuration.Nanoseconds()) / float64(results[i].ExecutionTime.Nanoseconds())
+			improvement := improvementRatio(baseDuration, results[i].ExecutionTime)
 			if improvement > bestImprovement {
 				bestResult = results[i]
 				bestImprovement = improvement
@@ -211,7 +941,20 @@ func (s *DemoService) displayPerformanceComparison(results []PerformanceResult)
 			bestImprovement,
 			float64(baseDuration.Nanoseconds())/1e6,
 			float64(bestResult.ExecutionTime.Nanoseconds())/1e6)
+		if bestResult.Significance != nil {
+			fmt.Printf("信頼度: %s\n", bestResult.Significance.Statement())
+		} else {
+			fmt.Println("信頼度: 1回の計測による比較のため有意差は検定していません（-iterations=5 以上で検定します）")
+		}
 	}
+
+	displayQueryCounts(results)
+	displayConcurrency(results)
+	displayParseComparison(results)
+	displayWorkloadClasses(results)
+	displayRACComparison(results)
+	displayPlans(results)
+	displayActualPlans(results)
 }
 
 // DisplaySampleData - サンプルデータを表示（デバッグ用）
@@ -278,23 +1021,33 @@ func (s *DemoService) DisplaySampleData(orderCount, employeeCount int) error {
 	return nil
 }
 
-// GetDatabaseStats - データベースの統計情報を取得
-func (s *DemoService) GetDatabaseStats() error {
+// GetDatabaseStats - データベースの統計情報を取得して表示
+func (s *DemoService) GetDatabaseStats() (*DatabaseStats, error) {
 	fmt.Printf("\n=== データベース統計情報 ===\n")
 
-	// テーブルごとの件数を取得
-	tables := []string{"orders", "order_details", "employees", "departments"}
+	stats, err := s.CollectDatabaseStats()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, table := range tables {
-		var count int
-		query := fmt.Sprintf("SELECT COUNT(*) FROM %s", table)
-		err := s.db.QueryRow(query).Scan(&count)
-		if err != nil {
-			fmt.Printf("%s: エラー (%v)\n", table, err)
-		} else {
-			fmt.Printf("%s: %d件\n", table, count)
+	for _, ts := range stats.Tables {
+		fmt.Printf("%s: %d件\n", ts.TableName, ts.RowCount)
+		fmt.Printf("  セグメントサイズ: %s, 平均行長: %d bytes\n", report.FormatBytes(ts.SegmentBytes), ts.AvgRowLength)
+		fmt.Printf("  索引: %d個 (%s)\n", ts.IndexCount, report.FormatBytes(ts.IndexBytes))
+
+		lastAnalyzed := "未収集"
+		if ts.LastAnalyzed != nil {
+			lastAnalyzed = ts.LastAnalyzed.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("  最終統計収集: %s\n", lastAnalyzed)
+
+		if ts.Partitioned {
+			fmt.Printf("  パーティション数: %d\n", ts.PartitionCount)
+		}
+		for _, e := range ts.Errors {
+			fmt.Printf("  エラー (%s)\n", e)
 		}
 	}
 
-	return nil
+	return stats, nil
 }
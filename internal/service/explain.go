@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/explain"
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// EnableExplain - 手法ごとに実行した問い合わせの実行計画（EXPLAIN PLAN）を結果に添える（-explain）
+//
+// captureはデータベースの接続を包むRecorderとして登録しておく。すべての接続の文を集めるため、
+// Forkしたサービスには引き継がない（並列に実行した手法の計画は取得しない）。
+func (s *DemoService) EnableExplain(capture *explain.Capture) {
+	s.planCapture = capture
+	s.explainer = explain.NewExplainer(s.db)
+}
+
+// EnablePlanStatistics - 手法ごとに実行した問い合わせの実際の行数（DISPLAY_CURSOR 'ALLSTATS LAST'）を結果に添える（-plan-stats）
+//
+// 接続はGATHER_PLAN_STATISTICSヒントを加えるよう包んでおく（config.Config.PlanStatistics）。
+// captureは EnableExplain と共有してよい。Forkしたサービスには引き継がない。
+func (s *DemoService) EnablePlanStatistics(capture *explain.Capture) {
+	s.planCapture = capture
+	s.cursorStats = explain.NewCursorStats(s.db)
+}
+
+// beginPlanCapture - 手法の実行の直前に、それまでに集めた文を捨てる
+func (s *DemoService) beginPlanCapture() {
+	if s.planCapture != nil {
+		s.planCapture.Reset()
+	}
+}
+
+// attachPlans - 手法の実行中に集めた問い合わせの実行計画を結果に添える（計測の後に実行する）
+func (s *DemoService) attachPlans(result *PerformanceResult) {
+	if s.planCapture == nil {
+		return
+	}
+	s.attachActualPlans(result)
+	if s.explainer == nil {
+		return
+	}
+	plans, err := s.explainer.ExplainAll(s.ctx, s.planCapture.Statements())
+	result.Plans = plans
+	if err != nil {
+		result.PlanError = err.Error()
+		fmt.Printf("   実行計画を取得できません（PLAN_TABLEとDBMS_XPLANが必要です）: %v\n", err)
+		return
+	}
+	for _, plan := range plans {
+		fmt.Printf("   実行計画: %s（%d回実行）\n", plan.Summary(), plan.Executions)
+	}
+}
+
+// attachActualPlans - 手法の実行中に流れた問い合わせの、最後の実行の推定と実際の行数を結果に添える
+func (s *DemoService) attachActualPlans(result *PerformanceResult) {
+	if s.cursorStats == nil {
+		return
+	}
+	plans, err := s.cursorStats.CollectAll(s.ctx, s.planCapture.Statements())
+	result.ActualPlans = plans
+	if err != nil {
+		result.ActualPlanError = err.Error()
+		fmt.Printf("   実際の行数を取得できません（V$SQL・V$SQL_PLAN_STATISTICS_ALLの参照権限が必要です）: %v\n", err)
+		return
+	}
+	for _, plan := range plans {
+		fmt.Printf("   実際の行数: %s（%d回実行）\n", plan.Summary(), plan.Executions)
+	}
+}
+
+// displayPlans - 手法ごとの実行計画をDBMS_XPLAN.DISPLAYの形式で表示
+func displayPlans(results []PerformanceResult) {
+	if len(results) == 0 || (results[0].Plans == nil && results[0].PlanError == "") {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading("実行計画（EXPLAIN PLAN）")
+	for _, result := range results {
+		if len(result.Plans) == 0 {
+			continue
+		}
+		w.Blank()
+		w.Linef("--- %s ---", result.Method)
+		for _, plan := range result.Plans {
+			w.Linef("%d回実行: %s", plan.Executions, plan.SQL)
+			for _, line := range plan.Text {
+				w.Line(line)
+			}
+		}
+	}
+	w.Blank()
+	w.Linef("バインド変数に値を入れずに説明した計画です。手法ごとに最初に実行した%d文までを表示します", explain.MaxStatements)
+}
+
+// displayActualPlans - 手法ごとの推定と実際の行数の差と、DBMS_XPLAN.DISPLAY_CURSOR（'ALLSTATS LAST'）の出力を表示
+func displayActualPlans(results []PerformanceResult) {
+	if len(results) == 0 || (results[0].ActualPlans == nil && results[0].ActualPlanError == "") {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading("実行計画の推定と実際の行数（ALLSTATS LAST）")
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "sql_id", Header: "SQL_ID"},
+		report.Column{Key: "step", Header: "誤差が最大のステップ"},
+		report.Column{Key: "estimated", Header: "推定行数", Align: report.AlignRight},
+		report.Column{Key: "starts", Header: "Starts", Align: report.AlignRight},
+		report.Column{Key: "actual", Header: "実際の行数", Align: report.AlignRight},
+		report.Column{Key: "misestimate", Header: "誤差", Align: report.AlignRight},
+	)
+	for _, result := range results {
+		for _, plan := range result.ActualPlans {
+			step, ok := plan.WorstStep()
+			if !ok {
+				continue
+			}
+			table.AddRow(
+				report.Text(result.Method),
+				report.Text(plan.SQLID),
+				report.Text(fmt.Sprintf("%d %s", step.ID, step.Name())),
+				report.Int(step.EstimatedRows),
+				report.Int(step.Starts),
+				report.Int(step.ActualRows),
+				report.Float("%.1f倍", step.Misestimate()))
+		}
+	}
+	w.Table(table)
+
+	for _, result := range results {
+		if len(result.ActualPlans) == 0 {
+			continue
+		}
+		w.Blank()
+		w.Linef("--- %s ---", result.Method)
+		for _, plan := range result.ActualPlans {
+			w.Linef("%d回実行（最後の1回の実績）: %s", plan.Executions, plan.SQL)
+			for _, line := range plan.Text {
+				w.Line(line)
+			}
+		}
+	}
+	w.Blank()
+	w.Line("推定行数は1回の開始あたり（E-Rows）、実際の行数はすべての開始の合計（A-Rows）。誤差は 推定行数 × Starts と実際の行数の比")
+	w.Line("GATHER_PLAN_STATISTICSヒントでステップごとの時間を集めるため、実行時間はヒントなしより長くなる")
+}
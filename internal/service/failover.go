@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FailoverMode - 計測中にセッションが切断された手法の扱い
+type FailoverMode string
+
+const (
+	// FailoverNone - 再実行しない（切断のエラーを呼び出し元へ返し、処理は失われる）
+	FailoverNone FailoverMode = "none"
+	// FailoverReplay - 接続を取り直して手法を最初から再実行する
+	//
+	// Application Continuity（AC / TAC）が、リクエストの開始（接続の取得）から切断までに実行したSQLを
+	// 新しいセッションで再実行するのと同じ範囲をやり直す。
+	FailoverReplay FailoverMode = "replay"
+)
+
+// FailoverModes - 指定できる扱い
+var FailoverModes = []FailoverMode{FailoverNone, FailoverReplay}
+
+// ErrKillNotAllowed - セッションを切断する権限がない
+var ErrKillNotAllowed = errors.New("ALTER SYSTEM privilege is required to kill sessions")
+
+// ParseFailoverModes - カンマ区切りの扱いを解釈
+func ParseFailoverModes(spec string) ([]FailoverMode, error) {
+	var modes []FailoverMode
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		valid := false
+		for _, mode := range FailoverModes {
+			if FailoverMode(name) == mode {
+				modes = append(modes, mode)
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown failover mode %q (none, replay)", name)
+		}
+	}
+	if len(modes) == 0 {
+		return nil, errors.New("no failover modes")
+	}
+	return modes, nil
+}
+
+// FailoverOptions - 障害注入の設定
+type FailoverOptions struct {
+	Days int
+	// KillFraction - 障害なしの実行時間のどの時点で切断するか（0〜1、KillAfter指定時は使わない）
+	KillFraction float64
+	// KillAfter - 開始から切断までの時間（0ならKillFractionで決める）
+	KillAfter time.Duration
+	Modes     []FailoverMode
+}
+
+// FailoverResult - 手法ごと・扱いごとの障害注入の結果
+type FailoverResult struct {
+	Method string       `json:"method"`
+	Mode   FailoverMode `json:"mode"`
+	// Clean - 障害なしの実行時間
+	Clean  time.Duration `json:"clean"`
+	KillAt time.Duration `json:"kill_at"`
+	// Disrupted - 実行中に切断されてエラーになったか（切断が完了後に届いた場合はfalse）
+	Disrupted bool `json:"disrupted"`
+	Completed bool `json:"completed"`
+	// Elapsed - 最初の開始から完了（または失敗）までの時間
+	Elapsed time.Duration `json:"elapsed"`
+	// LostWork - 切断されるまでに実行し、やり直しで無駄になった時間
+	LostWork time.Duration `json:"lost_work,omitempty"`
+	// Recovery - 切断から再実行の完了までの時間
+	Recovery time.Duration `json:"recovery,omitempty"`
+	Replays  int           `json:"replays,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// sessionID - ALTER SYSTEM KILL SESSION で指定するセッションの識別子
+type sessionID struct {
+	sid, serial, instance int
+}
+
+// CompareFailover - 受注データの手法ごとに、計測中にセッションを切断して再実行の有無による回復の違いを比較
+//
+// 切断には別のセッションから ALTER SYSTEM KILL SESSION を実行するため、ALTER SYSTEM権限とV$SESSIONの参照権限が必要。
+// 実行時間の短い一括取得は切断が届く前に終わるか、やり直す処理が少なく済む。N+1のループは切断までの処理をすべてやり直す。
+func (s *DemoService) CompareFailover(opts FailoverOptions) ([]FailoverResult, error) {
+	if s.conn != nil {
+		return nil, errors.New("failover comparison requires a connection pool")
+	}
+	var granted int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM session_privs WHERE privilege = 'ALTER SYSTEM'`).Scan(&granted); err != nil {
+		return nil, fmt.Errorf("failed to query session_privs: %w", err)
+	}
+	if granted == 0 {
+		return nil, ErrKillNotAllowed
+	}
+
+	fmt.Printf("=== セッション切断からの回復（受注データ、過去%d日間） ===\n\n", opts.Days)
+	var results []FailoverResult
+	for i, st := range s.orderStrategies(opts.Days) {
+		fmt.Printf("%d. %s\n", i+1, st.label)
+		clean, err := s.runOnSession(st)
+		if err != nil {
+			return nil, fmt.Errorf("%sでエラー: %w", st.label, err)
+		}
+		killAt := opts.KillAfter
+		if killAt == 0 {
+			killAt = time.Duration(float64(clean) * opts.KillFraction)
+		}
+		fmt.Printf("   障害なし: %v（%vで切断）\n", clean, killAt.Round(time.Microsecond))
+
+		for _, mode := range opts.Modes {
+			result, err := s.runWithKill(st, mode, killAt)
+			if err != nil {
+				return nil, fmt.Errorf("%sでエラー: %w", st.label, err)
+			}
+			result.Clean = clean
+			displayFailoverLine(result)
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// runOnSession - 手法を1つのセッションで実行して時間を返す
+func (s *DemoService) runOnSession(st strategy) (time.Duration, error) {
+	session, err := s.pinSession(ScenarioOrders, false)
+	if err != nil {
+		return 0, err
+	}
+	defer session.release()
+
+	start := s.clock.Now()
+	if _, err := st.run(); err != nil {
+		return 0, err
+	}
+	return s.clock.Since(start), nil
+}
+
+// runWithKill - 開始からkillAt後にセッションを切断し、modeに応じて再実行する
+func (s *DemoService) runWithKill(st strategy, mode FailoverMode, killAt time.Duration) (FailoverResult, error) {
+	result := FailoverResult{Method: st.method, Mode: mode, KillAt: killAt}
+
+	session, err := s.pinSession(ScenarioOrders, false)
+	if err != nil {
+		return result, err
+	}
+	id, err := currentSessionID(session.conn)
+	if err != nil {
+		session.release()
+		return result, err
+	}
+
+	type killOutcome struct {
+		at  time.Time
+		err error
+	}
+	killed := make(chan killOutcome, 1)
+	start := s.clock.Now()
+	timer := time.AfterFunc(killAt, func() {
+		killed <- killOutcome{at: s.clock.Now(), err: killSession(s.db, id)}
+	})
+
+	_, runErr := st.run()
+	firstElapsed := s.clock.Since(start)
+	fired := !timer.Stop()
+	var kill killOutcome
+	if fired {
+		// 切断のSQLが終わるまで待ち、次の計測のセッションに影響しないようにする
+		kill = <-killed
+		if kill.err != nil {
+			session.release()
+			return result, kill.err
+		}
+		// 切断したセッションは接続プールへ戻さずに閉じる
+		session.discard()
+	}
+	session.release()
+
+	switch {
+	case runErr == nil:
+		// 切断が届く前に完了した（または完了後に切断された）
+		result.Completed = true
+		result.Elapsed = firstElapsed
+		return result, nil
+	case !fired:
+		return result, runErr
+	}
+
+	result.Disrupted = true
+	result.LostWork = firstElapsed
+	if mode == FailoverNone {
+		result.Elapsed = firstElapsed
+		result.Error = runErr.Error()
+		return result, nil
+	}
+
+	// 新しいセッションで最初からやり直す
+	for {
+		result.Replays++
+		_, err := s.runOnSession(st)
+		if err == nil {
+			break
+		}
+		if result.Replays >= 3 {
+			result.Elapsed = s.clock.Since(start)
+			result.Error = err.Error()
+			return result, nil
+		}
+	}
+	result.Completed = true
+	result.Elapsed = s.clock.Since(start)
+	result.Recovery = s.clock.Since(kill.at)
+	return result, nil
+}
+
+// currentSessionID - 接続のセッションの識別子（V$SESSIONの参照権限が必要）
+func currentSessionID(conn *sql.Conn) (sessionID, error) {
+	var id sessionID
+	err := conn.QueryRowContext(context.Background(), `
+		SELECT sid, serial#, TO_NUMBER(SYS_CONTEXT('USERENV', 'INSTANCE'))
+		FROM v$session
+		WHERE sid = SYS_CONTEXT('USERENV', 'SID')`).Scan(&id.sid, &id.serial, &id.instance)
+	if err != nil {
+		return id, fmt.Errorf("failed to query v$session: %w", err)
+	}
+	return id, nil
+}
+
+// killSession - 別のセッションから指定したセッションを即時に切断する
+func killSession(db *sql.DB, id sessionID) error {
+	statement := fmt.Sprintf("ALTER SYSTEM KILL SESSION '%d,%d,@%d' IMMEDIATE", id.sid, id.serial, id.instance)
+	if _, err := db.Exec(statement); err != nil {
+		return fmt.Errorf("failed to kill session: %w", err)
+	}
+	return nil
+}
+
+// discard - 切断されたセッションを接続プールへ戻さずに閉じる
+func (session *pinnedSession) discard() {
+	_ = session.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+}
+
+// displayFailoverLine - 扱いごとの結果を1行で表示
+func displayFailoverLine(r FailoverResult) {
+	label := "再実行なし"
+	if r.Mode == FailoverReplay {
+		label = "再実行あり"
+	}
+	switch {
+	case !r.Disrupted:
+		fmt.Printf("   %s: 切断前に完了（%v）\n", label, r.Elapsed)
+	case !r.Completed:
+		fmt.Printf("   %s: 失敗（%v実行した処理が失われました）: %s\n", label, r.LostWork, r.Error)
+	default:
+		fmt.Printf("   %s: %d回の再実行で完了（合計 %v、やり直した処理 %v、切断から %v で回復）\n",
+			label, r.Replays, r.Elapsed, r.LostWork, r.Recovery.Round(time.Microsecond))
+	}
+}
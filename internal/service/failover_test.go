@@ -0,0 +1,29 @@
+package service
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFailoverModes(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []FailoverMode
+		wantErr bool
+	}{
+		{spec: "none,replay", want: []FailoverMode{FailoverNone, FailoverReplay}},
+		{spec: " replay ,", want: []FailoverMode{FailoverReplay}},
+		{spec: "taf", wantErr: true},
+		{spec: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFailoverModes(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFailoverModes(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseFailoverModes(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
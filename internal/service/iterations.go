@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+// PairedDifference - 交互実行で基準の手法と組にした差の統計
+type PairedDifference struct {
+	Baseline string `json:"baseline"`
+	stats.Paired
+}
+
+// SetIterations - 手法ごとの計測回数と、手法を1回ずつ交互に実行するかを設定
+//
+// 交互実行（A,B,A,B,...）では同じ回のA・Bを組にして差を取るため、時間とともに変わるDBの負荷が打ち消される。
+func (s *DemoService) SetIterations(iterations int, interleave bool) {
+	s.iterations = iterations
+	s.interleave = interleave
+}
+
+// summarizeSamples - 複数回の計測を1件の結果にまとめる（実行時間は中央値、その他の指標は最後の回）
+func summarizeSamples(samples []PerformanceResult) PerformanceResult {
+	if len(samples) == 1 {
+		return samples[0]
+	}
+
+	result := samples[len(samples)-1]
+	result.Samples = make([]time.Duration, len(samples))
+	for i, sample := range samples {
+		result.Samples[i] = sample.ExecutionTime
+	}
+	result.ExecutionTime = stats.MedianDuration(result.Samples)
+	result.Position = samples[0].Position
+	return result
+}
+
+// attachPairedDifferences - 最初の手法（N+1の手法）を基準に、各手法の回ごとの差を付与
+func attachPairedDifferences(results []PerformanceResult) {
+	if len(results) < 2 {
+		return
+	}
+	baseline := results[0]
+	for i := 1; i < len(results); i++ {
+		results[i].Paired = &PairedDifference{
+			Baseline: baseline.Method,
+			Paired:   stats.PairedDifference(baseline.Samples, results[i].Samples),
+		}
+	}
+}
+
+// displayIterations - 複数回計測した手法ごとの中央値と、交互実行の場合は基準との差を表示
+func displayIterations(results []PerformanceResult, interleave bool) {
+	w := report.Stdout()
+	mode := "手法ごとに連続して実行"
+	if interleave {
+		mode = "手法を交互に実行"
+	}
+	w.Heading(fmt.Sprintf("%d回計測の要約（%s）", len(results[0].Samples), mode))
+
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "median", Header: "中央値", Align: report.AlignRight},
+		report.Column{Key: "min", Header: "最小", Align: report.AlignRight},
+		report.Column{Key: "max", Header: "最大", Align: report.AlignRight},
+		report.Column{Key: "diff", Header: "基準との差（平均）", Align: report.AlignRight},
+		report.Column{Key: "ci", Header: "95%信頼区間", Align: report.AlignRight},
+		report.Column{Key: "ratio", Header: "倍率", Align: report.AlignRight},
+		report.Column{Key: "verdict", Header: "判定"},
+	)
+	for _, result := range results {
+		low, high := result.Samples[0], result.Samples[0]
+		for _, d := range result.Samples {
+			low, high = min(low, d), max(high, d)
+		}
+
+		diff, interval, ratio, verdict := report.Text("-"), report.Text("-"), report.Text("-"), report.Text("-")
+		if interleave && result.Paired == nil {
+			verdict = report.Text("基準")
+		}
+		if p := result.Paired; p != nil {
+			diff = report.Duration(p.MeanDiff.Round(time.Microsecond))
+			ratio = report.Float("%.2fx", p.Ratio)
+			switch {
+			case !p.HasInterval():
+				verdict = report.Text("判定不可（3回未満）")
+			case p.Faster():
+				verdict = report.Text("基準より速い")
+			case p.Slower():
+				verdict = report.Text("基準より遅い")
+			default:
+				verdict = report.Text("差は誤差の範囲")
+			}
+			if p.HasInterval() {
+				interval = report.Text(fmt.Sprintf("%v 〜 %v", p.CILow.Round(time.Microsecond), p.CIHigh.Round(time.Microsecond)))
+			}
+		}
+		table.AddRow(
+			report.Text(result.Method),
+			report.Duration(result.ExecutionTime.Round(time.Microsecond)),
+			report.Duration(low.Round(time.Microsecond)),
+			report.Duration(high.Round(time.Microsecond)),
+			diff, interval, ratio, verdict)
+	}
+	w.Table(table)
+
+	if interleave {
+		w.Linef("差は同じ回に実行した基準（%s）との差の平均（負の値は基準より速い）。信頼区間が0をまたがなければ差があると判定します", results[0].Method)
+	} else {
+		w.Line("回ごとの差（対応のある差）は -interleave で手法を交互に実行した場合に表示します")
+	}
+}
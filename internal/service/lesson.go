@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+)
+
+// lessonAction - 教材のステップから参照する手法（シナリオと手法名）
+type lessonAction struct {
+	scenario string
+	method   string
+}
+
+// lessonActions - 教材カタログのActionと比較対象の手法の対応
+var lessonActions = map[string]lessonAction{
+	"orders.n_plus_1":    {ScenarioOrders, "N+1_Problem"},
+	"orders.join":        {ScenarioOrders, "JOIN_Optimized"},
+	"orders.batch":       {ScenarioOrders, "Batch_Optimized"},
+	"orders.prepare":     {ScenarioOrders, "N+1_PrepareInLoop"},
+	"orders.stmt_cache":  {ScenarioOrders, "N+1_StmtCache"},
+	"employees.n_plus_1": {ScenarioEmployees, "N+1_Problem"},
+	"employees.memoized": {ScenarioEmployees, "Memoized_Partial"},
+	"employees.batch":    {ScenarioEmployees, "Batch_Optimized"},
+	"employees.join":     {ScenarioEmployees, "JOIN_Optimized"},
+}
+
+// LessonActions - 教材のステップから実行できる手法の名前（名前順）
+func LessonActions() []string {
+	names := make([]string, 0, len(lessonActions))
+	for name := range lessonActions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunLessonAction - 教材のステップが参照する手法を1回実行して計測
+//
+// 観測結果として実行回数・ラウンドトリップを示せるよう、-session-stats の指定にかかわらずセッション統計を取得する。
+func (s *DemoService) RunLessonAction(action string, days int) (PerformanceResult, error) {
+	st, scenario, err := s.lessonStrategy(action, days)
+	if err != nil {
+		return PerformanceResult{}, err
+	}
+	st.sessionStats = true
+
+	if err := s.resetBefore(0); err != nil {
+		return PerformanceResult{}, fmt.Errorf("%sの前のリセットでエラー: %w", st.label, err)
+	}
+	result, err := s.measureStrategy(scenario, st, 0)
+	if err != nil {
+		return PerformanceResult{}, err
+	}
+	s.recordResult(result)
+	return result, nil
+}
+
+// lessonStrategy - Actionの名前から手法を探す
+func (s *DemoService) lessonStrategy(action string, days int) (strategy, string, error) {
+	a, ok := lessonActions[action]
+	if !ok {
+		return strategy{}, "", fmt.Errorf("unknown lesson action: %s", action)
+	}
+
+	st, ok := findStrategy(s.scenarioStrategies(a.scenario, days), a.method)
+	if !ok {
+		return strategy{}, "", fmt.Errorf("lesson action %s refers to unknown method %s/%s", action, a.scenario, a.method)
+	}
+	return st, a.scenario, nil
+}
+
+// scenarioStrategies - 教材やクイズから1手法ずつ実行できるシナリオの手法（対象外のシナリオはnil）
+func (s *DemoService) scenarioStrategies(scenario string, days int) []strategy {
+	switch scenario {
+	case ScenarioOrders:
+		return s.orderStrategies(days)
+	case ScenarioEmployees:
+		return s.employeeStrategies()
+	case ScenarioEmployeeProjects:
+		return s.employeeProjectStrategies()
+	}
+	return nil
+}
+
+// findStrategy - 手法名で手法を探す
+func findStrategy(strategies []strategy, method string) (strategy, bool) {
+	for _, st := range strategies {
+		if st.method == method {
+			return st, true
+		}
+	}
+	return strategy{}, false
+}
+
+// Runner - 手法を1回実行する関数（戻り値は取得した件数）
+//
+// testing.Bなど外部の計測器から呼び出すため、計測・記録は行わない。手法の準備（setup）は返す前に済ませる。
+func (s *DemoService) Runner(scenario, method string, days int) (func() (int, error), error) {
+	st, ok := findStrategy(s.scenarioStrategies(scenario, days), method)
+	if !ok {
+		return nil, fmt.Errorf("unknown method %s/%s", scenario, method)
+	}
+	if st.setup != nil {
+		if err := st.setup(); err != nil {
+			return nil, fmt.Errorf("%sの準備でエラー: %w", st.label, err)
+		}
+	}
+	return st.run, nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"oracle-n-plus-1-demo/internal/lesson"
+)
+
+func TestLessonActionsResolve(t *testing.T) {
+	s := NewDemoService(nil)
+	for _, action := range LessonActions() {
+		if _, _, err := s.lessonStrategy(action, 30); err != nil {
+			t.Errorf("lessonStrategy(%q) error = %v", action, err)
+		}
+	}
+	if _, _, err := s.lessonStrategy("orders.unknown", 30); err == nil {
+		t.Error("lessonStrategy() accepted an unknown action")
+	}
+}
+
+func TestLessonCatalogActions(t *testing.T) {
+	catalog, err := lesson.Load()
+	if err != nil {
+		t.Fatalf("lesson.Load() error = %v", err)
+	}
+	if err := catalog.CheckActions(LessonActions()); err != nil {
+		t.Error(err)
+	}
+}
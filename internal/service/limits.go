@@ -0,0 +1,31 @@
+package service
+
+import "oracle-n-plus-1-demo/repository"
+
+// SetLimits - すべての手法で扱う受注・社員の件数の上限を設定（Forkしたサービスにも引き継ぐ）
+//
+// 上限は各手法のSQLに同じ副問合せとして加わるため、大きなデータでもN+1の手法が現実的な時間で終わり、
+// 手法間では同じ受注・社員を比較できる。
+func (s *DemoService) SetLimits(limits repository.Limits) {
+	s.limits = limits
+	s.applyLimits()
+}
+
+// SetFlashbackSCN - -as-of で接続を固定したSCN（Batch_SCN_Consistentが同じ時点を読むよう、Forkしたサービスにも引き継ぐ）
+func (s *DemoService) SetFlashbackSCN(scn uint64) {
+	s.flashbackSCN = scn
+	s.applyLimits()
+}
+
+// applyLimits - 現在のリポジトリに件数の上限と固定したSCNを設定
+func (s *DemoService) applyLimits() {
+	s.optimizedRepo.SetAsOfSCN(s.flashbackSCN)
+	s.problemRepo.SetLimits(s.limits)
+	s.optimizedRepo.SetLimits(s.limits)
+	s.problemEmpRepo.SetLimits(s.limits)
+	s.optimizedEmpRepo.SetLimits(s.limits)
+	if s.goResultCacheRepo != nil {
+		s.goResultCacheRepo.SetAsOfSCN(s.flashbackSCN)
+		s.goResultCacheRepo.SetLimits(s.limits)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SGAの構成要素名（V$SGA_DYNAMIC_COMPONENTS.COMPONENT）
+const (
+	sgaBufferCache = "DEFAULT buffer cache"
+	sgaSharedPool  = "shared pool"
+)
+
+// OracleMemory - キャッシュテスト前後のOracleのメモリ使用状況
+type OracleMemory struct {
+	Before *OracleMemorySnapshot `json:"before,omitempty"`
+	After  *OracleMemorySnapshot `json:"after,omitempty"`
+}
+
+// OracleMemorySnapshot - ある時点のSGA構成・Result Cache・セッションPGA（権限がなく取得できない項目はnil）
+type OracleMemorySnapshot struct {
+	SGA []SGAComponent `json:"sga,omitempty"`
+	// ResultCacheBytes - 共有プール内のResult Cacheの使用量（V$SGASTAT）
+	ResultCacheBytes *int64 `json:"result_cache_bytes,omitempty"`
+	// SessionPGABytes - 接続ユーザーの全セッションのPGA使用量（接続プールの接続を含む）
+	SessionPGABytes *int64 `json:"session_pga_bytes,omitempty"`
+	// Errors - 取得できなかった項目と理由
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Component - SGAの構成要素のサイズ（バイト）
+func (s *OracleMemorySnapshot) Component(name string) (int64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	for _, c := range s.SGA {
+		if c.Component == name {
+			return c.Bytes, true
+		}
+	}
+	return 0, false
+}
+
+// oracleMemorySnapshot - SGA構成・Result Cache・セッションPGAを取得（取得できない項目は理由を記録）
+func (c *CacheService) oracleMemorySnapshot() *OracleMemorySnapshot {
+	snapshot := &OracleMemorySnapshot{}
+
+	sga, err := c.getOracleSGAInfo()
+	if err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("SGA構成（V$SGA_DYNAMIC_COMPONENTS）: %v", err))
+	}
+	snapshot.SGA = sga
+
+	var resultCache int64
+	if err := c.db.QueryRow(`
+		SELECT NVL(SUM(bytes), 0) FROM V$SGASTAT
+		WHERE pool = 'shared pool' AND name LIKE 'Result Cache%'`).Scan(&resultCache); err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("Result Cache使用量（V$SGASTAT）: %v", err))
+	} else {
+		snapshot.ResultCacheBytes = &resultCache
+	}
+
+	var pga int64
+	if err := c.db.QueryRow(`
+		SELECT NVL(SUM(p.pga_used_mem), 0)
+		FROM V$SESSION s
+		JOIN V$PROCESS p ON p.addr = s.paddr
+		WHERE s.username = USER`).Scan(&pga); err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("セッションPGA（V$SESSION / V$PROCESS）: %v", err))
+	} else {
+		snapshot.SessionPGABytes = &pga
+	}
+
+	return snapshot
+}
+
+// fillOracleMemoryUsage - メモリ使用量が未設定のOracle内蔵キャッシュの結果に、SGAから求めた使用量を設定
+//
+// Buffer CacheはDEFAULT buffer cacheのサイズ、Result Cache・Function Result Cacheは共有プール内のResult Cacheの使用量を使う。
+func fillOracleMemoryUsage(results []CacheResult, snapshot *OracleMemorySnapshot) {
+	buffer, hasBuffer := snapshot.Component(sgaBufferCache)
+	var resultCache int64
+	hasResult := snapshot != nil && snapshot.ResultCacheBytes != nil
+	if hasResult {
+		resultCache = *snapshot.ResultCacheBytes
+	}
+
+	for i := range results {
+		r := &results[i]
+		if r.MemoryUsage != 0 || !strings.HasPrefix(r.Method, "Oracle_") {
+			continue
+		}
+		switch {
+		case strings.Contains(r.Method, "Buffer_Cache") && hasBuffer:
+			r.MemoryUsage = buffer
+		case (strings.Contains(r.Method, "Result_Cache") || strings.Contains(r.Method, "Function_Cache")) && hasResult:
+			r.MemoryUsage = resultCache
+		case strings.Contains(r.Method, "Integrated") && hasBuffer:
+			r.MemoryUsage = buffer + resultCache
+		}
+	}
+}
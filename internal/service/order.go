@@ -0,0 +1,55 @@
+package service
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// Shuffler - 実行順序を無作為に並べ替える（並列実行のForkからも共有できるよう排他する）
+type Shuffler struct {
+	mu   sync.Mutex
+	rng  *rand.Rand
+	seed uint64
+}
+
+// NewShuffler - シードを指定して作成（同じシードなら同じ順序を再現できる）
+func NewShuffler(seed uint64) *Shuffler {
+	return &Shuffler{rng: rand.New(rand.NewPCG(seed, seed)), seed: seed}
+}
+
+// Seed - 作成時のシード
+func (sh *Shuffler) Seed() uint64 {
+	return sh.seed
+}
+
+// Perm - 0〜n-1の並べ替え（nilの場合は元の順序）
+func (sh *Shuffler) Perm(n int) []int {
+	if sh == nil {
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		return order
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.rng.Perm(n)
+}
+
+// SetShuffler - シナリオ内の手法の実行順序を並べ替える（nilで定義順に戻す）
+//
+// 結果は実行順ではなく定義順で返すため、N+1の手法を基準にした比較表示は変わらない。
+func (s *DemoService) SetShuffler(shuffler *Shuffler) {
+	s.shuffler = shuffler
+}
+
+// SetRepetition - 以降に記録する結果の繰り返し番号（1始まり、0は繰り返しなし）
+func (s *DemoService) SetRepetition(repetition int) {
+	s.repetition = repetition
+}
+
+// SetScenarioPosition - 以降に記録する結果のシナリオの実行順（1始まり、0は記録しない）
+func (s *DemoService) SetScenarioPosition(position int) {
+	s.scenarioPosition = position
+}
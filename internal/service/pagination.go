@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+
+	"oracle-n-plus-1-demo/models"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// pageFetcher - 受注一覧の1ページと総件数を取得する関数
+type pageFetcher func(days, page, pageSize int) (*models.OrderPage, error)
+
+// ComparePaginationPerformance - 受注一覧のページングで、件数の取り方を比較
+//
+// 一覧画面がページごとに件数のCOUNT(*)とページの取得を別々に実行するのは、N+1と同じく
+// 「同じ条件の問い合わせを繰り返す」アンチパターン。先頭からpages枚のページを順にめくる操作を計測する。
+func (s *DemoService) ComparePaginationPerformance(days, pageSize, pages int) ([]PerformanceResult, error) {
+	fmt.Printf("\n=== ページングと件数の取得方法の比較（過去%d日間、%d件ずつ%dページ） ===\n", days, pageSize, pages)
+	fmt.Println("ページごとの件数クエリ → COUNT(*) OVER () → 標本からの推定")
+
+	totals := make(map[string]*models.OrderPage)
+	browse := func(method string, fetch pageFetcher) func() (int, error) {
+		return func() (int, error) {
+			var browsed []*models.OrderPage
+			rows := 0
+			for page := 0; page < pages; page++ {
+				p, err := fetch(days, page, pageSize)
+				if err != nil {
+					return 0, err
+				}
+				browsed = append(browsed, p)
+				rows += len(p.Orders)
+			}
+			if _, err := s.encodeResponse(browsed, nil); err != nil {
+				return 0, err
+			}
+			if len(browsed) > 0 {
+				totals[method] = browsed[0]
+			}
+			return rows, nil
+		}
+	}
+
+	strategies := []strategy{
+		{
+			method:      "Count_Then_Page",
+			label:       "件数クエリ + ページクエリのアプローチ",
+			description: "ページごとにCOUNT(*)とOFFSET/FETCHの2クエリ（ラウンドトリップと走査が2倍）",
+			run:         browse("Count_Then_Page", s.problemRepo.GetOrderPageWithCount),
+		},
+		{
+			method:      "Count_Over",
+			label:       "COUNT(*) OVER () のアプローチ",
+			description: "COUNT(*) OVER () で件数とページを1クエリで取得",
+			run:         browse("Count_Over", s.optimizedRepo.GetOrderPageCountOver),
+		},
+		{
+			method:      "Approx_Count",
+			label:       "推定件数 + ページクエリのアプローチ",
+			description: fmt.Sprintf("SAMPLE BLOCK (%d) で推定した件数とページの2クエリ（件数は概数）", repository.ApproxSamplePercent),
+			run:         browse("Approx_Count", s.optimizedRepo.GetOrderPageApproxCount),
+		},
+	}
+
+	results, err := s.runStrategies(ScenarioPagination, strategies)
+	if err != nil {
+		return nil, err
+	}
+
+	// パフォーマンス改善率を計算して表示（ページごとの件数クエリを基準とする）
+	s.displayPerformanceComparison(results)
+	displayPageTotals(totals, pageSize)
+
+	return results, nil
+}
+
+// displayPageTotals - 手法ごとの総件数と、推定件数の誤差を表示
+func displayPageTotals(totals map[string]*models.OrderPage, pageSize int) {
+	exact, ok := totals["Count_Then_Page"]
+	if !ok {
+		return
+	}
+	fmt.Println("\n=== 総件数の比較 ===")
+	fmt.Printf("COUNT(*): %d件（%dページ）\n", exact.Total, (exact.Total+int64(pageSize)-1)/int64(pageSize))
+	if p, ok := totals["Count_Over"]; ok {
+		fmt.Printf("COUNT(*) OVER (): %d件\n", p.Total)
+	}
+	if p, ok := totals["Approx_Count"]; ok {
+		fmt.Printf("推定（ブロックの%d%%の標本）: 約%d件", repository.ApproxSamplePercent, p.Total)
+		if exact.Total > 0 {
+			fmt.Printf("（誤差 %+.1f%%）", (float64(p.Total)-float64(exact.Total))/float64(exact.Total)*100)
+		}
+		fmt.Println()
+	}
+	fmt.Println("COUNT(*) OVER () は最後のページより後を指定して行がないと件数も得られません。推定件数は「約1,200件」のような表示に向きます")
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/stmtcache"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// Isolation - シナリオを並列に実行する際の接続の分離方法
+type Isolation string
+
+const (
+	// IsolationPool - 接続プールを共有する（SQLごとに空いている接続を使う）
+	IsolationPool Isolation = "pool"
+	// IsolationSession - シナリオごとに専用の接続（セッション）を確保し、終了まで使い続ける
+	IsolationSession Isolation = "session"
+)
+
+// Isolations - 指定できる分離方法
+var Isolations = []Isolation{IsolationPool, IsolationSession}
+
+// ErrUnknownIsolation - 未知の分離方法が指定された
+var ErrUnknownIsolation = errors.New("unknown isolation")
+
+// ParseIsolation - 分離方法の名前を解釈
+func ParseIsolation(name string) (Isolation, error) {
+	for _, isolation := range Isolations {
+		if Isolation(name) == isolation {
+			return isolation, nil
+		}
+	}
+
+	names := make([]string, len(Isolations))
+	for i, isolation := range Isolations {
+		names[i] = string(isolation)
+	}
+	return "", fmt.Errorf("%w: %s (%s)", ErrUnknownIsolation, name, strings.Join(names, ", "))
+}
+
+// Fork - 設定を引き継ぎ、ステートメントキャッシュと結果の履歴を独立させたサービスを作る
+//
+// 並列に実行するシナリオごとに作り、完了後にAdoptで結果を元のサービスへ取り込む。
+// IsolationSessionでは専用の接続を確保するため、使い終わったらCloseで返却すること。
+// 発行したSQLの数と実行計画はすべての接続の文から求めるため引き継がない（並列に実行した手法の文が混ざる）。
+func (s *DemoService) Fork(isolation Isolation) (*DemoService, error) {
+	child := &DemoService{
+		db:                      s.db,
+		sessionStats:            s.sessionStats,
+		sessionStatsUnavailable: s.sessionStatsUnavailable,
+		rac:                     s.rac,
+		racWaitsUnavailable:     s.racWaitsUnavailable,
+		payloadTiming:           s.payloadTiming,
+		shuffler:                s.shuffler,
+		iterations:              s.iterations,
+		interleave:              s.interleave,
+		repetition:              s.repetition,
+		clock:                   s.clock,
+		trace:                   s.trace,
+		ctx:                     s.ctx,
+		limits:                  s.limits,
+		flashbackSCN:            s.flashbackSCN,
+		warmup:                  s.warmup,
+	}
+
+	if isolation != IsolationSession {
+		child.stmtCache = stmtcache.New(s.db)
+		child.bindRepositories(s.db)
+		return child, nil
+	}
+
+	conn, err := s.db.Conn(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire dedicated connection: %w", err)
+	}
+	dedicated := repository.NewConnDBContext(s.ctx, conn)
+	child.conn = conn
+	child.stmtCache = stmtcache.New(dedicated)
+	child.bindRepositories(dedicated)
+	return child, nil
+}
+
+// Adopt - Forkしたサービスで完了した手法の結果を履歴に取り込む
+func (s *DemoService) Adopt(child *DemoService) {
+	for _, result := range child.ResultsSince(0) {
+		s.recordResult(result)
+	}
+}
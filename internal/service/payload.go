@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/report"
+)
+
+// payloadMeasurement - 直前の手法でのJSONレスポンス生成の計測値
+type payloadMeasurement struct {
+	encodeTime time.Duration
+	bytes      int
+}
+
+// EnablePayloadTiming - 取得結果をAPIレスポンスと同じくJSONへエンコードするまでを計測するかを設定
+//
+// 有効な場合、実行時間はDB取得からJSON生成までのエンドツーエンドの時間になる。
+func (s *DemoService) EnablePayloadTiming(enabled bool) {
+	s.payloadTiming = enabled
+}
+
+// encodeResponse - 取得結果の件数を返す（ペイロード計測が有効ならJSONへエンコードして計測）
+//
+// 各手法のrunから s.encodeResponse(repo.GetXxx(...)) の形で呼び出す。
+func (s *DemoService) encodeResponse(items interface{}, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	count := reflect.ValueOf(items).Len()
+	if !s.payloadTiming {
+		return count, nil
+	}
+
+	start := s.clock.Now()
+	data, err := json.Marshal(items)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	s.lastPayload = payloadMeasurement{encodeTime: s.clock.Since(start), bytes: len(data)}
+
+	return count, nil
+}
+
+// attachPayload - 計測したJSON生成時間とサイズを結果に付与する
+func (s *DemoService) attachPayload(result *PerformanceResult) {
+	if !s.payloadTiming {
+		return
+	}
+	result.EncodeTime = s.lastPayload.encodeTime
+	result.PayloadBytes = s.lastPayload.bytes
+	result.DBTime = result.ExecutionTime - result.EncodeTime
+
+	fmt.Printf("   DB取得: %v, JSON生成: %v, レスポンスサイズ: %s\n",
+		result.DBTime, result.EncodeTime, report.FormatBytes(int64(result.PayloadBytes)))
+}
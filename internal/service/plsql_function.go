@@ -0,0 +1,189 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PL/SQL Function Result Cacheテストで作成する関数
+const (
+	// plsqlFunctionBaseName - 接頭辞・接尾辞を除いた関数名
+	plsqlFunctionBaseName = "order_summary"
+	// DefaultPLSQLFunctionPrefix - 関数名の既定の接頭辞（共有スキーマでほかの関数と重ならないようにする）
+	DefaultPLSQLFunctionPrefix = "nplus1_"
+	// PLSQLFunctionMarker - このデモが作成した関数であることを示すソース中のコメント
+	PLSQLFunctionMarker = "created by oracle-n-plus-1-demo"
+	// maxPLSQLIdentifierLength - 12.1以前でも作成できる識別子の長さ
+	maxPLSQLIdentifierLength = 30
+)
+
+// plsqlIdentifier - 引用符なしで使える識別子
+var plsqlIdentifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*$`)
+
+// ErrPLSQLFunctionConflict - 同名の関数がこのデモ以外によって作成されている
+var ErrPLSQLFunctionConflict = errors.New("plsql function already exists and was not created by this demo")
+
+// PLSQLFunctionOptions - PL/SQL Function Result Cacheテストで作成する関数の設定
+type PLSQLFunctionOptions struct {
+	// Prefix / Suffix - 関数名の接頭辞と接尾辞（利用者ごとに分ける場合など）
+	Prefix string
+	Suffix string
+	// Disabled - 関数を作成せず、PL/SQL Function Result Cacheテストをスキップする
+	Disabled bool
+	// Keep - テスト後に関数を削除しない
+	Keep bool
+}
+
+// DefaultPLSQLFunctionOptions - 既定の接頭辞で作成し、テスト後に削除する設定
+func DefaultPLSQLFunctionOptions() PLSQLFunctionOptions {
+	return PLSQLFunctionOptions{Prefix: DefaultPLSQLFunctionPrefix}
+}
+
+// FunctionName - 作成する関数名（データディクショナリと同じ大文字）
+func (o PLSQLFunctionOptions) FunctionName() string {
+	return strings.ToUpper(o.Prefix + plsqlFunctionBaseName + o.Suffix)
+}
+
+// Validate - 関数名が引用符なしの識別子として使えるか確認
+func (o PLSQLFunctionOptions) Validate() error {
+	name := o.FunctionName()
+	if !plsqlIdentifier.MatchString(name) {
+		return fmt.Errorf("invalid function name %q: prefix and suffix may only contain letters, digits, _, $ and #, and the name must start with a letter", name)
+	}
+	if len(name) > maxPLSQLIdentifierLength {
+		return fmt.Errorf("function name %q is longer than %d characters", name, maxPLSQLIdentifierLength)
+	}
+	return nil
+}
+
+// SetPLSQLFunctionOptions - PL/SQL Function Result Cacheテストで作成する関数の設定
+func (c *CacheService) SetPLSQLFunctionOptions(opts PLSQLFunctionOptions) {
+	c.plsqlFunction = opts
+}
+
+// plsqlFunctionState - 作成前の同名の関数の状態
+type plsqlFunctionState struct {
+	exists bool
+	// ours - ソースに PLSQLFunctionMarker を含む（このデモが以前に作成した）
+	ours bool
+	// edition - 関数が実体化されているエディション（エディション化されていない場合は空）
+	edition string
+	// currentEdition - セッションの現在のエディション
+	currentEdition string
+	// editionable - スキーマでエディションが有効（関数はエディションごとに実体化される）
+	editionable bool
+}
+
+// inherited - 関数が祖先のエディションから継承されたものか（現在のエディションで置き換えると別の実体が作られる）
+func (s plsqlFunctionState) inherited() bool {
+	return s.editionable && s.edition != "" && s.edition != s.currentEdition
+}
+
+// lookupPLSQLFunction - 同名の関数の有無・作成者・エディションを確認
+func (c *CacheService) lookupPLSQLFunction(name string) (plsqlFunctionState, error) {
+	var state plsqlFunctionState
+	var current sql.NullString
+	if err := c.db.QueryRow("SELECT SYS_CONTEXT('USERENV', 'CURRENT_EDITION_NAME') FROM DUAL").Scan(&current); err != nil {
+		return state, fmt.Errorf("failed to query current edition: %w", err)
+	}
+	state.currentEdition = current.String
+
+	var enabled string
+	if err := c.db.QueryRow("SELECT editions_enabled FROM user_users").Scan(&enabled); err != nil {
+		return state, fmt.Errorf("failed to query editions_enabled: %w", err)
+	}
+	state.editionable = enabled == "Y"
+
+	var edition sql.NullString
+	err := c.db.QueryRow(`
+		SELECT edition_name FROM user_objects
+		WHERE object_name = :1 AND object_type = 'FUNCTION'`, name).Scan(&edition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to query function %s: %w", name, err)
+	}
+	state.exists = true
+	state.edition = edition.String
+
+	var marked int
+	if err := c.db.QueryRow(`
+		SELECT COUNT(*) FROM user_source
+		WHERE name = :1 AND type = 'FUNCTION' AND INSTR(text, :2) > 0`, name, PLSQLFunctionMarker).Scan(&marked); err != nil {
+		return state, fmt.Errorf("failed to query source of function %s: %w", name, err)
+	}
+	state.ours = marked > 0
+	return state, nil
+}
+
+// preparePLSQLFunction - 関数を用意し、テスト後に呼ぶ後片付けと利用者への注意を返す
+//
+// 同名の関数がこのデモ以外によって作成されている場合は置き換えず ErrPLSQLFunctionConflict を返す。
+// このデモが作成した関数が祖先のエディションから継承されている場合は、現在のエディションに別の実体を作らないよう
+// 置き換えずにそのまま使い、削除もしない（削除すると現在のエディションからだけ見えなくなるため）。
+func (c *CacheService) preparePLSQLFunction() (cleanup func() error, notes []string, err error) {
+	opts := c.plsqlFunction
+	name := opts.FunctionName()
+	noop := func() error { return nil }
+
+	state, err := c.lookupPLSQLFunction(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state.exists && !state.ours {
+		return nil, nil, fmt.Errorf("%w: %s (use -plsql-prefix or -plsql-suffix to choose another name)", ErrPLSQLFunctionConflict, name)
+	}
+	if state.exists && state.inherited() {
+		notes = append(notes, fmt.Sprintf("関数%sはエディション%sから継承されているため、置き換えずに使用します（現在のエディション: %s、削除もしません）",
+			name, state.edition, state.currentEdition))
+		return noop, notes, nil
+	}
+	if state.exists {
+		notes = append(notes, fmt.Sprintf("以前の実行で残った関数%sを置き換えます", name))
+	}
+
+	if _, err := c.db.Exec(plsqlFunctionDDL(name)); err != nil {
+		return nil, notes, fmt.Errorf("failed to create function %s: %w", name, err)
+	}
+	if state.editionable && !state.exists {
+		// エディション化されたスキーマでは、作成した関数は現在のエディションにだけ存在する
+		notes = append(notes, fmt.Sprintf("関数%sをエディション%sに作成しました", name, state.currentEdition))
+	}
+	if opts.Keep {
+		notes = append(notes, fmt.Sprintf("関数%sは削除せずに残します（-keep-plsql-function）", name))
+		return noop, notes, nil
+	}
+	return func() error {
+		if _, err := c.db.Exec("DROP FUNCTION " + name); err != nil {
+			return fmt.Errorf("failed to drop function %s: %w", name, err)
+		}
+		return nil
+	}, notes, nil
+}
+
+// plsqlFunctionDDL - Function Result Cache付きの関数を作成するDDL（nameはValidate済みの識別子）
+func plsqlFunctionDDL(name string) string {
+	return `
+		CREATE OR REPLACE FUNCTION ` + name + `(p_customer_id NUMBER)
+		RETURN VARCHAR2
+		RESULT_CACHE RELIES_ON (orders)
+		IS
+			-- ` + PLSQLFunctionMarker + `
+			l_summary VARCHAR2(1000);
+		BEGIN
+			SELECT 'Orders: ' || COUNT(*) || ', Total: $' || ROUND(SUM(total_amount), 2)
+			INTO l_summary
+			FROM orders
+			WHERE customer_id = p_customer_id
+			AND order_date >= SYSDATE - 90;
+
+			RETURN l_summary;
+		EXCEPTION
+			WHEN NO_DATA_FOUND THEN
+				RETURN 'No orders found';
+		END;`
+}
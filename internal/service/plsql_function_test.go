@@ -0,0 +1,59 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPLSQLFunctionOptionsName(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    PLSQLFunctionOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "default", opts: DefaultPLSQLFunctionOptions(), want: "NPLUS1_ORDER_SUMMARY"},
+		{name: "no prefix", opts: PLSQLFunctionOptions{}, want: "ORDER_SUMMARY"},
+		{name: "suffix", opts: PLSQLFunctionOptions{Prefix: "d_", Suffix: "_bob"}, want: "D_ORDER_SUMMARY_BOB"},
+		{name: "user suffix", opts: PLSQLFunctionOptions{Prefix: DefaultPLSQLFunctionPrefix, Suffix: "_alice"}, want: "NPLUS1_ORDER_SUMMARY_ALICE"},
+		{name: "too long", opts: PLSQLFunctionOptions{Prefix: DefaultPLSQLFunctionPrefix, Suffix: "_workshop_team"}, want: "NPLUS1_ORDER_SUMMARY_WORKSHOP_TEAM", wantErr: true},
+		{name: "starts with digit", opts: PLSQLFunctionOptions{Prefix: "1_"}, want: "1_ORDER_SUMMARY", wantErr: true},
+		{name: "injection", opts: PLSQLFunctionOptions{Suffix: "; DROP TABLE orders"}, want: "ORDER_SUMMARY; DROP TABLE ORDERS", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.FunctionName(); got != tt.want {
+				t.Errorf("FunctionName() = %q, want %q", got, tt.want)
+			}
+			if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPLSQLFunctionStateInherited(t *testing.T) {
+	tests := []struct {
+		name  string
+		state plsqlFunctionState
+		want  bool
+	}{
+		{"not editionable", plsqlFunctionState{edition: "", currentEdition: "ORA$BASE"}, false},
+		{"actual in current edition", plsqlFunctionState{editionable: true, edition: "V2", currentEdition: "V2"}, false},
+		{"inherited from parent", plsqlFunctionState{editionable: true, edition: "ORA$BASE", currentEdition: "V2"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.inherited(); got != tt.want {
+				t.Errorf("inherited() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPLSQLFunctionDDLHasMarker(t *testing.T) {
+	ddl := plsqlFunctionDDL("NPLUS1_ORDER_SUMMARY")
+	if !strings.Contains(ddl, "FUNCTION NPLUS1_ORDER_SUMMARY(") || !strings.Contains(ddl, PLSQLFunctionMarker) {
+		t.Errorf("DDL does not contain the function name and marker:\n%s", ddl)
+	}
+}
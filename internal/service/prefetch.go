@@ -0,0 +1,48 @@
+package service
+
+import (
+	"oracle-n-plus-1-demo/models"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// OrderQuery - 参照する関連を先に宣言して受注を取得するクエリ（ORMの Preload・includes と同じ使い方）
+//
+//	orders, err := s.Orders().Prefetch("details", "product").Find(30)
+//
+// 宣言しなかった関連は取得しないため、後から参照しても追加のSQLは発行されず、明細は空・商品はnilになる。
+type OrderQuery struct {
+	repo      *repository.OptimizedOrderRepository
+	relations []string
+	strategy  repository.FetchStrategy
+}
+
+// Orders - 受注を取得するクエリを作成
+func (s *DemoService) Orders() *OrderQuery {
+	return &OrderQuery{repo: s.optimizedRepo, strategy: repository.FetchAuto}
+}
+
+// Prefetch - 参照する関連（repository.RelationDetails・RelationProduct）を宣言する
+func (q *OrderQuery) Prefetch(relations ...string) *OrderQuery {
+	q.relations = append(q.relations, relations...)
+	return q
+}
+
+// Strategy - 取得方法を固定する（既定はrepository.FetchAutoで、関連の形から選ぶ）
+func (q *OrderQuery) Strategy(strategy repository.FetchStrategy) *OrderQuery {
+	q.strategy = strategy
+	return q
+}
+
+// Plan - 宣言された関連の取得計画（SQLは実行しない）
+func (q *OrderQuery) Plan() (repository.FetchPlan, error) {
+	return repository.PlanPrefetch(q.strategy, q.relations...)
+}
+
+// Find - 過去days日間の受注を、取得計画に従って関連と一緒に取得
+func (q *OrderQuery) Find(days int) ([]models.OrderWithProducts, error) {
+	plan, err := q.Plan()
+	if err != nil {
+		return nil, err
+	}
+	return q.repo.GetOrdersPrefetch(days, plan)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/querylog"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+// QueryCounts - 手法の実行中にアプリケーションが発行したSQLの数
+type QueryCounts struct {
+	querylog.Counts
+	// RoundTrips - V$MYSTATのSQL*Netラウンドトリップ（セッション統計を取得した場合のみ）
+	RoundTrips int64 `json:"round_trips,omitempty"`
+}
+
+// EnableQueryCount - 手法ごとに発行したSQLの数を数える（counterはデータベースの接続を包むRecorderとして登録しておく）
+//
+// counterはすべての接続の文を合計するため、Forkしたサービスには引き継がない（並列に実行した手法の数は記録しない）。
+func (s *DemoService) EnableQueryCount(counter *querylog.Counter) {
+	s.queryCounter = counter
+}
+
+// beginQueryCount - 手法の実行前の文の数（数えない場合はnil）
+func (s *DemoService) beginQueryCount() *querylog.Counts {
+	if s.queryCounter == nil {
+		return nil
+	}
+	before := s.queryCounter.Snapshot()
+	return &before
+}
+
+// endQueryCount - 実行前からの文の数を結果に記録
+func (s *DemoService) endQueryCount(result *PerformanceResult, before *querylog.Counts) {
+	if before == nil {
+		return
+	}
+	result.Queries = &QueryCounts{Counts: s.queryCounter.Snapshot().Sub(*before)}
+	fmt.Printf("   発行したSQL: %d文（問い合わせ %d, DML %d, バインド実行 %d回）\n",
+		result.Queries.Statements(), result.Queries.Queries, result.Queries.DML, result.Queries.BindExecutions)
+}
+
+// attachRoundTrips - セッション統計のラウンドトリップを文の数に添える
+func attachRoundTrips(result *PerformanceResult) {
+	if result.Queries == nil || result.SessionStats == nil {
+		return
+	}
+	result.Queries.RoundTrips = result.SessionStats[sessionstats.RoundTrips]
+}
+
+// displayQueryCounts - 手法ごとに発行したSQLの数を表示
+func displayQueryCounts(results []PerformanceResult) {
+	if len(results) == 0 || results[0].Queries == nil {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading("発行したSQLの数")
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "statements", Header: "SQL", Align: report.AlignRight},
+		report.Column{Key: "queries", Header: "問い合わせ", Align: report.AlignRight},
+		report.Column{Key: "dml", Header: "DML", Align: report.AlignRight},
+		report.Column{Key: "bind_executions", Header: "バインド実行", Align: report.AlignRight},
+		report.Column{Key: "round_trips", Header: "ラウンドトリップ", Align: report.AlignRight},
+	)
+	for _, result := range results {
+		counts := result.Queries
+		if counts == nil {
+			continue
+		}
+		roundTrips := report.Text("-")
+		if result.SessionStats != nil {
+			roundTrips = report.Int(counts.RoundTrips)
+		}
+		table.AddRow(
+			report.Text(result.Method),
+			report.Int(counts.Statements()),
+			report.Int(counts.Queries),
+			report.Int(counts.DML),
+			report.Int(counts.BindExecutions),
+			roundTrips)
+	}
+	w.Table(table)
+	w.Line("バインド実行は配列バインドの行数を含む。ラウンドトリップはセッション統計（-session-stats）を取得した場合のみ表示し、行の取得（フェッチ）も含む")
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuizScenario - クイズで出題するシナリオ（手法名を伏せて比較する手法と正解のN+1の手法）
+type QuizScenario struct {
+	// ID - シナリオ識別子（-quiz-scenario で指定する）
+	ID    string
+	Title string
+	// Methods - 比較する手法（N+1の手法を1つだけ含む）
+	Methods []string
+	// NPlusOne - 正解の手法
+	NPlusOne string
+	// Hint - 出題時に示す見分け方のヒント
+	Hint string
+	// Explanation - 答え合わせで示す解説
+	Explanation string
+}
+
+// quizScenarios - 出題できるシナリオ（出題順）
+//
+// 部分的な改善（メモ化・ステートメントキャッシュ）もループ内でDBにアクセスするため、
+// 正解が1つに決まるよう比較対象には含めない。
+var quizScenarios = []QuizScenario{
+	{
+		ID:          ScenarioOrders,
+		Title:       "受注と明細の取得",
+		Methods:     []string{"N+1_Problem", "JOIN_Optimized", "Batch_Optimized"},
+		NPlusOne:    "N+1_Problem",
+		Hint:        "取得件数はどの手法も同じです。SQLの実行回数と取得件数の関係に注目してください。",
+		Explanation: "N+1の手法は受注ごとに明細のSQLを実行するため、SQLの実行回数とラウンドトリップが受注数とほぼ同じになります。JOINは1回、IN句のバッチ取得は2回程度です。",
+	},
+	{
+		ID:          ScenarioEmployees,
+		Title:       "社員と部署の取得",
+		Methods:     []string{"N+1_Problem", "Batch_Optimized", "JOIN_Optimized"},
+		NPlusOne:    "N+1_Problem",
+		Hint:        "部署の数は社員数よりずっと少ないことを思い出してください。",
+		Explanation: "N+1の手法は社員ごとに部署を取得するため、SQLの実行回数が「1 + 社員数」になります。部署が少なくても実行回数は減りません。",
+	},
+	{
+		ID:          ScenarioEmployeeProjects,
+		Title:       "社員とプロジェクト（多対多）の取得",
+		Methods:     []string{"N+1_Problem", "Batch_Optimized", "JOIN_Optimized"},
+		NPlusOne:    "N+1_Problem",
+		Hint:        "中間テーブルを辿る場合、ループは二重になることがあります。",
+		Explanation: "N+1の手法は社員ごとに中間テーブルを、割り当てごとにプロジェクトを取得するため、SQLの実行回数が社員数と割り当て数の合計を超えます。",
+	},
+}
+
+// ErrUnknownQuizScenario - 出題できないシナリオが指定された
+var ErrUnknownQuizScenario = errors.New("unknown quiz scenario")
+
+// QuizScenarios - 出題できるシナリオの一覧（出題順）
+func QuizScenarios() []QuizScenario {
+	return append([]QuizScenario(nil), quizScenarios...)
+}
+
+// FindQuizScenario - IDで出題するシナリオを探す
+func FindQuizScenario(id string) (QuizScenario, error) {
+	ids := make([]string, len(quizScenarios))
+	for i, sc := range quizScenarios {
+		if sc.ID == id {
+			return sc, nil
+		}
+		ids[i] = sc.ID
+	}
+	return QuizScenario{}, fmt.Errorf("%w: %s (available: %s)", ErrUnknownQuizScenario, id, strings.Join(ids, ", "))
+}
+
+// QuizRun - 手法名を伏せた1回分の計測結果
+type QuizRun struct {
+	// Label - 出題時の呼び名（A, B, C...）
+	Label string
+	// MethodLabel - 答え合わせで明かす手法の説明
+	MethodLabel string
+	Result      PerformanceResult
+	NPlusOne    bool
+}
+
+// Quiz - 手法名を伏せて計測した1つのシナリオ
+type Quiz struct {
+	Scenario QuizScenario
+	// Runs - 呼び名の順（手法の並びは無作為）
+	Runs []QuizRun
+}
+
+// Labels - 回答の選択肢
+func (q *Quiz) Labels() []string {
+	labels := make([]string, len(q.Runs))
+	for i, run := range q.Runs {
+		labels[i] = run.Label
+	}
+	return labels
+}
+
+// Check - 回答（呼び名）がN+1の手法か判定
+func (q *Quiz) Check(label string) (bool, error) {
+	for _, run := range q.Runs {
+		if strings.EqualFold(run.Label, label) {
+			return run.NPlusOne, nil
+		}
+	}
+	return false, fmt.Errorf("unknown answer: %s", label)
+}
+
+// Answer - 正解の計測結果
+func (q *Quiz) Answer() QuizRun {
+	for _, run := range q.Runs {
+		if run.NPlusOne {
+			return run
+		}
+	}
+	return QuizRun{}
+}
+
+// RunQuiz - シナリオの手法を無作為な順に並べて呼び名を付け、手法名を表示せずに計測
+//
+// 答え合わせの手掛かりになるよう、-session-stats の指定にかかわらずセッション統計を取得する。
+// SetShufflerで並べ替えを指定していない場合は実行時刻から並べ替える。
+func (s *DemoService) RunQuiz(id string, days int) (*Quiz, error) {
+	sc, err := FindQuizScenario(id)
+	if err != nil {
+		return nil, err
+	}
+	strategies, err := s.quizStrategies(sc, days)
+	if err != nil {
+		return nil, err
+	}
+
+	shuffler := s.shuffler
+	if shuffler == nil {
+		shuffler = NewShuffler(uint64(time.Now().UnixNano()))
+	}
+
+	quiz := &Quiz{Scenario: sc}
+	for position, i := range shuffler.Perm(len(strategies)) {
+		st := strategies[i]
+		st.sessionStats = true
+		label := quizLabel(position)
+		fmt.Printf("手法 %s を実行中...\n", label)
+
+		if err := s.resetBefore(position); err != nil {
+			return nil, fmt.Errorf("手法 %s の前のリセットでエラー: %w", label, err)
+		}
+		result, err := s.measureStrategy(sc.ID, st, position)
+		if err != nil {
+			return nil, err
+		}
+		s.recordResult(result)
+		quiz.Runs = append(quiz.Runs, QuizRun{
+			Label:       label,
+			MethodLabel: st.label,
+			Result:      result,
+			NPlusOne:    st.method == sc.NPlusOne,
+		})
+	}
+	return quiz, nil
+}
+
+// quizStrategies - 出題するシナリオの比較対象の手法
+func (s *DemoService) quizStrategies(sc QuizScenario, days int) ([]strategy, error) {
+	all := s.scenarioStrategies(sc.ID, days)
+	strategies := make([]strategy, 0, len(sc.Methods))
+	for _, method := range sc.Methods {
+		st, ok := findStrategy(all, method)
+		if !ok {
+			return nil, fmt.Errorf("quiz scenario %s refers to unknown method %s", sc.ID, method)
+		}
+		strategies = append(strategies, st)
+	}
+	return strategies, nil
+}
+
+// quizLabel - 出題時の呼び名（0 → A）
+func quizLabel(i int) string {
+	return string(rune('A' + i))
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestQuizScenariosResolve(t *testing.T) {
+	s := NewDemoService(nil)
+	for _, sc := range QuizScenarios() {
+		if !slices.Contains(sc.Methods, sc.NPlusOne) {
+			t.Errorf("quiz scenario %s: NPlusOne %s is not in Methods", sc.ID, sc.NPlusOne)
+		}
+		if _, err := s.quizStrategies(sc, 30); err != nil {
+			t.Errorf("quizStrategies(%s) error = %v", sc.ID, err)
+		}
+	}
+	if _, err := FindQuizScenario("lob"); !errors.Is(err, ErrUnknownQuizScenario) {
+		t.Errorf("FindQuizScenario(lob) error = %v, want %v", err, ErrUnknownQuizScenario)
+	}
+}
+
+func TestQuizCheck(t *testing.T) {
+	quiz := &Quiz{Runs: []QuizRun{
+		{Label: "A", MethodLabel: "JOIN"},
+		{Label: "B", MethodLabel: "N+1", NPlusOne: true},
+		{Label: "C", MethodLabel: "Batch"},
+	}}
+	tests := []struct {
+		answer  string
+		want    bool
+		wantErr bool
+	}{
+		{answer: "A", want: false},
+		{answer: "B", want: true},
+		{answer: "b", want: true},
+		{answer: "D", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := quiz.Check(tt.answer)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Check(%q) error = %v, wantErr %v", tt.answer, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("Check(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+	}
+	if got := quiz.Answer().Label; got != "B" {
+		t.Errorf("Answer() = %s, want B", got)
+	}
+	if got := quiz.Labels(); !slices.Equal(got, []string{"A", "B", "C"}) {
+		t.Errorf("Labels() = %v", got)
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/rac"
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// pinnableScenarios - -rac-pin でインスタンスを固定できるシナリオ（手法ごとに1つのセッションで計測するもの）
+var pinnableScenarios = []string{
+	ScenarioOrders, ScenarioEmployees, ScenarioEmployeeProjects, ScenarioMonthlySales, ScenarioTopCustomers,
+	ScenarioWindowFunctions, ScenarioLOB, ScenarioCompositeFetch, ScenarioColumnPruning, ScenarioPagination,
+	ScenarioSoftDelete,
+}
+
+// ValidateRACPins - インスタンスを固定するシナリオ名を検証
+func ValidateRACPins(pins map[string]string) error {
+	for scenario := range pins {
+		valid := false
+		for _, s := range pinnableScenarios {
+			if scenario == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unknown scenario %q (%s)", scenario, strings.Join(pinnableScenarios, ", "))
+		}
+	}
+	return nil
+}
+
+// EnableRAC - 手法ごとに接続先インスタンスとClusterクラスの待機（gc ...）を記録する
+//
+// pinsにはシナリオごとに、インスタンスを固定した接続プールを指定する（固定しないシナリオは通常の接続プール）。
+// 記録のため、計測中はすべてのSQLを同じセッションで実行する。
+func (s *DemoService) EnableRAC(pins map[string]*sql.DB) {
+	s.rac = true
+	s.racPins = pins
+}
+
+// sessionDB - シナリオの計測で接続を確保する接続プール
+func (s *DemoService) sessionDB(scenario string) (*sql.DB, bool) {
+	if db, ok := s.racPins[scenario]; ok {
+		return db, true
+	}
+	return s.db, false
+}
+
+// racProbe - 計測中のセッションのインスタンスと待機の起点
+type racProbe struct {
+	db     repository.DBTX
+	before rac.Waits
+	result rac.Measurement
+}
+
+// beginRAC - 計測の直前にセッションのインスタンスと待機の累計を取得する
+func (s *DemoService) beginRAC(session *pinnedSession) *racProbe {
+	db := repository.NewConnDB(session.conn)
+	instance, err := rac.Current(db)
+	if err != nil {
+		fmt.Printf("   接続先インスタンスを取得できません: %v\n", err)
+		return nil
+	}
+	probe := &racProbe{db: db, result: rac.Measurement{Instance: instance, Pinned: session.pinned}}
+	if s.racWaitsUnavailable {
+		probe.result.WaitsUnavailable = true
+		return probe
+	}
+	if probe.before, err = rac.SessionWaits(db); err != nil {
+		fmt.Printf("   gc待機イベントを取得できないため、接続先インスタンスのみ記録します: %v\n", err)
+		s.racWaitsUnavailable = true
+		probe.result.WaitsUnavailable = true
+	}
+	return probe
+}
+
+// end - 計測後の待機の差分を結果に付与する
+func (p *racProbe) end(result *PerformanceResult) {
+	if !p.result.WaitsUnavailable {
+		after, err := rac.SessionWaits(p.db)
+		if err != nil {
+			fmt.Printf("   gc待機イベントの取得に失敗しました: %v\n", err)
+			p.result.WaitsUnavailable = true
+		} else {
+			p.result.GCWaits = after.Sub(p.before)
+		}
+	}
+	measurement := p.result
+	result.RAC = &measurement
+
+	line := fmt.Sprintf("   インスタンス: %s", measurement.Instance)
+	if measurement.Pinned {
+		line += "（固定）"
+	}
+	if !measurement.WaitsUnavailable {
+		line += fmt.Sprintf(", gc待機: %d回 / %v", measurement.GCWaitCount(), measurement.GCWaitTime().Round(time.Microsecond))
+		if len(measurement.GCWaits) > 0 {
+			top := measurement.GCWaits[0]
+			line += fmt.Sprintf("（最大: %s %d回）", top.Event, top.Waits)
+		}
+	}
+	fmt.Println(line)
+}
+
+// displayRACComparison - 手法ごとの接続先インスタンスとgc待機を一覧表示
+//
+// N+1のループはブロックを1つずつ読むため、他のインスタンスが持つブロックの転送（gc cr/current block）を
+// SQLの実行回数に比例して待つ。一括取得はマルチブロックの読み取りやキャッシュ済みのブロックでまとめて済む。
+func displayRACComparison(results []PerformanceResult) {
+	if len(results) == 0 || results[0].RAC == nil {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading("RAC: 接続先インスタンスとgc待機")
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "instance", Header: "インスタンス"},
+		report.Column{Key: "gc_waits", Header: "gc待機", Align: report.AlignRight},
+		report.Column{Key: "gc_time", Header: "gc待機時間", Align: report.AlignRight},
+		report.Column{Key: "gc_share", Header: "実行時間比", Align: report.AlignRight},
+	)
+	instances := make(map[string]bool)
+	for _, result := range results {
+		m := result.RAC
+		if m == nil {
+			continue
+		}
+		instances[m.Instance.Name] = true
+		if m.WaitsUnavailable {
+			table.AddRow(report.Text(result.Method), report.Text(m.Instance.Name), report.Text("-"), report.Text("-"), report.Text("-"))
+			continue
+		}
+		share := 0.0
+		if result.ExecutionTime > 0 {
+			share = float64(m.GCWaitTime()) / float64(result.ExecutionTime) * 100
+		}
+		table.AddRow(
+			report.Text(result.Method),
+			report.Text(m.Instance.Name),
+			report.Int(m.GCWaitCount()),
+			report.Duration(m.GCWaitTime().Round(time.Microsecond)),
+			report.Number(fmt.Sprintf("%.1f%%", share), share))
+	}
+	w.Table(table)
+
+	if len(instances) > 1 {
+		names := make([]string, 0, len(instances))
+		for name := range instances {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w.Linef("手法によって接続先のインスタンスが異なります（%s）。バッファキャッシュの状態が揃わないため、-rac-pin で固定して比較してください", strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/cache"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// mixCacheTTL - 読み書き混在ワークロードでのRedisキャッシュの有効期限（計測中に切れない長さ）
+const mixCacheTTL = 5 * time.Minute
+
+// mixKeyPattern - 読み書き混在ワークロードのキャッシュキー（計測前にまとめて削除する）
+const mixKeyPattern = "rwmix:customer:*"
+
+// TestReadWriteMix - 読み書き混在ワークロードで各キャッシュ手法の実効ヒット率と整合性を測定
+//
+// 包括的性能分析を実行済みの場合は、その結果（AnalysisResults.ReadWriteMix）にも記録する。
+func (c *CacheService) TestReadWriteMix(cfg cache.ReadWriteMixConfig) (*cache.ReadWriteMixResult, error) {
+	strategies := []cache.MixStrategy{cache.NewResultCacheMixStrategy(c.db)}
+	if c.redisClient != nil {
+		strategies = append(strategies,
+			&redisMixStrategy{service: c, invalidate: true},
+			&redisMixStrategy{service: c, invalidate: false},
+			newTwoTierMixStrategy(c))
+	}
+
+	cfg.Clock = c.clock
+	result, err := cache.RunReadWriteMix(c.db, cfg, strategies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run read/write mix: %w", err)
+	}
+	if c.analysis != nil {
+		c.analysis.ReadWriteMix = result
+	}
+	return result, nil
+}
+
+// redisMixStrategy - Redisのキャッシュアサイド（invalidate=falseは書き込み時に削除せずTTLに任せる）
+type redisMixStrategy struct {
+	service    *CacheService
+	invalidate bool
+}
+
+// Name - 手法名
+func (s *redisMixStrategy) Name() string {
+	if s.invalidate {
+		return "Redis_Invalidate_On_Write"
+	}
+	return "Redis_TTL_Only"
+}
+
+// Description - 手法の説明
+func (s *redisMixStrategy) Description() string {
+	if s.invalidate {
+		return "Redisキャッシュアサイド（書き込み時にキーを削除）"
+	}
+	return fmt.Sprintf("Redisキャッシュアサイド（無効化なし、TTL %v）", mixCacheTTL)
+}
+
+// Prepare - 前の手法が残したキーを削除
+func (s *redisMixStrategy) Prepare() error {
+	return deleteMixKeys(s.service.redisClient)
+}
+
+// deleteMixKeys - 読み書き混在ワークロードのRedisキーをすべて削除
+func deleteMixKeys(client *redis.Client) error {
+	ctx := context.Background()
+	iter := client.Scan(ctx, 0, mixKeyPattern, 100).Iterator()
+	for iter.Next(ctx) {
+		if err := client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// Read - Redisになければ集計クエリで取得して保存
+func (s *redisMixStrategy) Read(customerID int64) (cache.MixValue, bool, error) {
+	ctx := context.Background()
+	client := s.service.redisClient
+	key := mixCacheKey(customerID)
+
+	cached, err := client.Get(ctx, key).Result()
+	switch {
+	case err == nil:
+		var value cache.MixValue
+		if err := json.Unmarshal([]byte(cached), &value); err == nil {
+			return value, true, nil
+		}
+	case !errors.Is(err, redis.Nil):
+		return cache.MixValue{}, false, fmt.Errorf("failed to get cached totals: %w", err)
+	}
+
+	value, err := cache.QueryCustomerTotals(s.service.db, customerID, "/*+ NO_RESULT_CACHE */")
+	if err != nil {
+		return cache.MixValue{}, false, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return cache.MixValue{}, false, fmt.Errorf("failed to marshal totals: %w", err)
+	}
+	if err := client.Set(ctx, key, data, mixCacheTTL).Err(); err != nil {
+		return cache.MixValue{}, false, fmt.Errorf("failed to cache totals: %w", err)
+	}
+	return value, false, nil
+}
+
+// Written - invalidate=trueの場合は更新した顧客のキーだけを削除
+func (s *redisMixStrategy) Written(customerID int64) error {
+	if !s.invalidate {
+		return nil
+	}
+	return s.service.redisClient.Del(context.Background(), mixCacheKey(customerID)).Err()
+}
+
+// HitEstimated - Redisのヒットは直接観測できる
+func (s *redisMixStrategy) HitEstimated() bool { return false }
+
+// mixCacheKey - 読み書き混在ワークロードのキャッシュキー
+func mixCacheKey(customerID int64) string {
+	return fmt.Sprintf("rwmix:customer:%d", customerID)
+}
+
+// twoTierMixInstances - 2層キャッシュの手法で交互に読み取るアプリのインスタンス数
+const twoTierMixInstances = 2
+
+// twoTierMixStrategy - 2層キャッシュ（ローカルLRU + Redis）を使う複数のインスタンス
+//
+// 読み取りはインスタンスが交互に行い、書き込みは先頭のインスタンスが行う。書き込み時に削除できるのは
+// 書き込んだインスタンスのローカルの層とRedisだけで、ほかのインスタンスのローカルの層はTTLが切れるまで古い値を返す。
+type twoTierMixStrategy struct {
+	service   *CacheService
+	instances []*twoTierCache
+	next      int
+}
+
+// newTwoTierMixStrategy - Redisを共有するtwoTierMixInstances個のインスタンス
+func newTwoTierMixStrategy(c *CacheService) *twoTierMixStrategy {
+	s := &twoTierMixStrategy{service: c}
+	for range twoTierMixInstances {
+		s.instances = append(s.instances, newTwoTierCache(redisStore{client: c.redisClient}, c.clock, mixCacheTTL))
+	}
+	return s
+}
+
+// Name - 手法名
+func (s *twoTierMixStrategy) Name() string { return "Redis_TwoTier_Invalidate_On_Write" }
+
+// Description - 手法の説明
+func (s *twoTierMixStrategy) Description() string {
+	return fmt.Sprintf("2層キャッシュ（ローカルLRU + Redis、%dインスタンスで交互に読み取り、書き込んだインスタンスのローカルとRedisのキーを削除、ローカルのTTL %v）",
+		len(s.instances), twoTierLocalTTL)
+}
+
+// Prepare - Redisのキーとすべてのインスタンスのローカルの層を空にする
+func (s *twoTierMixStrategy) Prepare() error {
+	for _, instance := range s.instances {
+		instance.reset()
+	}
+	s.next = 0
+	return deleteMixKeys(s.service.redisClient)
+}
+
+// Read - 次のインスタンスの2層キャッシュから取得（どちらの層にもなければ集計クエリで取得して保存）
+func (s *twoTierMixStrategy) Read(customerID int64) (cache.MixValue, bool, error) {
+	instance := s.instances[s.next]
+	s.next = (s.next + 1) % len(s.instances)
+
+	data, tier, err := instance.get(context.Background(), mixCacheKey(customerID), func() ([]byte, error) {
+		value, err := cache.QueryCustomerTotals(s.service.db, customerID, "/*+ NO_RESULT_CACHE */")
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(value)
+	})
+	if err != nil {
+		return cache.MixValue{}, false, err
+	}
+	var value cache.MixValue
+	if err := json.Unmarshal(data, &value); err != nil {
+		return cache.MixValue{}, false, fmt.Errorf("failed to unmarshal totals: %w", err)
+	}
+	return value, tier != TierDatabase, nil
+}
+
+// Written - 書き込んだ（先頭の）インスタンスのローカルの層とRedisからキーを削除
+func (s *twoTierMixStrategy) Written(customerID int64) error {
+	return s.instances[0].invalidate(context.Background(), mixCacheKey(customerID))
+}
+
+// HitEstimated - 2層キャッシュのヒットは直接観測できる
+func (s *twoTierMixStrategy) HitEstimated() bool { return false }
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"oracle-n-plus-1-demo/internal/cachekey"
+)
+
+// RedisDemoKeyPatterns - デモが作成するRedisキー（MEMORY USAGEでキーごとの使用量を取得する対象、cleanupコマンドで削除する対象）
+var RedisDemoKeyPatterns = []string{
+	redisOrdersCacheKey,
+	"customer_summary:*",
+	"rwmix:customer:*",
+	cachekey.BenchNamespace + ":*",
+}
+
+// redisMemoryKeyLimit - キーごとの使用量を取得するキー数の上限（キーが多い環境での負荷を抑える）
+const redisMemoryKeyLimit = 1000
+
+// RedisMemory - 外部キャッシュテスト前後のRedisのメモリ使用状況
+type RedisMemory struct {
+	Before *RedisMemorySnapshot `json:"before,omitempty"`
+	After  *RedisMemorySnapshot `json:"after,omitempty"`
+	// Keys - デモが作成したキーごとの使用量（MEMORY USAGE）
+	Keys []RedisKeyMemory `json:"keys,omitempty"`
+	// KeysTruncated - キー数が上限を超えたため一部のキーのみ取得したか
+	KeysTruncated bool `json:"keys_truncated,omitempty"`
+}
+
+// RedisMemorySnapshot - ある時点のRedisの使用メモリとキー数
+type RedisMemorySnapshot struct {
+	// UsedMemory - INFO memory の used_memory（バイト）
+	UsedMemory      int64  `json:"used_memory_bytes"`
+	UsedMemoryHuman string `json:"used_memory_human"`
+	// Keys - 選択中のDBのキー数（DBSIZE）
+	Keys int64 `json:"keys"`
+}
+
+// RedisKeyMemory - キーごとの使用量
+type RedisKeyMemory struct {
+	Key   string `json:"key"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Delta - テスト前後の使用メモリの増分（前後どちらかが取得できなければfalse）
+func (m *RedisMemory) Delta() (int64, bool) {
+	if m.Before == nil || m.After == nil {
+		return 0, false
+	}
+	return m.After.UsedMemory - m.Before.UsedMemory, true
+}
+
+// KeyBytes - デモのキーの使用量の合計
+func (m *RedisMemory) KeyBytes() int64 {
+	var total int64
+	for _, k := range m.Keys {
+		total += k.Bytes
+	}
+	return total
+}
+
+// CacheBytes - キャッシュが使うメモリ（キーごとの合計、取得できなければ前後の増分）
+func (m *RedisMemory) CacheBytes() int64 {
+	if len(m.Keys) > 0 {
+		return m.KeyBytes()
+	}
+	if delta, ok := m.Delta(); ok && delta > 0 {
+		return delta
+	}
+	return 0
+}
+
+// redisMemorySnapshot - INFO memory と DBSIZE から使用メモリとキー数を取得
+func (c *CacheService) redisMemorySnapshot() (*RedisMemorySnapshot, error) {
+	ctx := context.Background()
+	info, err := c.redisClient.Info(ctx, "memory").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redis memory info: %w", err)
+	}
+
+	snapshot := &RedisMemorySnapshot{}
+	for _, line := range strings.Split(info, "\n") {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "used_memory":
+			if snapshot.UsedMemory, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("failed to parse used_memory %q: %w", value, err)
+			}
+		case "used_memory_human":
+			snapshot.UsedMemoryHuman = value
+		}
+	}
+
+	if snapshot.Keys, err = c.redisClient.DBSize(ctx).Result(); err != nil {
+		return nil, fmt.Errorf("failed to get redis key count: %w", err)
+	}
+	return snapshot, nil
+}
+
+// redisKeyMemory - デモのキーごとの使用量を MEMORY USAGE で取得（上限を超えた場合はtruncated=true）
+func (c *CacheService) redisKeyMemory() (keys []RedisKeyMemory, truncated bool, err error) {
+	ctx := context.Background()
+	for _, pattern := range RedisDemoKeyPatterns {
+		iter := c.redisClient.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			if len(keys) >= redisMemoryKeyLimit {
+				return keys, true, nil
+			}
+			key := iter.Val()
+			bytes, err := c.redisClient.MemoryUsage(ctx, key).Result()
+			if err != nil {
+				return keys, false, fmt.Errorf("failed to get memory usage of %s: %w", key, err)
+			}
+			keys = append(keys, RedisKeyMemory{Key: key, Bytes: bytes})
+		}
+		if err := iter.Err(); err != nil {
+			return keys, false, fmt.Errorf("failed to scan redis keys: %w", err)
+		}
+	}
+	return keys, false, nil
+}
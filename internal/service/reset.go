@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ResetScope - リセットを行う単位
+type ResetScope string
+
+const (
+	// ResetPerMethod - 手法ごとに計測の前でリセットする
+	ResetPerMethod ResetScope = "method"
+	// ResetPerScenario - シナリオの最初の手法の前でだけリセットする
+	ResetPerScenario ResetScope = "scenario"
+)
+
+// ResetPolicy - 計測の前に行うリセット
+//
+// 前の手法・シナリオで温まったキャッシュやセッションの状態が後の計測に持ち越されないようにする
+// （例: JOINの手法で読み込んだブロックがバッファキャッシュに残り、続くバッチ取得の手法が速く見える）。
+type ResetPolicy struct {
+	Scope            ResetScope    `json:"scope"`
+	FlushResultCache bool          `json:"flush_result_cache,omitempty"`
+	FlushBufferCache bool          `json:"flush_buffer_cache,omitempty"`
+	Reconnect        bool          `json:"reconnect,omitempty"`
+	Sleep            time.Duration `json:"sleep,omitempty"`
+	// SessionStatements - 計測する接続で実行するALTER SESSION文（指定時は手法ごとに接続を固定する）
+	SessionStatements []string `json:"session_statements,omitempty"`
+}
+
+// ResetKinds - -reset に指定できるリセットの種類
+var ResetKinds = []string{"result-cache", "buffer-cache", "reconnect"}
+
+// ParseResetPolicy - リセットの指定（種類はカンマ区切り、ALTER SESSION文はセミコロン区切り）を解釈
+func ParseResetPolicy(kinds, scope string, sleep time.Duration, session string) (ResetPolicy, error) {
+	policy := ResetPolicy{Scope: ResetScope(scope), Sleep: sleep}
+
+	switch policy.Scope {
+	case ResetPerMethod, ResetPerScenario:
+	default:
+		return policy, fmt.Errorf("unknown reset scope: %q (%s, %s)", scope, ResetPerMethod, ResetPerScenario)
+	}
+	if sleep < 0 {
+		return policy, fmt.Errorf("reset sleep must not be negative: %v", sleep)
+	}
+
+	for _, kind := range strings.Split(kinds, ",") {
+		switch strings.TrimSpace(kind) {
+		case "":
+		case "result-cache":
+			policy.FlushResultCache = true
+		case "buffer-cache":
+			policy.FlushBufferCache = true
+		case "reconnect":
+			policy.Reconnect = true
+		default:
+			return policy, fmt.Errorf("unknown reset kind: %q (%s)", kind, strings.Join(ResetKinds, ", "))
+		}
+	}
+
+	for _, statement := range strings.Split(session, ";") {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		// 計測対象の状態を変えるのはセッション単位の設定に限る
+		if fields := strings.Fields(strings.ToUpper(statement)); len(fields) < 2 || fields[0] != "ALTER" || fields[1] != "SESSION" {
+			return policy, fmt.Errorf("session reset must be an ALTER SESSION statement: %q", statement)
+		}
+		policy.SessionStatements = append(policy.SessionStatements, statement)
+	}
+
+	return policy, nil
+}
+
+// Enabled - いずれかのリセットが指定されているか
+func (p ResetPolicy) Enabled() bool {
+	return p.FlushResultCache || p.FlushBufferCache || p.Reconnect || p.Sleep > 0 || len(p.SessionStatements) > 0
+}
+
+// dueBefore - index番目の手法の前にインスタンス・接続プールのリセットを行うか
+func (p ResetPolicy) dueBefore(index int) bool {
+	return p.Scope == ResetPerMethod || index == 0
+}
+
+// SetResetPolicy - 計測の前に行うリセットを設定
+func (s *DemoService) SetResetPolicy(policy ResetPolicy) {
+	s.reset = policy
+}
+
+// resetBefore - 手法の計測前にキャッシュのフラッシュ・再接続・待機を行う
+//
+// ALTER SESSION文は計測する接続が決まってから applySessionReset で実行する。
+func (s *DemoService) resetBefore(index int) error {
+	if !s.reset.dueBefore(index) {
+		return nil
+	}
+
+	var done []string
+	if s.reset.FlushResultCache {
+		if _, err := s.db.Exec("BEGIN DBMS_RESULT_CACHE.FLUSH; END;"); err != nil {
+			return fmt.Errorf("failed to flush result cache: %w", err)
+		}
+		done = append(done, "Result Cacheのフラッシュ")
+	}
+	if s.reset.FlushBufferCache {
+		if _, err := s.db.Exec("ALTER SYSTEM FLUSH BUFFER_CACHE"); err != nil {
+			return fmt.Errorf("failed to flush buffer cache: %w", err)
+		}
+		done = append(done, "バッファキャッシュのフラッシュ")
+	}
+	if s.reset.Reconnect {
+		closed, err := s.discardIdleConnections()
+		if err != nil {
+			return err
+		}
+		done = append(done, fmt.Sprintf("再接続（待機中の接続%d本を切断）", closed))
+	}
+	if s.reset.Sleep > 0 {
+		time.Sleep(s.reset.Sleep)
+		done = append(done, fmt.Sprintf("%v待機", s.reset.Sleep))
+	}
+
+	if len(done) > 0 {
+		fmt.Printf("   リセット: %s\n", strings.Join(done, ", "))
+	}
+	return nil
+}
+
+// applySessionReset - 計測に使う固定した接続でALTER SESSION文を実行
+func (s *DemoService) applySessionReset(session *pinnedSession) error {
+	for _, statement := range s.reset.SessionStatements {
+		if _, err := session.conn.ExecContext(context.Background(), statement); err != nil {
+			return fmt.Errorf("failed to execute %q: %w", statement, err)
+		}
+	}
+	return nil
+}
+
+// discardIdleConnections - 接続プールで待機中の接続を切断し、以降のSQLを新しいセッションで実行させる
+func (s *DemoService) discardIdleConnections() (int, error) {
+	idle := s.db.Stats().Idle
+	conns := make([]*sql.Conn, 0, idle)
+	defer func() {
+		for _, conn := range conns {
+			// 切断済みの接続はErrConnDoneを返すため無視する
+			if err := conn.Close(); err != nil && !errors.Is(err, sql.ErrConnDone) {
+				fmt.Printf("conn.Close() failed: %v\n", err)
+			}
+		}
+	}()
+
+	// 待機中の接続は新しい接続より先に払い出されるため、待機数だけ取り出せばすべて手元に来る
+	for i := 0; i < idle; i++ {
+		conn, err := s.db.Conn(context.Background())
+		if err != nil {
+			return 0, fmt.Errorf("failed to acquire connection: %w", err)
+		}
+		conns = append(conns, conn)
+	}
+
+	for _, conn := range conns {
+		// driver.ErrBadConnを返すと、database/sqlはその接続をプールへ戻さずに閉じる
+		err := conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		if err != nil && !errors.Is(err, driver.ErrBadConn) {
+			return 0, fmt.Errorf("failed to discard connection: %w", err)
+		}
+	}
+	return len(conns), nil
+}
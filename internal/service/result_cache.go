@@ -0,0 +1,76 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"oracle-n-plus-1-demo/internal/resultcache"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// EnableGoResultCache - 月次売上レポートの比較に、プロセス内の結果キャッシュを通したGROUP BY（Go_Result_Cache）を加える
+//
+// dbはcacheで包んだ専用の接続プール（ほかの手法の問い合わせはキャッシュしない）。
+// キャッシュの統計はサービスをまたいで共有されるため、Forkしたサービスには引き継がない。
+func (s *DemoService) EnableGoResultCache(db *sql.DB, cache *resultcache.Cache) {
+	s.goResultCache = cache
+	s.goResultCacheRepo = repository.NewOptimizedOrderRepository(db)
+	s.applyLimits()
+}
+
+// goResultCacheStrategy - プロセス内の結果キャッシュを通したGROUP BYの手法（有効でない場合はok=false）
+func (s *DemoService) goResultCacheStrategy(months int) (st strategy, ok bool) {
+	if s.goResultCache == nil {
+		return strategy{}, false
+	}
+	var before resultcache.Stats
+	return strategy{
+		method:      "Go_Result_Cache",
+		label:       "プロセス内結果キャッシュ付きGROUP BYアプローチ",
+		description: fmt.Sprintf("GROUP BYの結果をドライバーのラッパーでプロセス内にキャッシュ（TTL %s、ほかのセッションの更新は期限まで反映されない）", s.goResultCache.TTL()),
+		// SQL_ResultCacheと同じく、計測前に1回実行してキャッシュを作成しておく
+		setup: func() error {
+			s.goResultCache.Purge()
+			_, err := s.goResultCacheRepo.GetMonthlySalesGroupBy(months)
+			before = s.goResultCache.Stats()
+			return err
+		},
+		run: func() (int, error) { return s.encodeResponse(s.goResultCacheRepo.GetMonthlySalesGroupBy(months)) },
+		after: func(result *PerformanceResult) {
+			stats := s.goResultCache.Stats().Sub(before)
+			result.GoResultCache = &stats
+			fmt.Printf("   プロセス内キャッシュ: ヒット %d / ミス %d（期限切れ %d）、%dエントリ %d行\n",
+				stats.Hits, stats.Misses, stats.Expired, stats.Entries, stats.Rows)
+		},
+	}, true
+}
+
+// checkGoResultCache - キャッシュした集計とDBの最新の集計を比べ、キャッシュが古い結果を返していないかを表示
+//
+// OracleのResult Cacheは依存する表へのDMLのコミットで無効化されるが、プロセス内のキャッシュはTTLまで古い結果を返す。
+// また月次売上の問い合わせはSYSDATEを含むため、月が変わってもキーは同じまま前月基準の集計を返しうる。
+func (s *DemoService) checkGoResultCache(months int) {
+	if s.goResultCache == nil {
+		return
+	}
+	cached, err := s.goResultCacheRepo.GetMonthlySalesGroupBy(months)
+	if err != nil {
+		fmt.Printf("プロセス内キャッシュの整合性を確認できません: %v\n", err)
+		return
+	}
+	fresh, err := s.optimizedRepo.GetMonthlySalesGroupBy(months)
+	if err != nil {
+		fmt.Printf("プロセス内キャッシュの整合性を確認できません: %v\n", err)
+		return
+	}
+
+	fmt.Println("\nプロセス内結果キャッシュの整合性:")
+	if reflect.DeepEqual(cached, fresh) {
+		fmt.Printf("  キャッシュの結果はDBの最新の集計と一致しています（ただし、ほかのセッションの更新は最大 %s 反映されません）\n", s.goResultCache.TTL())
+	} else {
+		fmt.Printf("  キャッシュの結果がDBの最新の集計と異なります（%d行 / DB %d行）。計測中にコミットされた更新がTTLまで反映されません\n", len(cached), len(fresh))
+	}
+	fmt.Println("  OracleのResult Cacheは依存する表へのDMLのコミットで無効化され、SYSDATEを含む問い合わせはキャッシュしない")
+	fmt.Println("  プロセス内のキャッシュはインスタンスごとに別々に古くなり、キーにNLS設定やSYSDATEの値を含まない")
+}
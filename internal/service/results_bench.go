@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+
+	"oracle-n-plus-1-demo/internal/fileutil"
+	"oracle-n-plus-1-demo/internal/runmeta"
+)
+
+// benchmarkPackage - ベンチマーク形式の pkg 行に書くモジュール名
+const benchmarkPackage = "oracle-n-plus-1-demo"
+
+// WriteBenchmarkFormat - 計測結果をGoのベンチマーク形式（go test -bench の出力）で書き出す
+//
+// benchstatで実行どうしを比べられるよう、手法ごとに BenchmarkOrders/JOIN_Optimized-8 のような名前で1行ずつ出力する。
+// 複数回計測した場合は各回を1行（反復回数1）として出力し、benchstatが標本として扱う。
+// メモリ割り当ては手法ごとに最後の回しか記録していないため、B/op・allocs/op は最後の回の行にだけ付ける。
+func WriteBenchmarkFormat(w io.Writer, report *ResultsReport) error {
+	var buf bytes.Buffer
+	goos, goarch, procs := runtime.GOOS, runtime.GOARCH, runtime.GOMAXPROCS(0)
+	if m := report.Metadata; m != nil {
+		goos, goarch, procs = m.OS, m.Arch, m.GOMAXPROCS
+	}
+	fmt.Fprintf(&buf, "goos: %s\ngoarch: %s\npkg: %s\n", goos, goarch, benchmarkPackage)
+	for _, kv := range benchmarkConfig(report.Metadata, report.Parameters) {
+		fmt.Fprintf(&buf, "%s: %s\n", kv[0], kv[1])
+	}
+
+	suffix := ""
+	if procs > 1 {
+		suffix = fmt.Sprintf("-%d", procs)
+	}
+	for _, r := range report.Results {
+		name := benchmarkName(r.Scenario, r.Method) + suffix
+		samples := r.Samples
+		if len(samples) == 0 {
+			samples = []time.Duration{r.ExecutionTime}
+		}
+		for i, d := range samples {
+			fmt.Fprintf(&buf, "%s\t%8d\t%12d ns/op", name, 1, d.Nanoseconds())
+			if i == len(samples)-1 {
+				fmt.Fprintf(&buf, "\t%10d B/op\t%8d allocs/op", r.AllocBytes, r.Allocs)
+			}
+			fmt.Fprintf(&buf, "\t%8d rows/op\n", r.RecordCount)
+		}
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write benchmark results: %w", err)
+	}
+	return nil
+}
+
+// ExportBenchmarkResults - これまでに完了した手法の結果をGoのベンチマーク形式でファイルに出力
+func (s *DemoService) ExportBenchmarkResults(path string, meta *runmeta.Metadata, params RunParameters) error {
+	var buf bytes.Buffer
+	if err := WriteBenchmarkFormat(&buf, s.BuildResultsReport(meta, params)); err != nil {
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("結果ファイルの書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// benchmarkConfig - ベンチマーク形式の設定行（benchstatが実行の条件として表示・比較する）
+func benchmarkConfig(meta *runmeta.Metadata, params RunParameters) [][2]string {
+	var config [][2]string
+	add := func(key, value string) {
+		if value != "" {
+			config = append(config, [2]string{key, value})
+		}
+	}
+	if meta != nil {
+		add("environment", meta.Environment)
+		add("db-version", meta.DBVersion)
+		add("driver", meta.DriverVersion)
+		add("commit", meta.GitCommit)
+	}
+	add("days", fmt.Sprint(params.Days))
+	if params.MaxOrders > 0 {
+		add("max-orders", fmt.Sprint(params.MaxOrders))
+	}
+	if params.MaxEmployees > 0 {
+		add("max-employees", fmt.Sprint(params.MaxEmployees))
+	}
+	if params.Payload {
+		add("payload", "true")
+	}
+	return config
+}
+
+// benchmarkName - シナリオと手法からベンチマーク名を作る（employee_projects → BenchmarkEmployeeProjects/N+1_Problem）
+//
+// ベンチマーク名には空白を含められないため、空白は _ に置き換える。
+func benchmarkName(scenario, method string) string {
+	var b strings.Builder
+	b.WriteString("Benchmark")
+	upper := true
+	for _, r := range scenario {
+		switch {
+		case r == '_' || r == '-' || unicode.IsSpace(r):
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString("/")
+	b.WriteString(strings.Join(strings.Fields(method), "_"))
+	return b.String()
+}
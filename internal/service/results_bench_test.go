@@ -0,0 +1,49 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/runmeta"
+)
+
+func TestWriteBenchmarkFormat(t *testing.T) {
+	report := &ResultsReport{
+		Metadata:   &runmeta.Metadata{OS: "linux", Arch: "amd64", GOMAXPROCS: 8, Environment: "dev", DBVersion: "23.4.0.24.05"},
+		Parameters: RunParameters{Days: 30, MaxOrders: 200},
+		Results: []PerformanceResult{
+			{Scenario: ScenarioOrders, Method: "N+1_Problem", ExecutionTime: 40 * time.Millisecond, RecordCount: 500, AllocBytes: 2048, Allocs: 30},
+			{
+				Scenario: ScenarioEmployeeProjects, Method: "JOIN_Optimized", RecordCount: 12, AllocBytes: 512, Allocs: 8,
+				Samples: []time.Duration{3 * time.Millisecond, 2 * time.Millisecond},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBenchmarkFormat(&buf, report); err != nil {
+		t.Fatalf("WriteBenchmarkFormat() failed: %v", err)
+	}
+	want := "goos: linux\ngoarch: amd64\npkg: oracle-n-plus-1-demo\n" +
+		"environment: dev\ndb-version: 23.4.0.24.05\ndays: 30\nmax-orders: 200\n" +
+		"BenchmarkOrders/N+1_Problem-8\t       1\t    40000000 ns/op\t      2048 B/op\t      30 allocs/op\t     500 rows/op\n" +
+		"BenchmarkEmployeeProjects/JOIN_Optimized-8\t       1\t     3000000 ns/op\t      12 rows/op\n" +
+		"BenchmarkEmployeeProjects/JOIN_Optimized-8\t       1\t     2000000 ns/op\t       512 B/op\t       8 allocs/op\t      12 rows/op\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteBenchmarkFormat() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBenchmarkName(t *testing.T) {
+	tests := map[[2]string]string{
+		{"orders", "JOIN_Optimized"}:            "BenchmarkOrders/JOIN_Optimized",
+		{"window_functions", "Window Function"}: "BenchmarkWindowFunctions/Window_Function",
+		{"lob", "N+1_Problem"}:                  "BenchmarkLob/N+1_Problem",
+	}
+	for in, want := range tests {
+		if got := benchmarkName(in[0], in[1]); got != want {
+			t.Errorf("benchmarkName(%q, %q) = %q, want %q", in[0], in[1], got, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/fileutil"
+	"oracle-n-plus-1-demo/internal/runmeta"
+)
+
+// RunParameters - 結果に影響する実行パラメーター
+type RunParameters struct {
+	Days         int  `json:"days"`
+	Months       int  `json:"months"`
+	SessionStats bool `json:"session_stats"`
+	Payload      bool `json:"payload"`
+	// Iterations / Interleave - 手法ごとの計測回数と交互実行の有無（1回の場合は0）
+	Iterations int  `json:"iterations,omitempty"`
+	Interleave bool `json:"interleave,omitempty"`
+	// Repeat / Seed - 全体実行の繰り返し回数と実行順序を並べ替えた乱数シード（指定しなかった場合は0）
+	Repeat int    `json:"repeat,omitempty"`
+	Seed   uint64 `json:"seed,omitempty"`
+	// Reset - 計測の前に行ったリセット（指定しなかった場合はnil）
+	Reset *ResetPolicy `json:"reset,omitempty"`
+	// MaxOrders / MaxEmployees - 扱った受注・社員の件数の上限（指定しなかった場合は0）
+	MaxOrders    int `json:"max_orders,omitempty"`
+	MaxEmployees int `json:"max_employees,omitempty"`
+	// RACPins - インスタンスを固定したシナリオ（-rac-pin 指定時のみ、シナリオ → インスタンス名）
+	RACPins map[string]string `json:"rac_pins,omitempty"`
+	// Warmup - シナリオの計測前に行ったウォームアップ（指定しなかった場合はnil）
+	Warmup *WarmupPolicy `json:"warmup,omitempty"`
+}
+
+// ResultsReport - エクスポートする計測結果
+type ResultsReport struct {
+	// SchemaVersion - 結果のスキーマバージョン（ResultsSchemaVersion。古い結果は DecodeResultsReport で変換する）
+	SchemaVersion int                 `json:"schema_version"`
+	Metadata      *runmeta.Metadata   `json:"metadata"`
+	Parameters    RunParameters       `json:"parameters"`
+	Results       []PerformanceResult `json:"results"`
+	// Cost - 手法ごとの月額コストの見積もり（-cost-model 指定時のみ）
+	Cost *CostReport `json:"cost,omitempty"`
+	// Capacity - 想定リクエスト数で必要なDBリソースの見積もり（-capacity-rps 指定時のみ）
+	Capacity *CapacityReport `json:"capacity,omitempty"`
+}
+
+// BuildResultsReport - これまでに完了した手法の結果に実行メタデータを添付する
+func (s *DemoService) BuildResultsReport(meta *runmeta.Metadata, params RunParameters) *ResultsReport {
+	return &ResultsReport{
+		SchemaVersion: ResultsSchemaVersion,
+		Metadata:      meta,
+		Parameters:    params,
+		Results:       s.ResultsSince(0),
+		Cost:          s.CostReport(),
+		Capacity:      s.CapacityReport(),
+	}
+}
+
+// MarshalResults - これまでに完了した手法の結果を実行メタデータ付きのJSONに変換
+func (s *DemoService) MarshalResults(meta *runmeta.Metadata, params RunParameters) ([]byte, error) {
+	report := s.BuildResultsReport(meta, params)
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("JSON変換エラー: %w", err)
+	}
+
+	return jsonData, nil
+}
+
+// ExportResults - これまでに完了した手法の結果を実行メタデータ付きでJSONファイルに出力
+func (s *DemoService) ExportResults(path string, meta *runmeta.Metadata, params RunParameters) error {
+	jsonData, err := s.MarshalResults(meta, params)
+	if err != nil {
+		return err
+	}
+
+	if err := fileutil.WriteFileAtomic(path, jsonData, 0o644); err != nil {
+		return fmt.Errorf("結果ファイルの書き込みに失敗: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// 計測結果のスキーマバージョン
+//
+// 結果の構造体を互換性のない形で変えるときは ResultsSchemaVersion を上げ、
+// 1つ前のバージョンから変換する関数を resultsMigrations に追加する。
+const (
+	// legacyResultsSchemaVersion - schema_version を持たない結果（バージョン管理を始める前の出力）
+	legacyResultsSchemaVersion = 1
+	// ResultsSchemaVersion - 現在出力している結果のスキーマバージョン
+	ResultsSchemaVersion = 2
+)
+
+// ErrUnsupportedSchemaVersion - このツールより新しいスキーマで出力された結果
+var ErrUnsupportedSchemaVersion = errors.New("unsupported results schema version")
+
+// resultsDocument - 変換中の結果（トップレベルのフィールド名と値）
+type resultsDocument map[string]json.RawMessage
+
+// resultsMigrations - キーのバージョンから次のバージョンへ変換する関数
+var resultsMigrations = map[int]func(resultsDocument) error{
+	1: migrateResultsV1,
+}
+
+// DecodeResultsReport - 結果JSONを現在のスキーマに変換して読み込む
+//
+// 古いスキーマの結果は1バージョンずつ変換し、新しいスキーマの結果は ErrUnsupportedSchemaVersion を返す。
+// 変換前のバージョンは戻り値の2番目で返す（schema_version がない場合は1）。
+func DecodeResultsReport(data []byte) (*ResultsReport, int, error) {
+	var doc resultsDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse results: %w", err)
+	}
+
+	version, err := doc.version()
+	if err != nil {
+		return nil, 0, err
+	}
+	if version > ResultsSchemaVersion {
+		return nil, version, fmt.Errorf("%w: %d (supported up to %d)", ErrUnsupportedSchemaVersion, version, ResultsSchemaVersion)
+	}
+
+	for v := version; v < ResultsSchemaVersion; v++ {
+		migrate, ok := resultsMigrations[v]
+		if !ok {
+			return nil, version, fmt.Errorf("%w: no migration from version %d", ErrUnsupportedSchemaVersion, v)
+		}
+		if err := migrate(doc); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate results from version %d: %w", v, err)
+		}
+		if err := doc.setVersion(v + 1); err != nil {
+			return nil, version, err
+		}
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to encode migrated results: %w", err)
+	}
+	var report ResultsReport
+	if err := json.Unmarshal(migrated, &report); err != nil {
+		return nil, version, fmt.Errorf("failed to decode results: %w", err)
+	}
+	return &report, version, nil
+}
+
+// version - schema_version の値（ない場合はバージョン管理を始める前の出力とみなす）
+func (d resultsDocument) version() (int, error) {
+	raw, ok := d["schema_version"]
+	if !ok {
+		return legacyResultsSchemaVersion, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil || version < legacyResultsSchemaVersion {
+		return 0, fmt.Errorf("invalid schema_version: %s", raw)
+	}
+	return version, nil
+}
+
+// setVersion - schema_version を書き換える
+func (d resultsDocument) setVersion(version int) error {
+	raw, err := json.Marshal(version)
+	if err != nil {
+		return fmt.Errorf("failed to encode schema_version: %w", err)
+	}
+	d["schema_version"] = raw
+	return nil
+}
+
+// migrateResultsV1 - バージョン1（schema_version なし）から2への変換
+//
+// バージョン2は schema_version を加えただけで、ほかのフィールドは変わらない。
+func migrateResultsV1(resultsDocument) error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeResultsReportLegacy(t *testing.T) {
+	legacy := []byte(`{
+		"metadata": {"environment": "dev"},
+		"parameters": {"days": 30, "months": 12, "session_stats": false, "payload": false},
+		"results": [{"scenario": "orders", "method": "N+1", "execution_time": 32000000, "position": 1}]
+	}`)
+
+	report, version, err := DecodeResultsReport(legacy)
+	if err != nil {
+		t.Fatalf("DecodeResultsReport() error = %v", err)
+	}
+	if version != legacyResultsSchemaVersion {
+		t.Errorf("version = %d, want %d", version, legacyResultsSchemaVersion)
+	}
+	if report.SchemaVersion != ResultsSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", report.SchemaVersion, ResultsSchemaVersion)
+	}
+	if len(report.Results) != 1 || report.Results[0].ExecutionTime != 32*time.Millisecond {
+		t.Errorf("Results = %+v, want one result of 32ms", report.Results)
+	}
+	if report.Metadata == nil || report.Metadata.Environment != "dev" {
+		t.Errorf("Metadata = %+v, want environment dev", report.Metadata)
+	}
+}
+
+func TestDecodeResultsReportRoundTrip(t *testing.T) {
+	want := ResultsReport{
+		SchemaVersion: ResultsSchemaVersion,
+		Results:       []PerformanceResult{{Scenario: "orders", Method: "JOIN", ExecutionTime: 3 * time.Millisecond}},
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, version, err := DecodeResultsReport(data)
+	if err != nil {
+		t.Fatalf("DecodeResultsReport() error = %v", err)
+	}
+	if version != ResultsSchemaVersion {
+		t.Errorf("version = %d, want %d", version, ResultsSchemaVersion)
+	}
+	if len(got.Results) != 1 || got.Results[0].Method != "JOIN" {
+		t.Errorf("Results = %+v, want JOIN", got.Results)
+	}
+}
+
+func TestDecodeResultsReportRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		unsupported bool
+	}{
+		{"newer version", `{"schema_version": 99, "results": []}`, true},
+		{"invalid version", `{"schema_version": "two", "results": []}`, false},
+		{"zero version", `{"schema_version": 0, "results": []}`, false},
+		{"not json", `results`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := DecodeResultsReport([]byte(tt.data))
+			if err == nil {
+				t.Fatal("DecodeResultsReport() error = nil, want error")
+			}
+			if got := errors.Is(err, ErrUnsupportedSchemaVersion); got != tt.unsupported {
+				t.Errorf("errors.Is(err, ErrUnsupportedSchemaVersion) = %v, want %v (err = %v)", got, tt.unsupported, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+	"oracle-n-plus-1-demo/internal/stmtcache"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// EnableSessionStats - 手法ごとにセッション統計（V$MYSTAT）を取得するかを設定
+func (s *DemoService) EnableSessionStats(enabled bool) {
+	s.sessionStats = enabled
+}
+
+// pinnedSession - 計測中に固定した単一接続とその統計コレクター（統計を取らない場合はnil）
+type pinnedSession struct {
+	conn      *sql.Conn
+	collector *sessionstats.Collector
+	before    sessionstats.Stats
+	release   func()
+	// pinned - -rac-pin でインスタンスを固定した接続プールから確保したか
+	pinned bool
+}
+
+// pinSession - リポジトリとステートメントキャッシュを単一接続に差し替える
+//
+// 接続プール経由だと手法の途中で別セッションが使われ得るため、
+// 計測中はすべてのSQLを同じセッションで実行してV$MYSTATの差分を手法の負荷とみなす。
+// withStatsがfalseの場合は接続の固定だけを行う（ALTER SESSIONによるリセットやRACの記録用）。
+func (s *DemoService) pinSession(scenario string, withStats bool) (*pinnedSession, error) {
+	if s.conn != nil {
+		// 専用の接続で実行しているサービスはそのまま同じセッションで計測する
+		session := &pinnedSession{conn: s.conn, release: func() {}}
+		if withStats {
+			collector, err := sessionstats.NewCollector(repository.NewConnDB(s.conn), nil)
+			if err != nil {
+				return nil, err
+			}
+			session.collector = collector
+		}
+		return session, nil
+	}
+
+	db, instancePinned := s.sessionDB(scenario)
+	conn, err := db.Conn(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin connection: %w", err)
+	}
+
+	pinned := repository.NewConnDBContext(s.ctx, conn)
+	var collector *sessionstats.Collector
+	if withStats {
+		collector, err = sessionstats.NewCollector(pinned, nil)
+		if err != nil {
+			if cerr := conn.Close(); cerr != nil {
+				fmt.Printf("conn.Close() failed: %v\n", cerr)
+			}
+			return nil, err
+		}
+	}
+
+	originalCache := s.stmtCache
+	s.bindRepositories(pinned)
+	s.stmtCache = stmtcache.New(pinned)
+
+	session := &pinnedSession{conn: conn, collector: collector, pinned: instancePinned}
+	session.release = func() {
+		if err := s.stmtCache.Close(); err != nil {
+			fmt.Printf("ステートメントキャッシュのクローズエラー: %v\n", err)
+		}
+		s.stmtCache = originalCache
+		s.bindRepositories(s.db)
+		// 切断されたセッションはdiscardで閉じ済みのことがある
+		if err := conn.Close(); err != nil && !errors.Is(err, sql.ErrConnDone) {
+			fmt.Printf("conn.Close() failed: %v\n", err)
+		}
+	}
+
+	return session, nil
+}
+
+// beginSessionStats - 手法の計測前に単一接続を確保して統計のスナップショットを取る
+//
+// 統計を取得できない場合はnilを返し、以降の手法では取得を試みない。
+func (s *DemoService) beginSessionStats(scenario string) *pinnedSession {
+	if s.sessionStatsUnavailable {
+		return nil
+	}
+
+	session, err := s.pinSession(scenario, true)
+	if err != nil {
+		fmt.Printf("   セッション統計を取得できないためスキップします: %v\n", err)
+		s.sessionStatsUnavailable = true
+		return nil
+	}
+
+	session.before, err = session.collector.Snapshot()
+	if err != nil {
+		fmt.Printf("   セッション統計を取得できないためスキップします: %v\n", err)
+		s.sessionStatsUnavailable = true
+		session.release()
+		return nil
+	}
+
+	return session
+}
+
+// restartSessionStats - 計測の起点を取り直す（準備処理やリセットの負荷を計測対象から除く）
+func (session *pinnedSession) restartSessionStats() error {
+	if session.collector == nil {
+		return nil
+	}
+	before, err := session.collector.Snapshot()
+	if err != nil {
+		return err
+	}
+	session.before = before
+	return nil
+}
+
+// endSessionStats - 計測後の差分を結果に付与する
+func (session *pinnedSession) endSessionStats(result *PerformanceResult) {
+	if session.collector == nil {
+		return
+	}
+	delta, err := session.collector.Delta(session.before)
+	if err != nil {
+		fmt.Printf("   セッション統計の取得に失敗しました: %v\n", err)
+		return
+	}
+	result.SessionStats = delta
+
+	fmt.Printf("   転送量: %s, ラウンドトリップ: %d回, 論理読み取り: %dブロック, 実行: %d回\n",
+		report.FormatBytes(delta[sessionstats.BytesSent]),
+		delta[sessionstats.RoundTrips],
+		delta[sessionstats.LogicalReads],
+		delta[sessionstats.ExecuteCount])
+	fmt.Printf("   パース: %d回（ハード %d回, ソフト %d回）, カーソルキャッシュヒット: %d回\n",
+		delta[sessionstats.ParseCountTotal],
+		delta[sessionstats.ParseCountHard],
+		delta.SoftParses(),
+		delta[sessionstats.CursorCacheHits])
+}
+
+// displayParseComparison - 手法ごとのパース回数とカーソルキャッシュヒットを一覧表示
+//
+// N+1のループは実行回数だけパース要求を出すため、共有プールへの負荷が単一SQLの手法より大きくなる。
+func displayParseComparison(results []PerformanceResult) {
+	if len(results) == 0 || results[0].SessionStats == nil {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading("パース・カーソルキャッシュ統計")
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "execute", Header: "実行", Align: report.AlignRight},
+		report.Column{Key: "parse", Header: "パース", Align: report.AlignRight},
+		report.Column{Key: "hard", Header: "ハード", Align: report.AlignRight},
+		report.Column{Key: "soft", Header: "ソフト", Align: report.AlignRight},
+		report.Column{Key: "cursor_cache", Header: "キャッシュ", Align: report.AlignRight},
+	)
+	for _, result := range results {
+		stats := result.SessionStats
+		if stats == nil {
+			continue
+		}
+		table.AddRow(
+			report.Text(result.Method),
+			report.Int(stats[sessionstats.ExecuteCount]),
+			report.Int(stats[sessionstats.ParseCountTotal]),
+			report.Int(stats[sessionstats.ParseCountHard]),
+			report.Int(stats.SoftParses()),
+			report.Int(stats[sessionstats.CursorCacheHits]))
+	}
+	w.Table(table)
+	w.Line("ソフトパース = パース総数 - ハードパース。カーソルキャッシュヒットはセッションカーソルキャッシュで解決したパース要求")
+}
+
+// bindRepositories - 指定したDBでリポジトリを作り直す
+func (s *DemoService) bindRepositories(db repository.DBTX) {
+	s.problemRepo = repository.NewProblemOrderRepository(db)
+	s.problemEmpRepo = repository.NewProblemEmployeeRepository(db)
+	s.optimizedRepo = repository.NewOptimizedOrderRepository(db)
+	s.optimizedEmpRepo = repository.NewOptimizedEmployeeRepository(db)
+	s.applyLimits()
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"oracle-n-plus-1-demo/internal/sharedpool"
+)
+
+// CompareSharedPoolPressure - リテラル埋め込みとバインド変数のN+1を並行実行して共有プールへの負荷を比較
+//
+// リテラル版は受注ごとに異なるSQLになるためハードパースと共有プールの消費が増える。
+// 共有プールを意図的に汚すため、runAllTestsには含めない。
+func (s *DemoService) CompareSharedPoolPressure(days, workers int) ([]PerformanceResult, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+	fmt.Printf("\n=== 共有プール負荷比較（過去%d日間、%d並列） ===\n", days, workers)
+	fmt.Println("同じN+1ループをリテラル埋め込みとバインド変数で並行実行します")
+
+	// 並行実行は接続プールを使うため、単一接続に固定するセッション統計とRACの記録は取得しない
+	defer func(enabled bool) { s.sessionStats = enabled }(s.sessionStats)
+	s.sessionStats = false
+	defer func(enabled bool) { s.rac = enabled }(s.rac)
+	s.rac = false
+
+	var before *sharedpool.Snapshot
+	snapshot := func() error {
+		before = sharedpool.Take(s.db)
+		return nil
+	}
+	attach := func(result *PerformanceResult) {
+		result.SharedPool = sharedpool.Take(s.db).Delta(before)
+		displaySharedPoolDelta(result.SharedPool)
+	}
+
+	strategies := []strategy{
+		{
+			method:      "N+1_Literal",
+			label:       "リテラル埋め込みのN+1アプローチ",
+			description: "受注IDをSQLに埋め込んだN+1（受注ごとに別SQL・ハードパース）を並行実行",
+			run: runConcurrently(workers, func() (int, error) {
+				return s.encodeResponse(s.problemRepo.GetOrdersWithDetailsLiteral(days))
+			}),
+			setup: snapshot,
+			after: attach,
+		},
+		{
+			method:      "N+1_Bind",
+			label:       "バインド変数のN+1アプローチ",
+			description: "バインド変数のN+1（同じSQLを共有・ソフトパース）を並行実行",
+			run: runConcurrently(workers, func() (int, error) {
+				return s.encodeResponse(s.problemRepo.GetOrdersWithDetails(days))
+			}),
+			setup: snapshot,
+			after: attach,
+		},
+	}
+
+	results, err := s.runStrategies(ScenarioSharedPool, strategies)
+	if err != nil {
+		return nil, err
+	}
+
+	// パフォーマンス改善率を計算して表示（リテラル埋め込みを基準とする）
+	s.displayPerformanceComparison(results)
+
+	return results, nil
+}
+
+// runConcurrently - 同じ処理をworkers並列で実行し、取得件数の合計を返すrun関数を作る
+func runConcurrently(workers int, run func() (int, error)) func() (int, error) {
+	return func() (int, error) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var total int
+		var firstErr error
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				count, err := run()
+
+				mu.Lock()
+				defer mu.Unlock()
+				total += count
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}()
+		}
+		wg.Wait()
+
+		return total, firstErr
+	}
+}
+
+// displaySharedPoolDelta - 共有プール関連統計の差分を表示
+func displaySharedPoolDelta(d *sharedpool.Delta) {
+	if d.Available("V$SGASTAT") {
+		fmt.Printf("   共有プール空きメモリ: %+d bytes\n", d.FreeMemoryChange)
+	}
+	if d.Available("V$SYSSTAT") {
+		fmt.Printf("   パース（インスタンス全体）: %d回（ハード %d回）\n", d.TotalParses, d.HardParses)
+	}
+	if d.Available("V$LATCH") {
+		for name, latch := range d.Latches {
+			fmt.Printf("   ラッチ %s: 取得 %d回, ミス %d回, スリープ %d回\n", name, latch.Gets, latch.Misses, latch.Sleeps)
+		}
+	}
+	if d.Available("V$SYSTEM_EVENT") {
+		for event, wait := range d.Waits {
+			if wait.TotalWaits > 0 {
+				fmt.Printf("   待機 %s: %d回, %v\n", event, wait.TotalWaits, wait.TimeWaited)
+			}
+		}
+	}
+	if len(d.Unavailable) > 0 {
+		fmt.Printf("   権限がないため取得できなかったビュー: %v\n", d.Unavailable)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrStopped - 中断（SIGINT/SIGTERM）により新しい計測を始めなかった
+var ErrStopped = errors.New("benchmark stopped")
+
+// stopped - ctxが取り消されていればErrStoppedを返す
+func stopped(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrStopped, err)
+	}
+	return nil
+}
+
+// SetContext - 中断時に取り消すコンテキストを設定（Forkしたサービスにも引き継ぐ）
+//
+// 取り消されると次の手法・回の計測を始めずErrStoppedを返し、固定した接続で実行中のSQLを中断する。
+func (s *DemoService) SetContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// SetContext - 中断時に取り消すコンテキストを設定
+//
+// 取り消されると次の回の計測を始めずErrStoppedを返し、PL/SQL関数の呼び出しを中断する。
+func (c *CacheService) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// Close - テスト中に作成したPL/SQL関数を削除し、Redisへの接続（NewCacheServiceで接続した場合）と独自のキャッシュ実装を閉じる
+//
+// 中断時に呼ぶと、実行中のPL/SQL Function Result Cacheテストの関数を残さずに終了できる。
+func (c *CacheService) Close() error {
+	c.mu.Lock()
+	drop := c.dropPLSQLFunction
+	c.dropPLSQLFunction = nil
+	c.mu.Unlock()
+
+	var errs []error
+	if drop != nil {
+		if err := drop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.ownsRedis {
+		if err := c.redisClient.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close redis client: %w", err))
+		}
+	}
+	if err := c.performanceAnalyzer.CloseBackends(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Close - Redisへの接続を閉じる
+func (s *CustomerSummaryService) Close() error {
+	if s.redisClient == nil {
+		return nil
+	}
+	if err := s.redisClient.Close(); err != nil {
+		return fmt.Errorf("failed to close redis client: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+// Significance - 基準の手法に対する有意差検定と効果量
+type Significance struct {
+	Baseline string `json:"baseline"`
+	// Speedup - 基準の中央値 / 手法の中央値（何倍速いか）
+	Speedup float64 `json:"speedup"`
+	// TTest - Welchのt検定（両方の実行時間が一定で検定できない場合はnil）
+	TTest       *stats.TTest      `json:"t_test,omitempty"`
+	MannWhitney stats.MannWhitney `json:"mann_whitney"`
+	// CohensD - (基準の平均 - 手法の平均) / 併合標準偏差（正の値は手法が速い）
+	CohensD float64 `json:"cohens_d"`
+	// CliffsDelta - 基準の方が遅い組の割合 - 速い組の割合（正の値は手法が速い）
+	CliffsDelta float64 `json:"cliffs_delta"`
+}
+
+// Significant - 実施できた検定のすべてでp値が有意水準（5%）未満か
+func (sig *Significance) Significant() bool {
+	if sig.MannWhitney.P >= stats.SignificanceLevel {
+		return false
+	}
+	return sig.TTest == nil || sig.TTest.P < stats.SignificanceLevel
+}
+
+// Statement - 「3.2x高速化」などの主張に添える信頼度の表記
+func (sig *Significance) Statement() string {
+	p := sig.MannWhitney.P
+	tests := "Mann-Whitney"
+	if sig.TTest != nil {
+		p = max(p, sig.TTest.P)
+		tests = "Welch t / Mann-Whitney"
+	}
+	if sig.Significant() {
+		return fmt.Sprintf("有意差あり p=%s（%s）", formatP(p), tests)
+	}
+	return fmt.Sprintf("有意差なし p=%s（%s）", formatP(p), tests)
+}
+
+// attachSignificance - 最初の手法（N+1の手法）を基準に、各手法の有意差検定を付与（各2回以上の計測が必要）
+func attachSignificance(results []PerformanceResult) {
+	if len(results) < 2 || len(results[0].Samples) < 2 {
+		return
+	}
+	baseline := results[0]
+	base := stats.Float64s(baseline.Samples)
+
+	for i := 1; i < len(results); i++ {
+		if len(results[i].Samples) < 2 {
+			continue
+		}
+		candidate := stats.Float64s(results[i].Samples)
+
+		mw, ok := stats.MannWhitneyU(base, candidate)
+		if !ok {
+			continue
+		}
+		sig := &Significance{
+			Baseline:    baseline.Method,
+			MannWhitney: mw,
+			CliffsDelta: stats.CliffsDelta(base, candidate),
+		}
+		if results[i].ExecutionTime > 0 {
+			sig.Speedup = float64(baseline.ExecutionTime) / float64(results[i].ExecutionTime)
+		}
+		if t, ok := stats.WelchTTest(base, candidate); ok {
+			sig.TTest = &t
+		}
+		if d, ok := stats.CohensD(base, candidate); ok {
+			sig.CohensD = d
+		}
+		results[i].Significance = sig
+	}
+}
+
+// displaySignificance - 基準の手法に対する有意差検定の結果を表示
+func displaySignificance(results []PerformanceResult) {
+	if len(results) < 2 || results[1].Significance == nil {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading(fmt.Sprintf("有意差検定（基準: %s）", results[0].Method))
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "speedup", Header: "倍率（中央値）", Align: report.AlignRight},
+		report.Column{Key: "t_p", Header: "t検定 p", Align: report.AlignRight},
+		report.Column{Key: "mw_p", Header: "U検定 p", Align: report.AlignRight},
+		report.Column{Key: "cohens_d", Header: "効果量 d", Align: report.AlignRight},
+		report.Column{Key: "cliffs_delta", Header: "Cliffのδ", Align: report.AlignRight},
+		report.Column{Key: "verdict", Header: "判定"},
+	)
+	for _, result := range results[1:] {
+		sig := result.Significance
+		if sig == nil {
+			continue
+		}
+		tp := report.Text("-")
+		if sig.TTest != nil {
+			tp = report.Number(formatP(sig.TTest.P), sig.TTest.P)
+		}
+		verdict := "有意差なし"
+		if sig.Significant() {
+			verdict = "有意差あり"
+		}
+		table.AddRow(
+			report.Text(result.Method),
+			report.Float("%.2fx", sig.Speedup),
+			tp,
+			report.Number(formatP(sig.MannWhitney.P), sig.MannWhitney.P),
+			report.Number(fmt.Sprintf("%+.2f（%s）", sig.CohensD, stats.EffectMagnitude(sig.CohensD)), sig.CohensD),
+			report.Float("%+.2f", sig.CliffsDelta),
+			report.Text(verdict))
+	}
+	w.Table(table)
+	w.Linef("t検定はWelchの方法、U検定はMann-Whitney（同順位がなければ正確なp値）。両方のp値が%.2f未満の場合に有意差ありと判定します", stats.SignificanceLevel)
+	w.Line("効果量は正の値ほど基準より速い（d: 0.2 小, 0.5 中, 0.8 大）。U検定で有意差を示すには各手法4回以上の計測が必要です")
+}
+
+// formatP - p値の表示（ごく小さい値は不等号で表す）
+func formatP(p float64) string {
+	if p < 0.001 {
+		return "<0.001"
+	}
+	return fmt.Sprintf("%.3f", p)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"oracle-n-plus-1-demo/repository"
+)
+
+// CompareSoftDeletePerformance - 論理削除された受注が大半を占める表で、削除済みを除く条件と索引の効き方を比較
+//
+// 受注日の索引は削除済みの受注も持つため、条件をSQLに書いても削除済みの行を表から読んで捨てる。
+// 削除されていない受注だけを持つ関数索引（部分索引の代わり）がなければ作成し、同じ取得を索引の式で書いた場合と比べる。
+// 索引は条件の式が一致する問い合わせでしか使われないため、作成後も他の手法の計画は変わらない。
+func (s *DemoService) CompareSoftDeletePerformance(days int) ([]PerformanceResult, error) {
+	fmt.Printf("\n=== 論理削除された受注の除外と部分索引の比較（過去%d日間） ===\n", days)
+
+	live, deleted, indexed, err := s.optimizedRepo.SoftDeleteStatus(days)
+	if errors.Is(err, repository.ErrSoftDeleteColumnMissing) {
+		return nil, fmt.Errorf("受注の表に論理削除フラグがありません。ALTER TABLE orders ADD (deleted_flag CHAR(1) DEFAULT 'N' NOT NULL) を実行し、scripts/load_test_data.sh でデータを再生成してください: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if total := live + deleted; total > 0 {
+		fmt.Printf("対象期間の受注: %d件（うち削除済み %d件、%.0f%%）\n", total, deleted, float64(deleted)/float64(total)*100)
+	}
+	if !indexed {
+		if err := s.optimizedRepo.CreateLiveOrderIndex(); err != nil {
+			return nil, fmt.Errorf("部分索引の作成に失敗しました: %w", err)
+		}
+		fmt.Printf("削除されていない受注だけを持つ関数索引 %s を作成しました（cleanupで受注の表と一緒に削除されます）\n", repository.LiveOrderIndex)
+	}
+
+	strategies := []strategy{
+		{
+			method:      "N+1_Problem",
+			label:       "N+1アプローチ",
+			description: "削除済みを除いた受注を取得し、受注ごとに明細を取得（1 + 受注数回のSQL）",
+			run:         func() (int, error) { return s.encodeResponse(s.problemRepo.GetLiveOrdersWithDetails(days)) },
+		},
+		{
+			method:      "JOIN_App_Filter",
+			label:       "アプリ側で削除済みを除くJOINアプローチ",
+			description: "削除済みを含めてJOINで取得し、アプリ側で捨てる（条件の書き忘れ）",
+			run:         func() (int, error) { return s.encodeResponse(s.problemRepo.GetOrdersJoinFilterInApp(days)) },
+		},
+		{
+			method:      "Batch_Optimized",
+			label:       "バッチ取得アプローチ",
+			description: "deleted_flag = 'N' の受注 + IN句で明細を一括取得（受注日の索引で削除済みも読む）",
+			run: func() (int, error) {
+				return s.encodeResponse(s.optimizedRepo.GetLiveOrdersWithDetailsBatch(days, false))
+			},
+		},
+		{
+			method:      "JOIN_Optimized",
+			label:       "JOINアプローチ",
+			description: "deleted_flag = 'N' の条件付きJOIN（受注日の索引で削除済みも読む）",
+			run: func() (int, error) {
+				return s.encodeResponse(s.optimizedRepo.GetLiveOrdersWithDetailsJoin(days, false))
+			},
+		},
+		{
+			method:      "Batch_PartialIndex",
+			label:       "部分索引を使うバッチ取得アプローチ",
+			description: "索引の式（CASE WHEN deleted_flag = 'N' THEN order_date END）で受注を絞り、IN句で明細を一括取得",
+			run: func() (int, error) {
+				return s.encodeResponse(s.optimizedRepo.GetLiveOrdersWithDetailsBatch(days, true))
+			},
+		},
+		{
+			method:      "JOIN_PartialIndex",
+			label:       "部分索引を使うJOINアプローチ",
+			description: "索引の式で受注を絞ったJOIN（削除されていない受注だけを索引から読む）",
+			run: func() (int, error) {
+				return s.encodeResponse(s.optimizedRepo.GetLiveOrdersWithDetailsJoin(days, true))
+			},
+		},
+	}
+
+	results, err := s.runStrategies(ScenarioSoftDelete, strategies)
+	if err != nil {
+		return nil, err
+	}
+
+	// パフォーマンス改善率を計算して表示（N+1を基準とする）
+	s.displayPerformanceComparison(results)
+	fmt.Println("\n削除済みの割合が高いほど、受注日の索引から読んで捨てる行が増え、部分索引との差が大きくなります")
+	fmt.Println("-session-stats を指定すると、手法ごとの論理読み取り（削除済みの行を読んだ分）と転送量を比べられます")
+
+	return results, nil
+}
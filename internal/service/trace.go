@@ -0,0 +1,18 @@
+package service
+
+import "oracle-n-plus-1-demo/internal/chrometrace"
+
+// SetTrace - 手法の実行区間を記録する先を設定（nilの場合は記録しない。Forkしたサービスにも引き継ぐ）
+//
+// 区間はシナリオごとのトラックに載せるため、並列実行でもシナリオの区間どうしは重ならない。
+func (s *DemoService) SetTrace(r *chrometrace.Recorder) {
+	s.trace = r
+}
+
+// beginSpan - 手法の実行区間を開始し、戻り値の関数で終了する（記録しない場合は何もしない）
+func (s *DemoService) beginSpan(scenario string, st strategy) func() {
+	if s.trace == nil {
+		return func() {}
+	}
+	return s.trace.Span(scenario, st.label, map[string]interface{}{"scenario": scenario, "method": st.method})
+}
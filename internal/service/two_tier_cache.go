@@ -0,0 +1,255 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// twoTierLocalEntries - 2層キャッシュのプロセス内（ローカル）の層に置くエントリ数の上限（超えたら最も古く使われたものから降格する）
+	twoTierLocalEntries = 32
+	// twoTierLocalTTL - ローカルの層のエントリの有効期限（ほかのインスタンスの更新はこの時間まで反映されない）
+	twoTierLocalTTL = 30 * time.Second
+)
+
+// CacheTier - 2層キャッシュで値を返した層
+type CacheTier string
+
+const (
+	// TierLocal - プロセス内のLRU
+	TierLocal CacheTier = "local"
+	// TierRedis - Redis（ローカルの層へ昇格する）
+	TierRedis CacheTier = "redis"
+	// TierDatabase - どちらの層にもなくDBから取得した
+	TierDatabase CacheTier = "database"
+)
+
+// TwoTierStats - 2層キャッシュの層ごとのヒット数と、層の間の移動
+type TwoTierStats struct {
+	LocalHits int `json:"local_hits"`
+	RedisHits int `json:"redis_hits"`
+	Misses    int `json:"misses"`
+	// Promotions - Redisのヒットをローカルの層にコピーした回数
+	Promotions int `json:"promotions"`
+	// Demotions - ローカルの層から追い出したエントリをRedisに書き戻した回数（Redisにすでにあった場合は数えない）
+	Demotions int `json:"demotions"`
+	// Evictions - ローカルの層の上限を超えて追い出した回数
+	Evictions int `json:"evictions"`
+	// LocalEntries / LocalBytes - 計測後にローカルの層にあるエントリ数と値の大きさ
+	LocalEntries int   `json:"local_entries"`
+	LocalBytes   int64 `json:"local_bytes"`
+}
+
+// Lookups - 取得した回数
+func (s TwoTierStats) Lookups() int {
+	return s.LocalHits + s.RedisHits + s.Misses
+}
+
+// TierHitRate - 取得のうちtierから返した割合（%）
+func (s TwoTierStats) TierHitRate(tier CacheTier) float64 {
+	if s.Lookups() == 0 {
+		return 0
+	}
+	n := s.Misses
+	switch tier {
+	case TierLocal:
+		n = s.LocalHits
+	case TierRedis:
+		n = s.RedisHits
+	}
+	return float64(n) / float64(s.Lookups()) * 100
+}
+
+// twoTierStore - 2層キャッシュの後ろの層（Redis。テストでは差し替える）
+type twoTierStore interface {
+	// get - キーの値（ない場合はok=false）
+	get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// set - 値を保存
+	set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// setIfAbsent - キーがない場合だけ保存し、保存したかを返す
+	setIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// del - キーを削除
+	del(ctx context.Context, key string) error
+}
+
+// redisStore - go-redisのクライアントを後ろの層として使う
+type redisStore struct {
+	client *redis.Client
+}
+
+func (s redisStore) get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s redisStore) set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s redisStore) setIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s redisStore) del(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+// localEntry - ローカルの層のエントリ
+type localEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// twoTierCache - プロセス内のLRUを前に、Redisを後ろに置く2層キャッシュ（キャッシュアサイド）
+//
+// ローカルの層でミスするとRedisを見て、ヒットすればローカルの層へ昇格する。どちらにもなければDBから取得して両方に保存する。
+// ローカルの層の上限を超えたエントリは、Redisになければ書き戻して（降格して）から追い出す。
+// ほかのインスタンスのローカルの層は無効化できないため、更新はローカルのTTLが切れるまで反映されない。
+type twoTierCache struct {
+	store    twoTierStore
+	clock    clock.Clock
+	ttl      time.Duration
+	localTTL time.Duration
+	capacity int
+
+	order   *list.List
+	entries map[string]*list.Element
+	stats   TwoTierStats
+}
+
+// newTwoTierCache - ローカルの層が空の2層キャッシュ（ttlはRedisに保存するときの有効期限）
+func newTwoTierCache(store twoTierStore, clk clock.Clock, ttl time.Duration) *twoTierCache {
+	return &twoTierCache{
+		store:    store,
+		clock:    clock.OrSystem(clk),
+		ttl:      ttl,
+		localTTL: twoTierLocalTTL,
+		capacity: twoTierLocalEntries,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get - ローカル・Redis・DB（load）の順に取得し、値と返した層を返す
+func (c *twoTierCache) get(ctx context.Context, key string, load func() ([]byte, error)) ([]byte, CacheTier, error) {
+	if value, ok := c.getLocal(key); ok {
+		c.stats.LocalHits++
+		return value, TierLocal, nil
+	}
+
+	value, ok, err := c.store.get(ctx, key)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get %s from redis: %w", key, err)
+	}
+	if ok {
+		c.stats.RedisHits++
+		c.stats.Promotions++
+		if err := c.putLocal(ctx, key, value); err != nil {
+			return nil, "", err
+		}
+		return value, TierRedis, nil
+	}
+
+	c.stats.Misses++
+	if value, err = load(); err != nil {
+		return nil, "", err
+	}
+	if err := c.store.set(ctx, key, value, c.ttl); err != nil {
+		return nil, "", fmt.Errorf("failed to set %s to redis: %w", key, err)
+	}
+	if err := c.putLocal(ctx, key, value); err != nil {
+		return nil, "", err
+	}
+	return value, TierDatabase, nil
+}
+
+// invalidate - このインスタンスのローカルの層とRedisからキーを削除
+func (c *twoTierCache) invalidate(ctx context.Context, key string) error {
+	c.removeLocal(key)
+	if err := c.store.del(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete %s from redis: %w", key, err)
+	}
+	return nil
+}
+
+// reset - ローカルの層を空にし、統計を初期化（Redisのキーは呼び出し側で削除する）
+func (c *twoTierCache) reset() {
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.stats = TwoTierStats{}
+}
+
+// snapshot - 層ごとのヒット数と、現在のローカルの層の大きさ
+func (c *twoTierCache) snapshot() TwoTierStats {
+	stats := c.stats
+	stats.LocalEntries = c.order.Len()
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		stats.LocalBytes += int64(len(e.Value.(*localEntry).value))
+	}
+	return stats
+}
+
+// getLocal - 有効期限内のエントリを返し、最近使ったものとして先頭に移す
+func (c *twoTierCache) getLocal(key string) ([]byte, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*localEntry)
+	if !c.clock.Now().Before(entry.expiresAt) {
+		c.removeLocal(key)
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return entry.value, true
+}
+
+// putLocal - ローカルの層に保存し、上限を超えたら最も古く使われたエントリを降格して追い出す
+func (c *twoTierCache) putLocal(ctx context.Context, key string, value []byte) error {
+	entry := &localEntry{key: key, value: value, expiresAt: c.clock.Now().Add(c.localTTL)}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back().Value.(*localEntry)
+		c.removeLocal(oldest.key)
+		c.stats.Evictions++
+		if !c.clock.Now().Before(oldest.expiresAt) {
+			continue
+		}
+		demoted, err := c.store.setIfAbsent(ctx, oldest.key, oldest.value, c.ttl)
+		if err != nil {
+			return fmt.Errorf("failed to demote %s to redis: %w", oldest.key, err)
+		}
+		if demoted {
+			c.stats.Demotions++
+		}
+	}
+	return nil
+}
+
+// removeLocal - ローカルの層からキーを削除
+func (c *twoTierCache) removeLocal(key string) {
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
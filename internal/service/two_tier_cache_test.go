@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/clock"
+)
+
+// fakeStore - メモリ上の後ろの層（有効期限は扱わない）
+type fakeStore map[string][]byte
+
+func (s fakeStore) get(_ context.Context, key string) ([]byte, bool, error) {
+	value, ok := s[key]
+	return value, ok, nil
+}
+
+func (s fakeStore) set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	s[key] = value
+	return nil
+}
+
+func (s fakeStore) setIfAbsent(_ context.Context, key string, value []byte, _ time.Duration) (bool, error) {
+	if _, ok := s[key]; ok {
+		return false, nil
+	}
+	s[key] = value
+	return true, nil
+}
+
+func (s fakeStore) del(_ context.Context, key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestTwoTierCacheTiers(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Unix(0, 0))
+	store := fakeStore{"shared": []byte("from-redis")}
+	c := newTwoTierCache(store, clk, time.Minute)
+	loads := 0
+	load := func() ([]byte, error) {
+		loads++
+		return []byte("from-db"), nil
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		advance time.Duration
+		want    CacheTier
+	}{
+		{"Redisにある値はローカルへ昇格", "shared", 0, TierRedis},
+		{"昇格した値はローカルから返す", "shared", 0, TierLocal},
+		{"どちらにもなければDB", "other", 0, TierDatabase},
+		{"DBから取得した値はローカルから返す", "other", 0, TierLocal},
+		{"ローカルのTTLが切れたらRedisから", "other", twoTierLocalTTL, TierRedis},
+	}
+	for _, tt := range tests {
+		clk.Advance(tt.advance)
+		_, tier, err := c.get(ctx, tt.key, load)
+		if err != nil {
+			t.Fatalf("%s: get() error = %v", tt.name, err)
+		}
+		if tier != tt.want {
+			t.Errorf("%s: tier = %s, want %s", tt.name, tier, tt.want)
+		}
+	}
+
+	stats := c.snapshot()
+	if stats.LocalHits != 2 || stats.RedisHits != 2 || stats.Misses != 1 || stats.Promotions != 2 || loads != 1 {
+		t.Errorf("stats = %+v, loads = %d, want 2 local / 2 redis / 1 miss / 2 promotions, 1 load", stats, loads)
+	}
+	if stats.LocalEntries != 2 {
+		t.Errorf("LocalEntries = %d, want 2", stats.LocalEntries)
+	}
+
+	if err := c.invalidate(ctx, "other"); err != nil {
+		t.Fatalf("invalidate() error = %v", err)
+	}
+	if _, tier, _ := c.get(ctx, "other", load); tier != TierDatabase {
+		t.Errorf("after invalidate: tier = %s, want %s", tier, TierDatabase)
+	}
+}
+
+func TestTwoTierCacheDemotion(t *testing.T) {
+	ctx := context.Background()
+	store := fakeStore{}
+	c := newTwoTierCache(store, clock.NewFake(time.Unix(0, 0)), time.Minute)
+	c.capacity = 2
+
+	for i := range 3 {
+		key := fmt.Sprintf("k%d", i)
+		if _, _, err := c.get(ctx, key, func() ([]byte, error) { return []byte(key), nil }); err != nil {
+			t.Fatalf("get(%s) error = %v", key, err)
+		}
+	}
+	// 追い出したk0はRedisにあるため降格しない
+	stats := c.snapshot()
+	if stats.Evictions != 1 || stats.Demotions != 0 || stats.LocalEntries != 2 {
+		t.Errorf("stats = %+v, want 1 eviction, 0 demotions, 2 entries", stats)
+	}
+
+	// Redisから消えた値は追い出すときに書き戻す
+	if err := store.del(ctx, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.get(ctx, "k3", func() ([]byte, error) { return []byte("k3"), nil }); err != nil {
+		t.Fatalf("get(k3) error = %v", err)
+	}
+	if stats := c.snapshot(); stats.Demotions != 1 || string(store["k1"]) != "k1" {
+		t.Errorf("Demotions = %d, store[k1] = %q, want 1 and k1", stats.Demotions, store["k1"])
+	}
+}
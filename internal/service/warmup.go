@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WarmupMode - シナリオの計測前に行うウォームアップ
+type WarmupMode string
+
+const (
+	// WarmupNone - ウォームアップしない（前のシナリオやリセットで決まるキャッシュの状態のまま計測する）
+	WarmupNone WarmupMode = "none"
+	// WarmupScan - シナリオが読む表を全表スキャンしてバッファキャッシュに載せる
+	WarmupScan WarmupMode = "scan"
+	// WarmupIteration - 各手法を計測せずに1回ずつ実行する（索引・カーソル・Result Cacheも温まる）
+	WarmupIteration WarmupMode = "iteration"
+)
+
+// WarmupModes - 指定できるウォームアップ
+var WarmupModes = []WarmupMode{WarmupNone, WarmupScan, WarmupIteration}
+
+// scenarioTables - 全表スキャンでウォームアップするシナリオごとの表
+var scenarioTables = map[string][]string{
+	ScenarioOrders:           {"orders", "order_details"},
+	ScenarioEmployees:        {"employees", "departments"},
+	ScenarioEmployeeProjects: {"employees", "employee_projects", "projects"},
+	ScenarioMonthlySales:     {"orders", "order_details"},
+	ScenarioTopCustomers:     {"orders", "order_details"},
+	ScenarioWindowFunctions:  {"orders", "order_details"},
+	ScenarioLOB:              {"orders", "order_details"},
+	ScenarioCompositeFetch:   {"orders", "order_details", "products"},
+	ScenarioColumnPruning:    {"orders", "order_details"},
+	ScenarioPagination:       {"orders"},
+	ScenarioSoftDelete:       {"orders", "order_details"},
+}
+
+// WarmupPolicy - シナリオごとのウォームアップ
+type WarmupPolicy struct {
+	// Default - シナリオを個別に指定しなかった場合のウォームアップ
+	Default WarmupMode `json:"default"`
+	// Scenarios - シナリオごとに指定したウォームアップ
+	Scenarios map[string]WarmupMode `json:"scenarios,omitempty"`
+}
+
+// ParseWarmupPolicy - ウォームアップの指定を解釈
+//
+// 「scan」のように種類だけを書くとすべてのシナリオに、「orders=iteration」のように書くとそのシナリオに適用する
+// （例: scan,lob=none）。
+func ParseWarmupPolicy(spec string) (WarmupPolicy, error) {
+	policy := WarmupPolicy{Default: WarmupNone}
+	defaultSet := false
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		scenario, name, scoped := strings.Cut(part, "=")
+		if !scoped {
+			name = scenario
+		}
+		mode, err := parseWarmupMode(strings.TrimSpace(name))
+		if err != nil {
+			return policy, err
+		}
+		if !scoped {
+			if defaultSet {
+				return policy, fmt.Errorf("duplicate default warmup %q", part)
+			}
+			policy.Default, defaultSet = mode, true
+			continue
+		}
+
+		scenario = strings.TrimSpace(scenario)
+		if _, ok := scenarioTables[scenario]; !ok {
+			return policy, fmt.Errorf("unknown scenario %q (%s)", scenario, strings.Join(warmupScenarios(), ", "))
+		}
+		if _, exists := policy.Scenarios[scenario]; exists {
+			return policy, fmt.Errorf("duplicate warmup for scenario %q", scenario)
+		}
+		if policy.Scenarios == nil {
+			policy.Scenarios = make(map[string]WarmupMode)
+		}
+		policy.Scenarios[scenario] = mode
+	}
+	return policy, nil
+}
+
+// parseWarmupMode - ウォームアップの種類を解釈
+func parseWarmupMode(name string) (WarmupMode, error) {
+	for _, mode := range WarmupModes {
+		if WarmupMode(name) == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown warmup %q (none, scan, iteration)", name)
+}
+
+// warmupScenarios - ウォームアップを指定できるシナリオ（表示用に名前順）
+func warmupScenarios() []string {
+	names := make([]string, 0, len(scenarioTables))
+	for name := range scenarioTables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// For - シナリオのウォームアップ
+func (p WarmupPolicy) For(scenario string) WarmupMode {
+	if mode, ok := p.Scenarios[scenario]; ok {
+		return mode
+	}
+	if p.Default == "" {
+		return WarmupNone
+	}
+	return p.Default
+}
+
+// Enabled - いずれかのシナリオでウォームアップするか
+func (p WarmupPolicy) Enabled() bool {
+	if p.Default != "" && p.Default != WarmupNone {
+		return true
+	}
+	for _, mode := range p.Scenarios {
+		if mode != WarmupNone {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWarmupPolicy - シナリオの計測前に行うウォームアップを設定
+func (s *DemoService) SetWarmupPolicy(policy WarmupPolicy) {
+	s.warmup = policy
+}
+
+// warmUp - シナリオの最初の計測の前にウォームアップを行い、行った種類を返す
+func (s *DemoService) warmUp(scenario string, strategies []strategy) (WarmupMode, error) {
+	if _, ok := scenarioTables[scenario]; !ok {
+		// 共有プール負荷など、温まっていない状態を計測するシナリオはウォームアップしない
+		return WarmupNone, nil
+	}
+	mode := s.warmup.For(scenario)
+	start := s.clock.Now()
+	switch mode {
+	case WarmupScan:
+		tables := scenarioTables[scenario]
+		var rows int64
+		for _, table := range tables {
+			// FULLヒントで索引の高速全スキャンではなく表のブロックを読ませる
+			var count int64
+			if err := s.db.QueryRow("SELECT /*+ FULL(t) */ COUNT(*) FROM " + table + " t").Scan(&count); err != nil {
+				return mode, fmt.Errorf("failed to scan %s for warmup: %w", table, err)
+			}
+			rows += count
+		}
+		fmt.Printf("   ウォームアップ: %sを全表スキャン（%d行、%v）\n", strings.Join(tables, "・"), rows, s.clock.Since(start).Round(time.Millisecond))
+	case WarmupIteration:
+		for _, st := range strategies {
+			if st.setup != nil {
+				if err := st.setup(); err != nil {
+					return mode, fmt.Errorf("%sのウォームアップの準備でエラー: %w", st.label, err)
+				}
+			}
+			if _, err := st.run(); err != nil {
+				return mode, fmt.Errorf("%sのウォームアップでエラー: %w", st.label, err)
+			}
+		}
+		fmt.Printf("   ウォームアップ: %d手法を計測せずに1回ずつ実行（%v）\n", len(strategies), s.clock.Since(start).Round(time.Millisecond))
+	}
+	return mode, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import "testing"
+
+func TestParseWarmupPolicy(t *testing.T) {
+	tests := []struct {
+		spec     string
+		scenario string
+		want     WarmupMode
+		enabled  bool
+		wantErr  bool
+	}{
+		{spec: "", scenario: ScenarioOrders, want: WarmupNone},
+		{spec: "scan", scenario: ScenarioOrders, want: WarmupScan, enabled: true},
+		{spec: "scan,lob=none", scenario: ScenarioLOB, want: WarmupNone, enabled: true},
+		{spec: "employees=iteration", scenario: ScenarioEmployees, want: WarmupIteration, enabled: true},
+		{spec: "employees=iteration", scenario: ScenarioOrders, want: WarmupNone, enabled: true},
+		{spec: "none,orders=none", scenario: ScenarioOrders, want: WarmupNone},
+		{spec: "scan,iteration", wantErr: true},
+		{spec: "orders=scan,orders=none", wantErr: true},
+		{spec: "shared_pool=scan", wantErr: true},
+		{spec: "hot", wantErr: true},
+	}
+	for _, tt := range tests {
+		policy, err := ParseWarmupPolicy(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWarmupPolicy(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got := policy.For(tt.scenario); got != tt.want {
+			t.Errorf("ParseWarmupPolicy(%q).For(%q) = %v, want %v", tt.spec, tt.scenario, got, tt.want)
+		}
+		if got := policy.Enabled(); got != tt.enabled {
+			t.Errorf("ParseWarmupPolicy(%q).Enabled() = %v, want %v", tt.spec, got, tt.enabled)
+		}
+	}
+}
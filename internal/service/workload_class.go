@@ -0,0 +1,132 @@
+package service
+
+import (
+	"time"
+
+	"oracle-n-plus-1-demo/internal/report"
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+// WorkloadClass - 手法の実行中にDBの時間を主に占めていた読み取り・処理の種類
+type WorkloadClass string
+
+const (
+	// WorkloadCPU - 集計・ソート・関数の評価などSQLの処理自体がCPUを使っている
+	WorkloadCPU WorkloadClass = "cpu"
+	// WorkloadLogicalRead - バッファキャッシュ上のブロックの読み取りが中心
+	WorkloadLogicalRead WorkloadClass = "logical_read"
+	// WorkloadPhysicalRead - バッファキャッシュになくディスク（またはファイルシステムキャッシュ）から読んだブロックが多い
+	WorkloadPhysicalRead WorkloadClass = "physical_read"
+)
+
+const (
+	// physicalReadShare - 論理読み取りのうち物理読み取りがこの割合以上なら物理読み取り主体とみなす
+	physicalReadShare = 0.1
+	// logicalReadCPUCost - 論理読み取り1回あたりのCPU時間の目安
+	logicalReadCPUCost = 5 * time.Microsecond
+	// logicalReadCPUShare - DBのCPU時間のうち論理読み取りの目安がこの割合以上なら論理読み取り主体とみなす
+	logicalReadCPUShare = 0.5
+)
+
+// Label - 表示用の分類名
+func (c WorkloadClass) Label() string {
+	switch c {
+	case WorkloadCPU:
+		return "CPU主体"
+	case WorkloadLogicalRead:
+		return "論理読み取り主体"
+	case WorkloadPhysicalRead:
+		return "物理読み取り主体"
+	}
+	return string(c)
+}
+
+// Advice - 分類ごとに効きやすい対策
+func (c WorkloadClass) Advice() string {
+	switch c {
+	case WorkloadCPU:
+		return "SQLの処理自体がCPUを使っています。Result Cache（/*+ RESULT_CACHE */）やマテリアライズドビューで集計結果を再利用すると、処理ごと省けるため最も効きます"
+	case WorkloadLogicalRead:
+		return "バッファキャッシュ上のブロックの読み取りが中心です。N+1の解消や索引の見直しで読むブロック数を減らすのが効きます（Result Cache・Redisは同じ結果を繰り返し読む場合に有効）"
+	case WorkloadPhysicalRead:
+		return "ディスクからの読み取りが中心です。キャッシュのヒット率より先に、バッファキャッシュのサイズ（V$DB_CACHE_ADVICE）や読むブロック数を見直してください（cold-read コマンドでコールドな読み取りの基準を確認できます）"
+	}
+	return ""
+}
+
+// classifyWorkload - セッション統計から手法の分類を求める（統計がなければ分類しない）
+//
+// 物理読み取りの割合が大きければ物理読み取り主体、そうでなければ論理読み取りの回数から見積もったCPU時間が
+// DBのCPU時間の大半を説明できるかで論理読み取り主体かCPU主体かを分ける。
+// CPU時間はセンチ秒単位のため、1センチ秒未満の短い実行は論理読み取りがあれば論理読み取り主体とする。
+func classifyWorkload(stats sessionstats.Stats) (WorkloadClass, bool) {
+	logical, ok := stats[sessionstats.LogicalReads]
+	if !ok {
+		return "", false
+	}
+	if logical > 0 && float64(stats[sessionstats.PhysicalReads])/float64(logical) >= physicalReadShare {
+		return WorkloadPhysicalRead, true
+	}
+
+	cpu := time.Duration(stats[sessionstats.CPUUsed]) * (time.Second / centisecondsPerSecond)
+	if cpu == 0 {
+		if logical == 0 {
+			return "", false
+		}
+		return WorkloadLogicalRead, true
+	}
+	if float64(time.Duration(logical)*logicalReadCPUCost) >= float64(cpu)*logicalReadCPUShare {
+		return WorkloadLogicalRead, true
+	}
+	return WorkloadCPU, true
+}
+
+// attachWorkloadClass - セッション統計から求めた分類を結果に付与する
+func attachWorkloadClass(result *PerformanceResult) {
+	if class, ok := classifyWorkload(result.SessionStats); ok {
+		result.Workload = class
+	}
+}
+
+// displayWorkloadClasses - 手法ごとの分類と、分類ごとに効きやすい対策を表示
+//
+// 同じシナリオでもN+1は論理読み取り主体、集計をDBに任せた手法はCPU主体になるなど、手法によって効く対策が変わる。
+func displayWorkloadClasses(results []PerformanceResult) {
+	var classes []WorkloadClass
+	seen := make(map[WorkloadClass]bool)
+	for _, result := range results {
+		if result.Workload != "" && !seen[result.Workload] {
+			seen[result.Workload] = true
+			classes = append(classes, result.Workload)
+		}
+	}
+	if len(classes) == 0 {
+		return
+	}
+
+	w := report.Stdout()
+	w.Heading("ワークロードの分類（CPU・論理読み取り・物理読み取り）")
+	table := report.NewTable(
+		report.Column{Key: "method", Header: "手法"},
+		report.Column{Key: "class", Header: "分類"},
+		report.Column{Key: "cpu", Header: "DB CPU", Align: report.AlignRight},
+		report.Column{Key: "logical", Header: "論理読み取り", Align: report.AlignRight},
+		report.Column{Key: "physical", Header: "物理読み取り", Align: report.AlignRight},
+	)
+	for _, result := range results {
+		if result.Workload == "" {
+			continue
+		}
+		stats := result.SessionStats
+		table.AddRow(
+			report.Text(result.Method),
+			report.Text(result.Workload.Label()),
+			report.Duration(time.Duration(stats[sessionstats.CPUUsed])*(time.Second/centisecondsPerSecond)),
+			report.Int(stats[sessionstats.LogicalReads]),
+			report.Int(stats[sessionstats.PhysicalReads]))
+	}
+	w.Table(table)
+	for _, class := range classes {
+		w.Linef("%s: %s", class.Label(), class.Advice())
+	}
+}
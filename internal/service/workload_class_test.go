@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"oracle-n-plus-1-demo/internal/sessionstats"
+)
+
+func TestClassifyWorkload(t *testing.T) {
+	tests := []struct {
+		name   string
+		stats  sessionstats.Stats
+		want   WorkloadClass
+		wantOK bool
+	}{
+		{name: "no stats", stats: nil},
+		{
+			name:   "physical reads",
+			stats:  sessionstats.Stats{sessionstats.LogicalReads: 1000, sessionstats.PhysicalReads: 200, sessionstats.CPUUsed: 1},
+			want:   WorkloadPhysicalRead,
+			wantOK: true,
+		},
+		{
+			name:   "logical reads explain cpu",
+			stats:  sessionstats.Stats{sessionstats.LogicalReads: 100000, sessionstats.PhysicalReads: 10, sessionstats.CPUUsed: 60},
+			want:   WorkloadLogicalRead,
+			wantOK: true,
+		},
+		{
+			name:   "aggregation cpu",
+			stats:  sessionstats.Stats{sessionstats.LogicalReads: 2000, sessionstats.CPUUsed: 50},
+			want:   WorkloadCPU,
+			wantOK: true,
+		},
+		{
+			name:   "below cpu resolution",
+			stats:  sessionstats.Stats{sessionstats.LogicalReads: 30, sessionstats.CPUUsed: 0},
+			want:   WorkloadLogicalRead,
+			wantOK: true,
+		},
+		{name: "idle", stats: sessionstats.Stats{sessionstats.LogicalReads: 0, sessionstats.CPUUsed: 0}},
+	}
+	for _, tt := range tests {
+		got, ok := classifyWorkload(tt.stats)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: classifyWorkload() = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package sessionstats
+
+import (
+	"fmt"
+
+	"oracle-n-plus-1-demo/internal/sqlutil"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// 計測対象のセッション統計名（V$STATNAME.NAME）
+const (
+	BytesSent       = "bytes sent via SQL*Net to client"
+	BytesReceived   = "bytes received via SQL*Net from client"
+	RoundTrips      = "SQL*Net roundtrips to/from client"
+	LogicalReads    = "session logical reads"
+	ParseCountTotal = "parse count (total)"
+	ParseCountHard  = "parse count (hard)"
+	ExecuteCount    = "execute count"
+	CursorCacheHits = "session cursor cache hits"
+	// CPUUsed - セッションが使ったCPU時間（センチ秒単位）
+	CPUUsed = "CPU used by this session"
+	// ParseTimeElapsed / ParseTimeCPU - パースにかかった経過時間とCPU時間（センチ秒単位）
+	ParseTimeElapsed = "parse time elapsed"
+	ParseTimeCPU     = "parse time cpu"
+	// PhysicalReads - ディスクから読んだブロック数（バッファキャッシュ経由とダイレクト・パスの合計）
+	PhysicalReads = "physical reads"
+	// PhysicalReadsCache / PhysicalReadsDirect - バッファキャッシュに読み込んだブロック数と、キャッシュを経由せずに読んだブロック数
+	PhysicalReadsCache  = "physical reads cache"
+	PhysicalReadsDirect = "physical reads direct"
+)
+
+// DefaultNames - 既定で取得する統計名
+var DefaultNames = []string{
+	BytesSent,
+	BytesReceived,
+	RoundTrips,
+	LogicalReads,
+	ParseCountTotal,
+	ParseCountHard,
+	ExecuteCount,
+	CursorCacheHits,
+	CPUUsed,
+	PhysicalReads,
+}
+
+// Stats - 統計名と値
+type Stats map[string]int64
+
+// Sub - 2つのスナップショットの差分（s - before）
+func (s Stats) Sub(before Stats) Stats {
+	diff := make(Stats, len(s))
+	for name, value := range s {
+		diff[name] = value - before[name]
+	}
+	return diff
+}
+
+// SoftParses - ソフトパース回数（パース総数 - ハードパース）
+func (s Stats) SoftParses() int64 {
+	soft := s[ParseCountTotal] - s[ParseCountHard]
+	if soft < 0 {
+		return 0
+	}
+	return soft
+}
+
+// Collector - 同一セッションのV$MYSTATを取得する
+//
+// スナップショット取得SQL自体の負荷（往復・パース・転送量）は初回に計測して差分から差し引く。
+type Collector struct {
+	db       repository.DBTX
+	names    []string
+	overhead Stats
+}
+
+// NewCollector - 統計コレクターのコンストラクタ（V$MYSTATへの権限がない場合はエラー）
+//
+// db は計測対象の処理と同じセッション（repository.ConnDB）である必要がある。
+func NewCollector(db repository.DBTX, names []string) (*Collector, error) {
+	if len(names) == 0 {
+		names = DefaultNames
+	}
+	c := &Collector{db: db, names: names}
+
+	// 権限確認を兼ねて2回取得し、スナップショット1回分のオーバーヘッドを求める
+	first, err := c.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	second, err := c.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	c.overhead = second.Sub(first)
+
+	return c, nil
+}
+
+// Snapshot - 現在の統計値を取得
+func (c *Collector) Snapshot() (Stats, error) {
+	return c.snapshot()
+}
+
+// Delta - before以降の差分からスナップショット取得分のオーバーヘッドを差し引く
+func (c *Collector) Delta(before Stats) (Stats, error) {
+	after, err := c.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	diff := after.Sub(before)
+	for name, value := range c.overhead {
+		diff[name] -= value
+		if diff[name] < 0 {
+			diff[name] = 0
+		}
+	}
+
+	return diff, nil
+}
+
+// snapshot - V$MYSTATとV$STATNAMEから統計値を取得
+func (c *Collector) snapshot() (Stats, error) {
+	query := fmt.Sprintf(`
+		SELECT sn.name, ms.value
+		FROM v$mystat ms
+		JOIN v$statname sn ON ms.statistic# = sn.statistic#
+		WHERE sn.name IN (%s)`, sqlutil.Placeholders(len(c.names)))
+
+	rows, err := c.db.Query(query, sqlutil.StringArgs(c.names)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v$mystat (SELECT権限が必要です): %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	stats := make(Stats, len(c.names))
+	for rows.Next() {
+		var name string
+		var value int64
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan session stat row: %w", err)
+		}
+		stats[name] = value
+	}
+
+	return stats, rows.Err()
+}
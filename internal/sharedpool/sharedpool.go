@@ -0,0 +1,228 @@
+package sharedpool
+
+import (
+	"fmt"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/sqlutil"
+	"oracle-n-plus-1-demo/repository"
+)
+
+// 計測対象のラッチ（11g以降のライブラリキャッシュはミューテックスのため待機イベントで見る）
+var latchNames = []string{"shared pool", "row cache objects"}
+
+// 計測対象の待機イベント
+var waitEvents = []string{
+	"library cache: mutex X",
+	"cursor: pin S wait on X",
+	"latch: shared pool",
+	"latch: row cache objects",
+}
+
+// LatchStats - ラッチの取得統計
+type LatchStats struct {
+	Gets   int64 `json:"gets"`
+	Misses int64 `json:"misses"`
+	Sleeps int64 `json:"sleeps"`
+}
+
+// WaitStats - 待機イベントの統計
+type WaitStats struct {
+	TotalWaits int64         `json:"total_waits"`
+	TimeWaited time.Duration `json:"time_waited"`
+}
+
+// Snapshot - インスタンス全体の共有プール関連統計
+//
+// V$SGASTAT / V$SYSSTAT / V$LATCH / V$SYSTEM_EVENT はインスタンス全体の値のため、
+// 同時に動いている他セッションの負荷も含まれる。
+type Snapshot struct {
+	FreeMemory  int64
+	HardParses  int64
+	TotalParses int64
+	Latches     map[string]LatchStats
+	Waits       map[string]WaitStats
+	// Unavailable - 権限不足などで取得できなかったビュー
+	Unavailable []string
+}
+
+// Delta - 2つのスナップショットの差分
+type Delta struct {
+	// FreeMemoryChange - 共有プール空きメモリの増減（負の値は消費）
+	FreeMemoryChange int64                 `json:"free_memory_change"`
+	HardParses       int64                 `json:"hard_parses"`
+	TotalParses      int64                 `json:"total_parses"`
+	Latches          map[string]LatchStats `json:"latches,omitempty"`
+	Waits            map[string]WaitStats  `json:"waits,omitempty"`
+	Unavailable      []string              `json:"unavailable,omitempty"`
+}
+
+// Take - 取得できる範囲で統計を取得（権限がないビューはUnavailableに記録して続行）
+func Take(db repository.DBTX) *Snapshot {
+	s := &Snapshot{
+		Latches: make(map[string]LatchStats),
+		Waits:   make(map[string]WaitStats),
+	}
+
+	if err := db.QueryRow(`
+		SELECT NVL(SUM(bytes), 0)
+		FROM v$sgastat
+		WHERE pool = 'shared pool' AND name = 'free memory'`).Scan(&s.FreeMemory); err != nil {
+		s.Unavailable = append(s.Unavailable, "V$SGASTAT")
+	}
+
+	if err := s.loadParseCounts(db); err != nil {
+		s.Unavailable = append(s.Unavailable, "V$SYSSTAT")
+	}
+	if err := s.loadLatches(db); err != nil {
+		s.Unavailable = append(s.Unavailable, "V$LATCH")
+	}
+	if err := s.loadWaits(db); err != nil {
+		s.Unavailable = append(s.Unavailable, "V$SYSTEM_EVENT")
+	}
+
+	return s
+}
+
+// Delta - before以降の差分
+func (s *Snapshot) Delta(before *Snapshot) *Delta {
+	d := &Delta{
+		FreeMemoryChange: s.FreeMemory - before.FreeMemory,
+		HardParses:       s.HardParses - before.HardParses,
+		TotalParses:      s.TotalParses - before.TotalParses,
+		Latches:          make(map[string]LatchStats),
+		Waits:            make(map[string]WaitStats),
+		Unavailable:      mergeUnavailable(before.Unavailable, s.Unavailable),
+	}
+
+	for name, after := range s.Latches {
+		prev := before.Latches[name]
+		d.Latches[name] = LatchStats{
+			Gets:   after.Gets - prev.Gets,
+			Misses: after.Misses - prev.Misses,
+			Sleeps: after.Sleeps - prev.Sleeps,
+		}
+	}
+	for name, after := range s.Waits {
+		prev := before.Waits[name]
+		d.Waits[name] = WaitStats{
+			TotalWaits: after.TotalWaits - prev.TotalWaits,
+			TimeWaited: after.TimeWaited - prev.TimeWaited,
+		}
+	}
+
+	return d
+}
+
+// Available - 指定したビューの統計を取得できたか
+func (d *Delta) Available(view string) bool {
+	for _, v := range d.Unavailable {
+		if v == view {
+			return false
+		}
+	}
+	return true
+}
+
+// loadParseCounts - インスタンス全体のパース回数を取得
+func (s *Snapshot) loadParseCounts(db repository.DBTX) error {
+	rows, err := db.Query(`
+		SELECT name, value
+		FROM v$sysstat
+		WHERE name IN ('parse count (hard)', 'parse count (total)')`)
+	if err != nil {
+		return fmt.Errorf("failed to query v$sysstat: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var name string
+		var value int64
+		if err := rows.Scan(&name, &value); err != nil {
+			return fmt.Errorf("failed to scan v$sysstat row: %w", err)
+		}
+		switch name {
+		case "parse count (hard)":
+			s.HardParses = value
+		case "parse count (total)":
+			s.TotalParses = value
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadLatches - 共有プール関連ラッチの統計を取得
+func (s *Snapshot) loadLatches(db repository.DBTX) error {
+	query := fmt.Sprintf(`
+		SELECT name, gets, misses, sleeps
+		FROM v$latch
+		WHERE name IN (%s)`, sqlutil.Placeholders(len(latchNames)))
+
+	rows, err := db.Query(query, sqlutil.StringArgs(latchNames)...)
+	if err != nil {
+		return fmt.Errorf("failed to query v$latch: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var name string
+		var stats LatchStats
+		if err := rows.Scan(&name, &stats.Gets, &stats.Misses, &stats.Sleeps); err != nil {
+			return fmt.Errorf("failed to scan v$latch row: %w", err)
+		}
+		s.Latches[name] = stats
+	}
+
+	return rows.Err()
+}
+
+// loadWaits - ライブラリキャッシュ・共有プール関連の待機イベントを取得
+func (s *Snapshot) loadWaits(db repository.DBTX) error {
+	query := fmt.Sprintf(`
+		SELECT event, total_waits, time_waited_micro
+		FROM v$system_event
+		WHERE event IN (%s)`, sqlutil.Placeholders(len(waitEvents)))
+
+	rows, err := db.Query(query, sqlutil.StringArgs(waitEvents)...)
+	if err != nil {
+		return fmt.Errorf("failed to query v$system_event: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var event string
+		var waits, micros int64
+		if err := rows.Scan(&event, &waits, &micros); err != nil {
+			return fmt.Errorf("failed to scan v$system_event row: %w", err)
+		}
+		s.Waits[event] = WaitStats{TotalWaits: waits, TimeWaited: time.Duration(micros) * time.Microsecond}
+	}
+
+	return rows.Err()
+}
+
+// mergeUnavailable - どちらかのスナップショットで取得できなかったビューを重複なしで返す
+func mergeUnavailable(a, b []string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, v := range append(append([]string{}, a...), b...) {
+		if !seen[v] {
+			seen[v] = true
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,147 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/fileutil"
+)
+
+// Algorithm - 署名アルゴリズム
+const Algorithm = "HMAC-SHA256"
+
+// SignatureSuffix - 署名ファイルの拡張子（results.json → results.json.sig）
+const SignatureSuffix = ".sig"
+
+// ErrNoKey - 署名鍵が設定されていない場合のエラー
+var ErrNoKey = errors.New("signing key is not configured")
+
+// ErrSignatureMismatch - 署名が一致しない（ファイルが改ざんされたか鍵が異なる）場合のエラー
+var ErrSignatureMismatch = errors.New("signature mismatch")
+
+// Signature - 結果ファイルと同じ場所に置く分離署名
+//
+// HMACはデータそのものではなく、アルゴリズム・ファイル名・SHA-256・署名日時を並べたもの（payload）に対して計算する。
+// SHA-256がデータと一致することも確認するため、データと署名ファイルの記載のどちらを書き換えても検証に失敗する。
+type Signature struct {
+	Algorithm string    `json:"algorithm"`
+	File      string    `json:"file"`
+	SHA256    string    `json:"sha256"`
+	HMAC      string    `json:"hmac"`
+	SignedAt  time.Time `json:"signed_at"`
+}
+
+// SignFile - ファイルのHMACを計算して <path>.sig に書き出す
+func SignFile(path string, key []byte) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file to sign: %w", err)
+	}
+
+	jsonData, err := Sign(filepath.Base(path), data, key)
+	if err != nil {
+		return "", err
+	}
+
+	sigPath := path + SignatureSuffix
+	if err := fileutil.WriteFileAtomic(sigPath, jsonData, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write signature: %w", err)
+	}
+
+	return sigPath, nil
+}
+
+// Sign - dataの分離署名をJSONで返す（ファイル以外の出力先に送る場合に使う）
+func Sign(name string, data, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrNoKey
+	}
+
+	sig := Signature{
+		Algorithm: Algorithm,
+		File:      name,
+		SHA256:    digest(data),
+		SignedAt:  time.Now().UTC(),
+	}
+	sig.HMAC = mac(sig.payload(), key)
+
+	jsonData, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signature: %w", err)
+	}
+
+	return jsonData, nil
+}
+
+// VerifyFile - 署名ファイルと照合する（sigPathが空の場合は <path>.sig）
+func VerifyFile(path, sigPath string, key []byte) (*Signature, error) {
+	if len(key) == 0 {
+		return nil, ErrNoKey
+	}
+	if sigPath == "" {
+		sigPath = path + SignatureSuffix
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file to verify: %w", err)
+	}
+	sigData, err := os.ReadFile(sigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	var sig Signature
+	if err := json.Unmarshal(sigData, &sig); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+	if sig.Algorithm != Algorithm {
+		return &sig, fmt.Errorf("unsupported signature algorithm: %q", sig.Algorithm)
+	}
+
+	expected, err := hex.DecodeString(sig.HMAC)
+	if err != nil {
+		return &sig, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	actual, _ := hex.DecodeString(mac(sig.payload(), key))
+	if !hmac.Equal(expected, actual) {
+		return &sig, ErrSignatureMismatch
+	}
+	// 署名ファイルの記載が正しいことを確かめてから、データと照合する
+	if !hmac.Equal([]byte(sig.SHA256), []byte(digest(data))) {
+		return &sig, fmt.Errorf("%w: sha256 does not match the file", ErrSignatureMismatch)
+	}
+
+	return &sig, nil
+}
+
+// payload - HMACを計算する対象（各項目を改行で区切る、署名日時はUTCのRFC 3339）
+func (s *Signature) payload() []byte {
+	return []byte(strings.Join([]string{
+		s.Algorithm,
+		s.File,
+		s.SHA256,
+		s.SignedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n") + "\n")
+}
+
+// mac - HMAC-SHA256を16進文字列で返す
+func mac(data, key []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// digest - SHA-256を16進文字列で返す（鍵なしで内容の同一性を確認する用途）
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
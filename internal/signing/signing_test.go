@@ -0,0 +1,146 @@
+package signing
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testKey = []byte("test-signing-key")
+
+// signTestFile - 一時ディレクトリにresults.jsonを書き出して署名する
+func signTestFile(t *testing.T) (path, sigPath string) {
+	t.Helper()
+	path = filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(path, []byte(`{"method":"JOIN_Optimized"}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	sigPath, err := SignFile(path, testKey)
+	if err != nil {
+		t.Fatalf("SignFile() failed: %v", err)
+	}
+	return path, sigPath
+}
+
+// editSignature - 署名ファイルの記載を書き換える
+func editSignature(t *testing.T, sigPath string, edit func(sig *Signature)) {
+	t.Helper()
+	data, err := os.ReadFile(sigPath)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	var sig Signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	edit(&sig)
+	if data, err = json.Marshal(sig); err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	if err := os.WriteFile(sigPath, data, 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	path, sigPath := signTestFile(t)
+	if sigPath != path+SignatureSuffix {
+		t.Errorf("SignFile() = %q, want %q", sigPath, path+SignatureSuffix)
+	}
+
+	sig, err := VerifyFile(path, "", testKey)
+	if err != nil {
+		t.Fatalf("VerifyFile() failed: %v", err)
+	}
+	if sig.File != "results.json" || sig.SHA256 != digest([]byte(`{"method":"JOIN_Optimized"}`)) || sig.Algorithm != Algorithm {
+		t.Errorf("VerifyFile() = %+v", sig)
+	}
+	if time.Since(sig.SignedAt) > time.Minute {
+		t.Errorf("VerifyFile() SignedAt = %v, want now", sig.SignedAt)
+	}
+
+	// 署名ファイルを別の場所に移しても -sig で指定すれば検証できる
+	moved := filepath.Join(t.TempDir(), "results.json.sig")
+	if err := os.Rename(sigPath, moved); err != nil {
+		t.Fatalf("Rename() failed: %v", err)
+	}
+	if _, err := VerifyFile(path, moved, testKey); err != nil {
+		t.Errorf("VerifyFile(-sig) failed: %v", err)
+	}
+}
+
+func TestVerifyTamperedData(t *testing.T) {
+	path, _ := signTestFile(t)
+	if err := os.WriteFile(path, []byte(`{"method":"N+1_Problem"}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if _, err := VerifyFile(path, "", testKey); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("VerifyFile(tampered data) error = %v, want %v", err, ErrSignatureMismatch)
+	}
+}
+
+func TestVerifyTamperedMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(sig *Signature)
+	}{
+		{name: "signed_at", edit: func(sig *Signature) { sig.SignedAt = sig.SignedAt.AddDate(0, -1, 0) }},
+		{name: "file", edit: func(sig *Signature) { sig.File = "approved.json" }},
+		{name: "sha256", edit: func(sig *Signature) { sig.SHA256 = digest([]byte("other")) }},
+		{name: "hmac", edit: func(sig *Signature) { sig.HMAC = mac([]byte("other"), testKey) }},
+	}
+	for _, tt := range tests {
+		path, sigPath := signTestFile(t)
+		editSignature(t, sigPath, tt.edit)
+		if _, err := VerifyFile(path, "", testKey); !errors.Is(err, ErrSignatureMismatch) {
+			t.Errorf("VerifyFile(tampered %s) error = %v, want %v", tt.name, err, ErrSignatureMismatch)
+		}
+	}
+
+	// データとSHA-256を同時に書き換えてもHMACが合わない
+	path, sigPath := signTestFile(t)
+	other := []byte(`{"method":"N+1_Problem"}`)
+	if err := os.WriteFile(path, other, 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	editSignature(t, sigPath, func(sig *Signature) { sig.SHA256 = digest(other) })
+	if _, err := VerifyFile(path, "", testKey); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("VerifyFile(tampered data and sha256) error = %v, want %v", err, ErrSignatureMismatch)
+	}
+
+	// 対応していないアルゴリズムは照合しない
+	path, sigPath = signTestFile(t)
+	editSignature(t, sigPath, func(sig *Signature) { sig.Algorithm = "NONE" })
+	if _, err := VerifyFile(path, "", testKey); err == nil || errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("VerifyFile(algorithm NONE) error = %v, want unsupported algorithm", err)
+	}
+}
+
+func TestVerifyWrongKey(t *testing.T) {
+	path, _ := signTestFile(t)
+	if _, err := VerifyFile(path, "", []byte("other-key")); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("VerifyFile(wrong key) error = %v, want %v", err, ErrSignatureMismatch)
+	}
+}
+
+func TestMissingKey(t *testing.T) {
+	if _, err := Sign("results.json", []byte("{}"), nil); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Sign(no key) error = %v, want %v", err, ErrNoKey)
+	}
+	path := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if _, err := SignFile(path, nil); !errors.Is(err, ErrNoKey) {
+		t.Errorf("SignFile(no key) error = %v, want %v", err, ErrNoKey)
+	}
+	if _, err := os.Stat(path + SignatureSuffix); !os.IsNotExist(err) {
+		t.Errorf("SignFile(no key) wrote a signature: %v", err)
+	}
+	if _, err := VerifyFile(path, "", nil); !errors.Is(err, ErrNoKey) {
+		t.Errorf("VerifyFile(no key) error = %v, want %v", err, ErrNoKey)
+	}
+}
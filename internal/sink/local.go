@@ -0,0 +1,47 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"oracle-n-plus-1-demo/internal/fileutil"
+)
+
+// StdoutSink - 標準出力に書き出す
+type StdoutSink struct{}
+
+// Write - dataをそのまま標準出力に書き出す
+func (s *StdoutSink) Write(_ context.Context, _ string, data []byte) error {
+	if _, err := os.Stdout.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write stdout: %w", err)
+	}
+	return nil
+}
+
+// String - 出力先の表示
+func (s *StdoutSink) String() string {
+	return "stdout"
+}
+
+// FileSink - ディレクトリにファイルとして保存する
+type FileSink struct {
+	Dir string
+}
+
+// Write - Dir/name に保存（ディレクトリがなければ作成）
+func (s *FileSink) Write(_ context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := fileutil.WriteFileAtomic(filepath.Join(s.Dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// String - 出力先の表示
+func (s *FileSink) String() string {
+	return "file:" + s.Dir
+}
@@ -0,0 +1,89 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTPSink - コレクターのエンドポイントにJSONをPOSTする
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// Write - dataをPOST（X-Result-Nameヘッダーに名前を付ける）
+func (s *HTTPSink) Write(ctx context.Context, name string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Result-Name", name)
+
+	return doUpload(s.Client, req)
+}
+
+// String - 出力先の表示（クエリ文字列のトークンなどは表示しない）
+func (s *HTTPSink) String() string {
+	return redactURL(s.URL)
+}
+
+// ObjectPutSink - URLの末尾にオブジェクト名を付けてPUTする
+//
+// OCI Object Storageの事前認証済みリクエスト（PAR）のように、
+// URL自体に認可が含まれるストレージへのアップロードに使う。
+type ObjectPutSink struct {
+	URLPrefix string
+	Client    *http.Client
+}
+
+// Write - URLPrefix + name にPUT
+func (s *ObjectPutSink) Write(ctx context.Context, name string, data []byte) error {
+	target := strings.TrimSuffix(s.URLPrefix, "/") + "/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doUpload(s.Client, req)
+}
+
+// String - 出力先の表示（PARのトークン部分は表示しない）
+func (s *ObjectPutSink) String() string {
+	return "oci-par:" + redactURL(s.URLPrefix)
+}
+
+// doUpload - リクエストを送信し、2xx以外をエラーにする
+func doUpload(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			fmt.Printf("resp.Body.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// redactURL - ホスト名までを残してパスとクエリを伏せる
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(invalid url)"
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
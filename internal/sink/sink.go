@@ -0,0 +1,82 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrUnknownSink - 解釈できない出力先が指定された場合のエラー
+var ErrUnknownSink = errors.New("unknown sink")
+
+// defaultTimeout - リモート出力先への送信タイムアウト
+const defaultTimeout = 30 * time.Second
+
+// Sink - 計測結果の出力先
+type Sink interface {
+	// Write - nameの名前でdataを書き出す（nameはファイル名・オブジェクト名として使う）
+	Write(ctx context.Context, name string, data []byte) error
+	// String - ログ表示用の出力先
+	String() string
+}
+
+// Parse - 出力先の指定を解釈する
+//
+//	stdout                       標準出力
+//	file:DIR                     DIR/<name> に保存
+//	http(s)://...                JSONをPOST（コレクター向け）
+//	s3://BUCKET/PREFIX           S3へPUT（AWS_REGION / AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY）
+//	oci-par:https://.../o/PREFIX OCI Object Storageの事前認証済みリクエスト（PAR）URLへPUT
+func Parse(spec string) (Sink, error) {
+	client := &http.Client{Timeout: defaultTimeout}
+
+	switch {
+	case spec == "stdout":
+		return &StdoutSink{}, nil
+	case strings.HasPrefix(spec, "file:"):
+		dir := strings.TrimPrefix(spec, "file:")
+		if dir == "" {
+			return nil, fmt.Errorf("%w: file sink requires a directory", ErrUnknownSink)
+		}
+		return &FileSink{Dir: dir}, nil
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return &HTTPSink{URL: spec, Client: client}, nil
+	case strings.HasPrefix(spec, "s3://"):
+		return newS3SinkFromEnv(strings.TrimPrefix(spec, "s3://"), client)
+	case strings.HasPrefix(spec, "oci-par:"):
+		return &ObjectPutSink{URLPrefix: strings.TrimPrefix(spec, "oci-par:"), Client: client}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSink, spec)
+	}
+}
+
+// ParseList - カンマ区切りの出力先を解釈する
+func ParseList(specs string) ([]Sink, error) {
+	var sinks []Sink
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		s, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// WriteAll - すべての出力先に書き出す（失敗した出力先があっても残りには書き出す）
+func WriteAll(ctx context.Context, sinks []Sink, name string, data []byte) error {
+	var errs []error
+	for _, s := range sinks {
+		if err := s.Write(ctx, name, data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,313 @@
+package sqlscript
+
+import "fmt"
+
+// inChunkSize - IN句1回あたりのID数（sqlutil.DefaultInChunkSizeと同じ）
+const inChunkSize = 1000
+
+// bindLoop - 親クエリの各行について「:idに値を入れて子クエリを実行する」スクリプトを生成するSQL
+//
+// idExprは子クエリに渡す値の式、fromは ORDER BY を含む親クエリのFROM句以降。
+func bindLoop(idExpr, child, from string) string {
+	return fmt.Sprintf(`SELECT 'EXEC :id := ' || %s || CHR(10) ||
+       '%s;'
+%s`, idExpr, child, from)
+}
+
+// inListLoop - 親クエリのIDを1000件ずつIN句に並べた子クエリのスクリプトを生成するSQL
+//
+// IDはリテラルとして埋め込む（SQL*Plusで1000個のバインド変数を扱うのは現実的でないため）。
+// head は「... IN (」までの子クエリ、tail は「)」以降（ORDER BY など）。
+// inner は id 列を1列返す副問合せ。
+func inListLoop(head, tail, inner string) string {
+	return fmt.Sprintf(`SELECT CASE WHEN MOD(rn, %[1]d) = 1 THEN '%[2]s' END
+       || id
+       || CASE WHEN MOD(rn, %[1]d) = 0 OR rn = cnt THEN ')%[3]s;' ELSE ',' END
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY id) AS rn, COUNT(*) OVER () AS cnt
+    FROM (%[4]s)
+)
+ORDER BY rn`, inChunkSize, head, tail, inner)
+}
+
+const (
+	ordersQuery = `SELECT order_id, customer_id, order_date, total_amount
+FROM orders
+WHERE order_date >= SYSDATE - :days
+ORDER BY order_id`
+
+	orderDetailsByIDQuery = `SELECT detail_id, order_id, product_id, quantity, unit_price FROM order_details WHERE order_id = :id`
+
+	ordersJoinQuery = `SELECT o.order_id, o.customer_id, o.order_date, o.total_amount,
+       od.detail_id, od.product_id, od.quantity, od.unit_price
+FROM orders o
+LEFT JOIN order_details od ON o.order_id = od.order_id
+WHERE o.order_date >= SYSDATE - :days
+ORDER BY o.order_id, od.detail_id`
+
+	ordersSelectStarQuery = `SELECT o.*, od.*
+FROM orders o
+LEFT JOIN order_details od ON o.order_id = od.order_id
+WHERE o.order_date >= SYSDATE - :days
+ORDER BY o.order_id, od.detail_id`
+
+	employeesQuery = `SELECT employee_id, first_name, last_name, email, department_id, hire_date, salary
+FROM employees
+ORDER BY employee_id`
+
+	departmentByIDQuery = `SELECT department_id, department_name, location FROM departments WHERE department_id = :id`
+
+	employeesJoinQuery = `SELECT e.employee_id, e.first_name, e.last_name, e.email, e.department_id, e.hire_date, e.salary,
+       d.department_name, d.location
+FROM employees e
+LEFT JOIN departments d ON e.department_id = d.department_id
+ORDER BY e.employee_id`
+
+	assignmentsByEmployeeQuery = `SELECT project_id, project_role FROM employee_projects WHERE employee_id = :id ORDER BY project_id`
+
+	projectByIDQuery = `SELECT project_id, project_name, NVL(budget, 0) FROM projects WHERE project_id = :id`
+
+	employeeProjectsJoinQuery = `SELECT e.employee_id, e.first_name, e.last_name, e.email, e.department_id, e.hire_date, e.salary,
+       p.project_id, p.project_name, p.budget, ep.project_role
+FROM employees e
+LEFT JOIN employee_projects ep ON e.employee_id = ep.employee_id
+LEFT JOIN projects p ON ep.project_id = p.project_id
+ORDER BY e.employee_id, p.project_id`
+
+	monthlySalesRawQuery = `SELECT o.order_id, o.customer_id, o.order_date, od.quantity, od.unit_price
+FROM orders o
+JOIN order_details od ON o.order_id = od.order_id
+WHERE o.order_date >= ADD_MONTHS(TRUNC(SYSDATE, 'MM'), -:months)`
+
+	monthlySalesGroupByQuery = `SELECT %so.customer_id,
+       TO_CHAR(TRUNC(o.order_date, 'MM'), 'YYYY-MM') AS sales_month,
+       COUNT(DISTINCT o.order_id),
+       SUM(od.quantity * od.unit_price)
+FROM orders o
+JOIN order_details od ON o.order_id = od.order_id
+WHERE o.order_date >= ADD_MONTHS(TRUNC(SYSDATE, 'MM'), -:months)
+GROUP BY o.customer_id, TRUNC(o.order_date, 'MM')
+ORDER BY o.customer_id, sales_month`
+
+	monthlySalesViewQuery = `SELECT customer_id, TO_CHAR(sales_month, 'YYYY-MM'), order_count, revenue
+FROM mv_monthly_customer_sales
+WHERE sales_month >= ADD_MONTHS(TRUNC(SYSDATE, 'MM'), -:months)
+ORDER BY customer_id, sales_month`
+
+	topCustomersQuery = `SELECT customer_id, MAX(customer_name), SUM(total_amount) AS revenue
+FROM orders
+GROUP BY customer_id
+ORDER BY revenue DESC, customer_id
+FETCH FIRST :top_customers ROWS ONLY`
+
+	recentOrdersByCustomerQuery = `SELECT order_id, customer_id, order_date, total_amount FROM orders WHERE customer_id = :id ORDER BY order_date DESC, order_id DESC FETCH FIRST :recent_orders ROWS ONLY`
+
+	topCustomersRowNumberQuery = `WITH top_customers AS (
+    SELECT customer_id, MAX(customer_name) AS customer_name, SUM(total_amount) AS revenue
+    FROM orders
+    GROUP BY customer_id
+    ORDER BY revenue DESC, customer_id
+    FETCH FIRST :top_customers ROWS ONLY
+),
+ranked_orders AS (
+    SELECT o.order_id, o.customer_id, o.order_date, o.total_amount,
+           ROW_NUMBER() OVER (PARTITION BY o.customer_id ORDER BY o.order_date DESC, o.order_id DESC) AS rn
+    FROM orders o
+    JOIN top_customers t ON o.customer_id = t.customer_id
+)
+SELECT t.customer_id, t.customer_name, t.revenue, r.order_id, r.order_date, r.total_amount
+FROM top_customers t
+JOIN ranked_orders r ON r.customer_id = t.customer_id
+WHERE r.rn <= :recent_orders
+ORDER BY t.revenue DESC, t.customer_id, r.rn`
+)
+
+// literalIDsNote - IN句にIDをリテラルで埋め込むことの注意書き
+const literalIDsNote = "Batch_Optimized はIN句のIDをリテラルで埋め込むため、Goのデモ（バインド変数）よりハードパースが増えます"
+
+// bindLoopNote - ループの再現方法の注意書き
+const bindLoopNote = "N+1のループは子クエリごとに EXEC でバインド変数を設定するため、Goのデモよりラウンドトリップが1回ずつ多くなります"
+
+// scenarios - 出力するシナリオ（ドライバー内部の挙動やGo側の処理が主体のシナリオは対象外）
+var scenarios = []Scenario{
+	{
+		ID:    "orders",
+		Title: "受注と明細の取得",
+		Notes: []string{bindLoopNote, literalIDsNote},
+		Methods: []Method{
+			{
+				Name:        "N+1_Problem",
+				Description: "受注ごとに明細を取得（1 + 受注数回のSQL）",
+				Statements:  []string{ordersQuery},
+				Loop: bindLoop("order_id", orderDetailsByIDQuery,
+					"FROM orders\nWHERE order_date >= SYSDATE - :days\nORDER BY order_id"),
+				Plans: []string{orderDetailsByIDQuery},
+			},
+			{
+				Name:        "JOIN_Optimized",
+				Description: "LEFT JOINで受注と明細を1回で取得",
+				Statements:  []string{ordersJoinQuery},
+				Plans:       []string{ordersJoinQuery},
+			},
+			{
+				Name:        "Batch_Optimized",
+				Description: "受注を取得し、明細をIN句でまとめて取得",
+				Statements:  []string{ordersQuery},
+				Loop: inListLoop(
+					"SELECT detail_id, order_id, product_id, quantity, unit_price FROM order_details WHERE order_id IN (",
+					" ORDER BY order_id, detail_id",
+					"SELECT order_id AS id FROM orders WHERE order_date >= SYSDATE - :days"),
+			},
+		},
+	},
+	{
+		ID:    "employees",
+		Title: "社員と部署の取得",
+		Notes: []string{bindLoopNote, literalIDsNote},
+		Methods: []Method{
+			{
+				Name:        "N+1_Problem",
+				Description: "社員ごとに部署を取得（1 + 社員数回のSQL）",
+				Statements:  []string{employeesQuery},
+				Loop:        bindLoop("department_id", departmentByIDQuery, "FROM employees\nORDER BY employee_id"),
+				Plans:       []string{departmentByIDQuery},
+			},
+			{
+				Name:        "Memoized_Partial",
+				Description: "ユニークな部署ごとに1回だけ取得（1 + 部署数回のSQL）",
+				Statements:  []string{employeesQuery},
+				Loop: bindLoop("department_id", departmentByIDQuery,
+					"FROM (SELECT department_id, MIN(employee_id) AS first_employee FROM employees GROUP BY department_id)\nORDER BY first_employee"),
+			},
+			{
+				Name:        "Batch_Optimized",
+				Description: "社員を取得し、部署をIN句でまとめて取得",
+				Statements:  []string{employeesQuery},
+				Loop: inListLoop(
+					"SELECT department_id, department_name, location FROM departments WHERE department_id IN (",
+					"",
+					"SELECT DISTINCT department_id AS id FROM employees WHERE department_id IS NOT NULL"),
+			},
+			{
+				Name:        "JOIN_Optimized",
+				Description: "LEFT JOINで社員と部署を1回で取得",
+				Statements:  []string{employeesJoinQuery},
+				Plans:       []string{employeesJoinQuery},
+			},
+		},
+	},
+	{
+		ID:    "employee_projects",
+		Title: "社員とプロジェクト（多対多）の取得",
+		Notes: []string{bindLoopNote, literalIDsNote},
+		Methods: []Method{
+			{
+				Name:        "N+1_Problem",
+				Description: "社員ごとに割り当てを、割り当てごとにプロジェクトを取得（1 + 社員数 + 割り当て数回のSQL）",
+				Statements:  []string{employeesQuery},
+				Loop: `SELECT text
+FROM (
+    SELECT employee_id, 0 AS seq, 0 AS project_id,
+           'EXEC :id := ' || employee_id || CHR(10) ||
+           '` + assignmentsByEmployeeQuery + `;' AS text
+    FROM employees
+    UNION ALL
+    SELECT employee_id, 1, project_id,
+           'EXEC :id := ' || project_id || CHR(10) ||
+           '` + projectByIDQuery + `;'
+    FROM employee_projects
+)
+ORDER BY employee_id, seq, project_id`,
+				Plans: []string{assignmentsByEmployeeQuery, projectByIDQuery},
+			},
+			{
+				Name:        "Batch_Optimized",
+				Description: "社員を取得し、割り当てとプロジェクトをIN句でまとめて取得",
+				Statements:  []string{employeesQuery},
+				Loop: inListLoop(
+					"SELECT ep.employee_id, p.project_id, p.project_name, p.budget, ep.project_role FROM employee_projects ep JOIN projects p ON ep.project_id = p.project_id WHERE ep.employee_id IN (",
+					" ORDER BY ep.employee_id, p.project_id",
+					"SELECT employee_id AS id FROM employees"),
+			},
+			{
+				Name:        "JOIN_Optimized",
+				Description: "3表のLEFT JOINで1回で取得",
+				Statements:  []string{employeeProjectsJoinQuery},
+				Plans:       []string{employeeProjectsJoinQuery},
+			},
+		},
+	},
+	{
+		ID:    "monthly_sales",
+		Title: "顧客別・月別売上レポート",
+		Notes: []string{
+			"App_Side_Aggregation は明細行の取得（転送）までを計測します。Goでの集計処理の時間は含みません",
+			"Materialized_View は MV_MONTHLY_CUSTOMER_SALES が存在しない場合エラーになります",
+		},
+		Methods: []Method{
+			{
+				Name:        "App_Side_Aggregation",
+				Description: "全明細行を取得（集計はアプリ側）",
+				Statements:  []string{monthlySalesRawQuery},
+				Plans:       []string{monthlySalesRawQuery},
+			},
+			{
+				Name:        "SQL_GroupBy",
+				Description: "GROUP BYでSQL側集計",
+				Statements:  []string{fmt.Sprintf(monthlySalesGroupByQuery, "")},
+				Plans:       []string{fmt.Sprintf(monthlySalesGroupByQuery, "")},
+			},
+			{
+				Name:        "SQL_ResultCache",
+				Description: "RESULT_CACHEヒント付きのGROUP BY",
+				Statements:  []string{fmt.Sprintf(monthlySalesGroupByQuery, "/*+ RESULT_CACHE */ ")},
+				Plans:       []string{fmt.Sprintf(monthlySalesGroupByQuery, "/*+ RESULT_CACHE */ ")},
+			},
+			{
+				Name:        "Materialized_View",
+				Description: "事前集計済みのマテリアライズドビューから取得",
+				Statements:  []string{monthlySalesViewQuery},
+				Plans:       []string{monthlySalesViewQuery},
+			},
+		},
+	},
+	{
+		ID:    "top_customers",
+		Title: "売上上位顧客と直近受注（Top-N）",
+		Notes: []string{bindLoopNote},
+		Methods: []Method{
+			{
+				Name:        "N+1_Problem",
+				Description: "上位顧客を取得し、顧客ごとに直近受注を取得（1 + 顧客数回のSQL）",
+				Statements:  []string{topCustomersQuery},
+				Loop: bindLoop("customer_id", recentOrdersByCustomerQuery,
+					"FROM (\n"+topCustomersQuery+"\n)\nORDER BY revenue DESC, customer_id"),
+				Plans: []string{recentOrdersByCustomerQuery},
+			},
+			{
+				Name:        "Analytic_RowNumber",
+				Description: "ROW_NUMBER() OVER (PARTITION BY ...) で1回で取得",
+				Statements:  []string{topCustomersRowNumberQuery},
+				Plans:       []string{topCustomersRowNumberQuery},
+			},
+		},
+	},
+	{
+		ID:    "column_pruning",
+		Title: "SELECT列の絞り込みと過剰取得",
+		Methods: []Method{
+			{
+				Name:        "SelectStar",
+				Description: "SELECT o.*, od.* で全列を取得（過剰取得）",
+				Statements:  []string{ordersSelectStarQuery},
+				Plans:       []string{ordersSelectStarQuery},
+			},
+			{
+				Name:        "SelectColumns",
+				Description: "画面で使う8列だけを指定したJOIN",
+				Statements:  []string{ordersJoinQuery},
+				Plans:       []string{ordersJoinQuery},
+			},
+		},
+	},
+}
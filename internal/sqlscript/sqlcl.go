@@ -0,0 +1,124 @@
+package sqlscript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQLclバンドルで書き出すファイル名
+const (
+	BenchmarkFile = "run_benchmark.sql" // 全シナリオを繰り返し実行して経過時間を書き出すスクリプト
+	TimingsFile   = "timings.csv"       // 実行ごとの経過時間（SQLcl の SET SQLFORMAT CSV で出力）
+	ChartFile     = "timings_chart.txt" // 手法ごとの平均と棒グラフ
+)
+
+// chartWidth - 棒グラフの最大の長さ（シナリオ内で最も遅い手法の長さ）
+const chartWidth = 40
+
+// captureStart - 計測区間の開始時刻を :t0 に記録する
+const captureStart = "EXEC :t0 := TO_CHAR(SYSTIMESTAMP, 'YYYY-MM-DD HH24:MI:SS.FF6 TZH:TZM')\n"
+
+// captureStop - 計測区間の経過時間（ミリ秒）を「シナリオ,手法,実行回,ミリ秒;」として :timings に追記する
+//
+// 小数点はNLSの設定によらず「.」にする（CSVの区切りと衝突させないため）。
+const captureStop = `DECLARE
+    elapsed INTERVAL DAY TO SECOND := SYSTIMESTAMP - TO_TIMESTAMP_TZ(:t0, 'YYYY-MM-DD HH24:MI:SS.FF6 TZH:TZM');
+BEGIN
+    :timings := :timings || '%s,%s,&run,' || TO_CHAR(
+        EXTRACT(DAY FROM elapsed) * 86400000 + EXTRACT(HOUR FROM elapsed) * 3600000
+            + EXTRACT(MINUTE FROM elapsed) * 60000 + EXTRACT(SECOND FROM elapsed) * 1000,
+        'FM999999990.000', 'NLS_NUMERIC_CHARACTERS=''.,''') || ';';
+END;
+/
+`
+
+// timingsQuery - :timings を1行1実行の表に展開する副問合せ（WITH句の一部）
+const timingsQuery = `WITH lines AS (
+    SELECT LEVEL AS line_no, TO_CHAR(REGEXP_SUBSTR(:timings, '[^;]+', 1, LEVEL)) AS line
+    FROM dual
+    CONNECT BY LEVEL <= REGEXP_COUNT(:timings, ';')
+),
+timings AS (
+    SELECT line_no,
+           REGEXP_SUBSTR(line, '[^,]+', 1, 1) AS scenario,
+           REGEXP_SUBSTR(line, '[^,]+', 1, 2) AS method,
+           TO_NUMBER(REGEXP_SUBSTR(line, '[^,]+', 1, 3)) AS run,
+           TO_NUMBER(REGEXP_SUBSTR(line, '[^,]+', 1, 4), '999999990.000', 'NLS_NUMERIC_CHARACTERS=''.,''') AS elapsed_ms
+    FROM lines
+)`
+
+// chartQuery - 手法ごとの平均・最小・最大と、シナリオの先頭の手法に対する倍率・棒グラフ
+const chartQuery = timingsQuery + `,
+summary AS (
+    SELECT scenario, method, MIN(line_no) AS first_line, COUNT(*) AS runs,
+           ROUND(AVG(elapsed_ms), 1) AS avg_ms, MIN(elapsed_ms) AS min_ms, MAX(elapsed_ms) AS max_ms
+    FROM timings
+    GROUP BY scenario, method
+)
+SELECT scenario, method, runs, avg_ms, min_ms, max_ms,
+       ROUND(FIRST_VALUE(avg_ms) OVER (PARTITION BY scenario ORDER BY first_line) / NULLIF(avg_ms, 0), 1) AS speedup,
+       RPAD('#', GREATEST(1, ROUND(%d * avg_ms / NULLIF(MAX(avg_ms) OVER (PARTITION BY scenario), 0))), '#') AS chart
+FROM summary
+ORDER BY MIN(first_line) OVER (PARTITION BY scenario), first_line`
+
+// RenderSQLcl - SQLclバンドル用のシナリオスクリプトを生成（手法ごとの経過時間を :timings に記録する）
+func RenderSQLcl(s Scenario, p Params) string {
+	return render(s, p, true)
+}
+
+// RenderBenchmark - 選んだシナリオを runs 回ずつ実行し、経過時間のCSVと棒グラフを書き出すスクリプトを生成
+//
+// SET SQLFORMAT はSQLcl（とSQL Developerのスクリプト実行）の機能のため、SQL*Plusでは実行できない。
+func RenderBenchmark(selected []Scenario, runs int) string {
+	var b strings.Builder
+	b.WriteString("-- oracle-n-plus-1-demo の export-sql -sqlcl で生成したスクリプトです。\n")
+	fmt.Fprintf(&b, "-- 実行例: sql user/password@host:1521/service @%s\n", BenchmarkFile)
+	fmt.Fprintf(&b, "-- 各シナリオを%d回ずつ実行し、%s と %s を書き出します（SQLcl / SQL Developer 用）。\n\n", runs, TimingsFile, ChartFile)
+	b.WriteString("SET ECHO OFF VERIFY OFF FEEDBACK OFF\n")
+	b.WriteString("VARIABLE t0 VARCHAR2(64)\n")
+	b.WriteString("VARIABLE timings CLOB\n")
+	b.WriteString("EXEC :timings := NULL\n\n")
+
+	for run := 1; run <= runs; run++ {
+		fmt.Fprintf(&b, "PROMPT\nPROMPT ########## %d/%d回目 ##########\n", run, runs)
+		fmt.Fprintf(&b, "DEFINE run = %d\n", run)
+		for _, s := range selected {
+			fmt.Fprintf(&b, "@@%s\n", s.FileName())
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("SET TIMING OFF TERMOUT OFF PAGESIZE 50000 LINESIZE 200\n")
+	b.WriteString("SET SQLFORMAT CSV\n")
+	fmt.Fprintf(&b, "SPOOL %s\n", TimingsFile)
+	fmt.Fprintf(&b, "%s\nSELECT scenario, method, run, elapsed_ms\nFROM timings\nORDER BY line_no;\n", timingsQuery)
+	b.WriteString("SPOOL OFF\n")
+	b.WriteString("SET SQLFORMAT DEFAULT\n")
+	b.WriteString("SET TERMOUT ON\n\n")
+
+	b.WriteString("COLUMN scenario FORMAT A20\n")
+	b.WriteString("COLUMN method FORMAT A22\n")
+	fmt.Fprintf(&b, "COLUMN chart FORMAT A%d\n", chartWidth)
+	b.WriteString("PROMPT\nPROMPT ########## 手法ごとの平均（ミリ秒）と先頭の手法に対する倍率 ##########\n")
+	fmt.Fprintf(&b, "SPOOL %s\n", ChartFile)
+	fmt.Fprintf(&b, chartQuery+";\n", chartWidth)
+	b.WriteString("SPOOL OFF\n")
+	fmt.Fprintf(&b, "PROMPT\nPROMPT 実行ごとの経過時間: %s / 棒グラフ: %s\n", TimingsFile, ChartFile)
+	return b.String()
+}
+
+// ExportSQLcl - SQLclバンドル（経過時間を記録するシナリオスクリプトと run_benchmark.sql）を書き出し、書いたファイルのパスを返す
+func ExportSQLcl(dir string, selected []Scenario, p Params, runs int) ([]string, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if runs <= 0 {
+		return nil, fmt.Errorf("runs must be positive: %d", runs)
+	}
+	files := make([]file, 0, len(selected)+1)
+	for _, s := range selected {
+		files = append(files, file{name: s.FileName(), content: RenderSQLcl(s, p)})
+	}
+	files = append(files, file{name: BenchmarkFile, content: RenderBenchmark(selected, runs)})
+	return writeFiles(dir, files)
+}
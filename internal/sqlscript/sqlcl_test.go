@@ -0,0 +1,83 @@
+package sqlscript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderSQLcl(t *testing.T) {
+	s, err := Find("employees")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	script := RenderSQLcl(s, DefaultParams())
+
+	if got := strings.Count(script, captureStart); got != len(s.Methods) {
+		t.Errorf("capture start count = %d, want %d", got, len(s.Methods))
+	}
+	for _, m := range s.Methods {
+		if !strings.Contains(script, "'employees,"+m.Name+",&run,'") {
+			t.Errorf("script does not record timing of %s", m.Name)
+		}
+	}
+	// 経過時間は TIMING STOP の表示より前に記録する
+	if strings.Index(script, "'employees,N+1_Problem,&run,'") > strings.Index(script, "TIMING STOP") {
+		t.Error("timing must be captured before TIMING STOP")
+	}
+	if strings.Contains(Render(s, DefaultParams()), ":timings") {
+		t.Error("plain script must not depend on :timings")
+	}
+}
+
+func TestRenderBenchmark(t *testing.T) {
+	selected, err := Select("orders,top_customers")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	script := RenderBenchmark(selected, 2)
+
+	for _, want := range []string{
+		"VARIABLE timings CLOB",
+		"DEFINE run = 1",
+		"DEFINE run = 2",
+		"SET SQLFORMAT CSV",
+		"SPOOL " + TimingsFile,
+		"SPOOL " + ChartFile,
+		"RPAD('#', GREATEST(1, ROUND(40 *",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script does not contain %q", want)
+		}
+	}
+	if got := strings.Count(script, "@@orders.sql"); got != 2 {
+		t.Errorf("orders.sql is run %d times, want 2", got)
+	}
+}
+
+func TestExportSQLcl(t *testing.T) {
+	dir := t.TempDir()
+	selected, err := Select("orders")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if _, err := ExportSQLcl(dir, selected, DefaultParams(), 0); err == nil {
+		t.Error("ExportSQLcl() with runs=0 should fail")
+	}
+
+	written, err := ExportSQLcl(dir, selected, DefaultParams(), 1)
+	if err != nil {
+		t.Fatalf("ExportSQLcl() error = %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("ExportSQLcl() wrote %d files, want 2", len(written))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "orders.sql"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(data), ":timings") {
+		t.Error("orders.sql in the bundle must record timings")
+	}
+}
@@ -0,0 +1,262 @@
+// Package sqlscript はベンチマークの各シナリオのクエリをSQL*Plus/SQLclで再実行できる
+// .sqlスクリプトとして出力する。
+//
+// Goの実行環境がないDBAでも同じ比較をSQL*Plus上で再現できるように、手法ごとに
+// TIMING START/STOP で経過時間を計測し、EXPLAIN PLAN で実行計画を表示する。
+// N+1のループはSQL*Plusに制御構文がないため、親クエリの結果から子クエリを並べた
+// スクリプトをSPOOLで生成し、それを実行して再現する（1行ごとに1回のラウンドトリップ）。
+package sqlscript
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RunAllFile - 全シナリオのスクリプトを順に実行するスクリプトのファイル名
+const RunAllFile = "run_all.sql"
+
+// ErrUnknownScenario - 出力対象にないシナリオが指定された
+var ErrUnknownScenario = errors.New("unknown scenario")
+
+// Params - スクリプトの置換変数（DEFINE）の既定値
+type Params struct {
+	Days         int
+	Months       int
+	TopCustomers int
+	RecentOrders int
+}
+
+// DefaultParams - デモの既定値と同じ置換変数
+func DefaultParams() Params {
+	return Params{Days: 30, Months: 12, TopCustomers: 10, RecentOrders: 5}
+}
+
+// Validate - 置換変数が正の値か検証
+func (p Params) Validate() error {
+	for _, v := range []struct {
+		name  string
+		value int
+	}{
+		{"days", p.Days}, {"months", p.Months},
+		{"top-customers", p.TopCustomers}, {"recent-orders", p.RecentOrders},
+	} {
+		if v.value <= 0 {
+			return fmt.Errorf("%s must be positive: %d", v.name, v.value)
+		}
+	}
+	return nil
+}
+
+// Method - シナリオ内の1つの手法
+type Method struct {
+	Name        string // デモの手法名（結果JSONのmethodと同じ）
+	Description string
+	Statements  []string // 計測区間で実行するSQL（末尾のセミコロンなし）
+	Loop        string   // 計測前にSPOOLで子クエリのスクリプトを生成するSQL（空ならループなし）
+	Plans       []string // EXPLAIN PLANで実行計画を表示するSQL
+}
+
+// Scenario - 出力するシナリオ
+type Scenario struct {
+	ID      string // デモのシナリオ名（結果JSONのscenarioと同じ）
+	Title   string
+	Notes   []string // スクリプトの先頭に書く、Goのデモとの違い
+	Methods []Method
+}
+
+// FileName - シナリオのスクリプトのファイル名
+func (s Scenario) FileName() string {
+	return s.ID + ".sql"
+}
+
+// loopFile - 手法のループ用に生成するスクリプトのファイル名
+func loopFile(scenario, method string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '_'
+	}, method)
+	return fmt.Sprintf("%s_%s_loop.sql", scenario, name)
+}
+
+// Scenarios - 出力できるシナリオの一覧（デモの実行順）
+func Scenarios() []Scenario {
+	return scenarios
+}
+
+// Find - IDでシナリオを探す
+func Find(id string) (Scenario, error) {
+	for _, s := range scenarios {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return Scenario{}, fmt.Errorf("%w: %s", ErrUnknownScenario, id)
+}
+
+// IDs - 出力できるシナリオのID一覧
+func IDs() []string {
+	ids := make([]string, len(scenarios))
+	for i, s := range scenarios {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// Select - カンマ区切りのIDからシナリオを選ぶ（空なら全シナリオ）
+func Select(list string) ([]Scenario, error) {
+	if strings.TrimSpace(list) == "" {
+		return Scenarios(), nil
+	}
+	var selected []Scenario
+	for _, id := range strings.Split(list, ",") {
+		s, err := Find(strings.TrimSpace(id))
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, s)
+	}
+	return selected, nil
+}
+
+// Render - シナリオのスクリプトを生成
+func Render(s Scenario, p Params) string {
+	return render(s, p, false)
+}
+
+// render - シナリオのスクリプトを生成（captureなら手法ごとの経過時間を :timings に記録する）
+func render(s Scenario, p Params, capture bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- %s（%s）\n", s.Title, s.ID)
+	b.WriteString("-- oracle-n-plus-1-demo の export-sql コマンドで生成したスクリプトです。\n")
+	if capture {
+		fmt.Fprintf(&b, "-- %s から呼び出します（経過時間を :timings に記録するため単独では実行できません）。\n", BenchmarkFile)
+	} else {
+		fmt.Fprintf(&b, "-- 実行例: sqlplus user/password@host:1521/service @%s\n", s.FileName())
+	}
+	b.WriteString("-- 置換変数の値は下の DEFINE を書き換えて変更できます。\n")
+	for _, note := range s.Notes {
+		fmt.Fprintf(&b, "-- 注意: %s\n", note)
+	}
+	b.WriteString("\n")
+	writeHeader(&b, p)
+
+	fmt.Fprintf(&b, "PROMPT\nPROMPT ##### %s #####\n", s.Title)
+	for _, m := range s.Methods {
+		writeMethod(&b, s.ID, m, capture)
+	}
+	return b.String()
+}
+
+// writeHeader - SQL*Plusの設定と置換変数・バインド変数の定義を書く
+func writeHeader(b *strings.Builder, p Params) {
+	b.WriteString("SET ECHO OFF VERIFY OFF FEEDBACK OFF\n")
+	b.WriteString("SET LINESIZE 200 PAGESIZE 100 TRIMSPOOL ON\n")
+	b.WriteString("SET TIMING ON\n\n")
+	fmt.Fprintf(b, "DEFINE days = %d\n", p.Days)
+	fmt.Fprintf(b, "DEFINE months = %d\n", p.Months)
+	fmt.Fprintf(b, "DEFINE top_customers = %d\n", p.TopCustomers)
+	fmt.Fprintf(b, "DEFINE recent_orders = %d\n\n", p.RecentOrders)
+	b.WriteString("VARIABLE days NUMBER\n")
+	b.WriteString("VARIABLE months NUMBER\n")
+	b.WriteString("VARIABLE top_customers NUMBER\n")
+	b.WriteString("VARIABLE recent_orders NUMBER\n")
+	b.WriteString("VARIABLE id NUMBER\n")
+	b.WriteString("EXEC :days := &days\n")
+	b.WriteString("EXEC :months := &months\n")
+	b.WriteString("EXEC :top_customers := &top_customers\n")
+	b.WriteString("EXEC :recent_orders := &recent_orders\n\n")
+}
+
+// writeMethod - 1つの手法の計測区間と実行計画を書く
+//
+// 結果行の表示は計測から除くため TERMOUT OFF で実行する（フェッチ自体は行われる）。
+func writeMethod(b *strings.Builder, scenario string, m Method, capture bool) {
+	fmt.Fprintf(b, "PROMPT\nPROMPT === %s: %s ===\n", m.Name, m.Description)
+	if m.Loop != "" {
+		file := loopFile(scenario, m.Name)
+		b.WriteString("SET TERMOUT OFF TIMING OFF HEADING OFF PAGESIZE 0 LINESIZE 4000\n")
+		fmt.Fprintf(b, "SPOOL %s\n", file)
+		fmt.Fprintf(b, "%s;\n", m.Loop)
+		b.WriteString("SPOOL OFF\n")
+		b.WriteString("SET TERMOUT ON TIMING ON HEADING ON PAGESIZE 100 LINESIZE 200\n")
+	}
+
+	b.WriteString("SET TERMOUT OFF\n")
+	if capture {
+		b.WriteString(captureStart)
+	}
+	fmt.Fprintf(b, "TIMING START %s\n", m.Name)
+	for _, stmt := range m.Statements {
+		fmt.Fprintf(b, "%s;\n", stmt)
+	}
+	if m.Loop != "" {
+		fmt.Fprintf(b, "@%s\n", loopFile(scenario, m.Name))
+	}
+	if capture {
+		fmt.Fprintf(b, captureStop, scenario, m.Name)
+	}
+	b.WriteString("SET TERMOUT ON\n")
+	b.WriteString("TIMING STOP\n")
+
+	for _, plan := range m.Plans {
+		fmt.Fprintf(b, "PROMPT --- 実行計画: %s ---\n", m.Name)
+		fmt.Fprintf(b, "EXPLAIN PLAN FOR\n%s;\n", plan)
+		b.WriteString("SELECT plan_table_output FROM TABLE(DBMS_XPLAN.DISPLAY(NULL, NULL, 'TYPICAL'));\n")
+	}
+	b.WriteString("\n")
+}
+
+// RenderRunAll - 選んだシナリオを順に実行するスクリプトを生成
+func RenderRunAll(selected []Scenario) string {
+	var b strings.Builder
+	b.WriteString("-- oracle-n-plus-1-demo の export-sql コマンドで生成したスクリプトです。\n")
+	fmt.Fprintf(&b, "-- 実行例: sqlplus user/password@host:1521/service @%s\n\n", RunAllFile)
+	for _, s := range selected {
+		fmt.Fprintf(&b, "@@%s\n", s.FileName())
+	}
+	return b.String()
+}
+
+// Export - 選んだシナリオのスクリプトと run_all.sql をディレクトリに書き出し、書いたファイルのパスを返す
+func Export(dir string, selected []Scenario, p Params) ([]string, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	files := make([]file, 0, len(selected)+1)
+	for _, s := range selected {
+		files = append(files, file{name: s.FileName(), content: Render(s, p)})
+	}
+	files = append(files, file{name: RunAllFile, content: RenderRunAll(selected)})
+	return writeFiles(dir, files)
+}
+
+// file - 書き出すスクリプト
+type file struct {
+	name    string
+	content string
+}
+
+// writeFiles - スクリプトをディレクトリに書き出し、書いたファイルのパスを返す
+func writeFiles(dir string, files []file) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var written []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte(f.content), 0o644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
@@ -0,0 +1,126 @@
+package sqlscript
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScenariosAreComplete(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, s := range Scenarios() {
+		if seen[s.ID] {
+			t.Errorf("duplicate scenario %s", s.ID)
+		}
+		seen[s.ID] = true
+		if len(s.Methods) < 2 {
+			t.Errorf("scenario %s has %d methods, want at least 2", s.ID, len(s.Methods))
+		}
+		for _, m := range s.Methods {
+			if len(m.Statements) == 0 {
+				t.Errorf("%s/%s has no statements", s.ID, m.Name)
+			}
+			for _, stmt := range append(append([]string{m.Loop}, m.Statements...), m.Plans...) {
+				if strings.Contains(stmt, "\n\n") {
+					t.Errorf("%s/%s contains a blank line, which ends the statement in SQL*Plus", s.ID, m.Name)
+				}
+				if strings.HasSuffix(strings.TrimSpace(stmt), ";") {
+					t.Errorf("%s/%s statement must not end with a semicolon", s.ID, m.Name)
+				}
+			}
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	s, err := Find("orders")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	script := Render(s, Params{Days: 7, Months: 3, TopCustomers: 10, RecentOrders: 5})
+
+	for _, want := range []string{
+		"SET TIMING ON",
+		"DEFINE days = 7",
+		"EXEC :days := &days",
+		"TIMING START N+1_Problem",
+		"SPOOL orders_n_1_problem_loop.sql",
+		"@orders_n_1_problem_loop.sql",
+		"TIMING START JOIN_Optimized",
+		"EXPLAIN PLAN FOR",
+		"DBMS_XPLAN.DISPLAY",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script does not contain %q", want)
+		}
+	}
+	if got := strings.Count(script, "TIMING START"); got != strings.Count(script, "TIMING STOP") || got != len(s.Methods) {
+		t.Errorf("TIMING START/STOP count = %d, want %d each", got, len(s.Methods))
+	}
+	// 生成したループ用スクリプトは計測区間より前にSPOOLで作っておく
+	if strings.Index(script, "SPOOL orders_n_1_problem_loop.sql") > strings.Index(script, "TIMING START N+1_Problem") {
+		t.Error("loop script must be spooled before the timed section")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "all", list: "", want: IDs()},
+		{name: "subset", list: "employees, orders", want: []string{"employees", "orders"}},
+		{name: "unknown", list: "orders,lob", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Select(tt.list)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownScenario) {
+					t.Fatalf("Select() error = %v, want ErrUnknownScenario", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Select() = %d scenarios, want %d", len(got), len(tt.want))
+			}
+			for i, s := range got {
+				if s.ID != tt.want[i] {
+					t.Errorf("Select()[%d] = %s, want %s", i, s.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sql")
+	written, err := Export(dir, Scenarios(), DefaultParams())
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(written) != len(Scenarios())+1 {
+		t.Fatalf("Export() wrote %d files, want %d", len(written), len(Scenarios())+1)
+	}
+
+	runAll, err := os.ReadFile(filepath.Join(dir, RunAllFile))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, s := range Scenarios() {
+		if !strings.Contains(string(runAll), "@@"+s.FileName()) {
+			t.Errorf("%s does not run %s", RunAllFile, s.FileName())
+		}
+	}
+
+	if _, err := Export(dir, Scenarios(), Params{Days: 0, Months: 1, TopCustomers: 1, RecentOrders: 1}); err == nil {
+		t.Error("Export() with days=0 should fail")
+	}
+}
@@ -0,0 +1,247 @@
+package sqlutil
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// systemPrefixes - 許可リスト照合の対象外とするディクショナリ／動的パフォーマンスビューの接頭辞
+var systemPrefixes = []string{"USER_", "ALL_", "DBA_", "V$", "GV$"}
+
+// tableKeywords - 直後にテーブル参照が続くキーワード（FROMのみカンマ区切りで複数続く）
+var tableKeywords = map[string]bool{"FROM": true, "JOIN": true, "INTO": true, "UPDATE": true, "USING": true}
+
+// clauseKeywords - テーブル参照の後に続く句のキーワード（別名とはみなさない）
+var clauseKeywords = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "HAVING": true, "CONNECT": true, "START": true,
+	"UNION": true, "INTERSECT": true, "MINUS": true, "EXCEPT": true, "FETCH": true, "OFFSET": true,
+	"FOR": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "OUTER": true, "ON": true, "USING": true, "SET": true, "VALUES": true,
+	"PARTITION": true, "PIVOT": true, "UNPIVOT": true, "SAMPLE": true, "RETURNING": true, "WITH": true,
+	"MODEL": true, "SELECT": true, "WHEN": true, "LOG": true,
+}
+
+// GuardQuery - 動的に組み立てたSQLのテーブル参照が許可リスト内かを検査する
+//
+// 識別子を文字列連結したSQLを実行する直前に呼び出す実行時ガード。
+// 文字列リテラル・コメントと、関数の引数など問い合わせでない括弧の中（EXTRACT(YEAR FROM ...) など）は読み飛ばし、
+// サブクエリの中は同じ規則で検査する。スキーマ修飾（hr.orders）は表名で照合し、WITH句の名前・DUAL・ディクショナリビューは検査対象外。
+func GuardQuery(query string) error {
+	for _, name := range tableRefs(query) {
+		if isSystemObject(name) {
+			continue
+		}
+		if !IsAllowed(name) {
+			return fmt.Errorf("%w: %q in query", ErrIdentifierNotAllowed, name)
+		}
+	}
+	return nil
+}
+
+// isSystemObject - ディクショナリビューなど検査対象外のオブジェクトか
+func isSystemObject(name string) bool {
+	upper := strings.ToUpper(name)
+	if upper == "DUAL" {
+		return true
+	}
+	for _, prefix := range systemPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableRefs - SQLが参照するテーブル名（引用符を外した表名、WITH句の名前を除く）
+func tableRefs(query string) []string {
+	s := &refScanner{tokens: tokenize(query), ctes: make(map[string]bool)}
+	s.scan(true)
+
+	refs := make([]string, 0, len(s.refs))
+	for _, name := range s.refs {
+		if !s.ctes[strings.ToUpper(name)] {
+			refs = append(refs, name)
+		}
+	}
+	return refs
+}
+
+// tokenize - SQLを識別子・引用識別子・記号に分割する（文字列リテラルは ' 、コメントと空白は除く）
+func tokenize(query string) []string {
+	var tokens []string
+	rs := []rune(query)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			for i += 3; i < len(rs) && !(rs[i-1] == '*' && rs[i] == '/'); i++ {
+			}
+			i++
+		case r == '\'':
+			// '' はリテラル内の引用符
+			for i++; i < len(rs); i++ {
+				if rs[i] == '\'' {
+					if i+1 < len(rs) && rs[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			i++
+			tokens = append(tokens, "'")
+		case r == '"':
+			start := i
+			for i++; i < len(rs) && rs[i] != '"'; i++ {
+			}
+			i++
+			tokens = append(tokens, string(rs[start:min(i, len(rs))]))
+		case isWordRune(r):
+			start := i
+			for i < len(rs) && isWordRune(rs[i]) {
+				i++
+			}
+			tokens = append(tokens, string(rs[start:i]))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+// isWordRune - 非引用識別子・数値を構成する文字か
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' || r == '#'
+}
+
+// refScanner - トークン列からテーブル参照を集める
+type refScanner struct {
+	tokens []string
+	pos    int
+	refs   []string
+	ctes   map[string]bool
+}
+
+// peek - n個先のトークン（なければ空文字列）
+func (s *refScanner) peek(n int) string {
+	if s.pos+n < len(s.tokens) {
+		return s.tokens[s.pos+n]
+	}
+	return ""
+}
+
+// next - 次のトークンを読み進める
+func (s *refScanner) next() string {
+	tok := s.peek(0)
+	s.pos++
+	return tok
+}
+
+// startsQuery - 次のトークンが問い合わせの始まりか（括弧がサブクエリかを判定する）
+func (s *refScanner) startsQuery() bool {
+	switch strings.ToUpper(s.peek(0)) {
+	case "SELECT", "WITH":
+		return true
+	}
+	return false
+}
+
+// scan - 対応する閉じ括弧（最上位は末尾）まで読み進める。queryがfalseの括弧の中はテーブル参照を探さない
+func (s *refScanner) scan(query bool) {
+	first, prev := true, ""
+	for s.pos < len(s.tokens) {
+		tok := s.next()
+		switch {
+		case tok == ")":
+			return
+		case tok == "(":
+			s.scan(s.startsQuery())
+		case !query:
+		case first && strings.EqualFold(tok, "WITH"):
+			s.withClause()
+		case strings.EqualFold(tok, "UPDATE") && strings.EqualFold(prev, "FOR"):
+			// FOR UPDATE [OF 列] はテーブル参照ではない
+		case tableKeywords[strings.ToUpper(tok)]:
+			s.tableList(strings.EqualFold(tok, "FROM"))
+		}
+		first, prev = false, tok
+	}
+}
+
+// withClause - WITH句の名前を記録し、各サブクエリを検査する
+func (s *refScanner) withClause() {
+	for isIdentifier(s.peek(0)) {
+		s.ctes[strings.ToUpper(strings.Trim(s.next(), `"`))] = true
+		if s.peek(0) == "(" {
+			s.next()
+			s.scan(false) // 列名の並び
+		}
+		if !strings.EqualFold(s.peek(0), "AS") {
+			return
+		}
+		s.next()
+		if s.peek(0) != "(" {
+			return
+		}
+		s.next()
+		s.scan(true)
+		if s.peek(0) != "," {
+			return
+		}
+		s.next()
+	}
+}
+
+// tableList - テーブル参照（schema.table、別名付き）を読む。listがtrueならカンマ区切りの続きも読む
+func (s *refScanner) tableList(list bool) {
+	for {
+		switch tok := s.peek(0); {
+		case tok == "(":
+			s.next()
+			s.scan(s.startsQuery())
+		case (strings.EqualFold(tok, "TABLE") || strings.EqualFold(tok, "LATERAL")) && s.peek(1) == "(":
+			s.pos += 2
+			s.scan(s.startsQuery())
+		case isIdentifier(tok) && !clauseKeywords[strings.ToUpper(tok)]:
+			name := s.next()
+			for s.peek(0) == "." && isIdentifier(s.peek(1)) {
+				s.next()
+				name = s.next()
+			}
+			if s.peek(0) == "@" && isIdentifier(s.peek(1)) {
+				s.pos += 2 // DBリンク
+			}
+			s.refs = append(s.refs, strings.Trim(name, `"`))
+		default:
+			return
+		}
+
+		if strings.EqualFold(s.peek(0), "AS") {
+			s.next()
+		}
+		if alias := s.peek(0); isIdentifier(alias) && !clauseKeywords[strings.ToUpper(alias)] {
+			s.next()
+		}
+		if !list || s.peek(0) != "," {
+			return
+		}
+		s.next()
+	}
+}
+
+// isIdentifier - トークンが識別子（引用識別子を含む）か
+func isIdentifier(tok string) bool {
+	if strings.HasPrefix(tok, `"`) {
+		return len(tok) > 2
+	}
+	r := []rune(tok)
+	return len(r) > 0 && unicode.IsLetter(r[0])
+}
@@ -0,0 +1,77 @@
+package sqlutil
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGuardQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "single table", query: "SELECT * FROM orders WHERE order_id = :1"},
+		{name: "quoted identifier", query: `SELECT * FROM "ORDERS" o JOIN "ORDER_DETAILS" d ON d.order_id = o.order_id`},
+		{name: "schema qualified", query: "SELECT * FROM hr.orders"},
+		{name: "comma join", query: "SELECT * FROM orders o, order_details d WHERE d.order_id = o.order_id"},
+		{name: "extract", query: "SELECT EXTRACT(YEAR FROM order_date) FROM orders"},
+		{name: "trim", query: "SELECT TRIM(' ' FROM status) FROM orders"},
+		{name: "from in literal", query: "SELECT * FROM orders WHERE status = 'shipped FROM secrets'"},
+		{name: "from in comment", query: "SELECT * FROM orders -- FROM secrets\n/* JOIN secrets */ WHERE 1 = 1"},
+		{name: "subquery", query: "SELECT * FROM orders WHERE customer_id IN (SELECT customer_id FROM orders WHERE total_amount > 0)"},
+		{name: "inline view", query: "SELECT t.n FROM (SELECT COUNT(*) n FROM order_details) t, products p"},
+		{name: "with clause", query: "WITH recent AS (SELECT * FROM orders) SELECT * FROM recent r JOIN order_details d USING (order_id)"},
+		{name: "dual", query: "SELECT SYSDATE FROM dual"},
+		{name: "dictionary view", query: "SELECT * FROM v$mystat m JOIN user_tables t ON 1 = 1"},
+		{name: "for update", query: "SELECT * FROM orders WHERE order_id = :1 FOR UPDATE OF status NOWAIT"},
+		{name: "flashback", query: "SELECT * FROM order_details AS OF SCN :1 WHERE order_id IN (:2)"},
+		{name: "dml", query: "UPDATE orders SET status = 'X' WHERE order_id IN (SELECT order_id FROM order_details)"},
+		{name: "insert", query: "INSERT INTO order_details (detail_id, order_id) VALUES (:1, :2)"},
+		{name: "unknown table", query: "SELECT * FROM secrets", wantErr: true},
+		{name: "unknown schema qualified", query: "SELECT * FROM orders.secrets", wantErr: true},
+		{name: "second table of comma join", query: "SELECT * FROM orders o, secrets s", wantErr: true},
+		{name: "third table of comma join", query: "SELECT * FROM orders, products, secrets", wantErr: true},
+		{name: "join", query: "SELECT * FROM orders o LEFT OUTER JOIN secrets s ON s.id = o.order_id", wantErr: true},
+		{name: "subquery table", query: "SELECT * FROM orders WHERE order_id IN (SELECT id FROM secrets)", wantErr: true},
+		{name: "subquery in function", query: "SELECT NVL((SELECT MAX(id) FROM secrets), 0) FROM orders", wantErr: true},
+		{name: "inline view table", query: "SELECT * FROM (SELECT * FROM secrets) t", wantErr: true},
+		{name: "with clause table", query: "WITH t AS (SELECT * FROM secrets) SELECT * FROM t", wantErr: true},
+		{name: "dual prefix", query: "SELECT * FROM dual_secrets", wantErr: true},
+		{name: "quoted unknown", query: `SELECT * FROM "SECRETS"`, wantErr: true},
+		{name: "insert into unknown", query: "INSERT INTO secrets SELECT * FROM orders", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := GuardQuery(tt.query)
+			if tt.wantErr {
+				if !errors.Is(err, ErrIdentifierNotAllowed) {
+					t.Errorf("GuardQuery(%q) error = %v, want %v", tt.query, err, ErrIdentifierNotAllowed)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("GuardQuery(%q) failed: %v", tt.query, err)
+			}
+		})
+	}
+}
+
+func TestTableRefs(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "SELECT * FROM hr.orders o, products AS p", want: []string{"orders", "products"}},
+		{query: `SELECT * FROM "Orders"@remote`, want: []string{"Orders"}},
+		{query: "SELECT TO_CHAR(order_date, 'YYYY') FROM orders WHERE note = 'it''s FROM x'", want: []string{"orders"}},
+		{query: "SELECT * FROM TABLE(DBMS_XPLAN.DISPLAY_CURSOR(NULL, NULL)) x", want: []string{}},
+		{query: "MERGE INTO orders o USING products p ON (o.order_id = p.product_id) WHEN MATCHED THEN UPDATE SET o.status = 'X'", want: []string{"orders", "products"}},
+	}
+	for _, tt := range tests {
+		if got := tableRefs(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tableRefs(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
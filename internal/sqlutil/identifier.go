@@ -0,0 +1,85 @@
+package sqlutil
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrIdentifierNotAllowed - 許可リストにない識別子が指定された場合のエラー
+var ErrIdentifierNotAllowed = errors.New("identifier is not allow-listed")
+
+// ErrInvalidIdentifier - 識別子として不正な文字列が指定された場合のエラー
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// identifierPattern - Oracleの非引用識別子として有効な形式（最大128バイト）
+var identifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]{0,127}$`)
+
+var (
+	allowMu sync.RWMutex
+	// allowedIdentifiers - SQL文字列に埋め込んでよい識別子（大文字で保持）
+	allowedIdentifiers = map[string]bool{
+		"ORDERS":            true,
+		"ORDER_DETAILS":     true,
+		"EMPLOYEES":         true,
+		"DEPARTMENTS":       true,
+		"PROJECTS":          true,
+		"EMPLOYEE_PROJECTS": true,
+		"PRODUCTS":          true,
+	}
+)
+
+// AllowIdentifiers - 埋め込みを許可する識別子を追加登録
+func AllowIdentifiers(names ...string) error {
+	allowMu.Lock()
+	defer allowMu.Unlock()
+
+	for _, name := range names {
+		if !identifierPattern.MatchString(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+		}
+		allowedIdentifiers[strings.ToUpper(name)] = true
+	}
+	return nil
+}
+
+// IsAllowed - 識別子が許可リストに含まれるか
+func IsAllowed(name string) bool {
+	allowMu.RLock()
+	defer allowMu.RUnlock()
+	return allowedIdentifiers[strings.ToUpper(name)]
+}
+
+// ValidateIdentifier - 識別子の形式と許可リストを検証
+func ValidateIdentifier(name string) error {
+	if !identifierPattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+	if !IsAllowed(name) {
+		return fmt.Errorf("%w: %q", ErrIdentifierNotAllowed, name)
+	}
+	return nil
+}
+
+// QuoteIdentifier - 検証済みの識別子を二重引用符で囲んで返す
+//
+// Oracleは非引用識別子を大文字で格納するため、大文字に正規化してから引用する。
+func QuoteIdentifier(name string) (string, error) {
+	if err := ValidateIdentifier(name); err != nil {
+		return "", err
+	}
+	return `"` + strings.ToUpper(name) + `"`, nil
+}
+
+// MustQuoteIdentifier - QuoteIdentifierの検証失敗時にpanicする版
+//
+// コード中の定数識別子にのみ使用し、外部入力には使用しないこと。
+func MustQuoteIdentifier(name string) string {
+	quoted, err := QuoteIdentifier(name)
+	if err != nil {
+		panic(err)
+	}
+	return quoted
+}
@@ -0,0 +1,69 @@
+package sqlutil
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr error
+	}{
+		{name: "orders", want: `"ORDERS"`},
+		{name: "Order_Details", want: `"ORDER_DETAILS"`},
+		{name: "secrets", wantErr: ErrIdentifierNotAllowed},
+		{name: `orders"; DROP TABLE orders; --`, wantErr: ErrInvalidIdentifier},
+		{name: "1orders", wantErr: ErrInvalidIdentifier},
+		{name: "", wantErr: ErrInvalidIdentifier},
+	}
+	for _, tt := range tests {
+		got, err := QuoteIdentifier(tt.name)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("QuoteIdentifier(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("QuoteIdentifier(%q) failed: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("QuoteIdentifier(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAllowIdentifiers(t *testing.T) {
+	const name = "order_details_copy_test"
+	if IsAllowed(name) {
+		t.Fatalf("IsAllowed(%q) = true before AllowIdentifiers()", name)
+	}
+	if err := AllowIdentifiers(name); err != nil {
+		t.Fatalf("AllowIdentifiers(%q) failed: %v", name, err)
+	}
+	if !IsAllowed("ORDER_DETAILS_COPY_TEST") {
+		t.Errorf("IsAllowed(%q) = false after AllowIdentifiers()", "ORDER_DETAILS_COPY_TEST")
+	}
+	if err := GuardQuery("SELECT * FROM " + name); err != nil {
+		t.Errorf("GuardQuery() after AllowIdentifiers() failed: %v", err)
+	}
+
+	if err := AllowIdentifiers("valid_name_test", "bad name"); !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("AllowIdentifiers(%q) error = %v, want %v", "bad name", err, ErrInvalidIdentifier)
+	}
+	if IsAllowed("bad name") {
+		t.Errorf("IsAllowed(%q) = true, want false", "bad name")
+	}
+}
+
+func TestMustQuoteIdentifierPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustQuoteIdentifier() did not panic")
+		}
+	}()
+	MustQuoteIdentifier("secrets")
+}
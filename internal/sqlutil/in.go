@@ -0,0 +1,171 @@
+package sqlutil
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MaxInListSize - IN句に列挙できる式の上限（超えると ORA-01795）
+const MaxInListSize = 1000
+
+// DefaultInChunkSize - QueryInでchunkSizeに0以下を指定した場合の1回あたりの件数
+const DefaultInChunkSize = MaxInListSize
+
+var (
+	// ErrEmptyInList - IN句に展開するスライスが空
+	ErrEmptyInList = errors.New("empty slice passed to IN query")
+	// ErrBindCount - ? の数とバインド引数の数が一致しない
+	ErrBindCount = errors.New("number of bind variables does not match number of arguments")
+	// ErrMultipleInLists - 分割して実行するクエリに複数のスライスが渡された
+	ErrMultipleInLists = errors.New("chunked IN query accepts only one slice argument")
+)
+
+// Queryer - QueryInが利用するDB操作（*sql.DB・*sql.Tx・repository.DBTXが満たす）
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// In - ? で書いたクエリを :1, :2, ... 形式に置き換え、スライスの引数をIN句用に展開する（sqlx.In相当）
+//
+// スライス（[]byteとdriver.Valuerを除く）を受け取った ? は要素数分のプレースホルダーになり、
+// 要素はバインド引数として順に展開される。複合キーは Tuples（tuple IN）か OrChain（等価条件のOR）で渡す。
+// 文字列リテラル内の ? は置き換えない。
+//
+//	query, args, err := In("SELECT * FROM orders WHERE status = ? AND order_id IN (?)", "NEW", []int64{1, 2, 3})
+//	// query: SELECT * FROM orders WHERE status = :1 AND order_id IN (:2,:3,:4)
+func In(query string, args ...interface{}) (string, []interface{}, error) {
+	var b strings.Builder
+	expanded := make([]interface{}, 0, len(args))
+	argIndex := 0
+	inLiteral := false
+
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inLiteral = !inLiteral
+		case r == '?' && !inLiteral:
+			if argIndex >= len(args) {
+				return "", nil, fmt.Errorf("%w: more than %d bind variables", ErrBindCount, len(args))
+			}
+			expr, values, ok, err := expandList(args[argIndex], len(expanded)+1)
+			if err != nil {
+				return "", nil, fmt.Errorf("%w: argument %d", err, argIndex+1)
+			}
+			if !ok {
+				expanded = append(expanded, args[argIndex])
+				b.WriteString(":" + strconv.Itoa(len(expanded)))
+				argIndex++
+				continue
+			}
+			b.WriteString(expr)
+			expanded = append(expanded, values...)
+			argIndex++
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	if argIndex != len(args) {
+		return "", nil, fmt.Errorf("%w: %d bind variables, %d arguments", ErrBindCount, argIndex, len(args))
+	}
+	return b.String(), expanded, nil
+}
+
+// QueryIn - IN句のスライスをchunkSize件ずつに分けてクエリを実行し、各行をscanに渡す
+//
+// queryは In と同じく ? で書き、スライス（Tuples・OrChainを含む）の引数は1つまでとする。
+// スライスが空の場合はクエリを実行しない。Tuples・OrChainは組の数をchunkSize件ずつに分ける。
+// ORDER BY は分割した実行ごとにしか効かないため、全体の順序が必要な場合は呼び出し側で並べ替えること。
+// chunkSizeが0以下の場合は DefaultInChunkSize、MaxInListSize を超える場合は MaxInListSize とする。
+func QueryIn(q Queryer, query string, chunkSize int, scan func(rows *sql.Rows) error, args ...interface{}) error {
+	if err := GuardQuery(query); err != nil {
+		return err
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultInChunkSize
+	}
+	chunkSize = min(chunkSize, MaxInListSize)
+
+	sliceIndex, length := -1, 0
+	var chunk func(start, end int) interface{}
+	for i, arg := range args {
+		n, c, ok := splitList(arg)
+		if !ok {
+			continue
+		}
+		if sliceIndex >= 0 {
+			return ErrMultipleInLists
+		}
+		sliceIndex, length, chunk = i, n, c
+	}
+	if sliceIndex < 0 {
+		return queryChunk(q, query, scan, args)
+	}
+
+	chunkArgs := make([]interface{}, len(args))
+	copy(chunkArgs, args)
+	for start := 0; start < length; start += chunkSize {
+		end := min(start+chunkSize, length)
+		chunkArgs[sliceIndex] = chunk(start, end)
+		if err := queryChunk(q, query, scan, chunkArgs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryChunk - Inで展開したクエリを1回実行し、各行をscanに渡す
+func queryChunk(q Queryer, query string, scan func(rows *sql.Rows) error, args []interface{}) error {
+	expandedQuery, expandedArgs, err := In(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.Query(expandedQuery, expandedArgs...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// sliceValues - IN句に展開するスライスなら要素を返す（[]byteとdriver.Valuerは単一の値として扱う）
+func sliceValues(arg interface{}) ([]interface{}, bool) {
+	if arg == nil {
+		return nil, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return nil, false
+	}
+	if _, ok := arg.([]byte); ok {
+		return nil, false
+	}
+	if values, ok := arg.([]interface{}); ok {
+		return values, true
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	values := make([]interface{}, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values, true
+}
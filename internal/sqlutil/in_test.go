@@ -0,0 +1,151 @@
+package sqlutil
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		args     []interface{}
+		want     string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		{
+			name:     "int64 slice",
+			query:    "SELECT * FROM orders WHERE order_id IN (?)",
+			args:     []interface{}{[]int64{10, 20, 30}},
+			want:     "SELECT * FROM orders WHERE order_id IN (:1,:2,:3)",
+			wantArgs: []interface{}{int64(10), int64(20), int64(30)},
+		},
+		{
+			name:     "scalars around slice",
+			query:    "SELECT * FROM orders WHERE status = ? AND order_id IN (?) AND total_amount > ?",
+			args:     []interface{}{"NEW", []int64{1, 2}, 100},
+			want:     "SELECT * FROM orders WHERE status = :1 AND order_id IN (:2,:3) AND total_amount > :4",
+			wantArgs: []interface{}{"NEW", int64(1), int64(2), 100},
+		},
+		{
+			name:     "string slice",
+			query:    "SELECT * FROM v$latch WHERE name IN (?)",
+			args:     []interface{}{[]string{"a", "b"}},
+			want:     "SELECT * FROM v$latch WHERE name IN (:1,:2)",
+			wantArgs: []interface{}{"a", "b"},
+		},
+		{
+			name:     "question mark in literal",
+			query:    "SELECT '?' FROM orders WHERE order_id = ?",
+			args:     []interface{}{int64(1)},
+			want:     "SELECT '?' FROM orders WHERE order_id = :1",
+			wantArgs: []interface{}{int64(1)},
+		},
+		{
+			name:     "bytes are not expanded",
+			query:    "SELECT * FROM orders WHERE notes = ?",
+			args:     []interface{}{[]byte("x")},
+			want:     "SELECT * FROM orders WHERE notes = :1",
+			wantArgs: []interface{}{[]byte("x")},
+		},
+		{
+			name:     "valuer is not expanded",
+			query:    "SELECT * FROM orders WHERE notes = ?",
+			args:     []interface{}{sql.NullString{String: "x", Valid: true}},
+			want:     "SELECT * FROM orders WHERE notes = :1",
+			wantArgs: []interface{}{sql.NullString{String: "x", Valid: true}},
+		},
+		{name: "empty slice", query: "WHERE order_id IN (?)", args: []interface{}{[]int64{}}, wantErr: ErrEmptyInList},
+		{name: "too few args", query: "WHERE a = ? AND b = ?", args: []interface{}{1}, wantErr: ErrBindCount},
+		{name: "too many args", query: "WHERE a = ?", args: []interface{}{1, 2}, wantErr: ErrBindCount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotArgs, err := In(tt.query, tt.args...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("In() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("In() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("In() query = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("In() args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+// recordingQueryer - 実行したクエリを記録し、常にerrStopを返すQueryer
+type recordingQueryer struct {
+	queries []string
+	args    [][]interface{}
+}
+
+var errStop = errors.New("stop")
+
+func (q *recordingQueryer) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	q.queries = append(q.queries, query)
+	q.args = append(q.args, args)
+	return nil, errStop
+}
+
+func TestQueryIn(t *testing.T) {
+	noScan := func(*sql.Rows) error { return nil }
+
+	t.Run("chunk size is capped", func(t *testing.T) {
+		ids := make([]int64, 2500)
+		for i := range ids {
+			ids[i] = int64(i + 1)
+		}
+		q := &recordingQueryer{}
+		err := QueryIn(q, "SELECT order_id FROM orders WHERE customer_id = ? AND order_id IN (?)", 5000, noScan, 7, ids)
+		if !errors.Is(err, errStop) {
+			t.Fatalf("QueryIn() error = %v, want %v", err, errStop)
+		}
+		if len(q.args) != 1 || len(q.args[0]) != 1+MaxInListSize {
+			t.Fatalf("first chunk has %d args, want %d", len(q.args[0]), 1+MaxInListSize)
+		}
+		if q.args[0][0] != 7 || q.args[0][1] != int64(1) {
+			t.Errorf("first chunk args start with %v, %v", q.args[0][0], q.args[0][1])
+		}
+		if !strings.HasSuffix(q.queries[0], ":1001)") {
+			t.Errorf("query does not end with :1001): %q", q.queries[0][len(q.queries[0])-20:])
+		}
+	})
+
+	t.Run("empty slice runs no query", func(t *testing.T) {
+		q := &recordingQueryer{}
+		if err := QueryIn(q, "SELECT order_id FROM orders WHERE order_id IN (?)", 0, noScan, []int64{}); err != nil {
+			t.Fatalf("QueryIn() error = %v", err)
+		}
+		if len(q.queries) != 0 {
+			t.Errorf("QueryIn() ran %d queries, want 0", len(q.queries))
+		}
+	})
+
+	t.Run("multiple slices", func(t *testing.T) {
+		q := &recordingQueryer{}
+		err := QueryIn(q, "SELECT * FROM orders WHERE order_id IN (?) AND customer_id IN (?)", 0, noScan, []int64{1}, []int64{2})
+		if !errors.Is(err, ErrMultipleInLists) {
+			t.Errorf("QueryIn() error = %v, want %v", err, ErrMultipleInLists)
+		}
+	})
+
+	t.Run("guarded table", func(t *testing.T) {
+		q := &recordingQueryer{}
+		err := QueryIn(q, "SELECT * FROM secrets WHERE id IN (?)", 0, noScan, []int64{1})
+		if !errors.Is(err, ErrIdentifierNotAllowed) {
+			t.Errorf("QueryIn() error = %v, want %v", err, ErrIdentifierNotAllowed)
+		}
+	})
+}
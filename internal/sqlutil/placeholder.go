@@ -0,0 +1,48 @@
+package sqlutil
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Placeholders - :1, :2, ... 形式のバインド変数プレースホルダーを生成
+//
+// 生成される文字列は位置番号のみで構成され、値は常にバインド引数として渡す。
+func Placeholders(n int) string {
+	return PlaceholdersFrom(1, n)
+}
+
+// PlaceholdersFrom - 指定した番号から始まるプレースホルダーを生成
+func PlaceholdersFrom(start, n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(start + i))
+	}
+	return b.String()
+}
+
+// Int64Args - []int64をバインド引数用の[]interface{}に変換
+func Int64Args(values []int64) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
+
+// StringArgs - []stringをバインド引数用の[]interface{}に変換
+func StringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
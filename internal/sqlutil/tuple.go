@@ -0,0 +1,153 @@
+package sqlutil
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrTupleWidth - 複合キーの組の要素数が揃っていない（または列の数と一致しない）
+	ErrTupleWidth = errors.New("composite key tuples must have the same number of values as columns")
+	// ErrInvalidColumn - OrChainの列として不正な文字列が指定された
+	ErrInvalidColumn = errors.New("invalid column reference")
+)
+
+// columnPattern - OrChainに埋め込む列（別名付きの od.order_id も可）
+var columnPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_$#]*\.)?[A-Za-z][A-Za-z0-9_$#]*$`)
+
+// Tuples - 複合キーの組（In・QueryIn で ? に渡すと (:1,:2),(:3,:4) の形に展開する）
+//
+//	query, args, err := In("SELECT * FROM order_details WHERE (order_id, product_id) IN (?)",
+//		Tuples{{int64(1), int64(2001)}, {int64(2), int64(2005)}})
+//	// query: SELECT * FROM order_details WHERE (order_id, product_id) IN ((:1,:2),(:3,:4))
+type Tuples [][]interface{}
+
+// Int64Tuples - 整数の複合キーをTuplesに変換
+func Int64Tuples(keys [][]int64) Tuples {
+	tuples := make(Tuples, len(keys))
+	for i, key := range keys {
+		tuples[i] = Int64Args(key)
+	}
+	return tuples
+}
+
+// width - 組の要素数（すべての組で揃っていなければ ErrTupleWidth）
+func (t Tuples) width() (int, error) {
+	if len(t) == 0 {
+		return 0, ErrEmptyInList
+	}
+	width := len(t[0])
+	for i, tuple := range t {
+		if len(tuple) == 0 || len(tuple) != width {
+			return 0, fmt.Errorf("%w: tuple %d has %d values, tuple 1 has %d", ErrTupleWidth, i+1, len(tuple), width)
+		}
+	}
+	return width, nil
+}
+
+// expand - start番から始まるプレースホルダーの組と、組の値を順に並べたバインド引数
+func (t Tuples) expand(start int) (string, []interface{}, error) {
+	width, err := t.width()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var b strings.Builder
+	values := make([]interface{}, 0, len(t)*width)
+	for i, tuple := range t {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('(')
+		b.WriteString(PlaceholdersFrom(start+len(values), width))
+		b.WriteByte(')')
+		values = append(values, tuple...)
+	}
+	return b.String(), values, nil
+}
+
+// OrChain - 複合キーの組を列ごとの等価条件のORで展開する引数
+//
+// tuple IN を使えない（または使わせたくない）場合の書き方で、? は
+// (c1 = :1 AND c2 = :2) OR (c1 = :3 AND c2 = :4) になる。他の条件と組み合わせる場合は (?) と括弧で囲むこと。
+// 列はSQLに埋め込むため、コード中の定数だけを指定する。
+type OrChain struct {
+	Columns []string
+	Tuples  Tuples
+}
+
+// expand - start番から始まるプレースホルダーで組ごとの等価条件をORでつないだ条件と、バインド引数
+func (o OrChain) expand(start int) (string, []interface{}, error) {
+	width, err := o.Tuples.width()
+	if err != nil {
+		return "", nil, err
+	}
+	if width != len(o.Columns) {
+		return "", nil, fmt.Errorf("%w: %d columns, %d values", ErrTupleWidth, len(o.Columns), width)
+	}
+	for _, column := range o.Columns {
+		if !columnPattern.MatchString(column) {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidColumn, column)
+		}
+	}
+
+	var b strings.Builder
+	values := make([]interface{}, 0, len(o.Tuples)*width)
+	for i, tuple := range o.Tuples {
+		if i > 0 {
+			b.WriteString(" OR ")
+		}
+		b.WriteByte('(')
+		for j, column := range o.Columns {
+			if j > 0 {
+				b.WriteString(" AND ")
+			}
+			values = append(values, tuple[j])
+			b.WriteString(column + " = :" + strconv.Itoa(start+len(values)-1))
+		}
+		b.WriteByte(')')
+	}
+	return b.String(), values, nil
+}
+
+// expandList - ? に渡したスライス・Tuples・OrChainを展開したSQLとバインド引数（展開しない引数はok=false）
+func expandList(arg interface{}, start int) (expr string, values []interface{}, ok bool, err error) {
+	switch v := arg.(type) {
+	case Tuples:
+		expr, values, err = v.expand(start)
+		return expr, values, true, err
+	case OrChain:
+		expr, values, err = v.expand(start)
+		return expr, values, true, err
+	}
+
+	values, ok = sliceValues(arg)
+	if !ok {
+		return "", nil, false, nil
+	}
+	if len(values) == 0 {
+		return "", nil, true, ErrEmptyInList
+	}
+	return PlaceholdersFrom(start, len(values)), values, true, nil
+}
+
+// splitList - QueryInで分割する引数なら、要素数と、start番目からend番目の手前までを同じ型で取り出す関数を返す
+func splitList(arg interface{}) (int, func(start, end int) interface{}, bool) {
+	switch v := arg.(type) {
+	case Tuples:
+		return len(v), func(start, end int) interface{} { return v[start:end] }, true
+	case OrChain:
+		return len(v.Tuples), func(start, end int) interface{} {
+			return OrChain{Columns: v.Columns, Tuples: v.Tuples[start:end]}
+		}, true
+	}
+
+	values, ok := sliceValues(arg)
+	if !ok {
+		return 0, nil, false
+	}
+	return len(values), func(start, end int) interface{} { return values[start:end] }, true
+}
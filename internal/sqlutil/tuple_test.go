@@ -0,0 +1,117 @@
+package sqlutil
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInTuples(t *testing.T) {
+	keys := Int64Tuples([][]int64{{1, 2001}, {2, 2005}})
+	tests := []struct {
+		name     string
+		query    string
+		args     []interface{}
+		want     string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		{
+			name:     "tuple in",
+			query:    "SELECT * FROM order_details WHERE quantity > ? AND (order_id, product_id) IN (?)",
+			args:     []interface{}{3, keys},
+			want:     "SELECT * FROM order_details WHERE quantity > :1 AND (order_id, product_id) IN ((:2,:3),(:4,:5))",
+			wantArgs: []interface{}{3, int64(1), int64(2001), int64(2), int64(2005)},
+		},
+		{
+			name:     "or chain",
+			query:    "SELECT * FROM order_details od WHERE (?) AND od.quantity > ?",
+			args:     []interface{}{OrChain{Columns: []string{"od.order_id", "od.product_id"}, Tuples: keys}, 3},
+			want:     "SELECT * FROM order_details od WHERE ((od.order_id = :1 AND od.product_id = :2) OR (od.order_id = :3 AND od.product_id = :4)) AND od.quantity > :5",
+			wantArgs: []interface{}{int64(1), int64(2001), int64(2), int64(2005), 3},
+		},
+		{
+			name:    "empty tuples",
+			query:   "WHERE (order_id, product_id) IN (?)",
+			args:    []interface{}{Tuples{}},
+			wantErr: ErrEmptyInList,
+		},
+		{
+			name:    "uneven tuples",
+			query:   "WHERE (order_id, product_id) IN (?)",
+			args:    []interface{}{Tuples{{1, 2}, {3}}},
+			wantErr: ErrTupleWidth,
+		},
+		{
+			name:    "columns and values differ",
+			query:   "WHERE (?)",
+			args:    []interface{}{OrChain{Columns: []string{"order_id"}, Tuples: keys}},
+			wantErr: ErrTupleWidth,
+		},
+		{
+			name:    "invalid column",
+			query:   "WHERE (?)",
+			args:    []interface{}{OrChain{Columns: []string{"order_id", "1=1 OR product_id"}, Tuples: keys}},
+			wantErr: ErrInvalidColumn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotArgs, err := In(tt.query, tt.args...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("In() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("In() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("In() query = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("In() args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestQueryInTuples(t *testing.T) {
+	keys := make([][]int64, 5)
+	for i := range keys {
+		keys[i] = []int64{int64(i + 1), int64(2001 + i)}
+	}
+
+	tests := []struct {
+		query string
+		arg   interface{}
+		want  string
+	}{
+		{
+			query: "SELECT detail_id FROM order_details WHERE (order_id, product_id) IN (?)",
+			arg:   Int64Tuples(keys),
+			want:  "SELECT detail_id FROM order_details WHERE (order_id, product_id) IN ((:1,:2),(:3,:4))",
+		},
+		{
+			query: "SELECT detail_id FROM order_details WHERE (?)",
+			arg:   OrChain{Columns: []string{"order_id", "product_id"}, Tuples: Int64Tuples(keys)},
+			want:  "SELECT detail_id FROM order_details WHERE ((order_id = :1 AND product_id = :2) OR (order_id = :3 AND product_id = :4))",
+		},
+	}
+	for _, tt := range tests {
+		q := &recordingQueryer{}
+		err := QueryIn(q, tt.query, 2, func(*sql.Rows) error { return nil }, tt.arg)
+		if !errors.Is(err, errStop) {
+			t.Fatalf("QueryIn(%T) error = %v, want %v", tt.arg, err, errStop)
+		}
+		// 組の数で分割するため、最初の実行は2組（4つのバインド変数）になる
+		if q.queries[0] != tt.want {
+			t.Errorf("QueryIn(%T) first chunk = %q, want %q", tt.arg, q.queries[0], tt.want)
+		}
+		if want := []interface{}{int64(1), int64(2001), int64(2), int64(2002)}; !reflect.DeepEqual(q.args[0], want) {
+			t.Errorf("QueryIn(%T) first chunk args = %v, want %v", tt.arg, q.args[0], want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStudentTQuantile(t *testing.T) {
+	// t分布表の上側2.5%・5%点
+	tests := []struct {
+		p, df float64
+		want  float64
+	}{
+		{p: 0.975, df: 1, want: 12.706204736},
+		{p: 0.975, df: 2, want: 4.302652730},
+		{p: 0.975, df: 5, want: 2.570581836},
+		{p: 0.975, df: 30, want: 2.042272456},
+		{p: 0.95, df: 10, want: 1.812461123},
+		{p: 0.025, df: 5, want: -2.570581836},
+		{p: 0.5, df: 7, want: 0},
+	}
+	for _, tt := range tests {
+		if got := StudentTQuantile(tt.p, tt.df); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("StudentTQuantile(%v, %v) = %.9f, want %.9f", tt.p, tt.df, got, tt.want)
+		}
+	}
+
+	for _, args := range [][2]float64{{0, 5}, {1, 5}, {0.5, 0}} {
+		if got := StudentTQuantile(args[0], args[1]); !math.IsNaN(got) {
+			t.Errorf("StudentTQuantile(%v, %v) = %v, want NaN", args[0], args[1], got)
+		}
+	}
+}
+
+func TestStudentTCDF(t *testing.T) {
+	tests := []struct {
+		t, df float64
+		want  float64
+	}{
+		// 自由度1はコーシー分布 1/2 + arctan(t)/π、自由度2は 1/2 + t / (2√(2+t²))
+		{t: 1, df: 1, want: 0.75},
+		{t: -3, df: 1, want: 0.5 + math.Atan(-3)/math.Pi},
+		{t: 2, df: 2, want: 0.5 + 2/(2*math.Sqrt(6))},
+		{t: 0, df: 9, want: 0.5},
+		{t: 2, df: 10, want: 0.9633059826},
+		{t: 1.5, df: 3.5, want: 0.8910909065},
+		{t: 2.042272456, df: 30, want: 0.975},
+		{t: 40, df: 30, want: 1},
+	}
+	for _, tt := range tests {
+		if got := StudentTCDF(tt.t, tt.df); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("StudentTCDF(%v, %v) = %.10f, want %.10f", tt.t, tt.df, got, tt.want)
+		}
+	}
+
+	if got := StudentTCDF(1, 0); !math.IsNaN(got) {
+		t.Errorf("StudentTCDF(1, 0) = %v, want NaN", got)
+	}
+}
+
+func TestRegularizedIncompleteBeta(t *testing.T) {
+	tests := []struct {
+		x, a, b float64
+		want    float64
+	}{
+		// I_x(1,1) = x、I_x(a,1) = x^a、I_x(1,b) = 1-(1-x)^b、I_0.5(a,a) = 1/2
+		{x: 0.3, a: 1, b: 1, want: 0.3},
+		{x: 0.6, a: 3, b: 1, want: math.Pow(0.6, 3)},
+		{x: 0.2, a: 1, b: 4, want: 1 - math.Pow(0.8, 4)},
+		{x: 0.5, a: 7.5, b: 7.5, want: 0.5},
+		{x: 0.9, a: 0.5, b: 20, want: 1},
+		{x: 0, a: 2, b: 3, want: 0},
+		{x: 1, a: 2, b: 3, want: 1},
+	}
+	for _, tt := range tests {
+		if got := regularizedIncompleteBeta(tt.x, tt.a, tt.b); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("regularizedIncompleteBeta(%v, %v, %v) = %.15f, want %.15f", tt.x, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
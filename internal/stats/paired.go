@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+// Paired - 対応のある2系列の差の統計（同じ回に続けて実行した組ごとに 候補 - 基準 を取る）
+//
+// 時間とともに変わるDBの負荷は同じ組の両方にほぼ等しく掛かるため、組ごとの差を取ると打ち消される。
+type Paired struct {
+	Pairs int `json:"pairs"`
+	// MeanDiff - 組ごとの差（候補 - 基準）の平均（負の値は候補が速い）
+	MeanDiff time.Duration `json:"mean_diff"`
+	StdDev   time.Duration `json:"stddev"`
+	// CILow / CIHigh - 差の平均の95%信頼区間（3組以上の場合のみ）
+	CILow  time.Duration `json:"ci95_low"`
+	CIHigh time.Duration `json:"ci95_high"`
+	// Ratio - 組ごとの 基準 / 候補 の幾何平均（何倍速いか）
+	Ratio float64 `json:"ratio"`
+	// P - 対応のあるt検定の両側p値（2組未満または差が一定の場合は求めず1）
+	P float64 `json:"p_value"`
+}
+
+// PairedDifference - 同じ添字の組で 候補 - 基準 の差の統計を求める（組数は短い方に合わせる）
+func PairedDifference(baseline, candidate []time.Duration) Paired {
+	n := min(len(baseline), len(candidate))
+	p := Paired{Pairs: n, P: 1}
+	if n == 0 {
+		return p
+	}
+
+	diffs := make([]float64, n)
+	var logRatio float64
+	ratios := 0
+	for i := 0; i < n; i++ {
+		diffs[i] = float64(candidate[i] - baseline[i])
+		if baseline[i] > 0 && candidate[i] > 0 {
+			logRatio += math.Log(float64(baseline[i]) / float64(candidate[i]))
+			ratios++
+		}
+	}
+	if ratios > 0 {
+		p.Ratio = math.Exp(logRatio / float64(ratios))
+	}
+
+	mean := Mean(diffs)
+	sd := StdDev(diffs)
+	p.MeanDiff = time.Duration(mean)
+	p.StdDev = time.Duration(sd)
+	if n >= 2 && sd > 0 {
+		p.P = twoSidedT(mean/(sd/math.Sqrt(float64(n))), float64(n-1))
+	}
+	if n >= 3 {
+		margin := StudentTQuantile(0.975, float64(n-1)) * sd / math.Sqrt(float64(n))
+		p.CILow = time.Duration(mean - margin)
+		p.CIHigh = time.Duration(mean + margin)
+	}
+	return p
+}
+
+// HasInterval - 信頼区間を求められたか
+func (p Paired) HasInterval() bool {
+	return p.Pairs >= 3
+}
+
+// Faster - 95%信頼区間が0より小さい（候補の方が速いといえる）
+func (p Paired) Faster() bool {
+	return p.HasInterval() && p.CIHigh < 0
+}
+
+// Slower - 95%信頼区間が0より大きい（候補の方が遅いといえる）
+func (p Paired) Slower() bool {
+	return p.HasInterval() && p.CILow > 0
+}
@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func ms(values ...float64) []time.Duration {
+	ds := make([]time.Duration, len(values))
+	for i, v := range values {
+		ds[i] = time.Duration(v * float64(time.Millisecond))
+	}
+	return ds
+}
+
+func TestPairedDifference(t *testing.T) {
+	// 差は -10,-10,-15,-5,-10ms（平均 -10ms、標準偏差 √12.5ms）
+	// 信頼区間の幅は t(0.975, 4) × sd / √5 ≈ 4.3899ms、t = -6.3246 の両側p値は 0.0031982
+	got := PairedDifference(ms(100, 110, 120, 130, 140), ms(90, 100, 105, 125, 130))
+
+	near := func(d time.Duration, wantMs float64) bool {
+		return math.Abs(float64(d)-wantMs*float64(time.Millisecond)) < float64(time.Microsecond)
+	}
+	if got.Pairs != 5 || got.MeanDiff != -10*time.Millisecond || !near(got.StdDev, math.Sqrt(12.5)) {
+		t.Errorf("PairedDifference() = %+v, want Pairs 5, MeanDiff -10ms, StdDev %.4fms", got, math.Sqrt(12.5))
+	}
+	if !near(got.CILow, -14.3899451651) || !near(got.CIHigh, -5.6100548349) {
+		t.Errorf("PairedDifference() CI = [%v, %v], want [-14.3899ms, -5.6101ms]", got.CILow, got.CIHigh)
+	}
+	if math.Abs(got.Ratio-1.0936340643) > 1e-9 {
+		t.Errorf("PairedDifference() Ratio = %v, want 1.0936340643", got.Ratio)
+	}
+	if math.Abs(got.P-0.0031982022) > 1e-8 {
+		t.Errorf("PairedDifference() P = %v, want 0.0031982022", got.P)
+	}
+	if !got.HasInterval() || !got.Faster() || got.Slower() {
+		t.Errorf("PairedDifference() Faster/Slower = %v/%v, want true/false", got.Faster(), got.Slower())
+	}
+
+	// 基準と候補を入れ替えると差の符号が反転し、遅いと判定される
+	rev := PairedDifference(ms(90, 100, 105, 125, 130), ms(100, 110, 120, 130, 140))
+	if rev.MeanDiff != 10*time.Millisecond || rev.CILow != -got.CIHigh || rev.CIHigh != -got.CILow || rev.P != got.P {
+		t.Errorf("PairedDifference(reversed) = %+v, want the mirror of %+v", rev, got)
+	}
+	if rev.Faster() || !rev.Slower() {
+		t.Errorf("PairedDifference(reversed) Faster/Slower = %v/%v, want false/true", rev.Faster(), rev.Slower())
+	}
+
+	// 区間が0をまたげばどちらともいえない
+	mixed := PairedDifference(ms(100, 100, 100, 100), ms(95, 104, 98, 103))
+	if !mixed.HasInterval() || mixed.Faster() || mixed.Slower() {
+		t.Errorf("PairedDifference(mixed) = %+v, want an interval containing 0", mixed)
+	}
+}
+
+func TestPairedDifferenceEdgeCases(t *testing.T) {
+	tests := []struct {
+		name         string
+		baseline     []time.Duration
+		candidate    []time.Duration
+		wantPairs    int
+		wantMean     time.Duration
+		wantRatio    float64
+		wantP        float64
+		wantInterval bool
+	}{
+		{name: "empty", wantP: 1},
+		{name: "one pair", baseline: ms(10), candidate: ms(5), wantPairs: 1, wantMean: -5 * time.Millisecond, wantRatio: 2, wantP: 1},
+		// 2組ならp値は求めるが（自由度1で t = -3）、信頼区間は3組から
+		{name: "two pairs", baseline: ms(10, 20), candidate: ms(5, 10), wantPairs: 2, wantMean: -7500 * time.Microsecond, wantRatio: 2, wantP: 1 - 2*math.Atan(3)/math.Pi},
+		{name: "two equal diffs", baseline: ms(10, 20), candidate: ms(5, 15), wantPairs: 2, wantMean: -5 * time.Millisecond, wantRatio: math.Sqrt(2 * 20.0 / 15), wantP: 1},
+		// 長さが違う場合は短い方に合わせる
+		{name: "truncated", baseline: ms(10, 20, 30, 40), candidate: ms(20, 30), wantPairs: 2, wantMean: 10 * time.Millisecond, wantRatio: math.Sqrt(1.0 / 3), wantP: 1},
+		// 0の組は比から除く
+		{name: "zero duration", baseline: ms(0, 10), candidate: ms(5, 15), wantPairs: 2, wantMean: 5 * time.Millisecond, wantRatio: 10.0 / 15, wantP: 1},
+	}
+	for _, tt := range tests {
+		got := PairedDifference(tt.baseline, tt.candidate)
+		if got.Pairs != tt.wantPairs || got.MeanDiff != tt.wantMean || math.Abs(got.Ratio-tt.wantRatio) > 1e-12 {
+			t.Errorf("%s: PairedDifference() = %+v, want Pairs %d, MeanDiff %v, Ratio %v", tt.name, got, tt.wantPairs, tt.wantMean, tt.wantRatio)
+		}
+		if math.Abs(got.P-tt.wantP) > 1e-9 {
+			t.Errorf("%s: PairedDifference() P = %v, want %v", tt.name, got.P, tt.wantP)
+		}
+		if got.HasInterval() != tt.wantInterval || got.Faster() || got.Slower() {
+			t.Errorf("%s: HasInterval/Faster/Slower = %v/%v/%v, want %v/false/false", tt.name, got.HasInterval(), got.Faster(), got.Slower(), tt.wantInterval)
+		}
+	}
+
+	// 差が一定（分散0）ならp値は求めず、信頼区間は平均の1点になる
+	constant := PairedDifference(ms(10, 20, 30), ms(8, 18, 28))
+	if constant.P != 1 || constant.StdDev != 0 || constant.CILow != -2*time.Millisecond || constant.CIHigh != -2*time.Millisecond {
+		t.Errorf("PairedDifference(constant) = %+v, want P 1 and CI [-2ms, -2ms]", constant)
+	}
+	if !constant.HasInterval() || !constant.Faster() {
+		t.Errorf("PairedDifference(constant) Faster = %v, want true", constant.Faster())
+	}
+}
@@ -0,0 +1,203 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// SignificanceLevel - 有意とみなすp値の上限
+const SignificanceLevel = 0.05
+
+// exactMannWhitneyLimit - 同順位がない場合に正確なU分布でp値を求める合計標本数の上限
+const exactMannWhitneyLimit = 40
+
+// TTest - Welchのt検定（等分散を仮定しない2標本の平均の差の検定、両側）
+type TTest struct {
+	T  float64 `json:"t"`
+	DF float64 `json:"df"`
+	P  float64 `json:"p_value"`
+}
+
+// WelchTTest - aとbの平均に差があるかを検定（各2件以上、両方の分散が0の場合は検定できない）
+func WelchTTest(a, b []float64) (TTest, bool) {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if n1 < 2 || n2 < 2 {
+		return TTest{}, false
+	}
+
+	v1, v2 := StdDev(a), StdDev(b)
+	v1, v2 = v1*v1/n1, v2*v2/n2
+	se := math.Sqrt(v1 + v2)
+	if se == 0 {
+		return TTest{}, false
+	}
+
+	t := (Mean(a) - Mean(b)) / se
+	// Welch–Satterthwaiteの近似自由度
+	df := (v1 + v2) * (v1 + v2) / (v1*v1/(n1-1) + v2*v2/(n2-1))
+	return TTest{T: t, DF: df, P: twoSidedT(t, df)}, true
+}
+
+// MannWhitney - Mann-WhitneyのU検定（分布の位置の差の検定、両側）
+//
+// 実行時間の分布は外れ値で右に裾を引くことが多いため、平均に基づくt検定と併せて順位に基づく検定も行う。
+type MannWhitney struct {
+	U float64 `json:"u"`
+	P float64 `json:"p_value"`
+	// Exact - 正確なU分布でp値を求めたか（falseは同順位補正付きの正規近似）
+	Exact bool `json:"exact"`
+}
+
+// MannWhitneyU - aとbの分布の位置に差があるかを検定（Uはaの順位和から求める）
+func MannWhitneyU(a, b []float64) (MannWhitney, bool) {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return MannWhitney{}, false
+	}
+
+	type value struct {
+		v     float64
+		fromA bool
+	}
+	values := make([]value, 0, n1+n2)
+	for _, v := range a {
+		values = append(values, value{v, true})
+	}
+	for _, v := range b {
+		values = append(values, value{v, false})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].v < values[j].v })
+
+	// 同順位には平均順位を割り当てる
+	var rankSumA, tieTerm float64
+	ties := false
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].v == values[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if values[k].fromA {
+				rankSumA += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+
+	u := rankSumA - float64(n1*(n1+1))/2
+	result := MannWhitney{U: u}
+
+	if !ties && n1+n2 <= exactMannWhitneyLimit {
+		result.P = exactMannWhitneyP(u, n1, n2)
+		result.Exact = true
+		return result, true
+	}
+
+	n := float64(n1 + n2)
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieTerm/(n*(n-1))))
+	if sigma == 0 {
+		result.P = 1
+		return result, true
+	}
+	// 連続性補正
+	z := math.Max(math.Abs(u-mu)-0.5, 0) / sigma
+	result.P = math.Min(1, math.Erfc(z/math.Sqrt2))
+	return result, true
+}
+
+// exactMannWhitneyP - 同順位がない場合のUの正確な両側p値
+func exactMannWhitneyP(u float64, n1, n2 int) float64 {
+	// counts[i][j][k] = i件とj件の並びのうちU=kとなる数（漸化式 f(i,j,k) = f(i-1,j,k-j) + f(i,j-1,k)）
+	maxU := n1 * n2
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = make([]float64, maxU+1)
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			cur[j] = make([]float64, maxU+1)
+			for k := 0; k <= i*j; k++ {
+				if k >= j {
+					cur[j][k] += prev[j][k-j]
+				}
+				cur[j][k] += cur[j-1][k]
+			}
+		}
+		prev = cur
+	}
+
+	dist := prev[n2]
+	var total, lower, upper float64
+	for k, count := range dist {
+		total += count
+		if float64(k) <= u {
+			lower += count
+		}
+		if float64(k) >= u {
+			upper += count
+		}
+	}
+	return math.Min(1, 2*math.Min(lower, upper)/total)
+}
+
+// CohensD - 平均の差を併合標準偏差で割った効果量（(平均a - 平均b) / 併合SD、求められない場合はfalse）
+func CohensD(a, b []float64) (float64, bool) {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if n1 < 2 || n2 < 2 {
+		return 0, false
+	}
+	s1, s2 := StdDev(a), StdDev(b)
+	pooled := math.Sqrt(((n1-1)*s1*s1 + (n2-1)*s2*s2) / (n1 + n2 - 2))
+	if pooled == 0 {
+		return 0, false
+	}
+	return (Mean(a) - Mean(b)) / pooled, true
+}
+
+// CliffsDelta - aの値がbの値より大きい組の割合から小さい組の割合を引いた効果量（-1〜1）
+func CliffsDelta(a, b []float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var greater, less int
+	for _, x := range a {
+		for _, y := range b {
+			switch {
+			case x > y:
+				greater++
+			case x < y:
+				less++
+			}
+		}
+	}
+	return float64(greater-less) / float64(len(a)*len(b))
+}
+
+// EffectMagnitude - Cohenのdの大きさの目安（0.2: 小, 0.5: 中, 0.8: 大）
+func EffectMagnitude(d float64) string {
+	switch d = math.Abs(d); {
+	case d < 0.2:
+		return "ごく小さい"
+	case d < 0.5:
+		return "小"
+	case d < 0.8:
+		return "中"
+	default:
+		return "大"
+	}
+}
+
+// twoSidedT - t分布の両側p値
+func twoSidedT(t, df float64) float64 {
+	return math.Min(1, 2*StudentTCDF(-math.Abs(t), df))
+}
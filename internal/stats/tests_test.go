@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWelchTTest(t *testing.T) {
+	// scipy.stats.ttest_ind(a, b, equal_var=False) と同じ値
+	tests := []struct {
+		name       string
+		a, b       []float64
+		wantT      float64
+		wantDF     float64
+		wantP      float64
+		wantFailed bool
+	}{
+		{
+			name:   "unequal variances",
+			a:      []float64{1, 2, 3, 4, 5},
+			b:      []float64{2, 4, 6, 8, 10},
+			wantT:  -1.8973665961,
+			wantDF: 5.8823529412,
+			wantP:  0.1075311949,
+		},
+		{
+			name:   "clear difference",
+			a:      []float64{10.1, 9.8, 10.3, 10.0, 9.9, 10.2},
+			b:      []float64{11.0, 11.4, 10.9, 11.8, 11.2},
+			wantT:  -6.8248034943,
+			wantDF: 5.7900977334,
+			wantP:  0.0005647801,
+		},
+		{name: "one sample", a: []float64{1}, b: []float64{2, 3}, wantFailed: true},
+		{name: "zero variance", a: []float64{5, 5, 5}, b: []float64{5, 5}, wantFailed: true},
+	}
+	for _, tt := range tests {
+		got, ok := WelchTTest(tt.a, tt.b)
+		if ok == tt.wantFailed {
+			t.Errorf("%s: WelchTTest() ok = %v, want %v", tt.name, ok, !tt.wantFailed)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(got.T-tt.wantT) > 1e-8 || math.Abs(got.DF-tt.wantDF) > 1e-8 || math.Abs(got.P-tt.wantP) > 1e-8 {
+			t.Errorf("%s: WelchTTest() = %+v, want {T:%v DF:%v P:%v}", tt.name, got, tt.wantT, tt.wantDF, tt.wantP)
+		}
+	}
+
+	// 順序を入れ替えるとtの符号だけが変わる
+	ab, _ := WelchTTest([]float64{1, 2, 3, 4, 5}, []float64{2, 4, 6, 8, 10})
+	ba, _ := WelchTTest([]float64{2, 4, 6, 8, 10}, []float64{1, 2, 3, 4, 5})
+	if ab.T != -ba.T || ab.P != ba.P {
+		t.Errorf("WelchTTest() is not symmetric: %+v / %+v", ab, ba)
+	}
+}
+
+func TestMannWhitneyUExact(t *testing.T) {
+	// n1=n2=3のUの分布は U=0..9 で 1,1,2,3,3,3,3,2,1,1（全20通り）
+	tests := []struct {
+		name  string
+		a, b  []float64
+		wantU float64
+		wantP float64
+	}{
+		{name: "all smaller", a: []float64{1, 2, 3}, b: []float64{4, 5, 6}, wantU: 0, wantP: 2.0 / 20},
+		{name: "all larger", a: []float64{4, 5, 6}, b: []float64{1, 2, 3}, wantU: 9, wantP: 2.0 / 20},
+		{name: "one swap", a: []float64{1, 2, 4}, b: []float64{3, 5, 6}, wantU: 1, wantP: 2 * 2.0 / 20},
+		{name: "interleaved", a: []float64{1, 3, 5}, b: []float64{2, 4, 6}, wantU: 3, wantP: 2 * 7.0 / 20},
+		{name: "middle", a: []float64{1, 4, 6}, b: []float64{2, 3, 5}, wantU: 5, wantP: 1},
+		// n1=n2=4で完全に分かれた場合は 2 / C(8,4) = 2/70
+		{name: "four each", a: []float64{1, 2, 3, 4}, b: []float64{5, 6, 7, 8}, wantU: 0, wantP: 2.0 / 70},
+	}
+	for _, tt := range tests {
+		got, ok := MannWhitneyU(tt.a, tt.b)
+		if !ok {
+			t.Errorf("%s: MannWhitneyU() failed", tt.name)
+			continue
+		}
+		if !got.Exact || got.U != tt.wantU || math.Abs(got.P-tt.wantP) > 1e-12 {
+			t.Errorf("%s: MannWhitneyU() = %+v, want {U:%v P:%v Exact:true}", tt.name, got, tt.wantU, tt.wantP)
+		}
+	}
+}
+
+func TestMannWhitneyUApproximation(t *testing.T) {
+	// 同順位があると正規近似（同順位補正・連続性補正付き）になる
+	a := []float64{1, 2, 2, 3, 4, 5, 5, 6}
+	b := []float64{5, 6, 7, 7, 8, 9, 10, 11}
+	got, ok := MannWhitneyU(a, b)
+	if !ok {
+		t.Fatal("MannWhitneyU() failed")
+	}
+	if got.Exact {
+		t.Error("MannWhitneyU() with ties used the exact distribution")
+	}
+	// 5と6の同順位は0.5として数える
+	if got.U != 2.5 {
+		t.Errorf("MannWhitneyU() U = %v, want 2.5", got.U)
+	}
+	if got.P <= 0 || got.P >= SignificanceLevel {
+		t.Errorf("MannWhitneyU() P = %v, want significant", got.P)
+	}
+
+	// すべて同じ値なら差はない
+	same, _ := MannWhitneyU([]float64{1, 1, 1}, []float64{1, 1})
+	if same.P != 1 {
+		t.Errorf("MannWhitneyU(all ties) P = %v, want 1", same.P)
+	}
+	if _, ok := MannWhitneyU(nil, []float64{1}); ok {
+		t.Error("MannWhitneyU(empty) succeeded")
+	}
+}
+
+func TestExactMannWhitneyP(t *testing.T) {
+	// n1=n2=3の下側の累積 P(U<=k) = 1,2,4,7,10 / 20
+	lower := []float64{1, 2, 4, 7, 10}
+	for u, count := range lower {
+		want := math.Min(1, 2*count/20)
+		if got := exactMannWhitneyP(float64(u), 3, 3); math.Abs(got-want) > 1e-12 {
+			t.Errorf("exactMannWhitneyP(%d, 3, 3) = %v, want %v", u, got, want)
+		}
+	}
+	// 標本数が異なる場合（n1=2, n2=5、全21通り、U=0は1通り）
+	if got := exactMannWhitneyP(0, 2, 5); math.Abs(got-2.0/21) > 1e-12 {
+		t.Errorf("exactMannWhitneyP(0, 2, 5) = %v, want %v", got, 2.0/21)
+	}
+}
+
+func TestEffectSizes(t *testing.T) {
+	d, ok := CohensD([]float64{2, 4, 6}, []float64{1, 3, 5})
+	if !ok || math.Abs(d-0.5) > 1e-12 {
+		t.Errorf("CohensD() = %v, %v, want 0.5", d, ok)
+	}
+	if _, ok := CohensD([]float64{1, 1}, []float64{1, 1}); ok {
+		t.Error("CohensD(zero variance) succeeded")
+	}
+
+	if got := CliffsDelta([]float64{4, 5}, []float64{1, 2, 3}); got != 1 {
+		t.Errorf("CliffsDelta(all greater) = %v, want 1", got)
+	}
+	if got := CliffsDelta([]float64{1, 4}, []float64{2, 3}); got != 0 {
+		t.Errorf("CliffsDelta(balanced) = %v, want 0", got)
+	}
+
+	for d, want := range map[float64]string{0.1: "ごく小さい", -0.3: "小", 0.5: "中", -1.2: "大"} {
+		if got := EffectMagnitude(d); got != want {
+			t.Errorf("EffectMagnitude(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
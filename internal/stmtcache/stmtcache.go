@@ -0,0 +1,111 @@
+package stmtcache
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Metrics - ステートメントキャッシュの利用状況
+type Metrics struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+// HitRate - ヒット率（%）
+func (m Metrics) HitRate() float64 {
+	total := m.Hits + m.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(m.Hits) / float64(total) * 100
+}
+
+// Preparer - ステートメントを作成できるDB（*sql.DB・*sql.Conn由来のアダプターなど）
+type Preparer interface {
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+// Cache - 正規化したSQLをキーとするアプリケーション側のプリペアドステートメントキャッシュ
+type Cache struct {
+	db     Preparer
+	mu     sync.Mutex
+	stmts  map[string]*sql.Stmt
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// New - ステートメントキャッシュのコンストラクタ
+func New(db Preparer) *Cache {
+	return &Cache{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+}
+
+// Normalize - 空白の違いを吸収したキャッシュキーを作成
+func Normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// Prepare - キャッシュ済みのステートメントを返し、なければdb.Prepareして登録する
+//
+// 返されたステートメントはキャッシュが所有するため、呼び出し側でCloseしないこと。
+func (c *Cache) Prepare(query string) (*sql.Stmt, error) {
+	key := Normalize(query)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[key]; ok {
+		c.hits.Add(1)
+		return stmt, nil
+	}
+
+	c.misses.Add(1)
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	c.stmts[key] = stmt
+
+	return stmt, nil
+}
+
+// Metrics - 現在のヒット・ミス件数を取得
+func (c *Cache) Metrics() Metrics {
+	c.mu.Lock()
+	entries := len(c.stmts)
+	c.mu.Unlock()
+
+	return Metrics{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+	}
+}
+
+// ResetMetrics - ヒット・ミス件数をリセット（キャッシュ内容は保持）
+func (c *Cache) ResetMetrics() {
+	c.hits.Store(0)
+	c.misses.Store(0)
+}
+
+// Close - キャッシュしているすべてのステートメントをクローズ
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for key, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.stmts, key)
+	}
+
+	return firstErr
+}
@@ -0,0 +1,283 @@
+// Package teardown - デモが作成したオブジェクトとRedisキーを削除して環境を初期状態に戻す
+package teardown
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"oracle-n-plus-1-demo/internal/schema"
+)
+
+// オブジェクトの種類（USER_OBJECTS.OBJECT_TYPE）
+const (
+	TypeFunction         = "FUNCTION"
+	TypeMaterializedView = "MATERIALIZED VIEW"
+	TypeIndex            = "INDEX"
+	TypeTable            = "TABLE"
+	TypeSequence         = "SEQUENCE"
+)
+
+// dropOrder - 削除する順序（依存するものから先に削除する）
+var dropOrder = []string{TypeFunction, TypeMaterializedView, TypeIndex, TypeTable, TypeSequence}
+
+// redisDeleteBatch - 1回のDELで削除するキー数
+const redisDeleteBatch = 100
+
+// objectName - 引用符なしで作成されたオブジェクト名（データディクショナリ上は大文字）
+var objectName = regexp.MustCompile(`^[A-Z][A-Z0-9_$#]*$`)
+
+// Object - 削除するデータベースオブジェクト
+type Object struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// DropStatement - オブジェクトを削除するDDL
+//
+// 表は参照制約ごと削除し、ごみ箱に残さない（PURGE）。名前はデータディクショナリから取得した識別子に限る。
+func (o Object) DropStatement() (string, error) {
+	if !objectName.MatchString(o.Name) {
+		return "", fmt.Errorf("unexpected object name %q", o.Name)
+	}
+	switch o.Type {
+	case TypeTable:
+		return fmt.Sprintf(`DROP TABLE "%s" CASCADE CONSTRAINTS PURGE`, o.Name), nil
+	case TypeFunction, TypeMaterializedView, TypeIndex, TypeSequence:
+		return fmt.Sprintf(`DROP %s "%s"`, o.Type, o.Name), nil
+	default:
+		return "", fmt.Errorf("unsupported object type %q", o.Type)
+	}
+}
+
+// Plan - 削除対象（存在するものだけ）
+type Plan struct {
+	Objects []Object `json:"objects"`
+	// RedisKeys - パターンごとの削除するキー（Redisに接続していない場合はnil）
+	RedisKeys map[string][]string `json:"redis_keys,omitempty"`
+}
+
+// RedisKeyCount - 削除するRedisキーの総数
+func (p *Plan) RedisKeyCount() int {
+	count := 0
+	for _, keys := range p.RedisKeys {
+		count += len(keys)
+	}
+	return count
+}
+
+// Empty - 削除するものがないか
+func (p *Plan) Empty() bool {
+	return len(p.Objects) == 0 && p.RedisKeyCount() == 0
+}
+
+// Planner - 削除対象を調べる
+type Planner struct {
+	db          *sql.DB
+	redisClient *redis.Client
+	// functionMarker - デモが作成したPL/SQL関数のソースに含まれる目印
+	functionMarker string
+	// redisPatterns - 削除するRedisキーのパターン
+	redisPatterns []string
+}
+
+// NewPlanner - Plannerのコンストラクタ（redisClientがnilの場合はRedisキーを調べない）
+func NewPlanner(db *sql.DB, redisClient *redis.Client, functionMarker string, redisPatterns []string) *Planner {
+	return &Planner{db: db, redisClient: redisClient, functionMarker: functionMarker, redisPatterns: redisPatterns}
+}
+
+// Plan - 接続ユーザーのスキーマとRedisから、存在するデモのオブジェクトとキーを調べる
+func (p *Planner) Plan(ctx context.Context) (*Plan, error) {
+	existing, err := p.loadObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	functions, err := p.loadDemoFunctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Objects: selectDemoObjects(existing, functions)}
+	if p.redisClient != nil {
+		plan.RedisKeys, err = p.scanRedisKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// loadObjects - スキーマ内の削除対象になりうる種類のオブジェクト
+func (p *Planner) loadObjects(ctx context.Context) (objects []Object, err error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT object_type, object_name FROM user_objects
+		WHERE object_type IN ('FUNCTION', 'MATERIALIZED VIEW', 'INDEX', 'TABLE', 'SEQUENCE')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user_objects: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var o Object
+		if err := rows.Scan(&o.Type, &o.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan user_objects: %w", err)
+		}
+		objects = append(objects, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate user_objects: %w", err)
+	}
+	return objects, nil
+}
+
+// loadDemoFunctions - ソースに目印を含む（デモが作成した）PL/SQL関数の名前
+//
+// 関数名は接頭辞・接尾辞で変えられるため、名前ではなくソース中の目印で探す。
+func (p *Planner) loadDemoFunctions(ctx context.Context) (names []string, err error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT name FROM user_source
+		WHERE type = 'FUNCTION' AND INSTR(text, :1) > 0`, p.functionMarker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user_source: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan user_source: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate user_source: %w", err)
+	}
+	return names, nil
+}
+
+// scanRedisKeys - パターンに一致するキー（SCANで取得するためKEYSのようにRedisを止めない）
+func (p *Planner) scanRedisKeys(ctx context.Context) (map[string][]string, error) {
+	keys := make(map[string][]string)
+	for _, pattern := range p.redisPatterns {
+		iter := p.redisClient.Scan(ctx, 0, pattern, redisDeleteBatch).Iterator()
+		for iter.Next(ctx) {
+			keys[pattern] = append(keys[pattern], iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, fmt.Errorf("failed to scan redis keys %s: %w", pattern, err)
+		}
+	}
+	return keys, nil
+}
+
+// selectDemoObjects - 既存のオブジェクトからデモのものを削除する順に選ぶ
+//
+// マテリアライズドビューはUSER_OBJECTSに同名の表としても現れるため、表としては扱わない。
+// マテリアライズドビューの索引はビューと一緒に削除されるため対象にしない。
+func selectDemoObjects(existing []Object, functions []string) []Object {
+	demo := map[string]map[string]bool{
+		TypeFunction:         set(functions),
+		TypeMaterializedView: set(schema.ExpectedMaterializedViews),
+		TypeSequence:         set(schema.ExpectedSequences),
+		TypeTable:            {},
+		TypeIndex:            {},
+	}
+	for _, table := range schema.ExpectedTables {
+		demo[TypeTable][table.Name] = true
+		for _, index := range table.Indexes {
+			// 主キー・一意制約の索引は表と一緒に削除される
+			if strings.HasPrefix(index.Name, "IDX_") {
+				demo[TypeIndex][index.Name] = true
+			}
+		}
+	}
+
+	byType := make(map[string][]Object)
+	for _, o := range existing {
+		if o.Type == TypeTable && demo[TypeMaterializedView][o.Name] {
+			continue
+		}
+		if demo[o.Type][o.Name] {
+			byType[o.Type] = append(byType[o.Type], o)
+		}
+	}
+
+	var objects []Object
+	for _, t := range dropOrder {
+		objects = append(objects, byType[t]...)
+	}
+	return objects
+}
+
+// set - 名前の集合
+func set(names []string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, name := range names {
+		m[name] = true
+	}
+	return m
+}
+
+// Result - 削除の結果
+type Result struct {
+	Dropped   []Object `json:"dropped"`
+	RedisKeys int64    `json:"redis_keys_deleted"`
+	Failures  []string `json:"failures,omitempty"`
+}
+
+// ErrIncomplete - 一部のオブジェクトまたはキーを削除できなかった
+var ErrIncomplete = errors.New("cleanup did not complete")
+
+// Execute - 計画したオブジェクトとキーを削除する
+//
+// 1件の削除に失敗しても残りの削除を続け、失敗したものは Result.Failures に記録して ErrIncomplete を返す。
+// 各DDLを実行する前にonDropを呼ぶ（進捗の表示用、nilでもよい）。
+func Execute(ctx context.Context, db *sql.DB, redisClient *redis.Client, plan *Plan, onDrop func(stmt string)) (*Result, error) {
+	result := &Result{}
+	for _, o := range plan.Objects {
+		stmt, err := o.DropStatement()
+		if err == nil {
+			if onDrop != nil {
+				onDrop(stmt)
+			}
+			_, err = db.ExecContext(ctx, stmt)
+		}
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s %s: %v", o.Type, o.Name, err))
+			continue
+		}
+		result.Dropped = append(result.Dropped, o)
+	}
+
+	if redisClient != nil {
+		for pattern, keys := range plan.RedisKeys {
+			for start := 0; start < len(keys); start += redisDeleteBatch {
+				end := min(start+redisDeleteBatch, len(keys))
+				deleted, err := redisClient.Del(ctx, keys[start:end]...).Result()
+				if err != nil {
+					result.Failures = append(result.Failures, fmt.Sprintf("redis %s: %v", pattern, err))
+					break
+				}
+				result.RedisKeys += deleted
+			}
+		}
+	}
+
+	if len(result.Failures) > 0 {
+		return result, fmt.Errorf("%w: %d failures", ErrIncomplete, len(result.Failures))
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package teardown
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectDemoObjects(t *testing.T) {
+	existing := []Object{
+		{Type: TypeTable, Name: "ORDERS"},
+		{Type: TypeSequence, Name: "SEQ_ORDERS"},
+		{Type: TypeIndex, Name: "IDX_ORDERS_CUSTOMER_ID"},
+		{Type: TypeIndex, Name: "SYS_C0012345"},
+		{Type: TypeIndex, Name: "IDX_MV_MONTHLY_SALES_MONTH"},
+		{Type: TypeTable, Name: "MV_MONTHLY_CUSTOMER_SALES"},
+		{Type: TypeMaterializedView, Name: "MV_MONTHLY_CUSTOMER_SALES"},
+		{Type: TypeFunction, Name: "NPLUS1_ORDER_SUMMARY"},
+		{Type: TypeFunction, Name: "SOMEONE_ELSES_FUNCTION"},
+		{Type: TypeTable, Name: "CUSTOMER_NOTES"},
+	}
+
+	got := selectDemoObjects(existing, []string{"NPLUS1_ORDER_SUMMARY"})
+	want := []Object{
+		{Type: TypeFunction, Name: "NPLUS1_ORDER_SUMMARY"},
+		{Type: TypeMaterializedView, Name: "MV_MONTHLY_CUSTOMER_SALES"},
+		{Type: TypeIndex, Name: "IDX_ORDERS_CUSTOMER_ID"},
+		{Type: TypeTable, Name: "ORDERS"},
+		{Type: TypeSequence, Name: "SEQ_ORDERS"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectDemoObjects() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestDropStatement(t *testing.T) {
+	tests := []struct {
+		obj     Object
+		want    string
+		wantErr bool
+	}{
+		{obj: Object{Type: TypeTable, Name: "ORDERS"}, want: `DROP TABLE "ORDERS" CASCADE CONSTRAINTS PURGE`},
+		{obj: Object{Type: TypeMaterializedView, Name: "MV_MONTHLY_CUSTOMER_SALES"}, want: `DROP MATERIALIZED VIEW "MV_MONTHLY_CUSTOMER_SALES"`},
+		{obj: Object{Type: TypeFunction, Name: "NPLUS1_ORDER_SUMMARY"}, want: `DROP FUNCTION "NPLUS1_ORDER_SUMMARY"`},
+		{obj: Object{Type: TypeTable, Name: `ORDERS" PURGE; --`}, wantErr: true},
+		{obj: Object{Type: "PACKAGE", Name: "DEMO_PKG"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.obj.DropStatement()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("DropStatement(%v) error = %v, wantErr %v", tt.obj, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DropStatement(%v) = %q, want %q", tt.obj, got, tt.want)
+		}
+	}
+}
+
+func TestPlanEmpty(t *testing.T) {
+	plan := &Plan{RedisKeys: map[string][]string{"customer_summary:*": nil}}
+	if !plan.Empty() {
+		t.Error("Empty() = false for plan without objects and keys")
+	}
+	plan.RedisKeys["customer_summary:*"] = []string{"customer_summary:1", "customer_summary:2"}
+	if plan.Empty() || plan.RedisKeyCount() != 2 {
+		t.Errorf("Empty() = %v, RedisKeyCount() = %d, want false, 2", plan.Empty(), plan.RedisKeyCount())
+	}
+}
@@ -0,0 +1,191 @@
+// Package telemetry - 匿名化した改善率の送信（-telemetry を指定した場合のみ）
+//
+// 「実環境でN+1がどれだけ遅いか」の集計に使えるよう、手法ごとの改善率と環境の区分だけを送る。
+// ホスト名・接続設定・スキーマ名・SQL・件数そのもの・実行時間そのものは含めない。
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/service"
+	"oracle-n-plus-1-demo/internal/sink"
+	"oracle-n-plus-1-demo/internal/stats"
+)
+
+// SchemaVersion - 送信する内容のスキーマバージョン
+const SchemaVersion = 1
+
+// baselineMethod - 改善率の基準にする手法
+const baselineMethod = "N+1_Problem"
+
+// sendTimeout - 送信のタイムアウト（計測の終了を長く待たせない）
+const sendTimeout = 10 * time.Second
+
+// データベースのエディションの区分
+const (
+	EditionFree       = "free"
+	EditionExpress    = "xe"
+	EditionStandard   = "se"
+	EditionEnterprise = "ee"
+	EditionUnknown    = "unknown"
+)
+
+// Report - 送信する内容
+type Report struct {
+	SchemaVersion int    `json:"schema_version"`
+	ToolVersion   string `json:"tool_version"`
+	// Edition - データベースのエディションの区分（free / xe / se / ee / unknown）
+	Edition string `json:"edition"`
+	// DatasetSize - 受注件数の区分（件数そのものは送らない）
+	DatasetSize  string        `json:"dataset_size"`
+	Improvements []Improvement `json:"improvements"`
+}
+
+// Improvement - シナリオ内の手法の改善率
+type Improvement struct {
+	Scenario string `json:"scenario"`
+	Method   string `json:"method"`
+	// Ratio - N+1の手法の平均実行時間 / 手法の平均実行時間（小数第1位に丸める）
+	Ratio float64 `json:"ratio"`
+}
+
+// ValidateEndpoint - 送信先がhttp(s)のURLか確認
+func ValidateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return errors.New("telemetry endpoint is not set")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid telemetry endpoint: %w", err)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("telemetry endpoint must be an http(s) URL: %s", endpoint)
+	}
+	return nil
+}
+
+// Edition - v$versionのバナーからエディションの区分を判定
+func Edition(banner string) string {
+	lower := strings.ToLower(banner)
+	switch {
+	case strings.Contains(lower, "express edition"):
+		return EditionExpress
+	case strings.Contains(lower, " free"):
+		return EditionFree
+	case strings.Contains(lower, "standard edition"):
+		return EditionStandard
+	case strings.Contains(lower, "enterprise edition"):
+		return EditionEnterprise
+	}
+	return EditionUnknown
+}
+
+// sizeBuckets - 受注件数の区分（上限未満なら該当）
+var sizeBuckets = []struct {
+	below int64
+	label string
+}{
+	{1_000, "<1k"},
+	{10_000, "1k-10k"},
+	{100_000, "10k-100k"},
+	{1_000_000, "100k-1M"},
+}
+
+// SizeBucket - 受注件数の区分（負の値は取得できなかったものとして unknown）
+func SizeBucket(orders int64) string {
+	if orders < 0 {
+		return "unknown"
+	}
+	for _, b := range sizeBuckets {
+		if orders < b.below {
+			return b.label
+		}
+	}
+	return ">=1M"
+}
+
+// Improvements - シナリオごとにN+1の手法を基準にした改善率を求める（基準のないシナリオは含めない）
+//
+// 繰り返し実行した場合は手法ごとの平均実行時間で比べる。
+func Improvements(results []service.PerformanceResult) []Improvement {
+	type key struct{ scenario, method string }
+	times := make(map[key][]float64)
+	var order []key
+	for _, r := range results {
+		if r.ExecutionTime <= 0 {
+			continue
+		}
+		k := key{r.Scenario, r.Method}
+		if _, ok := times[k]; !ok {
+			order = append(order, k)
+		}
+		times[k] = append(times[k], float64(r.ExecutionTime))
+	}
+
+	var improvements []Improvement
+	for _, k := range order {
+		if k.method == baselineMethod {
+			continue
+		}
+		base, ok := times[key{k.scenario, baselineMethod}]
+		if !ok {
+			continue
+		}
+		ratio := stats.Mean(base) / stats.Mean(times[k])
+		improvements = append(improvements, Improvement{
+			Scenario: k.scenario,
+			Method:   k.method,
+			Ratio:    math.Round(ratio*10) / 10,
+		})
+	}
+	sort.SliceStable(improvements, func(i, j int) bool {
+		return improvements[i].Scenario < improvements[j].Scenario
+	})
+	return improvements
+}
+
+// Build - 送信する内容を組み立てる
+func Build(toolVersion, banner string, orders int64, results []service.PerformanceResult) Report {
+	return Report{
+		SchemaVersion: SchemaVersion,
+		ToolVersion:   toolVersion,
+		Edition:       Edition(banner),
+		DatasetSize:   SizeBucket(orders),
+		Improvements:  Improvements(results),
+	}
+}
+
+// CountOrders - 受注件数（区分の判定にだけ使う。取得できない場合は-1）
+func CountOrders(db *sql.DB) int64 {
+	var count int64
+	if err := db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&count); err != nil {
+		return -1
+	}
+	return count
+}
+
+// Send - 送信先にJSONをPOST
+func Send(ctx context.Context, endpoint string, report Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telemetry: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	s := &sink.HTTPSink{URL: endpoint, Client: &http.Client{Timeout: sendTimeout}}
+	if err := s.Write(ctx, fmt.Sprintf("telemetry-v%d.json", SchemaVersion), data); err != nil {
+		return fmt.Errorf("failed to send telemetry to %s: %w", s, err)
+	}
+	return nil
+}
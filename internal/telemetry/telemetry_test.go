@@ -0,0 +1,110 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"oracle-n-plus-1-demo/internal/service"
+)
+
+func TestEdition(t *testing.T) {
+	tests := map[string]string{
+		"Oracle Database 21c Express Edition Release 21.0.0.0.0 - Production":                                  EditionExpress,
+		"Oracle Database 23ai Free Release 23.0.0.0.0 - Develop, Learn, and Run for Free":                      EditionFree,
+		"Oracle Database 19c Standard Edition 2 Release 19.0.0.0.0 - Production":                               EditionStandard,
+		"Oracle Database 19c Enterprise Edition Release 19.0.0.0.0 - Production":                               EditionEnterprise,
+		"Oracle Database 23ai Enterprise Edition Release 23.0.0.0.0 - for Oracle Cloud and Engineered Systems": EditionEnterprise,
+		"": EditionUnknown,
+	}
+	for banner, want := range tests {
+		if got := Edition(banner); got != want {
+			t.Errorf("Edition(%q) = %q, want %q", banner, got, want)
+		}
+	}
+}
+
+func TestSizeBucket(t *testing.T) {
+	tests := map[int64]string{
+		-1:        "unknown",
+		0:         "<1k",
+		999:       "<1k",
+		1_000:     "1k-10k",
+		99_999:    "10k-100k",
+		100_000:   "100k-1M",
+		1_000_000: ">=1M",
+	}
+	for orders, want := range tests {
+		if got := SizeBucket(orders); got != want {
+			t.Errorf("SizeBucket(%d) = %q, want %q", orders, got, want)
+		}
+	}
+}
+
+func TestImprovements(t *testing.T) {
+	results := []service.PerformanceResult{
+		{Scenario: "orders", Method: "N+1_Problem", ExecutionTime: 1000 * time.Millisecond},
+		{Scenario: "orders", Method: "JOIN_Optimized", ExecutionTime: 100 * time.Millisecond},
+		{Scenario: "orders", Method: "N+1_Problem", ExecutionTime: 1400 * time.Millisecond},
+		{Scenario: "orders", Method: "JOIN_Optimized", ExecutionTime: 140 * time.Millisecond},
+		{Scenario: "employees", Method: "N+1_Problem", ExecutionTime: 300 * time.Millisecond},
+		{Scenario: "employees", Method: "JOIN_Optimized", ExecutionTime: 90 * time.Millisecond},
+		// 基準のないシナリオは含めない
+		{Scenario: "monthly_sales", Method: "SQL_GroupBy", ExecutionTime: 10 * time.Millisecond},
+	}
+	want := []Improvement{
+		{Scenario: "employees", Method: "JOIN_Optimized", Ratio: 3.3},
+		{Scenario: "orders", Method: "JOIN_Optimized", Ratio: 10},
+	}
+	if got := Improvements(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("Improvements() = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{endpoint: "https://stats.example.com/v1/nplus1"},
+		{endpoint: "http://localhost:8080/"},
+		{endpoint: "", wantErr: true},
+		{endpoint: "file:/tmp", wantErr: true},
+		{endpoint: "https://", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidateEndpoint(tt.endpoint); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateEndpoint(%q) error = %v, wantErr %v", tt.endpoint, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	report := Build("v1.0.0", "Oracle Database 21c Express Edition Release", 5000, nil)
+	if err := Send(context.Background(), server.URL, report); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("sent body is not JSON: %v", err)
+	}
+	if got["edition"] != EditionExpress || got["dataset_size"] != "1k-10k" {
+		t.Errorf("sent body = %s", body)
+	}
+	if strings.Contains(string(body), "5000") {
+		t.Errorf("sent body contains the raw order count: %s", body)
+	}
+}
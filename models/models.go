@@ -46,3 +46,94 @@ type EmployeeWithDepartment struct {
 	Employee   Employee    `json:"employee"`
 	Department *Department `json:"department,omitempty"`
 }
+
+// Project - プロジェクトモデル
+type Project struct {
+	ProjectID   int64   `json:"project_id"`
+	ProjectName string  `json:"project_name"`
+	Budget      float64 `json:"budget"`
+}
+
+// ProjectAssignment - 社員に割り当てられたプロジェクト（中間テーブルの役割を含む）
+type ProjectAssignment struct {
+	Project Project `json:"project"`
+	Role    string  `json:"role"`
+}
+
+// EmployeeWithProjects - 社員と所属プロジェクト（多対多）を組み合わせたモデル
+type EmployeeWithProjects struct {
+	Employee Employee            `json:"employee"`
+	Projects []ProjectAssignment `json:"projects"`
+}
+
+// CustomerSummary - 顧客ごとの受注履歴サマリー（GET /customers/{id}/summary のレスポンス）
+type CustomerSummary struct {
+	CustomerID    int64   `json:"customer_id"`
+	CustomerName  string  `json:"customer_name"`
+	OrderCount    int     `json:"order_count"`
+	DetailCount   int     `json:"detail_count"`
+	TotalQuantity int64   `json:"total_quantity"`
+	TotalAmount   float64 `json:"total_amount"`
+	LastOrderDate string  `json:"last_order_date,omitempty"`
+}
+
+// MonthlySales - 顧客別・月別の売上集計
+type MonthlySales struct {
+	CustomerID int64   `json:"customer_id"`
+	Month      string  `json:"month"` // YYYY-MM
+	OrderCount int     `json:"order_count"`
+	Revenue    float64 `json:"revenue"`
+}
+
+// CustomerWithRecentOrders - 売上上位の顧客と直近の受注
+type CustomerWithRecentOrders struct {
+	CustomerID   int64   `json:"customer_id"`
+	CustomerName string  `json:"customer_name"`
+	Revenue      float64 `json:"revenue"`
+	RecentOrders []Order `json:"recent_orders"`
+}
+
+// OrderPage - 受注一覧の1ページと、条件に一致する受注の総件数
+type OrderPage struct {
+	Orders []Order `json:"orders"`
+	Total  int64   `json:"total"`
+	// Approximate - Totalが標本から推定した件数
+	Approximate bool `json:"approximate,omitempty"`
+}
+
+// OrderAnalytics - 分析関数（累計・順位・前後比較）の結果を付与した受注
+type OrderAnalytics struct {
+	Order        Order    `json:"order"`
+	RunningTotal float64  `json:"running_total,omitempty"`
+	AmountRank   int      `json:"amount_rank,omitempty"`
+	PrevAmount   *float64 `json:"prev_amount,omitempty"`
+	NextAmount   *float64 `json:"next_amount,omitempty"`
+}
+
+// OrderWithNotes - 受注備考（CLOB）と明細を組み合わせたモデル
+type OrderWithNotes struct {
+	Order       Order         `json:"order"`
+	Notes       *string       `json:"notes,omitempty"`
+	NotesLength int64         `json:"notes_length"`
+	Details     []OrderDetail `json:"details"`
+}
+
+// Product - 商品マスターモデル
+type Product struct {
+	ProductID   int64   `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Category    string  `json:"category"`
+	ListPrice   float64 `json:"list_price"`
+}
+
+// OrderDetailWithProduct - 明細と商品情報を組み合わせたモデル（商品マスターにない場合はProductがnil）
+type OrderDetailWithProduct struct {
+	Detail  OrderDetail `json:"detail"`
+	Product *Product    `json:"product,omitempty"`
+}
+
+// OrderWithProducts - 受注・明細・商品の3階層を組み合わせたモデル
+type OrderWithProducts struct {
+	Order   Order                    `json:"order"`
+	Details []OrderDetailWithProduct `json:"details"`
+}
@@ -0,0 +1,127 @@
+// Package cache - 独自のキャッシュ実装をキャッシュ性能分析に組み込むための公開API
+//
+// Hazelcast や Coherence などのキャッシュを Backend として実装し、init で Register すると、
+// -cache-backends で指定したときに Oracle内蔵キャッシュ・Redisと同じ条件で計測され、
+// 包括的性能分析（PerformanceAnalyzer）の結果、キャッシュ比較表、メモリ使用量・月額コストの見積もりに含まれる。
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// reservedPrefixes - 組み込みの手法が使う名前の接頭辞（独自のキャッシュには使えない）
+var reservedPrefixes = []string{"Oracle_", "Redis_"}
+
+// Backend - 計測対象のキャッシュ実装
+//
+// Fetch は計測のたびに呼ばれる。キャッシュにあればキャッシュから、なければ
+// BenchmarkQuery（LoadBenchmarkRows）でDBから取得してキャッシュに保存する、キャッシュアサイドを想定している。
+type Backend interface {
+	// Name - 結果に表示する手法名（Oracle_ / Redis_ で始まる名前は使えない）
+	Name() string
+	// Description - 手法の説明
+	Description() string
+	// Fetch - 計測対象のデータを1回取得し、キャッシュにヒットしたかを返す
+	Fetch(ctx context.Context) (hit bool, err error)
+}
+
+// Preparer - 計測前の準備（前回の計測で残ったエントリの削除など）が必要なBackend
+type Preparer interface {
+	Prepare(ctx context.Context) error
+}
+
+// MemoryReporter - キャッシュが使っているメモリ量（バイト）を報告できるBackend
+type MemoryReporter interface {
+	MemoryUsage(ctx context.Context) (int64, error)
+}
+
+// 終了時に接続や起動したプロセスの後始末が必要なBackendは io.Closer を実装する（キャッシュテストの終了時に呼ばれる）。
+
+// Factory - 計測に使うDB接続からBackendを作成する
+type Factory func(db *sql.DB) (Backend, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register - 名前を付けてBackendのFactoryを登録（-cache-backends で指定する名前）
+//
+// database/sql のドライバーと同じく init から呼び出す。名前が空、重複、factoryがnilの場合はpanicする。
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "" {
+		panic("cache: Register name is empty")
+	}
+	if factory == nil {
+		panic("cache: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("cache: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// Names - 登録済みのBackendの名前（名前順）
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ErrUnknownBackend - 登録されていないBackend
+var ErrUnknownBackend = errors.New("unknown cache backend")
+
+// Open - 登録名を指定してBackendを作成
+func Open(name string, db *sql.DB) (Backend, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (registered: %s)", ErrUnknownBackend, name, strings.Join(Names(), ", "))
+	}
+
+	backend, err := factory(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache backend %s: %w", name, err)
+	}
+	for _, prefix := range reservedPrefixes {
+		if strings.HasPrefix(backend.Name(), prefix) {
+			return nil, fmt.Errorf("cache backend %s: name %q must not start with %s", name, backend.Name(), prefix)
+		}
+	}
+	return backend, nil
+}
+
+// ParseNames - カンマ区切りのBackend名を確認して分割（空文字列の場合はnil）
+func ParseNames(value string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		registryMu.RLock()
+		_, ok := registry[name]
+		registryMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("%w: %q (registered: %s)", ErrUnknownBackend, name, strings.Join(Names(), ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
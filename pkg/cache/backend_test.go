@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// stubBackend - 何もしないBackend
+type stubBackend struct{ name string }
+
+func (b stubBackend) Name() string                        { return b.name }
+func (b stubBackend) Description() string                 { return "stub" }
+func (b stubBackend) Fetch(context.Context) (bool, error) { return true, nil }
+
+func TestRegisterAndOpen(t *testing.T) {
+	Register("test-stub", func(*sql.DB) (Backend, error) { return stubBackend{name: "Stub_Cache"}, nil })
+	Register("test-reserved", func(*sql.DB) (Backend, error) { return stubBackend{name: "Redis_Stub"}, nil })
+
+	b, err := Open("test-stub", nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if b.Name() != "Stub_Cache" {
+		t.Errorf("Name() = %q, want Stub_Cache", b.Name())
+	}
+
+	if _, err := Open("test-reserved", nil); err == nil {
+		t.Error("Open() with reserved name prefix error = nil, want error")
+	}
+	if _, err := Open("missing", nil); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("Open() error = %v, want ErrUnknownBackend", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() twice did not panic")
+		}
+	}()
+	Register("test-stub", func(*sql.DB) (Backend, error) { return stubBackend{}, nil })
+}
+
+func TestParseNames(t *testing.T) {
+	Register("test-parse", func(*sql.DB) (Backend, error) { return stubBackend{name: "Parse"}, nil })
+
+	names, err := ParseNames(" test-parse , ")
+	if err != nil {
+		t.Fatalf("ParseNames() error = %v", err)
+	}
+	if len(names) != 1 || names[0] != "test-parse" {
+		t.Errorf("ParseNames() = %v, want [test-parse]", names)
+	}
+
+	if names, err := ParseNames(""); err != nil || names != nil {
+		t.Errorf("ParseNames(\"\") = %v, %v, want nil, nil", names, err)
+	}
+	if _, err := ParseNames("test-parse,unknown"); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("ParseNames() error = %v, want ErrUnknownBackend", err)
+	}
+}
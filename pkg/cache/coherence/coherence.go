@@ -0,0 +1,174 @@
+// Package coherence - Oracle Coherence のキャッシュを Coherence REST 経由で使うBackend
+//
+// -cache-backends=coherence で計測に加わる。COHERENCE_URL（例: http://localhost:8080/api）に
+// Coherence REST のベースURLを指定する。未設定の場合は計測をスキップする。
+package coherence
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"oracle-n-plus-1-demo/pkg/cache"
+)
+
+const (
+	// BackendName - 登録名
+	BackendName = "coherence"
+	// DefaultCacheName - COHERENCE_CACHE を省略した場合のキャッシュ名
+	DefaultCacheName = "orders"
+	// cacheKey - 計測対象のデータを保存するキー（Redis外部キャッシュと同じ）
+	cacheKey = "orders_with_details_last_7_days"
+	// requestTimeout - 1回のRESTリクエストのタイムアウト
+	requestTimeout = 10 * time.Second
+)
+
+// ErrNotConfigured - COHERENCE_URL が設定されていない
+var ErrNotConfigured = errors.New("COHERENCE_URL is not set")
+
+func init() {
+	cache.Register(BackendName, New)
+}
+
+// Backend - Coherence REST（/{cache}/{key}）を使うキャッシュアサイド
+type Backend struct {
+	db       *sql.DB
+	client   *http.Client
+	entryURL string
+}
+
+// New - 環境変数（COHERENCE_URL・COHERENCE_CACHE）からBackendを作成
+func New(db *sql.DB) (cache.Backend, error) {
+	baseURL := os.Getenv("COHERENCE_URL")
+	if baseURL == "" {
+		return nil, ErrNotConfigured
+	}
+	cacheName := os.Getenv("COHERENCE_CACHE")
+	if cacheName == "" {
+		cacheName = DefaultCacheName
+	}
+	return NewWithClient(db, &http.Client{Timeout: requestTimeout}, baseURL, cacheName)
+}
+
+// NewWithClient - HTTPクライアントとURLを指定してBackendを作成
+func NewWithClient(db *sql.DB, client *http.Client, baseURL, cacheName string) (*Backend, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid COHERENCE_URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid COHERENCE_URL %q: must be an http(s) URL such as http://localhost:8080/api", baseURL)
+	}
+	entryURL := strings.TrimRight(baseURL, "/") + "/" + url.PathEscape(cacheName) + "/" + url.PathEscape(cacheKey)
+	return &Backend{db: db, client: client, entryURL: entryURL}, nil
+}
+
+// Name - 手法名
+func (b *Backend) Name() string { return "Coherence_Cache" }
+
+// Description - 手法の説明
+func (b *Backend) Description() string {
+	return "Oracle Coherence（中間層の分散キャッシュ、REST経由・JSONシリアライゼーション）"
+}
+
+// Prepare - 前回の計測で残ったエントリを削除
+func (b *Backend) Prepare(ctx context.Context) error {
+	resp, err := b.do(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	// 存在しないキーの削除は404でも構わない
+	if resp.statusCode != http.StatusNotFound {
+		return checkStatus(resp)
+	}
+	return nil
+}
+
+// Fetch - Coherenceになければ DB から取得して保存
+func (b *Backend) Fetch(ctx context.Context) (bool, error) {
+	resp, err := b.do(ctx, http.MethodGet, nil)
+	if err != nil {
+		return false, err
+	}
+	switch resp.statusCode {
+	case http.StatusOK:
+		var rows []cache.OrderDetailRow
+		if err := json.Unmarshal(resp.body, &rows); err != nil {
+			return false, fmt.Errorf("failed to decode cached rows: %w", err)
+		}
+		return true, nil
+	case http.StatusNotFound, http.StatusNoContent:
+		// Coherence REST はエントリがない場合に404（設定によっては204）を返す
+	default:
+		return false, checkStatus(resp)
+	}
+
+	rows, err := cache.LoadBenchmarkRows(ctx, b.db)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode rows: %w", err)
+	}
+	resp, err = b.do(ctx, http.MethodPut, data)
+	if err != nil {
+		return false, err
+	}
+	return false, checkStatus(resp)
+}
+
+// response - 読み終えたレスポンス
+type response struct {
+	statusCode int
+	body       []byte
+}
+
+// do - エントリのURLにリクエストを送り、本文を読み終えてから返す
+func (b *Backend) do(ctx context.Context, method string, body []byte) (*response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.entryURL, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coherence request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s coherence entry: %w", method, err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			fmt.Printf("coherence response Close() failed: %v\n", cerr)
+		}
+	}()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coherence response: %w", err)
+	}
+	return &response{statusCode: resp.StatusCode, body: data}, nil
+}
+
+// checkStatus - 2xx以外のステータスをエラーにする
+func checkStatus(resp *response) error {
+	if resp.statusCode >= 200 && resp.statusCode < 300 {
+		return nil
+	}
+	return fmt.Errorf("coherence returned %d: %s", resp.statusCode, strings.TrimSpace(string(resp.body)))
+}
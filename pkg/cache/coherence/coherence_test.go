@@ -0,0 +1,63 @@
+package coherence
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBackendAgainstRESTServer(t *testing.T) {
+	var requests []string
+	status := http.StatusNotFound
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`[{"order_id": 1, "quantity": 2}]`))
+		}
+	}))
+	defer srv.Close()
+
+	b, err := NewWithClient(nil, srv.Client(), srv.URL+"/api/", "orders")
+	if err != nil {
+		t.Fatalf("NewWithClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// 存在しないエントリの削除は成功扱い
+	if err := b.Prepare(ctx); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	status = http.StatusOK
+	hit, err := b.Fetch(ctx)
+	if err != nil || !hit {
+		t.Fatalf("Fetch() = %v, %v, want hit", hit, err)
+	}
+
+	status = http.StatusInternalServerError
+	if _, err := b.Fetch(ctx); err == nil {
+		t.Error("Fetch() error = nil on 500, want error")
+	}
+
+	want := "/api/orders/orders_with_details_last_7_days"
+	for _, got := range []string{requests[0], requests[1]} {
+		if got != "DELETE "+want && got != "GET "+want {
+			t.Errorf("request = %q, want path %s", got, want)
+		}
+	}
+}
+
+func TestNewRequiresURL(t *testing.T) {
+	t.Setenv("COHERENCE_URL", "")
+	if _, err := New(nil); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("New() error = %v, want ErrNotConfigured", err)
+	}
+
+	t.Setenv("COHERENCE_URL", "localhost:8080")
+	if _, err := New(nil); err == nil {
+		t.Error("New() with URL without scheme error = nil, want error")
+	}
+}
@@ -0,0 +1,323 @@
+// Package groupcache - 複数のデモのプロセスでキーを分担するプロセス内キャッシュ（groupcache）のBackend
+//
+// -cache-backends=groupcache で計測に加わる。計測するプロセスが -groupcache-workers で指定した数の
+// ピアプロセス（groupcache-peer コマンド）を起動し、自身を含めたピアの間でコンシステント・ハッシュによりキーの担当を決める。
+// 担当のピアだけがDBから取得してキャッシュし、ほかのピアはHTTPで担当のピアから取得する（分散インプロセスキャッシュ）。
+package groupcache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	gc "github.com/golang/groupcache"
+
+	"oracle-n-plus-1-demo/pkg/cache"
+)
+
+const (
+	// BackendName - 登録名
+	BackendName = "groupcache"
+	// PeerCommand - ピアプロセスとして起動するサブコマンド
+	PeerCommand = "groupcache-peer"
+	// DefaultWorkers - 既定で起動するピアプロセスの数（計測するプロセスを含めたピアは1つ多い）
+	DefaultWorkers = 2
+	// groupName - ピアの間で共有するグループ名
+	groupName = "orders"
+	// cacheKey - 計測対象のデータを保存するキー（Redis外部キャッシュと同じ。世代を付けて使う）
+	cacheKey = "orders_with_details_last_7_days"
+	// cacheBytes - ピアごとのキャッシュの上限
+	cacheBytes = 64 << 20
+	// startTimeout - ピアプロセスが接続を受け付けるまで待つ時間
+	startTimeout = 15 * time.Second
+	// stopTimeout - 標準入力を閉じてからピアプロセスの終了を待つ時間（過ぎたら強制終了する）
+	stopTimeout = 5 * time.Second
+)
+
+// loader - キャッシュミス時にDBから取得する関数
+type loader func(ctx context.Context) ([]cache.OrderDetailRow, error)
+
+var (
+	mu      sync.Mutex
+	workers = DefaultWorkers
+	// poolMade - groupcacheのピアの一覧（HTTPPool）とグループはプロセスに1つしか作れない
+	poolMade bool
+)
+
+func init() {
+	cache.Register(BackendName, New)
+}
+
+// SetWorkers - Backendを作成するときに起動するピアプロセスの数を設定（0: 計測するプロセスだけ）
+func SetWorkers(n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	workers = n
+}
+
+// Backend - groupcacheのグループから取得するキャッシュアサイド
+//
+// groupcacheはエントリを削除・更新できないため、Prepareではキーの世代を変え、世代ごとの初回の取得をミスとして数える。
+type Backend struct {
+	group   *gc.Group
+	server  *http.Server
+	peers   []*peerProcess
+	workers int
+
+	mu         sync.Mutex
+	generation int
+	fetched    bool
+}
+
+// peerProcess - 起動したピアプロセス（標準入力を閉じると終了する）
+type peerProcess struct {
+	cmd   *exec.Cmd
+	stdin interface{ Close() error }
+	url   string
+	done  chan error
+}
+
+// New - ピアプロセスを起動してBackendを作成
+func New(db *sql.DB) (cache.Backend, error) {
+	mu.Lock()
+	n := workers
+	mu.Unlock()
+	if n < 0 {
+		return nil, fmt.Errorf("groupcache workers must not be negative: %d", n)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable for groupcache peers: %w", err)
+	}
+	workerURLs := make([]string, n)
+	for i := range workerURLs {
+		if workerURLs[i], err = reservePeerURL(); err != nil {
+			return nil, err
+		}
+	}
+	load := func(ctx context.Context) ([]cache.OrderDetailRow, error) { return cache.LoadBenchmarkRows(ctx, db) }
+	return start(load, workerURLs, func(self string, peers []string) *exec.Cmd {
+		return exec.Command(executable, PeerCommand, "-self", self, "-peers", strings.Join(peers, ","))
+	})
+}
+
+// start - 計測するプロセスのピアを起動し、workerURLsのピアプロセスをspawnで起動して接続を待つ
+func start(load loader, workerURLs []string, spawn func(self string, peers []string) *exec.Cmd) (*Backend, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for groupcache peer: %w", err)
+	}
+	self := "http://" + listener.Addr().String()
+	peers := append([]string{self}, workerURLs...)
+
+	group, pool, err := newPeer(load, self, peers)
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	b := &Backend{group: group, server: &http.Server{Handler: pool, ReadHeaderTimeout: startTimeout}, workers: len(workerURLs)}
+	go func() {
+		if err := b.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("groupcache peer server failed: %v\n", err)
+		}
+	}()
+
+	for _, url := range workerURLs {
+		peer, err := startPeerProcess(spawn(url, peers), url)
+		if err != nil {
+			_ = b.Close()
+			return nil, err
+		}
+		b.peers = append(b.peers, peer)
+	}
+	for _, peer := range b.peers {
+		if err := peer.waitReady(); err != nil {
+			_ = b.Close()
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// newPeer - このプロセスのピア（ピアの一覧とDBから取得するグループ）を作る
+func newPeer(load loader, self string, peers []string) (*gc.Group, *gc.HTTPPool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if poolMade {
+		return nil, nil, errors.New("groupcache peer can be created only once per process")
+	}
+	poolMade = true
+
+	pool := gc.NewHTTPPoolOpts(self, nil)
+	pool.Set(peers...)
+	group := gc.NewGroup(groupName, cacheBytes, gc.GetterFunc(func(ctx context.Context, _ string, dest gc.Sink) error {
+		rows, err := load(ctx)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return fmt.Errorf("failed to encode rows: %w", err)
+		}
+		return dest.SetBytes(data)
+	}))
+	return group, pool, nil
+}
+
+// ServePeer - ピアプロセスとしてselfで待ち受け、ctxが取り消されるまでほかのピアからの取得に応える
+func ServePeer(ctx context.Context, db *sql.DB, self string, peers []string) error {
+	address := strings.TrimPrefix(self, "http://")
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	load := func(ctx context.Context) ([]cache.OrderDetailRow, error) { return cache.LoadBenchmarkRows(ctx, db) }
+	_, pool, err := newPeer(load, self, peers)
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	server := &http.Server{Handler: pool, ReadHeaderTimeout: startTimeout}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("groupcache peer server failed: %w", err)
+	}
+	return nil
+}
+
+// reservePeerURL - ピアプロセスに割り当てる空いているポートのURL
+func reservePeerURL() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to reserve port for groupcache peer: %w", err)
+	}
+	url := "http://" + listener.Addr().String()
+	if err := listener.Close(); err != nil {
+		return "", fmt.Errorf("failed to release reserved port: %w", err)
+	}
+	return url, nil
+}
+
+// startPeerProcess - ピアプロセスを起動する（標準入力はこのプロセスが終了すると閉じ、ピアも終了する）
+func startPeerProcess(cmd *exec.Cmd, url string) (*peerProcess, error) {
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin for groupcache peer: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start groupcache peer %s: %w", url, err)
+	}
+	peer := &peerProcess{cmd: cmd, stdin: stdin, url: url, done: make(chan error, 1)}
+	go func() { peer.done <- cmd.Wait() }()
+	return peer, nil
+}
+
+// waitReady - ピアプロセスが接続を受け付けるまで待つ
+func (p *peerProcess) waitReady() error {
+	address := strings.TrimPrefix(p.url, "http://")
+	deadline := time.Now().Add(startTimeout)
+	for {
+		select {
+		case err := <-p.done:
+			p.done <- err
+			return fmt.Errorf("groupcache peer %s exited before accepting connections: %v", p.url, err)
+		default:
+		}
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err == nil {
+			return conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("groupcache peer %s did not start within %v: %w", p.url, startTimeout, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// stop - 標準入力を閉じて終了を待ち、終わらなければ強制終了する
+func (p *peerProcess) stop() {
+	if err := p.stdin.Close(); err != nil {
+		fmt.Printf("failed to close groupcache peer stdin: %v\n", err)
+	}
+	select {
+	case <-p.done:
+	case <-time.After(stopTimeout):
+		if err := p.cmd.Process.Kill(); err != nil {
+			fmt.Printf("failed to kill groupcache peer %s: %v\n", p.url, err)
+		}
+		<-p.done
+	}
+}
+
+// Name - 手法名
+func (b *Backend) Name() string { return "GroupCache_Peers" }
+
+// Description - 手法の説明
+func (b *Backend) Description() string {
+	return fmt.Sprintf("groupcache（%dプロセスのピアでキーを分担するプロセス内キャッシュ、担当外のキーはHTTPで取得・JSONシリアライゼーション）", b.workers+1)
+}
+
+// Prepare - キーの世代を変え、前回の計測で残ったエントリを使わないようにする
+func (b *Backend) Prepare(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.generation++
+	b.fetched = false
+	return nil
+}
+
+// Fetch - 担当のピア（またはこのプロセスのキャッシュ）から取得し、どのピアにもなければ担当のピアがDBから取得する
+func (b *Backend) Fetch(ctx context.Context) (bool, error) {
+	b.mu.Lock()
+	key := fmt.Sprintf("%s:%d", cacheKey, b.generation)
+	hit := b.fetched
+	b.mu.Unlock()
+
+	var data []byte
+	if err := b.group.Get(ctx, key, gc.AllocatingByteSliceSink(&data)); err != nil {
+		return false, fmt.Errorf("failed to get %s from groupcache: %w", key, err)
+	}
+	var rows []cache.OrderDetailRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return false, fmt.Errorf("failed to decode cached rows: %w", err)
+	}
+
+	b.mu.Lock()
+	b.fetched = true
+	b.mu.Unlock()
+	return hit, nil
+}
+
+// MemoryUsage - このプロセスが保持しているエントリの大きさ（担当分と、よく使う担当外のキーの複製）
+//
+// ほかのピアプロセスが保持している分は含まない。
+func (b *Backend) MemoryUsage(context.Context) (int64, error) {
+	return b.group.CacheStats(gc.MainCache).Bytes + b.group.CacheStats(gc.HotCache).Bytes, nil
+}
+
+// Close - ピアプロセスを終了し、このプロセスのピアの待ち受けを止める
+func (b *Backend) Close() error {
+	for _, peer := range b.peers {
+		peer.stop()
+	}
+	if err := b.server.Close(); err != nil {
+		return fmt.Errorf("failed to close groupcache peer server: %w", err)
+	}
+	return nil
+}
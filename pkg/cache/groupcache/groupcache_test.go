@@ -0,0 +1,54 @@
+package groupcache
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"oracle-n-plus-1-demo/pkg/cache"
+)
+
+func TestBackendSinglePeer(t *testing.T) {
+	loads := 0
+	load := func(context.Context) ([]cache.OrderDetailRow, error) {
+		loads++
+		return []cache.OrderDetailRow{{OrderID: 1, Quantity: 2}}, nil
+	}
+	b, err := start(load, nil, func(string, []string) *exec.Cmd { return nil })
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	defer func() {
+		if err := b.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}()
+	ctx := context.Background()
+
+	for round := 1; round <= 2; round++ {
+		if err := b.Prepare(ctx); err != nil {
+			t.Fatalf("round %d: Prepare() error = %v", round, err)
+		}
+		for i, want := range []bool{false, true, true} {
+			hit, err := b.Fetch(ctx)
+			if err != nil {
+				t.Fatalf("round %d: Fetch() error = %v", round, err)
+			}
+			if hit != want {
+				t.Errorf("round %d: fetch %d hit = %v, want %v", round, i, hit, want)
+			}
+		}
+		// Prepareで世代が変わるため、世代ごとに1回だけDBから取得する
+		if loads != round {
+			t.Errorf("round %d: loads = %d, want %d", round, loads, round)
+		}
+	}
+
+	usage, err := b.MemoryUsage(ctx)
+	if err != nil || usage <= 0 {
+		t.Errorf("MemoryUsage() = %d, %v, want positive", usage, err)
+	}
+	if _, _, err := newPeer(load, "http://127.0.0.1:0", nil); err == nil {
+		t.Error("newPeer() twice error = nil, want error")
+	}
+}
@@ -0,0 +1,86 @@
+// Package memory - アプリケーションのプロセス内（Goのメモリ）に置くキャッシュのBackend
+//
+// 独自のBackendを実装する際の参考実装。-cache-backends=memory で計測に加わる。
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"oracle-n-plus-1-demo/pkg/cache"
+)
+
+// BackendName - 登録名
+const BackendName = "memory"
+
+func init() {
+	cache.Register(BackendName, New)
+}
+
+// Backend - プロセス内のキャッシュ（Redisと同じくJSONにシリアライズして保持する）
+type Backend struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	entry []byte
+}
+
+// New - プロセス内キャッシュのBackendを作成
+func New(db *sql.DB) (cache.Backend, error) {
+	return &Backend{db: db}, nil
+}
+
+// Name - 手法名
+func (b *Backend) Name() string { return "InProcess_Cache" }
+
+// Description - 手法の説明
+func (b *Backend) Description() string {
+	return "アプリケーションのプロセス内キャッシュ（JSONシリアライゼーション、ネットワーク通信なし）"
+}
+
+// Prepare - 前回の計測で残ったエントリを削除
+func (b *Backend) Prepare(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entry = nil
+	return nil
+}
+
+// Fetch - キャッシュになければDBから取得して保存
+func (b *Backend) Fetch(ctx context.Context) (bool, error) {
+	b.mu.Lock()
+	entry := b.entry
+	b.mu.Unlock()
+
+	if entry != nil {
+		var rows []cache.OrderDetailRow
+		if err := json.Unmarshal(entry, &rows); err != nil {
+			return false, fmt.Errorf("failed to decode cached rows: %w", err)
+		}
+		return true, nil
+	}
+
+	rows, err := cache.LoadBenchmarkRows(ctx, b.db)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode rows: %w", err)
+	}
+
+	b.mu.Lock()
+	b.entry = data
+	b.mu.Unlock()
+	return false, nil
+}
+
+// MemoryUsage - 保持しているエントリの大きさ
+func (b *Backend) MemoryUsage(context.Context) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int64(len(b.entry)), nil
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// BenchmarkQuery - キャッシュの計測対象のクエリ（直近7日間の受注と明細、Redis外部キャッシュと同じ）
+const BenchmarkQuery = `
+	SELECT o.order_id, o.customer_id, o.total_amount,
+	       od.detail_id, od.product_id, od.quantity
+	FROM orders o
+	JOIN order_details od ON o.order_id = od.order_id
+	WHERE o.order_date >= SYSDATE - 7
+	AND ROWNUM <= 100`
+
+// OrderDetailRow - BenchmarkQueryの1行
+type OrderDetailRow struct {
+	OrderID     int64   `json:"order_id"`
+	CustomerID  int64   `json:"customer_id"`
+	TotalAmount float64 `json:"total_amount"`
+	DetailID    int64   `json:"detail_id"`
+	ProductID   int64   `json:"product_id"`
+	Quantity    int     `json:"quantity"`
+}
+
+// LoadBenchmarkRows - BenchmarkQueryをDBで実行する（キャッシュミス時の取得に使う）
+func LoadBenchmarkRows(ctx context.Context, db *sql.DB) (rows []OrderDetailRow, err error) {
+	result, err := db.QueryContext(ctx, BenchmarkQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query benchmark rows: %w", err)
+	}
+	defer func() {
+		if cerr := result.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	for result.Next() {
+		var r OrderDetailRow
+		if err := result.Scan(&r.OrderID, &r.CustomerID, &r.TotalAmount, &r.DetailID, &r.ProductID, &r.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan benchmark row: %w", err)
+		}
+		rows = append(rows, r)
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate benchmark rows: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,105 @@
+// Package timesten - Oracle TimesTen In-Memory Cache のキャッシュグループから読み取るBackend
+//
+// -cache-backends=timesten で計測に加わる。TimesTen はOracleのワイヤープロトコル（TNS）を話さないため
+// go-ora では接続できない。TimesTen に接続できる database/sql ドライバー（ODBCドライバーなど）を
+// cmd/backends.go に blank import し、TIMESTEN_DRIVER にドライバー名、TIMESTEN_DSN に接続文字列を指定する。
+// TIMESTEN_DSN が未設定の場合は計測をスキップする。
+package timesten
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"oracle-n-plus-1-demo/pkg/cache"
+)
+
+const (
+	// BackendName - 登録名
+	BackendName = "timesten"
+	// DefaultDriver - TIMESTEN_DRIVER を省略した場合の database/sql ドライバー名
+	DefaultDriver = "odbc"
+	// kilobyte - SYS.MONITOR のサイズの単位
+	kilobyte = 1024
+)
+
+// ErrNotConfigured - TIMESTEN_DSN が設定されていない
+var ErrNotConfigured = errors.New("TIMESTEN_DSN is not set")
+
+func init() {
+	cache.Register(BackendName, New)
+}
+
+// Backend - TimesTen のキャッシュグループ（ORDERS・ORDER_DETAILS をキャッシュしたもの）への読み取り
+//
+// キャッシュグループのデータは TimesTen がOracleから読み込む（事前ロード、または動的キャッシュグループでは初回参照時）。
+// アプリからはミスを区別できないため、取得はすべてヒットとして記録する（動的ロードの時間は1回目の実行時間に表れる）。
+type Backend struct {
+	tt *sql.DB
+}
+
+// New - 環境変数（TIMESTEN_DRIVER・TIMESTEN_DSN）で TimesTen に接続してBackendを作成
+//
+// キャッシュミス時のOracleからの読み込みは TimesTen が行うため、計測に使うOracleの接続は使わない。
+func New(*sql.DB) (cache.Backend, error) {
+	dsn := os.Getenv("TIMESTEN_DSN")
+	if dsn == "" {
+		return nil, ErrNotConfigured
+	}
+	driver := os.Getenv("TIMESTEN_DRIVER")
+	if driver == "" {
+		driver = DefaultDriver
+	}
+
+	tt, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open timesten (driver %q must be imported in cmd/backends.go): %w", driver, err)
+	}
+	tt.SetMaxOpenConns(1)
+	return NewWithDB(tt), nil
+}
+
+// NewWithDB - 接続済みの TimesTen を指定してBackendを作成（ttはCloseで閉じる）
+func NewWithDB(tt *sql.DB) *Backend {
+	return &Backend{tt: tt}
+}
+
+// Name - 手法名
+func (b *Backend) Name() string { return "TimesTen_Cache" }
+
+// Description - 手法の説明
+func (b *Backend) Description() string {
+	return "Oracle TimesTen In-Memory Cache（キャッシュグループへのSQL、シリアライゼーションなし）"
+}
+
+// Prepare - TimesTen に接続できることを確認
+func (b *Backend) Prepare(ctx context.Context) error {
+	if err := b.tt.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to connect timesten: %w", err)
+	}
+	return nil
+}
+
+// Fetch - キャッシュグループに BenchmarkQuery を実行（TimesTen は SYSDATE・ROWNUM をサポートする）
+func (b *Backend) Fetch(ctx context.Context) (bool, error) {
+	if _, err := cache.LoadBenchmarkRows(ctx, b.tt); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MemoryUsage - TimesTen データベースの永続領域の使用量（SYS.MONITOR.PERM_IN_USE_SIZE、キャッシュグループ以外の表も含む）
+func (b *Backend) MemoryUsage(ctx context.Context) (int64, error) {
+	var inUseKB int64
+	if err := b.tt.QueryRowContext(ctx, "SELECT perm_in_use_size FROM sys.monitor").Scan(&inUseKB); err != nil {
+		return 0, fmt.Errorf("failed to query timesten memory usage: %w", err)
+	}
+	return inUseKB * kilobyte, nil
+}
+
+// Close - New で開いた TimesTen への接続を閉じる
+func (b *Backend) Close() error {
+	return b.tt.Close()
+}
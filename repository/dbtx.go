@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX - リポジトリが利用するDB操作（*sql.DB・*sql.Tx・ConnDBが満たす）
+type DBTX interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+// ConnDB - 単一の *sql.Conn をDBTXとして扱うアダプター
+//
+// 接続プールを経由せず常に同じセッションで実行されるため、
+// V$MYSTAT などセッション単位の統計で手法ごとの負荷を計測できる。
+type ConnDB struct {
+	conn *sql.Conn
+	ctx  context.Context
+}
+
+// NewConnDB - ConnDBのコンストラクタ
+func NewConnDB(conn *sql.Conn) *ConnDB {
+	return &ConnDB{conn: conn, ctx: context.Background()}
+}
+
+// NewConnDBContext - ctxが取り消されると実行中のSQLを中断するConnDBのコンストラクタ
+func NewConnDBContext(ctx context.Context, conn *sql.Conn) *ConnDB {
+	return &ConnDB{conn: conn, ctx: ctx}
+}
+
+// Query - 単一接続でクエリを実行
+func (c *ConnDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.conn.QueryContext(c.ctx, query, args...)
+}
+
+// QueryRow - 単一接続で1行を返すクエリを実行
+func (c *ConnDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.conn.QueryRowContext(c.ctx, query, args...)
+}
+
+// Exec - 単一接続でSQLを実行
+func (c *ConnDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.conn.ExecContext(c.ctx, query, args...)
+}
+
+// Prepare - 単一接続に紐づくプリペアドステートメントを作成
+func (c *ConnDB) Prepare(query string) (*sql.Stmt, error) {
+	return c.conn.PrepareContext(c.ctx, query)
+}
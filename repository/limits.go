@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Limits - 1回の実行で扱う受注・社員の件数の上限（0は上限なし）
+//
+// 上限はすべての手法で同じ条件（同じ副問合せ）としてSQLに加えるため、
+// 上限を指定しても手法間の比較は同じデータに対するものになる。
+type Limits struct {
+	// MaxOrders - 過去N日間の受注のうち、新しい順（受注日・受注IDの降順）に扱う件数
+	MaxOrders int `json:"max_orders,omitempty"`
+	// MaxEmployees - 社員ID順に扱う件数
+	MaxEmployees int `json:"max_employees,omitempty"`
+}
+
+// Validate - 上限が0以上か確認
+func (l Limits) Validate() error {
+	if l.MaxOrders < 0 {
+		return fmt.Errorf("max orders must not be negative: %d", l.MaxOrders)
+	}
+	if l.MaxEmployees < 0 {
+		return fmt.Errorf("max employees must not be negative: %d", l.MaxEmployees)
+	}
+	return nil
+}
+
+// orderWindow - 過去days日間の受注を表す条件とバインド引数（bindは条件内の最初のバインド番号）
+//
+// 上限がない場合は従来と同じ条件になる。上限がある場合は、対象の受注IDを新しい順に
+// MaxOrders件に絞る副問合せにする（JOINの手法でも明細ではなく受注の件数で絞られる）。
+func (l Limits) orderWindow(alias string, days, bind int) (string, []interface{}) {
+	return l.orderWindowAsOf(alias, days, bind, 0)
+}
+
+// orderWindowAsOf - orderWindowの副問合せの受注をscn時点（AS OF SCN）で読む条件（scnが0ならorderWindowと同じ）
+//
+// 外側の表と副問合せの時点がずれると、上限で絞る受注が外側で読む受注と食い違うため、同じSCNを指定する。
+func (l Limits) orderWindowAsOf(alias string, days, bind int, scn uint64) (string, []interface{}) {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	if l.MaxOrders <= 0 {
+		return prefix + "order_date >= SYSDATE - :" + strconv.Itoa(bind), []interface{}{days}
+	}
+	if scn != 0 {
+		return fmt.Sprintf(`%sorder_id IN (
+			SELECT order_id FROM orders AS OF SCN :%d
+			WHERE order_date >= SYSDATE - :%d
+			ORDER BY order_date DESC, order_id DESC
+			FETCH FIRST :%d ROWS ONLY)`, prefix, bind, bind+1, bind+2), []interface{}{scn, days, l.MaxOrders}
+	}
+	return fmt.Sprintf(`%sorder_id IN (
+			SELECT order_id FROM orders
+			WHERE order_date >= SYSDATE - :%d
+			ORDER BY order_date DESC, order_id DESC
+			FETCH FIRST :%d ROWS ONLY)`, prefix, bind, bind+1), []interface{}{days, l.MaxOrders}
+}
+
+// employeeFilter - 社員数の上限のWHERE句とバインド引数（上限がない場合は空）
+func (l Limits) employeeFilter(alias string, bind int) (string, []interface{}) {
+	if l.MaxEmployees <= 0 {
+		return "", nil
+	}
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	return fmt.Sprintf(`WHERE %semployee_id IN (
+			SELECT employee_id FROM employees
+			ORDER BY employee_id
+			FETCH FIRST :%d ROWS ONLY)`, prefix, bind), []interface{}{l.MaxEmployees}
+}
+
+// SetLimits - 扱う受注の件数の上限を設定
+func (r *ProblemOrderRepository) SetLimits(l Limits) {
+	r.limits = l
+}
+
+// SetLimits - 扱う受注の件数の上限を設定
+func (r *OptimizedOrderRepository) SetLimits(l Limits) {
+	r.limits = l
+}
+
+// SetLimits - 扱う社員の件数の上限を設定
+func (r *ProblemEmployeeRepository) SetLimits(l Limits) {
+	r.limits = l
+}
+
+// SetLimits - 扱う社員の件数の上限を設定
+func (r *OptimizedEmployeeRepository) SetLimits(l Limits) {
+	r.limits = l
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrderWindow(t *testing.T) {
+	where, args := Limits{}.orderWindow("o", 30, 1)
+	if where != "o.order_date >= SYSDATE - :1" {
+		t.Errorf("orderWindow() without limit = %q", where)
+	}
+	if !reflect.DeepEqual(args, []interface{}{30}) {
+		t.Errorf("orderWindow() args = %v", args)
+	}
+
+	where, args = Limits{MaxOrders: 500}.orderWindow("", 7, 2)
+	for _, want := range []string{"order_id IN (", "SYSDATE - :2", "ORDER BY order_date DESC, order_id DESC", "FETCH FIRST :3 ROWS ONLY"} {
+		if !strings.Contains(where, want) {
+			t.Errorf("orderWindow() with limit = %q, want it to contain %q", where, want)
+		}
+	}
+	if strings.HasPrefix(where, ".") {
+		t.Errorf("orderWindow() without alias = %q", where)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7, 500}) {
+		t.Errorf("orderWindow() args = %v", args)
+	}
+}
+
+func TestOrderWindowAsOf(t *testing.T) {
+	if where, args := (Limits{}).orderWindowAsOf("", 30, 2, 99); where != "order_date >= SYSDATE - :2" || !reflect.DeepEqual(args, []interface{}{30}) {
+		t.Errorf("orderWindowAsOf() without limit = %q, %v", where, args)
+	}
+
+	where, args := Limits{MaxOrders: 500}.orderWindowAsOf("", 7, 2, 99)
+	for _, want := range []string{"FROM orders AS OF SCN :2", "SYSDATE - :3", "FETCH FIRST :4 ROWS ONLY"} {
+		if !strings.Contains(where, want) {
+			t.Errorf("orderWindowAsOf() with limit = %q, want it to contain %q", where, want)
+		}
+	}
+	if !reflect.DeepEqual(args, []interface{}{uint64(99), 7, 500}) {
+		t.Errorf("orderWindowAsOf() args = %v", args)
+	}
+
+	// SCNが0なら現在のデータを読む（orderWindowと同じ）
+	current, _ := Limits{MaxOrders: 500}.orderWindowAsOf("", 7, 2, 0)
+	if plain, _ := (Limits{MaxOrders: 500}).orderWindow("", 7, 2); current != plain {
+		t.Errorf("orderWindowAsOf() with scn 0 = %q, want %q", current, plain)
+	}
+}
+
+func TestEmployeeFilter(t *testing.T) {
+	if filter, args := (Limits{}).employeeFilter("e", 1); filter != "" || args != nil {
+		t.Errorf("employeeFilter() without limit = %q, %v", filter, args)
+	}
+
+	filter, args := Limits{MaxEmployees: 200}.employeeFilter("e", 1)
+	if !strings.HasPrefix(filter, "WHERE e.employee_id IN (") || !strings.Contains(filter, "FETCH FIRST :1 ROWS ONLY") {
+		t.Errorf("employeeFilter() = %q", filter)
+	}
+	if !reflect.DeepEqual(args, []interface{}{200}) {
+		t.Errorf("employeeFilter() args = %v", args)
+	}
+}
+
+func TestLimitsValidate(t *testing.T) {
+	if err := (Limits{MaxOrders: 10}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (Limits{MaxEmployees: -1}).Validate(); err == nil {
+		t.Error("Validate() with negative limit should fail")
+	}
+}
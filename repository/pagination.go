@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+
+	"oracle-n-plus-1-demo/models"
+)
+
+// ApproxSamplePercent - 推定件数で標本にするブロックの割合（%）
+const ApproxSamplePercent = 10
+
+// approxSampleSeed - 推定件数の標本を実行ごとに同じにする乱数シード（手法間・実行間で比べられるようにする）
+const approxSampleSeed = 1
+
+// orderPageQuery - 過去days日間の受注を新しい順に並べた1ページを取得するSQL（bindはOFFSETの最初のバインド番号）
+//
+// countOverを指定すると、ページングの前の件数を COUNT(*) OVER () で各行に付ける。
+func orderPageQuery(where string, bind int, countOver bool) string {
+	total := ""
+	if countOver {
+		total = ",\n			COUNT(*) OVER () AS total_count"
+	}
+	return fmt.Sprintf(`
+		SELECT order_id, customer_id, order_date, total_amount%s
+		FROM orders
+		WHERE %s
+		ORDER BY order_date DESC, order_id DESC
+		OFFSET :%d ROWS FETCH NEXT :%d ROWS ONLY`, total, where, bind, bind+1)
+}
+
+// queryOrderPage - ページを取得する（countOverなら各行の件数をTotalに入れる）
+func queryOrderPage(db DBTX, where string, args []interface{}, page, pageSize int, countOver bool) (*models.OrderPage, error) {
+	query := orderPageQuery(where, len(args)+1, countOver)
+	args = append(args, page*pageSize, pageSize)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute order page query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	result := &models.OrderPage{}
+	for rows.Next() {
+		var order models.Order
+		dest := []interface{}{&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount}
+		if countOver {
+			dest = append(dest, &result.Total)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan order page row: %w", err)
+		}
+		result.Orders = append(result.Orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read order page: %w", err)
+	}
+	return result, nil
+}
+
+// GetOrderPageWithCount - 件数のCOUNT(*)とページの取得を別々のクエリで実行（ページごとに2回のラウンドトリップと2回の走査）
+func (r *ProblemOrderRepository) GetOrderPageWithCount(days, page, pageSize int) (*models.OrderPage, error) {
+	where, args := r.limits.orderWindow("", days, 1)
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM orders WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	result, err := queryOrderPage(r.db, where, args, page, pageSize, false)
+	if err != nil {
+		return nil, err
+	}
+	result.Total = total
+	return result, nil
+}
+
+// GetOrderPageCountOver - COUNT(*) OVER () で件数とページを1回のクエリで取得
+//
+// 件数は行に付くため、最後のページより後を指定して行がない場合はTotalが0になる。
+func (r *OptimizedOrderRepository) GetOrderPageCountOver(days, page, pageSize int) (*models.OrderPage, error) {
+	where, args := r.limits.orderWindow("", days, 1)
+	return queryOrderPage(r.db, where, args, page, pageSize, true)
+}
+
+// GetOrderPageApproxCount - ブロックの標本（SAMPLE BLOCK）から推定した件数とページを取得
+//
+// 件数のクエリは表の一部のブロックだけを読むため、正確なCOUNT(*)より軽い代わりに誤差がある。
+func (r *OptimizedOrderRepository) GetOrderPageApproxCount(days, page, pageSize int) (*models.OrderPage, error) {
+	where, args := r.limits.orderWindow("", days, 1)
+	// SAMPLE句の割合とシードはバインド変数にできないため、定数を埋め込む
+	query := "SELECT COUNT(*) FROM orders SAMPLE BLOCK (" + strconv.Itoa(ApproxSamplePercent) + ") SEED (" +
+		strconv.Itoa(approxSampleSeed) + ") WHERE " + where
+	var sampled int64
+	if err := r.db.QueryRow(query, args...).Scan(&sampled); err != nil {
+		return nil, fmt.Errorf("failed to estimate order count: %w", err)
+	}
+
+	result, err := queryOrderPage(r.db, where, args, page, pageSize, false)
+	if err != nil {
+		return nil, err
+	}
+	result.Total = sampled * 100 / ApproxSamplePercent
+	result.Approximate = true
+	return result, nil
+}
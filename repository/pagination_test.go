@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderPageQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		countOver bool
+		want      []string
+	}{
+		{
+			name: "plain",
+			want: []string{"WHERE order_date >= SYSDATE - :1", "OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY"},
+		},
+		{
+			name:      "count over",
+			countOver: true,
+			want:      []string{"COUNT(*) OVER () AS total_count", "OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY"},
+		},
+		{
+			// 件数の上限のバインド変数の後にOFFSET/FETCHのバインド変数が続く
+			name:   "with limit",
+			limits: Limits{MaxOrders: 500},
+			want:   []string{"FETCH FIRST :2 ROWS ONLY", "OFFSET :3 ROWS FETCH NEXT :4 ROWS ONLY"},
+		},
+	}
+
+	for _, tt := range tests {
+		where, args := tt.limits.orderWindow("", 30, 1)
+		query := orderPageQuery(where, len(args)+1, tt.countOver)
+		for _, want := range tt.want {
+			if !strings.Contains(query, want) {
+				t.Errorf("%s: orderPageQuery() = %q, want it to contain %q", tt.name, query, want)
+			}
+		}
+		if !tt.countOver && strings.Contains(query, "OVER ()") {
+			t.Errorf("%s: orderPageQuery() = %q, want no COUNT(*) OVER ()", tt.name, query)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"oracle-n-plus-1-demo/models"
+)
+
+// 受注の取得で先読みを宣言できる関連
+const (
+	// RelationDetails - 受注の明細（1対多）
+	RelationDetails = "details"
+	// RelationProduct - 明細の商品（多対1、明細の先読みを含む）
+	RelationProduct = "product"
+)
+
+// FetchStrategy - 先読みする関連の取得方法
+type FetchStrategy string
+
+const (
+	// FetchAuto - 宣言された関連の形からFetchJoinかFetchBatchを選ぶ
+	FetchAuto FetchStrategy = "auto"
+	// FetchJoin - 1回のJOINで取得する（ORMの Joins に相当）
+	FetchJoin FetchStrategy = "join"
+	// FetchBatch - 関連ごとにIN句で一括取得する（ORMの Preload・includes に相当）
+	FetchBatch FetchStrategy = "batch"
+)
+
+// ErrUnknownRelation - 先読みできない関連が宣言された
+var ErrUnknownRelation = errors.New("unknown relation")
+
+// FetchPlan - 宣言された関連の取得計画
+type FetchPlan struct {
+	// Relations - 先読みする関連（依存する関連を補い、RelationDetails・RelationProductの順に並べる）
+	Relations []string `json:"relations"`
+	// Strategy - 取得方法（FetchAutoは解決済み）
+	Strategy FetchStrategy `json:"strategy"`
+	// Queries - 実行するSQLの数（件数の上限による分割を除く）
+	Queries int `json:"queries"`
+	// Reason - 取得方法を選んだ理由
+	Reason string `json:"reason"`
+}
+
+// Has - 関連を先読みするか
+func (p FetchPlan) Has(relation string) bool {
+	for _, r := range p.Relations {
+		if r == relation {
+			return true
+		}
+	}
+	return false
+}
+
+// String - 計画の1行表示（例: details+product: batch（3クエリ））
+func (p FetchPlan) String() string {
+	relations := "なし"
+	if len(p.Relations) > 0 {
+		relations = strings.Join(p.Relations, "+")
+	}
+	return fmt.Sprintf("%s: %s（%dクエリ）", relations, p.Strategy, p.Queries)
+}
+
+// PlanPrefetch - 先読みする関連と取得方法から取得計画を立てる
+//
+// FetchAutoでは、1対多の関連が1つだけならJOINを選ぶ（受注の列の繰り返しより、ラウンドトリップの削減が効く）。
+// 1対多の先に多対1の関連が続く場合は、同じ商品の列が明細の数だけ繰り返し転送されるため、関連ごとの一括取得を選ぶ。
+func PlanPrefetch(strategy FetchStrategy, relations ...string) (FetchPlan, error) {
+	declared := make(map[string]bool)
+	for _, relation := range relations {
+		switch relation {
+		case RelationDetails:
+		case RelationProduct:
+			// 商品は明細を経由してしか辿れない
+			declared[RelationDetails] = true
+		default:
+			return FetchPlan{}, fmt.Errorf("%w: %q", ErrUnknownRelation, relation)
+		}
+		declared[relation] = true
+	}
+
+	plan := FetchPlan{Relations: []string{}}
+	for _, relation := range []string{RelationDetails, RelationProduct} {
+		if declared[relation] {
+			plan.Relations = append(plan.Relations, relation)
+		}
+	}
+
+	switch strategy {
+	case FetchAuto:
+		switch {
+		case len(plan.Relations) == 0:
+			plan.Strategy, plan.Reason = FetchBatch, "関連を先読みしないため、受注だけを取得する"
+		case plan.Has(RelationProduct):
+			plan.Strategy, plan.Reason = FetchBatch, "多対1の商品をJOINすると同じ商品の列が明細ごとに繰り返されるため、関連ごとに一括取得する"
+		default:
+			plan.Strategy, plan.Reason = FetchJoin, "1対多の関連が1つだけのため、1回のJOINでラウンドトリップを減らす"
+		}
+	case FetchJoin, FetchBatch:
+		plan.Strategy, plan.Reason = strategy, "取得方法が指定された"
+	default:
+		return FetchPlan{}, fmt.Errorf("unknown fetch strategy: %q", strategy)
+	}
+
+	plan.Queries = 1
+	if plan.Strategy == FetchBatch {
+		plan.Queries += len(plan.Relations)
+	}
+	return plan, nil
+}
+
+// GetOrdersPrefetch - 過去days日間の受注を、取得計画で先読みする関連と一緒に取得
+//
+// 先読みしなかった関連は、明細なら空のスライス、商品ならnilのまま返す。
+func (r *OptimizedOrderRepository) GetOrdersPrefetch(days int, plan FetchPlan) ([]models.OrderWithProducts, error) {
+	switch {
+	case plan.Has(RelationProduct) && plan.Strategy == FetchJoin:
+		return r.GetOrdersWithProductsJoin(days)
+	case plan.Has(RelationProduct):
+		return r.GetOrdersWithProductsBatch(days)
+	case plan.Has(RelationDetails) && plan.Strategy == FetchJoin:
+		orders, err := r.GetOrdersWithDetailsJoin(days)
+		return withoutProducts(orders), err
+	case plan.Has(RelationDetails):
+		orders, err := r.GetOrdersWithDetailsBatch(days)
+		return withoutProducts(orders), err
+	}
+
+	orders, err := r.GetOrdersByDays(days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	result := make([]models.OrderWithProducts, len(orders))
+	for i, order := range orders {
+		result[i] = models.OrderWithProducts{Order: order, Details: []models.OrderDetailWithProduct{}}
+	}
+	return result, nil
+}
+
+// withoutProducts - 明細だけを先読みした結果を、商品を持たない3階層のモデルに詰め替える
+func withoutProducts(orders []models.OrderWithDetails) []models.OrderWithProducts {
+	if orders == nil {
+		return nil
+	}
+	result := make([]models.OrderWithProducts, len(orders))
+	for i, order := range orders {
+		items := make([]models.OrderDetailWithProduct, len(order.Details))
+		for j, detail := range order.Details {
+			items[j] = models.OrderDetailWithProduct{Detail: detail}
+		}
+		result[i] = models.OrderWithProducts{Order: order.Order, Details: items}
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPlanPrefetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  FetchStrategy
+		relations []string
+		want      []string
+		wantStrat FetchStrategy
+		wantQuery int
+	}{
+		{name: "orders only", strategy: FetchAuto, want: []string{}, wantStrat: FetchBatch, wantQuery: 1},
+		{name: "details", strategy: FetchAuto, relations: []string{"details"}, want: []string{"details"}, wantStrat: FetchJoin, wantQuery: 1},
+		{
+			// 商品は明細を補い、宣言の順序や重複にかかわらず同じ計画になる
+			name:      "product implies details",
+			strategy:  FetchAuto,
+			relations: []string{"product", "details", "product"},
+			want:      []string{"details", "product"},
+			wantStrat: FetchBatch,
+			wantQuery: 3,
+		},
+		{name: "forced join", strategy: FetchJoin, relations: []string{"product"}, want: []string{"details", "product"}, wantStrat: FetchJoin, wantQuery: 1},
+		{name: "forced batch", strategy: FetchBatch, relations: []string{"details"}, want: []string{"details"}, wantStrat: FetchBatch, wantQuery: 2},
+	}
+	for _, tt := range tests {
+		plan, err := PlanPrefetch(tt.strategy, tt.relations...)
+		if err != nil {
+			t.Errorf("%s: PlanPrefetch() error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(plan.Relations, tt.want) || plan.Strategy != tt.wantStrat || plan.Queries != tt.wantQuery {
+			t.Errorf("%s: PlanPrefetch() = %v %s %d, want %v %s %d",
+				tt.name, plan.Relations, plan.Strategy, plan.Queries, tt.want, tt.wantStrat, tt.wantQuery)
+		}
+		if plan.Reason == "" {
+			t.Errorf("%s: PlanPrefetch() has no reason", tt.name)
+		}
+	}
+
+	if _, err := PlanPrefetch(FetchAuto, "details", "customer"); !errors.Is(err, ErrUnknownRelation) {
+		t.Errorf("PlanPrefetch() with unknown relation error = %v, want %v", err, ErrUnknownRelation)
+	}
+	if _, err := PlanPrefetch("lazy", "details"); err == nil {
+		t.Error("PlanPrefetch() with unknown strategy should fail")
+	}
+}
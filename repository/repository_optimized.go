@@ -3,24 +3,29 @@ package repository
 import (
 	"database/sql"
 	"fmt"
-	"strings"
+	"time"
 
+	"oracle-n-plus-1-demo/internal/sqlutil"
 	"oracle-n-plus-1-demo/models"
 )
 
 // OptimizedOrderRepository - N+1問題を解決したリポジトリ
 type OptimizedOrderRepository struct {
-	db *sql.DB
+	db     DBTX
+	limits Limits
+	// asOfSCN - GetOrdersWithDetailsBatchSCNが読む時点（0なら実行ごとに現在のSCNを取得する）
+	asOfSCN uint64
 }
 
 // NewOptimizedOrderRepository - 最適化されたリポジトリのコンストラクタ
-func NewOptimizedOrderRepository(db *sql.DB) *OptimizedOrderRepository {
+func NewOptimizedOrderRepository(db DBTX) *OptimizedOrderRepository {
 	return &OptimizedOrderRepository{db: db}
 }
 
 // GetOrdersWithDetailsJoin - JOINを使用した一括取得（推奨方法1）
 func (r *OptimizedOrderRepository) GetOrdersWithDetailsJoin(days int) ([]models.OrderWithDetails, error) {
-	query := `
+	where, args := r.limits.orderWindow("o", days, 1)
+	query := fmt.Sprintf(`
 		SELECT 
 			o.order_id,
 			o.customer_id,
@@ -32,10 +37,10 @@ func (r *OptimizedOrderRepository) GetOrdersWithDetailsJoin(days int) ([]models.
 			od.unit_price
 		FROM orders o
 		LEFT JOIN order_details od ON o.order_id = od.order_id
-		WHERE o.order_date >= SYSDATE - :1
-		ORDER BY o.order_id, od.detail_id`
+		WHERE %s
+		ORDER BY o.order_id, od.detail_id`, where)
 
-	rows, err := r.db.Query(query, days)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute join query: %w", err)
 	}
@@ -145,30 +150,403 @@ func (r *OptimizedOrderRepository) GetOrdersWithDetailsBatch(days int) ([]models
 	return result, nil
 }
 
+// GetOrdersWithDetailsBatchSCN - 受注と明細の2つのクエリを同じSCN時点（AS OF SCN）で読むバッチ取得
+//
+// Batch_Optimizedの2つのクエリはそれぞれの開始時点で読み取り一貫性が保たれるだけで、間にほかのセッションが
+// コミットすると、受注は更新前、明細は更新後という組み合わせを返し得る（1文で読むJOINでは起きない）。
+// 最初に現在のSCNを取得して両方のクエリをその時点に固定するため、ラウンドトリップが1回増える。
+func (r *OptimizedOrderRepository) GetOrdersWithDetailsBatchSCN(days int) ([]models.OrderWithDetails, error) {
+	scn := r.asOfSCN
+	if scn == 0 {
+		if err := r.db.QueryRow("SELECT DBMS_FLASHBACK.GET_SYSTEM_CHANGE_NUMBER FROM dual").Scan(&scn); err != nil {
+			return nil, fmt.Errorf("failed to get current SCN: %w", err)
+		}
+	}
+
+	orders, err := r.getOrdersByDays(days, scn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	orderIDs := make([]int64, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.OrderID
+	}
+	details, err := r.getDetailsByOrderIDs(orderIDs, scn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get details: %w", err)
+	}
+
+	detailsByOrderID := make(map[int64][]models.OrderDetail)
+	for _, detail := range details {
+		detailsByOrderID[detail.OrderID] = append(detailsByOrderID[detail.OrderID], detail)
+	}
+	result := make([]models.OrderWithDetails, len(orders))
+	for i, order := range orders {
+		result[i] = models.OrderWithDetails{Order: order, Details: detailsByOrderID[order.OrderID]}
+		if result[i].Details == nil {
+			result[i].Details = []models.OrderDetail{}
+		}
+	}
+	return result, nil
+}
+
+// SetAsOfSCN - GetOrdersWithDetailsBatchSCNが読む時点を固定する（-as-of でセッションを固定した場合）
+//
+// フラッシュバック・モードでもGET_SYSTEM_CHANGE_NUMBERは現在のSCNを返し、AS OF SCNはセッションの時点より優先されるため、
+// 固定した時点を渡さないとこの手法だけが現在のデータを読む。
+func (r *OptimizedOrderRepository) SetAsOfSCN(scn uint64) {
+	r.asOfSCN = scn
+}
+
 // GetDetailsByOrderIDs - IN句を使用した明細の一括取得
 func (r *OptimizedOrderRepository) GetDetailsByOrderIDs(orderIDs []int64) ([]models.OrderDetail, error) {
+	return r.getDetailsByOrderIDs(orderIDs, 0)
+}
+
+// getDetailsByOrderIDs - 明細をIN句で一括取得（scnが0以外ならその時点のデータを読む）
+func (r *OptimizedOrderRepository) getDetailsByOrderIDs(orderIDs []int64, scn uint64) ([]models.OrderDetail, error) {
 	if len(orderIDs) == 0 {
 		return []models.OrderDetail{}, nil
 	}
 
-	// IN句用のプレースホルダーを生成
-	placeholders := make([]string, len(orderIDs))
-	args := make([]interface{}, len(orderIDs))
-	for i, id := range orderIDs {
-		placeholders[i] = fmt.Sprintf(":%d", i+1)
-		args[i] = id
+	// IN句のプレースホルダーと引数はsqlutil.QueryInが展開する（1000件を超える場合は分割して実行）
+	query := `
+		SELECT detail_id, order_id, product_id, quantity, unit_price
+		FROM order_details
+		WHERE order_id IN (?)
+		ORDER BY order_id, detail_id`
+	args := []interface{}{orderIDs}
+	if scn != 0 {
+		// 分割して実行しても、すべての実行が同じ時点を読む
+		query = `
+		SELECT detail_id, order_id, product_id, quantity, unit_price
+		FROM order_details AS OF SCN ?
+		WHERE order_id IN (?)
+		ORDER BY order_id, detail_id`
+		args = []interface{}{scn, orderIDs}
+	}
+
+	var details []models.OrderDetail
+	err := sqlutil.QueryIn(r.db, query, sqlutil.DefaultInChunkSize, func(rows *sql.Rows) error {
+		var detail models.OrderDetail
+		err := rows.Scan(
+			&detail.DetailID,
+			&detail.OrderID,
+			&detail.ProductID,
+			&detail.Quantity,
+			&detail.UnitPrice,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan detail row: %w", err)
+		}
+		details = append(details, detail)
+		return nil
+	}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute batch query: %w", err)
+	}
+
+	return details, nil
+}
+
+// GetCustomerSummary - 1回の集計SQLで顧客サマリーを取得
+//
+// 顧客が存在しない（受注が0件）の場合はnilを返す。
+func (r *OptimizedOrderRepository) GetCustomerSummary(customerID int64) (*models.CustomerSummary, error) {
+	query := `
+		SELECT
+			MAX(o.customer_name),
+			COUNT(DISTINCT o.order_id),
+			COUNT(od.detail_id),
+			NVL(SUM(od.quantity), 0),
+			NVL(SUM(od.quantity * od.unit_price), 0),
+			TO_CHAR(MAX(o.order_date), 'YYYY-MM-DD')
+		FROM orders o
+		LEFT JOIN order_details od ON o.order_id = od.order_id
+		WHERE o.customer_id = :1`
+
+	summary := &models.CustomerSummary{CustomerID: customerID}
+	var customerName, lastOrderDate sql.NullString
+	err := r.db.QueryRow(query, customerID).Scan(
+		&customerName,
+		&summary.OrderCount,
+		&summary.DetailCount,
+		&summary.TotalQuantity,
+		&summary.TotalAmount,
+		&lastOrderDate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute customer summary query: %w", err)
+	}
+
+	// 集計関数は行がなくても1行返すため、受注件数で存在を判定する
+	if summary.OrderCount == 0 {
+		return nil, nil
+	}
+	summary.CustomerName = customerName.String
+	summary.LastOrderDate = lastOrderDate.String
+
+	return summary, nil
+}
+
+// monthlySalesGroupByQuery - 顧客別・月別売上をSQL側で集計するクエリ（%sにヒントを埋め込む）
+const monthlySalesGroupByQuery = `
+	SELECT %s
+		o.customer_id,
+		TO_CHAR(TRUNC(o.order_date, 'MM'), 'YYYY-MM') AS sales_month,
+		COUNT(DISTINCT o.order_id),
+		SUM(od.quantity * od.unit_price)
+	FROM orders o
+	JOIN order_details od ON o.order_id = od.order_id
+	WHERE o.order_date >= ADD_MONTHS(TRUNC(SYSDATE, 'MM'), -:1)
+	GROUP BY o.customer_id, TRUNC(o.order_date, 'MM')
+	ORDER BY o.customer_id, sales_month`
+
+// MonthlySalesView - 月次売上マテリアライズドビュー名
+const MonthlySalesView = "MV_MONTHLY_CUSTOMER_SALES"
+
+// GetMonthlySalesGroupBy - GROUP BYでSQL側集計した月次売上を取得
+func (r *OptimizedOrderRepository) GetMonthlySalesGroupBy(months int) ([]models.MonthlySales, error) {
+	return r.queryMonthlySales(fmt.Sprintf(monthlySalesGroupByQuery, ""), months)
+}
+
+// GetMonthlySalesResultCache - RESULT_CACHEヒント付きのGROUP BYで月次売上を取得
+func (r *OptimizedOrderRepository) GetMonthlySalesResultCache(months int) ([]models.MonthlySales, error) {
+	return r.queryMonthlySales(fmt.Sprintf(monthlySalesGroupByQuery, "/*+ RESULT_CACHE */"), months)
+}
+
+// GetMonthlySalesMaterializedView - 事前集計済みのマテリアライズドビューから月次売上を取得
+func (r *OptimizedOrderRepository) GetMonthlySalesMaterializedView(months int) ([]models.MonthlySales, error) {
+	query := `
+		SELECT customer_id, TO_CHAR(sales_month, 'YYYY-MM'), order_count, revenue
+		FROM mv_monthly_customer_sales
+		WHERE sales_month >= ADD_MONTHS(TRUNC(SYSDATE, 'MM'), -:1)
+		ORDER BY customer_id, sales_month`
+
+	return r.queryMonthlySales(query, months)
+}
+
+// MonthlySalesViewStatus - マテリアライズドビューの有無と最終リフレッシュ日時を取得
+//
+// ビューが存在しない場合はexists=falseを返す。
+func (r *OptimizedOrderRepository) MonthlySalesViewStatus() (exists bool, lastRefresh *time.Time, err error) {
+	query := `
+		SELECT last_refresh_date
+		FROM user_mviews
+		WHERE mview_name = :1`
+
+	var refreshed sql.NullTime
+	err = r.db.QueryRow(query, MonthlySalesView).Scan(&refreshed)
+	if err == sql.ErrNoRows {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to query user_mviews: %w", err)
+	}
+	if refreshed.Valid {
+		lastRefresh = &refreshed.Time
+	}
+	return true, lastRefresh, nil
+}
+
+// RefreshMonthlySalesView - マテリアライズドビューを完全リフレッシュ
+func (r *OptimizedOrderRepository) RefreshMonthlySalesView() error {
+	if _, err := r.db.Exec("BEGIN DBMS_MVIEW.REFRESH(:1, 'C'); END;", MonthlySalesView); err != nil {
+		return fmt.Errorf("failed to refresh materialized view: %w", err)
+	}
+	return nil
+}
+
+// queryMonthlySales - 集計済みの月次売上クエリを実行
+func (r *OptimizedOrderRepository) queryMonthlySales(query string, months int) ([]models.MonthlySales, error) {
+	rows, err := r.db.Query(query, months)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute monthly sales query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var result []models.MonthlySales
+	for rows.Next() {
+		var sales models.MonthlySales
+		if err := rows.Scan(&sales.CustomerID, &sales.Month, &sales.OrderCount, &sales.Revenue); err != nil {
+			return nil, fmt.Errorf("failed to scan monthly sales row: %w", err)
+		}
+		result = append(result, sales)
+	}
+
+	return result, rows.Err()
+}
+
+// GetTopCustomersWithRecentOrders - ROW_NUMBER() OVER (PARTITION BY ...) で売上上位顧客と直近受注を1回で取得
+func (r *OptimizedOrderRepository) GetTopCustomersWithRecentOrders(topN, recentOrders int) ([]models.CustomerWithRecentOrders, error) {
+	query := `
+		WITH top_customers AS (
+			SELECT customer_id, MAX(customer_name) AS customer_name, SUM(total_amount) AS revenue
+			FROM orders
+			GROUP BY customer_id
+			ORDER BY revenue DESC, customer_id
+			FETCH FIRST :1 ROWS ONLY
+		),
+		ranked_orders AS (
+			SELECT
+				o.order_id,
+				o.customer_id,
+				o.order_date,
+				o.total_amount,
+				ROW_NUMBER() OVER (
+					PARTITION BY o.customer_id
+					ORDER BY o.order_date DESC, o.order_id DESC
+				) AS rn
+			FROM orders o
+			JOIN top_customers t ON o.customer_id = t.customer_id
+		)
+		SELECT t.customer_id, t.customer_name, t.revenue, r.order_id, r.order_date, r.total_amount
+		FROM top_customers t
+		JOIN ranked_orders r ON r.customer_id = t.customer_id
+		WHERE r.rn <= :2
+		ORDER BY t.revenue DESC, t.customer_id, r.rn`
+
+	rows, err := r.db.Query(query, topN, recentOrders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute top customers query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	// ORDER BYで顧客ごとに連続して返るため、直前の顧客と比較してグルーピングする
+	var result []models.CustomerWithRecentOrders
+	for rows.Next() {
+		var customerID int64
+		var customerName string
+		var revenue float64
+		var order models.Order
+
+		if err := rows.Scan(&customerID, &customerName, &revenue, &order.OrderID, &order.OrderDate, &order.TotalAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan top customer row: %w", err)
+		}
+		order.CustomerID = customerID
+
+		if len(result) == 0 || result[len(result)-1].CustomerID != customerID {
+			result = append(result, models.CustomerWithRecentOrders{
+				CustomerID:   customerID,
+				CustomerName: customerName,
+				Revenue:      revenue,
+				RecentOrders: []models.Order{},
+			})
+		}
+		current := &result[len(result)-1]
+		current.RecentOrders = append(current.RecentOrders, order)
+	}
+
+	return result, rows.Err()
+}
+
+// GetCustomerRunningTotals - SUM() OVER で顧客ごとの受注金額の累計を取得
+func (r *OptimizedOrderRepository) GetCustomerRunningTotals(days int) ([]models.OrderAnalytics, error) {
+	where, args := r.limits.orderWindow("", days, 1)
+	query := fmt.Sprintf(`
+		SELECT
+			order_id, customer_id, order_date, total_amount,
+			SUM(total_amount) OVER (
+				PARTITION BY customer_id
+				ORDER BY order_date, order_id
+				ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+			) AS running_total
+		FROM orders
+		WHERE %s
+		ORDER BY customer_id, order_date, order_id`, where)
+
+	return r.queryOrderAnalytics(query, args, func(a *models.OrderAnalytics) []interface{} {
+		return []interface{}{&a.RunningTotal}
+	})
+}
+
+// GetOrderAmountRanks - RANK() OVER で顧客内の受注金額順位を取得
+func (r *OptimizedOrderRepository) GetOrderAmountRanks(days int) ([]models.OrderAnalytics, error) {
+	where, args := r.limits.orderWindow("", days, 1)
+	query := fmt.Sprintf(`
+		SELECT
+			order_id, customer_id, order_date, total_amount,
+			RANK() OVER (PARTITION BY customer_id ORDER BY total_amount DESC) AS amount_rank
+		FROM orders
+		WHERE %s
+		ORDER BY customer_id, order_date, order_id`, where)
+
+	return r.queryOrderAnalytics(query, args, func(a *models.OrderAnalytics) []interface{} {
+		return []interface{}{&a.AmountRank}
+	})
+}
+
+// GetOrderAmountChanges - LAG()/LEAD() で顧客内の前後の受注金額を取得
+func (r *OptimizedOrderRepository) GetOrderAmountChanges(days int) ([]models.OrderAnalytics, error) {
+	where, args := r.limits.orderWindow("", days, 1)
+	query := fmt.Sprintf(`
+		SELECT
+			order_id, customer_id, order_date, total_amount,
+			LAG(total_amount) OVER (PARTITION BY customer_id ORDER BY order_date, order_id) AS prev_amount,
+			LEAD(total_amount) OVER (PARTITION BY customer_id ORDER BY order_date, order_id) AS next_amount
+		FROM orders
+		WHERE %s
+		ORDER BY customer_id, order_date, order_id`, where)
+
+	return r.queryOrderAnalytics(query, args, func(a *models.OrderAnalytics) []interface{} {
+		return []interface{}{&a.PrevAmount, &a.NextAmount}
+	})
+}
+
+// queryOrderAnalytics - 受注列に続けて分析関数の列を返すクエリを実行
+//
+// extra は受注列以降のScan先を返す関数。
+func (r *OptimizedOrderRepository) queryOrderAnalytics(query string, args []interface{}, extra func(*models.OrderAnalytics) []interface{}) ([]models.OrderAnalytics, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute analytic query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var result []models.OrderAnalytics
+	for rows.Next() {
+		var a models.OrderAnalytics
+		dest := append([]interface{}{
+			&a.Order.OrderID, &a.Order.CustomerID, &a.Order.OrderDate, &a.Order.TotalAmount,
+		}, extra(&a)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan analytic row: %w", err)
+		}
+		result = append(result, a)
 	}
 
+	return result, rows.Err()
+}
+
+// GetOrdersWithNotesJoin - 受注備考（CLOB）を含めてJOINで一括取得
+//
+// LOB列は明細行の数だけ繰り返し転送されるため、幅の広いLOBではJOINが不利になる。
+func (r *OptimizedOrderRepository) GetOrdersWithNotesJoin(days int) ([]models.OrderWithNotes, error) {
+	where, args := r.limits.orderWindow("o", days, 1)
 	query := fmt.Sprintf(`
-		SELECT detail_id, order_id, product_id, quantity, unit_price
-		FROM order_details
-		WHERE order_id IN (%s)
-		ORDER BY order_id, detail_id`,
-		strings.Join(placeholders, ","))
+		SELECT
+			o.order_id, o.customer_id, o.order_date, o.total_amount, o.notes,
+			od.detail_id, od.product_id, od.quantity, od.unit_price
+		FROM orders o
+		LEFT JOIN order_details od ON o.order_id = od.order_id
+		WHERE %s
+		ORDER BY o.order_id, od.detail_id`, where)
 
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute batch query: %w", err)
+		return nil, fmt.Errorf("failed to execute notes join query: %w", err)
 	}
 	defer func() {
 		if cerr := rows.Close(); cerr != nil {
@@ -176,38 +554,430 @@ func (r *OptimizedOrderRepository) GetDetailsByOrderIDs(orderIDs []int64) ([]mod
 		}
 	}()
 
-	var details []models.OrderDetail
+	var result []models.OrderWithNotes
 	for rows.Next() {
-		var detail models.OrderDetail
+		var order models.Order
+		var notes sql.NullString
+		var detailID, productID *int64
+		var quantity *int
+		var unitPrice *float64
+
 		err := rows.Scan(
-			&detail.DetailID,
-			&detail.OrderID,
-			&detail.ProductID,
-			&detail.Quantity,
-			&detail.UnitPrice,
+			&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount, &notes,
+			&detailID, &productID, &quantity, &unitPrice,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan detail row: %w", err)
+			return nil, fmt.Errorf("failed to scan notes join row: %w", err)
+		}
+
+		// ORDER BYで受注ごとに連続して返るため、直前の受注と比較してグルーピングする
+		if len(result) == 0 || result[len(result)-1].Order.OrderID != order.OrderID {
+			result = append(result, newOrderWithNotes(order, notes))
+		}
+
+		if detailID != nil {
+			current := &result[len(result)-1]
+			current.Details = append(current.Details, models.OrderDetail{
+				DetailID:  *detailID,
+				OrderID:   order.OrderID,
+				ProductID: *productID,
+				Quantity:  *quantity,
+				UnitPrice: *unitPrice,
+			})
 		}
-		details = append(details, detail)
 	}
 
-	return details, nil
+	return result, rows.Err()
+}
+
+// GetOrdersWithNotesBatch - 受注（備考を含む）を1回、明細をIN句で1回取得
+//
+// LOB列は受注1件につき1回だけ転送される。
+func (r *OptimizedOrderRepository) GetOrdersWithNotesBatch(days int) ([]models.OrderWithNotes, error) {
+	where, args := r.limits.orderWindow("", days, 1)
+	query := fmt.Sprintf(`
+		SELECT order_id, customer_id, order_date, total_amount, notes
+		FROM orders
+		WHERE %s
+		ORDER BY order_id`, where)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute orders with notes query: %w", err)
+	}
+
+	var result []models.OrderWithNotes
+	for rows.Next() {
+		var order models.Order
+		var notes sql.NullString
+		if err := rows.Scan(&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount, &notes); err != nil {
+			if cerr := rows.Close(); cerr != nil {
+				fmt.Printf("rows.Close() failed: %v\n", cerr)
+			}
+			return nil, fmt.Errorf("failed to scan order with notes row: %w", err)
+		}
+		result = append(result, newOrderWithNotes(order, notes))
+	}
+	if cerr := rows.Close(); cerr != nil {
+		fmt.Printf("rows.Close() failed: %v\n", cerr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachDetails(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetOrdersWithNotesDeferred - LOBを除いてJOINで取得し、備考は後から必要な受注分だけ取得（遅延LOB取得）
+//
+// 1回目のJOINでは DBMS_LOB.GETLENGTH で長さだけを取得し、LOB本体は備考がある受注に限って
+// IN句で1回取得する。一覧表示など本文が不要な場面では2回目のクエリ自体を省略できる。
+func (r *OptimizedOrderRepository) GetOrdersWithNotesDeferred(days int) ([]models.OrderWithNotes, error) {
+	where, args := r.limits.orderWindow("o", days, 1)
+	query := fmt.Sprintf(`
+		SELECT
+			o.order_id, o.customer_id, o.order_date, o.total_amount,
+			NVL(DBMS_LOB.GETLENGTH(o.notes), 0),
+			od.detail_id, od.product_id, od.quantity, od.unit_price
+		FROM orders o
+		LEFT JOIN order_details od ON o.order_id = od.order_id
+		WHERE %s
+		ORDER BY o.order_id, od.detail_id`, where)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute deferred notes join query: %w", err)
+	}
+
+	var result []models.OrderWithNotes
+	for rows.Next() {
+		var order models.Order
+		var notesLength int64
+		var detailID, productID *int64
+		var quantity *int
+		var unitPrice *float64
+
+		err := rows.Scan(
+			&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount, &notesLength,
+			&detailID, &productID, &quantity, &unitPrice,
+		)
+		if err != nil {
+			if cerr := rows.Close(); cerr != nil {
+				fmt.Printf("rows.Close() failed: %v\n", cerr)
+			}
+			return nil, fmt.Errorf("failed to scan deferred notes row: %w", err)
+		}
+
+		if len(result) == 0 || result[len(result)-1].Order.OrderID != order.OrderID {
+			result = append(result, models.OrderWithNotes{
+				Order:       order,
+				NotesLength: notesLength,
+				Details:     []models.OrderDetail{},
+			})
+		}
+
+		if detailID != nil {
+			current := &result[len(result)-1]
+			current.Details = append(current.Details, models.OrderDetail{
+				DetailID:  *detailID,
+				OrderID:   order.OrderID,
+				ProductID: *productID,
+				Quantity:  *quantity,
+				UnitPrice: *unitPrice,
+			})
+		}
+	}
+	if cerr := rows.Close(); cerr != nil {
+		fmt.Printf("rows.Close() failed: %v\n", cerr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachNotes(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// attachDetails - IN句で明細を一括取得して受注に割り当てる
+func (r *OptimizedOrderRepository) attachDetails(orders []models.OrderWithNotes) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	orderIDs := make([]int64, len(orders))
+	for i, o := range orders {
+		orderIDs[i] = o.Order.OrderID
+	}
+
+	details, err := r.GetDetailsByOrderIDs(orderIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get details: %w", err)
+	}
+
+	detailsByOrderID := make(map[int64][]models.OrderDetail)
+	for _, detail := range details {
+		detailsByOrderID[detail.OrderID] = append(detailsByOrderID[detail.OrderID], detail)
+	}
+	for i := range orders {
+		if d, ok := detailsByOrderID[orders[i].Order.OrderID]; ok {
+			orders[i].Details = d
+		}
+	}
+
+	return nil
+}
+
+// attachNotes - 備考がある受注に限ってLOB本体をIN句で取得して割り当てる
+func (r *OptimizedOrderRepository) attachNotes(orders []models.OrderWithNotes) error {
+	indexByID := make(map[int64]int)
+	var orderIDs []int64
+	for i, o := range orders {
+		if o.NotesLength > 0 {
+			indexByID[o.Order.OrderID] = i
+			orderIDs = append(orderIDs, o.Order.OrderID)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT order_id, notes
+		FROM orders
+		WHERE order_id IN (?)`
+
+	err := sqlutil.QueryIn(r.db, query, sqlutil.DefaultInChunkSize, func(rows *sql.Rows) error {
+		var orderID int64
+		var notes sql.NullString
+		if err := rows.Scan(&orderID, &notes); err != nil {
+			return fmt.Errorf("failed to scan notes row: %w", err)
+		}
+		if notes.Valid {
+			text := notes.String
+			orders[indexByID[orderID]].Notes = &text
+		}
+		return nil
+	}, orderIDs)
+	if err != nil {
+		return fmt.Errorf("failed to execute notes query: %w", err)
+	}
+
+	return nil
+}
+
+// newOrderWithNotes - NULL許可の備考列から受注を作成
+func newOrderWithNotes(order models.Order, notes sql.NullString) models.OrderWithNotes {
+	result := models.OrderWithNotes{
+		Order:   order,
+		Details: []models.OrderDetail{},
+	}
+	if notes.Valid {
+		text := notes.String
+		result.Notes = &text
+		result.NotesLength = int64(len([]rune(text)))
+	}
+	return result
+}
+
+// GetOrdersWithProductsBatch - 受注・明細・商品を3回のクエリで取得
+//
+// 受注1回 + 明細IN句1回 + 商品IN句1回。商品は重複を除いたIDで取得するため、
+// 同じ商品が多くの明細に現れても転送は1回で済む。
+func (r *OptimizedOrderRepository) GetOrdersWithProductsBatch(days int) ([]models.OrderWithProducts, error) {
+	// 1. 受注一覧を取得
+	orders, err := r.GetOrdersByDays(days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	if len(orders) == 0 {
+		return []models.OrderWithProducts{}, nil
+	}
+
+	orderIDs := make([]int64, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.OrderID
+	}
+
+	// 2. 明細を一括取得
+	details, err := r.GetDetailsByOrderIDs(orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get details: %w", err)
+	}
+
+	// 3. 明細に現れる商品を重複なしで一括取得
+	seen := make(map[int64]bool)
+	var productIDs []int64
+	for _, detail := range details {
+		if !seen[detail.ProductID] {
+			seen[detail.ProductID] = true
+			productIDs = append(productIDs, detail.ProductID)
+		}
+	}
+	products, err := r.GetProductsByIDs(productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	// 4. メモリ上で組み立て
+	itemsByOrderID := make(map[int64][]models.OrderDetailWithProduct)
+	for _, detail := range details {
+		itemsByOrderID[detail.OrderID] = append(itemsByOrderID[detail.OrderID], models.OrderDetailWithProduct{
+			Detail:  detail,
+			Product: products[detail.ProductID],
+		})
+	}
+
+	result := make([]models.OrderWithProducts, len(orders))
+	for i, order := range orders {
+		result[i] = models.OrderWithProducts{Order: order, Details: itemsByOrderID[order.OrderID]}
+		if result[i].Details == nil {
+			result[i].Details = []models.OrderDetailWithProduct{}
+		}
+	}
+
+	return result, nil
+}
+
+// GetProductsByIDs - IN句を使用した商品の一括取得（商品IDをキーにしたマップを返す）
+func (r *OptimizedOrderRepository) GetProductsByIDs(productIDs []int64) (map[int64]*models.Product, error) {
+	products := make(map[int64]*models.Product, len(productIDs))
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+
+	query := `
+		SELECT product_id, product_name, category, list_price
+		FROM products
+		WHERE product_id IN (?)`
+
+	err := sqlutil.QueryIn(r.db, query, sqlutil.DefaultInChunkSize, func(rows *sql.Rows) error {
+		var product models.Product
+		var category sql.NullString
+		var listPrice sql.NullFloat64
+		if err := rows.Scan(&product.ProductID, &product.ProductName, &category, &listPrice); err != nil {
+			return fmt.Errorf("failed to scan product row: %w", err)
+		}
+		product.Category = category.String
+		product.ListPrice = listPrice.Float64
+		products[product.ProductID] = &product
+		return nil
+	}, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute products query: %w", err)
+	}
+
+	return products, nil
+}
+
+// GetOrdersWithProductsJoin - 受注・明細・商品を1回の多段JOINで取得
+//
+// クエリは1回だが、受注の列は明細行の数だけ、商品の列は出現回数だけ繰り返し転送される。
+func (r *OptimizedOrderRepository) GetOrdersWithProductsJoin(days int) ([]models.OrderWithProducts, error) {
+	where, args := r.limits.orderWindow("o", days, 1)
+	query := fmt.Sprintf(`
+		SELECT
+			o.order_id,
+			o.customer_id,
+			o.order_date,
+			o.total_amount,
+			od.detail_id,
+			od.product_id,
+			od.quantity,
+			od.unit_price,
+			p.product_name,
+			p.category,
+			p.list_price
+		FROM orders o
+		LEFT JOIN order_details od ON o.order_id = od.order_id
+		LEFT JOIN products p ON od.product_id = p.product_id
+		WHERE %s
+		ORDER BY o.order_id, od.detail_id`, where)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute wide join query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var result []models.OrderWithProducts
+	for rows.Next() {
+		var order models.Order
+		var detailID, productID *int64
+		var quantity *int
+		var unitPrice *float64
+		var productName, category sql.NullString
+		var listPrice sql.NullFloat64
+
+		err := rows.Scan(
+			&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount,
+			&detailID, &productID, &quantity, &unitPrice,
+			&productName, &category, &listPrice,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		// ORDER BY o.order_id なので受注IDが変わったら新しい受注
+		if len(result) == 0 || result[len(result)-1].Order.OrderID != order.OrderID {
+			result = append(result, models.OrderWithProducts{Order: order, Details: []models.OrderDetailWithProduct{}})
+		}
+
+		// 明細が存在する場合は追加
+		if detailID == nil {
+			continue
+		}
+		item := models.OrderDetailWithProduct{
+			Detail: models.OrderDetail{
+				DetailID:  *detailID,
+				OrderID:   order.OrderID,
+				ProductID: *productID,
+				Quantity:  *quantity,
+				UnitPrice: *unitPrice,
+			},
+		}
+		// 商品マスターにない場合はproductsの列がすべてNULL
+		if productName.Valid {
+			item.Product = &models.Product{
+				ProductID:   *productID,
+				ProductName: productName.String,
+				Category:    category.String,
+				ListPrice:   listPrice.Float64,
+			}
+		}
+
+		last := &result[len(result)-1]
+		last.Details = append(last.Details, item)
+	}
+
+	return result, rows.Err()
 }
 
 // OptimizedEmployeeRepository - 社員管理の最適化されたリポジトリ
 type OptimizedEmployeeRepository struct {
-	db *sql.DB
+	db     DBTX
+	limits Limits
 }
 
 // NewOptimizedEmployeeRepository - 最適化された社員リポジトリのコンストラクタ
-func NewOptimizedEmployeeRepository(db *sql.DB) *OptimizedEmployeeRepository {
+func NewOptimizedEmployeeRepository(db DBTX) *OptimizedEmployeeRepository {
 	return &OptimizedEmployeeRepository{db: db}
 }
 
 // GetEmployeesWithDepartmentJoin - JOINを使用した社員と部署の一括取得
 func (r *OptimizedEmployeeRepository) GetEmployeesWithDepartmentJoin() ([]models.EmployeeWithDepartment, error) {
-	query := `
+	filter, args := r.limits.employeeFilter("e", 1)
+	query := fmt.Sprintf(`
 		SELECT 
 			e.employee_id,
 			e.first_name,
@@ -220,9 +990,10 @@ func (r *OptimizedEmployeeRepository) GetEmployeesWithDepartmentJoin() ([]models
 			d.location
 		FROM employees e
 		LEFT JOIN departments d ON e.department_id = d.department_id
-		ORDER BY e.employee_id`
+		%s
+		ORDER BY e.employee_id`, filter)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute employee join query: %w", err)
 	}
@@ -317,12 +1088,14 @@ func (r *OptimizedEmployeeRepository) GetEmployeesWithDepartmentBatch() ([]model
 
 // GetAllEmployees - 全社員を取得
 func (r *OptimizedEmployeeRepository) GetAllEmployees() ([]models.Employee, error) {
-	query := `
+	filter, args := r.limits.employeeFilter("", 1)
+	query := fmt.Sprintf(`
 		SELECT employee_id, first_name, last_name, email, department_id, hire_date, salary
 		FROM employees
-		ORDER BY employee_id`
+		%s
+		ORDER BY employee_id`, filter)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute employee query: %w", err)
 	}
@@ -359,32 +1132,14 @@ func (r *OptimizedEmployeeRepository) GetDepartmentsByIDs(departmentIDs []int64)
 		return []models.Department{}, nil
 	}
 
-	// IN句用のプレースホルダーを生成
-	placeholders := make([]string, len(departmentIDs))
-	args := make([]interface{}, len(departmentIDs))
-	for i, id := range departmentIDs {
-		placeholders[i] = fmt.Sprintf(":%d", i+1)
-		args[i] = id
-	}
-
-	query := fmt.Sprintf(`
+	// IN句のプレースホルダーと引数はsqlutil.QueryInが展開する（1000件を超える場合は分割して実行）
+	query := `
 		SELECT department_id, department_name, location
 		FROM departments
-		WHERE department_id IN (%s)`,
-		strings.Join(placeholders, ","))
-
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute department batch query: %w", err)
-	}
-	defer func() {
-		if cerr := rows.Close(); cerr != nil {
-			fmt.Printf("rows.Close() failed: %v\n", cerr)
-		}
-	}()
+		WHERE department_id IN (?)`
 
 	var departments []models.Department
-	for rows.Next() {
+	err := sqlutil.QueryIn(r.db, query, sqlutil.DefaultInChunkSize, func(rows *sql.Rows) error {
 		var dept models.Department
 		err := rows.Scan(
 			&dept.DepartmentID,
@@ -392,9 +1147,13 @@ func (r *OptimizedEmployeeRepository) GetDepartmentsByIDs(departmentIDs []int64)
 			&dept.Location,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan department row: %w", err)
+			return fmt.Errorf("failed to scan department row: %w", err)
 		}
 		departments = append(departments, dept)
+		return nil
+	}, departmentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute department batch query: %w", err)
 	}
 
 	return departments, nil
@@ -402,13 +1161,26 @@ func (r *OptimizedEmployeeRepository) GetDepartmentsByIDs(departmentIDs []int64)
 
 // GetOrdersByDays - 過去N日間の受注を取得
 func (r *OptimizedOrderRepository) GetOrdersByDays(days int) ([]models.Order, error) {
-	query := `
+	return r.getOrdersByDays(days, 0)
+}
+
+// getOrdersByDays - 過去N日間の受注を取得（scnが0以外ならその時点のデータを読む）
+func (r *OptimizedOrderRepository) getOrdersByDays(days int, scn uint64) ([]models.Order, error) {
+	from := "orders"
+	var args []interface{}
+	bind := 1
+	if scn != 0 {
+		from, args, bind = "orders AS OF SCN :1", []interface{}{scn}, 2
+	}
+	where, windowArgs := r.limits.orderWindowAsOf("", days, bind, scn)
+	args = append(args, windowArgs...)
+	query := fmt.Sprintf(`
 		SELECT order_id, customer_id, order_date, total_amount
-		FROM orders
-		WHERE order_date >= SYSDATE - :1
-		ORDER BY order_id`
+		FROM %s
+		WHERE %s
+		ORDER BY order_id`, from, where)
 
-	rows, err := r.db.Query(query, days)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute orders query: %w", err)
 	}
@@ -435,3 +1207,168 @@ func (r *OptimizedOrderRepository) GetOrdersByDays(days int) ([]models.Order, er
 
 	return orders, nil
 }
+
+// GetEmployeesWithProjectsJoin - 社員・中間テーブル・プロジェクトを1回のJOINで取得し、社員ごとにグルーピング
+func (r *OptimizedEmployeeRepository) GetEmployeesWithProjectsJoin() ([]models.EmployeeWithProjects, error) {
+	filter, args := r.limits.employeeFilter("e", 1)
+	query := fmt.Sprintf(`
+		SELECT
+			e.employee_id,
+			e.first_name,
+			e.last_name,
+			e.email,
+			e.department_id,
+			e.hire_date,
+			e.salary,
+			p.project_id,
+			p.project_name,
+			p.budget,
+			ep.project_role
+		FROM employees e
+		LEFT JOIN employee_projects ep ON e.employee_id = ep.employee_id
+		LEFT JOIN projects p ON ep.project_id = p.project_id
+		%s
+		ORDER BY e.employee_id, p.project_id`, filter)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute employee project join query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	// ORDER BYで社員ごとに連続して返るため、直前の社員と比較してグルーピングする
+	var result []models.EmployeeWithProjects
+	for rows.Next() {
+		var emp models.Employee
+		var projectID *int64
+		var projectName, role *string
+		var budget *float64
+
+		err := rows.Scan(
+			&emp.EmployeeID,
+			&emp.FirstName,
+			&emp.LastName,
+			&emp.Email,
+			&emp.DepartmentID,
+			&emp.HireDate,
+			&emp.Salary,
+			&projectID,
+			&projectName,
+			&budget,
+			&role,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan employee project row: %w", err)
+		}
+
+		if len(result) == 0 || result[len(result)-1].Employee.EmployeeID != emp.EmployeeID {
+			result = append(result, models.EmployeeWithProjects{
+				Employee: emp,
+				Projects: []models.ProjectAssignment{},
+			})
+		}
+
+		// 割り当てがある場合のみプロジェクトを追加
+		if projectID != nil && projectName != nil {
+			current := &result[len(result)-1]
+			current.Projects = append(current.Projects, newProjectAssignment(*projectID, *projectName, budget, role))
+		}
+	}
+
+	return result, rows.Err()
+}
+
+// GetEmployeesWithProjectsBatch - 社員一覧と割り当て（中間テーブル + プロジェクト）の2クエリで取得
+func (r *OptimizedEmployeeRepository) GetEmployeesWithProjectsBatch() ([]models.EmployeeWithProjects, error) {
+	// 1. 社員一覧を取得
+	employees, err := r.GetAllEmployees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employees: %w", err)
+	}
+
+	if len(employees) == 0 {
+		return []models.EmployeeWithProjects{}, nil
+	}
+
+	// 2. 社員IDをリストで抽出
+	employeeIDs := make([]int64, len(employees))
+	for i, emp := range employees {
+		employeeIDs[i] = emp.EmployeeID
+	}
+
+	// 3. 割り当てとプロジェクトを一括取得
+	assignmentsByEmployee, err := r.GetProjectAssignmentsByEmployeeIDs(employeeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project assignments: %w", err)
+	}
+
+	// 4. 結果を組み立て
+	result := make([]models.EmployeeWithProjects, len(employees))
+	for i, emp := range employees {
+		result[i] = models.EmployeeWithProjects{
+			Employee: emp,
+			Projects: assignmentsByEmployee[emp.EmployeeID],
+		}
+		// nilスライスを空スライスに変換
+		if result[i].Projects == nil {
+			result[i].Projects = []models.ProjectAssignment{}
+		}
+	}
+
+	return result, nil
+}
+
+// GetProjectAssignmentsByEmployeeIDs - IN句で指定社員のプロジェクト割り当てを一括取得し、社員IDごとに返す
+func (r *OptimizedEmployeeRepository) GetProjectAssignmentsByEmployeeIDs(employeeIDs []int64) (map[int64][]models.ProjectAssignment, error) {
+	result := make(map[int64][]models.ProjectAssignment)
+	if len(employeeIDs) == 0 {
+		return result, nil
+	}
+
+	// IN句のプレースホルダーと引数はsqlutil.QueryInが展開する（1000件を超える場合は分割して実行）
+	query := `
+		SELECT ep.employee_id, p.project_id, p.project_name, p.budget, ep.project_role
+		FROM employee_projects ep
+		JOIN projects p ON ep.project_id = p.project_id
+		WHERE ep.employee_id IN (?)
+		ORDER BY ep.employee_id, p.project_id`
+
+	err := sqlutil.QueryIn(r.db, query, sqlutil.DefaultInChunkSize, func(rows *sql.Rows) error {
+		var employeeID, projectID int64
+		var projectName string
+		var budget *float64
+		var role *string
+
+		if err := rows.Scan(&employeeID, &projectID, &projectName, &budget, &role); err != nil {
+			return fmt.Errorf("failed to scan project assignment row: %w", err)
+		}
+		result[employeeID] = append(result[employeeID], newProjectAssignment(projectID, projectName, budget, role))
+		return nil
+	}, employeeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute project assignment batch query: %w", err)
+	}
+
+	return result, nil
+}
+
+// newProjectAssignment - NULL許可列を考慮してプロジェクト割り当てを作成
+func newProjectAssignment(projectID int64, projectName string, budget *float64, role *string) models.ProjectAssignment {
+	assignment := models.ProjectAssignment{
+		Project: models.Project{
+			ProjectID:   projectID,
+			ProjectName: projectName,
+		},
+	}
+	if budget != nil {
+		assignment.Project.Budget = *budget
+	}
+	if role != nil {
+		assignment.Role = *role
+	}
+	return assignment
+}
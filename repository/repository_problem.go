@@ -3,17 +3,23 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strconv"
+	"time"
 
+	"oracle-n-plus-1-demo/internal/sqlutil"
+	"oracle-n-plus-1-demo/internal/stmtcache"
 	"oracle-n-plus-1-demo/models"
 )
 
 // ProblemOrderRepository - N+1問題のあるリポジトリ
 type ProblemOrderRepository struct {
-	db *sql.DB
+	db     DBTX
+	limits Limits
 }
 
 // NewProblemOrderRepository - 問題のあるリポジトリのコンストラクタ
-func NewProblemOrderRepository(db *sql.DB) *ProblemOrderRepository {
+func NewProblemOrderRepository(db DBTX) *ProblemOrderRepository {
 	return &ProblemOrderRepository{db: db}
 }
 
@@ -45,13 +51,14 @@ func (r *ProblemOrderRepository) GetOrdersWithDetails(days int) ([]models.OrderW
 
 // GetOrdersByDays - 過去N日間の受注を取得
 func (r *ProblemOrderRepository) GetOrdersByDays(days int) ([]models.Order, error) {
-	query := `
+	where, args := r.limits.orderWindow("", days, 1)
+	query := fmt.Sprintf(`
 		SELECT order_id, customer_id, order_date, total_amount
 		FROM orders
-		WHERE order_date >= SYSDATE - :1
-		ORDER BY order_id`
+		WHERE %s
+		ORDER BY order_id`, where)
 
-	rows, err := r.db.Query(query, days)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute orders query: %w", err)
 	}
@@ -79,18 +86,100 @@ func (r *ProblemOrderRepository) GetOrdersByDays(days int) ([]models.Order, erro
 	return orders, nil
 }
 
-// GetDetailsByOrderID - 特定の受注IDの明細を取得（N+1問題の原因）
-func (r *ProblemOrderRepository) GetDetailsByOrderID(orderID int64) ([]models.OrderDetail, error) {
-	query := `
+// detailsByOrderIDQuery - 受注ID単位の明細取得SQL（N+1ループ内で繰り返し実行される）
+const detailsByOrderIDQuery = `
 		SELECT detail_id, order_id, product_id, quantity, unit_price
 		FROM order_details
 		WHERE order_id = :1
 		ORDER BY detail_id`
 
-	rows, err := r.db.Query(query, orderID)
+// GetDetailsByOrderID - 特定の受注IDの明細を取得（N+1問題の原因）
+func (r *ProblemOrderRepository) GetDetailsByOrderID(orderID int64) ([]models.OrderDetail, error) {
+	rows, err := r.db.Query(detailsByOrderIDQuery, orderID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute order details query: %w", err)
 	}
+
+	return scanOrderDetails(rows)
+}
+
+// GetOrdersWithDetailsPrepareInLoop - ループ内で毎回Prepareする最悪パターンのN+1
+func (r *ProblemOrderRepository) GetOrdersWithDetailsPrepareInLoop(days int) ([]models.OrderWithDetails, error) {
+	orders, err := r.GetOrdersByDays(days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	var result []models.OrderWithDetails
+
+	for _, order := range orders {
+		// 受注ごとにPrepare → Query → Closeを繰り返す（パース負荷も上乗せされる）
+		stmt, err := r.db.Prepare(detailsByOrderIDQuery)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare details query: %w", err)
+		}
+
+		rows, err := stmt.Query(order.OrderID)
+		if err != nil {
+			if cerr := stmt.Close(); cerr != nil {
+				fmt.Printf("stmt.Close() failed: %v\n", cerr)
+			}
+			return nil, fmt.Errorf("failed to get details for order %d: %w", order.OrderID, err)
+		}
+
+		details, err := scanOrderDetails(rows)
+		if cerr := stmt.Close(); cerr != nil {
+			fmt.Printf("stmt.Close() failed: %v\n", cerr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details for order %d: %w", order.OrderID, err)
+		}
+
+		result = append(result, models.OrderWithDetails{
+			Order:   order,
+			Details: details,
+		})
+	}
+
+	return result, nil
+}
+
+// GetOrdersWithDetailsCachedStmt - ステートメントキャッシュを使ったN+1（Prepareは1回だがクエリはN回）
+func (r *ProblemOrderRepository) GetOrdersWithDetailsCachedStmt(days int, cache *stmtcache.Cache) ([]models.OrderWithDetails, error) {
+	orders, err := r.GetOrdersByDays(days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	var result []models.OrderWithDetails
+
+	for _, order := range orders {
+		stmt, err := cache.Prepare(detailsByOrderIDQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := stmt.Query(order.OrderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details for order %d: %w", order.OrderID, err)
+		}
+
+		details, err := scanOrderDetails(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details for order %d: %w", order.OrderID, err)
+		}
+
+		result = append(result, models.OrderWithDetails{
+			Order:   order,
+			Details: details,
+		})
+	}
+
+	return result, nil
+}
+
+// scanOrderDetails - 明細行を読み取ってrowsをクローズする
+func scanOrderDetails(rows *sql.Rows) ([]models.OrderDetail, error) {
 	defer func() {
 		if cerr := rows.Close(); cerr != nil {
 			fmt.Printf("rows.Close() failed: %v\n", cerr)
@@ -118,11 +207,12 @@ func (r *ProblemOrderRepository) GetDetailsByOrderID(orderID int64) ([]models.Or
 
 // ProblemEmployeeRepository - N+1問題のある社員管理リポジトリ
 type ProblemEmployeeRepository struct {
-	db *sql.DB
+	db     DBTX
+	limits Limits
 }
 
 // NewProblemEmployeeRepository - 問題のある社員リポジトリのコンストラクタ
-func NewProblemEmployeeRepository(db *sql.DB) *ProblemEmployeeRepository {
+func NewProblemEmployeeRepository(db DBTX) *ProblemEmployeeRepository {
 	return &ProblemEmployeeRepository{db: db}
 }
 
@@ -152,14 +242,49 @@ func (r *ProblemEmployeeRepository) GetEmployeesWithDepartment() ([]models.Emplo
 	return result, nil
 }
 
+// GetEmployeesWithDepartmentMemoized - ループは残したまま部署をリクエスト内でメモ化する部分的な改善
+//
+// クエリ回数は「1 + ユニークな部署数」に減るが、ループ内DBアクセスという構造は変わらない。
+func (r *ProblemEmployeeRepository) GetEmployeesWithDepartmentMemoized() ([]models.EmployeeWithDepartment, error) {
+	employees, err := r.GetAllEmployees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employees: %w", err)
+	}
+
+	// リクエストスコープのキャッシュ（見つからなかった部署もnilとして記憶する）
+	departments := make(map[int64]*models.Department)
+
+	var result []models.EmployeeWithDepartment
+
+	for _, employee := range employees {
+		department, cached := departments[employee.DepartmentID]
+		if !cached {
+			department, err = r.GetDepartmentByID(employee.DepartmentID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get department for employee %d: %w", employee.EmployeeID, err)
+			}
+			departments[employee.DepartmentID] = department
+		}
+
+		result = append(result, models.EmployeeWithDepartment{
+			Employee:   employee,
+			Department: department,
+		})
+	}
+
+	return result, nil
+}
+
 // GetAllEmployees - 全社員を取得
 func (r *ProblemEmployeeRepository) GetAllEmployees() ([]models.Employee, error) {
-	query := `
+	filter, args := r.limits.employeeFilter("", 1)
+	query := fmt.Sprintf(`
 		SELECT employee_id, first_name, last_name, email, department_id, hire_date, salary
 		FROM employees
-		ORDER BY employee_id`
+		%s
+		ORDER BY employee_id`, filter)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute employees query: %w", err)
 	}
@@ -212,3 +337,716 @@ func (r *ProblemEmployeeRepository) GetDepartmentByID(departmentID int64) (*mode
 
 	return &dept, nil
 }
+
+// GetEmployeesWithProjects - N+1問題のある社員とプロジェクト（多対多）の取得
+//
+// 社員ごとに中間テーブルを引き、さらに割り当てごとにプロジェクトを引くため、
+// クエリ回数は「1 + 社員数 + 割り当て数」になる。
+func (r *ProblemEmployeeRepository) GetEmployeesWithProjects() ([]models.EmployeeWithProjects, error) {
+	// 1. 社員一覧を取得（1回のクエリ）
+	employees, err := r.GetAllEmployees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employees: %w", err)
+	}
+
+	var result []models.EmployeeWithProjects
+
+	for _, employee := range employees {
+		// 2. 社員ごとに中間テーブルを取得（N回のクエリ）
+		assignments, err := r.getAssignmentsByEmployeeID(employee.EmployeeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get assignments for employee %d: %w", employee.EmployeeID, err)
+		}
+
+		projects := []models.ProjectAssignment{}
+		for _, a := range assignments {
+			// 3. 割り当てごとにプロジェクトを取得（さらにM回のクエリ）
+			project, err := r.GetProjectByID(a.projectID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get project %d: %w", a.projectID, err)
+			}
+			if project == nil {
+				continue
+			}
+			projects = append(projects, models.ProjectAssignment{
+				Project: *project,
+				Role:    a.role,
+			})
+		}
+
+		result = append(result, models.EmployeeWithProjects{
+			Employee: employee,
+			Projects: projects,
+		})
+	}
+
+	return result, nil
+}
+
+// projectAssignmentRow - 中間テーブルの1行
+type projectAssignmentRow struct {
+	projectID int64
+	role      string
+}
+
+// getAssignmentsByEmployeeID - 特定社員のプロジェクト割り当てを取得（N+1問題の原因）
+func (r *ProblemEmployeeRepository) getAssignmentsByEmployeeID(employeeID int64) ([]projectAssignmentRow, error) {
+	query := `
+		SELECT project_id, project_role
+		FROM employee_projects
+		WHERE employee_id = :1
+		ORDER BY project_id`
+
+	rows, err := r.db.Query(query, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute assignments query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var assignments []projectAssignmentRow
+	for rows.Next() {
+		var a projectAssignmentRow
+		var role sql.NullString
+		if err := rows.Scan(&a.projectID, &role); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment row: %w", err)
+		}
+		a.role = role.String
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+// GetProjectByID - 特定のIDのプロジェクトを取得（N+1問題の原因）
+func (r *ProblemEmployeeRepository) GetProjectByID(projectID int64) (*models.Project, error) {
+	query := `
+		SELECT project_id, project_name, NVL(budget, 0)
+		FROM projects
+		WHERE project_id = :1`
+
+	var project models.Project
+	err := r.db.QueryRow(query, projectID).Scan(
+		&project.ProjectID,
+		&project.ProjectName,
+		&project.Budget,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // プロジェクトが見つからない場合
+		}
+		return nil, fmt.Errorf("failed to query project: %w", err)
+	}
+
+	return &project, nil
+}
+
+// GetCustomerSummary - N+1問題のある顧客サマリー取得（受注ごとに明細を取得してアプリ側で集計）
+//
+// 顧客が存在しない（受注が0件）の場合はnilを返す。
+func (r *ProblemOrderRepository) GetCustomerSummary(customerID int64) (*models.CustomerSummary, error) {
+	query := `
+		SELECT order_id, customer_name, TO_CHAR(order_date, 'YYYY-MM-DD')
+		FROM orders
+		WHERE customer_id = :1
+		ORDER BY order_date`
+
+	rows, err := r.db.Query(query, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute customer orders query: %w", err)
+	}
+
+	type customerOrder struct {
+		orderID   int64
+		orderDate string
+	}
+
+	summary := &models.CustomerSummary{CustomerID: customerID}
+	var orders []customerOrder
+	for rows.Next() {
+		var o customerOrder
+		var orderDate sql.NullString
+		if err := rows.Scan(&o.orderID, &summary.CustomerName, &orderDate); err != nil {
+			if cerr := rows.Close(); cerr != nil {
+				fmt.Printf("rows.Close() failed: %v\n", cerr)
+			}
+			return nil, fmt.Errorf("failed to scan customer order row: %w", err)
+		}
+		o.orderDate = orderDate.String
+		orders = append(orders, o)
+	}
+	// 明細取得の前に結果セットを閉じて接続を返却する
+	if cerr := rows.Close(); cerr != nil {
+		fmt.Printf("rows.Close() failed: %v\n", cerr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	// 各受注ごとに明細を取得して集計（N回のクエリ - N+1問題発生！）
+	for _, o := range orders {
+		details, err := r.GetDetailsByOrderID(o.orderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details for order %d: %w", o.orderID, err)
+		}
+
+		summary.OrderCount++
+		summary.LastOrderDate = o.orderDate
+		for _, d := range details {
+			summary.DetailCount++
+			summary.TotalQuantity += int64(d.Quantity)
+			summary.TotalAmount += float64(d.Quantity) * d.UnitPrice
+		}
+	}
+
+	return summary, nil
+}
+
+// GetMonthlySalesAppSide - 明細行をすべて取得してアプリ側で顧客別・月別に集計（アプリ側集計）
+func (r *ProblemOrderRepository) GetMonthlySalesAppSide(months int) ([]models.MonthlySales, error) {
+	query := `
+		SELECT o.order_id, o.customer_id, o.order_date, od.quantity, od.unit_price
+		FROM orders o
+		JOIN order_details od ON o.order_id = od.order_id
+		WHERE o.order_date >= ADD_MONTHS(TRUNC(SYSDATE, 'MM'), -:1)`
+
+	rows, err := r.db.Query(query, months)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute sales rows query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	type salesKey struct {
+		customerID int64
+		month      string
+	}
+	totals := make(map[salesKey]*models.MonthlySales)
+	ordersByKey := make(map[salesKey]map[int64]bool)
+
+	for rows.Next() {
+		var orderID, customerID int64
+		var orderDate time.Time
+		var quantity int
+		var unitPrice float64
+		if err := rows.Scan(&orderID, &customerID, &orderDate, &quantity, &unitPrice); err != nil {
+			return nil, fmt.Errorf("failed to scan sales row: %w", err)
+		}
+
+		key := salesKey{customerID: customerID, month: orderDate.Format("2006-01")}
+		total, ok := totals[key]
+		if !ok {
+			total = &models.MonthlySales{CustomerID: customerID, Month: key.month}
+			totals[key] = total
+			ordersByKey[key] = make(map[int64]bool)
+		}
+		total.Revenue += float64(quantity) * unitPrice
+		ordersByKey[key][orderID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.MonthlySales, 0, len(totals))
+	for key, total := range totals {
+		total.OrderCount = len(ordersByKey[key])
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CustomerID != result[j].CustomerID {
+			return result[i].CustomerID < result[j].CustomerID
+		}
+		return result[i].Month < result[j].Month
+	})
+
+	return result, nil
+}
+
+// GetTopCustomersWithRecentOrders - N+1問題のある売上上位顧客と直近受注の取得
+//
+// 上位顧客を1回で取得したあと、顧客ごとに直近の受注を取得する（1 + topN回のクエリ）。
+func (r *ProblemOrderRepository) GetTopCustomersWithRecentOrders(topN, recentOrders int) ([]models.CustomerWithRecentOrders, error) {
+	// 1. 売上上位の顧客を取得（1回のクエリ）
+	customers, err := r.getTopCustomers(topN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top customers: %w", err)
+	}
+
+	// 2. 顧客ごとに直近の受注を取得（N回のクエリ - N+1問題発生！）
+	for i := range customers {
+		orders, err := r.getRecentOrdersByCustomerID(customers[i].CustomerID, recentOrders)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recent orders for customer %d: %w", customers[i].CustomerID, err)
+		}
+		customers[i].RecentOrders = orders
+	}
+
+	return customers, nil
+}
+
+// getTopCustomers - 売上上位の顧客を取得
+func (r *ProblemOrderRepository) getTopCustomers(topN int) ([]models.CustomerWithRecentOrders, error) {
+	query := `
+		SELECT customer_id, MAX(customer_name), SUM(total_amount) AS revenue
+		FROM orders
+		GROUP BY customer_id
+		ORDER BY revenue DESC, customer_id
+		FETCH FIRST :1 ROWS ONLY`
+
+	rows, err := r.db.Query(query, topN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute top customers query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var customers []models.CustomerWithRecentOrders
+	for rows.Next() {
+		var c models.CustomerWithRecentOrders
+		if err := rows.Scan(&c.CustomerID, &c.CustomerName, &c.Revenue); err != nil {
+			return nil, fmt.Errorf("failed to scan top customer row: %w", err)
+		}
+		customers = append(customers, c)
+	}
+
+	return customers, rows.Err()
+}
+
+// getRecentOrdersByCustomerID - 特定顧客の直近の受注を取得（N+1問題の原因）
+func (r *ProblemOrderRepository) getRecentOrdersByCustomerID(customerID int64, limit int) ([]models.Order, error) {
+	query := `
+		SELECT order_id, customer_id, order_date, total_amount
+		FROM orders
+		WHERE customer_id = :1
+		ORDER BY order_date DESC, order_id DESC
+		FETCH FIRST :2 ROWS ONLY`
+
+	rows, err := r.db.Query(query, customerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute recent orders query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		var order models.Order
+		if err := rows.Scan(&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan recent order row: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+// GetCustomerRunningTotals - 顧客ごとの受注金額の累計をアプリ側のループで計算（問題のあるアプローチ）
+func (r *ProblemOrderRepository) GetCustomerRunningTotals(days int) ([]models.OrderAnalytics, error) {
+	groups, err := r.getOrdersGroupedByCustomer(days)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.OrderAnalytics
+	for _, orders := range groups {
+		var total float64
+		for _, order := range orders {
+			total += order.TotalAmount
+			result = append(result, models.OrderAnalytics{Order: order, RunningTotal: total})
+		}
+	}
+
+	return result, nil
+}
+
+// GetOrderAmountRanks - 顧客内の受注金額順位（RANK相当）をアプリ側で計算（問題のあるアプローチ）
+func (r *ProblemOrderRepository) GetOrderAmountRanks(days int) ([]models.OrderAnalytics, error) {
+	groups, err := r.getOrdersGroupedByCustomer(days)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.OrderAnalytics
+	for _, orders := range groups {
+		// 金額の降順に並べ替え、同額は同順位・次の順位は飛ばす（RANKと同じ規則）
+		sorted := make([]models.Order, len(orders))
+		copy(sorted, orders)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].TotalAmount > sorted[j].TotalAmount })
+
+		ranks := make(map[int64]int, len(sorted))
+		for i, order := range sorted {
+			if i > 0 && order.TotalAmount == sorted[i-1].TotalAmount {
+				ranks[order.OrderID] = ranks[sorted[i-1].OrderID]
+				continue
+			}
+			ranks[order.OrderID] = i + 1
+		}
+
+		for _, order := range orders {
+			result = append(result, models.OrderAnalytics{Order: order, AmountRank: ranks[order.OrderID]})
+		}
+	}
+
+	return result, nil
+}
+
+// GetOrderAmountChanges - 顧客内の前後の受注金額（LAG/LEAD相当）をアプリ側で計算（問題のあるアプローチ）
+func (r *ProblemOrderRepository) GetOrderAmountChanges(days int) ([]models.OrderAnalytics, error) {
+	groups, err := r.getOrdersGroupedByCustomer(days)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.OrderAnalytics
+	for _, orders := range groups {
+		for i, order := range orders {
+			analytics := models.OrderAnalytics{Order: order}
+			if i > 0 {
+				prev := orders[i-1].TotalAmount
+				analytics.PrevAmount = &prev
+			}
+			if i < len(orders)-1 {
+				next := orders[i+1].TotalAmount
+				analytics.NextAmount = &next
+			}
+			result = append(result, analytics)
+		}
+	}
+
+	return result, nil
+}
+
+// getOrdersGroupedByCustomer - 顧客一覧を取得し、顧客ごとに受注を日付順で取得（N+1問題の原因）
+func (r *ProblemOrderRepository) getOrdersGroupedByCustomer(days int) ([][]models.Order, error) {
+	where, args := r.limits.orderWindow("", days, 1)
+	customerQuery := fmt.Sprintf(`
+		SELECT DISTINCT customer_id
+		FROM orders
+		WHERE %s
+		ORDER BY customer_id`, where)
+
+	rows, err := r.db.Query(customerQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute customers query: %w", err)
+	}
+
+	var customerIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			if cerr := rows.Close(); cerr != nil {
+				fmt.Printf("rows.Close() failed: %v\n", cerr)
+			}
+			return nil, fmt.Errorf("failed to scan customer row: %w", err)
+		}
+		customerIDs = append(customerIDs, id)
+	}
+	if cerr := rows.Close(); cerr != nil {
+		fmt.Printf("rows.Close() failed: %v\n", cerr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	where, args = r.limits.orderWindow("", days, 2)
+	ordersQuery := fmt.Sprintf(`
+		SELECT order_id, customer_id, order_date, total_amount
+		FROM orders
+		WHERE customer_id = :1
+		AND %s
+		ORDER BY order_date, order_id`, where)
+
+	groups := make([][]models.Order, 0, len(customerIDs))
+	for _, customerID := range customerIDs {
+		orders, err := r.queryOrders(ordersQuery, append([]interface{}{customerID}, args...)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orders for customer %d: %w", customerID, err)
+		}
+		groups = append(groups, orders)
+	}
+
+	return groups, nil
+}
+
+// queryOrders - 受注一覧を返すクエリを実行
+func (r *ProblemOrderRepository) queryOrders(query string, args ...interface{}) ([]models.Order, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute orders query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var orders []models.Order
+	for rows.Next() {
+		var order models.Order
+		if err := rows.Scan(&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan order row: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+// GetOrdersWithDetailsSelectStar - SELECT * で全列を取得してから必要な列だけ使う（過剰取得）
+//
+// JOIN自体は1回だがCLOBの備考・顧客名・商品名・監査列など使わない列まで転送する。
+// 汎用ORMが列を指定せずにエンティティ全体を読み込む場合と同じ形。
+func (r *ProblemOrderRepository) GetOrdersWithDetailsSelectStar(days int) ([]models.OrderWithDetails, error) {
+	where, args := r.limits.orderWindow("o", days, 1)
+	query := fmt.Sprintf(`
+		SELECT o.*, od.*
+		FROM orders o
+		LEFT JOIN order_details od ON o.order_id = od.order_id
+		WHERE %s
+		ORDER BY o.order_id, od.detail_id`, where)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select star query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	// 同名の列（ORDER_ID、CREATED_ATなど）は先に現れた受注側を使う
+	index := make(map[string]int, len(columns))
+	for i, name := range columns {
+		if _, exists := index[name]; !exists {
+			index[name] = i
+		}
+	}
+	for _, name := range []string{"ORDER_ID", "CUSTOMER_ID", "ORDER_DATE", "TOTAL_AMOUNT", "DETAIL_ID", "PRODUCT_ID", "QUANTITY", "UNIT_PRICE"} {
+		if _, exists := index[name]; !exists {
+			return nil, fmt.Errorf("column %s not found in select star result", name)
+		}
+	}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var result []models.OrderWithDetails
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		orderID, err := toInt64(values[index["ORDER_ID"]])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert ORDER_ID: %w", err)
+		}
+
+		// ORDER BY o.order_id なので受注IDが変わったら新しい受注
+		if len(result) == 0 || result[len(result)-1].Order.OrderID != orderID {
+			order := models.Order{OrderID: orderID, OrderDate: toDateString(values[index["ORDER_DATE"]])}
+			if order.CustomerID, err = toInt64(values[index["CUSTOMER_ID"]]); err != nil {
+				return nil, fmt.Errorf("failed to convert CUSTOMER_ID: %w", err)
+			}
+			if order.TotalAmount, err = toFloat64(values[index["TOTAL_AMOUNT"]]); err != nil {
+				return nil, fmt.Errorf("failed to convert TOTAL_AMOUNT: %w", err)
+			}
+			result = append(result, models.OrderWithDetails{Order: order, Details: []models.OrderDetail{}})
+		}
+
+		// LEFT JOINで明細がない受注はDETAIL_IDがNULL
+		if values[index["DETAIL_ID"]] == nil {
+			continue
+		}
+		detail := models.OrderDetail{OrderID: orderID}
+		if detail.DetailID, err = toInt64(values[index["DETAIL_ID"]]); err != nil {
+			return nil, fmt.Errorf("failed to convert DETAIL_ID: %w", err)
+		}
+		if detail.ProductID, err = toInt64(values[index["PRODUCT_ID"]]); err != nil {
+			return nil, fmt.Errorf("failed to convert PRODUCT_ID: %w", err)
+		}
+		quantity, err := toInt64(values[index["QUANTITY"]])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert QUANTITY: %w", err)
+		}
+		detail.Quantity = int(quantity)
+		if detail.UnitPrice, err = toFloat64(values[index["UNIT_PRICE"]]); err != nil {
+			return nil, fmt.Errorf("failed to convert UNIT_PRICE: %w", err)
+		}
+
+		last := &result[len(result)-1]
+		last.Details = append(last.Details, detail)
+	}
+
+	return result, rows.Err()
+}
+
+// toInt64 - 汎用スキャン結果（NUMBERはドライバーにより数値型または文字列）を整数に変換
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case fmt.Stringer:
+		return strconv.ParseInt(v.String(), 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected type %T", value)
+	}
+}
+
+// toFloat64 - 汎用スキャン結果を小数に変換
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case fmt.Stringer:
+		return strconv.ParseFloat(v.String(), 64)
+	default:
+		return 0, fmt.Errorf("unexpected type %T", value)
+	}
+}
+
+// toDateString - 汎用スキャン結果の日付を文字列に変換
+func toDateString(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// GetOrdersWithProducts - 受注・明細・商品を入れ子のN+1で取得
+//
+// 受注ごとに明細を引き、さらに明細ごとに商品を引くため、
+// クエリ回数は「1 + 受注数 + 明細数」になる。
+func (r *ProblemOrderRepository) GetOrdersWithProducts(days int) ([]models.OrderWithProducts, error) {
+	// 1. 受注一覧を取得（1回のクエリ）
+	orders, err := r.GetOrdersByDays(days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	result := make([]models.OrderWithProducts, 0, len(orders))
+	for _, order := range orders {
+		// 2. 受注ごとに明細を取得（N回のクエリ）
+		details, err := r.GetDetailsByOrderID(order.OrderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details for order %d: %w", order.OrderID, err)
+		}
+
+		// 3. 明細ごとに商品を取得（明細数分のクエリ - 入れ子のN+1）
+		items := make([]models.OrderDetailWithProduct, 0, len(details))
+		for _, detail := range details {
+			product, err := r.GetProductByID(detail.ProductID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get product %d: %w", detail.ProductID, err)
+			}
+			items = append(items, models.OrderDetailWithProduct{Detail: detail, Product: product})
+		}
+
+		result = append(result, models.OrderWithProducts{Order: order, Details: items})
+	}
+
+	return result, nil
+}
+
+// GetProductByID - 特定のIDの商品を取得（入れ子のN+1問題の原因）
+func (r *ProblemOrderRepository) GetProductByID(productID int64) (*models.Product, error) {
+	query := `
+		SELECT product_id, product_name, category, list_price
+		FROM products
+		WHERE product_id = :1`
+
+	var product models.Product
+	var category sql.NullString
+	var listPrice sql.NullFloat64
+	err := r.db.QueryRow(query, productID).Scan(
+		&product.ProductID,
+		&product.ProductName,
+		&category,
+		&listPrice,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 商品マスターにない場合
+		}
+		return nil, fmt.Errorf("failed to query product: %w", err)
+	}
+	product.Category = category.String
+	product.ListPrice = listPrice.Float64
+
+	return &product, nil
+}
+
+// GetOrdersWithDetailsLiteral - 受注IDをリテラルとして埋め込んだN+1（アンチパターン）
+//
+// 受注ごとにSQL文字列が変わるため共有プールのカーソルを共有できず、毎回ハードパースになる。
+// 埋め込むのはint64の受注IDのみで、SQLインジェクションの余地はない。
+func (r *ProblemOrderRepository) GetOrdersWithDetailsLiteral(days int) ([]models.OrderWithDetails, error) {
+	orders, err := r.GetOrdersByDays(days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	result := make([]models.OrderWithDetails, 0, len(orders))
+	for _, order := range orders {
+		query := fmt.Sprintf(`
+		SELECT detail_id, order_id, product_id, quantity, unit_price
+		FROM order_details
+		WHERE order_id = %d
+		ORDER BY detail_id`, order.OrderID)
+		if err := sqlutil.GuardQuery(query); err != nil {
+			return nil, err
+		}
+
+		rows, err := r.db.Query(query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute literal details query: %w", err)
+		}
+		details, err := scanOrderDetails(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details for order %d: %w", order.OrderID, err)
+		}
+
+		result = append(result, models.OrderWithDetails{Order: order, Details: details})
+	}
+
+	return result, nil
+}
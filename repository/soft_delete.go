@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"oracle-n-plus-1-demo/models"
+)
+
+// LiveOrderIndex - 削除されていない受注だけを受注日で引く関数索引（論理削除シナリオが作成する）
+const LiveOrderIndex = "IDX_ORDERS_LIVE_DATE"
+
+// liveOrderDate - 削除されていない受注は受注日、削除済みはNULLになる式
+//
+// すべての列がNULLの行は索引に入らないため、この式の索引は削除されていない受注だけを持つ
+// （Oracleには部分索引がないため、関数索引で同じ効果を得る）。問い合わせの条件が索引の式と同じでないと索引は使われない。
+const liveOrderDate = "CASE WHEN %sdeleted_flag = 'N' THEN %sorder_date END"
+
+// ErrSoftDeleteColumnMissing - ordersに論理削除フラグ（deleted_flag）がない
+var ErrSoftDeleteColumnMissing = errors.New("orders.deleted_flag does not exist")
+
+// liveOrderWindow - 過去days日間の削除されていない受注を表す条件とバインド引数（bindは条件内の最初のバインド番号）
+//
+// partialIndexを指定すると、受注日の代わりにLiveOrderIndexの式で比べる。件数の上限は削除されていない受注に対してかかるため、
+// 索引の有無にかかわらず同じ受注を扱う。
+func (l Limits) liveOrderWindow(alias string, days, bind int, partialIndex bool) (string, []interface{}) {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	condition := func(p string) string {
+		if partialIndex {
+			return fmt.Sprintf(liveOrderDate, p, p) + " >= SYSDATE - :" + strconv.Itoa(bind)
+		}
+		return fmt.Sprintf("%sdeleted_flag = 'N' AND %sorder_date >= SYSDATE - :%d", p, p, bind)
+	}
+	if l.MaxOrders <= 0 {
+		return condition(prefix), []interface{}{days}
+	}
+	return fmt.Sprintf(`%sorder_id IN (
+			SELECT order_id FROM orders
+			WHERE %s
+			ORDER BY order_date DESC, order_id DESC
+			FETCH FIRST :%d ROWS ONLY)`, prefix, condition(""), bind+1), []interface{}{days, l.MaxOrders}
+}
+
+// liveOrdersQuery - 削除されていない受注を取得するSQL
+func liveOrdersQuery(where string) string {
+	return fmt.Sprintf(`
+		SELECT order_id, customer_id, order_date, total_amount
+		FROM orders
+		WHERE %s
+		ORDER BY order_id`, where)
+}
+
+// liveOrdersJoinQuery - 受注と明細をJOINで取得するSQL（withFlagなら削除フラグも取得する）
+func liveOrdersJoinQuery(where string, withFlag bool) string {
+	flag := ""
+	if withFlag {
+		flag = ",\n			o.deleted_flag"
+	}
+	return fmt.Sprintf(`
+		SELECT
+			o.order_id,
+			o.customer_id,
+			o.order_date,
+			o.total_amount,
+			od.detail_id,
+			od.product_id,
+			od.quantity,
+			od.unit_price%s
+		FROM orders o
+		LEFT JOIN order_details od ON o.order_id = od.order_id
+		WHERE %s
+		ORDER BY o.order_id, od.detail_id`, flag, where)
+}
+
+// GetLiveOrdersWithDetails - 削除されていない受注を取得し、受注ごとに明細を取得（N+1）
+func (r *ProblemOrderRepository) GetLiveOrdersWithDetails(days int) ([]models.OrderWithDetails, error) {
+	where, args := r.limits.liveOrderWindow("", days, 1, false)
+	orders, err := queryOrders(r.db, liveOrdersQuery(where), args)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.OrderWithDetails, 0, len(orders))
+	for _, order := range orders {
+		details, err := r.GetDetailsByOrderID(order.OrderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details for order %d: %w", order.OrderID, err)
+		}
+		result = append(result, models.OrderWithDetails{Order: order, Details: details})
+	}
+	return result, nil
+}
+
+// GetOrdersJoinFilterInApp - 削除済みを含めてJOINで取得し、アプリ側で削除済みの受注を除く
+//
+// 論理削除の条件をSQLに書き忘れた（またはORMのグローバルフィルターの外で問い合わせた）場合と同じ形で、
+// 削除済みの受注と明細もDBで読み、転送してから捨てる。件数の上限は削除済みを含めた受注にかかる。
+func (r *ProblemOrderRepository) GetOrdersJoinFilterInApp(days int) ([]models.OrderWithDetails, error) {
+	where, args := r.limits.orderWindow("o", days, 1)
+	return queryOrdersJoin(r.db, liveOrdersJoinQuery(where, true), args, true)
+}
+
+// GetLiveOrdersWithDetailsBatch - 削除されていない受注を取得し、明細をIN句でまとめて取得
+//
+// partialIndexを指定すると、受注の条件をLiveOrderIndexの式で書く。
+func (r *OptimizedOrderRepository) GetLiveOrdersWithDetailsBatch(days int, partialIndex bool) ([]models.OrderWithDetails, error) {
+	where, args := r.limits.liveOrderWindow("", days, 1, partialIndex)
+	orders, err := queryOrders(r.db, liveOrdersQuery(where), args)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return []models.OrderWithDetails{}, nil
+	}
+
+	orderIDs := make([]int64, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.OrderID
+	}
+	details, err := r.GetDetailsByOrderIDs(orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get details: %w", err)
+	}
+	detailsByOrderID := make(map[int64][]models.OrderDetail)
+	for _, detail := range details {
+		detailsByOrderID[detail.OrderID] = append(detailsByOrderID[detail.OrderID], detail)
+	}
+
+	result := make([]models.OrderWithDetails, len(orders))
+	for i, order := range orders {
+		result[i] = models.OrderWithDetails{Order: order, Details: detailsByOrderID[order.OrderID]}
+		if result[i].Details == nil {
+			result[i].Details = []models.OrderDetail{}
+		}
+	}
+	return result, nil
+}
+
+// GetLiveOrdersWithDetailsJoin - 削除されていない受注と明細をJOINで取得
+//
+// partialIndexを指定すると、受注の条件をLiveOrderIndexの式で書く。
+func (r *OptimizedOrderRepository) GetLiveOrdersWithDetailsJoin(days int, partialIndex bool) ([]models.OrderWithDetails, error) {
+	where, args := r.limits.liveOrderWindow("o", days, 1, partialIndex)
+	return queryOrdersJoin(r.db, liveOrdersJoinQuery(where, false), args, false)
+}
+
+// SoftDeleteStatus - 過去days日間の削除されていない受注と削除済みの受注の件数、LiveOrderIndexの有無
+//
+// ordersにdeleted_flagがない場合は ErrSoftDeleteColumnMissing を返す。
+func (r *OptimizedOrderRepository) SoftDeleteStatus(days int) (live, deleted int64, indexed bool, err error) {
+	var columns int
+	if err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM user_tab_columns
+		WHERE table_name = 'ORDERS' AND column_name = 'DELETED_FLAG'`).Scan(&columns); err != nil {
+		return 0, 0, false, fmt.Errorf("failed to query user_tab_columns: %w", err)
+	}
+	if columns == 0 {
+		return 0, 0, false, ErrSoftDeleteColumnMissing
+	}
+
+	if err := r.db.QueryRow(`
+		SELECT COUNT(CASE WHEN deleted_flag = 'N' THEN 1 END), COUNT(CASE WHEN deleted_flag <> 'N' THEN 1 END)
+		FROM orders
+		WHERE order_date >= SYSDATE - :1`, days).Scan(&live, &deleted); err != nil {
+		return 0, 0, false, fmt.Errorf("failed to count soft-deleted orders: %w", err)
+	}
+
+	var indexes int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM user_indexes WHERE index_name = :1", LiveOrderIndex).Scan(&indexes); err != nil {
+		return 0, 0, false, fmt.Errorf("failed to query user_indexes: %w", err)
+	}
+	return live, deleted, indexes > 0, nil
+}
+
+// CreateLiveOrderIndex - LiveOrderIndexを作成し、索引の式（隠し列）の統計を収集する
+//
+// 統計がないとオプティマイザは式の選択率を推測するため、索引を使う計画にならないことがある。
+func (r *OptimizedOrderRepository) CreateLiveOrderIndex() error {
+	ddl := fmt.Sprintf("CREATE INDEX %s ON orders (%s)", LiveOrderIndex, fmt.Sprintf(liveOrderDate, "", ""))
+	if _, err := r.db.Exec(ddl); err != nil {
+		return fmt.Errorf("failed to create %s: %w", LiveOrderIndex, err)
+	}
+	if _, err := r.db.Exec(`
+		BEGIN
+			DBMS_STATS.GATHER_TABLE_STATS(USER, 'ORDERS', method_opt => 'FOR ALL HIDDEN COLUMNS SIZE 1', cascade => TRUE);
+		END;`); err != nil {
+		return fmt.Errorf("failed to gather statistics for %s: %w", LiveOrderIndex, err)
+	}
+	return nil
+}
+
+// queryOrders - 受注の一覧を取得する
+func queryOrders(db DBTX, query string, args []interface{}) ([]models.Order, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute orders query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var orders []models.Order
+	for rows.Next() {
+		var order models.Order
+		if err := rows.Scan(&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan order row: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read orders: %w", err)
+	}
+	return orders, nil
+}
+
+// queryOrdersJoin - 受注と明細のJOINの結果を受注ごとにまとめる（withFlagなら削除済みの受注の行を捨てる）
+func queryOrdersJoin(db DBTX, query string, args []interface{}, withFlag bool) ([]models.OrderWithDetails, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute join query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil {
+			fmt.Printf("rows.Close() failed: %v\n", cerr)
+		}
+	}()
+
+	var result []models.OrderWithDetails
+	for rows.Next() {
+		var order models.Order
+		var detailID, productID sql.NullInt64
+		var quantity sql.NullInt64
+		var unitPrice sql.NullFloat64
+		var deletedFlag string
+		dest := []interface{}{
+			&order.OrderID, &order.CustomerID, &order.OrderDate, &order.TotalAmount,
+			&detailID, &productID, &quantity, &unitPrice,
+		}
+		if withFlag {
+			dest = append(dest, &deletedFlag)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if withFlag && deletedFlag != "N" {
+			continue
+		}
+
+		// 受注IDの順に並んでいるため、直前と違う受注なら新しい受注として追加する
+		if len(result) == 0 || result[len(result)-1].Order.OrderID != order.OrderID {
+			result = append(result, models.OrderWithDetails{Order: order, Details: []models.OrderDetail{}})
+		}
+		if detailID.Valid {
+			current := &result[len(result)-1]
+			current.Details = append(current.Details, models.OrderDetail{
+				DetailID:  detailID.Int64,
+				OrderID:   order.OrderID,
+				ProductID: productID.Int64,
+				Quantity:  int(quantity.Int64),
+				UnitPrice: unitPrice.Float64,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read join rows: %w", err)
+	}
+	return result, nil
+}
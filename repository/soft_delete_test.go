@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLiveOrderWindow(t *testing.T) {
+	tests := []struct {
+		name         string
+		limits       Limits
+		alias        string
+		partialIndex bool
+		want         string
+		args         []interface{}
+	}{
+		{
+			name:  "filter",
+			alias: "o",
+			want:  "o.deleted_flag = 'N' AND o.order_date >= SYSDATE - :1",
+			args:  []interface{}{30},
+		},
+		{
+			// 条件は索引の式（CASE WHEN deleted_flag = 'N' THEN order_date END）と同じでないと索引が使われない
+			name:         "partial index",
+			alias:        "o",
+			partialIndex: true,
+			want:         "CASE WHEN o.deleted_flag = 'N' THEN o.order_date END >= SYSDATE - :1",
+			args:         []interface{}{30},
+		},
+		{
+			name:         "partial index with limit",
+			limits:       Limits{MaxOrders: 500},
+			partialIndex: true,
+			want:         "WHERE CASE WHEN deleted_flag = 'N' THEN order_date END >= SYSDATE - :1",
+			args:         []interface{}{30, 500},
+		},
+	}
+
+	for _, tt := range tests {
+		where, args := tt.limits.liveOrderWindow(tt.alias, 30, 1, tt.partialIndex)
+		if !strings.Contains(where, tt.want) {
+			t.Errorf("%s: liveOrderWindow() = %q, want it to contain %q", tt.name, where, tt.want)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: liveOrderWindow() args = %v, want %v", tt.name, args, tt.args)
+		}
+		if tt.limits.MaxOrders > 0 && !strings.Contains(where, "FETCH FIRST :2 ROWS ONLY") {
+			t.Errorf("%s: liveOrderWindow() = %q, want the limit bound to :2", tt.name, where)
+		}
+	}
+}
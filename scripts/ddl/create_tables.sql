@@ -37,6 +37,50 @@ CREATE INDEX idx_employees_department_id ON employees(department_id);
 -- 社員名にインデックス作成（検索で使用）
 CREATE INDEX idx_employees_name ON employees(last_name, first_name);
 
+-- ============================================
+-- プロジェクトテーブル
+-- ============================================
+CREATE TABLE projects (
+    project_id NUMBER(10) PRIMARY KEY,
+    project_name VARCHAR2(200) NOT NULL,
+    budget NUMBER(14,2),
+    created_at DATE DEFAULT SYSDATE,
+    updated_at DATE DEFAULT SYSDATE
+);
+
+-- ============================================
+-- 社員・プロジェクト中間テーブル（多対多）
+-- ============================================
+CREATE TABLE employee_projects (
+    employee_id NUMBER(10) NOT NULL,
+    project_id NUMBER(10) NOT NULL,
+    project_role VARCHAR2(50),
+    assigned_at DATE DEFAULT SYSDATE,
+    CONSTRAINT pk_employee_projects PRIMARY KEY (employee_id, project_id),
+    CONSTRAINT fk_emp_projects_employee
+        FOREIGN KEY (employee_id) REFERENCES employees(employee_id) ON DELETE CASCADE,
+    CONSTRAINT fk_emp_projects_project
+        FOREIGN KEY (project_id) REFERENCES projects(project_id) ON DELETE CASCADE
+);
+
+-- プロジェクトIDにインデックス作成（プロジェクト側からの参照で使用）
+-- 社員IDは主キーの先頭列のため追加のインデックスは不要
+CREATE INDEX idx_emp_projects_project_id ON employee_projects(project_id);
+
+-- ============================================
+-- 商品マスターテーブル
+-- ============================================
+-- order_details.product_name は受注時点の商品名のスナップショットとして残し、
+-- カテゴリや定価など現在の商品情報はこちらから参照する
+CREATE TABLE products (
+    product_id NUMBER(10) PRIMARY KEY,
+    product_name VARCHAR2(200) NOT NULL,
+    category VARCHAR2(50),
+    list_price NUMBER(10,2),
+    created_at DATE DEFAULT SYSDATE,
+    updated_at DATE DEFAULT SYSDATE
+);
+
 -- ============================================
 -- 受注テーブル
 -- ============================================
@@ -47,6 +91,8 @@ CREATE TABLE orders (
     order_date DATE DEFAULT SYSDATE,
     total_amount NUMBER(12,2) DEFAULT 0,
     status VARCHAR2(20) DEFAULT 'PENDING',
+    notes CLOB,
+    deleted_flag CHAR(1) DEFAULT 'N' NOT NULL,
     created_at DATE DEFAULT SYSDATE,
     updated_at DATE DEFAULT SYSDATE
 );
@@ -58,6 +104,12 @@ CREATE INDEX idx_orders_order_date ON orders(order_date);
 -- ステータスにインデックス作成
 CREATE INDEX idx_orders_status ON orders(status);
 
+-- 既存環境に備考列（LOBシナリオ用）を追加する場合:
+-- ALTER TABLE orders ADD (notes CLOB);
+
+-- 既存環境に論理削除フラグ（論理削除シナリオ用）を追加する場合:
+-- ALTER TABLE orders ADD (deleted_flag CHAR(1) DEFAULT 'N' NOT NULL);
+
 -- ============================================
 -- 受注明細テーブル
 -- ============================================
@@ -80,6 +132,26 @@ CREATE INDEX idx_order_details_order_id ON order_details(order_id);
 -- 商品IDにインデックス作成
 CREATE INDEX idx_order_details_product_id ON order_details(product_id);
 
+-- ============================================
+-- 月次売上マテリアライズドビュー（レポートシナリオ用）
+-- ============================================
+
+-- 顧客別・月別の売上を事前集計（データ投入後に DBMS_MVIEW.REFRESH で更新）
+CREATE MATERIALIZED VIEW mv_monthly_customer_sales
+    BUILD DEFERRED
+    REFRESH COMPLETE ON DEMAND
+AS
+SELECT
+    o.customer_id,
+    TRUNC(o.order_date, 'MM') AS sales_month,
+    COUNT(DISTINCT o.order_id) AS order_count,
+    SUM(od.quantity * od.unit_price) AS revenue
+FROM orders o
+JOIN order_details od ON o.order_id = od.order_id
+GROUP BY o.customer_id, TRUNC(o.order_date, 'MM');
+
+CREATE INDEX idx_mv_monthly_sales_month ON mv_monthly_customer_sales(sales_month);
+
 -- ============================================
 -- シーケンス作成
 -- ============================================
@@ -96,6 +168,12 @@ CREATE SEQUENCE seq_employees
     INCREMENT BY 1
     NOCACHE;
 
+-- プロジェクトID用シーケンス
+CREATE SEQUENCE seq_projects
+    START WITH 1
+    INCREMENT BY 1
+    NOCACHE;
+
 -- 受注ID用シーケンス
 CREATE SEQUENCE seq_orders
     START WITH 1
@@ -115,6 +193,9 @@ CREATE SEQUENCE seq_order_details
 -- 統計情報を収集してオプティマイザの判断を向上させる
 EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'DEPARTMENTS');
 EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'EMPLOYEES');
+EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'PROJECTS');
+EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'EMPLOYEE_PROJECTS');
+EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'PRODUCTS');
 EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'ORDERS');
 EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'ORDER_DETAILS');
 
@@ -0,0 +1,9 @@
+// Package ddl - デモスキーマのDDLスクリプト（setupコマンドが埋め込んで使う）
+package ddl
+
+import _ "embed"
+
+// CreateTables - create_tables.sql の内容
+//
+//go:embed create_tables.sql
+var CreateTables string
@@ -68,6 +68,73 @@ INSERT INTO employees (employee_id, first_name, last_name, email, department_id,
 INSERT INTO employees (employee_id, first_name, last_name, email, department_id, salary, hire_date) VALUES
 (seq_employees.NEXTVAL, '大輝', '加藤', 'kato.daiki@company.com', 5, 5300000, TO_DATE('2020-04-01', 'YYYY-MM-DD'));
 
+-- ============================================
+-- プロジェクトデータ投入
+-- ============================================
+
+INSERT INTO projects (project_id, project_name, budget) VALUES
+(seq_projects.NEXTVAL, '基幹システム刷新', 50000000);
+
+INSERT INTO projects (project_id, project_name, budget) VALUES
+(seq_projects.NEXTVAL, '顧客ポータル開発', 20000000);
+
+INSERT INTO projects (project_id, project_name, budget) VALUES
+(seq_projects.NEXTVAL, '採用プロセス改善', 5000000);
+
+-- ============================================
+-- 社員・プロジェクト割り当てデータ投入（多対多）
+-- ============================================
+
+-- 基幹システム刷新（project_id = 1）
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (1, 1, 'オーナー');
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (4, 1, 'リーダー');
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (5, 1, 'メンバー');
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (6, 1, 'メンバー');
+
+-- 顧客ポータル開発（project_id = 2）
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (2, 2, 'オーナー');
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (4, 2, 'メンバー');
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (10, 2, 'メンバー');
+
+-- 採用プロセス改善（project_id = 3）
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (7, 3, 'リーダー');
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (8, 3, 'メンバー');
+INSERT INTO employee_projects (employee_id, project_id, project_role) VALUES (1, 3, 'アドバイザー');
+
+-- ============================================
+-- 商品マスターデータ投入
+-- ============================================
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2001, 'ノートパソコン Type-A', 'PC', 80000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2002, 'ワイヤレスマウス', '周辺機器', 3000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2003, 'USB-Cハブ', '周辺機器', 4000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2004, 'モニター 24インチ', 'ディスプレイ', 45000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2005, 'キーボード', '周辺機器', 15000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2006, 'プリンター', 'オフィス機器', 120000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2007, 'スキャナー', 'オフィス機器', 30000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2008, 'Webカメラ', '周辺機器', 6000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2009, 'サーバー', 'サーバー・ネットワーク', 300000);
+
+INSERT INTO products (product_id, product_name, category, list_price) VALUES
+(2010, 'ネットワーク機器', 'サーバー・ネットワーク', 60000);
+
 -- ============================================
 -- 受注データ投入
 -- ============================================
@@ -92,6 +159,15 @@ INSERT INTO orders (order_id, customer_id, customer_name, order_date, total_amou
 INSERT INTO orders (order_id, customer_id, customer_name, order_date, total_amount, status) VALUES
 (seq_orders.NEXTVAL, 1004, '合同会社GHI物産', TO_DATE('2024-02-15', 'YYYY-MM-DD'), 420000, 'COMPLETED');
 
+-- 受注備考（LOBシナリオ用、受注3は備考なし）
+UPDATE orders SET notes = TO_CLOB(RPAD('初回取引のため検収条件を個別に確認すること。', 4000, '詳細は契約書を参照。')) WHERE order_id = 1;
+UPDATE orders SET notes = TO_CLOB('分納対応: 2回に分けて出荷。') WHERE order_id = 2;
+UPDATE orders SET notes = TO_CLOB(RPAD('大口案件。設置作業の日程調整が必要。', 8000, '作業手順書を添付。')) WHERE order_id = 4;
+UPDATE orders SET notes = TO_CLOB('サーバー設置場所の電源容量を事前確認済み。') WHERE order_id = 5;
+
+-- 論理削除（論理削除シナリオ用、受注3と受注5以外は削除済み）
+UPDATE orders SET deleted_flag = 'Y' WHERE order_id IN (1, 2, 4);
+
 -- ============================================
 -- 受注明細データ投入
 -- ============================================
@@ -142,9 +218,15 @@ INSERT INTO order_details (detail_id, order_id, product_id, product_name, quanti
 -- ============================================
 EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'DEPARTMENTS');
 EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'EMPLOYEES');
+EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'PROJECTS');
+EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'EMPLOYEE_PROJECTS');
+EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'PRODUCTS');
 EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'ORDERS');
 EXEC DBMS_STATS.GATHER_TABLE_STATS(USER, 'ORDER_DETAILS');
 
+-- 月次売上マテリアライズドビューの更新
+EXEC DBMS_MVIEW.REFRESH('MV_MONTHLY_CUSTOMER_SALES', 'C');
+
 COMMIT;
 
 -- ============================================
@@ -160,6 +242,16 @@ LEFT JOIN employees e ON d.department_id = e.department_id
 GROUP BY d.department_id, d.department_name
 ORDER BY d.department_id;
 
+-- 社員別プロジェクト数
+SELECT
+    e.employee_id,
+    e.last_name || ' ' || e.first_name as employee_name,
+    COUNT(ep.project_id) as project_count
+FROM employees e
+LEFT JOIN employee_projects ep ON e.employee_id = ep.employee_id
+GROUP BY e.employee_id, e.last_name, e.first_name
+ORDER BY e.employee_id;
+
 -- 受注別明細数
 SELECT 
     o.order_id,
@@ -19,8 +19,13 @@ ORACLE_SERVICE="${ORACLE_SERVICE:-XEPDB1}"
 # データ生成量設定
 DEPARTMENTS_COUNT=20        # 部署数
 EMPLOYEES_PER_DEPT=50      # 部署あたりの社員数
+PROJECTS_COUNT=30          # プロジェクト数
+PROJECTS_PER_EMPLOYEE=3    # 社員あたりのプロジェクト割り当て数（最大）
+PRODUCTS_COUNT=100         # 商品数（商品ID 2001〜2100）
 ORDERS_COUNT=1000          # 受注数
 DETAILS_PER_ORDER=5        # 受注あたりの明細数（平均）
+NOTES_MAX_CHARS=16000      # 受注備考（CLOB）の最大文字数（3件に1件は備考なし）
+DELETED_PERCENT=80         # 論理削除済み（deleted_flag = 'Y'）の受注の割合
 
 echo "============================================"
 echo "Oracle N+1問題デモ用大量データ生成開始"
@@ -31,8 +36,13 @@ echo ""
 echo "生成データ量:"
 echo "  - 部署数: ${DEPARTMENTS_COUNT}"
 echo "  - 社員数: $((DEPARTMENTS_COUNT * EMPLOYEES_PER_DEPT))"
+echo "  - プロジェクト数: ${PROJECTS_COUNT}"
+echo "  - 社員あたりのプロジェクト数: 1〜${PROJECTS_PER_EMPLOYEE}"
+echo "  - 商品数: ${PRODUCTS_COUNT}"
 echo "  - 受注数: ${ORDERS_COUNT}"
 echo "  - 明細数: $((ORDERS_COUNT * DETAILS_PER_ORDER))"
+echo "  - 受注備考(CLOB): 最大${NOTES_MAX_CHARS}文字"
+echo "  - 論理削除済みの受注: ${DELETED_PERCENT}%"
 echo "============================================"
 echo ""
 
@@ -115,6 +125,13 @@ DECLARE
         'サポート契約', '保守契約', '技術コンサルティング', 'クラウドサービス', 'データベースライセンス'
     );
 
+    -- 商品カテゴリリスト
+    TYPE category_array IS VARRAY(10) OF VARCHAR2(50);
+    categories category_array := category_array(
+        'PC', '周辺機器', 'ディスプレイ', 'オフィス機器', 'ストレージ',
+        'サーバー・ネットワーク', 'ケーブル・電源', '家具', 'ソフトウェア', 'サービス'
+    );
+
     -- 会社名リスト
     TYPE company_array IS VARRAY(50) OF VARCHAR2(100);
     companies company_array := company_array(
@@ -133,7 +150,23 @@ DECLARE
         '株式会社LMN情報', '有限会社OPQ商会'
     );
 
+    -- プロジェクト名リスト
+    TYPE project_array IS VARRAY(30) OF VARCHAR2(100);
+    project_names project_array := project_array(
+        '基幹システム刷新', '顧客ポータル開発', '採用プロセス改善', 'データ基盤構築', 'クラウド移行',
+        'モバイルアプリ開発', 'セキュリティ強化', 'BIダッシュボード', '在庫最適化', '物流網再編',
+        '新製品企画', 'ブランド刷新', '海外拠点立ち上げ', '社内Wiki整備', 'RPA導入',
+        'AIチャットボット', '会計システム更改', '人事評価制度改定', 'ECサイトリニューアル', '品質監査自動化',
+        'ネットワーク更改', 'DR環境構築', 'ペーパーレス推進', '研修プログラム開発', 'API基盤整備',
+        '需要予測モデル', 'カスタマーサクセス強化', 'ログ分析基盤', 'ゼロトラスト導入', 'サステナビリティ報告'
+    );
+
+    TYPE role_array IS VARRAY(4) OF VARCHAR2(20);
+    project_roles role_array := role_array('オーナー', 'リーダー', 'メンバー', 'アドバイザー');
+
     v_counter NUMBER := 0;
+    v_project_id NUMBER;
+    v_project_count NUMBER;
     v_dept_id NUMBER;
     v_emp_id NUMBER;
     v_order_id NUMBER;
@@ -145,6 +178,7 @@ DECLARE
     v_last_name VARCHAR2(50);
     v_company_name VARCHAR2(200);
     v_product_name VARCHAR2(200);
+    v_notes VARCHAR2(32767);
 
 BEGIN
     DBMS_OUTPUT.PUT_LINE('大量ダミーデータ生成開始...');
@@ -220,12 +254,111 @@ BEGIN
     
     DBMS_OUTPUT.PUT_LINE('社員データ生成完了: ' || v_counter || '件');
     v_counter := 0;
+
+    -- 3. プロジェクトデータ生成
+    DBMS_OUTPUT.PUT_LINE('プロジェクトデータ生成中...');
+    FOR i IN 1..30 LOOP
+        INSERT INTO projects (
+            project_id,
+            project_name,
+            budget,
+            created_at,
+            updated_at
+        ) VALUES (
+            seq_projects.NEXTVAL,
+            project_names(MOD(i-1, project_names.COUNT) + 1),
+            ROUND(DBMS_RANDOM.VALUE(1000000, 100000000), -5),
+            SYSDATE - DBMS_RANDOM.VALUE(0, 365),
+            SYSDATE
+        );
+        v_counter := v_counter + 1;
+    END LOOP;
+    COMMIT;
+
+    DBMS_OUTPUT.PUT_LINE('プロジェクトデータ生成完了: ' || v_counter || '件');
+    v_counter := 0;
+
+    -- 4. 社員・プロジェクト割り当て生成（社員ごとに1〜3件、重複なし）
+    DBMS_OUTPUT.PUT_LINE('プロジェクト割り当てデータ生成中...');
+    FOR emp IN (SELECT employee_id FROM employees) LOOP
+        v_project_count := ROUND(DBMS_RANDOM.VALUE(1, 3));
+        FOR j IN 1..v_project_count LOOP
+            -- 社員IDとjから決まる異なるプロジェクトを割り当てる
+            v_project_id := MOD(emp.employee_id * 7 + j * 11, 30) + 1;
+
+            INSERT INTO employee_projects (
+                employee_id,
+                project_id,
+                project_role,
+                assigned_at
+            ) VALUES (
+                emp.employee_id,
+                v_project_id,
+                project_roles(MOD(emp.employee_id + j, project_roles.COUNT) + 1),
+                SYSDATE - DBMS_RANDOM.VALUE(0, 365)
+            );
+
+            v_counter := v_counter + 1;
+        END LOOP;
+
+        IF MOD(v_counter, 500) = 0 THEN
+            COMMIT;
+        END IF;
+    END LOOP;
+    COMMIT;
+
+    DBMS_OUTPUT.PUT_LINE('プロジェクト割り当てデータ生成完了: ' || v_counter || '件');
+    v_counter := 0;
     
-    -- 3. 受注データ生成
+    -- 5. 商品マスターデータ生成（初期データ投入済みの商品IDはスキップ）
+    DBMS_OUTPUT.PUT_LINE('商品データ生成中...');
+    FOR i IN 1..100 LOOP
+        v_product_name := products(MOD(i-1, products.COUNT) + 1);
+        IF i > products.COUNT THEN
+            v_product_name := v_product_name || ' 後継モデル';
+        END IF;
+
+        INSERT INTO products (
+            product_id,
+            product_name,
+            category,
+            list_price,
+            created_at,
+            updated_at
+        )
+        SELECT
+            2000 + i,
+            v_product_name,
+            categories(MOD(i-1, categories.COUNT) + 1),
+            ROUND(DBMS_RANDOM.VALUE(1000, 100000), -2),
+            SYSDATE - DBMS_RANDOM.VALUE(0, 365),
+            SYSDATE
+        FROM dual
+        WHERE NOT EXISTS (SELECT 1 FROM products WHERE product_id = 2000 + i);
+
+        v_counter := v_counter + SQL%ROWCOUNT;
+    END LOOP;
+    COMMIT;
+
+    DBMS_OUTPUT.PUT_LINE('商品データ生成完了: ' || v_counter || '件');
+    v_counter := 0;
+
+    -- 6. 受注データ生成
     DBMS_OUTPUT.PUT_LINE('受注データ生成中...');
     FOR i IN 1..1000 LOOP
         v_customer_id := ROUND(DBMS_RANDOM.VALUE(1001, 1050));
         v_company_name := companies(MOD(v_customer_id - 1001, companies.COUNT) + 1);
+
+        -- 受注備考（幅の広いLOB）: 3件に1件はNULL、それ以外は2,000〜16,000文字
+        IF MOD(i, 3) = 0 THEN
+            v_notes := NULL;
+        ELSE
+            v_notes := RPAD(
+                'Order note #' || i || ': ',
+                ROUND(DBMS_RANDOM.VALUE(2000, 16000)),
+                'Delivery and acceptance conditions. '
+            );
+        END IF;
         
         INSERT INTO orders (
             order_id,
@@ -234,6 +367,8 @@ BEGIN
             order_date,
             total_amount,
             status,
+            notes,
+            deleted_flag,
             created_at,
             updated_at
         ) VALUES (
@@ -248,6 +383,9 @@ BEGIN
                 WHEN 2 THEN 'PROCESSING'
                 ELSE 'CANCELLED'
             END,
+            TO_CLOB(v_notes),
+            -- 論理削除: 5件に4件は削除済み（生きている受注が少ない表で索引の効き方を比べる）
+            CASE WHEN MOD(i, 5) = 0 THEN 'N' ELSE 'Y' END,
             SYSDATE - DBMS_RANDOM.VALUE(0, 30),
             SYSDATE
         );
@@ -262,12 +400,16 @@ BEGIN
     DBMS_OUTPUT.PUT_LINE('受注データ生成完了: ' || v_counter || '件');
     v_counter := 0;
     
-    -- 4. 受注明細データ生成
+    -- 7. 受注明細データ生成
     DBMS_OUTPUT.PUT_LINE('受注明細データ生成中...');
     FOR ord IN (SELECT order_id FROM orders WHERE order_id > 5) LOOP
         -- 各受注に3-7個の明細を追加
         FOR i IN 1..ROUND(DBMS_RANDOM.VALUE(3, 7)) LOOP
-            v_product_name := products(MOD(v_counter, products.COUNT) + 1);
+            -- 商品IDと商品名（受注時点のスナップショット）を商品マスターと揃える
+            v_product_name := products(MOD(MOD(v_counter, 100), products.COUNT) + 1);
+            IF MOD(v_counter, 100) + 1 > products.COUNT THEN
+                v_product_name := v_product_name || ' 後継モデル';
+            END IF;
             
             INSERT INTO order_details (
                 detail_id,
@@ -305,8 +447,15 @@ BEGIN
     DBMS_OUTPUT.PUT_LINE('統計情報更新中...');
     DBMS_STATS.GATHER_TABLE_STATS(USER, 'DEPARTMENTS');
     DBMS_STATS.GATHER_TABLE_STATS(USER, 'EMPLOYEES');
+    DBMS_STATS.GATHER_TABLE_STATS(USER, 'PROJECTS');
+    DBMS_STATS.GATHER_TABLE_STATS(USER, 'EMPLOYEE_PROJECTS');
+    DBMS_STATS.GATHER_TABLE_STATS(USER, 'PRODUCTS');
     DBMS_STATS.GATHER_TABLE_STATS(USER, 'ORDERS');
     DBMS_STATS.GATHER_TABLE_STATS(USER, 'ORDER_DETAILS');
+
+    -- 月次売上マテリアライズドビュー更新
+    DBMS_OUTPUT.PUT_LINE('マテリアライズドビュー更新中...');
+    DBMS_MVIEW.REFRESH('MV_MONTHLY_CUSTOMER_SALES', 'C');
     
     DBMS_OUTPUT.PUT_LINE('大量ダミーデータ生成完了！');
     
@@ -317,6 +466,12 @@ BEGIN
         UNION ALL
         SELECT 'EMPLOYEES', COUNT(*) FROM employees
         UNION ALL
+        SELECT 'PROJECTS', COUNT(*) FROM projects
+        UNION ALL
+        SELECT 'EMPLOYEE_PROJECTS', COUNT(*) FROM employee_projects
+        UNION ALL
+        SELECT 'PRODUCTS', COUNT(*) FROM products
+        UNION ALL
         SELECT 'ORDERS', COUNT(*) FROM orders
         UNION ALL
         SELECT 'ORDER_DETAILS', COUNT(*) FROM order_details